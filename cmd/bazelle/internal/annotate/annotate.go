@@ -0,0 +1,79 @@
+// Package annotate marks the imports a run left unresolved in BUILD files.
+//
+// An import no target provides is dropped from deps, so nothing in the
+// generated BUILD file shows the gap. Unresolved writes it down instead, as
+// a comment above the rule that imports it:
+//
+//	# bazelle: unresolved requests
+//	py_library(
+//	    name = "app",
+//	    srcs = ["app.py"],
+//	)
+package annotate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	bzl "github.com/bazelbuild/buildtools/build"
+)
+
+// Prefix starts every comment Unresolved writes, followed by the import.
+const Prefix = "# bazelle: unresolved "
+
+// Unresolved returns the BUILD file data with a comment above each named
+// rule for each of its unresolved imports, given keyed by rule name. The
+// comments an earlier call wrote are replaced, so a rule whose imports all
+// resolve now loses them. It returns data itself, not reformatted, if the
+// comments are already up to date. path is only used in error messages.
+func Unresolved(path string, data []byte, imports map[string][]string) ([]byte, error) {
+	f, err := bzl.ParseBuild(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	changed := false
+	for _, stmt := range f.Stmt {
+		call, ok := stmt.(*bzl.CallExpr)
+		if !ok {
+			continue
+		}
+		comments := call.Comment()
+		before := slices.DeleteFunc(slices.Clone(comments.Before), func(c bzl.Comment) bool {
+			return strings.HasPrefix(c.Token, Prefix)
+		})
+		if name, ok := ruleName(call); ok {
+			sorted := slices.Clone(imports[name])
+			slices.Sort(sorted)
+			for _, imp := range slices.Compact(sorted) {
+				before = append(before, bzl.Comment{Token: Prefix + imp})
+			}
+		}
+		if !slices.EqualFunc(before, comments.Before, func(a, b bzl.Comment) bool { return a.Token == b.Token }) {
+			comments.Before = before
+			changed = true
+		}
+	}
+
+	if !changed {
+		return data, nil
+	}
+	return bzl.Format(f), nil
+}
+
+// ruleName returns the value of the name attribute of call.
+func ruleName(call *bzl.CallExpr) (string, bool) {
+	for _, arg := range call.List {
+		assign, ok := arg.(*bzl.AssignExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := assign.LHS.(*bzl.Ident); ok && key.Name == "name" {
+			if s, ok := assign.RHS.(*bzl.StringExpr); ok {
+				return s.Value, true
+			}
+		}
+	}
+	return "", false
+}
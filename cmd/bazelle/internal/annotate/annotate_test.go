@@ -0,0 +1,99 @@
+package annotate
+
+import "testing"
+
+func TestUnresolved(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		imports map[string][]string
+		want    string
+	}{
+		{
+			name: "adds comments above the rule",
+			data: `py_library(
+    name = "app",
+    srcs = ["app.py"],
+)
+
+py_test(
+    name = "app_test",
+    srcs = ["app_test.py"],
+)
+`,
+			imports: map[string][]string{"app": {"yaml", "requests", "yaml"}},
+			want: `# bazelle: unresolved requests
+# bazelle: unresolved yaml
+py_library(
+    name = "app",
+    srcs = ["app.py"],
+)
+
+py_test(
+    name = "app_test",
+    srcs = ["app_test.py"],
+)
+`,
+		},
+		{
+			name: "replaces earlier comments and keeps others",
+			data: `# keep
+# bazelle: unresolved yaml
+py_library(
+    name = "app",
+    srcs = ["app.py"],
+)
+
+# bazelle: unresolved attr
+py_test(
+    name = "app_test",
+    srcs = ["app_test.py"],
+)
+`,
+			imports: map[string][]string{"app": {"requests"}},
+			want: `# keep
+# bazelle: unresolved requests
+py_library(
+    name = "app",
+    srcs = ["app.py"],
+)
+
+py_test(
+    name = "app_test",
+    srcs = ["app_test.py"],
+)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unresolved("app/BUILD.bazel", []byte(tt.data), tt.imports)
+			if err != nil {
+				t.Fatalf("Unresolved() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Unresolved() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnresolved_UpToDate(t *testing.T) {
+	// Not formatted the way buildifier would, so a rewrite would show
+	data := []byte("# bazelle: unresolved yaml\npy_library(name = \"app\", srcs = [\"app.py\"])\n")
+
+	got, err := Unresolved("BUILD", data, map[string][]string{"app": {"yaml"}})
+	if err != nil {
+		t.Fatalf("Unresolved() error = %v", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("Unresolved() rewrote an up to date file:\n%s", got)
+	}
+}
+
+func TestUnresolved_ParseError(t *testing.T) {
+	if _, err := Unresolved("BUILD", []byte("py_library(\n"), nil); err == nil {
+		t.Error("Unresolved() succeeded on an invalid BUILD file")
+	}
+}
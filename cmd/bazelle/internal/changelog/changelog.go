@@ -0,0 +1,305 @@
+// Package changelog summarizes what a BUILD file update changed.
+//
+// # Overview
+//
+// Gazelle rewrites BUILD files in place, which makes a large update hard to
+// review. This package captures the BUILD files of a workspace before and
+// after an update and reports, per package and target, which deps and srcs
+// were added or removed.
+//
+// # Usage
+//
+//	before, _ := changelog.TakeSnapshot(root)
+//	// ... run gazelle ...
+//	after, _ := changelog.TakeSnapshot(root)
+//	summary, _ := changelog.Compare(before, after)
+//	fmt.Print(summary.Format())
+//
+// # Determinism
+//
+// Summaries are DETERMINISTIC: packages, targets, and attribute values are
+// always reported in sorted order.
+package changelog
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+// buildFileNames lists recognized BUILD file names in order of preference.
+var buildFileNames = []string{"BUILD.bazel", "BUILD"}
+
+// Snapshot holds the BUILD file contents of a workspace at a point in time.
+// Keys are package paths relative to the workspace root ("" for the root).
+type Snapshot map[string][]byte
+
+// TakeSnapshot reads every BUILD file under root.
+//
+// Directories matching langs.IgnoredDirs or listed in .bazelignore are
+// skipped. If a directory has both BUILD.bazel and BUILD, BUILD.bazel is used.
+func TakeSnapshot(root string) (Snapshot, error) {
+	snap := make(Snapshot)
+	ignorer := langs.NewIgnorer(root)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if ignorer.SkipDir(path) {
+			return filepath.SkipDir
+		}
+
+		for _, name := range buildFileNames {
+			data, err := os.ReadFile(filepath.Join(path, name))
+			if err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			if rel == "." {
+				rel = ""
+			}
+			snap[filepath.ToSlash(rel)] = data
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("snapshot BUILD files: %w", err)
+	}
+
+	return snap, nil
+}
+
+// Summary reports the changes made to BUILD files between two snapshots.
+type Summary struct {
+	// Packages lists changed packages, sorted by path.
+	Packages []PackageChange `json:"packages"`
+}
+
+// PackageChange reports the changed targets in a single package.
+type PackageChange struct {
+	// Package is the Bazel package label (e.g., "//src/app").
+	Package string `json:"package"`
+
+	// Targets lists changed targets, sorted by name.
+	Targets []TargetChange `json:"targets"`
+}
+
+// TargetChange reports the deps and srcs changes of a single target.
+type TargetChange struct {
+	// Name is the target name.
+	Name string `json:"name"`
+
+	// Kind is the rule kind (e.g., "kt_jvm_library").
+	Kind string `json:"kind"`
+
+	// Status is "added", "removed", or "modified".
+	Status string `json:"status"`
+
+	AddedDeps   []string `json:"added_deps,omitempty"`
+	RemovedDeps []string `json:"removed_deps,omitempty"`
+	AddedSrcs   []string `json:"added_srcs,omitempty"`
+	RemovedSrcs []string `json:"removed_srcs,omitempty"`
+}
+
+// Target status values.
+const (
+	StatusAdded    = "added"
+	StatusRemoved  = "removed"
+	StatusModified = "modified"
+)
+
+// IsEmpty returns true if no targets changed.
+func (s *Summary) IsEmpty() bool {
+	return s == nil || len(s.Packages) == 0
+}
+
+// Compare computes the per-target changes between two snapshots.
+//
+// Packages whose BUILD file content is byte-identical are skipped without
+// parsing. Returns an error if a changed BUILD file cannot be parsed.
+func Compare(before, after Snapshot) (*Summary, error) {
+	pkgs := make(map[string]bool, len(after))
+	for pkg := range before {
+		pkgs[pkg] = true
+	}
+	for pkg := range after {
+		pkgs[pkg] = true
+	}
+
+	summary := &Summary{Packages: []PackageChange{}}
+	for _, pkg := range slices.Sorted(maps.Keys(pkgs)) {
+		if slices.Equal(before[pkg], after[pkg]) {
+			continue
+		}
+
+		oldRules, err := loadRules(pkg, before[pkg])
+		if err != nil {
+			return nil, err
+		}
+		newRules, err := loadRules(pkg, after[pkg])
+		if err != nil {
+			return nil, err
+		}
+
+		if targets := compareRules(oldRules, newRules); len(targets) > 0 {
+			summary.Packages = append(summary.Packages, PackageChange{
+				Package: "//" + pkg,
+				Targets: targets,
+			})
+		}
+	}
+
+	return summary, nil
+}
+
+// loadRules parses BUILD file content into rules keyed by name.
+// A nil content (missing file) yields no rules.
+func loadRules(pkg string, data []byte) (map[string]*rule.Rule, error) {
+	rules := make(map[string]*rule.Rule)
+	if data == nil {
+		return rules, nil
+	}
+
+	path := filepath.Join(pkg, "BUILD.bazel")
+	f, err := rule.LoadData(path, pkg, data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for _, r := range f.Rules {
+		if name := r.Name(); name != "" {
+			rules[name] = r
+		}
+	}
+	return rules, nil
+}
+
+// compareRules computes target changes between two rule sets.
+func compareRules(oldRules, newRules map[string]*rule.Rule) []TargetChange {
+	names := make(map[string]bool, len(newRules))
+	for name := range oldRules {
+		names[name] = true
+	}
+	for name := range newRules {
+		names[name] = true
+	}
+
+	var changes []TargetChange
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		oldRule, newRule := oldRules[name], newRules[name]
+
+		tc := TargetChange{Name: name}
+		switch {
+		case oldRule == nil:
+			tc.Kind = newRule.Kind()
+			tc.Status = StatusAdded
+		case newRule == nil:
+			tc.Kind = oldRule.Kind()
+			tc.Status = StatusRemoved
+		default:
+			tc.Kind = newRule.Kind()
+			tc.Status = StatusModified
+		}
+
+		oldDeps, newDeps := attrValues(oldRule, "deps"), attrValues(newRule, "deps")
+		tc.AddedDeps = difference(newDeps, oldDeps)
+		tc.RemovedDeps = difference(oldDeps, newDeps)
+
+		oldSrcs, newSrcs := attrValues(oldRule, "srcs"), attrValues(newRule, "srcs")
+		tc.AddedSrcs = difference(newSrcs, oldSrcs)
+		tc.RemovedSrcs = difference(oldSrcs, newSrcs)
+
+		if tc.Status == StatusModified && !tc.hasAttrChanges() {
+			continue
+		}
+		changes = append(changes, tc)
+	}
+	return changes
+}
+
+// hasAttrChanges returns true if any deps or srcs changed.
+func (tc TargetChange) hasAttrChanges() bool {
+	return len(tc.AddedDeps) > 0 || len(tc.RemovedDeps) > 0 ||
+		len(tc.AddedSrcs) > 0 || len(tc.RemovedSrcs) > 0
+}
+
+// attrValues returns the string values of a list attribute.
+//
+// Glob expressions are reported as "glob:<pattern>" entries so that pattern
+// changes still show up in the summary.
+func attrValues(r *rule.Rule, key string) []string {
+	if r == nil {
+		return nil
+	}
+	if values := r.AttrStrings(key); values != nil {
+		return values
+	}
+	if expr := r.Attr(key); expr != nil {
+		if glob, ok := rule.ParseGlobExpr(expr); ok {
+			values := make([]string, 0, len(glob.Patterns))
+			for _, p := range glob.Patterns {
+				values = append(values, "glob:"+p)
+			}
+			return values
+		}
+	}
+	return nil
+}
+
+// difference returns elements in a but not in b, sorted.
+func difference(a, b []string) []string {
+	set := make(map[string]bool, len(b))
+	for _, s := range b {
+		set[s] = true
+	}
+	var diff []string
+	for _, s := range a {
+		if !set[s] {
+			diff = append(diff, s)
+			set[s] = true
+		}
+	}
+	slices.Sort(diff)
+	return diff
+}
+
+// Format renders the summary as human-readable text.
+func (s *Summary) Format() string {
+	if s.IsEmpty() {
+		return "No BUILD file changes\n"
+	}
+
+	var b strings.Builder
+	for _, pkg := range s.Packages {
+		fmt.Fprintf(&b, "%s\n", pkg.Package)
+		for _, t := range pkg.Targets {
+			fmt.Fprintf(&b, "  %s %s (%s)\n", t.Kind, t.Name, t.Status)
+			writeValues(&b, "+ dep", t.AddedDeps)
+			writeValues(&b, "- dep", t.RemovedDeps)
+			writeValues(&b, "+ src", t.AddedSrcs)
+			writeValues(&b, "- src", t.RemovedSrcs)
+		}
+	}
+	return b.String()
+}
+
+// writeValues writes one line per value with the given prefix.
+func writeValues(b *strings.Builder, prefix string, values []string) {
+	for _, v := range values {
+		fmt.Fprintf(b, "    %s %s\n", prefix, v)
+	}
+}
@@ -0,0 +1,157 @@
+package changelog
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const buildBefore = `load("@rules_kotlin//kotlin:jvm.bzl", "kt_jvm_library")
+
+kt_jvm_library(
+    name = "app",
+    srcs = ["App.kt"],
+    deps = ["//lib/core"],
+)
+`
+
+const buildAfter = `load("@rules_kotlin//kotlin:jvm.bzl", "kt_jvm_library")
+
+kt_jvm_library(
+    name = "app",
+    srcs = ["App.kt"],
+    deps = [
+        "//lib/core",
+        "//lib/util",
+    ],
+)
+`
+
+func writeBuild(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "BUILD.bazel"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareDepAdded(t *testing.T) {
+	root := t.TempDir()
+	pkgDir := filepath.Join(root, "src", "app")
+	writeBuild(t, pkgDir, buildBefore)
+	writeBuild(t, filepath.Join(root, "lib", "core"), "")
+
+	before, err := TakeSnapshot(root)
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error = %v", err)
+	}
+
+	writeBuild(t, pkgDir, buildAfter)
+
+	after, err := TakeSnapshot(root)
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error = %v", err)
+	}
+
+	summary, err := Compare(before, after)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	if len(summary.Packages) != 1 {
+		t.Fatalf("expected 1 changed package, got %+v", summary.Packages)
+	}
+	pkg := summary.Packages[0]
+	if pkg.Package != "//src/app" {
+		t.Errorf("Package = %q, want %q", pkg.Package, "//src/app")
+	}
+	if len(pkg.Targets) != 1 {
+		t.Fatalf("expected 1 changed target, got %+v", pkg.Targets)
+	}
+
+	tc := pkg.Targets[0]
+	if tc.Name != "app" || tc.Kind != "kt_jvm_library" || tc.Status != StatusModified {
+		t.Errorf("unexpected target change %+v", tc)
+	}
+	if !slices.Equal(tc.AddedDeps, []string{"//lib/util"}) {
+		t.Errorf("AddedDeps = %v, want [//lib/util]", tc.AddedDeps)
+	}
+	if len(tc.RemovedDeps) != 0 || len(tc.AddedSrcs) != 0 || len(tc.RemovedSrcs) != 0 {
+		t.Errorf("unexpected changes %+v", tc)
+	}
+
+	text := summary.Format()
+	if !strings.Contains(text, "+ dep //lib/util") {
+		t.Errorf("Format() missing added dep:\n%s", text)
+	}
+}
+
+func TestCompareTargetAddedAndRemoved(t *testing.T) {
+	before := Snapshot{
+		"": []byte(`java_library(name = "old", srcs = ["Old.java"])`),
+	}
+	after := Snapshot{
+		"": []byte(`java_library(name = "new", srcs = glob(["*.java"]))`),
+	}
+
+	summary, err := Compare(before, after)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(summary.Packages) != 1 || summary.Packages[0].Package != "//" {
+		t.Fatalf("unexpected packages %+v", summary.Packages)
+	}
+
+	targets := summary.Packages[0].Targets
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 target changes, got %+v", targets)
+	}
+	if targets[0].Name != "new" || targets[0].Status != StatusAdded {
+		t.Errorf("targets[0] = %+v, want added 'new'", targets[0])
+	}
+	if !slices.Equal(targets[0].AddedSrcs, []string{"glob:*.java"}) {
+		t.Errorf("AddedSrcs = %v, want [glob:*.java]", targets[0].AddedSrcs)
+	}
+	if targets[1].Name != "old" || targets[1].Status != StatusRemoved {
+		t.Errorf("targets[1] = %+v, want removed 'old'", targets[1])
+	}
+	if !slices.Equal(targets[1].RemovedSrcs, []string{"Old.java"}) {
+		t.Errorf("RemovedSrcs = %v, want [Old.java]", targets[1].RemovedSrcs)
+	}
+}
+
+func TestCompareUnchanged(t *testing.T) {
+	snap := Snapshot{"src": []byte(buildBefore)}
+
+	summary, err := Compare(snap, snap)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if !summary.IsEmpty() {
+		t.Errorf("expected empty summary, got %+v", summary.Packages)
+	}
+	if got := summary.Format(); got != "No BUILD file changes\n" {
+		t.Errorf("Format() = %q", got)
+	}
+}
+
+func TestTakeSnapshotSkipsIgnoredDirs(t *testing.T) {
+	root := t.TempDir()
+	writeBuild(t, filepath.Join(root, "src"), buildBefore)
+	writeBuild(t, filepath.Join(root, "bazel-out", "pkg"), buildBefore)
+
+	snap, err := TakeSnapshot(root)
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error = %v", err)
+	}
+	if _, ok := snap["src"]; !ok {
+		t.Error("expected src to be captured")
+	}
+	if _, ok := snap["bazel-out/pkg"]; ok {
+		t.Error("expected bazel-out to be skipped")
+	}
+}
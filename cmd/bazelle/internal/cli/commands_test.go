@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/daemon"
 	"github.com/bazelbuild/bazel-gazelle/language"
 	"github.com/spf13/cobra"
 )
@@ -59,6 +63,54 @@ func TestUpdateCmd_FlagDefaults(t *testing.T) {
 			wantDefault:  "false",
 			wantShortcut: "",
 		},
+		{
+			name:         "summary flag defaults to false",
+			flagName:     "summary",
+			wantDefault:  "false",
+			wantShortcut: "",
+		},
+		{
+			name:         "json flag defaults to false",
+			flagName:     "json",
+			wantDefault:  "false",
+			wantShortcut: "",
+		},
+		{
+			name:         "quiet flag defaults to false",
+			flagName:     "quiet",
+			wantDefault:  "false",
+			wantShortcut: "",
+		},
+		{
+			name:         "no-color flag defaults to false",
+			flagName:     "no-color",
+			wantDefault:  "false",
+			wantShortcut: "",
+		},
+		{
+			name:         "strict-resolve flag defaults to false",
+			flagName:     "strict-resolve",
+			wantDefault:  "false",
+			wantShortcut: "",
+		},
+		{
+			name:         "format flag defaults to text",
+			flagName:     "format",
+			wantDefault:  "text",
+			wantShortcut: "",
+		},
+		{
+			name:         "resolve-only flag defaults to empty",
+			flagName:     "resolve-only",
+			wantDefault:  "",
+			wantShortcut: "",
+		},
+		{
+			name:         "report flag defaults to empty",
+			flagName:     "report",
+			wantDefault:  "",
+			wantShortcut: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -321,6 +373,18 @@ func TestStatusCmd_FlagDefaults(t *testing.T) {
 			wantDefault:  "false",
 			wantShortcut: "",
 		},
+		{
+			name:         "force flag defaults to false",
+			flagName:     "force",
+			wantDefault:  "false",
+			wantShortcut: "",
+		},
+		{
+			name:         "format flag defaults to text",
+			flagName:     "format",
+			wantDefault:  "text",
+			wantShortcut: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -507,6 +571,18 @@ func TestWatchCmd_FlagDefaults(t *testing.T) {
 			wantDefault:  "false",
 			wantShortcut: "",
 		},
+		{
+			name:         "on-update flag defaults to empty",
+			flagName:     "on-update",
+			wantDefault:  "",
+			wantShortcut: "",
+		},
+		{
+			name:         "replay-on-start flag defaults to false",
+			flagName:     "replay-on-start",
+			wantDefault:  "false",
+			wantShortcut: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -620,6 +696,18 @@ func TestRootCmd_GlobalFlags(t *testing.T) {
 			wantDefault:  "text",
 			wantShortcut: "",
 		},
+		{
+			name:         "jobs flag defaults to 0",
+			flagName:     "jobs",
+			wantDefault:  "0",
+			wantShortcut: "j",
+		},
+		{
+			name:         "parse-timeout flag defaults to 0s",
+			flagName:     "parse-timeout",
+			wantDefault:  "0s",
+			wantShortcut: "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -816,6 +904,38 @@ func TestSetLanguages_Nil(t *testing.T) {
 	languages = originalLangs
 }
 
+func TestDiffBuildFiles(t *testing.T) {
+	const date = "\t1970-01-01 00:00:00.000000001 +0000"
+	diff := "--- app/BUILD.bazel" + date + "\n" +
+		"+++ app/BUILD.bazel" + date + "\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		"-    srcs = [\"Old.kt\"],\n" +
+		"+    srcs = [\"App.kt\"],\n" +
+		"--- /dev/null" + date + "\n" +
+		"+++ lib/BUILD.bazel" + date + "\n" +
+		"@@ -0,0 +1,2 @@\n" +
+		"+kt_jvm_library(name = \"lib\")\n"
+
+	got := diffBuildFiles([]byte(diff))
+	if want := []string{"app/BUILD.bazel", "lib/BUILD.bazel"}; !slices.Equal(got, want) {
+		t.Errorf("diffBuildFiles() = %v, want %v", got, want)
+	}
+	if got := diffBuildFiles(nil); len(got) != 0 {
+		t.Errorf("diffBuildFiles(nil) = %v, want none", got)
+	}
+}
+
+func TestRunUpdate_FormatRequiresCheck(t *testing.T) {
+	prev := updateFlags.format
+	updateFlags.format = "sarif"
+	t.Cleanup(func() { updateFlags.format = prev })
+
+	err := runUpdate(updateCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "requires --check") {
+		t.Errorf("runUpdate() error = %v, want --format=sarif to require --check", err)
+	}
+}
+
 // ============================================================================
 // outputJSON Tests
 // ============================================================================
@@ -1037,7 +1157,7 @@ func TestWatchCmd_LongDescription(t *testing.T) {
 // ============================================================================
 
 func TestCommands_HaveRunE(t *testing.T) {
-	commands := []string{"update", "fix", "watch", "status", "gazelle"}
+	commands := []string{"update", "fix", "watch", "status", "gazelle", "validate-resolve", "graph", "dump-ast"}
 
 	for _, cmdName := range commands {
 		t.Run(cmdName, func(t *testing.T) {
@@ -1071,6 +1191,11 @@ func TestUpdateCmd_FlagUsage(t *testing.T) {
 		{"verbose", "Show detailed output"},
 		{"incremental", "Only update directories with changed source files"},
 		{"force", "Force full update, ignoring cached state"},
+		{"summary", "Print a per-package summary of added/removed deps and srcs"},
+		{"quiet", "Suppress progress output"},
+		{"format", "Report format for --check"},
+		{"resolve-only", "Only generate and resolve rules for this language"},
+		{"report", "Write a JSON report of the run's diagnostics"},
 	}
 
 	for _, tt := range tests {
@@ -1158,6 +1283,8 @@ func TestStatusCmd_FlagUsage(t *testing.T) {
 	}{
 		{"verbose", "Show individual file changes"},
 		{"json", "Output as JSON"},
+		{"force", "Re-hash every file"},
+		{"format", "Output format"},
 	}
 
 	for _, tt := range tests {
@@ -1174,6 +1301,175 @@ func TestStatusCmd_FlagUsage(t *testing.T) {
 	}
 }
 
+// ============================================================================
+// Daemon Index Command Tests
+// ============================================================================
+
+func TestGraphCmd_FlagDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		defValue string
+	}{
+		{"language", "kotlin"},
+		{"format", "dot"},
+		{"exclude-test-imports", "false"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := graphCmd.Flags().Lookup(tt.name)
+			if f == nil {
+				t.Fatalf("flag %q not found", tt.name)
+			}
+			if f.DefValue != tt.defValue {
+				t.Errorf("flag %q default = %q, want %q", tt.name, f.DefValue, tt.defValue)
+			}
+		})
+	}
+}
+
+func TestWorkspaceRel(t *testing.T) {
+	wd := t.TempDir()
+	t.Chdir(wd)
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{".", "", false},
+		{"src/app", "src/app", false},
+		{filepath.Join(wd, "lib"), "lib", false},
+		{"..", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := workspaceRel(wd, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("workspaceRel(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("workspaceRel(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDaemonConfigCmd_Flags(t *testing.T) {
+	for _, name := range []string{"json", "socket"} {
+		if daemonConfigCmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag %q not found on daemon config command", name)
+		}
+	}
+}
+
+func TestWriteDaemonConfigText(t *testing.T) {
+	var buf bytes.Buffer
+	writeDaemonConfigText(&buf, &daemon.GetConfigResult{
+		Version:       "1.2.3",
+		PID:           42,
+		Dir:           "/ws/.bazelle",
+		Socket:        "/ws/.bazelle/daemon.sock",
+		PIDFile:       "/ws/.bazelle/daemon.pid",
+		LogFile:       "/ws/.bazelle/daemon.log",
+		Languages:     []string{"kotlin", "python"},
+		Debounce:      500,
+		Backends:      []string{"cgo"},
+		TreeCacheSize: 512,
+		WarmIndex:     true,
+		Watching:      true,
+		WatchPaths:    []string{"/ws"},
+	})
+
+	want := `Version:          1.2.3
+PID:              42
+Directory:        /ws/.bazelle
+Socket:           /ws/.bazelle/daemon.sock
+PID file:         /ws/.bazelle/daemon.pid
+Log file:         /ws/.bazelle/daemon.log
+Languages:        kotlin, python
+Gazelle defaults: (none)
+Debounce:         500ms
+Backends:         cgo
+Tree cache size:  512
+Warm index:       yes
+Watching:         /ws
+`
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestDaemonStartCmd_Flags(t *testing.T) {
+	for _, name := range []string{"foreground", "socket", "log", "warm-index"} {
+		if daemonStartCmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag %q not found on daemon start command", name)
+		}
+	}
+}
+
+func TestDaemonIndexCmd_Flags(t *testing.T) {
+	for _, name := range []string{"json", "socket"} {
+		if daemonIndexCmd.Flags().Lookup(name) == nil {
+			t.Errorf("flag %q not found on daemon index command", name)
+		}
+	}
+}
+
+func TestWriteDaemonIndexText(t *testing.T) {
+	var buf bytes.Buffer
+	writeDaemonIndexText(&buf, &daemon.DumpIndexResult{
+		Status: "ok",
+		Roots:  []string{"/ws"},
+		Files: map[string]string{
+			"src/b.go": "//src",
+			"a.go":     "//",
+		},
+	})
+
+	want := `Roots:
+  - /ws
+Files (2):
+  a.go -> //
+  src/b.go -> //src
+`
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	writeDaemonIndexText(&buf, &daemon.DumpIndexResult{Status: "not_watching"})
+	if !contains(buf.String(), "not watching") {
+		t.Errorf("expected not-watching message, got %q", buf.String())
+	}
+}
+
+func TestWriteDaemonIndexText_Symbols(t *testing.T) {
+	var buf bytes.Buffer
+	writeDaemonIndexText(&buf, &daemon.DumpIndexResult{
+		Status: "ok",
+		Roots:  []string{"/ws"},
+		Files:  map[string]string{"lib/Util.kt": "//lib"},
+		Symbols: map[string][]string{
+			"com.example.util": {"//lib", "//lib/extra"},
+			"com.example.app":  {"//app"},
+		},
+	})
+
+	want := `Roots:
+  - /ws
+Files (1):
+  lib/Util.kt -> //lib
+Symbols (2):
+  com.example.app -> //app
+  com.example.util -> //lib, //lib/extra
+`
+	if buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
 // ============================================================================
 // Helper Functions
 // ============================================================================
@@ -19,6 +19,8 @@ Commands:
   stop    - Stop the running daemon
   status  - Show daemon status
   restart - Restart the daemon
+  index   - Print the file-to-package index (debugging)
+  config  - Print the daemon's effective configuration
 
 Examples:
   bazelle daemon start              # Start daemon in background
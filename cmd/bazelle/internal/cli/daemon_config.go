@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var daemonConfigFlags struct {
+	jsonOutput bool
+	socket     string
+}
+
+var daemonConfigCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Print the daemon's effective configuration",
+	Long: `Print the configuration the running daemon is using.
+
+Shows the daemon version and PID, its socket, PID and log file paths,
+the languages and gazelle defaults it runs with, the watcher debounce,
+the available tree-sitter backends and the parse tree cache size. Use
+it to check that flags and environment variables took effect.
+
+Examples:
+  bazelle daemon config        # Show config as text
+  bazelle daemon config --json # Show config as JSON`,
+	RunE: runDaemonConfig,
+}
+
+func init() {
+	daemonConfigCmd.Flags().BoolVar(&daemonConfigFlags.jsonOutput, "json", false,
+		"Output as JSON")
+	daemonConfigCmd.Flags().StringVar(&daemonConfigFlags.socket, "socket", "",
+		"Custom socket path")
+
+	daemonCmd.AddCommand(daemonConfigCmd)
+}
+
+func runDaemonConfig(cmd *cobra.Command, args []string) error {
+	paths, err := getConfigDaemonPaths()
+	if err != nil {
+		return err
+	}
+
+	client, err := daemon.Connect(paths.Socket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	result, err := client.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get config: %w", err)
+	}
+
+	if daemonConfigFlags.jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	writeDaemonConfigText(os.Stdout, result)
+	return nil
+}
+
+// writeDaemonConfigText writes the config as human-readable text, one
+// setting per line.
+func writeDaemonConfigText(w io.Writer, cfg *daemon.GetConfigResult) {
+	list := func(values []string) string {
+		if len(values) == 0 {
+			return "(none)"
+		}
+		return strings.Join(values, ", ")
+	}
+
+	_, _ = fmt.Fprintf(w, "Version:          %s\n", cfg.Version)
+	_, _ = fmt.Fprintf(w, "PID:              %d\n", cfg.PID)
+	_, _ = fmt.Fprintf(w, "Directory:        %s\n", cfg.Dir)
+	_, _ = fmt.Fprintf(w, "Socket:           %s\n", cfg.Socket)
+	_, _ = fmt.Fprintf(w, "PID file:         %s\n", cfg.PIDFile)
+	_, _ = fmt.Fprintf(w, "Log file:         %s\n", cfg.LogFile)
+	_, _ = fmt.Fprintf(w, "Languages:        %s\n", list(cfg.Languages))
+	_, _ = fmt.Fprintf(w, "Gazelle defaults: %s\n", list(cfg.GazelleDefaults))
+	_, _ = fmt.Fprintf(w, "Debounce:         %dms\n", cfg.Debounce)
+	_, _ = fmt.Fprintf(w, "Backends:         %s\n", list(cfg.Backends))
+	_, _ = fmt.Fprintf(w, "Tree cache size:  %d\n", cfg.TreeCacheSize)
+	if cfg.WarmIndex {
+		_, _ = fmt.Fprintln(w, "Warm index:       yes")
+	} else {
+		_, _ = fmt.Fprintln(w, "Warm index:       no")
+	}
+	if cfg.Watching {
+		_, _ = fmt.Fprintf(w, "Watching:         %s\n", list(cfg.WatchPaths))
+	} else {
+		_, _ = fmt.Fprintln(w, "Watching:         no")
+	}
+}
+
+// getConfigDaemonPaths returns the daemon paths based on flags or defaults.
+func getConfigDaemonPaths() (*daemon.Paths, error) {
+	if daemonConfigFlags.socket != "" {
+		socketDir := filepath.Dir(daemonConfigFlags.socket)
+		return &daemon.Paths{
+			Dir:    socketDir,
+			Socket: daemonConfigFlags.socket,
+			PID:    daemonConfigFlags.socket + ".pid",
+			Log:    daemonConfigFlags.socket + ".log",
+		}, nil
+	}
+
+	return daemon.DefaultPaths()
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var daemonIndexFlags struct {
+	jsonOutput bool
+	socket     string
+}
+
+var daemonIndexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Print the daemon's file-to-package index",
+	Long: `Print the file index the daemon uses for incremental updates.
+
+Shows the watch roots and, for every tracked file, the package that
+owns it. This is a debugging aid for when incremental updates touch
+the wrong packages or miss changes. If the daemon was started with
+--warm-index, the symbol index (source package to targets) is shown too.
+
+Examples:
+  bazelle daemon index        # Show index as text
+  bazelle daemon index --json # Show index as JSON`,
+	RunE: runDaemonIndex,
+}
+
+func init() {
+	daemonIndexCmd.Flags().BoolVar(&daemonIndexFlags.jsonOutput, "json", false,
+		"Output as JSON")
+	daemonIndexCmd.Flags().StringVar(&daemonIndexFlags.socket, "socket", "",
+		"Custom socket path")
+
+	daemonCmd.AddCommand(daemonIndexCmd)
+}
+
+func runDaemonIndex(cmd *cobra.Command, args []string) error {
+	paths, err := getIndexDaemonPaths()
+	if err != nil {
+		return err
+	}
+
+	client, err := daemon.Connect(paths.Socket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	result, err := client.DumpIndex()
+	if err != nil {
+		return fmt.Errorf("failed to get index: %w", err)
+	}
+
+	if daemonIndexFlags.jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	writeDaemonIndexText(os.Stdout, result)
+	return nil
+}
+
+// writeDaemonIndexText writes the index as human-readable text, one file
+// per line sorted by path.
+func writeDaemonIndexText(w io.Writer, result *daemon.DumpIndexResult) {
+	if result.Status == "not_watching" {
+		_, _ = fmt.Fprintln(w, "Daemon is not watching; no index available")
+		return
+	}
+
+	_, _ = fmt.Fprintln(w, "Roots:")
+	for _, root := range result.Roots {
+		_, _ = fmt.Fprintf(w, "  - %s\n", root)
+	}
+
+	_, _ = fmt.Fprintf(w, "Files (%d):\n", len(result.Files))
+	files := make([]string, 0, len(result.Files))
+	for f := range result.Files {
+		files = append(files, f)
+	}
+	slices.Sort(files)
+	for _, f := range files {
+		_, _ = fmt.Fprintf(w, "  %s -> %s\n", f, result.Files[f])
+	}
+
+	if result.Symbols == nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Symbols (%d):\n", len(result.Symbols))
+	packages := make([]string, 0, len(result.Symbols))
+	for pkg := range result.Symbols {
+		packages = append(packages, pkg)
+	}
+	slices.Sort(packages)
+	for _, pkg := range packages {
+		_, _ = fmt.Fprintf(w, "  %s -> %s\n", pkg, strings.Join(result.Symbols[pkg], ", "))
+	}
+}
+
+// getIndexDaemonPaths returns the daemon paths based on flags or defaults.
+func getIndexDaemonPaths() (*daemon.Paths, error) {
+	if daemonIndexFlags.socket != "" {
+		socketDir := filepath.Dir(daemonIndexFlags.socket)
+		return &daemon.Paths{
+			Dir:    socketDir,
+			Socket: daemonIndexFlags.socket,
+			PID:    daemonIndexFlags.socket + ".pid",
+			Log:    daemonIndexFlags.socket + ".log",
+		}, nil
+	}
+
+	return daemon.DefaultPaths()
+}
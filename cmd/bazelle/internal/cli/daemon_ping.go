@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var daemonPingFlags struct {
+	jsonOutput bool
+	socket     string
+}
+
+var daemonPingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check that the daemon is responding",
+	Long: `Ping the running bazelle daemon.
+
+Connects to the daemon socket and prints the daemon's reply: its
+version, uptime and start time. Fails if the daemon cannot be reached,
+so it doubles as a liveness check in scripts.
+
+Examples:
+  bazelle daemon ping        # Show the reply as text
+  bazelle daemon ping --json # Show the reply as JSON`,
+	RunE: runDaemonPing,
+}
+
+func init() {
+	daemonPingCmd.Flags().BoolVar(&daemonPingFlags.jsonOutput, "json", false,
+		"Output as JSON")
+	daemonPingCmd.Flags().StringVar(&daemonPingFlags.socket, "socket", "",
+		"Custom socket path")
+
+	daemonCmd.AddCommand(daemonPingCmd)
+}
+
+func runDaemonPing(cmd *cobra.Command, args []string) error {
+	paths, err := getPingDaemonPaths()
+	if err != nil {
+		return err
+	}
+
+	client, err := daemon.Connect(paths.Socket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	result, err := client.Ping()
+	if err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+
+	if daemonPingFlags.jsonOutput {
+		encoder := json.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+
+	writeDaemonPingText(cmd.OutOrStdout(), result)
+	return nil
+}
+
+// writeDaemonPingText writes the ping reply as human-readable text.
+func writeDaemonPingText(w io.Writer, ping *daemon.PingResult) {
+	if ping.Pong {
+		_, _ = fmt.Fprintln(w, "Pong: yes")
+	} else {
+		_, _ = fmt.Fprintln(w, "Pong: no")
+	}
+	_, _ = fmt.Fprintf(w, "Version: %s\n", ping.Version)
+	_, _ = fmt.Fprintf(w, "Uptime: %s\n", formatUptime(ping.Uptime))
+	_, _ = fmt.Fprintf(w, "Started: %s\n", ping.StartTime)
+}
+
+// getPingDaemonPaths returns the daemon paths based on flags or defaults.
+func getPingDaemonPaths() (*daemon.Paths, error) {
+	if daemonPingFlags.socket != "" {
+		socketDir := filepath.Dir(daemonPingFlags.socket)
+		return &daemon.Paths{
+			Dir:    socketDir,
+			Socket: daemonPingFlags.socket,
+			PID:    daemonPingFlags.socket + ".pid",
+			Log:    daemonPingFlags.socket + ".log",
+		}, nil
+	}
+
+	return daemon.DefaultPaths()
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/daemon"
+)
+
+// startTestDaemon runs an in-process daemon server reporting version and
+// returns its socket path. The server is shut down when the test ends.
+func startTestDaemon(t *testing.T, version string) string {
+	t.Helper()
+	// Unix socket paths are limited to ~104 characters on macOS, too short
+	// for t.TempDir.
+	dir, err := os.MkdirTemp("/tmp", "dp")
+	if err != nil {
+		t.Fatalf("MkdirTemp: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	paths := &daemon.Paths{
+		Dir:    dir,
+		Socket: filepath.Join(dir, "daemon.sock"),
+		PID:    filepath.Join(dir, "daemon.pid"),
+		Log:    filepath.Join(dir, "daemon.log"),
+	}
+	server := daemon.NewServer(daemon.ServerConfig{Paths: paths, Version: version})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		select {
+		case <-errCh:
+		case <-time.After(5 * time.Second):
+			t.Error("daemon shutdown timed out")
+		}
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if client, err := daemon.Connect(paths.Socket); err == nil {
+			_ = client.Close()
+			return paths.Socket
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("daemon did not start listening")
+	return ""
+}
+
+func TestDaemonPingCmd(t *testing.T) {
+	socket := startTestDaemon(t, "9.8.7")
+
+	oldFlags := daemonPingFlags
+	t.Cleanup(func() { daemonPingFlags = oldFlags })
+
+	t.Run("text", func(t *testing.T) {
+		daemonPingFlags.socket = socket
+		daemonPingFlags.jsonOutput = false
+
+		var buf bytes.Buffer
+		daemonPingCmd.SetOut(&buf)
+		t.Cleanup(func() { daemonPingCmd.SetOut(nil) })
+
+		if err := runDaemonPing(daemonPingCmd, nil); err != nil {
+			t.Fatalf("runDaemonPing: %v", err)
+		}
+		out := buf.String()
+		for _, want := range []string{"Pong: yes\n", "Version: 9.8.7\n", "Uptime: 0s\n", "Started: "} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		daemonPingFlags.socket = socket
+		daemonPingFlags.jsonOutput = true
+
+		var buf bytes.Buffer
+		daemonPingCmd.SetOut(&buf)
+		t.Cleanup(func() { daemonPingCmd.SetOut(nil) })
+
+		if err := runDaemonPing(daemonPingCmd, nil); err != nil {
+			t.Fatalf("runDaemonPing: %v", err)
+		}
+		var got daemon.PingResult
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+		}
+		if !got.Pong || got.Version != "9.8.7" {
+			t.Errorf("ping = %+v, want pong from version 9.8.7", got)
+		}
+		if _, err := time.ParseDuration(got.Uptime); err != nil {
+			t.Errorf("uptime %q is not a duration: %v", got.Uptime, err)
+		}
+		if _, err := time.Parse(time.RFC3339, got.StartTime); err != nil {
+			t.Errorf("start time %q is not RFC 3339: %v", got.StartTime, err)
+		}
+	})
+}
+
+func TestDaemonPingCmd_NotRunning(t *testing.T) {
+	oldFlags := daemonPingFlags
+	t.Cleanup(func() { daemonPingFlags = oldFlags })
+	daemonPingFlags.socket = filepath.Join(t.TempDir(), "missing.sock")
+
+	if err := runDaemonPing(daemonPingCmd, nil); err == nil {
+		t.Error("runDaemonPing succeeded without a daemon")
+	}
+}
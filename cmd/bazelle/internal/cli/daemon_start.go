@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/daemon"
+	"github.com/albertocavalcante/bazelle/gazelle-kotlin/kotlin"
 	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/bazelbuild/bazel-gazelle/runner"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +19,7 @@ var daemonStartFlags struct {
 	foreground bool
 	socket     string
 	logFile    string
+	warmIndex  bool
 }
 
 var daemonStartCmd = &cobra.Command{
@@ -30,10 +33,17 @@ in the foreground for debugging.
 The daemon listens on a Unix socket for client connections. Multiple
 clients can connect simultaneously.
 
+With --warm-index, each watch session first parses the sources under the
+watch root and builds the package-to-target index before it reports ready.
+"bazelle daemon status" shows the watch as indexing until then. Each update
+then re-indexes the packages it updates and warns about the Kotlin imports
+that no workspace package declares.
+
 Examples:
   bazelle daemon start              # Start in background
   bazelle daemon start --foreground # Run in foreground (Ctrl+C to stop)
-  bazelle daemon start --socket /custom/path.sock`,
+  bazelle daemon start --socket /custom/path.sock
+  bazelle daemon start --warm-index # Index watch roots before ready`,
 	RunE: runDaemonStart,
 }
 
@@ -44,6 +54,8 @@ func init() {
 		"Custom socket path (default: ~/.bazelle/daemon.sock)")
 	daemonStartCmd.Flags().StringVar(&daemonStartFlags.logFile, "log", "",
 		"Log file path (default: ~/.bazelle/daemon.log)")
+	daemonStartCmd.Flags().BoolVar(&daemonStartFlags.warmIndex, "warm-index", false,
+		"Build the symbol index of the watch root before reporting ready")
 
 	daemonCmd.AddCommand(daemonStartCmd)
 }
@@ -83,11 +95,24 @@ func runDaemonForeground(paths *daemon.Paths) error {
 	fmt.Println("Press Ctrl+C to stop")
 	fmt.Println()
 
+	wd, err := runner.GetDefaultWorkspaceDirectory()
+	if err != nil {
+		return err
+	}
+	defaults, err := gazelleDefaultsFor(wd)
+	if err != nil {
+		return err
+	}
+
 	// Create server
 	handler := daemon.NewHandlerWithConfig(nil, daemon.HandlerConfig{
 		Languages:       languages,
-		GazelleDefaults: GazelleDefaults,
+		GazelleDefaults: defaults,
+		WarmIndex:       daemonStartFlags.warmIndex,
 	})
+	// Kotlin tree-sitter parses reuse the trees of unchanged files
+	kotlin.UseTreeCache(handler.Trees())
+	defer kotlin.UseTreeCache(nil)
 
 	server := daemon.NewServer(daemon.ServerConfig{
 		Paths:   paths,
@@ -116,6 +141,9 @@ func runDaemonBackground(paths *daemon.Paths) error {
 	if daemonStartFlags.logFile != "" {
 		args = append(args, "--log", daemonStartFlags.logFile)
 	}
+	if daemonStartFlags.warmIndex {
+		args = append(args, "--warm-index")
+	}
 
 	// Ensure daemon directory exists
 	if err := paths.EnsureDir(); err != nil {
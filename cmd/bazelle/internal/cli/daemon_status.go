@@ -41,17 +41,18 @@ func init() {
 
 // DaemonStatusOutput is the JSON output format for daemon status.
 type DaemonStatusOutput struct {
-	Running         bool     `json:"running"`
-	PID             int      `json:"pid,omitempty"`
-	SocketPath      string   `json:"socket_path"`
-	Version         string   `json:"version,omitempty"`
-	Uptime          string   `json:"uptime,omitempty"`
-	StartTime       string   `json:"start_time,omitempty"`
-	Watching        bool     `json:"watching"`
-	WatchPaths      []string `json:"watch_paths,omitempty"`
-	WatchLanguages  []string `json:"watch_languages,omitempty"`
-	ConnectedClients int     `json:"connected_clients,omitempty"`
-	Error           string   `json:"error,omitempty"`
+	Running          bool     `json:"running"`
+	PID              int      `json:"pid,omitempty"`
+	SocketPath       string   `json:"socket_path"`
+	Version          string   `json:"version,omitempty"`
+	Uptime           string   `json:"uptime,omitempty"`
+	StartTime        string   `json:"start_time,omitempty"`
+	Watching         bool     `json:"watching"`
+	WatchPaths       []string `json:"watch_paths,omitempty"`
+	WatchLanguages   []string `json:"watch_languages,omitempty"`
+	WatchState       string   `json:"watch_state,omitempty"`
+	ConnectedClients int      `json:"connected_clients,omitempty"`
+	Error            string   `json:"error,omitempty"`
 }
 
 func runDaemonStatus(cmd *cobra.Command, args []string) error {
@@ -111,6 +112,7 @@ func enrichStatusFromDaemon(paths *daemon.Paths, output *DaemonStatusOutput) err
 	output.Watching = watchStatus.Watching
 	output.WatchPaths = watchStatus.Paths
 	output.WatchLanguages = watchStatus.Languages
+	output.WatchState = watchStatus.State
 
 	return nil
 }
@@ -146,6 +148,9 @@ func outputDaemonStatusText(output DaemonStatusOutput, status *daemon.DaemonStat
 
 	if output.Watching {
 		fmt.Println("Watching: yes")
+		if output.WatchState != "" {
+			fmt.Printf("  State: %s\n", output.WatchState)
+		}
 		if len(output.WatchPaths) > 0 {
 			fmt.Println("  Paths:")
 			for _, p := range output.WatchPaths {
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+	"github.com/spf13/cobra"
+)
+
+var dumpASTFlags struct {
+	language  string
+	nodeTypes []string
+}
+
+var dumpASTCmd = &cobra.Command{
+	Use:   "dump-ast --language <lang> <file>",
+	Short: "Print the tree-sitter syntax tree of a source file",
+	Long: `Parses a source file with tree-sitter and prints its syntax tree as an
+S-expression.
+
+This is a debugging aid: when an import or reference is not extracted from a
+file, the tree shows the node types the parser actually produced for it. The
+backend is chosen as for parsing (see BAZELLE_TREESITTER_BACKEND) and must
+support the language.
+
+With --node-types, only the nodes of the listed types are printed instead, one
+per line in source order with their position and quoted source text, to
+prototype an extraction before writing it.
+
+Examples:
+  bazelle dump-ast --language kotlin src/main/kotlin/App.kt
+  bazelle dump-ast --language kotlin --node-types import_header,call_expression App.kt
+  BAZELLE_TREESITTER_BACKEND=wazero bazelle dump-ast --language c main.c`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDumpAST,
+}
+
+func init() {
+	dumpASTCmd.Flags().StringVar(&dumpASTFlags.language, "language", "",
+		"Tree-sitter language of the file (e.g. kotlin, java, python)")
+	dumpASTCmd.Flags().StringSliceVar(&dumpASTFlags.nodeTypes, "node-types", nil,
+		"Print only the nodes of these types with their source text (comma-separated)")
+
+	rootCmd.AddCommand(dumpASTCmd)
+}
+
+func runDumpAST(cmd *cobra.Command, args []string) error {
+	lang := treesitter.Language(dumpASTFlags.language)
+	if lang == "" {
+		return fmt.Errorf("--language is required")
+	}
+	if !slices.Contains(treesitter.AllLanguages(), lang) {
+		return fmt.Errorf("unknown language %q", lang)
+	}
+
+	source, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	backend, err := treesitter.NewBackendFromEnv()
+	if err != nil {
+		return fmt.Errorf("tree-sitter backend unavailable: %w", err)
+	}
+	defer func() { _ = backend.Close() }()
+
+	if len(dumpASTFlags.nodeTypes) > 0 {
+		return dumpNodes(cmd.Context(), cmd.OutOrStdout(), backend, lang, source, dumpASTFlags.nodeTypes)
+	}
+	return dumpAST(cmd.Context(), cmd.OutOrStdout(), backend, lang, source)
+}
+
+// dumpAST parses source as lang with backend and writes the S-expression of
+// its syntax tree to w.
+func dumpAST(ctx context.Context, w io.Writer, backend treesitter.Backend, lang treesitter.Language, source []byte) error {
+	return withTree(ctx, backend, lang, source, func(tree treesitter.Tree) error {
+		_, err := fmt.Fprintln(w, tree.RootNode().String())
+		return err
+	})
+}
+
+// dumpNodes parses source as lang with backend and writes the nodes of
+// nodeTypes to w in source order, one per line as
+// "<line>:<column> <type> <quoted source text>", with 1-based positions.
+func dumpNodes(ctx context.Context, w io.Writer, backend treesitter.Backend, lang treesitter.Language, source []byte, nodeTypes []string) error {
+	return withTree(ctx, backend, lang, source, func(tree treesitter.Tree) error {
+		root := tree.RootNode()
+		var nodes []treesitter.Node
+		for _, nodeType := range slices.Compact(slices.Sorted(slices.Values(nodeTypes))) {
+			nodes = append(nodes, treesitter.FindByType(root, nodeType)...)
+		}
+		slices.SortStableFunc(nodes, func(a, b treesitter.Node) int {
+			return cmp.Compare(a.StartByte(), b.StartByte())
+		})
+
+		for _, n := range nodes {
+			start := n.StartPoint()
+			if _, err := fmt.Fprintf(w, "%d:%d %s %q\n", start.Row+1, start.Column+1, n.Type(), n.Content(source)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// withTree parses source as lang with backend and calls fn with the tree.
+func withTree(ctx context.Context, backend treesitter.Backend, lang treesitter.Language, source []byte, fn func(treesitter.Tree) error) error {
+	if !backend.SupportsLanguage(lang) {
+		return fmt.Errorf("tree-sitter %s backend does not support %s", backend.Name(), lang)
+	}
+	parser, err := backend.NewParser(lang)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = parser.Close() }()
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	tree, err := parser.Parse(ctx, source)
+	if err != nil {
+		return fmt.Errorf("parse: %w", err)
+	}
+	defer func() { _ = tree.Close() }()
+
+	return fn(tree)
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+func TestDumpAST_Kotlin(t *testing.T) {
+	backend, err := treesitter.NewBackendFromEnv()
+	if err != nil {
+		t.Skipf("tree-sitter backend unavailable: %v", err)
+	}
+	supported := backend.SupportsLanguage(treesitter.Kotlin)
+	_ = backend.Close()
+	if !supported {
+		t.Skip("tree-sitter backend does not support kotlin")
+	}
+
+	file := filepath.Join(t.TempDir(), "App.kt")
+	if err := os.WriteFile(file, []byte("package app\n\nimport com.example.Foo\n\nclass App\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := dumpASTFlags.language
+	dumpASTFlags.language = "kotlin"
+	t.Cleanup(func() { dumpASTFlags.language = prev })
+
+	var buf bytes.Buffer
+	dumpASTCmd.SetOut(&buf)
+	t.Cleanup(func() { dumpASTCmd.SetOut(nil) })
+
+	if err := runDumpAST(dumpASTCmd, []string{file}); err != nil {
+		t.Fatalf("runDumpAST() error = %v", err)
+	}
+	out := strings.TrimSpace(buf.String())
+	if !strings.HasPrefix(out, "(source_file") || !strings.HasSuffix(out, ")") {
+		t.Errorf("output is not an S-expression: %q", out)
+	}
+	for _, want := range []string{"import_header", "class_declaration"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %s node: %q", want, out)
+		}
+	}
+}
+
+func TestDumpAST_NodeTypes(t *testing.T) {
+	backend, err := treesitter.NewBackendFromEnv()
+	if err != nil {
+		t.Skipf("tree-sitter backend unavailable: %v", err)
+	}
+	supported := backend.SupportsLanguage(treesitter.Kotlin)
+	_ = backend.Close()
+	if !supported {
+		t.Skip("tree-sitter backend does not support kotlin")
+	}
+
+	file := filepath.Join(t.TempDir(), "App.kt")
+	source := "package app\n\nimport com.example.Foo\nimport com.example.bar.*\n\nclass App\n"
+	if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prevLang, prevTypes := dumpASTFlags.language, dumpASTFlags.nodeTypes
+	dumpASTFlags.language = "kotlin"
+	dumpASTFlags.nodeTypes = []string{"import_header"}
+	t.Cleanup(func() { dumpASTFlags.language, dumpASTFlags.nodeTypes = prevLang, prevTypes })
+
+	var buf bytes.Buffer
+	dumpASTCmd.SetOut(&buf)
+	t.Cleanup(func() { dumpASTCmd.SetOut(nil) })
+
+	if err := runDumpAST(dumpASTCmd, []string{file}); err != nil {
+		t.Fatalf("runDumpAST() error = %v", err)
+	}
+	want := "3:1 import_header \"import com.example.Foo\"\n4:1 import_header \"import com.example.bar.*\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestDumpAST_BadLanguage(t *testing.T) {
+	prev := dumpASTFlags.language
+	t.Cleanup(func() { dumpASTFlags.language = prev })
+
+	for lang, want := range map[string]string{
+		"":      "--language is required",
+		"cobol": `unknown language "cobol"`,
+	} {
+		dumpASTFlags.language = lang
+		err := runDumpAST(dumpASTCmd, []string{"main.cob"})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("runDumpAST(--language=%q) error = %v, want %q", lang, err, want)
+		}
+	}
+}
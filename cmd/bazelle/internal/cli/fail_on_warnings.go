@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// checkWarnings fails if the update that just ran in the workspace at wd
+// emitted any warning, listing them: the warnings logged while it ran
+// (given) and the diagnostics --report collects, such as unresolved and
+// ambiguous imports and dependency cycles. The Kotlin diagnostics, computed
+// from the sources, are limited to dirs, the workspace-relative directories
+// the update visited.
+func checkWarnings(wd string, dirs, logged []string) error {
+	warnings := slices.Clone(logged)
+	for _, w := range workspaceWarnings(wd, dirs) {
+		warnings = append(warnings, w.String())
+	}
+	slices.Sort(warnings)
+	warnings = slices.Compact(warnings)
+	if len(warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("fail on warnings: %d warning(s):\n  %s",
+		len(warnings), strings.Join(warnings, "\n  "))
+}
@@ -51,8 +51,12 @@ func runFix(cmd *cobra.Command, args []string) error {
 
 	// Build gazelle arguments: "fix" + defaults + mode + passthrough args
 	// Note: gazelle expects command first, then flags
+	defaults, err := gazelleDefaultsFor(wd)
+	if err != nil {
+		return err
+	}
 	gazelleArgs := []string{"fix"}
-	gazelleArgs = append(gazelleArgs, GazelleDefaults...)
+	gazelleArgs = append(gazelleArgs, defaults...)
 
 	if fixFlags.check || fixFlags.dryRun {
 		gazelleArgs = append(gazelleArgs, "-mode=diff")
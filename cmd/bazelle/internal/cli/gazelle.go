@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"github.com/bazelbuild/bazel-gazelle/runner"
 )
@@ -11,9 +13,16 @@ var gazelleCmd = &cobra.Command{
 	Long: `Passes all arguments directly to gazelle with language extensions.
 
 This is a raw passthrough - no opinionated defaults are added.
-Use 'bazelle update' or 'bazelle fix' for enhanced commands with defaults.`,
+Use 'bazelle update' or 'bazelle fix' for enhanced commands with defaults.
+
+'bazelle gazelle --version' prints the gazelle implementation and version
+linked into bazelle instead of running gazelle.`,
 	DisableFlagParsing: true, // Pass all flags to gazelle
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 && args[0] == "--version" {
+			_, err := fmt.Fprintln(cmd.OutOrStdout(), linkedGazelle())
+			return err
+		}
 		wd, err := runner.GetDefaultWorkspaceDirectory()
 		if err != nil {
 			return err
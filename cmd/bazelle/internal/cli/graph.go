@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/depgraph"
+	"github.com/bazelbuild/bazel-gazelle/runner"
+	"github.com/spf13/cobra"
+)
+
+var graphFlags struct {
+	language           string
+	format             string
+	excludeTestImports bool
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [path]",
+	Short: "Print the dependency graph between targets",
+	Long: `Prints the dependency graph between the workspace's targets for visualization.
+
+Nodes are the targets owning the sources under path (default: the current
+directory); edges are the first-party dependencies between them, derived from
+imports and fully qualified references the same way update resolves deps.
+Dependencies on targets outside path, the stdlib, or external artifacts are
+not shown. With --exclude-test-imports, only the imports of production
+sources make edges.
+
+Formats:
+  dot   Graphviz digraph (bazelle graph | dot -Tsvg > deps.svg)
+  json  {"nodes": [...], "edges": [{"from": ..., "to": ...}]}`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runGraph,
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&graphFlags.language, "language", "kotlin",
+		fmt.Sprintf("Language to graph (%s)", strings.Join(depgraph.Languages, ", ")))
+	graphCmd.Flags().StringVar(&graphFlags.format, "format", "dot",
+		fmt.Sprintf("Output format (%s)", strings.Join(depgraph.Formats, ", ")))
+	graphCmd.Flags().BoolVar(&graphFlags.excludeTestImports, "exclude-test-imports", false,
+		"Leave the imports of test files out of the graph")
+
+	rootCmd.AddCommand(graphCmd)
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(depgraph.Formats, graphFlags.format) {
+		return fmt.Errorf("unsupported format %q (supported: %s)", graphFlags.format, strings.Join(depgraph.Formats, ", "))
+	}
+
+	wd, err := runner.GetDefaultWorkspaceDirectory()
+	if err != nil {
+		return err
+	}
+
+	path := "."
+	if len(args) > 0 {
+		path = args[0]
+	}
+	dir, err := workspaceRel(wd, path)
+	if err != nil {
+		return err
+	}
+
+	var opts []depgraph.Option
+	if graphFlags.excludeTestImports {
+		opts = append(opts, depgraph.ExcludeTestImports())
+	}
+	g, err := depgraph.Build(wd, dir, graphFlags.language, opts...)
+	if err != nil {
+		return err
+	}
+	return depgraph.Write(os.Stdout, g, graphFlags.format)
+}
+
+// workspaceRel returns path, relative to the current directory, as a
+// slash-separated path relative to the workspace root wd.
+func workspaceRel(wd, path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+	rel, err := filepath.Rel(wd, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the workspace %s", path, wd)
+	}
+	if rel == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/albertocavalcante/bazelle/pkg/util"
+)
+
+// stopParseTrace stops the --trace-parse trace of the current update and
+// returns its timings; nil when the flag is not set.
+var stopParseTrace func() []util.ParseTiming
+
+// reportParse is the parse time of one source file, for --trace-parse.
+type reportParse struct {
+	Path       string  `json:"path"` // workspace-relative
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// finishParseTrace stops the --trace-parse trace, prints the slowest parses
+// of the run to stderr and adds them to the --report. It does nothing
+// without --trace-parse, and runs only once per run, so exits on stale BUILD
+// files can call it early.
+func finishParseTrace(wd string) {
+	stop := stopParseTrace
+	if stop == nil {
+		return
+	}
+	stopParseTrace = nil
+
+	slowest := slowestParses(wd, stop(), updateFlags.traceParse)
+	writeSlowestParses(os.Stderr, slowest)
+	report.setSlowestParses(slowest)
+}
+
+// slowestParses returns the first n of timings, which are sorted slowest
+// first, with their paths made relative to wd.
+func slowestParses(wd string, timings []util.ParseTiming, n int) []reportParse {
+	timings = timings[:min(n, len(timings))]
+	parses := make([]reportParse, 0, len(timings))
+	for _, t := range timings {
+		p := t.Path
+		if rel, err := filepath.Rel(wd, p); err == nil && filepath.IsLocal(rel) {
+			p = rel
+		}
+		parses = append(parses, reportParse{
+			Path:       filepath.ToSlash(p),
+			DurationMS: float64(t.Duration.Microseconds()) / 1000,
+		})
+	}
+	return parses
+}
+
+// writeSlowestParses prints the slowest parses of a run, one per line.
+func writeSlowestParses(w io.Writer, parses []reportParse) {
+	if len(parses) == 0 {
+		_, _ = fmt.Fprintln(w, "No files parsed")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Slowest %d parses:\n", len(parses))
+	for _, p := range parses {
+		_, _ = fmt.Fprintf(w, "  %10.3fms  %s\n", p.DurationMS, p.Path)
+	}
+}
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/albertocavalcante/bazelle/pkg/util"
+)
+
+func TestSlowestParses(t *testing.T) {
+	timings := []util.ParseTiming{
+		{Path: "/ws/b/big.kt", Duration: 1500 * time.Microsecond},
+		{Path: "/ws/a/mid.kt", Duration: time.Millisecond},
+		{Path: "/elsewhere/x.kt", Duration: time.Microsecond},
+	}
+	got := slowestParses("/ws", timings, 2)
+	want := []reportParse{{Path: "b/big.kt", DurationMS: 1.5}, {Path: "a/mid.kt", DurationMS: 1}}
+	if !slices.Equal(got, want) {
+		t.Errorf("slowestParses() = %+v, want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	writeSlowestParses(&buf, got)
+	if out := buf.String(); !strings.HasPrefix(out, "Slowest 2 parses:\n") || !strings.Contains(out, "1.500ms  b/big.kt\n") {
+		t.Errorf("writeSlowestParses() wrote:\n%s", out)
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+// treeSitterLanguages are the languages the extensions parse with
+// tree-sitter on the backend BackendAuto selects.
+var treeSitterLanguages = []treesitter.Language{treesitter.Java, treesitter.Kotlin}
+
+// initPrintBackend reports the tree-sitter backend of this run on stderr
+// when --print-backend is set.
+func initPrintBackend() {
+	if globalFlags.printBackend {
+		_ = writeBackend(rootCmd.ErrOrStderr(), treesitter.SelectedBackend())
+	}
+}
+
+// writeBackend writes the tree-sitter backend BackendAuto resolved to, e.g.
+// "tree-sitter backend: cgo (auto)". When the backend cannot parse some of
+// treeSitterLanguages, one line per language follows saying which backend,
+// if any, parses it, since those languages then differ from the rest.
+func writeBackend(w io.Writer, selected treesitter.BackendType) error {
+	if _, err := fmt.Fprintf(w, "tree-sitter backend: %s (%s)\n", selected, treesitter.BackendAuto); err != nil {
+		return err
+	}
+
+	supported := treesitter.GetBackendInfo(selected).SupportedLanguages
+	if !slices.ContainsFunc(treeSitterLanguages, func(lang treesitter.Language) bool {
+		return !slices.Contains(supported, lang)
+	}) {
+		return nil
+	}
+	for _, lang := range treeSitterLanguages {
+		backend := string(selected)
+		if !slices.Contains(supported, lang) {
+			backend = "none (not supported by " + backend + ")"
+		}
+		if _, err := fmt.Fprintf(w, "  %s: %s\n", lang, backend); err != nil {
+			return err
+		}
+	}
+	return nil
+}
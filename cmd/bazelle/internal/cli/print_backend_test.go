@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+func TestPrintBackendFlag(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	rootCmd.SetOut(&stdout)
+	rootCmd.SetErr(&stderr)
+	rootCmd.SetArgs([]string{"--print-backend", "version"})
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetErr(nil)
+		rootCmd.SetArgs(nil)
+		globalFlags.printBackend = false
+	})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := "tree-sitter backend: " + string(treesitter.SelectedBackend()) + " (auto)\n"
+	if !strings.HasPrefix(stderr.String(), want) {
+		t.Errorf("stderr = %q, want prefix %q", stderr.String(), want)
+	}
+	if strings.Contains(stdout.String(), "tree-sitter backend") {
+		t.Errorf("backend printed to stdout: %q", stdout.String())
+	}
+}
+
+func TestWriteBackend(t *testing.T) {
+	tests := []struct {
+		backend treesitter.BackendType
+		want    string
+	}{
+		{
+			backend: treesitter.BackendCGO,
+			want:    "tree-sitter backend: cgo (auto)\n",
+		},
+		{
+			backend: treesitter.BackendWazero,
+			want: "tree-sitter backend: wazero (auto)\n" +
+				"  java: none (not supported by wazero)\n" +
+				"  kotlin: none (not supported by wazero)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.backend), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeBackend(&buf, tt.backend); err != nil {
+				t.Fatalf("writeBackend() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("writeBackend() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/depgraph"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/progress"
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// runReport is the JSON document written by update --report.
+type runReport struct {
+	// Languages are the extensions that generated rules for at least one
+	// directory, sorted.
+	Languages []string `json:"languages"`
+
+	// FilesParsed counts the source files in the directories the run
+	// visited, for the languages that visited them.
+	FilesParsed int `json:"files_parsed"`
+
+	// StaleDirs are the directories found out of date before the run: the
+	// incremental stale set, or with --check the directories of the BUILD
+	// files that would change. Empty for a full update.
+	StaleDirs []string `json:"stale_dirs"`
+
+	// UpdatedDirs are the directories whose BUILD file the run wrote.
+	UpdatedDirs []string `json:"updated_dirs"`
+
+	// Warnings are the diagnostics of the run, for every language but
+	// cycles, which only the Kotlin dependency graph reports.
+	Warnings []reportWarning `json:"warnings"`
+	Timing   reportTiming    `json:"timing"`
+
+	// SlowestParses are the source files that took longest to parse,
+	// slowest first. Only listed with --trace-parse.
+	SlowestParses []reportParse `json:"slowest_parses,omitempty"`
+
+	// Error is the error the run failed with, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Kinds of reportWarning.
+const (
+	warningUnresolvedImport = "unresolved_import"
+	warningAmbiguousImport  = "ambiguous_import"
+	warningDependencyPolicy = "dependency_policy"
+	warningCycle            = "cycle"
+)
+
+// reportWarning is a diagnostic that did not necessarily fail the run.
+type reportWarning struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// reportTiming records when the run started and how long it took.
+type reportTiming struct {
+	Start      string `json:"start"` // RFC 3339
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// reportCollector gathers the data of a runReport while update runs.
+// It is safe for concurrent use.
+type reportCollector struct {
+	wd    string
+	start time.Time
+
+	mu      sync.Mutex
+	visited map[string]map[string]bool // dir -> languages that generated rules
+	stale   []string
+	updated []string
+	parses  []reportParse
+}
+
+// report collects the diagnostics of the current update for --report and
+// the directories it visited for --fail-on-warnings; nil when neither flag
+// is set.
+var report *reportCollector
+
+func newReportCollector(wd string, start time.Time) *reportCollector {
+	return &reportCollector{wd: wd, start: start, visited: make(map[string]map[string]bool)}
+}
+
+// track returns a progress callback that records the directories and
+// languages of each event before passing it on to fn, which may be nil.
+// A nil collector returns fn unchanged.
+func (c *reportCollector) track(fn progress.Func) progress.Func {
+	if c == nil {
+		return fn
+	}
+	return func(ev progress.Event) {
+		c.mu.Lock()
+		if c.visited[ev.Dir] == nil {
+			c.visited[ev.Dir] = make(map[string]bool)
+		}
+		c.visited[ev.Dir][ev.Language] = true
+		c.mu.Unlock()
+		if fn != nil {
+			fn(ev)
+		}
+	}
+}
+
+// visitedDirs returns the sorted directories that rules were generated for,
+// or nil for a nil collector. A run that visited nothing, such as an
+// incremental update with nothing stale, gets an empty non-nil slice, since
+// nil stands for the whole workspace.
+func (c *reportCollector) visitedDirs() []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dirs := slices.AppendSeq([]string{}, maps.Keys(c.visited))
+	slices.Sort(dirs)
+	return dirs
+}
+
+// setStale records the directories found stale before the run.
+func (c *reportCollector) setStale(dirs []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale = slices.Clone(dirs)
+}
+
+// addUpdated records the workspace-relative BUILD files the run wrote.
+func (c *reportCollector) addUpdated(buildFiles []string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, f := range buildFiles {
+		c.updated = append(c.updated, path.Dir(filepath.ToSlash(f)))
+	}
+}
+
+// setSlowestParses records the slowest parses of the run.
+func (c *reportCollector) setSlowestParses(parses []reportParse) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parses = parses
+}
+
+// build assembles the report of a run that ended with runErr.
+func (c *reportCollector) build(runErr error) *runReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r := &runReport{
+		Languages:   []string{},
+		StaleDirs:   sortedDirs(c.stale),
+		UpdatedDirs: sortedDirs(c.updated),
+		Warnings:    []reportWarning{},
+		Timing: reportTiming{
+			Start:      c.start.Format(time.RFC3339),
+			DurationMS: time.Since(c.start).Milliseconds(),
+		},
+		SlowestParses: c.parses,
+	}
+	if runErr != nil {
+		r.Error = runErr.Error()
+	}
+
+	for dir, byLang := range c.visited {
+		for lang := range byLang {
+			if !slices.Contains(r.Languages, lang) {
+				r.Languages = append(r.Languages, lang)
+			}
+		}
+		r.FilesParsed += countSources(filepath.Join(c.wd, filepath.FromSlash(dir)), byLang)
+	}
+	slices.Sort(r.Languages)
+
+	r.Warnings = append(r.Warnings, workspaceWarnings(c.wd, nil)...)
+	return r
+}
+
+// String formats w as a line of warning output, e.g.
+// "unresolved import: com.missing.Thing".
+func (w reportWarning) String() string {
+	return strings.ReplaceAll(w.Kind, "_", " ") + ": " + w.Message
+}
+
+// workspaceWarnings collects the diagnostics of the workspace at wd: the
+// imports the last gazelle run resolved to nothing, ambiguously or against
+// the dependency policy, and the Kotlin imports that resolve to nothing and
+// the dependency cycles between Kotlin targets, which are computed here from
+// the sources since the Kotlin extension records neither. Only Kotlin has a
+// dependency graph, so cycles between targets of other languages are not
+// reported. If dirs is not nil, the Kotlin diagnostics are limited to those
+// workspace-relative directories: imports of the files in them, and cycles
+// through a target in one of them.
+func workspaceWarnings(wd string, dirs []string) []reportWarning {
+	var warnings []reportWarning
+
+	if unresolved, err := unresolvedImports(wd, dirs); err == nil {
+		for _, imp := range unresolved {
+			warnings = append(warnings, reportWarning{Kind: warningUnresolvedImport, Message: imp})
+		}
+	}
+
+	for _, u := range deppolicy.UnresolvedImports() {
+		warnings = append(warnings, reportWarning{
+			Kind:    warningUnresolvedImport,
+			Message: fmt.Sprintf("%s imports %q", u.From, u.Import),
+		})
+	}
+
+	for _, a := range deppolicy.Ambiguities() {
+		warnings = append(warnings, reportWarning{Kind: warningAmbiguousImport, Message: a.String()})
+	}
+
+	if err := deppolicy.Errors(); err != nil {
+		errs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = joined.Unwrap()
+		}
+		for _, e := range errs {
+			warnings = append(warnings, reportWarning{Kind: warningDependencyPolicy, Message: e.Error()})
+		}
+	}
+
+	if g, err := depgraph.Build(wd, "", "kotlin"); err == nil {
+		for _, cycle := range g.Cycles() {
+			if dirs != nil && !slices.ContainsFunc(cycle, func(node string) bool {
+				l, err := label.Parse(node)
+				return err == nil && slices.Contains(dirs, l.Pkg)
+			}) {
+				continue
+			}
+			warnings = append(warnings, reportWarning{Kind: warningCycle, Message: strings.Join(cycle, " -> ")})
+		}
+	}
+	return warnings
+}
+
+// countSources counts the files directly in dir that have an extension of
+// one of langNames.
+func countSources(dir string, langNames map[string]bool) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var exts []string
+	for lang := range langNames {
+		exts = append(exts, langs.Extensions[lang]...)
+	}
+	count := 0
+	for _, e := range entries {
+		if !e.IsDir() && slices.Contains(exts, filepath.Ext(e.Name())) {
+			count++
+		}
+	}
+	return count
+}
+
+// sortedDirs returns dirs sorted and without duplicates, never nil.
+func sortedDirs(dirs []string) []string {
+	sorted := slices.Clone(dirs)
+	slices.Sort(sorted)
+	return append([]string{}, slices.Compact(sorted)...)
+}
+
+// writeRunReport writes the report of the current run, which ended with
+// runErr, to the --report file. It does nothing without --report, and
+// writes the report only once per run, so exits on stale BUILD files can
+// write it early.
+func writeRunReport(runErr error) error {
+	c := report
+	if c == nil || updateFlags.report == "" {
+		return nil
+	}
+	report = nil
+
+	data, err := json.MarshalIndent(c.build(runErr), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(updateFlags.report, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
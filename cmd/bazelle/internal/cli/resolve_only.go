@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/resolve"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+// checkResolveOnly fails if name is set but is not one of langs.
+func checkResolveOnly(langs []language.Language, name string) error {
+	if name == "" {
+		return nil
+	}
+	names := make([]string, len(langs))
+	for i, l := range langs {
+		names[i] = l.Name()
+		if names[i] == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("--resolve-only: unknown language %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// resolveOnly returns langs with every language but the one called name
+// muted: it still parses the sources of each directory, so indexes built
+// while generating stay complete, but its rules are dropped before gazelle
+// merges and resolves them, leaving its BUILD content as it is. Dropped rules
+// are also missing from GenerateArgs.OtherGen, so the kept language does not
+// build on them (Go does not embed a go_proto_library, for instance). An
+// empty name returns langs unchanged.
+func resolveOnly(langs []language.Language, name string) []language.Language {
+	if name == "" {
+		return langs
+	}
+	wrapped := make([]language.Language, len(langs))
+	for i, l := range langs {
+		if l.Name() == name {
+			wrapped[i] = l
+		} else {
+			wrapped[i] = &mutedLanguage{Language: l}
+		}
+	}
+	return wrapped
+}
+
+// mutedLanguage runs GenerateRules and discards the result.
+//
+// The runner type-asserts languages for optional interfaces, so the wrapper
+// implements all of them and forwards to the wrapped language when it does.
+type mutedLanguage struct {
+	language.Language
+}
+
+var (
+	_ language.LifecycleManager    = (*mutedLanguage)(nil)
+	_ language.ModuleAwareLanguage = (*mutedLanguage)(nil)
+	_ resolve.CrossResolver        = (*mutedLanguage)(nil)
+)
+
+func (l *mutedLanguage) GenerateRules(args language.GenerateArgs) language.GenerateResult {
+	_ = l.Language.GenerateRules(args)
+	return language.GenerateResult{}
+}
+
+func (l *mutedLanguage) ApparentLoads(moduleToApparentName func(string) string) []rule.LoadInfo {
+	if m, ok := l.Language.(language.ModuleAwareLanguage); ok {
+		return m.ApparentLoads(moduleToApparentName)
+	}
+	return l.Loads()
+}
+
+func (l *mutedLanguage) Before(ctx context.Context) {
+	if m, ok := l.Language.(language.LifecycleManager); ok {
+		m.Before(ctx)
+	}
+}
+
+func (l *mutedLanguage) DoneGeneratingRules() {
+	if f, ok := l.Language.(language.FinishableLanguage); ok {
+		f.DoneGeneratingRules()
+	}
+}
+
+func (l *mutedLanguage) AfterResolvingDeps(ctx context.Context) {
+	if m, ok := l.Language.(language.LifecycleManager); ok {
+		m.AfterResolvingDeps(ctx)
+	}
+}
+
+func (l *mutedLanguage) CrossResolve(c *config.Config, ix *resolve.RuleIndex, imp resolve.ImportSpec, lang string) []resolve.FindResult {
+	if cr, ok := l.Language.(resolve.CrossResolver); ok {
+		return cr.CrossResolve(c, ix, imp, lang)
+	}
+	return nil
+}
@@ -4,8 +4,14 @@ package cli
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/detect"
 	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/pkg/util"
 	"github.com/bazelbuild/bazel-gazelle/language"
 	"github.com/spf13/cobra"
 )
@@ -21,8 +27,12 @@ var languages []language.Language
 
 // globalFlags holds persistent flags that apply to all commands
 var globalFlags struct {
-	verbosity int
-	logFormat string
+	verbosity     int
+	logFormat     string
+	buildFileName string
+	jobs          int
+	parseTimeout  time.Duration
+	printBackend  bool
 }
 
 // SetLanguages sets the language extensions to use with gazelle
@@ -44,16 +54,27 @@ Use 'bazelle gazelle' for direct access to the underlying gazelle binary.`,
 	},
 }
 
+var versionFlags struct {
+	jsonOutput bool
+}
+
 // versionCmd shows version information
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("bazelle %s (%s)\n", Version, GitCommit)
+	Long: `Print the bazelle version and the gazelle implementation it links.
+
+Bazelle builds on a fork of gazelle, so behavior can differ from upstream
+gazelle of the same version. The gazelle line names the linked module, its
+version and whether it is the upstream module, its v2 or a fork.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeVersion(cmd.OutOrStdout(), versionFlags.jsonOutput)
 	},
 }
 
 func init() {
+	versionCmd.Flags().BoolVar(&versionFlags.jsonOutput, "json", false,
+		"Output as JSON")
 	rootCmd.AddCommand(versionCmd)
 
 	// Global flags (persistent across all commands)
@@ -61,9 +82,17 @@ func init() {
 		"Verbosity level (0=error, 1=warn, 2=info, 3=debug, 4=trace)")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.logFormat, "log-format", "text",
 		"Log format (text, json)")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.buildFileName, "build-file-name", "",
+		"BUILD file name to write (BUILD or BUILD.bazel; default: detect from workspace)")
+	rootCmd.PersistentFlags().IntVarP(&globalFlags.jobs, "jobs", "j", 0,
+		"Number of files to parse in parallel (default: $"+util.EnvVarJobs+" or the number of CPUs)")
+	rootCmd.PersistentFlags().DurationVar(&globalFlags.parseTimeout, "parse-timeout", 0,
+		"Skip any file whose parse takes longer than this, e.g. 5s (default: $"+util.EnvVarParseTimeout+" or no limit)")
+	rootCmd.PersistentFlags().BoolVar(&globalFlags.printBackend, "print-backend", false,
+		"Print the tree-sitter backend selected for this run (cgo or wazero)")
 
 	// Hook to apply flags before command runs
-	cobra.OnInitialize(initLogging)
+	cobra.OnInitialize(initLogging, initJobs, initParseTimeout, initPrintBackend)
 }
 
 // initLogging applies CLI flags to the logger.
@@ -76,6 +105,23 @@ func initLogging() {
 	}
 }
 
+// initJobs exports --jobs as BAZELLE_JOBS so the language extensions, which
+// run in-process and read util.Jobs, pick it up. The flag overrides any value
+// already in the environment.
+func initJobs() {
+	if globalFlags.jobs > 0 {
+		_ = os.Setenv(util.EnvVarJobs, strconv.Itoa(globalFlags.jobs))
+	}
+}
+
+// initParseTimeout exports --parse-timeout as BAZELLE_PARSE_TIMEOUT, the same
+// way initJobs exports --jobs.
+func initParseTimeout() {
+	if globalFlags.parseTimeout > 0 {
+		_ = os.Setenv(util.EnvVarParseTimeout, globalFlags.parseTimeout.String())
+	}
+}
+
 // GazelleDefaults are opinionated defaults prepended to gazelle args.
 //
 // Why these defaults?
@@ -103,6 +149,55 @@ var GazelleDefaults = []string{
 	"-go_naming_convention_external=import", // Same for external deps
 }
 
+// gazelleDefaultsFor returns GazelleDefaults plus the -build_file_name flag
+// for the workspace at wd.
+//
+// Gazelle creates new files using the first configured name, so without this
+// a repo that standardized on BUILD would get BUILD.bazel files in new
+// packages. The name comes from --build-file-name, or is detected from the
+// files already in the workspace (see detect.BuildFileName) once per
+// workspace, since detecting it walks the whole tree.
+func gazelleDefaultsFor(wd string) ([]string, error) {
+	name := globalFlags.buildFileName
+	switch name {
+	case "":
+		detected, err := detectBuildFileName(wd)
+		if err != nil {
+			return nil, err
+		}
+		name = detected
+	case detect.BuildFileBazel, detect.BuildFilePlain:
+	default:
+		return nil, fmt.Errorf("invalid --build-file-name %q: must be %s or %s",
+			name, detect.BuildFilePlain, detect.BuildFileBazel)
+	}
+
+	args := slices.Clone(GazelleDefaults)
+	return append(args, "-build_file_name="+detect.BuildFileNames(name)), nil
+}
+
+var (
+	buildFileNamesMu sync.Mutex
+	buildFileNames   = make(map[string]string) // workspace root -> detected BUILD file name
+)
+
+// detectBuildFileName returns the BUILD file name detected for the workspace
+// at wd, detecting it on first use.
+func detectBuildFileName(wd string) (string, error) {
+	buildFileNamesMu.Lock()
+	defer buildFileNamesMu.Unlock()
+	if name, ok := buildFileNames[wd]; ok {
+		return name, nil
+	}
+	name, err := detect.BuildFileName(wd)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect BUILD file name: %w", err)
+	}
+	log.V(2).Infow("detected BUILD file name", "name", name)
+	buildFileNames[wd] = name
+	return name, nil
+}
+
 // Execute runs the root command.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
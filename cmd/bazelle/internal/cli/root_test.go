@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/albertocavalcante/bazelle/pkg/util"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	golang "github.com/bazelbuild/bazel-gazelle/language/go"
+	"github.com/bazelbuild/bazel-gazelle/language/proto"
+	"github.com/bazelbuild/bazel-gazelle/runner"
+)
+
+func writeTestFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGazelleDefaultsFor_BuildFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string // BUILD file name already used by the fixture
+		flag     string
+		want     string
+		wantErr  bool
+	}{
+		{name: "detects BUILD.bazel", existing: "BUILD.bazel", want: "-build_file_name=BUILD.bazel,BUILD"},
+		{name: "detects BUILD", existing: "BUILD", want: "-build_file_name=BUILD,BUILD.bazel"},
+		{name: "flag overrides detection", existing: "BUILD", flag: "BUILD.bazel", want: "-build_file_name=BUILD.bazel,BUILD"},
+		{name: "invalid flag", existing: "BUILD", flag: "BUCK", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wd := t.TempDir()
+			writeTestFile(t, wd, tt.existing, "")
+
+			old := globalFlags.buildFileName
+			globalFlags.buildFileName = tt.flag
+			t.Cleanup(func() { globalFlags.buildFileName = old })
+
+			args, err := gazelleDefaultsFor(wd)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error for invalid --build-file-name")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("gazelleDefaultsFor() error = %v", err)
+			}
+			if !slices.Contains(args, tt.want) {
+				t.Errorf("gazelleDefaultsFor() = %v, want to contain %q", args, tt.want)
+			}
+			for _, d := range GazelleDefaults {
+				if !slices.Contains(args, d) {
+					t.Errorf("gazelleDefaultsFor() = %v, missing default %q", args, d)
+				}
+			}
+		})
+	}
+}
+
+func TestGazelleDefaultsFor_DetectsOncePerWorkspace(t *testing.T) {
+	wd := t.TempDir()
+	writeTestFile(t, wd, "BUILD", "")
+	want := "-build_file_name=BUILD,BUILD.bazel"
+
+	for _, add := range []string{"a/BUILD.bazel", "b/BUILD.bazel"} {
+		args, err := gazelleDefaultsFor(wd)
+		if err != nil {
+			t.Fatalf("gazelleDefaultsFor() error = %v", err)
+		}
+		if !slices.Contains(args, want) {
+			t.Errorf("gazelleDefaultsFor() = %v, want to contain %q", args, want)
+		}
+		// A later call goes by the name detected first, without walking
+		// the workspace again
+		writeTestFile(t, wd, add, "")
+	}
+}
+
+// TestGazelleDefaultsFor_WritesWorkspaceConvention runs gazelle over fixtures
+// using each convention and checks that new and existing packages both end up
+// with the workspace's BUILD file name.
+func TestGazelleDefaultsFor_WritesWorkspaceConvention(t *testing.T) {
+	for _, convention := range []string{"BUILD", "BUILD.bazel"} {
+		t.Run(convention, func(t *testing.T) {
+			wd := t.TempDir()
+			writeTestFile(t, wd, "WORKSPACE", "")
+			writeTestFile(t, wd, convention, "# gazelle:prefix example.com/x\n")
+			writeTestFile(t, wd, filepath.Join("lib", convention), "")
+			writeTestFile(t, wd, "lib/lib.go", "package lib\n")
+			writeTestFile(t, wd, "app/app.go", "package app\n") // new package
+
+			defaults, err := gazelleDefaultsFor(wd)
+			if err != nil {
+				t.Fatalf("gazelleDefaultsFor() error = %v", err)
+			}
+			args := append([]string{"update"}, defaults...)
+			args = append(args, "-repo_root="+wd)
+			langs := []language.Language{proto.NewLanguage(), golang.NewLanguage()}
+			if err := runner.Run(langs, wd, args...); err != nil {
+				t.Fatalf("gazelle update failed: %v", err)
+			}
+
+			other := "BUILD.bazel"
+			if convention == "BUILD.bazel" {
+				other = "BUILD"
+			}
+			for _, pkg := range []string{"lib", "app"} {
+				content, err := os.ReadFile(filepath.Join(wd, pkg, convention))
+				if err != nil {
+					t.Fatalf("%s/%s not written: %v", pkg, convention, err)
+				}
+				if !strings.Contains(string(content), "go_library") {
+					t.Errorf("%s/%s missing generated rule:\n%s", pkg, convention, content)
+				}
+				if _, err := os.Stat(filepath.Join(wd, pkg, other)); err == nil {
+					t.Errorf("%s/%s should not be created in a %s workspace", pkg, other, convention)
+				}
+			}
+		})
+	}
+}
+
+func TestInitJobs(t *testing.T) {
+	tests := []struct {
+		name string
+		flag int
+		env  string
+		want int
+	}{
+		{name: "env without flag", env: "3", want: 3},
+		{name: "flag overrides env", flag: 2, env: "3", want: 2},
+		{name: "flag without env", flag: 4, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(util.EnvVarJobs, tt.env)
+			orig := globalFlags.jobs
+			t.Cleanup(func() { globalFlags.jobs = orig })
+			globalFlags.jobs = tt.flag
+
+			initJobs()
+
+			got, err := util.Jobs(0)
+			if err != nil {
+				t.Fatalf("Jobs failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("effective jobs = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitParseTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		flag time.Duration
+		env  string
+		want time.Duration
+	}{
+		{name: "env without flag", env: "3s", want: 3 * time.Second},
+		{name: "flag overrides env", flag: time.Second, env: "3s", want: time.Second},
+		{name: "flag without env", flag: 250 * time.Millisecond, want: 250 * time.Millisecond},
+		{name: "neither", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(util.EnvVarParseTimeout, tt.env)
+			orig := globalFlags.parseTimeout
+			t.Cleanup(func() { globalFlags.parseTimeout = orig })
+			globalFlags.parseTimeout = tt.flag
+
+			initParseTimeout()
+
+			got, err := util.ParseTimeout(0)
+			if err != nil {
+				t.Fatalf("ParseTimeout failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("effective parse timeout = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
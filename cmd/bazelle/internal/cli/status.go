@@ -5,8 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/incremental"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/sarif"
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
 	"github.com/bazelbuild/bazel-gazelle/runner"
 	"github.com/spf13/cobra"
 )
@@ -14,8 +20,13 @@ import (
 var statusFlags struct {
 	verbose bool
 	json    bool
+	force   bool
+	format  string
 }
 
+// statusFormats lists the output formats of bazelle status.
+var statusFormats = []string{"text", "json", "sarif"}
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show which directories have stale BUILD files",
@@ -24,8 +35,17 @@ var statusCmd = &cobra.Command{
 Compares the current state of source files against the last 'bazelle update'
 to identify directories that need BUILD file regeneration.
 
+Only files whose modification time or size changed since the last update are
+re-hashed, which keeps status fast on large trees. The --force flag re-hashes
+every file instead, catching edits that preserved the modification time.
+
 The --verbose flag shows individual file changes (new, modified, deleted).
-The --json flag outputs the result as JSON for scripting.`,
+The --json flag outputs the result as JSON for scripting.
+
+The --format flag selects the output format: text (default), json (same as
+--json) or sarif. The sarif format reports each stale BUILD file as a
+"bazelle/stale-build" result, for upload to GitHub code scanning or other
+SARIF consumers.`,
 	RunE: runStatus,
 }
 
@@ -34,6 +54,10 @@ func init() {
 		"Show individual file changes")
 	statusCmd.Flags().BoolVar(&statusFlags.json, "json", false,
 		"Output as JSON")
+	statusCmd.Flags().BoolVar(&statusFlags.force, "force", false,
+		"Re-hash every file instead of only those with a changed mtime or size")
+	statusCmd.Flags().StringVar(&statusFlags.format, "format", "text",
+		fmt.Sprintf("Output format (%s)", strings.Join(statusFormats, ", ")))
 
 	rootCmd.AddCommand(statusCmd)
 }
@@ -49,6 +73,14 @@ type StatusOutput struct {
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	format := statusFlags.format
+	if statusFlags.json {
+		format = "json"
+	}
+	if !slices.Contains(statusFormats, format) {
+		return fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(statusFormats, ", "))
+	}
+
 	wd, err := runner.GetDefaultWorkspaceDirectory()
 	if err != nil {
 		return err
@@ -59,7 +91,10 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	// Check if state file exists
 	if !tracker.HasState() {
-		if statusFlags.json {
+		switch format {
+		case "sarif":
+			return sarif.StaleBuildLog(staleBuildFiles(wd, []string{"."})).Write(os.Stdout)
+		case "json":
 			output := StatusOutput{
 				Stale:     true,
 				StaleDirs: []string{"."},
@@ -72,13 +107,20 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get status
-	cs, err := tracker.Status(ctx)
+	status := tracker.Status
+	if statusFlags.force {
+		status = tracker.StatusFull
+	}
+	cs, err := status(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to detect staleness: %w", err)
 	}
 
 	// Output result
-	if statusFlags.json {
+	switch format {
+	case "sarif":
+		return sarif.StaleBuildLog(staleBuildFiles(wd, cs.AffectedDirs())).Write(os.Stdout)
+	case "json":
 		output := StatusOutput{
 			Stale:         !cs.IsEmpty(),
 			StaleDirs:     cs.AffectedDirs(),
@@ -128,6 +170,24 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// staleBuildFiles returns the BUILD file of each directory in dirs, relative
+// to the workspace at wd. A directory without one gets the BUILD.bazel file
+// that update would create.
+func staleBuildFiles(wd string, dirs []string) []string {
+	files := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		name := jvm.DefaultBuildFileNames[0]
+		for _, candidate := range jvm.DefaultBuildFileNames {
+			if _, err := os.Stat(filepath.Join(wd, dir, candidate)); err == nil {
+				name = candidate
+				break
+			}
+		}
+		files = append(files, path.Join(dir, name))
+	}
+	return files
+}
+
 func outputJSON(v any) error {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
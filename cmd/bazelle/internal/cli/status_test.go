@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/incremental"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/sarif"
+)
+
+func TestRunStatus_SARIF(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel":           "",
+		"app/App.kt":             "package com.example.app\n",
+		"app/BUILD":              "",
+		"lib/Lib.kt":             "package com.example.lib\n",
+		"clean/Clean.kt":         "package com.example.clean\n",
+		"clean/BUILD.bazel":      "",
+		"lib/nested/Nested.kt":   "package com.example.lib.nested\n",
+		"lib/nested/BUILD.bazel": "",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	if err := incremental.NewTracker(wd, nil).Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	// Touch one file per stale directory; the new mtime makes status re-hash it
+	later := time.Now().Add(time.Hour)
+	for rel, content := range map[string]string{
+		"app/App.kt": "package com.example.app\n\nclass App\n",
+		"lib/Lib.kt": "package com.example.lib\n\nclass Lib\n",
+	} {
+		path := filepath.Join(wd, rel)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prev := statusFlags.format
+	statusFlags.format = "sarif"
+	t.Cleanup(func() { statusFlags.format = prev })
+
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+	err = runStatus(statusCmd, nil)
+	_ = w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("runStatus() error = %v", err)
+	}
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+
+	var log sarif.Log
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("status emitted invalid SARIF: %v\n%s", err, buf.String())
+	}
+	if log.Version != sarif.Version || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v, want one SARIF %s run", log, sarif.Version)
+	}
+
+	var uris []string
+	for _, r := range log.Runs[0].Results {
+		if r.RuleID != sarif.RuleStaleBuild {
+			t.Errorf("result rule = %q, want %q", r.RuleID, sarif.RuleStaleBuild)
+		}
+		uris = append(uris, r.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	}
+	// lib has no BUILD file yet, so it points at the one update would create
+	if want := []string{"app/BUILD", "lib/BUILD.bazel"}; !slices.Equal(uris, want) {
+		t.Errorf("stale BUILD files = %v, want %v", uris, want)
+	}
+}
+
+func TestRunStatus_UnsupportedFormat(t *testing.T) {
+	prev := statusFlags.format
+	statusFlags.format = "xml"
+	t.Cleanup(func() { statusFlags.format = prev })
+
+	if err := runStatus(statusCmd, nil); err == nil {
+		t.Error("runStatus() error = nil, want unsupported format error")
+	}
+}
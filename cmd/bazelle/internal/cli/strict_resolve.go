@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/gazelle-kotlin/kotlin"
+	"github.com/albertocavalcante/bazelle/internal/wspath"
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// checkStrictResolve fails if any Kotlin import or FQN in the workspace at
+// wd resolves to no target, listing the offending names.
+func checkStrictResolve(wd string) error {
+	unresolved, err := unresolvedImports(wd, nil)
+	if err != nil {
+		return fmt.Errorf("strict resolve: %w", err)
+	}
+	if len(unresolved) == 0 {
+		return nil
+	}
+	return fmt.Errorf("strict resolve: %d unresolved import(s); add the missing target or map them in %s:\n  %s",
+		len(unresolved), resolvemap.DefaultPath, strings.Join(unresolved, "\n  "))
+}
+
+// unresolvedImports returns the sorted Kotlin dependencies in the workspace
+// at wd that no workspace source declares the package of and that the
+// resolve maps applying to the importing file do not cover: those of its
+// directory and of the directories above it, up to the global map. Stdlib
+// names are never reported. If dirs is not nil, only the imports of files
+// directly in those workspace-relative directories are reported, though
+// every source still counts towards resolving them.
+func unresolvedImports(wd string, dirs []string) ([]string, error) {
+	dir, err := kotlin.NewParser().ParseDir(wd, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only membership matters here, so each package is keyed to the
+	// directory of a file declaring it rather than to its real target.
+	index := jvm.NewPackageIndex()
+	fileDirs := make([]string, len(dir.Results))
+	for i, r := range dir.Results {
+		rel, err := wspath.Rel(wd, filepath.Dir(r.FilePath))
+		if err != nil {
+			return nil, err
+		}
+		fileDirs[i] = rel
+		index.Add(r.Package, label.New("", rel, ""))
+	}
+
+	// dir.Unresolved holds the non-stdlib dependencies of all the files, but
+	// each file's are looked up in the resolve maps of its own directory.
+	candidates := make(map[string]bool, len(dir.Unresolved))
+	for _, dep := range dir.Unresolved {
+		if !index.Contains(dep) {
+			candidates[dep] = true
+		}
+	}
+	overrides := resolvemap.NewLayered(wd)
+	unresolved := make(map[string]bool)
+	for i, r := range dir.Results {
+		if dirs != nil && !slices.Contains(dirs, fileDirs[i]) {
+			continue
+		}
+		layers, err := overrides.For(fileDirs[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range slices.Concat(r.AllDependencies, r.StarImports) {
+			if !candidates[dep] {
+				continue
+			}
+			if _, ok := layers.Lookup(dep); !ok {
+				unresolved[dep] = true
+			}
+		}
+	}
+	return slices.Sorted(maps.Keys(unresolved)), nil
+}
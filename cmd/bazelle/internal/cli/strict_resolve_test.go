@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+)
+
+func writeStrictResolveWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	wd := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(wd, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return wd
+}
+
+func TestUnresolvedImports(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"app/App.kt": `package com.example.app
+
+import com.example.model.User
+import com.google.common.collect.ImmutableList
+import com.exmaple.util.Strings
+import kotlin.collections.List
+`,
+		"model/User.kt":        "package com.example.model\n\ndata class User(val name: String)\n",
+		resolvemap.DefaultPath: "[resolve]\n\"com.google.common.*\" = \"@maven//:guava\"\n",
+	})
+
+	got, err := unresolvedImports(wd, nil)
+	if err != nil {
+		t.Fatalf("unresolvedImports() error = %v", err)
+	}
+	if want := []string{"com.exmaple.util.Strings"}; !slices.Equal(got, want) {
+		t.Errorf("unresolvedImports() = %v, want %v", got, want)
+	}
+}
+
+func TestUnresolvedImports_LocalResolveMap(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel":         "",
+		"app/App.kt":           "package com.example.app\n\nimport com.acme.widgets.Widget\nimport com.google.common.base.Strings\n",
+		"services/pay/Pay.kt":  "package com.example.pay\n\nimport com.acme.widgets.Widget\nimport com.google.common.base.Strings\n",
+		resolvemap.DefaultPath: "[resolve]\n\"com.google.common.*\" = \"@maven//:guava\"\n",
+		"services/pay/" + filepath.ToSlash(resolvemap.DefaultPath): "[resolve]\n\"com.acme.*\" = \"@vendor//:acme\"\n",
+	})
+
+	// Only the file under services/pay sees the local map; both see the
+	// global one
+	got, err := unresolvedImports(wd, nil)
+	if err != nil {
+		t.Fatalf("unresolvedImports() error = %v", err)
+	}
+	if want := []string{"com.acme.widgets.Widget"}; !slices.Equal(got, want) {
+		t.Errorf("unresolvedImports() = %v, want %v", got, want)
+	}
+
+	if err := os.Remove(filepath.Join(wd, "app", "App.kt")); err != nil {
+		t.Fatal(err)
+	}
+	got, err = unresolvedImports(wd, nil)
+	if err != nil {
+		t.Fatalf("unresolvedImports() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("unresolvedImports() = %v, want none once only services/pay imports com.acme", got)
+	}
+}
+
+func TestRunUpdate_StrictResolve(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"app/App.kt":   "package com.example.app\n\nimport com.acme.missing.Widget\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	prev := updateFlags.strictResolve
+	updateFlags.strictResolve = true
+	t.Cleanup(func() { updateFlags.strictResolve = prev })
+
+	err := runUpdate(updateCmd, nil)
+	if err == nil {
+		t.Fatal("runUpdate() error = nil, want an unresolved import error")
+	}
+	for _, want := range []string{"strict resolve", "1 unresolved import(s)", "com.acme.missing.Widget"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(wd, "app", "BUILD.bazel")); !os.IsNotExist(err) {
+		t.Errorf("BUILD file written despite the failed check (stat error = %v)", err)
+	}
+}
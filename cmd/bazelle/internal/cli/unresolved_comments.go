@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/annotate"
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+)
+
+// annotateUnresolved writes a "# bazelle: unresolved" comment above each
+// rule for each import of it that the run recorded as unresolved, editing
+// files, the BUILD files generated by the run keyed by workspace-relative
+// path. A package with unresolved imports whose BUILD file gazelle left
+// unchanged has its current file from the workspace at wd annotated and
+// added to files. The comments of earlier runs are replaced in every file
+// annotated.
+func annotateUnresolved(wd string, files map[string][]byte) error {
+	imports := make(map[string]map[string][]string) // package -> rule -> imports
+	for _, u := range deppolicy.UnresolvedImports() {
+		if imports[u.From.Pkg] == nil {
+			imports[u.From.Pkg] = make(map[string][]string)
+		}
+		imports[u.From.Pkg][u.From.Name] = append(imports[u.From.Pkg][u.From.Name], u.Import)
+	}
+
+	targets := make(map[string]string, len(files)) // BUILD file -> package
+	for rel := range files {
+		targets[rel] = buildFilePackage(rel)
+	}
+	for pkg := range imports {
+		if rel, ok := existingBuildFile(wd, pkg); ok {
+			if _, generated := targets[rel]; !generated {
+				targets[rel] = pkg
+			}
+		}
+	}
+
+	for rel, pkg := range targets {
+		data, generated := files[rel]
+		if !generated {
+			var err error
+			if data, err = os.ReadFile(filepath.Join(wd, filepath.FromSlash(rel))); err != nil {
+				return err
+			}
+		}
+		annotated, err := annotate.Unresolved(rel, data, imports[pkg])
+		if err != nil {
+			return fmt.Errorf("annotate unresolved imports: %w", err)
+		}
+		if generated || !bytes.Equal(annotated, data) {
+			files[rel] = annotated
+		}
+	}
+	return nil
+}
+
+// buildFilePackage returns the package of the workspace-relative BUILD file
+// rel; the root package is "".
+func buildFilePackage(rel string) string {
+	if pkg := path.Dir(rel); pkg != "." {
+		return pkg
+	}
+	return ""
+}
+
+// existingBuildFile returns the workspace-relative path of the BUILD file of
+// package pkg in the workspace at wd, if it has one.
+func existingBuildFile(wd, pkg string) (string, bool) {
+	for _, name := range jvm.DefaultBuildFileNames {
+		rel := path.Join(pkg, name)
+		if _, err := os.Stat(filepath.Join(wd, filepath.FromSlash(rel))); err == nil {
+			return rel, true
+		}
+	}
+	return "", false
+}
@@ -3,25 +3,52 @@ package cli
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/changelog"
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/incremental"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/keep"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/output"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/progress"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/sarif"
 	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
+	"github.com/albertocavalcante/bazelle/pkg/util"
 	"github.com/bazelbuild/bazel-gazelle/runner"
 	"github.com/spf13/cobra"
 )
 
 var updateFlags struct {
-	check       bool
-	languages   []string
-	verbose     bool
-	incremental bool
-	force       bool
+	check         bool
+	languages     []string
+	verbose       bool
+	incremental   bool
+	force         bool
+	summary       bool
+	json          bool
+	quiet         bool
+	noColor       bool
+	strictResolve bool
+	format        string
+	resolveOnly   string
+	report        string
+	traceParse    int
+
+	unresolvedComments bool
+	failOnWarnings     bool
 }
 
+// checkFormats lists the output formats of update --check.
+var checkFormats = []string{"text", "sarif"}
+
 var updateCmd = &cobra.Command{
 	Use:   "update [path...]",
 	Short: "Update BUILD files",
@@ -36,6 +63,80 @@ significantly faster for large codebases.
 
 The --force flag forces a full update, ignoring any cached state.
 
+The --summary flag prints a per-package report of the deps and srcs that
+were added or removed by the update. Combine with --json for machine-readable
+output, which also lists the warnings logged during the update and sets
+"changed" to whether any BUILD file was modified. Without --summary, an
+update that leaves every BUILD file as it was prints "No BUILD file changes".
+
+While gazelle runs, a progress line shows the directories processed so far
+and the language being generated. It is drawn only when stderr is a terminal;
+--quiet turns it off and --no-color drops its colors.
+
+The --strict-resolve flag fails the update, before any BUILD file is
+written, if a Kotlin import or FQN resolves to no target: its package is not
+declared by any source in the workspace, it is not in the stdlib, and the
+resolve maps do not cover it. The global map is .bazelle/resolve.toml; any
+directory may add a .bazelle/resolve.toml of its own, which takes precedence
+for the sources under it and falls through to the maps above it for imports
+it does not list. The same maps pin the Kotlin, Python and Groovy imports
+they cover to their labels in deps, with or without the flag. The error lists
+the unresolved names. Use it in CI to catch typos and missing deps instead of
+having them silently left out.
+
+The --unresolved-comments flag writes a "# bazelle: unresolved <import>"
+comment above each rule for each Python or Groovy import of it that resolved
+to no target and so was left out of deps. The comments are rewritten on
+every run with the flag, dropping those of imports that now resolve, and
+left as they are without it. It has no effect with --check.
+
+With --check, the --format flag selects how stale BUILD files are reported:
+text (default) or sarif. The sarif format prints a SARIF log with one
+"bazelle/stale-build" result per BUILD file that would change, for upload
+to GitHub code scanning; the exit code is still 1 when any are stale.
+
+Resolved deps are checked against the banned deps list in .bazelle/deps.toml.
+Banned deps are removed from the generated rules; when the list sets
+"fail = true" the update also fails, naming each banned dep and its reason.
+
+When an import could resolve to several targets, the one in the most
+specific (deepest) package is chosen, then the lexicographically smallest
+label, so repeated runs agree. --verbose lists each such ambiguous import
+with the target chosen and the ones passed over.
+
+The --resolve-only flag limits generation and dependency resolution to one
+language extension, for debugging that language in a polyglot repo. Every
+extension still parses its sources, so cross-language resolution sees the
+whole workspace, but the rules of the other languages are left as they are:
+none are added, removed or re-resolved. Unlike --languages, no language is
+skipped.
+
+The --report flag writes a JSON report of the run to the given file, for
+keeping as a CI artifact: the languages processed, the number of source files
+in the directories visited, the stale and updated directories, warnings and
+timing. The warnings are the unresolved imports of Kotlin, Python and Groovy
+sources, the ambiguous imports of Python and Groovy sources, dependency
+policy violations and the dependency cycles between Kotlin targets. It is
+written even when the run fails, with the error included.
+
+The --trace-parse flag times the parse of every source file and prints the
+slowest ones to stderr when the run ends, to find the single file that makes
+an update slow. It lists 10 files, or N with --trace-parse=N, and with
+--report they are also written to the report under "slowest_parses".
+
+The --fail-on-warnings flag makes the update exit non-zero, after writing
+the BUILD files, if it emitted any warning: a warning logged while it ran
+(like a Python file modifying sys.path), an import resolved to no target or
+to one of several, or a dependency cycle between Kotlin targets. Only the
+directories the update visited are checked, so "update some/dir" ignores
+warnings elsewhere. Each warning is listed, so CI can enforce a warning-free
+workspace.
+
+BUILD file content marked with a "# keep" comment (a rule, an attribute or
+a list element) must survive the update. If a generated BUILD file would drop
+any of it, the update fails, listing what would be lost, and no BUILD files
+are written.
+
 Additional gazelle flags (like -bzlmod, -go_prefix) are passed through.`,
 	RunE:                  runUpdate,
 	FParseErrWhitelist:    cobra.FParseErrWhitelist{UnknownFlags: true},
@@ -53,6 +154,29 @@ func init() {
 		"Only update directories with changed source files")
 	updateCmd.Flags().BoolVar(&updateFlags.force, "force", false,
 		"Force full update, ignoring cached state")
+	updateCmd.Flags().BoolVar(&updateFlags.summary, "summary", false,
+		"Print a per-package summary of added/removed deps and srcs")
+	updateCmd.Flags().BoolVar(&updateFlags.json, "json", false,
+		"Output the --summary report as JSON")
+	updateCmd.Flags().BoolVar(&updateFlags.quiet, "quiet", false,
+		"Suppress progress output")
+	updateCmd.Flags().BoolVar(&updateFlags.noColor, "no-color", false,
+		"Disable colored output")
+	updateCmd.Flags().BoolVar(&updateFlags.strictResolve, "strict-resolve", false,
+		"Fail if an import or FQN cannot be resolved to any target")
+	updateCmd.Flags().StringVar(&updateFlags.format, "format", "text",
+		fmt.Sprintf("Report format for --check (%s)", strings.Join(checkFormats, ", ")))
+	updateCmd.Flags().StringVar(&updateFlags.resolveOnly, "resolve-only", "",
+		"Only generate and resolve rules for this language; others still parse")
+	updateCmd.Flags().StringVar(&updateFlags.report, "report", "",
+		"Write a JSON report of the run's diagnostics to this file")
+	updateCmd.Flags().BoolVar(&updateFlags.unresolvedComments, "unresolved-comments", false,
+		"Mark imports that resolve to no target with a comment above their rule")
+	updateCmd.Flags().IntVar(&updateFlags.traceParse, "trace-parse", 0,
+		"Time each file parse and list the N slowest files (10 if no N is given)")
+	updateCmd.Flags().Lookup("trace-parse").NoOptDefVal = "10"
+	updateCmd.Flags().BoolVar(&updateFlags.failOnWarnings, "fail-on-warnings", false,
+		"Fail if the update emits any warning, such as an unresolved import")
 
 	rootCmd.AddCommand(updateCmd)
 }
@@ -64,6 +188,44 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if !slices.Contains(checkFormats, updateFlags.format) {
+		return fmt.Errorf("unsupported format %q (supported: %s)", updateFlags.format, strings.Join(checkFormats, ", "))
+	}
+	if updateFlags.format != "text" && !updateFlags.check {
+		return fmt.Errorf("--format=%s requires --check", updateFlags.format)
+	}
+	if err := checkResolveOnly(languages, updateFlags.resolveOnly); err != nil {
+		return err
+	}
+
+	report = nil
+	if updateFlags.report != "" || updateFlags.failOnWarnings {
+		report = newReportCollector(wd, start)
+	}
+	stopParseTrace = nil
+	if updateFlags.traceParse > 0 {
+		stopParseTrace = util.TraceParses()
+	}
+	var stopRecording func() []string
+	if updateFlags.failOnWarnings {
+		stopRecording = log.RecordWarnings()
+	}
+	err = runUpdateIn(wd, args, start)
+	if stopRecording != nil {
+		if logged := stopRecording(); err == nil {
+			err = checkWarnings(wd, report.visitedDirs(), logged)
+		}
+	}
+	finishParseTrace(wd)
+	if reportErr := writeRunReport(err); err == nil {
+		err = reportErr
+	}
+	return err
+}
+
+// runUpdateIn runs the update selected by the flags in the workspace at wd.
+func runUpdateIn(wd string, args []string, start time.Time) error {
+	changedBuildFiles = nil
 	log.V(2).Infow("starting update",
 		"dir", wd,
 		"incremental", updateFlags.incremental,
@@ -71,8 +233,12 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 
 	// Build gazelle arguments: "update" + defaults + mode + passthrough args
 	// Note: gazelle expects command first, then flags
+	defaults, err := gazelleDefaultsFor(wd)
+	if err != nil {
+		return err
+	}
 	gazelleArgs := []string{"update"}
-	gazelleArgs = append(gazelleArgs, GazelleDefaults...)
+	gazelleArgs = append(gazelleArgs, defaults...)
 
 	if updateFlags.check {
 		gazelleArgs = append(gazelleArgs, "-mode=diff")
@@ -83,17 +249,34 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		gazelleArgs = append(gazelleArgs, args...)
 	}
 
+	if updateFlags.strictResolve {
+		if err := checkStrictResolve(wd); err != nil {
+			return err
+		}
+	}
+
 	if updateFlags.check {
 		return runUpdateCheck(wd, gazelleArgs)
 	}
 
+	if updateFlags.summary {
+		return runUpdateWithSummary(wd, func() error {
+			return runUpdateApply(wd, args, gazelleArgs, start)
+		})
+	}
+
+	return runUpdateApply(wd, args, gazelleArgs, start)
+}
+
+// runUpdateApply runs an incremental or full update that writes BUILD files.
+func runUpdateApply(wd string, args, gazelleArgs []string, start time.Time) error {
 	// Handle incremental mode
 	if updateFlags.incremental && !updateFlags.force {
 		return runIncrementalUpdate(wd, args)
 	}
 
 	// Normal update: run gazelle
-	if err := runner.Run(languages, wd, gazelleArgs...); err != nil {
+	if err := runGazelle(wd, pathArgs(args), gazelleArgs); err != nil {
 		return err
 	}
 
@@ -106,6 +289,53 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// updateSummary is the output of update --summary --json: the BUILD file
+// changes of the update and the warnings logged while it ran.
+type updateSummary struct {
+	*changelog.Summary
+
+	// Changed reports whether the update modified any BUILD file. Packages
+	// only lists deps and srcs changes, so it can be empty when other
+	// attributes changed.
+	Changed bool `json:"changed"`
+
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// runUpdateWithSummary runs update and prints the BUILD file changes it made.
+func runUpdateWithSummary(wd string, update func() error) error {
+	before, err := changelog.TakeSnapshot(wd)
+	if err != nil {
+		return err
+	}
+
+	stopRecording := log.RecordWarnings()
+	err = update()
+	warnings := stopRecording()
+	if err != nil {
+		return err
+	}
+	for _, a := range deppolicy.Ambiguities() {
+		warnings = append(warnings, a.Warning())
+	}
+
+	after, err := changelog.TakeSnapshot(wd)
+	if err != nil {
+		return err
+	}
+
+	summary, err := changelog.Compare(before, after)
+	if err != nil {
+		return fmt.Errorf("failed to summarize changes: %w", err)
+	}
+
+	if updateFlags.json {
+		return outputJSON(updateSummary{Summary: summary, Changed: len(changedBuildFiles) > 0, Warnings: warnings})
+	}
+	fmt.Print(summary.Format())
+	return nil
+}
+
 func runUpdateCheck(wd string, args []string) error {
 	// Capture output by redirecting stdout/stderr
 	var buf bytes.Buffer
@@ -122,7 +352,11 @@ func runUpdateCheck(wd string, args []string) error {
 	os.Stderr = w
 
 	// Run gazelle
-	runErr := runner.Run(languages, wd, args...)
+	langs := resolveOnly(languages, updateFlags.resolveOnly)
+	if fn := report.track(nil); fn != nil {
+		langs = progress.Languages(langs, 0, fn)
+	}
+	runErr := runner.Run(langs, wd, args...)
 
 	// Restore stdout/stderr
 	_ = w.Close()
@@ -133,6 +367,16 @@ func runUpdateCheck(wd string, args []string) error {
 	_, _ = buf.ReadFrom(r)
 	output := buf.Bytes()
 
+	var staleDirs []string
+	for _, f := range diffBuildFiles(output) {
+		staleDirs = append(staleDirs, filepath.Dir(f))
+	}
+	report.setStale(staleDirs)
+
+	if updateFlags.format == "sarif" {
+		return reportCheckSARIF(output, runErr)
+	}
+
 	if len(output) > 0 {
 		// There are changes needed
 		if updateFlags.verbose {
@@ -155,7 +399,7 @@ func runUpdateCheck(wd string, args []string) error {
 			}
 		}
 		fmt.Fprintln(os.Stderr, "Run 'bazelle update' to apply changes")
-		os.Exit(1)
+		exitStale()
 	}
 
 	if runErr != nil {
@@ -166,6 +410,50 @@ func runUpdateCheck(wd string, args []string) error {
 	return nil
 }
 
+// reportCheckSARIF prints the BUILD files changed in the gazelle diff output
+// as a SARIF log, exiting with status 1 if there are any.
+func reportCheckSARIF(output []byte, runErr error) error {
+	files := diffBuildFiles(output)
+	if len(files) == 0 && runErr != nil {
+		return fmt.Errorf("gazelle failed: %w", runErr)
+	}
+	if err := sarif.StaleBuildLog(files).Write(os.Stdout); err != nil {
+		return err
+	}
+	if len(files) > 0 {
+		exitStale()
+	}
+	return nil
+}
+
+// exitStale exits with status 1 because BUILD files are out of date, printing
+// the --trace-parse timings and writing the --report file first.
+func exitStale() {
+	if wd, err := runner.GetDefaultWorkspaceDirectory(); err == nil {
+		finishParseTrace(wd)
+	}
+	if err := writeRunReport(errors.New("BUILD files need updating")); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(1)
+}
+
+// diffBuildFiles returns the files named by the "+++" headers of a unified
+// diff, which for gazelle's diff mode are the workspace-relative BUILD files
+// that would change.
+func diffBuildFiles(diff []byte) []string {
+	var files []string
+	for line := range strings.Lines(string(diff)) {
+		name, ok := strings.CutPrefix(line, "+++ ")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(strings.TrimRight(name, "\r\n"), "\t")
+		files = append(files, name)
+	}
+	return files
+}
+
 func runIncrementalUpdate(wd string, passthroughArgs []string) error {
 	ctx := context.Background()
 	tracker := incremental.NewTracker(wd, updateFlags.languages)
@@ -191,6 +479,7 @@ func runIncrementalUpdate(wd string, passthroughArgs []string) error {
 	}
 
 	staleDirs := cs.AffectedDirs()
+	report.setStale(staleDirs)
 
 	// Print stale directories
 	if updateFlags.verbose {
@@ -202,8 +491,12 @@ func runIncrementalUpdate(wd string, passthroughArgs []string) error {
 	}
 
 	// Build gazelle arguments with stale directories as targets
+	defaults, err := gazelleDefaultsFor(wd)
+	if err != nil {
+		return err
+	}
 	gazelleArgs := []string{"update"}
-	gazelleArgs = append(gazelleArgs, GazelleDefaults...)
+	gazelleArgs = append(gazelleArgs, defaults...)
 	gazelleArgs = append(gazelleArgs, passthroughArgs...)
 
 	// Add stale directories as targets
@@ -211,8 +504,10 @@ func runIncrementalUpdate(wd string, passthroughArgs []string) error {
 	gazelleArgs = append(gazelleArgs, targets...)
 
 	// Run gazelle on stale directories
-	fmt.Printf("Updating %d directories...\n", len(staleDirs))
-	if err := runner.Run(languages, wd, gazelleArgs...); err != nil {
+	if !updateFlags.quiet {
+		fmt.Printf("Updating %d directories...\n", len(staleDirs))
+	}
+	if err := runGazelle(wd, staleDirs, gazelleArgs); err != nil {
 		return fmt.Errorf("gazelle failed: %w", err)
 	}
 
@@ -222,12 +517,16 @@ func runIncrementalUpdate(wd string, passthroughArgs []string) error {
 
 func runFullUpdate(wd string, passthroughArgs []string) error {
 	// Build gazelle arguments
+	defaults, err := gazelleDefaultsFor(wd)
+	if err != nil {
+		return err
+	}
 	gazelleArgs := []string{"update"}
-	gazelleArgs = append(gazelleArgs, GazelleDefaults...)
+	gazelleArgs = append(gazelleArgs, defaults...)
 	gazelleArgs = append(gazelleArgs, passthroughArgs...)
 
 	// Run gazelle
-	if err := runner.Run(languages, wd, gazelleArgs...); err != nil {
+	if err := runGazelle(wd, pathArgs(passthroughArgs), gazelleArgs); err != nil {
 		return fmt.Errorf("gazelle failed: %w", err)
 	}
 
@@ -235,12 +534,144 @@ func runFullUpdate(wd string, passthroughArgs []string) error {
 	return updateStateAfterRun(wd)
 }
 
+// buildWriter receives the BUILD files written by update. Nil writes them
+// to the workspace.
+var buildWriter output.Writer
+
+// changedBuildFiles are the BUILD files, relative to the workspace root,
+// that the gazelle runs of the current update changed.
+var changedBuildFiles []string
+
+// runGazelle runs gazelle over dirs (none means the whole workspace),
+// drawing a progress line on stderr unless --quiet is set.
+func runGazelle(wd string, dirs, gazelleArgs []string) error {
+	// Violations of the dependency policy are collected during the run, as
+	// gazelle's resolve step has no way to fail
+	deppolicy.Reset()
+
+	// The generated files are held back until their kept content is checked
+	generated := &output.MemWriter{}
+	if err := runGazelleLangs(wd, dirs, gazelleArgs, generated); err != nil {
+		return err
+	}
+	files := generated.Files()
+	if updateFlags.unresolvedComments {
+		if err := annotateUnresolved(wd, files); err != nil {
+			return err
+		}
+	}
+	if err := writeBuildFiles(wd, files); err != nil {
+		return err
+	}
+	report.addUpdated(slices.Collect(maps.Keys(files)))
+	changedBuildFiles = append(changedBuildFiles, slices.Sorted(maps.Keys(files))...)
+	if len(files) == 0 && !updateFlags.quiet && !updateFlags.summary {
+		// Gazelle writes nothing for current BUILD files, which would
+		// otherwise look the same as a successful update
+		fmt.Println("No BUILD file changes")
+	}
+
+	if updateFlags.verbose {
+		printAmbiguities(os.Stderr, deppolicy.Ambiguities())
+	}
+	if err := deppolicy.Errors(); err != nil {
+		return fmt.Errorf("dependency policy: %w", err)
+	}
+	return nil
+}
+
+// printAmbiguities lists the imports that resolved to one of several
+// candidate targets.
+func printAmbiguities(w io.Writer, ambiguities []deppolicy.Ambiguity) {
+	if len(ambiguities) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Ambiguous imports (%d):\n", len(ambiguities))
+	for _, a := range ambiguities {
+		_, _ = fmt.Fprintf(w, "  %s\n", a)
+	}
+}
+
+// runGazelleLangs runs gazelle with the progress renderer unless it is
+// disabled, handing the BUILD files it generates to w.
+func runGazelleLangs(wd string, dirs, gazelleArgs []string, w output.Writer) error {
+	langs := resolveOnly(languages, updateFlags.resolveOnly)
+	renderer := progress.NewRenderer(os.Stderr, updateFlags.noColor)
+	fn := renderer.Func()
+	if updateFlags.quiet {
+		fn = nil
+	}
+	if fn = report.track(fn); fn == nil {
+		return output.Run(langs, wd, w, gazelleArgs...)
+	}
+
+	defer renderer.Finish()
+	langs = progress.Languages(langs, progress.CountDirs(wd, dirs), fn)
+	return output.Run(langs, wd, w, gazelleArgs...)
+}
+
+// writeBuildFiles hands the generated BUILD files, keyed by workspace-relative
+// path, to buildWriter. It fails without writing any of them if one would
+// drop content that its current version marks with "# keep".
+func writeBuildFiles(wd string, files map[string][]byte) error {
+	paths := slices.Sorted(maps.Keys(files))
+
+	var lost []string
+	for _, rel := range paths {
+		before, err := os.ReadFile(filepath.Join(wd, filepath.FromSlash(rel)))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		markers, err := keep.Lost(rel, before, files[rel])
+		if err != nil {
+			return fmt.Errorf("check kept content: %w", err)
+		}
+		for _, m := range markers {
+			lost = append(lost, rel+": "+m.String())
+		}
+	}
+	if len(lost) > 0 {
+		return fmt.Errorf("update would remove %d item(s) marked \"# keep\"; no BUILD files were written:\n  %s",
+			len(lost), strings.Join(lost, "\n  "))
+	}
+
+	w := buildWriter
+	if w == nil {
+		w = output.FSWriter{Root: wd}
+	}
+	for _, rel := range paths {
+		if err := w.WriteBuildFile(rel, files[rel]); err != nil {
+			return fmt.Errorf("write %s: %w", rel, err)
+		}
+	}
+	return nil
+}
+
+// pathArgs returns the directory arguments among the passthrough args,
+// dropping gazelle flags.
+func pathArgs(args []string) []string {
+	var dirs []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			dirs = append(dirs, arg)
+		}
+	}
+	return dirs
+}
+
 func updateStateAfterRun(wd string) error {
 	ctx := context.Background()
 	tracker := incremental.NewTracker(wd, updateFlags.languages)
 
-	// Refresh state from current disk state
-	if err := tracker.Refresh(ctx); err != nil {
+	// Refresh state from current disk state; --force re-hashes every file
+	refresh := tracker.Refresh
+	if updateFlags.force {
+		refresh = tracker.RefreshFull
+	}
+	if err := refresh(ctx); err != nil {
 		return fmt.Errorf("failed to update state: %w", err)
 	}
 
@@ -0,0 +1,607 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/gazelle-python/python"
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	golang "github.com/bazelbuild/bazel-gazelle/language/go"
+	"github.com/bazelbuild/bazel-gazelle/language/proto"
+)
+
+const keepBuildFile = `load("@rules_go//go:def.bzl", "go_library")
+
+# keep
+go_library(
+    name = "legacy",
+    srcs = ["gone.go"],
+    importpath = "example.com/ws/lib/legacy",
+)
+
+go_library(
+    name = "lib",
+    srcs = ["lib.go"],
+    data = [
+        "schema.json",  # keep
+    ],
+    importpath = "example.com/ws/lib",
+    visibility = ["//visibility:public"],
+)
+`
+
+func TestRunUpdate_PreservesKeep(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel":    "",
+		"lib/lib.go":      "package lib\n",
+		"lib/util.go":     "package lib\n",
+		"lib/BUILD.bazel": keepBuildFile,
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	prev := languages
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage()}
+	t.Cleanup(func() { languages = prev })
+
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(wd, "lib", "BUILD.bazel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if !strings.Contains(got, `"util.go"`) {
+		t.Errorf("BUILD file was not updated:\n%s", got)
+	}
+	for _, want := range []string{`name = "legacy"`, `"gone.go"`, `"schema.json",  # keep`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("kept content %s missing after update:\n%s", want, got)
+		}
+	}
+}
+
+func TestWriteBuildFiles_LostKeep(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"lib/BUILD.bazel": keepBuildFile,
+	})
+
+	regenerated := map[string][]byte{
+		"app/BUILD.bazel": []byte("go_library(name = \"app\")\n"),
+		"lib/BUILD.bazel": []byte("go_library(\n    name = \"lib\",\n    srcs = [\"lib.go\"],\n)\n"),
+	}
+	err := writeBuildFiles(wd, regenerated)
+	if err == nil {
+		t.Fatal("writeBuildFiles() error = nil, want lost kept content")
+	}
+	for _, want := range []string{
+		"2 item(s)",
+		`lib/BUILD.bazel: go_library "legacy"`,
+		`lib/BUILD.bazel: go_library "lib" data "schema.json"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(wd, "app", "BUILD.bazel")); !os.IsNotExist(err) {
+		t.Errorf("BUILD file written despite lost kept content (stat error = %v)", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(wd, "lib", "BUILD.bazel")); string(data) != keepBuildFile {
+		t.Errorf("lib/BUILD.bazel was rewritten:\n%s", data)
+	}
+}
+
+func TestRunUpdate_ResolveOnly(t *testing.T) {
+	const protoBuild = `load("@rules_proto//proto:defs.bzl", "proto_library")
+
+proto_library(
+    name = "api_proto",
+    srcs = ["api.proto"],
+    visibility = ["//visibility:public"],
+)
+`
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel":    "",
+		"api/BUILD.bazel": protoBuild,
+		"api/api.proto":   "syntax = \"proto3\";\npackage api;\n",
+		"api/extra.proto": "syntax = \"proto3\";\npackage api;\n",
+		"api/api.go":      "package api\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	prevLangs, prevOnly := languages, updateFlags.resolveOnly
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage()}
+	updateFlags.resolveOnly = "go"
+	t.Cleanup(func() { languages, updateFlags.resolveOnly = prevLangs, prevOnly })
+
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(wd, "api", "BUILD.bazel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	if !strings.Contains(got, "go_library(") || !strings.Contains(got, `"api.go"`) {
+		t.Errorf("go rules were not generated:\n%s", got)
+	}
+	if strings.Contains(got, "extra.proto") {
+		t.Errorf("proto rules were regenerated despite --resolve-only=go:\n%s", got)
+	}
+	if !strings.Contains(got, protoBuild[strings.Index(protoBuild, "proto_library("):]) {
+		t.Errorf("proto_library changed:\n%s", got)
+	}
+}
+
+func TestRunUpdate_ResolveOnlyUnknownLanguage(t *testing.T) {
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", t.TempDir())
+
+	prevLangs, prevOnly := languages, updateFlags.resolveOnly
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage()}
+	updateFlags.resolveOnly = "cobol"
+	t.Cleanup(func() { languages, updateFlags.resolveOnly = prevLangs, prevOnly })
+
+	err := runUpdate(updateCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown language "cobol"`) || !strings.Contains(err.Error(), "proto, go") {
+		t.Errorf("runUpdate() error = %v, want unknown language listing proto, go", err)
+	}
+}
+
+func TestRunUpdate_SummaryJSONWarnings(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"BUILD.bazel":  "# gazelle:python_enabled true\n",
+		"tools/run.py": "import os, sys\nsys.path.insert(0, os.path.dirname(__file__))\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	prevLangs, prevSummary, prevJSON := languages, updateFlags.summary, updateFlags.json
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage(), python.NewLanguage()}
+	updateFlags.summary, updateFlags.json = true, true
+	t.Cleanup(func() { languages, updateFlags.summary, updateFlags.json = prevLangs, prevSummary, prevJSON })
+
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	os.Stdout = w
+	err = runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"})
+	_ = w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+	var buf bytes.Buffer
+	_, _ = buf.ReadFrom(r)
+
+	var got struct {
+		Packages []json.RawMessage `json:"packages"`
+		Warnings []string          `json:"warnings"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("summary is not JSON: %v\n%s", err, buf.String())
+	}
+	if len(got.Packages) == 0 {
+		t.Errorf("summary lists no packages:\n%s", buf.String())
+	}
+	if !slices.ContainsFunc(got.Warnings, func(w string) bool {
+		return strings.Contains(w, "modifies sys.path") && strings.Contains(w, "tools/run.py")
+	}) {
+		t.Errorf("Warnings = %q, want the sys.path warning for tools/run.py", got.Warnings)
+	}
+}
+
+func TestRunUpdate_NoChanges(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"lib/lib.go":   "package lib\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	prevLangs, prevSummary, prevJSON := languages, updateFlags.summary, updateFlags.json
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage()}
+	t.Cleanup(func() { languages, updateFlags.summary, updateFlags.json = prevLangs, prevSummary, prevJSON })
+
+	update := func() string {
+		t.Helper()
+		oldStdout := os.Stdout
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("failed to create pipe: %v", err)
+		}
+		os.Stdout = w
+		err = runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"})
+		_ = w.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			t.Fatalf("runUpdate() error = %v", err)
+		}
+		var buf bytes.Buffer
+		_, _ = buf.ReadFrom(r)
+		return buf.String()
+	}
+	changed := func(out string) bool {
+		t.Helper()
+		var got struct {
+			Changed  *bool             `json:"changed"`
+			Packages []json.RawMessage `json:"packages"`
+		}
+		if err := json.Unmarshal([]byte(out), &got); err != nil {
+			t.Fatalf("summary is not JSON: %v\n%s", err, out)
+		}
+		if got.Changed == nil {
+			t.Fatalf("summary has no changed field:\n%s", out)
+		}
+		if !*got.Changed && len(got.Packages) != 0 {
+			t.Errorf("unchanged summary lists packages:\n%s", out)
+		}
+		return *got.Changed
+	}
+
+	updateFlags.summary, updateFlags.json = true, true
+	if out := update(); !changed(out) {
+		t.Errorf("first update reported no changes:\n%s", out)
+	}
+	// The BUILD files are current now
+	if out := update(); changed(out) {
+		t.Errorf("update of a current workspace reported changes:\n%s", out)
+	}
+
+	updateFlags.summary, updateFlags.json = false, false
+	if out := update(); out != "No BUILD file changes\n" {
+		t.Errorf("update of a current workspace printed %q, want No BUILD file changes", out)
+	}
+}
+
+func TestRunUpdate_Report(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"lib/lib.go":   "package lib\n",
+		"a/A.kt":       "package com.example.a\n\nimport com.example.b.B\nimport com.missing.Thing\n\nclass A(val b: B)\n",
+		"b/B.kt":       "package com.example.b\n\nimport com.example.a.A\n\nclass B(val a: A?)\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+
+	prevLangs, prevReport := languages, updateFlags.report
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage()}
+	updateFlags.report = reportFile
+	t.Cleanup(func() { languages, updateFlags.report = prevLangs, prevReport })
+
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		t.Fatalf("report is not a JSON object: %v\n%s", err, data)
+	}
+	for _, key := range []string{"languages", "files_parsed", "stale_dirs", "updated_dirs", "warnings", "timing"} {
+		if _, ok := keys[key]; !ok {
+			t.Errorf("report missing key %q:\n%s", key, data)
+		}
+	}
+
+	var got runReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(got.Languages, "go") {
+		t.Errorf("Languages = %v, want go included", got.Languages)
+	}
+	if got.FilesParsed != 1 {
+		t.Errorf("FilesParsed = %d, want 1", got.FilesParsed)
+	}
+	if !slices.Equal(got.UpdatedDirs, []string{"lib"}) {
+		t.Errorf("UpdatedDirs = %v, want [lib]", got.UpdatedDirs)
+	}
+	want := []reportWarning{
+		{Kind: warningUnresolvedImport, Message: "com.missing.Thing"},
+		{Kind: warningCycle, Message: "//a -> //b"},
+	}
+	if !slices.Equal(got.Warnings, want) {
+		t.Errorf("Warnings = %v, want %v", got.Warnings, want)
+	}
+	if got.Timing.Start == "" || got.Error != "" {
+		t.Errorf("Timing = %+v, Error = %q; want a start time and no error", got.Timing, got.Error)
+	}
+}
+
+func TestRunUpdate_ReportOtherLanguages(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"BUILD.bazel":  "# gazelle:python_enabled true\n",
+		"tools/run.py": "import yaml\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+
+	prevLangs, prevReport := languages, updateFlags.report
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage(), python.NewLanguage()}
+	updateFlags.report = reportFile
+	t.Cleanup(func() { languages, updateFlags.report = prevLangs, prevReport })
+
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var got runReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []reportWarning{{Kind: warningUnresolvedImport, Message: `//tools imports "yaml"`}}
+	if !slices.Equal(got.Warnings, want) {
+		t.Errorf("Warnings = %v, want %v", got.Warnings, want)
+	}
+}
+
+func TestRunUpdate_FailOnWarnings(t *testing.T) {
+	prevLangs, prevFail := languages, updateFlags.failOnWarnings
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage(), python.NewLanguage()}
+	t.Cleanup(func() { languages, updateFlags.failOnWarnings = prevLangs, prevFail })
+
+	update := func(files map[string]string, failOnWarnings bool) error {
+		t.Helper()
+		wd := writeStrictResolveWorkspace(t, files)
+		t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+		updateFlags.failOnWarnings = failOnWarnings
+		return runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"})
+	}
+	warning := map[string]string{
+		"MODULE.bazel": "",
+		"BUILD.bazel":  "# gazelle:python_enabled true\n",
+		"tools/run.py": "import os, sys\nsys.path.insert(0, os.path.dirname(__file__))\n",
+	}
+	clean := map[string]string{
+		"MODULE.bazel": "",
+		"BUILD.bazel":  "# gazelle:python_enabled true\n",
+		"tools/run.py": "import os\n",
+	}
+
+	if err := update(warning, false); err != nil {
+		t.Errorf("update with a warning and no --fail-on-warnings failed: %v", err)
+	}
+	if err := update(clean, true); err != nil {
+		t.Errorf("update without warnings failed under --fail-on-warnings: %v", err)
+	}
+	err := update(warning, true)
+	if err == nil {
+		t.Fatal("update with a warning succeeded under --fail-on-warnings")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "fail on warnings") || !strings.Contains(msg, "modifies sys.path") {
+		t.Errorf("error = %q, want it to list the sys.path warning", msg)
+	}
+}
+
+func TestRunUpdate_FailOnWarningsScopedToDirs(t *testing.T) {
+	prevLangs, prevFail := languages, updateFlags.failOnWarnings
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage(), python.NewLanguage()}
+	updateFlags.failOnWarnings = true
+	t.Cleanup(func() { languages, updateFlags.failOnWarnings = prevLangs, prevFail })
+
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"BUILD.bazel":  "# gazelle:python_enabled true\n",
+		"lib/Lib.kt":   "package com.example.lib\n\nimport com.missing.Thing\n",
+		"tools/run.py": "import os\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws", filepath.Join(wd, "tools")}); err != nil {
+		t.Errorf("update of tools failed on a warning in lib: %v", err)
+	}
+	err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"})
+	if err == nil {
+		t.Fatal("full update succeeded under --fail-on-warnings with an unresolved import in lib")
+	}
+	if msg := err.Error(); !strings.Contains(msg, "unresolved import: com.missing.Thing") {
+		t.Errorf("error = %q, want it to list the unresolved import", msg)
+	}
+}
+
+func TestRunUpdate_FailOnWarningsNoOpIncremental(t *testing.T) {
+	prevLangs, prevFail, prevIncremental := languages, updateFlags.failOnWarnings, updateFlags.incremental
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage()}
+	updateFlags.incremental = true
+	t.Cleanup(func() {
+		languages, updateFlags.failOnWarnings, updateFlags.incremental = prevLangs, prevFail, prevIncremental
+	})
+
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"lib/Lib.kt":   "package com.example.lib\n\nimport com.missing.Thing\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	updateFlags.failOnWarnings = false
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+		t.Fatalf("first update failed: %v", err)
+	}
+	// Nothing is stale, so the warning in lib belongs to no visited package
+	updateFlags.failOnWarnings = true
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+		t.Errorf("no-op incremental update failed under --fail-on-warnings: %v", err)
+	}
+}
+
+func TestRunUpdate_TraceParse(t *testing.T) {
+	var large strings.Builder
+	for i := range 20000 {
+		fmt.Fprintf(&large, "import mod%d\nvalue%d = \"\"\"doc\"\"\"\n", i, i)
+	}
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel":     "",
+		"BUILD.bazel":      "# gazelle:python_enabled true\n",
+		"app/small.py":     "import os\n",
+		"app/tiny.py":      "x = 1\n",
+		"app/generated.py": large.String(),
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+
+	prevLangs, prevReport, prevTrace := languages, updateFlags.report, updateFlags.traceParse
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage(), python.NewLanguage()}
+	updateFlags.report, updateFlags.traceParse = reportFile, 10
+	t.Cleanup(func() {
+		languages, updateFlags.report, updateFlags.traceParse = prevLangs, prevReport, prevTrace
+	})
+
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("report not written: %v", err)
+	}
+	var got runReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for i, p := range got.SlowestParses {
+		paths = append(paths, p.Path)
+		if i > 0 && p.DurationMS > got.SlowestParses[i-1].DurationMS {
+			t.Errorf("SlowestParses not sorted slowest first: %+v", got.SlowestParses)
+		}
+	}
+	slices.Sort(paths)
+	if want := []string{"app/generated.py", "app/small.py", "app/tiny.py"}; !slices.Equal(paths, want) {
+		t.Fatalf("SlowestParses paths = %v, want %v", paths, want)
+	}
+	if first := got.SlowestParses[0].Path; first != "app/generated.py" {
+		t.Errorf("slowest parse = %s, want the large app/generated.py", first)
+	}
+}
+
+func TestPrintAmbiguities(t *testing.T) {
+	var buf bytes.Buffer
+	printAmbiguities(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("printAmbiguities(nil) wrote %q, want nothing", buf.String())
+	}
+
+	chosen := label.New("", "lib/foo", "bar")
+	printAmbiguities(&buf, []deppolicy.Ambiguity{{
+		Import:     "foo.bar",
+		From:       label.New("", "app", "app"),
+		Chosen:     chosen,
+		Candidates: []label.Label{chosen, label.New("", "lib", "foo")},
+	}})
+	want := "Ambiguous imports (1):\n  //app imports \"foo.bar\": chose //lib/foo:bar over //lib:foo\n"
+	if got := buf.String(); got != want {
+		t.Errorf("printAmbiguities() wrote %q, want %q", got, want)
+	}
+}
+
+func TestRunUpdate_UnresolvedComments(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel": "",
+		"BUILD.bazel":  "# gazelle:python_enabled true\n",
+		"app/app.py":   "import os\nimport missing_mod\n",
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	prevLangs, prevComments := languages, updateFlags.unresolvedComments
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage(), python.NewLanguage()}
+	updateFlags.unresolvedComments = true
+	t.Cleanup(func() { languages, updateFlags.unresolvedComments = prevLangs, prevComments })
+
+	// A second run must leave the comments as the first wrote them
+	var first string
+	for run := range 2 {
+		if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+			t.Fatalf("runUpdate() error = %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(wd, "app", "BUILD.bazel"))
+		if err != nil {
+			t.Fatalf("BUILD file not written: %v", err)
+		}
+		got := string(data)
+		if run == 0 {
+			first = got
+		} else if got != first {
+			t.Errorf("second run changed the BUILD file:\n%s\nwant:\n%s", got, first)
+		}
+		if !strings.Contains(got, "# bazelle: unresolved missing_mod\npy_library(") {
+			t.Errorf("run %d: BUILD file lacks the unresolved comment above the library:\n%s", run+1, got)
+		}
+		if strings.Contains(got, "unresolved os") {
+			t.Errorf("run %d: stdlib import marked unresolved:\n%s", run+1, got)
+		}
+	}
+}
+
+func TestRunUpdate_PreservesDepsOrderAndComments(t *testing.T) {
+	wd := writeStrictResolveWorkspace(t, map[string]string{
+		"MODULE.bazel":   "",
+		"BUILD.bazel":    "# gazelle:python_enabled true\n",
+		"alpha/alpha.py": "X = 1\n",
+		"mid/mid.py":     "X = 1\n",
+		"zeta/zeta.py":   "X = 1\n",
+		"app/app.py":     "import zeta\nimport alpha\nimport mid\n",
+		"app/BUILD.bazel": `load("@rules_python//python:defs.bzl", "py_library")
+
+py_library(
+    name = "app",
+    srcs = glob(
+        ["**/*.py"],
+        exclude = [
+            "**/*_test.py",
+            "**/test_*.py",
+        ],
+    ),
+    visibility = ["//visibility:public"],
+    deps = [
+        # Shared code
+        "//alpha",
+        "//zeta",  # pinned until the 2.0 migration
+    ],
+)
+`,
+	})
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", wd)
+
+	prevLangs := languages
+	languages = []language.Language{proto.NewLanguage(), golang.NewLanguage(), python.NewLanguage()}
+	t.Cleanup(func() { languages = prevLangs })
+
+	if err := runUpdate(updateCmd, []string{"-go_prefix=example.com/ws"}); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(wd, "app", "BUILD.bazel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `    deps = [
+        # Shared code
+        "//alpha",
+        "//mid",
+        "//zeta",  # pinned until the 2.0 migration
+    ],
+`
+	if got := string(data); !strings.Contains(got, want) {
+		t.Errorf("deps not merged into the existing list:\n%s\nwant deps:\n%s", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+	"github.com/bazelbuild/bazel-gazelle/runner"
+	"github.com/spf13/cobra"
+)
+
+var validateResolveFlags struct {
+	mapPath string
+	json    bool
+}
+
+var validateResolveCmd = &cobra.Command{
+	Use:   "validate-resolve",
+	Short: "Check the resolve override map for mistakes",
+	Long: `Validates the resolve override map (.bazelle/resolve.toml).
+
+Reports as errors:
+  - patterns that are not a dotted name or a "pkg.*" prefix
+  - labels that do not parse
+
+Reports as warnings:
+  - patterns that match no import in the workspace
+  - overlapping patterns that map the same imports to different labels
+
+Exits with a non-zero status if any errors are found.`,
+	RunE: runValidateResolve,
+}
+
+func init() {
+	validateResolveCmd.Flags().StringVar(&validateResolveFlags.mapPath, "map", "",
+		"Path to the resolve map (default: <workspace>/.bazelle/resolve.toml)")
+	validateResolveCmd.Flags().BoolVar(&validateResolveFlags.json, "json", false,
+		"Output as JSON")
+
+	rootCmd.AddCommand(validateResolveCmd)
+}
+
+// ValidateResolveOutput is the JSON output format for bazelle validate-resolve.
+type ValidateResolveOutput struct {
+	Path     string               `json:"path"`
+	Valid    bool                 `json:"valid"`
+	Problems []resolvemap.Problem `json:"problems"`
+}
+
+func runValidateResolve(cmd *cobra.Command, args []string) error {
+	wd, err := runner.GetDefaultWorkspaceDirectory()
+	if err != nil {
+		return err
+	}
+
+	mapPath := validateResolveFlags.mapPath
+	if mapPath == "" {
+		mapPath = filepath.Join(wd, resolvemap.DefaultPath)
+	}
+
+	problems, err := validateResolve(wd, mapPath)
+	if err != nil {
+		return err
+	}
+
+	if validateResolveFlags.json {
+		if err := outputJSON(ValidateResolveOutput{
+			Path:     mapPath,
+			Valid:    !resolvemap.HasErrors(problems),
+			Problems: problems,
+		}); err != nil {
+			return err
+		}
+	} else {
+		printResolveProblems(os.Stdout, mapPath, problems)
+	}
+
+	if resolvemap.HasErrors(problems) {
+		return fmt.Errorf("resolve map %s has errors", mapPath)
+	}
+	return nil
+}
+
+// validateResolve loads the map at mapPath and validates it against the
+// imports found in the workspace at wd.
+func validateResolve(wd, mapPath string) ([]resolvemap.Problem, error) {
+	if _, err := os.Stat(mapPath); err != nil {
+		return nil, fmt.Errorf("resolve map not found: %w", err)
+	}
+
+	m, err := resolvemap.Load(mapPath)
+	if err != nil {
+		return nil, err
+	}
+
+	imports, err := scanWorkspaceImports(wd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan workspace imports: %w", err)
+	}
+
+	return resolvemap.Validate(m, imports), nil
+}
+
+func printResolveProblems(w io.Writer, mapPath string, problems []resolvemap.Problem) {
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%s: OK\n", mapPath)
+		return
+	}
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+}
+
+// importLineRegex matches the import forms shared by the JVM languages and
+// Python: "import a.b.C", "import static a.b.C.m" and "from a.b import c".
+var importLineRegex = regexp.MustCompile(`^\s*(?:import\s+(?:static\s+)?([\w.]+)|from\s+([\w.]+)\s+import\b)`)
+
+// scanWorkspaceImports returns the sorted, de-duplicated imports found in
+// the workspace's source files. It is a line-based scan, good enough to tell
+// whether a resolve pattern is used at all.
+func scanWorkspaceImports(wd string) ([]string, error) {
+	exts := langs.ExtensionSet(nil)
+	ignorer := langs.NewIgnorer(wd)
+	seen := make(map[string]bool)
+
+	err := filepath.WalkDir(wd, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if ignorer.SkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !exts[filepath.Ext(path)] {
+			return nil
+		}
+		return collectImports(path, seen)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	imports := make([]string, 0, len(seen))
+	for imp := range seen {
+		imports = append(imports, imp)
+	}
+	slices.Sort(imports)
+	return imports, nil
+}
+
+func collectImports(path string, seen map[string]bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		m := importLineRegex.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		imp := m[1] + m[2]
+		if !strings.HasPrefix(imp, ".") {
+			seen[imp] = true
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+)
+
+func TestValidateResolve(t *testing.T) {
+	wd := t.TempDir()
+	writeFile := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(wd, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("src/App.kt", "package app\n\nimport com.google.common.collect.ImmutableList\n")
+	writeFile("tools/gen.py", "from yaml import safe_load\n")
+	// Imports under ignored directories don't count as usage.
+	writeFile("bazel-out/Gen.java", "import org.unused.Thing;\n")
+	writeFile(resolvemap.DefaultPath, `
+[resolve]
+"com.google.common.*" = "@maven//:guava"
+"yaml" = "@pip//pyyaml:"
+"org.unused.*" = "@maven//:unused"
+`)
+
+	problems, err := validateResolve(wd, filepath.Join(wd, resolvemap.DefaultPath))
+	if err != nil {
+		t.Fatalf("validateResolve() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	printResolveProblems(&buf, "resolve.toml", problems)
+	out := buf.String()
+
+	for _, want := range []string{
+		`error: "yaml": invalid label "@pip//pyyaml:"`,
+		`warning: "org.unused.*": matches no import in the workspace`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "com.google.common") {
+		t.Errorf("used, valid pattern reported:\n%s", out)
+	}
+	if !resolvemap.HasErrors(problems) {
+		t.Error("HasErrors() = false, want true")
+	}
+}
+
+func TestValidateResolve_MissingMap(t *testing.T) {
+	wd := t.TempDir()
+	if _, err := validateResolve(wd, filepath.Join(wd, resolvemap.DefaultPath)); err == nil {
+		t.Error("validateResolve() error = nil, want not-found error")
+	}
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime/debug"
+	"strings"
+)
+
+// Gazelle build information (set via ldflags). Builds that carry no module
+// information, such as Bazel's, use these to report the linked gazelle;
+// otherwise it is read from the binary's build info.
+var (
+	GazelleModule  = ""
+	GazelleVersion = ""
+)
+
+// gazelleModulePath is the upstream module path of gazelle, which bazelle
+// imports whatever implementation is linked in.
+const gazelleModulePath = "github.com/bazelbuild/bazel-gazelle"
+
+// Gazelle implementations reported by gazelleBuild.Implementation.
+const (
+	gazelleUpstream = "upstream" // the upstream module, unreplaced
+	gazelleV2       = "v2"       // the upstream v2 module
+	gazelleFork     = "fork"     // a replacement module
+	gazelleUnknown  = "unknown"  // no build information available
+)
+
+// gazelleBuild describes the gazelle implementation linked into the binary.
+type gazelleBuild struct {
+	Implementation string `json:"implementation"`
+	Module         string `json:"module,omitempty"` // module actually linked
+	Version        string `json:"version,omitempty"`
+}
+
+// versionInfo is the output of "bazelle version --json".
+type versionInfo struct {
+	Version   string       `json:"version"`
+	GitCommit string       `json:"git_commit"`
+	Gazelle   gazelleBuild `json:"gazelle"`
+}
+
+// linkedGazelle returns the gazelle implementation the binary was built with.
+func linkedGazelle() gazelleBuild {
+	if GazelleModule != "" {
+		return classifyGazelle(GazelleModule, GazelleVersion, GazelleModule != gazelleModulePath)
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return gazelleBuild{Implementation: gazelleUnknown}
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != gazelleModulePath && !strings.HasPrefix(dep.Path, gazelleModulePath+"/v") {
+			continue
+		}
+		if dep.Replace != nil {
+			return classifyGazelle(dep.Replace.Path, dep.Replace.Version, true)
+		}
+		return classifyGazelle(dep.Path, dep.Version, false)
+	}
+	return gazelleBuild{Implementation: gazelleUnknown}
+}
+
+// classifyGazelle names the implementation of the gazelle module at path.
+// A replaced module is a fork unless it is the upstream module itself at
+// another version.
+func classifyGazelle(path, version string, replaced bool) gazelleBuild {
+	b := gazelleBuild{Module: path, Version: version}
+	switch {
+	case path == gazelleModulePath+"/v2":
+		b.Implementation = gazelleV2
+	case path == gazelleModulePath:
+		b.Implementation = gazelleUpstream
+	case replaced:
+		b.Implementation = gazelleFork
+	default:
+		b.Implementation = gazelleUnknown
+	}
+	return b
+}
+
+// String formats b for the text output of the version commands, e.g.
+// "gazelle github.com/bazelbuild/bazel-gazelle v0.47.0 (upstream)".
+func (b gazelleBuild) String() string {
+	if b.Module == "" {
+		return "gazelle (" + b.Implementation + ")"
+	}
+	version := b.Version
+	if version == "" {
+		version = "(devel)"
+	}
+	return fmt.Sprintf("gazelle %s %s (%s)", b.Module, version, b.Implementation)
+}
+
+// writeVersion writes the bazelle and gazelle versions to w, as JSON if
+// asJSON is set.
+func writeVersion(w io.Writer, asJSON bool) error {
+	info := versionInfo{Version: Version, GitCommit: GitCommit, Gazelle: linkedGazelle()}
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+	_, err := fmt.Fprintf(w, "bazelle %s (%s)\n%s\n", info.Version, info.GitCommit, info.Gazelle)
+	return err
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWriteVersion(t *testing.T) {
+	implementations := []string{gazelleUpstream, gazelleV2, gazelleFork, gazelleUnknown}
+
+	var buf bytes.Buffer
+	if err := writeVersion(&buf, false); err != nil {
+		t.Fatalf("writeVersion() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "bazelle ") || !strings.HasPrefix(lines[1], "gazelle ") {
+		t.Fatalf("output = %q, want a bazelle and a gazelle line", buf.String())
+	}
+	if !slices.ContainsFunc(implementations, func(impl string) bool { return strings.HasSuffix(lines[1], "("+impl+")") }) {
+		t.Errorf("gazelle line %q names no implementation", lines[1])
+	}
+
+	buf.Reset()
+	if err := writeVersion(&buf, true); err != nil {
+		t.Fatalf("writeVersion(json) error = %v", err)
+	}
+	var info versionInfo
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	if !slices.Contains(implementations, info.Gazelle.Implementation) {
+		t.Errorf("gazelle.implementation = %q, want one of %v", info.Gazelle.Implementation, implementations)
+	}
+}
+
+func TestLinkedGazelle_FromBuildInfo(t *testing.T) {
+	// go.mod replaces gazelle with the fork, and test binaries carry their
+	// module information.
+	got := linkedGazelle()
+	if got.Implementation == gazelleUnknown {
+		t.Skip("test binary carries no module information (e.g. built by Bazel)")
+	}
+	if got.Implementation != gazelleFork || !strings.Contains(got.Module, "fork-bazel-gazelle") {
+		t.Errorf("linkedGazelle() = %+v, want the fork", got)
+	}
+}
+
+func TestGazelleCmd_Version(t *testing.T) {
+	var buf bytes.Buffer
+	gazelleCmd.SetOut(&buf)
+	t.Cleanup(func() { gazelleCmd.SetOut(nil) })
+
+	if err := gazelleCmd.RunE(gazelleCmd, []string{"--version"}); err != nil {
+		t.Fatalf("gazelle --version error = %v", err)
+	}
+	if got, want := strings.TrimSpace(buf.String()), linkedGazelle().String(); got != want {
+		t.Errorf("gazelle --version = %q, want %q", got, want)
+	}
+}
+
+func TestLinkedGazelle_Ldflags(t *testing.T) {
+	prevModule, prevVersion := GazelleModule, GazelleVersion
+	t.Cleanup(func() { GazelleModule, GazelleVersion = prevModule, prevVersion })
+
+	GazelleModule, GazelleVersion = "github.com/bazelbuild/bazel-gazelle/v2", "v2.0.0"
+	want := gazelleBuild{Implementation: gazelleV2, Module: GazelleModule, Version: "v2.0.0"}
+	if got := linkedGazelle(); got != want {
+		t.Errorf("linkedGazelle() = %+v, want %+v", got, want)
+	}
+	if got, want := want.String(), "gazelle github.com/bazelbuild/bazel-gazelle/v2 v2.0.0 (v2)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestClassifyGazelle(t *testing.T) {
+	tests := []struct {
+		path     string
+		replaced bool
+		want     string
+	}{
+		{"github.com/bazelbuild/bazel-gazelle", false, gazelleUpstream},
+		{"github.com/bazelbuild/bazel-gazelle", true, gazelleUpstream},
+		{"github.com/bazelbuild/bazel-gazelle/v2", false, gazelleV2},
+		{"github.com/albertocavalcante/fork-bazel-gazelle", true, gazelleFork},
+		{"../bazel-gazelle", true, gazelleFork},
+	}
+	for _, tt := range tests {
+		if got := classifyGazelle(tt.path, "v1", tt.replaced); got.Implementation != tt.want {
+			t.Errorf("classifyGazelle(%q, replaced=%v) = %q, want %q", tt.path, tt.replaced, got.Implementation, tt.want)
+		}
+	}
+}
@@ -18,6 +18,10 @@ var watchFlags struct {
 	verbose   bool
 	json      bool
 	noColor   bool
+	clear     bool
+	onUpdate  string
+	replay    bool
+	events    string
 }
 
 var watchCmd = &cobra.Command{
@@ -41,6 +45,28 @@ Example output:
   [14:32:15] updating //src/auth:all...
   [14:32:16] ✓ src/auth/BUILD.bazel updated
 
+Use --clear to redraw the terminal for each update cycle, so it shows only
+the latest one like a dashboard. It has no effect with --no-color or --json,
+or when the output is not a terminal.
+
+Use --replay-on-start to first update the packages that went stale while
+the watcher was not running (all of them if no state exists yet), so BUILD
+files are current before the first change is handled.
+
+Use --on-update to run a shell command after each successful update.
+The command runs in the workspace root with these variables set:
+
+  BAZELLE_UPDATED_PACKAGES  updated packages, space-separated (//src/auth //lib)
+  BAZELLE_WORKSPACE_ROOT    absolute workspace path
+
+A failing command is reported but does not stop the watcher:
+
+  $ bazelle watch --on-update 'bazel run //:buildifier'
+
+Use --events consolidated to report each update cycle as one "updated"
+event listing every BUILD file it wrote, instead of one event per package.
+This keeps --json consumers to a single notification per cycle.
+
 Press Ctrl+C to stop watching.`,
 	RunE: runWatch,
 }
@@ -56,6 +82,14 @@ func init() {
 		"Stream JSON events (for tooling integration)")
 	watchCmd.Flags().BoolVar(&watchFlags.noColor, "no-color", false,
 		"Disable colored output")
+	watchCmd.Flags().BoolVar(&watchFlags.clear, "clear", false,
+		"Clear the terminal before each update cycle")
+	watchCmd.Flags().StringVar(&watchFlags.onUpdate, "on-update", "",
+		"Shell command to run after each successful update")
+	watchCmd.Flags().BoolVar(&watchFlags.replay, "replay-on-start", false,
+		"Update already-stale packages before watching for changes")
+	watchCmd.Flags().StringVar(&watchFlags.events, "events", string(watch.EventsPerPackage),
+		"Report updates per package or consolidated per cycle (per-package, consolidated)")
 
 	rootCmd.AddCommand(watchCmd)
 }
@@ -79,11 +113,21 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	events, err := watch.ParseEventMode(watchFlags.events)
+	if err != nil {
+		return fmt.Errorf("invalid --events: %w", err)
+	}
+
 	// Setup signal handling for graceful shutdown
 	// Include SIGHUP to handle terminal hangup
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 	defer cancel()
 
+	defaults, err := gazelleDefaultsFor(wd)
+	if err != nil {
+		return err
+	}
+
 	// Create watcher
 	w, err := watch.New(watch.Config{
 		Root:            wd,
@@ -92,8 +136,12 @@ func runWatch(cmd *cobra.Command, args []string) error {
 		Debounce:        watchFlags.debounce,
 		Verbose:         watchFlags.verbose,
 		NoColor:         watchFlags.noColor,
+		Clear:           watchFlags.clear,
 		JSON:            watchFlags.json,
-		GazelleDefaults: GazelleDefaults,
+		GazelleDefaults: defaults,
+		OnUpdate:        watchFlags.onUpdate,
+		ReplayOnStart:   watchFlags.replay,
+		Events:          events,
 	})
 	if err != nil {
 		return err
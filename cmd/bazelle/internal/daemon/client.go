@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net"
 	"sync"
 	"time"
@@ -17,6 +18,26 @@ var ErrNotConnected = errors.New("not connected to daemon")
 // ErrDaemonNotRunning is returned when the daemon is not running.
 var ErrDaemonNotRunning = errors.New("daemon not running")
 
+// ErrDaemonUnresponsive is returned when the daemon socket exists but keeps
+// refusing connections after the connect retry budget is spent, e.g. because
+// the daemon crashed and left a stale socket behind.
+var ErrDaemonUnresponsive = errors.New("daemon socket exists but is not accepting connections")
+
+// connectRetryDelays are the waits between dial attempts while the socket
+// refuses connections. A daemon that has just created its socket may not be
+// accepting yet; this gives it about 1.5s before Connect gives up.
+var connectRetryDelays = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	400 * time.Millisecond,
+	800 * time.Millisecond,
+}
+
+// ErrInsecureSocket is returned when the daemon socket is not owned by the
+// current user or has overly permissive modes.
+var ErrInsecureSocket = errors.New("refusing to connect to insecure daemon socket")
+
 // Client is a client for connecting to the daemon.
 type Client struct {
 	conn      net.Conn
@@ -30,16 +51,70 @@ type Client struct {
 	eventCh   chan *Notification
 	eventOnce sync.Once
 	closeCh   chan struct{}
+
+	// Once SubscribeEvents starts readEvents, it is the only reader of the
+	// connection and hands each response to the call waiting on its ID.
+	// pending is nil before that and after readEvents stops.
+	pendingMu sync.Mutex
+	pending   map[int64]chan *Response
+}
+
+// message is any message from the daemon: a notification if it has a method
+// and no ID, a response otherwise.
+type message struct {
+	Response
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// isNotification reports whether m is a notification.
+func (m *message) isNotification() bool {
+	return m.ID == nil && m.Method != ""
+}
+
+// notification returns m as a Notification.
+func (m *message) notification() *Notification {
+	return &Notification{JSONRPC: m.JSONRPC, Method: m.Method, Params: m.Params}
 }
 
 // Connect connects to the daemon at the given socket path.
+//
+// The socket must be owned by the current user, must not be group or world
+// writable and must not be reached through a symlink; otherwise
+// ErrInsecureSocket is returned without dialing.
+//
+// A missing socket returns ErrDaemonNotRunning immediately. A socket that
+// refuses connections is retried with backoff so a daemon that is still
+// starting up can be reached; if it keeps refusing, ErrDaemonUnresponsive is
+// returned.
 func Connect(socketPath string) (*Client, error) {
-	conn, err := net.DialTimeout("unix", socketPath, 5*time.Second)
-	if err != nil {
-		if errors.Is(err, net.ErrClosed) || isConnectionRefused(err) {
+	return connect(socketPath, connectRetryDelays)
+}
+
+// connect implements Connect, waiting retryDelays[i] before retry i+1.
+func connect(socketPath string, retryDelays []time.Duration) (*Client, error) {
+	if err := verifySocket(socketPath); err != nil {
+		return nil, err
+	}
+
+	var conn net.Conn
+	for attempt := 0; ; attempt++ {
+		var err error
+		conn, err = net.DialTimeout("unix", socketPath, 5*time.Second)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, net.ErrClosed) || isSocketMissing(err) {
 			return nil, ErrDaemonNotRunning
 		}
-		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+		if !isConnectionRefused(err) {
+			return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+		}
+		if attempt >= len(retryDelays) {
+			return nil, fmt.Errorf("%w: %s (%d attempts): %v",
+				ErrDaemonUnresponsive, socketPath, attempt+1, err)
+		}
+		time.Sleep(retryDelays[attempt])
 	}
 
 	return &Client{
@@ -59,6 +134,12 @@ func ConnectDefault() (*Client, error) {
 	return Connect(paths.Socket)
 }
 
+// isSocketMissing reports whether a dial failed because the socket file does
+// not exist, as opposed to existing and refusing the connection.
+func isSocketMissing(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
 // isConnectionRefused checks if the error is a connection refused error.
 func isConnectionRefused(err error) bool {
 	if err == nil {
@@ -84,6 +165,10 @@ func (c *Client) Close() error {
 }
 
 // call sends a request and waits for a response.
+//
+// Notifications the daemon sends before the response, such as the
+// update/progress stream of update/run, go to the SubscribeEvents channel,
+// or are dropped without a subscription.
 func (c *Client) call(method string, params any, result any) error {
 	if c.conn == nil {
 		return ErrNotConnected
@@ -95,24 +180,38 @@ func (c *Client) call(method string, params any, result any) error {
 		return err
 	}
 
+	// With events subscribed, readEvents reads the response for us
+	c.pendingMu.Lock()
+	var respCh chan *Response
+	if c.pending != nil {
+		respCh = make(chan *Response, 1)
+		c.pending[id] = respCh
+	}
+	c.pendingMu.Unlock()
+
 	// Send request
 	c.encoderMu.Lock()
 	err = c.encoder.Encode(req)
 	c.encoderMu.Unlock()
 	if err != nil {
+		c.dropPending(id)
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 
 	// Read response
-	c.decoderMu.Lock()
-	var resp Response
-	err = c.decoder.Decode(&resp)
-	c.decoderMu.Unlock()
-	if err != nil {
-		if err == io.EOF {
+	var resp *Response
+	if respCh != nil {
+		var ok bool
+		select {
+		case resp, ok = <-respCh:
+			if !ok {
+				return ErrNotConnected
+			}
+		case <-c.closeCh:
 			return ErrNotConnected
 		}
-		return fmt.Errorf("failed to read response: %w", err)
+	} else if resp, err = c.readResponse(); err != nil {
+		return err
 	}
 
 	// Check for error
@@ -130,6 +229,56 @@ func (c *Client) call(method string, params any, result any) error {
 	return nil
 }
 
+// readResponse reads messages until a response arrives, dropping the
+// notifications before it. It is used while no readEvents is running.
+func (c *Client) readResponse() (*Response, error) {
+	c.decoderMu.Lock()
+	defer c.decoderMu.Unlock()
+
+	for {
+		var msg message
+		if err := c.decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil, ErrNotConnected
+			}
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if !msg.isNotification() {
+			return &msg.Response, nil
+		}
+	}
+}
+
+// dropPending forgets the call waiting on id.
+func (c *Client) dropPending(id int64) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	delete(c.pending, id)
+}
+
+// Notify sends a notification to the daemon: a fire-and-forget request that
+// the daemon processes without responding, so Notify returns once the
+// message is written and reports neither a result nor a failure of the
+// method itself.
+func (c *Client) Notify(method string, params any) error {
+	if c.conn == nil {
+		return ErrNotConnected
+	}
+
+	notif, err := NewNotification(method, params)
+	if err != nil {
+		return err
+	}
+
+	c.encoderMu.Lock()
+	err = c.encoder.Encode(notif)
+	c.encoderMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	return nil
+}
+
 // Ping sends a ping request to the daemon.
 func (c *Client) Ping() (*PingResult, error) {
 	var result PingResult
@@ -184,6 +333,43 @@ func (c *Client) UpdateRun(params *UpdateRunParams) (*UpdateRunResult, error) {
 	return &result, nil
 }
 
+// UpdateDryRun previews what the next update would regenerate for the
+// daemon's current stale set, without writing any files.
+func (c *Client) UpdateDryRun() (*UpdateDryRunResult, error) {
+	var result UpdateDryRunResult
+	if err := c.call(MethodUpdateDryRun, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Reindex rebuilds the daemon's file index for its watch roots.
+func (c *Client) Reindex() (*ReindexResult, error) {
+	var result ReindexResult
+	if err := c.call(MethodReindex, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DumpIndex returns the daemon's file-to-package index and watch roots.
+func (c *Client) DumpIndex() (*DumpIndexResult, error) {
+	var result DumpIndexResult
+	if err := c.call(MethodDumpIndex, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetConfig returns the daemon's effective runtime configuration.
+func (c *Client) GetConfig() (*GetConfigResult, error) {
+	var result GetConfigResult
+	if err := c.call(MethodGetConfig, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 // StatusGet returns the staleness status.
 func (c *Client) StatusGet() (*StatusGetResult, error) {
 	var result StatusGetResult
@@ -199,6 +385,10 @@ func (c *Client) StatusGet() (*StatusGetResult, error) {
 // messages; if the buffer fills up, older messages are dropped. The channel is
 // closed when the connection is closed.
 //
+// Requests can still be made once subscribed; notifications sent while one
+// is in flight, such as the update/progress stream of UpdateRun, arrive on
+// the channel.
+//
 // Note: The client must call WatchStart to subscribe the server-side connection
 // to watch events. This method only sets up the client-side event receiver.
 //
@@ -215,15 +405,28 @@ func (c *Client) SubscribeEvents() (<-chan *Notification, error) {
 	// Create event channel if not already created
 	c.eventOnce.Do(func() {
 		c.eventCh = make(chan *Notification, 100)
+		c.pendingMu.Lock()
+		c.pending = make(map[int64]chan *Response)
+		c.pendingMu.Unlock()
 		go c.readEvents()
 	})
 
 	return c.eventCh, nil
 }
 
-// readEvents reads notifications from the server.
+// readEvents reads all messages from the server, passing notifications to
+// the event channel and responses to the calls waiting for them.
 func (c *Client) readEvents() {
 	defer close(c.eventCh)
+	defer func() {
+		// Fail the calls still waiting, and let later ones read for themselves
+		c.pendingMu.Lock()
+		for _, ch := range c.pending {
+			close(ch)
+		}
+		c.pending = nil
+		c.pendingMu.Unlock()
+	}()
 
 	for {
 		select {
@@ -233,8 +436,8 @@ func (c *Client) readEvents() {
 		}
 
 		c.decoderMu.Lock()
-		var notif Notification
-		err := c.decoder.Decode(&notif)
+		var msg message
+		err := c.decoder.Decode(&msg)
 		c.decoderMu.Unlock()
 
 		if err != nil {
@@ -244,13 +447,25 @@ func (c *Client) readEvents() {
 			continue
 		}
 
-		// Only process notifications (no ID)
-		if notif.Method != "" {
+		if msg.isNotification() {
 			select {
-			case c.eventCh <- &notif:
+			case c.eventCh <- msg.notification():
 			default:
 				// Drop if channel full
 			}
+			continue
+		}
+
+		if msg.ID == nil {
+			continue
+		}
+		c.pendingMu.Lock()
+		ch, ok := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		c.pendingMu.Unlock()
+		if ok {
+			resp := msg.Response
+			ch <- &resp
 		}
 	}
 }
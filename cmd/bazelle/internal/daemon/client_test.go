@@ -9,6 +9,8 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -330,6 +332,9 @@ func TestClient_CallNotConnected(t *testing.T) {
 	if !errors.Is(err, ErrNotConnected) {
 		t.Errorf("Error should be ErrNotConnected, got %v", err)
 	}
+	if err := client.Notify(MethodPing, nil); !errors.Is(err, ErrNotConnected) {
+		t.Errorf("Notify() error = %v, want ErrNotConnected", err)
+	}
 }
 
 func TestClient_CallServerDisconnects(t *testing.T) {
@@ -626,6 +631,84 @@ func TestIsDaemonRunningAt(t *testing.T) {
 	}
 }
 
+// staleSocket leaves a socket file at path with nothing listening on it, so
+// dials fail with connection refused rather than a missing file.
+func staleSocket(t *testing.T, path string) {
+	t.Helper()
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+}
+
+func TestConnect_RetriesUntilDaemonListens(t *testing.T) {
+	t.Parallel()
+	tmpDir := shortTempDir(t)
+	socketPath := filepath.Join(tmpDir, "daemon.sock")
+	staleSocket(t, socketPath)
+
+	// The daemon comes up after the first dial has already been refused.
+	ready := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(120 * time.Millisecond)
+		_ = os.Remove(socketPath)
+		listener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			ready <- nil
+			return
+		}
+		ready <- listener
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	start := time.Now()
+	client, err := Connect(socketPath)
+	if listener := <-ready; listener != nil {
+		defer listener.Close()
+	} else {
+		t.Fatal("failed to start listener")
+	}
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+		return // for nilaway
+	}
+	defer client.Close()
+
+	var budget time.Duration
+	for _, d := range connectRetryDelays {
+		budget += d
+	}
+	if elapsed := time.Since(start); elapsed > budget+time.Second {
+		t.Errorf("Connect took %v, longer than the retry budget %v", elapsed, budget)
+	}
+}
+
+func TestConnect_RefusedAfterRetryBudget(t *testing.T) {
+	t.Parallel()
+	tmpDir := shortTempDir(t)
+	socketPath := filepath.Join(tmpDir, "daemon.sock")
+	staleSocket(t, socketPath)
+
+	_, err := connect(socketPath, []time.Duration{time.Millisecond, time.Millisecond})
+	if !errors.Is(err, ErrDaemonUnresponsive) {
+		t.Fatalf("expected ErrDaemonUnresponsive, got %v", err)
+	}
+	if errors.Is(err, ErrDaemonNotRunning) {
+		t.Error("refused socket should be distinct from ErrDaemonNotRunning")
+	}
+	if !strings.Contains(err.Error(), "3 attempts") {
+		t.Errorf("error should report the attempt count, got %v", err)
+	}
+}
+
 func TestIsConnectionRefused(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -877,6 +960,184 @@ func TestClient_StatusGet(t *testing.T) {
 	}
 }
 
+func TestClient_Reindex(t *testing.T) {
+	t.Parallel()
+	tmpDir := shortTempDir(t)
+	socketPath := filepath.Join(tmpDir, "daemon.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		decoder := json.NewDecoder(bufio.NewReader(conn))
+		encoder := json.NewEncoder(conn)
+
+		var req Request
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+
+		if req.Method == MethodReindex {
+			result := ReindexResult{
+				Status:    "reindexed",
+				FileCount: 7,
+			}
+			resp, _ := NewResponse(*req.ID, result)
+			encoder.Encode(resp)
+		}
+	}()
+
+	client, err := Connect(socketPath)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+		return // for nilaway
+	}
+	defer client.Close()
+
+	result, err := client.Reindex()
+	if err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+
+	if result.Status != "reindexed" || result.FileCount != 7 {
+		t.Errorf("Reindex() = %+v, want reindexed with 7 files", result)
+	}
+}
+
+func TestClient_DumpIndex(t *testing.T) {
+	t.Parallel()
+	tmpDir := shortTempDir(t)
+	socketPath := filepath.Join(tmpDir, "daemon.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	defer listener.Close()
+
+	want := DumpIndexResult{
+		Status: "ok",
+		Roots:  []string{"/workspace"},
+		Files: map[string]string{
+			"BUILD.bazel":       "//",
+			"src/auth/Login.kt": "//src/auth",
+		},
+	}
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		decoder := json.NewDecoder(bufio.NewReader(conn))
+		encoder := json.NewEncoder(conn)
+
+		var req Request
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+
+		if req.Method == MethodDumpIndex {
+			resp, _ := NewResponse(*req.ID, want)
+			encoder.Encode(resp)
+		}
+	}()
+
+	client, err := Connect(socketPath)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+		return // for nilaway
+	}
+	defer client.Close()
+
+	result, err := client.DumpIndex()
+	if err != nil {
+		t.Fatalf("DumpIndex() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(*result, want) {
+		t.Errorf("DumpIndex() = %+v, want %+v", *result, want)
+	}
+}
+
+func TestClient_UpdateDryRun(t *testing.T) {
+	t.Parallel()
+	tmpDir := shortTempDir(t)
+	socketPath := filepath.Join(tmpDir, "daemon.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	defer listener.Close()
+
+	want := UpdateDryRunResult{
+		Status: "ok",
+		Packages: []PlannedChange{
+			{
+				Package:   "//src/auth",
+				BuildFile: "src/auth/BUILD.bazel",
+				Added:     []string{"src/auth/Token.kt"},
+				Modified:  []string{"src/auth/Login.kt"},
+			},
+			{
+				Package:      "//src/billing",
+				BuildFile:    "src/billing/BUILD.bazel",
+				NewBuildFile: true,
+				Added:        []string{"src/billing/Invoice.kt"},
+			},
+		},
+	}
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		decoder := json.NewDecoder(bufio.NewReader(conn))
+		encoder := json.NewEncoder(conn)
+
+		var req Request
+		if err := decoder.Decode(&req); err != nil {
+			return
+		}
+
+		if req.Method == MethodUpdateDryRun {
+			resp, _ := NewResponse(*req.ID, want)
+			encoder.Encode(resp)
+		}
+	}()
+
+	client, err := Connect(socketPath)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+		return // for nilaway
+	}
+	defer client.Close()
+
+	result, err := client.UpdateDryRun()
+	if err != nil {
+		t.Fatalf("UpdateDryRun() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(*result, want) {
+		t.Errorf("UpdateDryRun() = %+v, want %+v", *result, want)
+	}
+}
+
 func TestClient_ReadEventsChannelClosed(t *testing.T) {
 	t.Parallel()
 	tmpDir := shortTempDir(t)
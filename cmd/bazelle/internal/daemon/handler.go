@@ -4,11 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/progress"
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/watch"
 	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
 	"github.com/bazelbuild/bazel-gazelle/language"
 )
 
@@ -17,27 +24,46 @@ type Handler struct {
 	server    *Server
 	languages []language.Language
 	defaults  []string // gazelle defaults
+	trees     *TreeCache
+	warmIndex bool
+
+	// locks are shared by every watcher the handler starts, so update/run
+	// and watch cycles, of the current watcher or one still stopping, take
+	// turns on each package
+	locks *watch.PackageLocks
 
 	// Watch state
-	watchMu      sync.RWMutex
-	watcher      *watch.Watcher
-	watchCancel  context.CancelFunc
-	watchPaths   []string
-	watchLangs   []string
-	lastUpdate   time.Time
-	watching     bool
+	watchMu     sync.RWMutex
+	watcher     *watch.Watcher
+	watchCancel context.CancelFunc
+	watchPaths  []string
+	watchLangs  []string
+	debounce    int // milliseconds, of the running watcher
+	lastUpdate  time.Time
+	watching    bool
 }
 
+// DefaultDebounce is the watcher debounce, in milliseconds, used when
+// watch/start does not set one.
+const DefaultDebounce = 500
+
 // HandlerConfig configures the RPC handler.
 type HandlerConfig struct {
 	Languages       []language.Language
 	GazelleDefaults []string
+	TreeCacheSize   int // max cached parse trees (0 = DefaultTreeCacheSize)
+
+	// WarmIndex builds the symbol index of the watch root when a watch
+	// starts, reporting the "indexing" state until it is done.
+	WarmIndex bool
 }
 
 // NewHandler creates a new RPC handler.
 func NewHandler(server *Server) *Handler {
 	return &Handler{
 		server: server,
+		trees:  NewTreeCache(DefaultTreeCacheSize),
+		locks:  watch.NewPackageLocks(),
 	}
 }
 
@@ -47,9 +73,17 @@ func NewHandlerWithConfig(server *Server, cfg HandlerConfig) *Handler {
 		server:    server,
 		languages: cfg.Languages,
 		defaults:  cfg.GazelleDefaults,
+		trees:     NewTreeCache(cfg.TreeCacheSize),
+		warmIndex: cfg.WarmIndex,
+		locks:     watch.NewPackageLocks(),
 	}
 }
 
+// Trees returns the parse tree cache shared across update cycles.
+func (h *Handler) Trees() *TreeCache {
+	return h.trees
+}
+
 // SetLanguages sets the language extensions to use.
 func (h *Handler) SetLanguages(langs []language.Language) {
 	h.languages = langs
@@ -61,7 +95,27 @@ func (h *Handler) SetDefaults(defaults []string) {
 }
 
 // HandleRequest dispatches a request to the appropriate handler.
+//
+// A request without an ID is a notification: it is processed the same way,
+// but the response is dropped and nil returned, as JSON-RPC sends no reply to
+// notifications, not even for errors.
 func (h *Handler) HandleRequest(client *ClientConn, req *Request) *Response {
+	if req.ID != nil {
+		return h.dispatch(client, req)
+	}
+
+	// The method handlers answer with the request's ID, so give them one.
+	var id int64
+	notif := *req
+	notif.ID = &id
+	if resp := h.dispatch(client, &notif); resp != nil && resp.Error != nil {
+		log.Component("daemon").Debugw("notification failed", "method", req.Method, "error", resp.Error)
+	}
+	return nil
+}
+
+// dispatch routes a request with an ID to the handler for its method.
+func (h *Handler) dispatch(client *ClientConn, req *Request) *Response {
 	logger := log.Component("daemon")
 	logger.Debugw("handling request", "method", req.Method, "id", req.ID)
 
@@ -77,9 +131,17 @@ func (h *Handler) HandleRequest(client *ClientConn, req *Request) *Response {
 	case MethodWatchStatus:
 		return h.handleWatchStatus(req)
 	case MethodUpdateRun:
-		return h.handleUpdateRun(req)
+		return h.handleUpdateRun(client, req)
+	case MethodUpdateDryRun:
+		return h.handleUpdateDryRun(req)
 	case MethodStatusGet:
 		return h.handleStatusGet(req)
+	case MethodReindex:
+		return h.handleReindex(req)
+	case MethodDumpIndex:
+		return h.handleDumpIndex(req)
+	case MethodGetConfig:
+		return h.handleGetConfig(req)
 	default:
 		return NewErrorResponse(req.ID, ErrCodeMethodNotFound, fmt.Sprintf("Method not found: %s", req.Method), nil)
 	}
@@ -131,6 +193,11 @@ func (h *Handler) handleWatchStart(client *ClientConn, req *Request) *Response {
 		}
 	}
 
+	events, err := watch.ParseEventMode(params.Events)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInvalidParams, "Invalid params", err.Error())
+	}
+
 	h.watchMu.Lock()
 	defer h.watchMu.Unlock()
 
@@ -157,7 +224,7 @@ func (h *Handler) handleWatchStart(client *ClientConn, req *Request) *Response {
 	// Create watcher config
 	debounce := params.Debounce
 	if debounce <= 0 {
-		debounce = 500
+		debounce = DefaultDebounce
 	}
 
 	cfg := watch.Config{
@@ -169,6 +236,11 @@ func (h *Handler) handleWatchStart(client *ClientConn, req *Request) *Response {
 		NoColor:         true,
 		JSON:            false,
 		GazelleDefaults: h.defaults,
+		OnProgress:      h.BroadcastProgress,
+		Events:          events,
+		OnUpdated:       h.broadcastUpdated,
+		WarmIndex:       h.warmIndex,
+		Locks:           h.locks,
 	}
 
 	watcher, err := watch.New(cfg)
@@ -182,6 +254,7 @@ func (h *Handler) handleWatchStart(client *ClientConn, req *Request) *Response {
 	h.watchCancel = cancel
 	h.watchPaths = paths
 	h.watchLangs = params.Languages
+	h.debounce = debounce
 	h.watching = true
 
 	go h.runWatcher(ctx, watcher)
@@ -278,6 +351,11 @@ func (h *Handler) GetWatchStatus() *WatchStatusResult {
 		Languages: h.watchLangs,
 	}
 
+	if h.watcher != nil {
+		result.FileCount = h.watcher.FileCount()
+		result.State = string(h.watcher.State())
+	}
+
 	if !h.lastUpdate.IsZero() {
 		result.UpdateTime = h.lastUpdate.Format(time.RFC3339)
 	}
@@ -285,8 +363,44 @@ func (h *Handler) GetWatchStatus() *WatchStatusResult {
 	return result
 }
 
+// handleReindex handles the daemon/reindex request.
+// It rebuilds the watcher's file index without stopping the watch session.
+func (h *Handler) handleReindex(req *Request) *Response {
+	h.watchMu.RLock()
+	watcher := h.watcher
+	h.watchMu.RUnlock()
+
+	if watcher == nil {
+		resp, _ := NewResponse(*req.ID, ReindexResult{Status: "not_watching"})
+		return resp
+	}
+
+	start := time.Now()
+	fileCount, err := watcher.Reindex(context.Background())
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Failed to reindex", err.Error())
+	}
+
+	log.Component("daemon").Infow("reindexed", "files", fileCount, "duration", time.Since(start))
+
+	result := ReindexResult{
+		Status:    "reindexed",
+		FileCount: fileCount,
+		Duration:  time.Since(start).String(),
+	}
+
+	resp, err := NewResponse(*req.ID, result)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Failed to create response", nil)
+	}
+	return resp
+}
+
 // handleUpdateRun handles the update/run request.
-func (h *Handler) handleUpdateRun(req *Request) *Response {
+// It updates the given directories of the watch root, or its stale set, and
+// sends the requesting client an update/progress notification for each
+// directory as gazelle reaches it, ahead of the final result.
+func (h *Handler) handleUpdateRun(client *ClientConn, req *Request) *Response {
 	var params UpdateRunParams
 	if req.Params != nil {
 		if err := json.Unmarshal(req.Params, &params); err != nil {
@@ -294,9 +408,114 @@ func (h *Handler) handleUpdateRun(req *Request) *Response {
 		}
 	}
 
-	// TODO: Implement manual update trigger
-	// For now, return a not implemented error
-	return NewErrorResponse(req.ID, ErrCodeInternalError, "Not implemented", nil)
+	h.watchMu.RLock()
+	watcher := h.watcher
+	var root string
+	if len(h.watchPaths) > 0 {
+		root = h.watchPaths[0]
+	}
+	h.watchMu.RUnlock()
+
+	if watcher == nil {
+		resp, _ := NewResponse(*req.ID, UpdateRunResult{Status: "not_watching"})
+		return resp
+	}
+
+	dirs, err := updateRunDirs(root, params)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInvalidParams, "Invalid params", err.Error())
+	}
+
+	start := time.Now()
+	updated, err := watcher.Update(context.Background(), dirs, h.sendProgress(client))
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Update failed", err.Error())
+	}
+
+	result := UpdateRunResult{
+		Status:   "ok",
+		Changed:  len(updated.BuildFiles) > 0,
+		Duration: time.Since(start).String(),
+		Warnings: updated.Warnings,
+	}
+	if !result.Changed {
+		result.Status = "up_to_date"
+	}
+	for _, file := range updated.BuildFiles {
+		result.UpdatedDirs = append(result.UpdatedDirs, path.Dir(file))
+	}
+
+	resp, err := NewResponse(*req.ID, result)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Failed to create response", nil)
+	}
+	return resp
+}
+
+// updateRunDirs returns the directories, relative to the watch root, that
+// an update/run with params covers: the given paths, or with none the stale
+// set (nil) for an incremental run and the whole workspace otherwise.
+func updateRunDirs(root string, params UpdateRunParams) ([]string, error) {
+	if len(params.Paths) == 0 {
+		if params.Incremental {
+			return nil, nil
+		}
+		return []string{"."}, nil
+	}
+
+	dirs := make([]string, 0, len(params.Paths))
+	for _, p := range params.Paths {
+		rel := filepath.Clean(p)
+		if filepath.IsAbs(p) {
+			var err error
+			if rel, err = filepath.Rel(root, p); err != nil {
+				return nil, err
+			}
+		}
+		if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("path %s is outside the watch root %s", p, root)
+		}
+		dirs = append(dirs, rel)
+	}
+	return dirs, nil
+}
+
+// handleUpdateDryRun handles the update/dry-run request.
+// It previews the packages the watcher would regenerate for its stale set,
+// with the diff of each BUILD file.
+func (h *Handler) handleUpdateDryRun(req *Request) *Response {
+	h.watchMu.RLock()
+	watcher := h.watcher
+	h.watchMu.RUnlock()
+
+	if watcher == nil {
+		resp, _ := NewResponse(*req.ID, UpdateDryRunResult{Status: "not_watching"})
+		return resp
+	}
+
+	planned, err := watcher.Plan(context.Background())
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Failed to plan update", err.Error())
+	}
+
+	result := UpdateDryRunResult{Status: "ok"}
+	for _, p := range planned {
+		result.Packages = append(result.Packages, PlannedChange{
+			Package:      p.Package,
+			BuildFile:    filepath.ToSlash(p.BuildFile),
+			NewBuildFile: p.NewBuildFile,
+			Added:        p.Added,
+			Modified:     p.Modified,
+			Deleted:      p.Deleted,
+			Diff:         p.Diff,
+		})
+	}
+
+	resp, err := NewResponse(*req.ID, result)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Failed to create response", nil)
+	}
+	return resp
 }
 
 // handleStatusGet handles the status/get request.
@@ -315,6 +534,84 @@ func (h *Handler) handleStatusGet(req *Request) *Response {
 	return resp
 }
 
+// handleDumpIndex handles the daemon/index request.
+// It returns the watcher's file-to-package index and watch roots.
+func (h *Handler) handleDumpIndex(req *Request) *Response {
+	h.watchMu.RLock()
+	watcher := h.watcher
+	roots := slices.Clone(h.watchPaths)
+	h.watchMu.RUnlock()
+
+	if watcher == nil {
+		resp, _ := NewResponse(*req.ID, DumpIndexResult{Status: "not_watching"})
+		return resp
+	}
+
+	files, err := watcher.Index()
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Failed to read index", err.Error())
+	}
+
+	result := DumpIndexResult{
+		Status: "ok",
+		Roots:  roots,
+		Files:  files,
+	}
+	if symbols := watcher.Symbols(); symbols != nil {
+		result.Symbols = make(map[string][]string)
+		for _, pkg := range symbols.Packages() {
+			for _, target := range symbols.Targets(pkg) {
+				result.Symbols[pkg] = append(result.Symbols[pkg], target.String())
+			}
+		}
+	}
+
+	resp, err := NewResponse(*req.ID, result)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Failed to create response", nil)
+	}
+	return resp
+}
+
+// handleGetConfig handles the daemon/config request.
+func (h *Handler) handleGetConfig(req *Request) *Response {
+	result := GetConfigResult{
+		Version:         h.server.version,
+		PID:             os.Getpid(),
+		GazelleDefaults: h.defaults,
+		Debounce:        DefaultDebounce,
+		Backends:        []string{},
+		TreeCacheSize:   h.trees.capacity,
+		WarmIndex:       h.warmIndex,
+	}
+	if paths := h.server.paths; paths != nil {
+		result.Dir = paths.Dir
+		result.Socket = paths.Socket
+		result.PIDFile = paths.PID
+		result.LogFile = paths.Log
+	}
+	for _, lang := range h.languages {
+		result.Languages = append(result.Languages, lang.Name())
+	}
+	for _, b := range treesitter.AvailableBackends() {
+		result.Backends = append(result.Backends, string(b))
+	}
+
+	h.watchMu.RLock()
+	if h.watching {
+		result.Watching = true
+		result.WatchPaths = slices.Clone(h.watchPaths)
+		result.Debounce = h.debounce
+	}
+	h.watchMu.RUnlock()
+
+	resp, err := NewResponse(*req.ID, result)
+	if err != nil {
+		return NewErrorResponse(req.ID, ErrCodeInternalError, "Failed to create response", nil)
+	}
+	return resp
+}
+
 // Stop stops the handler and any running watcher.
 func (h *Handler) Stop() {
 	h.watchMu.Lock()
@@ -330,6 +627,10 @@ func (h *Handler) Stop() {
 	h.watching = false
 	h.watcher = nil
 	h.watchCancel = nil
+
+	if h.trees != nil {
+		h.trees.Purge()
+	}
 }
 
 // BroadcastEvent broadcasts a watch event to all subscribed clients.
@@ -351,3 +652,54 @@ func (h *Handler) BroadcastEvent(eventType string, dirs []string, files []string
 
 	h.server.Broadcast(notif)
 }
+
+// broadcastUpdated broadcasts an "update" watch/event listing dirs, the
+// packages a watch cycle regenerated.
+func (h *Handler) broadcastUpdated(dirs []string) {
+	h.BroadcastEvent("update", dirs, nil, "")
+}
+
+// BroadcastProgress broadcasts an update/progress notification to all
+// subscribed clients.
+func (h *Handler) BroadcastProgress(ev progress.Event) {
+	if h.server == nil {
+		return
+	}
+
+	notif, err := newProgressNotification(ev)
+	if err != nil {
+		return
+	}
+
+	h.server.Broadcast(notif)
+}
+
+// sendProgress returns a progress callback that sends client one
+// update/progress notification per directory, when the first language
+// starts on it. Failed sends are ignored; the update carries on.
+func (h *Handler) sendProgress(client *ClientConn) progress.Func {
+	done := 0
+	return func(ev progress.Event) {
+		if ev.Done == done {
+			return
+		}
+		done = ev.Done
+
+		notif, err := newProgressNotification(ev)
+		if err != nil {
+			return
+		}
+		_ = client.Send(notif)
+	}
+}
+
+// newProgressNotification creates the update/progress notification for ev.
+func newProgressNotification(ev progress.Event) (*Notification, error) {
+	return NewNotification(MethodUpdateProgress, UpdateProgressParams{
+		Done:      ev.Done,
+		Total:     ev.Total,
+		Directory: ev.Dir,
+		Language:  ev.Language,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
@@ -1,9 +1,22 @@
 package daemon
 
 import (
+	"context"
 	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/incremental"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/progress"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/watch"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	golang "github.com/bazelbuild/bazel-gazelle/language/go"
+	"github.com/bazelbuild/bazel-gazelle/language/proto"
 )
 
 func TestNewHandler(t *testing.T) {
@@ -84,6 +97,18 @@ func TestHandler_HandleRequest_MethodNotFound(t *testing.T) {
 	}
 }
 
+func TestHandler_Notification(t *testing.T) {
+	t.Parallel()
+	handler := NewHandler(&Server{startTime: time.Now(), version: "1.0.0"})
+
+	for _, method := range []string{MethodPing, MethodWatchStatus, "unknown/method"} {
+		req := &Request{JSONRPC: JSONRPCVersion, Method: method}
+		if resp := handler.HandleRequest(&ClientConn{}, req); resp != nil {
+			t.Errorf("HandleRequest(%s notification) = %+v, want no response", method, resp)
+		}
+	}
+}
+
 func TestHandler_HandlePing(t *testing.T) {
 	t.Parallel()
 	startTime := time.Now().Add(-1 * time.Hour)
@@ -314,7 +339,7 @@ func TestHandler_HandleStatusGet(t *testing.T) {
 	}
 }
 
-func TestHandler_HandleUpdateRun_NotImplemented(t *testing.T) {
+func TestHandler_HandleUpdateRun_NotWatching(t *testing.T) {
 	t.Parallel()
 	server := &Server{
 		startTime: time.Now(),
@@ -333,11 +358,44 @@ func TestHandler_HandleUpdateRun_NotImplemented(t *testing.T) {
 	if resp == nil {
 		t.Fatal("Response should not be nil")
 	}
-	// Currently returns error (not implemented)
-	if resp.Error == nil {
-		t.Log("UpdateRun is now implemented")
-	} else if resp.Error.Code != ErrCodeInternalError {
-		t.Errorf("Error code = %d, want %d", resp.Error.Code, ErrCodeInternalError)
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+
+	var result UpdateRunResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if result.Status != "not_watching" {
+		t.Errorf("Status = %q, want not_watching", result.Status)
+	}
+}
+
+func TestUpdateRunDirs(t *testing.T) {
+	t.Parallel()
+	root := filepath.Join(string(filepath.Separator), "ws")
+	tests := []struct {
+		name    string
+		params  UpdateRunParams
+		want    []string
+		wantErr bool
+	}{
+		{name: "full", want: []string{"."}},
+		{name: "incremental", params: UpdateRunParams{Incremental: true}, want: nil},
+		{name: "relative", params: UpdateRunParams{Paths: []string{"src/a/", "b"}}, want: []string{filepath.Join("src", "a"), "b"}},
+		{name: "absolute", params: UpdateRunParams{Paths: []string{filepath.Join(root, "lib")}}, want: []string{"lib"}},
+		{name: "outside", params: UpdateRunParams{Paths: []string{"../other"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := updateRunDirs(root, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("updateRunDirs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("updateRunDirs() = %v, want %v", got, tt.want)
+			}
+		})
 	}
 }
 
@@ -369,6 +427,33 @@ func TestHandler_HandleWatchStart_InvalidParams(t *testing.T) {
 	}
 }
 
+func TestHandler_HandleWatchStart_UnknownEventMode(t *testing.T) {
+	t.Parallel()
+	server := &Server{
+		startTime: time.Now(),
+		version:   "1.0.0",
+	}
+	handler := NewHandler(server)
+
+	req := &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(1)),
+		Method:  MethodWatchStart,
+		Params:  json.RawMessage(`{"paths":["."],"events":"batched"}`),
+	}
+
+	resp := handler.HandleRequest(&ClientConn{}, req)
+	if resp == nil || resp.Error == nil {
+		t.Fatalf("Expected error for unknown event mode, got %+v", resp)
+	}
+	if resp.Error.Code != ErrCodeInvalidParams {
+		t.Errorf("Error code = %d, want %d", resp.Error.Code, ErrCodeInvalidParams)
+	}
+	if handler.watching {
+		t.Error("handler started watching despite invalid params")
+	}
+}
+
 func TestHandler_HandleUpdateRun_InvalidParams(t *testing.T) {
 	t.Parallel()
 	server := &Server{
@@ -494,6 +579,40 @@ func TestHandler_BroadcastEvent(t *testing.T) {
 	handler.BroadcastEvent("change", []string{"src"}, []string{"main.go"}, "file changed")
 }
 
+func TestHandler_BroadcastProgress(t *testing.T) {
+	t.Parallel()
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	cc := &ClientConn{
+		conn:       serverConn,
+		encoder:    json.NewEncoder(serverConn),
+		subscribed: true,
+	}
+	server := &Server{clients: map[*ClientConn]struct{}{cc: {}}}
+	handler := &Handler{server: server}
+
+	go handler.BroadcastProgress(progress.Event{Done: 3, Total: 10, Dir: "src/app", Language: "kotlin"})
+
+	_ = clientConn.SetReadDeadline(time.Now().Add(time.Second))
+	var notif Notification
+	if err := json.NewDecoder(clientConn).Decode(&notif); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if notif.Method != MethodUpdateProgress {
+		t.Errorf("Method = %q, want %q", notif.Method, MethodUpdateProgress)
+	}
+
+	var params UpdateProgressParams
+	if err := json.Unmarshal(notif.Params, &params); err != nil {
+		t.Fatalf("Unmarshal params: %v", err)
+	}
+	if params.Done != 3 || params.Total != 10 || params.Directory != "src/app" || params.Language != "kotlin" {
+		t.Errorf("params = %+v", params)
+	}
+}
+
 func TestHandler_AllMethods(t *testing.T) {
 	t.Parallel()
 	// Methods that don't require a client connection
@@ -503,7 +622,11 @@ func TestHandler_AllMethods(t *testing.T) {
 		MethodWatchStop,
 		MethodWatchStatus,
 		MethodUpdateRun,
+		MethodUpdateDryRun,
 		MethodStatusGet,
+		MethodReindex,
+		MethodDumpIndex,
+		MethodGetConfig,
 	}
 
 	server := &Server{
@@ -513,6 +636,12 @@ func TestHandler_AllMethods(t *testing.T) {
 	}
 	handler := NewHandler(server)
 
+	// Watch a scratch root so the watcher's state file stays out of the
+	// source tree. The watcher stops before the root is removed.
+	root := t.TempDir()
+	t.Cleanup(handler.Stop)
+	watchParams, _ := json.Marshal(WatchStartParams{Paths: []string{root}})
+
 	for _, method := range safeMethods {
 		t.Run(method, func(t *testing.T) {
 			// Skip parallel for shutdown as it modifies shared state
@@ -543,6 +672,7 @@ func TestHandler_AllMethods(t *testing.T) {
 			JSONRPC: JSONRPCVersion,
 			ID:      ptr(int64(1)),
 			Method:  MethodWatchStart,
+			Params:  watchParams,
 		}
 		// This may fail due to watcher creation, but should not panic
 		defer func() {
@@ -679,3 +809,456 @@ func TestHandler_HandleWatchStop_WithWatcher(t *testing.T) {
 		t.Error("watching should be false after stop")
 	}
 }
+
+func TestHandler_HandleReindex_NotWatching(t *testing.T) {
+	t.Parallel()
+	handler := NewHandler(&Server{startTime: time.Now()})
+
+	req := &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(1)),
+		Method:  MethodReindex,
+	}
+
+	resp := handler.HandleRequest(&ClientConn{}, req)
+	if resp == nil {
+		t.Fatal("Response should not be nil")
+	}
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+
+	var result ReindexResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if result.Status != "not_watching" {
+		t.Errorf("Status = %q, want %q", result.Status, "not_watching")
+	}
+}
+
+func TestHandler_HandleReindex_UpdatesFileCount(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	writeFile := func(rel string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("main.go")
+
+	w, err := watch.New(watch.Config{Root: root, NoColor: true})
+	if err != nil {
+		t.Fatalf("watch.New() error = %v", err)
+	}
+	defer w.Close()
+
+	handler := NewHandler(&Server{startTime: time.Now()})
+	handler.watcher = w
+	handler.watching = true
+	handler.watchPaths = []string{root}
+
+	reindex := func() ReindexResult {
+		t.Helper()
+		req := &Request{
+			JSONRPC: JSONRPCVersion,
+			ID:      ptr(int64(1)),
+			Method:  MethodReindex,
+		}
+		resp := handler.HandleRequest(&ClientConn{}, req)
+		if resp == nil || resp.Error != nil {
+			t.Fatalf("reindex failed: %+v", resp)
+		}
+		var result ReindexResult
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatalf("Failed to unmarshal result: %v", err)
+		}
+		return result
+	}
+
+	if got := reindex(); got.Status != "reindexed" || got.FileCount != 1 {
+		t.Fatalf("first reindex = %+v, want reindexed with 1 file", got)
+	}
+
+	// Files added out-of-band (no fsnotify events processed).
+	writeFile("lib/a.go")
+	writeFile("lib/b.go")
+
+	if got := reindex(); got.FileCount != 3 {
+		t.Errorf("FileCount after reindex = %d, want 3", got.FileCount)
+	}
+
+	status := handler.GetWatchStatus()
+	if !status.Watching {
+		t.Error("watch session should survive reindex")
+	}
+	if status.FileCount != 3 {
+		t.Errorf("watch/status FileCount = %d, want 3", status.FileCount)
+	}
+}
+
+func TestHandler_HandleDumpIndex_NotWatching(t *testing.T) {
+	t.Parallel()
+	handler := NewHandler(&Server{startTime: time.Now()})
+
+	req := &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(1)),
+		Method:  MethodDumpIndex,
+	}
+
+	resp := handler.HandleRequest(&ClientConn{}, req)
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("dump index failed: %+v", resp)
+	}
+
+	var result DumpIndexResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if result.Status != "not_watching" {
+		t.Errorf("Status = %q, want %q", result.Status, "not_watching")
+	}
+}
+
+func TestHandler_HandleDumpIndex(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	for _, rel := range []string{"main.go", "lib/a.go"} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := watch.New(watch.Config{Root: root, NoColor: true})
+	if err != nil {
+		t.Fatalf("watch.New() error = %v", err)
+	}
+	defer w.Close()
+	if _, err := w.Reindex(context.Background()); err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+
+	handler := NewHandler(&Server{startTime: time.Now()})
+	handler.watcher = w
+	handler.watching = true
+	handler.watchPaths = []string{root}
+
+	req := &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(1)),
+		Method:  MethodDumpIndex,
+	}
+	resp := handler.HandleRequest(&ClientConn{}, req)
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("dump index failed: %+v", resp)
+	}
+
+	var result DumpIndexResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+
+	want := map[string]string{"main.go": "//", "lib/a.go": "//lib"}
+	if result.Status != "ok" || !reflect.DeepEqual(result.Files, want) {
+		t.Errorf("result = %+v, want status ok with files %v", result, want)
+	}
+	if !reflect.DeepEqual(result.Roots, []string{root}) {
+		t.Errorf("Roots = %v, want [%s]", result.Roots, root)
+	}
+}
+
+func TestHandler_WatchStart_WarmIndex(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	path := filepath.Join(root, "lib", "Util.kt")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("package com.example.util\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandlerWithConfig(&Server{startTime: time.Now()}, HandlerConfig{WarmIndex: true})
+	defer handler.Stop()
+
+	params, _ := json.Marshal(WatchStartParams{Paths: []string{root}, Languages: []string{"kotlin"}})
+	resp := handler.HandleRequest(&ClientConn{}, &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(1)),
+		Method:  MethodWatchStart,
+		Params:  params,
+	})
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("watch start failed: %+v", resp)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		state := handler.GetWatchStatus().State
+		if state == string(watch.StateReady) {
+			break
+		}
+		if state != string(watch.StateStarting) && state != string(watch.StateIndexing) {
+			t.Fatalf("State = %q while warming, want starting or indexing", state)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("watcher never became ready; state %q", state)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Once ready is reported, the symbol index must be populated.
+	resp = handler.HandleRequest(&ClientConn{}, &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(2)),
+		Method:  MethodDumpIndex,
+	})
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("dump index failed: %+v", resp)
+	}
+	var result DumpIndexResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	want := map[string][]string{"com.example.util": {"//lib"}}
+	if !reflect.DeepEqual(result.Symbols, want) {
+		t.Errorf("Symbols = %v, want %v", result.Symbols, want)
+	}
+}
+
+func TestHandler_HandleGetConfig_Watching(t *testing.T) {
+	t.Parallel()
+	handler := NewHandler(&Server{startTime: time.Now(), version: "1.0.0"})
+
+	// Simulate a watcher started with a custom debounce
+	handler.watching = true
+	handler.watchPaths = []string{"/ws"}
+	handler.debounce = 200
+
+	req := &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(1)),
+		Method:  MethodGetConfig,
+	}
+
+	resp := handler.HandleRequest(&ClientConn{}, req)
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("get config failed: %+v", resp)
+	}
+
+	var result GetConfigResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if result.Version != "1.0.0" {
+		t.Errorf("Version = %q, want %q", result.Version, "1.0.0")
+	}
+	if !result.Watching || len(result.WatchPaths) != 1 || result.WatchPaths[0] != "/ws" {
+		t.Errorf("Watching = %v, WatchPaths = %v, want watching /ws", result.Watching, result.WatchPaths)
+	}
+	if result.Debounce != 200 {
+		t.Errorf("Debounce = %d, want 200", result.Debounce)
+	}
+	if result.TreeCacheSize != DefaultTreeCacheSize {
+		t.Errorf("TreeCacheSize = %d, want %d", result.TreeCacheSize, DefaultTreeCacheSize)
+	}
+}
+
+func TestHandler_HandleUpdateDryRun_NotWatching(t *testing.T) {
+	t.Parallel()
+	handler := NewHandler(&Server{startTime: time.Now()})
+
+	req := &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(1)),
+		Method:  MethodUpdateDryRun,
+	}
+
+	resp := handler.HandleRequest(&ClientConn{}, req)
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("dry run failed: %+v", resp)
+	}
+
+	var result UpdateDryRunResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+	if result.Status != "not_watching" {
+		t.Errorf("Status = %q, want %q", result.Status, "not_watching")
+	}
+}
+
+func TestHandler_HandleUpdateDryRun(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("MODULE.bazel", "")
+	write("lib/a.go", "package lib\n")
+	write("lib/BUILD.bazel", "# lib\n")
+	write("main.go", "package main\n")
+
+	w, err := watch.New(watch.Config{
+		Root:            root,
+		Languages:       []language.Language{proto.NewLanguage(), golang.NewLanguage()},
+		GazelleDefaults: []string{"-repo_root=" + root, "-go_prefix=example.com/ws"},
+		NoColor:         true,
+	})
+	if err != nil {
+		t.Fatalf("watch.New() error = %v", err)
+	}
+	defer w.Close()
+	// Record the sources as up to date, as an update would
+	if err := incremental.NewTracker(root, nil).Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	// Make lib stale and add a new package without a BUILD file.
+	write("lib/a.go", "package lib\n\nfunc A() {}\n")
+	write("cmd/tool/main.go", "package main\n")
+	buildBefore, _ := os.ReadFile(filepath.Join(root, "lib/BUILD.bazel"))
+
+	handler := NewHandler(&Server{startTime: time.Now()})
+	handler.watcher = w
+	handler.watching = true
+
+	req := &Request{
+		JSONRPC: JSONRPCVersion,
+		ID:      ptr(int64(1)),
+		Method:  MethodUpdateDryRun,
+	}
+	resp := handler.HandleRequest(&ClientConn{}, req)
+	if resp == nil || resp.Error != nil {
+		t.Fatalf("dry run failed: %+v", resp)
+	}
+
+	var result UpdateDryRunResult
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		t.Fatalf("Failed to unmarshal result: %v", err)
+	}
+
+	// Each package carries the diff gazelle would apply to its BUILD file.
+	for i, diff := range []struct{ from, to string }{
+		{"--- /dev/null\n", "+++ cmd/tool/BUILD.bazel\n"},
+		{"--- lib/BUILD.bazel\n", "+++ lib/BUILD.bazel\n"},
+	} {
+		if i >= len(result.Packages) {
+			break
+		}
+		got := result.Packages[i].Diff
+		if !strings.HasPrefix(got, diff.from+diff.to) || !strings.Contains(got, "+go_library(") {
+			t.Errorf("Packages[%d].Diff = %q, want a diff adding a go_library", i, got)
+		}
+		result.Packages[i].Diff = ""
+	}
+
+	want := UpdateDryRunResult{
+		Status: "ok",
+		Packages: []PlannedChange{
+			{
+				Package:      "//cmd/tool",
+				BuildFile:    "cmd/tool/BUILD.bazel",
+				NewBuildFile: true,
+				Added:        []string{"cmd/tool/main.go"},
+			},
+			{
+				Package:   "//lib",
+				BuildFile: "lib/BUILD.bazel",
+				Modified:  []string{"lib/a.go"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result = %+v, want %+v", result, want)
+	}
+
+	// A dry run writes nothing.
+	if _, err := os.Stat(filepath.Join(root, "cmd/tool/BUILD.bazel")); !os.IsNotExist(err) {
+		t.Errorf("dry run created a BUILD file (stat err = %v)", err)
+	}
+	if buildAfter, _ := os.ReadFile(filepath.Join(root, "lib/BUILD.bazel")); string(buildAfter) != string(buildBefore) {
+		t.Error("dry run modified lib/BUILD.bazel")
+	}
+}
+
+func TestHandler_HandleUpdateRun_NoChanges(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	for rel, content := range map[string]string{
+		"MODULE.bazel": "",
+		"lib/a.go":     "package lib\n",
+	} {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := watch.New(watch.Config{
+		Root:            root,
+		Languages:       []language.Language{proto.NewLanguage(), golang.NewLanguage()},
+		GazelleDefaults: []string{"-repo_root=" + root, "-go_prefix=example.com/ws"},
+		NoColor:         true,
+	})
+	if err != nil {
+		t.Fatalf("watch.New() error = %v", err)
+	}
+	defer w.Close()
+
+	handler := NewHandler(&Server{startTime: time.Now()})
+	handler.watcher = w
+	handler.watching = true
+	handler.watchPaths = []string{root}
+
+	run := func() UpdateRunResult {
+		t.Helper()
+		req := &Request{
+			JSONRPC: JSONRPCVersion,
+			ID:      ptr(int64(1)),
+			Method:  MethodUpdateRun,
+			Params:  json.RawMessage(`{"paths":["lib"]}`),
+		}
+		// A closed connection drops the progress notifications.
+		resp := handler.HandleRequest(&ClientConn{closed: true}, req)
+		if resp == nil || resp.Error != nil {
+			t.Fatalf("update run failed: %+v", resp)
+		}
+		var result UpdateRunResult
+		if err := json.Unmarshal(resp.Result, &result); err != nil {
+			t.Fatalf("Failed to unmarshal result: %v", err)
+		}
+		return result
+	}
+
+	first := run()
+	if first.Status != "ok" || !first.Changed || !reflect.DeepEqual(first.UpdatedDirs, []string{"lib"}) {
+		t.Errorf("first run = %+v, want status ok, changed, for [lib]", first)
+	}
+
+	// lib/BUILD.bazel is now current, so the same run changes nothing.
+	second := run()
+	if second.Status != "up_to_date" || second.Changed || len(second.UpdatedDirs) != 0 {
+		t.Errorf("second run = %+v, want status up_to_date, unchanged, no updated dirs", second)
+	}
+}
@@ -19,8 +19,9 @@
 //
 // All communication uses JSON-RPC 2.0 with newline-delimited messages.
 // Supported methods: ping, shutdown, watch/start, watch/stop, watch/status,
-// update/run, status/get. The server sends watch/event notifications to
-// subscribed clients when file changes are detected.
+// update/run, update/dry-run, status/get. The server sends watch/event notifications to
+// subscribed clients when file changes are detected, and update/progress
+// notifications while the resulting update runs.
 //
 // # Usage
 //
@@ -173,14 +174,19 @@ func NewErrorResponse(id *int64, code int, message string, data any) *Response {
 
 // Standard RPC methods.
 const (
-	MethodPing        = "ping"
-	MethodShutdown    = "shutdown"
-	MethodWatchStart  = "watch/start"
-	MethodWatchStop   = "watch/stop"
-	MethodWatchStatus = "watch/status"
-	MethodWatchEvent  = "watch/event" // notification from server to client
-	MethodUpdateRun   = "update/run"
-	MethodStatusGet   = "status/get"
+	MethodPing           = "ping"
+	MethodShutdown       = "shutdown"
+	MethodWatchStart     = "watch/start"
+	MethodWatchStop      = "watch/stop"
+	MethodWatchStatus    = "watch/status"
+	MethodWatchEvent     = "watch/event" // notification from server to client
+	MethodUpdateRun      = "update/run"
+	MethodUpdateProgress = "update/progress" // notification from server to client
+	MethodUpdateDryRun   = "update/dry-run"
+	MethodStatusGet      = "status/get"
+	MethodReindex        = "daemon/reindex"
+	MethodDumpIndex      = "daemon/index"
+	MethodGetConfig      = "daemon/config"
 )
 
 // PingResult is the response to a ping request.
@@ -201,6 +207,7 @@ type WatchStartParams struct {
 	Paths     []string `json:"paths,omitempty"`
 	Languages []string `json:"languages,omitempty"`
 	Debounce  int      `json:"debounce,omitempty"` // milliseconds
+	Events    string   `json:"events,omitempty"`   // "per-package" (default) or "consolidated"
 }
 
 // WatchStartResult is the response to watch/start.
@@ -222,6 +229,7 @@ type WatchStatusResult struct {
 	Languages  []string `json:"languages,omitempty"`
 	FileCount  int      `json:"file_count,omitempty"`
 	UpdateTime string   `json:"update_time,omitempty"` // time of last update
+	State      string   `json:"state,omitempty"`       // watcher state: "starting", "indexing" or "ready"
 }
 
 // WatchEventParams are the parameters for watch/event notifications.
@@ -233,6 +241,16 @@ type WatchEventParams struct {
 	Timestamp   string   `json:"timestamp"`
 }
 
+// UpdateProgressParams are the parameters for update/progress notifications,
+// sent as gazelle works through the directories of an update cycle.
+type UpdateProgressParams struct {
+	Done      int    `json:"done"`  // directories started so far
+	Total     int    `json:"total"` // expected directories; may grow
+	Directory string `json:"directory"`
+	Language  string `json:"language"`
+	Timestamp string `json:"timestamp"`
+}
+
 // UpdateRunParams are the parameters for update/run.
 type UpdateRunParams struct {
 	Paths       []string `json:"paths,omitempty"`
@@ -241,9 +259,40 @@ type UpdateRunParams struct {
 
 // UpdateRunResult is the response to update/run.
 type UpdateRunResult struct {
-	Status      string   `json:"status"`
+	// Status is "ok" when BUILD files changed, "up_to_date" when the run
+	// left all of them as they were, and "not_watching" without a watch.
+	Status string `json:"status"`
+
+	// Changed reports whether the run modified any BUILD file.
+	Changed bool `json:"changed"`
+
+	// UpdatedDirs are the directories, relative to the watch root, whose
+	// BUILD file the run modified; empty when Changed is false.
 	UpdatedDirs []string `json:"updated_dirs,omitempty"`
 	Duration    string   `json:"duration,omitempty"`
+
+	// Warnings are the warnings logged during the update, such as imports
+	// resolved ambiguously or Python files that modify sys.path.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// UpdateDryRunResult is the response to update/dry-run: what the next update
+// cycle would regenerate for the daemon's current stale set. Nothing is
+// written.
+type UpdateDryRunResult struct {
+	Status   string          `json:"status"` // "ok" or "not_watching"
+	Packages []PlannedChange `json:"packages,omitempty"`
+}
+
+// PlannedChange is one package in an update/dry-run preview.
+type PlannedChange struct {
+	Package      string   `json:"package"`    // e.g. "//src/auth"
+	BuildFile    string   `json:"build_file"` // relative to the watch root
+	NewBuildFile bool     `json:"new_build_file,omitempty"`
+	Added        []string `json:"added,omitempty"`
+	Modified     []string `json:"modified,omitempty"`
+	Deleted      []string `json:"deleted,omitempty"`
+	Diff         string   `json:"diff,omitempty"` // unified diff of BuildFile against disk
 }
 
 // StatusGetResult is the response to status/get.
@@ -252,6 +301,41 @@ type StatusGetResult struct {
 	StaleDirs []string `json:"stale_dirs,omitempty"`
 }
 
+// ReindexResult is the response to daemon/reindex.
+type ReindexResult struct {
+	Status    string `json:"status"` // "reindexed" or "not_watching"
+	FileCount int    `json:"file_count"`
+	Duration  string `json:"duration,omitempty"`
+}
+
+// DumpIndexResult is the response to daemon/index.
+// It exposes the incremental file index for debugging.
+type DumpIndexResult struct {
+	Status  string              `json:"status"` // "ok" or "not_watching"
+	Roots   []string            `json:"roots,omitempty"`
+	Files   map[string]string   `json:"files,omitempty"`   // file path -> package label
+	Symbols map[string][]string `json:"symbols,omitempty"` // source package -> target labels, if warmed
+}
+
+// GetConfigResult is the response to daemon/config: the daemon's effective
+// runtime configuration, for checking that flags and environment took effect.
+type GetConfigResult struct {
+	Version         string   `json:"version"`
+	PID             int      `json:"pid"`
+	Dir             string   `json:"dir,omitempty"`
+	Socket          string   `json:"socket,omitempty"`
+	PIDFile         string   `json:"pid_file,omitempty"`
+	LogFile         string   `json:"log_file,omitempty"`
+	Languages       []string `json:"languages,omitempty"`
+	GazelleDefaults []string `json:"gazelle_defaults,omitempty"`
+	Debounce        int      `json:"debounce"`        // milliseconds; the running watcher's, else the default
+	Backends        []string `json:"backends"`        // available tree-sitter backends
+	TreeCacheSize   int      `json:"tree_cache_size"` // max cached parse trees
+	WarmIndex       bool     `json:"warm_index"`      // build the symbol index when a watch starts
+	Watching        bool     `json:"watching"`
+	WatchPaths      []string `json:"watch_paths,omitempty"`
+}
+
 // IDGenerator generates unique request IDs.
 type IDGenerator struct {
 	counter atomic.Int64
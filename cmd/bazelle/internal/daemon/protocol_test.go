@@ -625,7 +625,11 @@ func TestMethodConstants(t *testing.T) {
 		MethodWatchStatus,
 		MethodWatchEvent,
 		MethodUpdateRun,
+		MethodUpdateProgress,
+		MethodUpdateDryRun,
 		MethodStatusGet,
+		MethodReindex,
+		MethodDumpIndex,
 	}
 
 	seen := make(map[string]bool)
@@ -656,6 +660,8 @@ func TestParamStructsSerialization(t *testing.T) {
 		{"UpdateRunParams", UpdateRunParams{Paths: []string{"."}, Incremental: true}},
 		{"UpdateRunResult", UpdateRunResult{Status: "success", UpdatedDirs: []string{"src"}}},
 		{"StatusGetResult", StatusGetResult{Stale: true, StaleDirs: []string{"pkg"}}},
+		{"ReindexResult", ReindexResult{Status: "reindexed", FileCount: 42}},
+		{"DumpIndexResult", DumpIndexResult{Status: "ok", Roots: []string{"/ws"}, Files: map[string]string{"a/b.go": "//a"}}},
 		{"DaemonInfo", DaemonInfo{PID: 1234, SocketPath: "/tmp/daemon.sock", Version: "1.0"}},
 	}
 	for _, tt := range tests {
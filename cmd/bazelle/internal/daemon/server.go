@@ -214,8 +214,12 @@ func (s *Server) handleClient(client *ClientConn) {
 			continue
 		}
 
-		// Validate JSON-RPC version
+		// Validate JSON-RPC version; a bad notification is dropped unanswered
 		if req.JSONRPC != JSONRPCVersion {
+			if req.ID == nil {
+				logger.Debugw("dropping notification with unsupported JSON-RPC version", "method", req.Method)
+				continue
+			}
 			resp := NewErrorResponse(req.ID, ErrCodeInvalidRequest, "Invalid Request: unsupported JSON-RPC version", nil)
 			if err := client.Send(resp); err != nil {
 				logger.Debugw("failed to send error response", "error", err)
@@ -223,7 +227,7 @@ func (s *Server) handleClient(client *ClientConn) {
 			continue
 		}
 
-		// Handle the request
+		// Handle the request; notifications yield no response
 		resp := s.handler.HandleRequest(client, &req)
 		if resp != nil {
 			if err := client.Send(resp); err != nil {
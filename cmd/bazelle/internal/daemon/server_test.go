@@ -8,10 +8,16 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/bazelbuild/bazel-gazelle/language"
+	golang "github.com/bazelbuild/bazel-gazelle/language/go"
+	"github.com/bazelbuild/bazel-gazelle/language/proto"
 )
 
 // waitForSocketReady waits for a Unix socket to become available.
@@ -340,6 +346,68 @@ func TestServer_ConcurrentClients(t *testing.T) {
 	<-errCh
 }
 
+func TestServer_GetConfig(t *testing.T) {
+	t.Parallel()
+	tmpDir := shortTempDirServer(t)
+	paths := &Paths{
+		Dir:    tmpDir,
+		Socket: filepath.Join(tmpDir, "daemon.sock"),
+		PID:    filepath.Join(tmpDir, "daemon.pid"),
+		Log:    filepath.Join(tmpDir, "daemon.log"),
+	}
+
+	handler := NewHandlerWithConfig(nil, HandlerConfig{
+		GazelleDefaults: []string{"-mode=fix"},
+		TreeCacheSize:   64,
+	})
+	server := NewServer(ServerConfig{Paths: paths, Version: "2.3.4", Handler: handler})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start(ctx)
+	}()
+	if !waitForSocketReady(paths.Socket, 2*time.Second) {
+		t.Fatal("server did not start")
+	}
+
+	client, err := Connect(paths.Socket)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+		return // for nilaway
+	}
+	defer client.Close()
+
+	cfg, err := client.GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig() error = %v", err)
+	}
+
+	if cfg.Version != "2.3.4" {
+		t.Errorf("Version = %q, want %q", cfg.Version, "2.3.4")
+	}
+	if cfg.PID != os.Getpid() {
+		t.Errorf("PID = %d, want %d", cfg.PID, os.Getpid())
+	}
+	if cfg.Dir != paths.Dir || cfg.Socket != paths.Socket || cfg.PIDFile != paths.PID || cfg.LogFile != paths.Log {
+		t.Errorf("paths = %q, %q, %q, %q, want %+v", cfg.Dir, cfg.Socket, cfg.PIDFile, cfg.LogFile, paths)
+	}
+	if len(cfg.GazelleDefaults) != 1 || cfg.GazelleDefaults[0] != "-mode=fix" {
+		t.Errorf("GazelleDefaults = %v, want [-mode=fix]", cfg.GazelleDefaults)
+	}
+	if cfg.TreeCacheSize != 64 {
+		t.Errorf("TreeCacheSize = %d, want 64", cfg.TreeCacheSize)
+	}
+	if cfg.Watching || cfg.Debounce != DefaultDebounce {
+		t.Errorf("Watching = %v, Debounce = %d, want not watching with default debounce", cfg.Watching, cfg.Debounce)
+	}
+
+	cancel()
+	<-errCh
+}
+
 func TestServer_MalformedRequest(t *testing.T) {
 	t.Parallel()
 	tmpDir := shortTempDirServer(t)
@@ -448,6 +516,179 @@ func TestServer_InvalidJSONRPCVersion(t *testing.T) {
 	<-errCh
 }
 
+func TestServer_ClientNotification(t *testing.T) {
+	t.Parallel()
+	tmpDir := shortTempDirServer(t)
+	paths := &Paths{
+		Dir:    tmpDir,
+		Socket: filepath.Join(tmpDir, "daemon.sock"),
+		PID:    filepath.Join(tmpDir, "daemon.pid"),
+		Log:    filepath.Join(tmpDir, "daemon.log"),
+	}
+
+	server := NewServer(ServerConfig{Paths: paths, Version: "1.0.0"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start(ctx)
+	}()
+
+	if !waitForSocketReady(paths.Socket, 2*time.Second) {
+		t.Fatal("Server did not start in time")
+	}
+
+	conn, err := net.DialTimeout("unix", paths.Socket, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer conn.Close()
+
+	// Notifications, including one for an unknown method, get no response:
+	// the first response read is the one for the ping request after them.
+	encoder := json.NewEncoder(conn)
+	for _, method := range []string{MethodPing, "no/such/method"} {
+		notif, err := NewNotification(method, nil)
+		if err != nil {
+			t.Fatalf("NewNotification error: %v", err)
+		}
+		if err := encoder.Encode(notif); err != nil {
+			t.Fatalf("Encode error: %v", err)
+		}
+	}
+	req, err := NewRequest(7, MethodPing, nil)
+	if err != nil {
+		t.Fatalf("NewRequest error: %v", err)
+	}
+	if err := encoder.Encode(req); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var resp Response
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if resp.ID == nil || *resp.ID != 7 {
+		t.Fatalf("first response ID = %v, want 7: a notification was answered", resp.ID)
+	}
+
+	// A notification is still processed: shutdown stops the server.
+	client, err := Connect(paths.Socket)
+	if err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer client.Close()
+	if err := client.Notify(MethodShutdown, nil); err != nil {
+		t.Fatalf("Notify error: %v", err)
+	}
+
+	select {
+	case <-errCh:
+		// Successfully shut down
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown notification did not stop the server")
+	}
+}
+
+func TestServer_UpdateRunStreamsProgress(t *testing.T) {
+	t.Parallel()
+	root := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		src := []byte("package " + dir + "\n")
+		if err := os.WriteFile(filepath.Join(root, dir, dir+".go"), src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "MODULE.bazel"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := shortTempDirServer(t)
+	paths := &Paths{
+		Dir:    tmpDir,
+		Socket: filepath.Join(tmpDir, "daemon.sock"),
+		PID:    filepath.Join(tmpDir, "daemon.pid"),
+		Log:    filepath.Join(tmpDir, "daemon.log"),
+	}
+
+	handler := NewHandler(nil)
+	handler.SetLanguages([]language.Language{proto.NewLanguage(), golang.NewLanguage()})
+	handler.SetDefaults([]string{"-repo_root=" + root, "-go_prefix=example.com/ws"})
+	defer handler.Stop()
+	server := NewServer(ServerConfig{Paths: paths, Version: "1.0.0", Handler: handler})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Start(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-errCh
+	}()
+
+	if !waitForSocketReady(paths.Socket, 2*time.Second) {
+		t.Fatal("Server did not start in time")
+	}
+
+	client, err := Connect(paths.Socket)
+	if err != nil {
+		t.Fatalf("Connect error: %v", err)
+	}
+	defer client.Close()
+
+	events, err := client.SubscribeEvents()
+	if err != nil {
+		t.Fatalf("SubscribeEvents error: %v", err)
+	}
+	if _, err := client.WatchStart(&WatchStartParams{Paths: []string{root}, Languages: []string{"go"}}); err != nil {
+		t.Fatalf("WatchStart error: %v", err)
+	}
+
+	result, err := client.UpdateRun(&UpdateRunParams{Paths: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("UpdateRun error: %v", err)
+	}
+	if result.Status != "ok" || !reflect.DeepEqual(result.UpdatedDirs, []string{"a", "b"}) {
+		t.Errorf("UpdateRun() = %+v, want status ok for [a b]", result)
+	}
+
+	// The progress notifications came before the result, so they are
+	// already queued.
+	var dirs []string
+	for len(events) > 0 {
+		notif := <-events
+		if notif.Method != MethodUpdateProgress {
+			continue
+		}
+		var params UpdateProgressParams
+		if err := json.Unmarshal(notif.Params, &params); err != nil {
+			t.Fatalf("Unmarshal progress: %v", err)
+		}
+		if params.Done != len(dirs)+1 {
+			t.Errorf("progress for %s: Done = %d, want %d", params.Directory, params.Done, len(dirs)+1)
+		}
+		dirs = append(dirs, params.Directory)
+	}
+	slices.Sort(dirs)
+	if want := []string{"a", "b"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("progress directories = %v, want %v", dirs, want)
+	}
+
+	for _, dir := range []string{"a", "b"} {
+		if _, err := os.Stat(filepath.Join(root, dir, "BUILD.bazel")); err != nil {
+			t.Errorf("update did not write %s/BUILD.bazel: %v", dir, err)
+		}
+	}
+}
+
 func TestServer_Uptime(t *testing.T) {
 	t.Parallel()
 	server := &Server{
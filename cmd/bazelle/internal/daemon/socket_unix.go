@@ -0,0 +1,53 @@
+//go:build unix
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// insecureSocketPerms are permission bits that let other users connect to
+// or replace the socket. The server creates the socket with mode 0600.
+const insecureSocketPerms = 0o022
+
+// verifySocket checks that path is a socket, not a symlink, owned by the
+// current user and not writable by group or others.
+//
+// A missing path is not an error here; the subsequent dial reports it.
+func verifySocket(path string) error {
+	return verifySocketOwner(path, os.Getuid())
+}
+
+// verifySocketOwner checks that path is a socket owned by uid with no
+// insecure permission bits.
+func verifySocketOwner(path string, uid int) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("stat socket: %w", err)
+	}
+
+	// The dial follows symlinks, so a link would bypass the checks below.
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%w: %s is a symlink", ErrInsecureSocket, path)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%w: %s is not a socket", ErrInsecureSocket, path)
+	}
+
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok && int(stat.Uid) != uid {
+		return fmt.Errorf("%w: %s is owned by uid %d, expected %d",
+			ErrInsecureSocket, path, stat.Uid, uid)
+	}
+
+	if perm := info.Mode().Perm(); perm&insecureSocketPerms != 0 {
+		return fmt.Errorf("%w: %s has mode %04o, must not be group or world writable",
+			ErrInsecureSocket, path, perm)
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build unix
+
+package daemon
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// listenSocket creates a Unix socket listener with the given permissions.
+func listenSocket(t *testing.T, perm os.FileMode) string {
+	t.Helper()
+	socketPath := filepath.Join(shortTempDir(t), "daemon.sock")
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Listen error: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	if err := os.Chmod(socketPath, perm); err != nil {
+		t.Fatalf("Chmod error: %v", err)
+	}
+	return socketPath
+}
+
+func TestConnect_RefusesWorldWritableSocket(t *testing.T) {
+	t.Parallel()
+	socketPath := listenSocket(t, 0o666)
+
+	client, err := Connect(socketPath)
+	if err == nil {
+		client.Close()
+		t.Fatal("Connect() should refuse a world-writable socket")
+	}
+	if !errors.Is(err, ErrInsecureSocket) {
+		t.Errorf("Error should be ErrInsecureSocket, got %v", err)
+	}
+}
+
+func TestConnect_AcceptsPrivateSocket(t *testing.T) {
+	t.Parallel()
+	socketPath := listenSocket(t, 0o600)
+
+	client, err := Connect(socketPath)
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	client.Close()
+}
+
+func TestVerifySocketOwner_WrongOwner(t *testing.T) {
+	t.Parallel()
+	socketPath := listenSocket(t, 0o600)
+
+	err := verifySocketOwner(socketPath, os.Getuid()+1)
+	if !errors.Is(err, ErrInsecureSocket) {
+		t.Errorf("verifySocketOwner() should reject foreign owner, got %v", err)
+	}
+
+	if err := verifySocketOwner(socketPath, os.Getuid()); err != nil {
+		t.Errorf("verifySocketOwner() should accept own socket, got %v", err)
+	}
+}
+
+func TestVerifySocketOwner_NotSocket(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(shortTempDir(t), "daemon.sock")
+
+	// A missing path is left for the dial to report.
+	if err := verifySocketOwner(path, os.Getuid()); err != nil {
+		t.Errorf("verifySocketOwner() missing path error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte("stale"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySocketOwner(path, os.Getuid()); !errors.Is(err, ErrInsecureSocket) {
+		t.Errorf("verifySocketOwner() should reject a regular file, got %v", err)
+	}
+}
+
+func TestVerifySocketOwner_Symlink(t *testing.T) {
+	t.Parallel()
+	target := listenSocket(t, 0o600)
+	link := filepath.Join(shortTempDir(t), "daemon.sock")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifySocketOwner(link, os.Getuid()); !errors.Is(err, ErrInsecureSocket) {
+		t.Errorf("verifySocketOwner() should reject a symlink, got %v", err)
+	}
+	client, err := Connect(link)
+	if err == nil {
+		client.Close()
+		t.Fatal("Connect() should refuse a symlinked socket")
+	}
+	if !errors.Is(err, ErrInsecureSocket) {
+		t.Errorf("Error should be ErrInsecureSocket, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package daemon
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"sync"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+// DefaultTreeCacheSize is the number of parse trees kept by the daemon.
+// Trees hold native memory, so the cache is bounded by entry count.
+const DefaultTreeCacheSize = 512
+
+// TreeCache is a bounded LRU cache of tree-sitter parse trees.
+//
+// Entries are keyed by file path and validated by a hash of the file content,
+// so an unchanged file is served from the cache across update cycles while an
+// edited file is re-parsed. There is at most one tree per path; a newer
+// version replaces the older one.
+//
+// Trees are reference counted. GetOrParse hands out a release func and the
+// tree stays valid until it is called, even if the entry is evicted in the
+// meantime. An evicted tree is closed once its last reference is released.
+//
+// TreeCache is safe for concurrent use.
+type TreeCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element // path -> element holding *treeEntry
+	lru      *list.List               // front = most recently used
+}
+
+var _ treesitter.TreeCache = (*TreeCache)(nil)
+
+type treeEntry struct {
+	path    string
+	hash    [sha256.Size]byte
+	tree    treesitter.Tree
+	refs    int
+	evicted bool
+}
+
+// NewTreeCache creates a cache holding at most capacity trees.
+// A non-positive capacity uses DefaultTreeCacheSize.
+func NewTreeCache(capacity int) *TreeCache {
+	if capacity <= 0 {
+		capacity = DefaultTreeCacheSize
+	}
+	return &TreeCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// GetOrParse returns the cached tree for path if it was parsed from the same
+// content, otherwise it parses content with parser and caches the result.
+//
+// The caller must call release exactly once when done with the tree and must
+// not close the tree itself.
+func (c *TreeCache) GetOrParse(ctx context.Context, parser treesitter.Parser, path string, content []byte) (tree treesitter.Tree, release func(), err error) {
+	hash := sha256.Sum256(content)
+
+	c.mu.Lock()
+	if entry := c.lookupLocked(path, hash); entry != nil {
+		c.mu.Unlock()
+		return entry.tree, c.releaseFunc(entry), nil
+	}
+	c.mu.Unlock()
+
+	// Parse outside the lock so slow files don't block other lookups.
+	parsed, err := parser.Parse(ctx, content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Another caller may have cached the same content while we were parsing.
+	if entry := c.lookupLocked(path, hash); entry != nil {
+		_ = parsed.Close()
+		return entry.tree, c.releaseFunc(entry), nil
+	}
+
+	if elem, ok := c.entries[path]; ok {
+		c.removeLocked(elem)
+	}
+	entry := &treeEntry{path: path, hash: hash, tree: parsed, refs: 1}
+	c.entries[path] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.capacity {
+		c.removeLocked(c.lru.Back())
+	}
+	return entry.tree, c.releaseFunc(entry), nil
+}
+
+// Len returns the number of cached trees.
+func (c *TreeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Purge evicts every entry. Trees still in use are closed on release.
+func (c *TreeCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// lookupLocked returns the live entry for path with a matching hash and takes
+// a reference to it. Must be called with c.mu held.
+func (c *TreeCache) lookupLocked(path string, hash [sha256.Size]byte) *treeEntry {
+	elem, ok := c.entries[path]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*treeEntry)
+	if entry.hash != hash {
+		return nil
+	}
+	entry.refs++
+	c.lru.MoveToFront(elem)
+	return entry
+}
+
+// removeLocked evicts elem and closes its tree if nobody holds it.
+// Must be called with c.mu held.
+func (c *TreeCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*treeEntry)
+	delete(c.entries, entry.path)
+	entry.evicted = true
+	if entry.refs == 0 {
+		_ = entry.tree.Close()
+	}
+}
+
+// releaseFunc returns a func dropping one reference to entry.
+// Extra calls are ignored.
+func (c *TreeCache) releaseFunc(entry *treeEntry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			entry.refs--
+			if entry.refs == 0 && entry.evicted {
+				_ = entry.tree.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package daemon
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+// fakeTree records whether it has been closed.
+type fakeTree struct {
+	source []byte
+	closed atomic.Bool
+}
+
+func (t *fakeTree) RootNode() treesitter.Node { return nil }
+func (t *fakeTree) Source() []byte            { return t.source }
+func (t *fakeTree) HasError() bool            { return false }
+func (t *fakeTree) Close() error {
+	t.closed.Store(true)
+	return nil
+}
+
+// countingParser returns a new fakeTree per Parse call.
+type countingParser struct {
+	parses atomic.Int32
+}
+
+func (p *countingParser) Language() treesitter.Language { return treesitter.Kotlin }
+func (p *countingParser) Parse(_ context.Context, source []byte) (treesitter.Tree, error) {
+	p.parses.Add(1)
+	return &fakeTree{source: source}, nil
+}
+func (p *countingParser) ParseString(ctx context.Context, source string) (treesitter.Tree, error) {
+	return p.Parse(ctx, []byte(source))
+}
+func (p *countingParser) Close() error { return nil }
+
+func TestTreeCache_HitAvoidsReparse(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTreeCache(4)
+	parser := &countingParser{}
+
+	first, release, err := cache.GetOrParse(ctx, parser, "Foo.kt", []byte("class Foo"))
+	if err != nil {
+		t.Fatalf("GetOrParse failed: %v", err)
+	}
+	release()
+
+	second, release, err := cache.GetOrParse(ctx, parser, "Foo.kt", []byte("class Foo"))
+	if err != nil {
+		t.Fatalf("GetOrParse failed: %v", err)
+	}
+	release()
+
+	if got := parser.parses.Load(); got != 1 {
+		t.Errorf("parses = %d, want 1 for unchanged content", got)
+	}
+	if first != second {
+		t.Error("expected the cached tree to be returned")
+	}
+}
+
+func TestTreeCache_ChangedContentReparses(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTreeCache(4)
+	parser := &countingParser{}
+
+	old, release, _ := cache.GetOrParse(ctx, parser, "Foo.kt", []byte("class Foo"))
+	release()
+	_, release, _ = cache.GetOrParse(ctx, parser, "Foo.kt", []byte("class Foo2"))
+	release()
+
+	if got := parser.parses.Load(); got != 2 {
+		t.Errorf("parses = %d, want 2 after content change", got)
+	}
+	if !old.(*fakeTree).closed.Load() {
+		t.Error("stale tree should be closed when replaced")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", cache.Len())
+	}
+}
+
+func TestTreeCache_EvictionClosesTrees(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTreeCache(2)
+	parser := &countingParser{}
+
+	a, release, _ := cache.GetOrParse(ctx, parser, "A.kt", []byte("a"))
+	release()
+	b, release, _ := cache.GetOrParse(ctx, parser, "B.kt", []byte("b"))
+	release()
+
+	// Touch A so B becomes least recently used.
+	_, release, _ = cache.GetOrParse(ctx, parser, "A.kt", []byte("a"))
+	release()
+	_, release, _ = cache.GetOrParse(ctx, parser, "C.kt", []byte("c"))
+	release()
+
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+	if !b.(*fakeTree).closed.Load() {
+		t.Error("evicted tree B should be closed")
+	}
+	if a.(*fakeTree).closed.Load() {
+		t.Error("recently used tree A should stay open")
+	}
+}
+
+func TestTreeCache_EvictedTreeClosedAfterRelease(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTreeCache(1)
+	parser := &countingParser{}
+
+	held, releaseHeld, _ := cache.GetOrParse(ctx, parser, "A.kt", []byte("a"))
+	_, release, _ := cache.GetOrParse(ctx, parser, "B.kt", []byte("b"))
+	release()
+
+	if held.(*fakeTree).closed.Load() {
+		t.Fatal("tree must stay open while a caller holds it")
+	}
+	releaseHeld()
+	releaseHeld() // extra calls are ignored
+	if !held.(*fakeTree).closed.Load() {
+		t.Error("evicted tree should be closed on final release")
+	}
+}
+
+func TestTreeCache_Purge(t *testing.T) {
+	ctx := context.Background()
+	cache := NewTreeCache(4)
+	parser := &countingParser{}
+
+	tree, release, _ := cache.GetOrParse(ctx, parser, "A.kt", []byte("a"))
+	release()
+	cache.Purge()
+
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d, want 0 after Purge", cache.Len())
+	}
+	if !tree.(*fakeTree).closed.Load() {
+		t.Error("purged tree should be closed")
+	}
+}
@@ -0,0 +1,65 @@
+package depgraph
+
+import "slices"
+
+// Cycles returns the dependency cycles of g: each is a strongly connected
+// component of two or more nodes, with its nodes sorted. The cycles are
+// sorted by their first node. Build drops self-edges, so a target depending
+// on itself is not reported.
+func (g *Graph) Cycles() [][]string {
+	succ := make(map[string][]string)
+	for _, e := range g.Edges {
+		succ[e.From] = append(succ[e.From], e.To)
+	}
+
+	// Tarjan's algorithm over the nodes in sorted order.
+	var (
+		index   = make(map[string]int)
+		low     = make(map[string]int)
+		onStack = make(map[string]bool)
+		stack   []string
+		cycles  [][]string
+	)
+	var visit func(n string)
+	visit = func(n string) {
+		index[n] = len(index)
+		low[n] = index[n]
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, m := range succ[n] {
+			if _, seen := index[m]; !seen {
+				visit(m)
+				low[n] = min(low[n], low[m])
+			} else if onStack[m] {
+				low[n] = min(low[n], index[m])
+			}
+		}
+
+		if low[n] != index[n] {
+			return
+		}
+		var scc []string
+		for {
+			m := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[m] = false
+			scc = append(scc, m)
+			if m == n {
+				break
+			}
+		}
+		if len(scc) > 1 {
+			slices.Sort(scc)
+			cycles = append(cycles, scc)
+		}
+	}
+	for _, n := range g.Nodes {
+		if _, seen := index[n]; !seen {
+			visit(n)
+		}
+	}
+
+	slices.SortFunc(cycles, func(a, b []string) int { return slices.Compare(a, b) })
+	return cycles
+}
@@ -0,0 +1,310 @@
+// Package depgraph builds the dependency graph between the Bazel targets of
+// a workspace from the imports and FQNs their sources reference.
+//
+// Only first-party edges are recorded: a dependency becomes an edge when
+// another target in the scanned tree declares its package. Dependencies on
+// the stdlib and on external artifacts are dropped, which keeps the graph
+// focused on the workspace's own architecture.
+package depgraph
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+	"github.com/albertocavalcante/bazelle/gazelle-kotlin/kotlin"
+	"github.com/albertocavalcante/bazelle/internal/wspath"
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+	"github.com/albertocavalcante/bazelle/pkg/util"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// Graph is a directed dependency graph between targets.
+type Graph struct {
+	// Nodes are the target labels that own scanned sources, sorted.
+	Nodes []string `json:"nodes"`
+
+	// Edges are the dependencies between nodes, sorted by From then To.
+	Edges []Edge `json:"edges"`
+}
+
+// Edge records that From depends on To.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Languages lists the languages Build supports.
+var Languages = []string{"kotlin"}
+
+// Option configures Build.
+type Option func(*buildOptions)
+
+type buildOptions struct {
+	excludeTestImports bool
+}
+
+// ExcludeTestImports leaves the imports of test files out of the graph, so
+// its edges are the dependencies production code needs. Test files still
+// make their targets nodes.
+func ExcludeTestImports() Option {
+	return func(o *buildOptions) { o.excludeTestImports = true }
+}
+
+// Build parses the sources of lang under dir (relative to root; "" or "."
+// for the whole workspace) and returns the dependency graph between the
+// targets that own them.
+func Build(root, dir, lang string, opts ...Option) (*Graph, error) {
+	var o buildOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	switch lang {
+	case "kotlin":
+		return buildKotlin(root, dir, o)
+	default:
+		return nil, fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages, ", "))
+	}
+}
+
+// Index parses the sources of lang under dir (relative to root; "" or "."
+// for the whole workspace) and adds to index the symbols Build resolves
+// against: each source package mapped to the targets that declare it. It
+// stops early with ctx.Err() once ctx is done.
+func Index(ctx context.Context, index *jvm.PackageIndex, root, dir, lang string) error {
+	switch lang {
+	case "kotlin":
+		_, err := addKotlin(ctx, index, root, dir, nil)
+		return err
+	default:
+		return fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages, ", "))
+	}
+}
+
+// Reindex parses again the sources of lang that belong to the targets
+// owning dirs (slash- or OS-separated, relative to root) and replaces the
+// entries of those targets in index, which Index filled, so the index keeps
+// up with source changes without being rebuilt. It returns the imports of
+// the re-parsed sources that no package in index declares, sorted and keyed
+// by target; stdlib imports are left out.
+func Reindex(ctx context.Context, index *jvm.PackageIndex, root string, dirs []string, lang string) (map[label.Label][]string, error) {
+	switch lang {
+	case "kotlin":
+		return reindexKotlin(ctx, index, root, dirs)
+	default:
+		return nil, fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages, ", "))
+	}
+}
+
+// indexKotlin indexes the Kotlin files under dir into a new index, see
+// addKotlin.
+func indexKotlin(root, dir string) (*jvm.PackageIndex, map[label.Label][]*kotlin.ParseResult, error) {
+	index := jvm.NewPackageIndex()
+	byTarget, err := addKotlin(context.Background(), index, root, dir, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return index, byTarget, nil
+}
+
+// addKotlin maps each Kotlin file under dir to the target of its nearest
+// enclosing Bazel package (its own directory if there is none) and adds the
+// Kotlin packages and top-level types each target declares to index. Only
+// the files of the targets in owned are added, or of every target if owned
+// is nil. It returns the parse results of the added files grouped by
+// target.
+func addKotlin(ctx context.Context, index *jvm.PackageIndex, root, dir string, owned map[label.Label]bool) (map[label.Label][]*kotlin.ParseResult, error) {
+	files, err := findSources(ctx, root, dir, langs.Extensions["kotlin"])
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.Join(root, filepath.FromSlash(f))
+	}
+	results, err := kotlin.NewParser().ParseFilesContext(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	boundaries := jvm.NewPackageBoundaries(root, nil)
+	byTarget := make(map[label.Label][]*kotlin.ParseResult)
+	for _, r := range results {
+		// Files past the parse timeout are missing from results, so map each
+		// result back through its own path rather than by position.
+		rel, err := wspath.Rel(root, r.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		target := kotlinTarget(root, boundaries, path.Dir(rel))
+		if owned != nil && !owned[target] {
+			continue
+		}
+		byTarget[target] = append(byTarget[target], r)
+		index.Add(r.Package, target)
+		for _, typ := range r.DeclaredTypes {
+			if r.Package != "" {
+				typ = r.Package + "." + typ
+			}
+			index.AddType(typ, target)
+		}
+	}
+	return byTarget, nil
+}
+
+// kotlinTarget returns the target owning the Kotlin files in dir, a
+// slash-separated directory relative to root: that of its nearest enclosing
+// Bazel package, or of dir itself if there is none.
+func kotlinTarget(root string, boundaries *jvm.PackageBoundaries, dir string) label.Label {
+	if dir == "." {
+		dir = ""
+	}
+	if target, ok := boundaries.Target(dir); ok {
+		return target
+	}
+	return label.New("", dir, jvm.DeriveTargetName(dir, root))
+}
+
+// reindexKotlin re-indexes the targets owning dirs, see Reindex.
+func reindexKotlin(ctx context.Context, index *jvm.PackageIndex, root string, dirs []string) (map[label.Label][]string, error) {
+	boundaries := jvm.NewPackageBoundaries(root, nil)
+	owned := make(map[label.Label]bool)
+	for _, dir := range dirs {
+		owned[kotlinTarget(root, boundaries, filepath.ToSlash(filepath.Clean(dir)))] = true
+	}
+
+	// A target whose directory was deleted, which happens when it has no
+	// enclosing Bazel package, has no sources left to add back.
+	var live []label.Label
+	for target := range owned {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(target.Pkg)))
+		if err == nil {
+			live = append(live, target)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	// Every target is removed before any is added back, so the imports of
+	// one re-parsed target can resolve to another.
+	for target := range owned {
+		index.Remove(target)
+	}
+	byTarget := make(map[label.Label][]*kotlin.ParseResult)
+	for _, target := range live {
+		results, err := addKotlin(ctx, index, root, target.Pkg, owned)
+		if err != nil {
+			return nil, err
+		}
+		byTarget[target] = results[target]
+	}
+
+	unresolved := make(map[label.Label][]string)
+	for target, results := range byTarget {
+		var imports []string
+		for _, r := range results {
+			r.ClassifyImports(index.Contains)
+			imports = append(imports, r.ImportsByOrigin[util.OriginThirdParty]...)
+		}
+		if len(imports) > 0 {
+			slices.Sort(imports)
+			unresolved[target] = slices.Compact(imports)
+		}
+	}
+	return unresolved, nil
+}
+
+// buildKotlin resolves the aggregate dependencies of every Kotlin target
+// against the index of the packages the targets declare.
+func buildKotlin(root, dir string, o buildOptions) (*Graph, error) {
+	index, byTarget, err := indexKotlin(root, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	aggregate := kotlin.GetAllDependencies
+	if o.excludeTestImports {
+		aggregate = kotlin.GetProductionDependencies
+	}
+
+	g := &Graph{Nodes: []string{}, Edges: []Edge{}}
+	for target, group := range byTarget {
+		g.Nodes = append(g.Nodes, target.String())
+
+		deps := make(map[label.Label]bool)
+		for _, dep := range aggregate(group) {
+			for _, to := range index.ResolveImport(dep, target) {
+				deps[to] = true
+			}
+		}
+		for _, r := range group {
+			if o.excludeTestImports && r.IsTest {
+				continue
+			}
+			for _, imp := range r.StarImports {
+				for _, to := range index.ResolveStarImport(imp, target) {
+					deps[to] = true
+				}
+			}
+		}
+
+		delete(deps, target)
+		for to := range deps {
+			g.Edges = append(g.Edges, Edge{From: target.String(), To: to.String()})
+		}
+	}
+
+	slices.Sort(g.Nodes)
+	slices.SortFunc(g.Edges, func(a, b Edge) int {
+		return cmp.Or(strings.Compare(a.From, b.From), strings.Compare(a.To, b.To))
+	})
+	return g, nil
+}
+
+// findSources returns the slash-separated paths, relative to root, of the
+// files under dir with one of exts. Directories matching langs.IgnoredDirs
+// or listed in .bazelignore are skipped, as are entries deleted during the
+// walk. It stops with ctx.Err() once ctx is done.
+func findSources(ctx context.Context, root, dir string, exts []string) ([]string, error) {
+	start := filepath.Join(root, filepath.FromSlash(dir))
+	ignorer := langs.NewIgnorer(root)
+	var files []string
+	err := filepath.WalkDir(start, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// A file or directory deleted while the walk runs has no
+			// sources to find.
+			if p != start && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != start && ignorer.SkipDir(p) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(exts, filepath.Ext(p)) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
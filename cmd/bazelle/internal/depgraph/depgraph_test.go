@@ -0,0 +1,397 @@
+package depgraph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func kotlinFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/BUILD.bazel": "",
+		"app/src/Main.kt": `package com.example.app
+
+import com.example.model.User
+import com.example.util.*
+import kotlinx.coroutines.launch
+
+fun main() = println(format(User("a")))
+`,
+		"model/User.kt": `package com.example.model
+
+data class User(val name: String)
+`,
+		"util/Format.kt": `package com.example.util
+
+fun format(u: Any): String = com.example.model.User::class.simpleName + u
+`,
+		"util/Strings.kt": `package com.example.util
+
+import com.example.util.format
+
+fun shout(s: String) = format(s).uppercase()
+`,
+		"bazel-out/Gen.kt": `package com.example.gen
+
+import com.example.model.User
+`,
+	})
+	return root
+}
+
+func TestBuild_Kotlin(t *testing.T) {
+	root := kotlinFixture(t)
+
+	g, err := Build(root, "", "kotlin")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	wantNodes := []string{"//app", "//model", "//util"}
+	if !slices.Equal(g.Nodes, wantNodes) {
+		t.Errorf("Nodes = %v, want %v", g.Nodes, wantNodes)
+	}
+	wantEdges := []Edge{
+		{From: "//app", To: "//model"},
+		{From: "//app", To: "//util"},
+		{From: "//util", To: "//model"},
+	}
+	if !slices.Equal(g.Edges, wantEdges) {
+		t.Errorf("Edges = %v, want %v", g.Edges, wantEdges)
+	}
+}
+
+func TestBuild_ExcludeTestImports(t *testing.T) {
+	root := kotlinFixture(t)
+	writeFiles(t, root, map[string]string{
+		"app/src/MainTest.kt": `package com.example.app
+
+import com.example.fixtures.Users
+import com.example.mocks.*
+
+class MainTest
+`,
+		"fixtures/Users.kt": "package com.example.fixtures\n\nobject Users\n",
+		"mocks/Mocks.kt":    "package com.example.mocks\n\nclass MockUser\n",
+	})
+
+	tests := []struct {
+		name string
+		opts []Option
+		want []Edge
+	}{
+		{"all imports", nil, []Edge{
+			{From: "//app", To: "//fixtures"},
+			{From: "//app", To: "//mocks"},
+			{From: "//app", To: "//model"},
+			{From: "//app", To: "//util"},
+			{From: "//util", To: "//model"},
+		}},
+		{"production imports", []Option{ExcludeTestImports()}, []Edge{
+			{From: "//app", To: "//model"},
+			{From: "//app", To: "//util"},
+			{From: "//util", To: "//model"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := Build(root, "", "kotlin", tt.opts...)
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if want := []string{"//app", "//fixtures", "//mocks", "//model", "//util"}; !slices.Equal(g.Nodes, want) {
+				t.Errorf("Nodes = %v, want %v", g.Nodes, want)
+			}
+			if !slices.Equal(g.Edges, tt.want) {
+				t.Errorf("Edges = %v, want %v", g.Edges, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuild_ExtensionFunctionImport(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/Main.kt": `package com.example.app
+
+import com.example.ext.toSlug
+
+fun main() = println("Hello World".toSlug())
+`,
+		"ext/Slugs.kt": `package com.example.ext
+
+fun String.toSlug(): String = lowercase().replace(' ', '-')
+`,
+	})
+
+	g, err := Build(root, "", "kotlin")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if want := []Edge{{From: "//app", To: "//ext"}}; !slices.Equal(g.Edges, want) {
+		t.Errorf("Edges = %v, want %v", g.Edges, want)
+	}
+}
+
+func TestBuild_NestedClassImport(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"app/Main.kt": `package com.example.app
+
+import com.example.model.Outer.Inner
+
+fun main() = println(Inner())
+`,
+		"model/shapes/Outer.kt": `package com.example.model
+
+class Outer {
+    class Inner
+}
+`,
+		"model/misc/Other.kt": `package com.example.model
+
+class Other
+`,
+	})
+
+	g, err := Build(root, "", "kotlin")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	// The package is split across two targets; only one declares Outer.
+	if want := []Edge{{From: "//app", To: "//model/shapes"}}; !slices.Equal(g.Edges, want) {
+		t.Errorf("Edges = %v, want %v", g.Edges, want)
+	}
+}
+
+func TestBuild_Subdirectory(t *testing.T) {
+	root := kotlinFixture(t)
+
+	g, err := Build(root, "util", "kotlin")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if want := []string{"//util"}; !slices.Equal(g.Nodes, want) {
+		t.Errorf("Nodes = %v, want %v", g.Nodes, want)
+	}
+	// model was not scanned, so it is not first-party here.
+	if len(g.Edges) != 0 {
+		t.Errorf("Edges = %v, want none", g.Edges)
+	}
+}
+
+func TestIndex_Kotlin(t *testing.T) {
+	root := kotlinFixture(t)
+
+	ix := jvm.NewPackageIndex()
+	if err := Index(context.Background(), ix, root, "", "kotlin"); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	want := []string{"com.example.app", "com.example.model", "com.example.util"}
+	if got := ix.Packages(); !slices.Equal(got, want) {
+		t.Errorf("Packages() = %v, want %v", got, want)
+	}
+	targets := ix.Targets("com.example.app")
+	if len(targets) != 1 || targets[0].String() != "//app" {
+		t.Errorf("Targets(com.example.app) = %v, want [//app]", targets)
+	}
+}
+
+func TestIndex_CoarsePackage(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		// The BUILD file sits two levels above the sources
+		"src/com/BUILD.bazel":        "",
+		"src/com/example/foo/Bar.kt": "package com.example.foo\n\nclass Bar\n",
+	})
+
+	ix := jvm.NewPackageIndex()
+	if err := Index(context.Background(), ix, root, "", "kotlin"); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	targets := ix.Targets("com.example.foo")
+	if len(targets) != 1 || targets[0].String() != "//src/com" {
+		t.Errorf("Targets(com.example.foo) = %v, want [//src/com]", targets)
+	}
+}
+
+func TestIndex_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Index(ctx, jvm.NewPackageIndex(), kotlinFixture(t), "", "kotlin"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Index() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestReindex_Kotlin(t *testing.T) {
+	root := kotlinFixture(t)
+	ix := jvm.NewPackageIndex()
+	if err := Index(context.Background(), ix, root, "", "kotlin"); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	// util moves to a new package, which app imports along with the old one
+	if err := os.Remove(filepath.Join(root, "util", "Strings.kt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, root, map[string]string{
+		"util/Format.kt":  "package com.example.text\n\nfun format(s: Any) = s.toString()\n",
+		"app/src/Main.kt": "package com.example.app\n\nimport com.example.text.format\nimport com.example.util.*\n",
+	})
+	unresolved, err := Reindex(context.Background(), ix, root, []string{"util", filepath.Join("app", "src")}, "kotlin")
+	if err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+
+	want := []string{"com.example.app", "com.example.model", "com.example.text"}
+	if got := ix.Packages(); !slices.Equal(got, want) {
+		t.Errorf("Packages() = %v, want %v", got, want)
+	}
+	app := label.New("", "app", "app")
+	if got, want := unresolved, map[label.Label][]string{app: {"com.example.util"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Reindex() = %v, want %v", got, want)
+	}
+}
+
+func TestReindex_DeletedDir(t *testing.T) {
+	root := kotlinFixture(t)
+	ix := jvm.NewPackageIndex()
+	if err := Index(context.Background(), ix, root, "", "kotlin"); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	// model has no BUILD file, so its deleted directory is its own target
+	if err := os.RemoveAll(filepath.Join(root, "model")); err != nil {
+		t.Fatal(err)
+	}
+	unresolved, err := Reindex(context.Background(), ix, root, []string{"model", filepath.Join("app", "src")}, "kotlin")
+	if err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+
+	want := []string{"com.example.app", "com.example.util"}
+	if got := ix.Packages(); !slices.Equal(got, want) {
+		t.Errorf("Packages() = %v, want %v", got, want)
+	}
+	app := label.New("", "app", "app")
+	if got, want := unresolved, map[label.Label][]string{app: {"com.example.model.User", "kotlinx.coroutines.launch"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Reindex() = %v, want %v", got, want)
+	}
+}
+
+func TestBuild_UnsupportedLanguage(t *testing.T) {
+	if _, err := Build(t.TempDir(), "", "cobol"); err == nil {
+		t.Error("Build() error = nil, want unsupported language error")
+	}
+}
+
+func TestGraph_Cycles(t *testing.T) {
+	g := &Graph{
+		Nodes: []string{"//a", "//b", "//c", "//d", "//e"},
+		Edges: []Edge{
+			{From: "//a", To: "//b"},
+			{From: "//b", To: "//c"},
+			{From: "//c", To: "//a"},
+			{From: "//c", To: "//d"},
+			{From: "//d", To: "//e"},
+			{From: "//e", To: "//d"},
+		},
+	}
+
+	want := [][]string{{"//a", "//b", "//c"}, {"//d", "//e"}}
+	if got := g.Cycles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Cycles() = %v, want %v", got, want)
+	}
+
+	if got := (&Graph{Nodes: []string{"//a", "//b"}, Edges: []Edge{{From: "//a", To: "//b"}}}).Cycles(); len(got) != 0 {
+		t.Errorf("Cycles() of an acyclic graph = %v, want none", got)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g, err := Build(kotlinFixture(t), "", "kotlin")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, g); err != nil {
+		t.Fatalf("WriteDOT() error = %v", err)
+	}
+	out := buf.String()
+
+	if !strings.HasPrefix(out, "digraph deps {\n") || !strings.HasSuffix(out, "}\n") {
+		t.Errorf("WriteDOT() output is not a digraph:\n%s", out)
+	}
+	for _, want := range []string{
+		`"//app";`,
+		`"//app" -> "//model";`,
+		`"//app" -> "//util";`,
+		`"//util" -> "//model";`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteDOT() output missing %s:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `-> "//app";`) {
+		t.Errorf("WriteDOT() output has an edge into //app:\n%s", out)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	g := &Graph{
+		Nodes: []string{"//a:a", "//b:b"},
+		Edges: []Edge{{From: "//a:a", To: "//b:b"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, g); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	var got Graph
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if !slices.Equal(got.Nodes, g.Nodes) || !slices.Equal(got.Edges, g.Edges) {
+		t.Errorf("round trip = %+v, want %+v", got, *g)
+	}
+	if !strings.Contains(buf.String(), `"from": "//a:a"`) {
+		t.Errorf("WriteJSON() output missing from field:\n%s", buf.String())
+	}
+}
+
+func TestWrite_UnsupportedFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, &Graph{}, "svg"); err == nil {
+		t.Error("Write() error = nil, want unsupported format error")
+	}
+}
@@ -0,0 +1,47 @@
+package depgraph
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Formats lists the output formats Write supports.
+var Formats = []string{"dot", "json"}
+
+// Write renders g to w in the named format.
+func Write(w io.Writer, g *Graph, format string) error {
+	switch format {
+	case "dot":
+		return WriteDOT(w, g)
+	case "json":
+		return WriteJSON(w, g)
+	default:
+		return fmt.Errorf("unsupported format %q (supported: dot, json)", format)
+	}
+}
+
+// WriteDOT renders g as a Graphviz digraph. Every node is declared, so
+// targets without first-party dependencies still appear.
+func WriteDOT(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph deps {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(bw, "  %s;\n", strconv.Quote(n))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s;\n", strconv.Quote(e.From), strconv.Quote(e.To))
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteJSON renders g as indented JSON.
+func WriteJSON(w io.Writer, g *Graph) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
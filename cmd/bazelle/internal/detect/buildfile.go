@@ -0,0 +1,68 @@
+package detect
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+)
+
+// Build file names recognized by Bazel.
+const (
+	BuildFileBazel = "BUILD.bazel"
+	BuildFilePlain = "BUILD"
+)
+
+// BuildFileName detects the BUILD file naming convention used in root.
+//
+// This function is DETERMINISTIC: it counts existing BUILD.bazel and BUILD
+// files (skipping langs.IgnoredDirs and .bazelignore entries) and returns the
+// more common name.
+// Ties, including a workspace with no BUILD files yet, resolve to
+// BUILD.bazel, which is Bazel's recommended name.
+func BuildFileName(root string) (string, error) {
+	var bazelCount, plainCount int
+	ignorer := langs.NewIgnorer(root)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if ignorer.SkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		switch d.Name() {
+		case BuildFileBazel:
+			bazelCount++
+		case BuildFilePlain:
+			plainCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if plainCount > bazelCount {
+		return BuildFilePlain, nil
+	}
+	return BuildFileBazel, nil
+}
+
+// BuildFileNames returns the value for gazelle's -build_file_name flag with
+// preferred first. Gazelle creates new files with the first name and still
+// reads existing files with either name.
+func BuildFileNames(preferred string) string {
+	if preferred == BuildFilePlain {
+		return BuildFilePlain + "," + BuildFileBazel
+	}
+	if preferred == "" || preferred == BuildFileBazel {
+		return BuildFileBazel + "," + BuildFilePlain
+	}
+	return preferred
+}
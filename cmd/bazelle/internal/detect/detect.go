@@ -18,14 +18,14 @@
 // # Ignored Directories
 //
 // Certain directories are skipped during detection to avoid false positives
-// from build outputs, vendored code, and generated files. See langs.IgnoredDirs.
+// from build outputs, vendored code, and generated files. See langs.IgnoredDirs;
+// directories listed in the workspace's .bazelignore are skipped as well.
 package detect
 
 import (
 	"io/fs"
 	"path/filepath"
 	"slices"
-	"strings"
 
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
 )
@@ -43,6 +43,7 @@ import (
 // Returns a sorted slice of language identifiers (e.g., ["go", "kotlin", "proto"]).
 func Languages(root string) ([]string, error) {
 	found := make(map[string]bool)
+	ignorer := langs.NewIgnorer(root)
 
 	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -51,11 +52,8 @@ func Languages(root string) ([]string, error) {
 
 		// Skip ignored directories (using shared list from langs package)
 		if d.IsDir() {
-			name := d.Name()
-			for _, prefix := range langs.IgnoredDirs {
-				if strings.HasPrefix(name, prefix) {
-					return filepath.SkipDir
-				}
+			if ignorer.SkipDir(path) {
+				return filepath.SkipDir
 			}
 			return nil
 		}
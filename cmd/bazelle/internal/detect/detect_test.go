@@ -161,3 +161,48 @@ func createFile(t *testing.T, base, path string) {
 		t.Fatal(err)
 	}
 }
+
+func TestBuildFileName(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"empty workspace", nil, detect.BuildFileBazel},
+		{"BUILD.bazel convention", []string{"BUILD.bazel", "lib/BUILD.bazel", "app/BUILD.bazel"}, detect.BuildFileBazel},
+		{"BUILD convention", []string{"BUILD", "lib/BUILD", "app/BUILD"}, detect.BuildFilePlain},
+		{"mostly BUILD", []string{"BUILD", "lib/BUILD", "app/BUILD.bazel"}, detect.BuildFilePlain},
+		{"tie prefers BUILD.bazel", []string{"BUILD", "lib/BUILD.bazel"}, detect.BuildFileBazel},
+		{"ignores output dirs", []string{"BUILD.bazel", "bazel-out/a/BUILD", "bazel-out/b/BUILD"}, detect.BuildFileBazel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for _, f := range tt.files {
+				createFile(t, tmpDir, f)
+			}
+
+			got, err := detect.BuildFileName(tmpDir)
+			if err != nil {
+				t.Fatalf("BuildFileName() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BuildFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFileNames(t *testing.T) {
+	tests := map[string]string{
+		"":                    "BUILD.bazel,BUILD",
+		detect.BuildFileBazel: "BUILD.bazel,BUILD",
+		detect.BuildFilePlain: "BUILD,BUILD.bazel",
+	}
+	for preferred, want := range tests {
+		if got := detect.BuildFileNames(preferred); got != want {
+			t.Errorf("BuildFileNames(%q) = %q, want %q", preferred, got, want)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package dirparse parses every source file of a directory with the parser of
+// its language.
+//
+// Gazelle runs each language extension over a directory on its own, so a
+// directory mixing Kotlin, Python and Java sources is parsed three times by
+// three unrelated code paths. ParseDir gives tools that want a single view of
+// a directory (the daemon, editor integrations) one call that dispatches each
+// file to its language's parser and collects the results side by side.
+//
+// # Failure Isolation
+//
+// A file that fails to parse is recorded with its error and parsing carries
+// on: neither the other files of that language nor the other languages are
+// affected. A panicking parser is treated as a failure of the file it was
+// parsing.
+package dirparse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+	"github.com/albertocavalcante/bazelle/gazelle-groovy/groovy"
+	"github.com/albertocavalcante/bazelle/gazelle-java/java"
+	"github.com/albertocavalcante/bazelle/gazelle-kotlin/kotlin"
+	"github.com/albertocavalcante/bazelle/gazelle-python/python"
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+// Parser parses the source files of one language.
+type Parser struct {
+	// Language is the language name, as used by langs.Extensions.
+	Language string
+
+	// Extensions lists the file extensions the parser handles.
+	Extensions []string
+
+	// ParseFile parses the file at path. The result is the language's own
+	// parse result type (e.g. *kotlin.ParseResult).
+	ParseFile func(ctx context.Context, path string) (any, error)
+
+	// Open, if set, is used instead of ParseFile for parsers holding
+	// resources such as a tree-sitter backend. ParseDir calls it once, before
+	// the first file of the language, parses every file of the directory
+	// with the returned parseFile and calls close when it is done. An Open
+	// error is recorded for each file of the language.
+	Open func() (parseFile func(ctx context.Context, path string) (any, error), close func() error, err error)
+}
+
+// File is the outcome of parsing one source file.
+type File struct {
+	// Path is the path of the file, joined onto the parsed directory.
+	Path string
+
+	// Result is what the language parser returned. Nil if Err is set.
+	Result any
+
+	// Err is the error the parser returned for the file, if any.
+	Err error
+}
+
+// Result holds the parsed files of a directory grouped by language.
+type Result struct {
+	// Files maps each language that had matching files to those files, in
+	// name order.
+	Files map[string][]File
+}
+
+// Errors returns the errors of all failed files joined together, or nil if
+// every file parsed.
+func (r *Result) Errors() error {
+	var errs []error
+	for _, lang := range slices.Sorted(maps.Keys(r.Files)) {
+		for _, f := range r.Files[lang] {
+			if f.Err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.Path, f.Err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DefaultParsers returns parsers for the languages bazelle parses itself:
+// Kotlin, Java, Python and Groovy. Java parsing needs a tree-sitter backend
+// with Java support; without one every Java file fails with the backend's
+// error while the other languages still parse.
+func DefaultParsers() []Parser {
+	kt := kotlin.NewParser()
+	py := python.NewParser()
+	gv := groovy.NewParser()
+	return []Parser{
+		{
+			Language:   "kotlin",
+			Extensions: langs.Extensions["kotlin"],
+			ParseFile: func(_ context.Context, path string) (any, error) {
+				return kt.ParseFile(path)
+			},
+		},
+		{
+			Language:   "java",
+			Extensions: langs.Extensions["java"],
+			Open:       openJavaParser,
+		},
+		{
+			Language:   "python",
+			Extensions: langs.Extensions["python"],
+			ParseFile: func(_ context.Context, path string) (any, error) {
+				return py.ParseFile(path)
+			},
+		},
+		{
+			Language:   "groovy",
+			Extensions: langs.Extensions["groovy"],
+			ParseFile: func(_ context.Context, path string) (any, error) {
+				return gv.ParseFile(path)
+			},
+		},
+	}
+}
+
+// openJavaParser creates the Java parser, on a tree-sitter backend, that
+// the Java files of a directory share.
+func openJavaParser() (func(ctx context.Context, path string) (any, error), func() error, error) {
+	p, err := java.NewParser(treesitter.BackendAuto)
+	if err != nil {
+		return nil, nil, err
+	}
+	parseFile := func(ctx context.Context, path string) (any, error) {
+		return p.ParseFile(ctx, path)
+	}
+	return parseFile, p.Close, nil
+}
+
+// openedParser is a Parser opened by ParseDir.
+type openedParser struct {
+	parseFile func(ctx context.Context, path string) (any, error)
+	close     func() error
+	err       error
+}
+
+// ParseDir parses the regular files directly in dir (not in subdirectories)
+// with the parser matching their extension. Files no parser handles are
+// skipped.
+//
+// The returned error is for failing to list dir, or ctx.Err() when ctx is
+// cancelled before every file is parsed, in which case no result is
+// returned, or for failing to close an opened parser, which comes with the
+// result. Per-file failures are recorded in the result (see
+// Result.Errors).
+func ParseDir(ctx context.Context, dir string, parsers []Parser) (_ *Result, retErr error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byExt := make(map[string]*Parser)
+	for i := range parsers {
+		for _, ext := range parsers[i].Extensions {
+			if _, ok := byExt[ext]; !ok {
+				byExt[ext] = &parsers[i]
+			}
+		}
+	}
+
+	// Parsers with Open are opened on their first file and closed, in the
+	// order they were opened, once every file is parsed
+	opened := make(map[*Parser]*openedParser)
+	var closers []func() error
+	defer func() {
+		for _, closeParser := range closers {
+			if err := closeParser(); err != nil && retErr == nil {
+				retErr = err
+			}
+		}
+	}()
+
+	result := &Result{Files: make(map[string][]File)}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		p, ok := byExt[filepath.Ext(entry.Name())]
+		if !ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, entry.Name())
+		parse := p.ParseFile
+		if p.Open != nil {
+			o, ok := opened[p]
+			if !ok {
+				o = &openedParser{}
+				o.parseFile, o.close, o.err = p.Open()
+				opened[p] = o
+				if o.err == nil {
+					closers = append(closers, o.close)
+				}
+			}
+			if o.err != nil {
+				result.Files[p.Language] = append(result.Files[p.Language], File{Path: path, Err: o.err})
+				continue
+			}
+			parse = o.parseFile
+		}
+		res, err := parseFile(ctx, p.Language, parse, path)
+		result.Files[p.Language] = append(result.Files[p.Language], File{Path: path, Result: res, Err: err})
+	}
+	return result, nil
+}
+
+// parseFile runs the lang parser parse on path, turning a panic into an
+// error.
+func parseFile(ctx context.Context, lang string, parse func(context.Context, string) (any, error), path string) (res any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, fmt.Errorf("%s parser panicked: %v", lang, r)
+		}
+	}()
+	res, err = parse(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
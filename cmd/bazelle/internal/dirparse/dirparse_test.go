@@ -0,0 +1,212 @@
+package dirparse
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+	"github.com/albertocavalcante/bazelle/gazelle-java/java"
+	"github.com/albertocavalcante/bazelle/gazelle-kotlin/kotlin"
+	"github.com/albertocavalcante/bazelle/gazelle-python/python"
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseDir_MixedLanguages(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"App.kt":    "package com.example.app\n\nimport com.example.model.User\n",
+		"Main.java": "package com.example.app;\n\nimport java.util.List;\n",
+		"Util.java": "package com.example.app;\n",
+		"main.py":   "import os\nfrom app import models\n",
+		"README.md": "# not source\n",
+	})
+	// A dangling symlink cannot be read, so the Python parser fails on it.
+	if err := os.Symlink(filepath.Join(dir, "missing.py"), filepath.Join(dir, "broken.py")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ParseDir(context.Background(), dir, DefaultParsers())
+	if err != nil {
+		t.Fatalf("ParseDir() error = %v", err)
+	}
+
+	if got := slices.Sorted(maps.Keys(result.Files)); !slices.Equal(got, []string{"java", "kotlin", "python"}) {
+		t.Fatalf("languages = %v, want [java kotlin python]", got)
+	}
+
+	kt := result.Files["kotlin"]
+	if len(kt) != 1 || kt[0].Err != nil {
+		t.Fatalf("kotlin files = %+v, want one parsed file", kt)
+	}
+	if pkg := kt[0].Result.(*kotlin.ParseResult).Package; pkg != "com.example.app" {
+		t.Errorf("kotlin package = %q, want com.example.app", pkg)
+	}
+
+	py := result.Files["python"]
+	if len(py) != 2 {
+		t.Fatalf("python files = %+v, want 2", py)
+	}
+	if py[0].Err == nil || filepath.Base(py[0].Path) != "broken.py" {
+		t.Errorf("python[0] = %+v, want broken.py to fail", py[0])
+	}
+	if py[1].Err != nil {
+		t.Fatalf("main.py failed: %v", py[1].Err)
+	}
+	if imports := py[1].Result.(*python.ParseResult).Imports; !slices.Contains(imports, "os") {
+		t.Errorf("python imports = %v, want os", imports)
+	}
+
+	// The Java files share one parser
+	jv := result.Files["java"]
+	if len(jv) != 2 {
+		t.Fatalf("java files = %+v, want 2", jv)
+	}
+	if len(treesitter.AvailableBackends()) > 0 {
+		for _, f := range jv {
+			if f.Err != nil {
+				t.Fatalf("%s failed: %v", filepath.Base(f.Path), f.Err)
+			}
+			if pkg := f.Result.(*java.ParseResult).Package; pkg != "com.example.app" {
+				t.Errorf("%s package = %q, want com.example.app", filepath.Base(f.Path), pkg)
+			}
+		}
+	}
+
+	if err := result.Errors(); err == nil {
+		t.Error("Errors() = nil, want the broken.py failure")
+	}
+}
+
+func TestParseDir_CustomExtension(t *testing.T) {
+	saved := maps.Clone(langs.Extensions)
+	t.Cleanup(func() { langs.Extensions = saved })
+	if err := langs.AddExtensions(map[string][]string{"kotlin": {".ktm"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Module.ktm": "package com.example.custom\n\nimport com.example.model.User\n",
+		"other.ktx":  "package com.example.ignored\n",
+	})
+
+	result, err := ParseDir(context.Background(), dir, DefaultParsers())
+	if err != nil {
+		t.Fatalf("ParseDir() error = %v", err)
+	}
+
+	if got := slices.Sorted(maps.Keys(result.Files)); !slices.Equal(got, []string{"kotlin"}) {
+		t.Fatalf("languages = %v, want [kotlin]", got)
+	}
+	kt := result.Files["kotlin"]
+	if len(kt) != 1 || kt[0].Err != nil || filepath.Base(kt[0].Path) != "Module.ktm" {
+		t.Fatalf("kotlin files = %+v, want Module.ktm parsed", kt)
+	}
+	if pkg := kt[0].Result.(*kotlin.ParseResult).Package; pkg != "com.example.custom" {
+		t.Errorf("kotlin package = %q, want com.example.custom", pkg)
+	}
+}
+
+func TestParseDir_PanicIsolated(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.x": "", "b.y": ""})
+
+	parsers := []Parser{
+		{
+			Language:   "x",
+			Extensions: []string{".x"},
+			ParseFile: func(context.Context, string) (any, error) {
+				panic("boom")
+			},
+		},
+		{
+			Language:   "y",
+			Extensions: []string{".y"},
+			ParseFile: func(_ context.Context, path string) (any, error) {
+				return filepath.Base(path), nil
+			},
+		},
+	}
+
+	result, err := ParseDir(context.Background(), dir, parsers)
+	if err != nil {
+		t.Fatalf("ParseDir() error = %v", err)
+	}
+	if x := result.Files["x"]; len(x) != 1 || x[0].Err == nil {
+		t.Errorf("x files = %+v, want one failed file", x)
+	}
+	if y := result.Files["y"]; len(y) != 1 || y[0].Result != "b.y" {
+		t.Errorf("y files = %+v, want b.y parsed", y)
+	}
+}
+
+func TestParseDir_OpenOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"a.x": "", "b.x": "", "c.x": "", "d.y": ""})
+
+	var opens, closes int
+	closeErr := errors.New("close failed")
+	parsers := []Parser{
+		{
+			Language:   "x",
+			Extensions: []string{".x"},
+			Open: func() (func(context.Context, string) (any, error), func() error, error) {
+				opens++
+				parse := func(_ context.Context, path string) (any, error) {
+					if closes > 0 {
+						return nil, errors.New("parsed after close")
+					}
+					return filepath.Base(path), nil
+				}
+				return parse, func() error { closes++; return closeErr }, nil
+			},
+		},
+		{
+			Language:   "y",
+			Extensions: []string{".y"},
+			Open: func() (func(context.Context, string) (any, error), func() error, error) {
+				return nil, nil, errors.New("no backend")
+			},
+		},
+	}
+
+	result, err := ParseDir(context.Background(), dir, parsers)
+	if !errors.Is(err, closeErr) {
+		t.Errorf("ParseDir() error = %v, want the close error", err)
+	}
+	if opens != 1 || closes != 1 {
+		t.Errorf("x parser opened %d and closed %d times, want once each", opens, closes)
+	}
+	x := result.Files["x"]
+	if len(x) != 3 || x[0].Err != nil || x[2].Result != "c.x" {
+		t.Errorf("x files = %+v, want a.x, b.x and c.x parsed", x)
+	}
+	if y := result.Files["y"]; len(y) != 1 || y[0].Err == nil {
+		t.Errorf("y files = %+v, want d.y to fail with the Open error", y)
+	}
+}
+
+func TestParseDir_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"App.kt": "package app\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseDir(ctx, dir, DefaultParsers()); !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseDir() error = %v, want context.Canceled", err)
+	}
+}
@@ -3,7 +3,9 @@ package incremental
 import (
 	"context"
 	"fmt"
+	"maps"
 	"path/filepath"
+	"slices"
 )
 
 // Tracker provides high-level incremental update tracking.
@@ -30,7 +32,20 @@ func NewTracker(workspaceRoot string, languages []string) *Tracker {
 
 // Status checks for changes without modifying state.
 // Returns a ChangeSet describing what has changed since the last Refresh.
+//
+// Only files whose mtime or size differ from the stored index are hashed;
+// the rest are assumed unchanged. Use StatusFull to hash every file.
 func (t *Tracker) Status(ctx context.Context) (*ChangeSet, error) {
+	return t.status(ctx, false)
+}
+
+// StatusFull is like Status but hashes every file, catching content changes
+// that left the mtime and size untouched.
+func (t *Tracker) StatusFull(ctx context.Context) (*ChangeSet, error) {
+	return t.status(ctx, true)
+}
+
+func (t *Tracker) status(ctx context.Context, full bool) (*ChangeSet, error) {
 	// Load stored index
 	oldIdx, err := t.store.Load()
 	if err != nil {
@@ -44,12 +59,13 @@ func (t *Tracker) Status(ctx context.Context) (*ChangeSet, error) {
 	}
 
 	// Compute changes, hashing only files that might have changed
-	cs := t.computeChangesWithLazyHash(ctx, oldIdx, fastIdx)
+	cs := t.computeChangesWithLazyHash(ctx, oldIdx, fastIdx, full)
 	return cs, nil
 }
 
 // computeChangesWithLazyHash computes changes, only hashing files when needed.
-func (t *Tracker) computeChangesWithLazyHash(ctx context.Context, oldIdx, fastIdx *Index) *ChangeSet {
+// With full set, every file present in both indexes is hashed.
+func (t *Tracker) computeChangesWithLazyHash(ctx context.Context, oldIdx, fastIdx *Index, full bool) *ChangeSet {
 	cs := NewChangeSet()
 
 	oldEntries := make(map[string]*Entry)
@@ -79,12 +95,12 @@ func (t *Tracker) computeChangesWithLazyHash(ctx context.Context, oldIdx, fastId
 		}
 
 		// Fast path: if mtime and size unchanged, skip hash comparison
-		if oldEntry.ModTime == newEntry.ModTime && oldEntry.Size == newEntry.Size {
+		if !full && oldEntry.ModTime == newEntry.ModTime && oldEntry.Size == newEntry.Size {
 			continue
 		}
 
 		// Need to hash to check if content actually changed
-		hash, err := HashFile(filepath.Join(t.root, path))
+		hash, err := t.scanner.hash(filepath.Join(t.root, path))
 		if err != nil {
 			// If we can't hash, assume modified
 			cs.Modified = append(cs.Modified, path)
@@ -108,9 +124,23 @@ func (t *Tracker) computeChangesWithLazyHash(ctx context.Context, oldIdx, fastId
 }
 
 // Refresh updates the stored index from current disk state.
+//
+// Hashes from the stored index are reused for files whose mtime and size are
+// unchanged. Without a readable stored index every file is hashed, as with
+// RefreshFull.
 func (t *Tracker) Refresh(ctx context.Context) error {
-	// Scan current state with full hashing
-	idx, err := t.scanner.Scan(ctx)
+	// An unreadable index is rebuilt from scratch rather than failing.
+	prev, _ := t.store.Load()
+	return t.refresh(ctx, prev)
+}
+
+// RefreshFull rebuilds the stored index, hashing every file.
+func (t *Tracker) RefreshFull(ctx context.Context) error {
+	return t.refresh(ctx, nil)
+}
+
+func (t *Tracker) refresh(ctx context.Context, prev *Index) error {
+	idx, err := t.scanner.ScanIncremental(ctx, prev)
 	if err != nil {
 		return fmt.Errorf("failed to scan workspace: %w", err)
 	}
@@ -140,3 +170,16 @@ func (t *Tracker) TrackedFileCount() int {
 	}
 	return len(idx.Entries)
 }
+
+// TrackedFiles returns the sorted workspace-relative paths in the current
+// stored index. Returns nil if no state exists.
+func (t *Tracker) TrackedFiles() ([]string, error) {
+	idx, err := t.store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	if idx == nil || idx.Entries == nil {
+		return nil, nil
+	}
+	return slices.Sorted(maps.Keys(idx.Entries)), nil
+}
@@ -2,9 +2,12 @@ package incremental
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestHashBytes(t *testing.T) {
@@ -391,6 +394,50 @@ func TestScanSkipsIgnoredDirs(t *testing.T) {
 	}
 }
 
+func TestScanSkipsBazelIgnoreDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := []struct {
+		path    string
+		content string
+	}{
+		{".bazelignore", "# vendored checkout\nthird_party/upstream\nexamples/broken\n"},
+		{"src/main.go", "package main"},
+		{"third_party/upstream/lib.go", "package lib"},
+		{"third_party/upstream/sub/deep.go", "package sub"},
+		{"third_party/kept/kept.go", "package kept"},
+		{"examples/broken/main.go", "package main"},
+	}
+
+	for _, f := range files {
+		fullPath := filepath.Join(tmpDir, f.path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(f.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scanner := NewScanner(ScanConfig{
+		Root:      tmpDir,
+		Languages: []string{"go"},
+	})
+	idx, err := scanner.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	if len(idx.Entries) != 2 {
+		t.Errorf("Scan() found %d files, want 2: %v", len(idx.Entries), idx.Entries)
+	}
+	for _, want := range []string{"src/main.go", "third_party/kept/kept.go"} {
+		if _, ok := idx.Get(want); !ok {
+			t.Errorf("Scan() should find %s", want)
+		}
+	}
+}
+
 func TestScanContextCancellation(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -505,6 +552,102 @@ func TestJSONStoreClear(t *testing.T) {
 	}
 }
 
+// writeStateFile writes content as the state file of the workspace at root.
+func writeStateFile(t *testing.T, root, content string) string {
+	t.Helper()
+	path := filepath.Join(root, stateDir, stateFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestJSONStoreUnsupportedVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "newer version",
+			content: `{"version": 99, "entries": [{"file": "a.go"}]}`,
+		},
+		{
+			name:    "older version",
+			content: `{"version": 0, "entries": {"a.go": {"path": "a.go", "hash": "abc"}}}`,
+		},
+		{
+			name:    "no version",
+			content: `{"entries": {}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			writeStateFile(t, tmpDir, tt.content)
+			store := NewJSONStore(tmpDir)
+
+			if store.Exists() {
+				t.Error("Exists() = true, want false for an unsupported version")
+			}
+			idx, err := store.Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if idx.Version != IndexVersion || len(idx.Entries) != 0 {
+				t.Errorf("Load() = version %d with %d entries, want an empty version %d index",
+					idx.Version, len(idx.Entries), IndexVersion)
+			}
+		})
+	}
+}
+
+func TestJSONStoreMigratesOlderVersion(t *testing.T) {
+	old := indexMigrations
+	t.Cleanup(func() { indexMigrations = old })
+	indexMigrations = map[int]func(*Index){
+		0: func(idx *Index) {
+			for path, e := range idx.Entries {
+				e.Path = path
+			}
+		},
+	}
+
+	tmpDir := t.TempDir()
+	writeStateFile(t, tmpDir, `{"version": 0, "entries": {"a.go": {"hash": "abc"}}}`)
+	store := NewJSONStore(tmpDir)
+
+	if !store.Exists() {
+		t.Error("Exists() = false, want true for a migratable version")
+	}
+	idx, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if idx.Version != IndexVersion {
+		t.Errorf("Version = %d, want %d", idx.Version, IndexVersion)
+	}
+	if e, ok := idx.Get("a.go"); !ok || e.Path != "a.go" || e.Hash != "abc" {
+		t.Errorf("Get(a.go) = %+v, %v; want the migrated entry", e, ok)
+	}
+}
+
+func TestJSONStoreCorruptFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeStateFile(t, tmpDir, "{not json")
+	store := NewJSONStore(tmpDir)
+
+	if !store.Exists() {
+		t.Error("Exists() = false, want true so Load reports the corrupt file")
+	}
+	if _, err := store.Load(); err == nil {
+		t.Error("Load() should fail on a corrupt state file")
+	}
+}
+
 func TestTrackerStatus(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -593,6 +736,77 @@ func TestTrackerStatusNoChanges(t *testing.T) {
 	}
 }
 
+func TestTrackerRehashesOnlyTouchedFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"same.go", "touched.go"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("package "+name[:len(name)-3]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tracker := NewTracker(tmpDir, []string{"go"})
+	var hashed []string
+	tracker.scanner.hash = func(path string) (string, error) {
+		rel, _ := filepath.Rel(tmpDir, path)
+		hashed = append(hashed, rel)
+		return HashFile(path)
+	}
+	ctx := context.Background()
+
+	// Without a stored index every file is hashed.
+	if err := tracker.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	slices.Sort(hashed)
+	if want := []string{"same.go", "touched.go"}; !slices.Equal(hashed, want) {
+		t.Fatalf("initial Refresh() hashed %v, want %v", hashed, want)
+	}
+
+	// Touch one file without changing its content.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(tmpDir, "touched.go"), later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	hashed = nil
+	cs, err := tracker.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if want := []string{"touched.go"}; !slices.Equal(hashed, want) {
+		t.Errorf("Status() hashed %v, want %v", hashed, want)
+	}
+	if !cs.IsEmpty() {
+		t.Errorf("Status() = %+v, want no changes for a touch", cs)
+	}
+
+	hashed = nil
+	if err := tracker.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if want := []string{"touched.go"}; !slices.Equal(hashed, want) {
+		t.Errorf("Refresh() hashed %v, want %v", hashed, want)
+	}
+
+	hashed = nil
+	if _, err := tracker.StatusFull(ctx); err != nil {
+		t.Fatalf("StatusFull() error = %v", err)
+	}
+	slices.Sort(hashed)
+	if want := []string{"same.go", "touched.go"}; !slices.Equal(hashed, want) {
+		t.Errorf("StatusFull() hashed %v, want %v", hashed, want)
+	}
+
+	hashed = nil
+	if err := tracker.RefreshFull(ctx); err != nil {
+		t.Fatalf("RefreshFull() error = %v", err)
+	}
+	slices.Sort(hashed)
+	if want := []string{"same.go", "touched.go"}; !slices.Equal(hashed, want) {
+		t.Errorf("RefreshFull() hashed %v, want %v", hashed, want)
+	}
+}
+
 func TestTrackerHasState(t *testing.T) {
 	tmpDir := t.TempDir()
 	tracker := NewTracker(tmpDir, nil)
@@ -611,6 +825,42 @@ func TestTrackerHasState(t *testing.T) {
 	}
 }
 
+func TestTrackerRegeneratesUnsupportedVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.go"), []byte("package a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	statePath := writeStateFile(t, tmpDir, `{"version": 99, "entries": {"gone.go": {"path": "gone.go"}}}`)
+
+	tracker := NewTracker(tmpDir, []string{"go"})
+	ctx := context.Background()
+
+	if tracker.HasState() {
+		t.Error("HasState() = true, want false for an unknown version")
+	}
+	if err := tracker.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		t.Fatalf("regenerated state file is not valid JSON: %v", err)
+	}
+	if idx.Version != IndexVersion {
+		t.Errorf("regenerated version = %d, want %d", idx.Version, IndexVersion)
+	}
+	if got, err := tracker.TrackedFiles(); err != nil || !slices.Equal(got, []string{"a.go"}) {
+		t.Errorf("TrackedFiles() = %v, %v; want [a.go]", got, err)
+	}
+	if !tracker.HasState() {
+		t.Error("HasState() = false after Refresh()")
+	}
+}
+
 func TestTrackerTrackedFileCount(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -4,10 +4,35 @@ import (
 	"time"
 )
 
-// IndexVersion is the current version of the index format.
+// IndexVersion is the version of the index format this build reads and
+// writes. Bump it for changes that a build expecting the previous format
+// would misread, such as renaming or retyping a field; new fields alone do
+// not need a bump, since unknown fields are ignored when decoding. Register a
+// migration in indexMigrations for the old version if its data can be kept.
 const IndexVersion = 1
 
+// indexMigrations upgrade an index decoded from an older format, keyed by
+// the version they upgrade from; each yields the next version. An index
+// whose version has no chain of migrations up to IndexVersion is
+// unsupported. There are none yet: version 1 is the first format.
+var indexMigrations = map[int]func(*Index){}
+
 // Index represents a snapshot of files in the workspace.
+//
+// It is stored as the status manifest, .bazelle/state.json, a JSON object
+// of the form (version 1):
+//
+//	{
+//	  "version": 1,
+//	  "updated_at": "2026-01-02T15:04:05Z",
+//	  "entries": {
+//	    "pkg/a.go": {"path": "pkg/a.go", "hash": "9f2c...", "mtime_ns": 1767366245000000000, "size": 120}
+//	  }
+//	}
+//
+// "version" is the IndexVersion the file was written with. "entries" is
+// keyed by the slash-separated workspace-relative path of each file; see
+// Entry for its fields.
 type Index struct {
 	Version   int               `json:"version"`
 	UpdatedAt time.Time         `json:"updated_at"`
@@ -23,6 +48,25 @@ func NewIndex() *Index {
 	}
 }
 
+// supportedVersion reports whether an index of the given version can be
+// read, possibly after migrating it.
+func supportedVersion(version int) bool {
+	for ; version < IndexVersion; version++ {
+		if indexMigrations[version] == nil {
+			return false
+		}
+	}
+	return version == IndexVersion
+}
+
+// migrate upgrades idx, of a supported version, to IndexVersion.
+func (idx *Index) migrate() {
+	for idx.Version < IndexVersion {
+		indexMigrations[idx.Version](idx)
+		idx.Version++
+	}
+}
+
 // Add adds or updates an entry.
 func (idx *Index) Add(e *Entry) {
 	if idx == nil || e == nil {
@@ -4,7 +4,6 @@ import (
 	"context"
 	"io/fs"
 	"path/filepath"
-	"strings"
 
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
 )
@@ -19,8 +18,11 @@ type ScanConfig struct {
 // Scanner builds an Index by walking the filesystem.
 type Scanner struct {
 	root       string
-	ignoreDirs []string
+	ignorer    *langs.Ignorer
 	extensions map[string]bool
+
+	// hash computes a file's content hash (HashFile; replaced in tests)
+	hash func(path string) (string, error)
 }
 
 // NewScanner creates a scanner with the given config.
@@ -28,81 +30,47 @@ func NewScanner(cfg ScanConfig) *Scanner {
 	// Build extension filter from shared config
 	extensions := langs.ExtensionSet(cfg.Languages)
 
-	// Combine default and custom ignored dirs
-	ignoreDirs := make([]string, len(langs.IgnoredDirs))
-	copy(ignoreDirs, langs.IgnoredDirs)
-	ignoreDirs = append(ignoreDirs, cfg.IgnoreDirs...)
-
 	return &Scanner{
 		root:       cfg.Root,
-		ignoreDirs: ignoreDirs,
+		ignorer:    langs.NewIgnorer(cfg.Root, cfg.IgnoreDirs...),
 		extensions: extensions,
+		hash:       HashFile,
 	}
 }
 
-// Scan walks the filesystem and builds an Index.
+// Scan walks the filesystem and builds an Index, hashing every file.
 func (s *Scanner) Scan(ctx context.Context) (*Index, error) {
-	idx := NewIndex()
-
-	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
-		// Check context cancellation
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		if err != nil {
-			return err
-		}
-
-		// Skip ignored directories
-		if d.IsDir() {
-			name := d.Name()
-			for _, prefix := range s.ignoreDirs {
-				if strings.HasPrefix(name, prefix) {
-					return filepath.SkipDir
-				}
-			}
-			return nil
-		}
-
-		// Check if file has a tracked extension
-		ext := filepath.Ext(path)
-		if !s.extensions[ext] {
-			return nil
-		}
-
-		// Get file info
-		info, err := d.Info()
-		if err != nil {
-			return err
-		}
-
-		// Compute relative path
-		relPath, err := filepath.Rel(s.root, path)
-		if err != nil {
-			return err
-		}
+	return s.ScanIncremental(ctx, nil)
+}
 
-		// Get file hash
-		hash, err := HashFile(path)
-		if err != nil {
-			return err
-		}
+// ScanIncremental walks the filesystem and builds an Index like Scan, but
+// reuses the hash recorded in prev for files whose mtime and size are
+// unchanged, so only touched files are re-hashed. A nil prev hashes every
+// file.
+func (s *Scanner) ScanIncremental(ctx context.Context, prev *Index) (*Index, error) {
+	idx := NewIndex()
 
-		// Create entry
+	err := s.walk(ctx, func(path, relPath string, info fs.FileInfo) error {
 		entry := &Entry{
 			Path:    relPath,
-			Hash:    hash,
 			ModTime: info.ModTime().UnixNano(),
 			Size:    info.Size(),
 		}
 
+		if old, ok := prev.Get(relPath); ok && old.Hash != "" &&
+			old.ModTime == entry.ModTime && old.Size == entry.Size {
+			entry.Hash = old.Hash
+		} else {
+			hash, err := s.hash(path)
+			if err != nil {
+				return err
+			}
+			entry.Hash = hash
+		}
+
 		idx.Add(entry)
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +83,26 @@ func (s *Scanner) Scan(ctx context.Context) (*Index, error) {
 func (s *Scanner) ScanFast(ctx context.Context) (*Index, error) {
 	idx := NewIndex()
 
-	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+	err := s.walk(ctx, func(_, relPath string, info fs.FileInfo) error {
+		// Create entry without hash
+		idx.Add(&Entry{
+			Path:    relPath,
+			ModTime: info.ModTime().UnixNano(),
+			Size:    info.Size(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// walk calls visit for every tracked file under the root with its absolute
+// path, its path relative to the root and its file info.
+func (s *Scanner) walk(ctx context.Context, visit func(path, relPath string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
 		// Check context cancellation
 		select {
 		case <-ctx.Done():
@@ -127,13 +114,10 @@ func (s *Scanner) ScanFast(ctx context.Context) (*Index, error) {
 			return err
 		}
 
-		// Skip ignored directories
+		// Skip ignored directories, including .bazelignore entries
 		if d.IsDir() {
-			name := d.Name()
-			for _, prefix := range s.ignoreDirs {
-				if strings.HasPrefix(name, prefix) {
-					return filepath.SkipDir
-				}
+			if s.ignorer.SkipDir(path) {
+				return filepath.SkipDir
 			}
 			return nil
 		}
@@ -156,20 +140,6 @@ func (s *Scanner) ScanFast(ctx context.Context) (*Index, error) {
 			return err
 		}
 
-		// Create entry without hash
-		entry := &Entry{
-			Path:    relPath,
-			ModTime: info.ModTime().UnixNano(),
-			Size:    info.Size(),
-		}
-
-		idx.Add(entry)
-		return nil
+		return visit(path, relPath, info)
 	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return idx, nil
 }
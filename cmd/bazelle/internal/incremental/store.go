@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/albertocavalcante/bazelle/internal/log"
 )
 
 const (
@@ -41,7 +43,18 @@ func NewJSONStore(workspaceRoot string) *JSONStore {
 	}
 }
 
+// indexHeader is the part of a state file common to every format version.
+type indexHeader struct {
+	Version int `json:"version"`
+}
+
 // Load reads the index from disk. If the state file doesn't exist, returns an empty index.
+//
+// A state file of an unsupported version, older or newer than this build
+// handles (see IndexVersion), is treated as missing too: an empty index is
+// returned, and the next Save replaces the file. Only the version is decoded
+// before the check, so a newer format that no longer fits Index is not an
+// error either.
 func (s *JSONStore) Load() (*Index, error) {
 	data, err := os.ReadFile(s.path)
 	if os.IsNotExist(err) {
@@ -51,15 +64,21 @@ func (s *JSONStore) Load() (*Index, error) {
 		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
 
-	var idx Index
-	if err := json.Unmarshal(data, &idx); err != nil {
+	var header indexHeader
+	if err := json.Unmarshal(data, &header); err != nil {
 		return nil, fmt.Errorf("failed to parse state file: %w", err)
 	}
+	if !supportedVersion(header.Version) {
+		log.V(2).Infow("ignoring state file of unsupported version",
+			"path", s.path, "version", header.Version, "supported", IndexVersion)
+		return NewIndex(), nil
+	}
 
-	// Check version compatibility
-	if idx.Version > IndexVersion {
-		return nil, fmt.Errorf("state file version %d is newer than supported version %d", idx.Version, IndexVersion)
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
 	}
+	idx.migrate()
 
 	// Ensure entries map is initialized
 	if idx.Entries == nil {
@@ -105,10 +124,20 @@ func (s *JSONStore) Save(idx *Index) error {
 	return nil
 }
 
-// Exists returns true if the state file exists.
+// Exists returns true if the state file exists and is of a supported
+// version. A file Load would ignore counts as missing, so callers rebuild
+// the state as on a first run. A file too malformed to tell its version
+// counts as existing, leaving Load to report it.
 func (s *JSONStore) Exists() bool {
-	_, err := os.Stat(s.path)
-	return err == nil
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return false
+	}
+	var header indexHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return true
+	}
+	return supportedVersion(header.Version)
 }
 
 // Clear removes the state file and directory.
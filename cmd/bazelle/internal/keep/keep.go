@@ -0,0 +1,222 @@
+// Package keep verifies that BUILD file content marked with "# keep"
+// survives an update.
+//
+// Gazelle leaves "# keep" content alone, but a BUILD file passes through more
+// than gazelle on its way back to disk: map_kind, custom macros and the
+// language extensions all rewrite rules. This package finds the kept content
+// of a BUILD file before an update and reports any of it missing from the
+// content the update produced, so the update can fail instead of silently
+// dropping hand-written rules.
+//
+// # Markers
+//
+// A "# keep" comment (or "# keep: reason") marks, as in gazelle, a whole
+// rule, one attribute, or one element of a list attribute:
+//
+//	# keep
+//	go_library(
+//	    name = "legacy",
+//	    srcs = ["legacy.go"],
+//	)
+//
+//	go_library(
+//	    name = "app",
+//	    visibility = ["//visibility:public"],  # keep
+//	    data = [
+//	        "schema.json",  # keep
+//	    ],
+//	)
+//
+// Only named rules are tracked; calls without a name attribute such as
+// package() or exports_files() are ignored.
+package keep
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	bzl "github.com/bazelbuild/buildtools/build"
+)
+
+// Marker is one piece of kept BUILD file content.
+type Marker struct {
+	// Kind is the kind of the rule holding the content (e.g. "go_library").
+	Kind string
+
+	// Rule is the name of the rule holding the content.
+	Rule string
+
+	// Attr is the kept attribute, or the attribute holding the kept list
+	// element. Empty when the whole rule is kept.
+	Attr string
+
+	// Value is the kept list element. Empty when a whole rule or attribute
+	// is kept.
+	Value string
+}
+
+// String describes m for error messages, e.g. `go_library "legacy" data
+// "schema.json"`.
+func (m Marker) String() string {
+	s := fmt.Sprintf("%s %q", m.Kind, m.Rule)
+	if m.Attr != "" {
+		s += " " + m.Attr
+	}
+	if m.Value != "" {
+		s += " " + m.Value
+	}
+	return s
+}
+
+// Find returns the kept content of the BUILD file data, in file order. path
+// is only used in error messages.
+func Find(path string, data []byte) ([]Marker, error) {
+	rules, err := parse(path, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var markers []Marker
+	for _, r := range rules {
+		if rule.ShouldKeep(r.call) {
+			markers = append(markers, Marker{Kind: r.kind, Rule: r.name})
+			continue
+		}
+		for _, attr := range r.attrs {
+			if rule.ShouldKeep(attr.expr) {
+				markers = append(markers, Marker{Kind: r.kind, Rule: r.name, Attr: attr.key})
+				continue
+			}
+			list, ok := attr.expr.RHS.(*bzl.ListExpr)
+			if !ok {
+				continue
+			}
+			for _, elem := range list.List {
+				if rule.ShouldKeep(elem) {
+					markers = append(markers, Marker{Kind: r.kind, Rule: r.name, Attr: attr.key, Value: value(elem)})
+				}
+			}
+		}
+	}
+	return markers, nil
+}
+
+// Lost returns the kept content of before that is missing from after, the
+// content an update produced for the same BUILD file. A nil after means the
+// file is deleted, losing all of it.
+func Lost(path string, before, after []byte) ([]Marker, error) {
+	markers, err := Find(path, before)
+	if err != nil || len(markers) == 0 {
+		return nil, err
+	}
+
+	rules, err := parse(path, after)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]parsedRule, len(rules))
+	for _, r := range rules {
+		byName[r.name] = r
+	}
+
+	var lost []Marker
+	for _, m := range markers {
+		if !present(byName, m) {
+			lost = append(lost, m)
+		}
+	}
+	return lost, nil
+}
+
+// present reports whether the content m marks is in rules.
+func present(rules map[string]parsedRule, m Marker) bool {
+	r, ok := rules[m.Rule]
+	if !ok || r.kind != m.Kind {
+		return false
+	}
+	if m.Attr == "" {
+		return true
+	}
+	for _, attr := range r.attrs {
+		if attr.key != m.Attr {
+			continue
+		}
+		if m.Value == "" {
+			return true
+		}
+		list, ok := attr.expr.RHS.(*bzl.ListExpr)
+		if !ok {
+			return false
+		}
+		for _, elem := range list.List {
+			if value(elem) == m.Value {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+type parsedRule struct {
+	kind  string
+	name  string
+	call  *bzl.CallExpr
+	attrs []parsedAttr
+}
+
+type parsedAttr struct {
+	key  string
+	expr *bzl.AssignExpr
+}
+
+// parse returns the named rules of the BUILD file data in file order.
+func parse(path string, data []byte) ([]parsedRule, error) {
+	if data == nil {
+		return nil, nil
+	}
+	f, err := bzl.ParseBuild(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	var rules []parsedRule
+	for _, stmt := range f.Stmt {
+		call, ok := stmt.(*bzl.CallExpr)
+		if !ok {
+			continue
+		}
+		r := parsedRule{kind: bzl.FormatString(call.X), call: call}
+		for _, arg := range call.List {
+			assign, ok := arg.(*bzl.AssignExpr)
+			if !ok {
+				continue
+			}
+			key, ok := assign.LHS.(*bzl.Ident)
+			if !ok {
+				continue
+			}
+			if key.Name == "name" {
+				if s, ok := assign.RHS.(*bzl.StringExpr); ok {
+					r.name = s.Value
+				}
+				continue
+			}
+			r.attrs = append(r.attrs, parsedAttr{key: key.Name, expr: assign})
+		}
+		if r.name != "" {
+			rules = append(rules, r)
+		}
+	}
+	return rules, nil
+}
+
+// value returns the text a list element is matched by across versions of a
+// file. Strings are compared by value so quoting style does not matter.
+func value(e bzl.Expr) string {
+	if s, ok := e.(*bzl.StringExpr); ok {
+		return strconv.Quote(s.Value)
+	}
+	return bzl.FormatString(e)
+}
@@ -0,0 +1,141 @@
+package keep
+
+import (
+	"reflect"
+	"testing"
+)
+
+const fixture = `load("@rules_go//go:def.bzl", "go_library")
+
+# keep
+go_library(
+    name = "legacy",
+    srcs = ["legacy.go"],
+)
+
+go_library(
+    name = "app",
+    srcs = ["app.go"],
+    data = [
+        "schema.json",  # keep
+        "other.json",
+    ],
+    visibility = ["//visibility:public"],  # keep: consumed by //tools
+    deps = [":legacy"],
+)
+
+exports_files(["LICENSE"])  # keep
+`
+
+func TestFind(t *testing.T) {
+	got, err := Find("app/BUILD.bazel", []byte(fixture))
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+
+	want := []Marker{
+		{Kind: "go_library", Rule: "legacy"},
+		{Kind: "go_library", Rule: "app", Attr: "data", Value: `"schema.json"`},
+		{Kind: "go_library", Rule: "app", Attr: "visibility"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %v, want %v", got, want)
+	}
+}
+
+func TestLost(t *testing.T) {
+	tests := []struct {
+		name  string
+		after string
+		want  []Marker
+	}{
+		{
+			name:  "unchanged",
+			after: fixture,
+		},
+		{
+			name: "regenerated around kept content",
+			after: `load("@rules_go//go:def.bzl", "go_library")
+
+# keep
+go_library(
+    name = "legacy",
+    srcs = ["legacy.go"],
+)
+
+go_library(
+    name = "app",
+    srcs = [
+        "app.go",
+        "util.go",
+    ],
+    data = ['schema.json'],
+    visibility = ["//visibility:public"],
+)
+`,
+		},
+		{
+			name: "kept content removed",
+			after: `go_library(
+    name = "app",
+    srcs = ["app.go"],
+    data = ["other.json"],
+)
+`,
+			want: []Marker{
+				{Kind: "go_library", Rule: "legacy"},
+				{Kind: "go_library", Rule: "app", Attr: "data", Value: `"schema.json"`},
+				{Kind: "go_library", Rule: "app", Attr: "visibility"},
+			},
+		},
+		{
+			name: "kept rule changes kind",
+			after: `go_binary(
+    name = "legacy",
+)
+
+go_library(
+    name = "app",
+    data = ["schema.json"],
+    visibility = ["//visibility:public"],
+)
+`,
+			want: []Marker{{Kind: "go_library", Rule: "legacy"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lost("app/BUILD.bazel", []byte(fixture), []byte(tt.after))
+			if err != nil {
+				t.Fatalf("Lost() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lost() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLost_DeletedFile(t *testing.T) {
+	got, err := Lost("app/BUILD.bazel", []byte(fixture), nil)
+	if err != nil {
+		t.Fatalf("Lost() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Errorf("Lost() = %v, want all 3 markers", got)
+	}
+}
+
+func TestLost_ParseError(t *testing.T) {
+	if _, err := Lost("app/BUILD.bazel", []byte(fixture), []byte("go_library(")); err == nil {
+		t.Error("Lost() error = nil, want a parse error")
+	}
+}
+
+func TestMarkerString(t *testing.T) {
+	m := Marker{Kind: "go_library", Rule: "app", Attr: "data", Value: `"schema.json"`}
+	if got, want := m.String(), `go_library "app" data "schema.json"`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package langs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/internal/log"
+)
+
+// BazelIgnoreFile is the workspace-root file listing directories Bazel does
+// not consider part of the workspace.
+const BazelIgnoreFile = ".bazelignore"
+
+// LoadBazelIgnore reads root/.bazelignore and returns the listed directories
+// as clean slash-separated paths relative to root. Blank lines and lines
+// starting with "#" are skipped, as are entries that point outside the
+// workspace. A missing file yields no entries.
+func LoadBazelIgnore(root string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, BazelIgnoreFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		dir := path.Clean(filepath.ToSlash(line))
+		if dir == "." || dir == ".." || strings.HasPrefix(dir, "../") || path.IsAbs(dir) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// Ignorer decides which directories a workspace walk skips: any whose name
+// starts with one of IgnoredDirs, and the paths listed in .bazelignore along
+// with everything under them.
+type Ignorer struct {
+	root     string
+	prefixes []string
+	paths    map[string]bool
+}
+
+// NewIgnorer creates an Ignorer for the workspace at root. extra adds name
+// prefixes on top of IgnoredDirs. An unreadable .bazelignore is logged and
+// otherwise disregarded, matching Gazelle.
+func NewIgnorer(root string, extra ...string) *Ignorer {
+	dirs, err := LoadBazelIgnore(root)
+	if err != nil {
+		log.Warn("failed to read "+BazelIgnoreFile, "root", root, "error", err)
+	}
+
+	paths := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		paths[dir] = true
+	}
+	return &Ignorer{
+		root:     root,
+		prefixes: append(append([]string(nil), IgnoredDirs...), extra...),
+		paths:    paths,
+	}
+}
+
+// SkipDir reports whether the directory at p should be skipped. p is a path
+// as seen by a filepath.WalkDir callback over root or one of its
+// subdirectories. A directory is skipped if its name has an ignored prefix or
+// it is, or is under, a .bazelignore entry. The workspace root itself is
+// never skipped.
+func (ig *Ignorer) SkipDir(p string) bool {
+	rel, err := filepath.Rel(ig.root, p)
+	if err != nil || rel == "." {
+		return false
+	}
+
+	name := filepath.Base(p)
+	for _, prefix := range ig.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for dir := filepath.ToSlash(rel); dir != "."; dir = path.Dir(dir) {
+		if ig.paths[dir] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package langs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadBazelIgnore(t *testing.T) {
+	root := t.TempDir()
+	content := "# generated trees\nthird_party/node\n\n  ./gen/  \n../outside\n/abs\n.\n"
+	if err := os.WriteFile(filepath.Join(root, BazelIgnoreFile), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadBazelIgnore(root)
+	if err != nil {
+		t.Fatalf("LoadBazelIgnore() error = %v", err)
+	}
+	if want := []string{"third_party/node", "gen"}; !slices.Equal(got, want) {
+		t.Errorf("LoadBazelIgnore() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadBazelIgnore_Missing(t *testing.T) {
+	got, err := LoadBazelIgnore(t.TempDir())
+	if err != nil || got != nil {
+		t.Errorf("LoadBazelIgnore() = %v, %v; want nil, nil", got, err)
+	}
+}
+
+func TestIgnorer_SkipDir(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, BazelIgnoreFile), []byte("third_party/node\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ig := NewIgnorer(root, "generated")
+
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{".", false},
+		{"src", false},
+		{"third_party", false},
+		{"third_party/node", true},
+		{"third_party/node/pkg", true},
+		{"third_party/nodejs", false},
+		{"src/bazel-out", true},
+		{"src/.cache", true},
+		{"src/generated_protos", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			if got := ig.SkipDir(filepath.Join(root, tt.rel)); got != tt.want {
+				t.Errorf("SkipDir(%q) = %v, want %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
@@ -25,8 +25,24 @@
 //  1. Add an entry to the Extensions map below
 //  2. Ensure the extension list is complete for the language
 //  3. Update any language-specific gazelle extensions
+//
+// # Custom Extensions
+//
+// Projects that use extensions beyond the built-in ones (say ".ktm" for
+// Kotlin) list them in the [extensions] table of their configuration, which
+// AddExtensions registers at startup.
 package langs
 
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/gazelle-python/python"
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+)
+
 // Extensions maps language names to their file extensions.
 //
 // This is the SINGLE SOURCE OF TRUTH for file extension filtering
@@ -46,6 +62,60 @@ var Extensions = map[string][]string{
 	"rust":   {".rs"},
 }
 
+// AddExtensions registers extra file extensions per language, such as
+// {"kotlin": {".ktm"}}, in Extensions, so the watcher, the incremental
+// scanner and the directory parsers treat those files as sources of that
+// language. The JVM and Python extensions are registered with their gazelle
+// extensions too, so the rules they generate glob those files. A missing
+// leading dot is added, and extensions the language already has are skipped.
+//
+// Nothing is registered if a language is unknown or an extension already
+// belongs to another language. Call it at startup, before anything reads
+// Extensions.
+func AddExtensions(extra map[string][]string) error {
+	owner := make(map[string]string)
+	for lang, exts := range Extensions {
+		for _, ext := range exts {
+			owner[ext] = lang
+		}
+	}
+
+	added := make(map[string][]string)
+	for _, lang := range slices.Sorted(maps.Keys(extra)) {
+		if _, ok := Extensions[lang]; !ok {
+			return fmt.Errorf("extensions: unknown language %q", lang)
+		}
+		for _, ext := range extra[lang] {
+			ext = strings.TrimSpace(ext)
+			if ext == "" || ext == "." {
+				return fmt.Errorf("extensions: empty extension for %s", lang)
+			}
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+			if other, ok := owner[ext]; ok {
+				if other != lang {
+					return fmt.Errorf("extensions: %s is already a %s extension, cannot add it to %s", ext, other, lang)
+				}
+				continue
+			}
+			owner[ext] = lang
+			added[lang] = append(added[lang], ext)
+		}
+	}
+
+	for lang, exts := range added {
+		Extensions[lang] = append(slices.Clip(Extensions[lang]), exts...)
+		switch {
+		case lang == "python":
+			python.AddFileExtensions(exts...)
+		case slices.Contains(jvm.AllLanguages(), jvm.Language(lang)):
+			jvm.AddFileExtensions(jvm.Language(lang), exts...)
+		}
+	}
+	return nil
+}
+
 // IgnoredDirs contains directory prefixes to skip during scanning/watching.
 //
 // These patterns are DETERMINISTIC: any directory starting with one of
@@ -53,6 +123,9 @@ var Extensions = map[string][]string{
 // vendored dependencies, and other non-source directories.
 //
 // Note: Prefix matching means "bazel-" matches "bazel-out", "bazel-bin", etc.
+//
+// Workspace walks should go through an Ignorer, which also honors the
+// workspace's .bazelignore.
 var IgnoredDirs = []string{
 	"bazel-",       // Bazel output directories
 	".",            // Hidden directories
@@ -0,0 +1,51 @@
+package langs
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// restoreExtensions undoes the registrations of a test.
+func restoreExtensions(t *testing.T) {
+	t.Helper()
+	saved := maps.Clone(Extensions)
+	t.Cleanup(func() { Extensions = saved })
+}
+
+func TestAddExtensions(t *testing.T) {
+	restoreExtensions(t)
+
+	if err := AddExtensions(map[string][]string{"kotlin": {".ktm", "ktx", ".kt"}}); err != nil {
+		t.Fatalf("AddExtensions() error = %v", err)
+	}
+
+	if got := Extensions["kotlin"]; !slices.Equal(got, []string{".kt", ".kts", ".ktm", ".ktx"}) {
+		t.Errorf("kotlin extensions = %v, want [.kt .kts .ktm .ktx]", got)
+	}
+	if set := ExtensionSet([]string{"kotlin"}); !set[".ktm"] || set[".py"] {
+		t.Errorf("ExtensionSet(kotlin) = %v, want .ktm and no .py", set)
+	}
+}
+
+func TestAddExtensions_Invalid(t *testing.T) {
+	tests := map[string]map[string][]string{
+		"unknown language":     {"cobol": {".cbl"}},
+		"owned by other":       {"kotlin": {".ktm", ".py"}},
+		"empty extension":      {"kotlin": {""}},
+		"claimed by two langs": {"kotlin": {".x"}, "scala": {".x"}},
+	}
+	for name, extra := range tests {
+		t.Run(name, func(t *testing.T) {
+			restoreExtensions(t)
+			before := maps.Clone(Extensions)
+
+			if err := AddExtensions(extra); err == nil {
+				t.Fatal("AddExtensions() succeeded, want error")
+			}
+			if !maps.EqualFunc(Extensions, before, slices.Equal) {
+				t.Errorf("Extensions changed despite the error: %v", Extensions)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package output controls where the BUILD files generated by a gazelle run
+// end up.
+//
+// By default gazelle rewrites BUILD files in place. Run accepts a Writer
+// instead, so callers embedding bazelle can capture the generated content in
+// memory, write it to a virtual file system or transform it first.
+package output
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/runner"
+)
+
+// Writer receives the BUILD files produced by a gazelle run.
+type Writer interface {
+	// WriteBuildFile stores the content generated for a BUILD file. rel is
+	// the slash-separated path of the file relative to the workspace root
+	// (e.g. "src/app/BUILD.bazel").
+	WriteBuildFile(rel string, content []byte) error
+}
+
+// FSWriter writes BUILD files under Root, creating directories as needed.
+type FSWriter struct {
+	Root string
+}
+
+// WriteBuildFile writes content to Root/rel.
+func (w FSWriter) WriteBuildFile(rel string, content []byte) error {
+	path := filepath.Join(w.Root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o644)
+}
+
+// MemWriter keeps BUILD files in memory. The zero value is ready to use and
+// MemWriter is safe for concurrent use.
+type MemWriter struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// WriteBuildFile records content for rel, replacing any earlier content.
+func (w *MemWriter) WriteBuildFile(rel string, content []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.files == nil {
+		w.files = make(map[string][]byte)
+	}
+	w.files[rel] = slices.Clone(content)
+	return nil
+}
+
+// Files returns a copy of the recorded files keyed by relative path.
+func (w *MemWriter) Files() map[string][]byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return maps.Clone(w.files)
+}
+
+// Run runs gazelle like runner.Run and hands every BUILD file it changes to w.
+// A nil w lets gazelle rewrite the files in the workspace itself.
+//
+// args must start with the gazelle command ("update" or "fix"). Gazelle is
+// pointed at a scratch directory for its output, which is then passed to w in
+// path order, so the workspace is left untouched. Files gazelle would leave
+// unchanged are not written.
+func Run(langs []language.Language, root string, w Writer, args ...string) error {
+	if w == nil {
+		return runner.Run(langs, root, args...)
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("missing gazelle command")
+	}
+
+	scratch, err := os.MkdirTemp("", "bazelle-output-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(scratch) }()
+
+	runArgs := make([]string, 0, len(args)+1)
+	runArgs = append(runArgs, args[0], "-experimental_write_build_files_dir="+scratch)
+	runArgs = append(runArgs, args[1:]...)
+	if err := runner.Run(langs, root, runArgs...); err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(scratch, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(scratch, path)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteBuildFile(filepath.ToSlash(rel), content); err != nil {
+			return fmt.Errorf("write %s: %w", filepath.ToSlash(rel), err)
+		}
+		return nil
+	})
+}
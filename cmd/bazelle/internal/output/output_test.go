@@ -0,0 +1,115 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+// fakeLang generates a filegroup named after each directory holding a
+// ".txt" file.
+type fakeLang struct {
+	language.BaseLang
+}
+
+func (*fakeLang) Name() string { return "fake" }
+
+func (*fakeLang) Kinds() map[string]rule.KindInfo {
+	return map[string]rule.KindInfo{
+		"filegroup": {MergeableAttrs: map[string]bool{"srcs": true}},
+	}
+}
+
+func (*fakeLang) GenerateRules(args language.GenerateArgs) language.GenerateResult {
+	var srcs []string
+	for _, f := range args.RegularFiles {
+		if strings.HasSuffix(f, ".txt") {
+			srcs = append(srcs, f)
+		}
+	}
+	if len(srcs) == 0 {
+		return language.GenerateResult{}
+	}
+	r := rule.NewRule("filegroup", "files")
+	r.SetAttr("srcs", srcs)
+	return language.GenerateResult{Gen: []*rule.Rule{r}, Imports: []interface{}{nil}}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun_MemWriter(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "MODULE.bazel"), "")
+	writeFile(t, filepath.Join(root, "app", "a.txt"), "a")
+	writeFile(t, filepath.Join(root, "lib", "b.txt"), "b")
+
+	var w MemWriter
+	langs := []language.Language{&fakeLang{}}
+	if err := Run(langs, root, &w, "update", "-repo_root="+root); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	files := w.Files()
+	if len(files) != 2 {
+		t.Fatalf("captured %d files, want 2: %v", len(files), files)
+	}
+	for rel, src := range map[string]string{"app/BUILD.bazel": "a.txt", "lib/BUILD.bazel": "b.txt"} {
+		content, ok := files[rel]
+		if !ok {
+			t.Errorf("no content captured for %s", rel)
+			continue
+		}
+		if !strings.Contains(string(content), `name = "files"`) || !strings.Contains(string(content), src) {
+			t.Errorf("%s = %q, want a filegroup over %s", rel, content, src)
+		}
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(rel))); !os.IsNotExist(err) {
+			t.Errorf("%s was written to the workspace (stat error = %v)", rel, err)
+		}
+	}
+}
+
+func TestRun_NilWriterWritesInPlace(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "MODULE.bazel"), "")
+	writeFile(t, filepath.Join(root, "app", "a.txt"), "a")
+
+	if err := Run([]language.Language{&fakeLang{}}, root, nil, "update", "-repo_root="+root); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "app", "BUILD.bazel")); err != nil {
+		t.Errorf("BUILD file not written in place: %v", err)
+	}
+}
+
+func TestFSWriter(t *testing.T) {
+	root := t.TempDir()
+	w := FSWriter{Root: root}
+	if err := w.WriteBuildFile("a/b/BUILD.bazel", []byte("# generated\n")); err != nil {
+		t.Fatalf("WriteBuildFile() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(root, "a", "b", "BUILD.bazel"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "# generated\n" {
+		t.Errorf("content = %q, want %q", got, "# generated\n")
+	}
+}
+
+func TestRun_MissingCommand(t *testing.T) {
+	if err := Run(nil, t.TempDir(), &MemWriter{}); err == nil {
+		t.Error("Run() without a command should fail")
+	}
+}
@@ -0,0 +1,153 @@
+// Package progress reports how far a gazelle update run has got.
+//
+// Gazelle's runner does not expose its directory walk, but it calls
+// GenerateRules once per language for every directory it updates. Languages
+// wraps each extension so those calls are reported as Events, which the CLI
+// renders as a progress line and the daemon broadcasts to its clients.
+package progress
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sync"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/resolve"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+// Event describes the state of an update run when a language starts
+// generating rules for a directory.
+type Event struct {
+	// Done is the number of directories started so far, including Dir.
+	Done int `json:"done"`
+
+	// Total is the expected number of directories. It is an estimate made
+	// before the run and is raised if the run visits more directories.
+	Total int `json:"total"`
+
+	// Dir is the slash-separated directory being processed, relative to the
+	// workspace root ("" for the root).
+	Dir string `json:"dir"`
+
+	// Language is the name of the extension generating rules for Dir.
+	Language string `json:"language"`
+}
+
+// Func receives progress events. Calls are serialized and Done never
+// decreases from one call to the next.
+type Func func(Event)
+
+// reporter counts distinct directories across all wrapped languages.
+type reporter struct {
+	mu    sync.Mutex
+	fn    Func
+	total int
+	seen  map[string]bool
+}
+
+func (r *reporter) report(rel, lang string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seen[rel] = true
+	done := len(r.seen)
+	r.total = max(r.total, done)
+	r.fn(Event{Done: done, Total: r.total, Dir: rel, Language: lang})
+}
+
+// Languages returns langs wrapped to report to fn, expecting total
+// directories. A nil fn returns langs unchanged.
+func Languages(langs []language.Language, total int, fn Func) []language.Language {
+	if fn == nil {
+		return langs
+	}
+
+	r := &reporter{fn: fn, total: total, seen: make(map[string]bool)}
+	wrapped := make([]language.Language, len(langs))
+	for i, l := range langs {
+		wrapped[i] = &reportingLanguage{Language: l, r: r}
+	}
+	return wrapped
+}
+
+// reportingLanguage reports each GenerateRules call before delegating.
+//
+// The runner type-asserts languages for optional interfaces, so the wrapper
+// implements all of them and forwards to the wrapped language when it does.
+type reportingLanguage struct {
+	language.Language
+	r *reporter
+}
+
+var (
+	_ language.LifecycleManager    = (*reportingLanguage)(nil)
+	_ language.ModuleAwareLanguage = (*reportingLanguage)(nil)
+	_ resolve.CrossResolver        = (*reportingLanguage)(nil)
+)
+
+func (l *reportingLanguage) GenerateRules(args language.GenerateArgs) language.GenerateResult {
+	l.r.report(args.Rel, l.Name())
+	return l.Language.GenerateRules(args)
+}
+
+func (l *reportingLanguage) ApparentLoads(moduleToApparentName func(string) string) []rule.LoadInfo {
+	if m, ok := l.Language.(language.ModuleAwareLanguage); ok {
+		return m.ApparentLoads(moduleToApparentName)
+	}
+	return l.Loads()
+}
+
+func (l *reportingLanguage) Before(ctx context.Context) {
+	if m, ok := l.Language.(language.LifecycleManager); ok {
+		m.Before(ctx)
+	}
+}
+
+func (l *reportingLanguage) DoneGeneratingRules() {
+	if f, ok := l.Language.(language.FinishableLanguage); ok {
+		f.DoneGeneratingRules()
+	}
+}
+
+func (l *reportingLanguage) AfterResolvingDeps(ctx context.Context) {
+	if m, ok := l.Language.(language.LifecycleManager); ok {
+		m.AfterResolvingDeps(ctx)
+	}
+}
+
+func (l *reportingLanguage) CrossResolve(c *config.Config, ix *resolve.RuleIndex, imp resolve.ImportSpec, lang string) []resolve.FindResult {
+	if cr, ok := l.Language.(resolve.CrossResolver); ok {
+		return cr.CrossResolve(c, ix, imp, lang)
+	}
+	return nil
+}
+
+// CountDirs estimates how many directories an update of dirs (relative to
+// root; none means the whole workspace) will visit. Directories matching
+// langs.IgnoredDirs or listed in .bazelignore are not counted.
+func CountDirs(root string, dirs []string) int {
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	ignorer := langs.NewIgnorer(root)
+	count := 0
+	for _, dir := range dirs {
+		start := filepath.Join(root, filepath.FromSlash(dir))
+		_ = filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if path != start && ignorer.SkipDir(path) {
+				return filepath.SkipDir
+			}
+			count++
+			return nil
+		})
+	}
+	return count
+}
@@ -0,0 +1,140 @@
+package progress
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/runner"
+)
+
+type fakeLang struct {
+	language.BaseLang
+	name string
+}
+
+func (l *fakeLang) Name() string { return l.name }
+
+func makeWorkspace(t *testing.T, dirs ...string) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "MODULE.bazel"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLanguages_ReportsMonotonicProgress(t *testing.T) {
+	root := makeWorkspace(t, "src/app", "src/lib/util", "tools", "bazel-out/bin")
+
+	total := CountDirs(root, nil)
+	// ".", src, src/app, src/lib, src/lib/util, tools; bazel-out is ignored.
+	if total != 6 {
+		t.Fatalf("CountDirs() = %d, want 6", total)
+	}
+
+	var events []Event
+	langs := Languages([]language.Language{&fakeLang{name: "alpha"}, &fakeLang{name: "beta"}}, total,
+		func(ev Event) { events = append(events, ev) })
+
+	if err := runner.Run(langs, root, "update", "-repo_root="+root); err != nil {
+		t.Fatalf("runner.Run() error = %v", err)
+	}
+
+	if len(events) == 0 {
+		t.Fatal("no progress events")
+	}
+
+	dirs := make(map[string]bool)
+	perLang := make(map[string]int)
+	for i, ev := range events {
+		if i > 0 && ev.Done < events[i-1].Done {
+			t.Errorf("event %d: Done = %d after %d, want non-decreasing", i, ev.Done, events[i-1].Done)
+		}
+		if ev.Done > ev.Total {
+			t.Errorf("event %d: Done = %d > Total = %d", i, ev.Done, ev.Total)
+		}
+		dirs[ev.Dir] = true
+		perLang[ev.Language]++
+	}
+
+	last := events[len(events)-1]
+	if last.Done != len(dirs) {
+		t.Errorf("final Done = %d, want %d distinct dirs", last.Done, len(dirs))
+	}
+	if len(dirs) < 4 {
+		t.Errorf("visited %d dirs, want at least 4: %v", len(dirs), dirs)
+	}
+	if perLang["alpha"] != len(dirs) || perLang["beta"] != len(dirs) {
+		t.Errorf("per-language events = %v, want %d each", perLang, len(dirs))
+	}
+}
+
+func TestLanguages_NilFunc(t *testing.T) {
+	in := []language.Language{&fakeLang{name: "alpha"}}
+	if out := Languages(in, 1, nil); &out[0] != &in[0] {
+		t.Error("Languages(nil fn) should return the input unchanged")
+	}
+}
+
+func TestLanguages_TotalGrows(t *testing.T) {
+	var got []Event
+	l := Languages([]language.Language{&fakeLang{name: "alpha"}}, 1,
+		func(ev Event) { got = append(got, ev) })[0]
+
+	for _, rel := range []string{"", "a", "a", "b"} {
+		l.GenerateRules(language.GenerateArgs{Rel: rel})
+	}
+
+	want := []Event{
+		{Done: 1, Total: 1, Dir: "", Language: "alpha"},
+		{Done: 2, Total: 2, Dir: "a", Language: "alpha"},
+		{Done: 2, Total: 2, Dir: "a", Language: "alpha"},
+		{Done: 3, Total: 3, Dir: "b", Language: "alpha"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	r := &Renderer{w: &buf, enabled: true}
+
+	r.Render(Event{Done: 1, Total: 12, Dir: "", Language: "kotlin"})
+	r.Render(Event{Done: 2, Total: 12, Dir: "src/app", Language: "python"})
+	r.Finish()
+
+	out := buf.String()
+	for _, want := range []string{"\r[ 1/12] kotlin .", "\r[ 2/12] python src/app"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q missing %q", out, want)
+		}
+	}
+	if strings.Contains(out, "\033[") {
+		t.Errorf("output %q has color codes, want none", out)
+	}
+	if !strings.HasSuffix(out, "\r") {
+		t.Errorf("output %q should end by returning to column 0", out)
+	}
+}
+
+func TestRenderer_NotTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	if fn := NewRenderer(&buf, false).Func(); fn != nil {
+		t.Error("Func() should be nil when the writer is not a terminal")
+	}
+}
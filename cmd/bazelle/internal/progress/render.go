@@ -0,0 +1,75 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Renderer draws events as a single progress line that is rewritten in
+// place, e.g. "[ 12/340] kotlin src/app/auth".
+//
+// Nothing is drawn unless the writer is a terminal, so redirected output and
+// CI logs stay clean.
+type Renderer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	enabled bool
+	color   bool
+	width   int // length of the last line drawn, for clearing
+}
+
+// NewRenderer creates a renderer writing to w. Color is used only when
+// noColor is false and w is a terminal.
+func NewRenderer(w io.Writer, noColor bool) *Renderer {
+	isTTY := false
+	if f, ok := w.(*os.File); ok {
+		isTTY = term.IsTerminal(int(f.Fd()))
+	}
+	return &Renderer{w: w, enabled: isTTY, color: isTTY && !noColor}
+}
+
+// Func returns the renderer as a progress callback, or nil if it draws
+// nothing, so callers can skip wrapping languages entirely.
+func (r *Renderer) Func() Func {
+	if !r.enabled {
+		return nil
+	}
+	return r.Render
+}
+
+// Render draws ev, replacing the previous line.
+func (r *Renderer) Render(ev Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dir := ev.Dir
+	if dir == "" {
+		dir = "."
+	}
+	count := fmt.Sprintf("[%*d/%d]", len(fmt.Sprint(ev.Total)), ev.Done, ev.Total)
+	line := fmt.Sprintf("%s %s %s", count, ev.Language, dir)
+	width := len(line)
+	if r.color {
+		line = "\033[36m" + count + "\033[0m" + line[len(count):]
+	}
+
+	pad := max(r.width-width, 0)
+	_, _ = fmt.Fprintf(r.w, "\r%s%*s", line, pad, "")
+	r.width = width
+}
+
+// Finish clears the progress line, if one was drawn.
+func (r *Renderer) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.width == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(r.w, "\r%*s\r", r.width, "")
+	r.width = 0
+}
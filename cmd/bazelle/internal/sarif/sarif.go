@@ -0,0 +1,147 @@
+// Package sarif reports stale BUILD files as a SARIF 2.1.0 log.
+//
+// SARIF (Static Analysis Results Interchange Format) is what GitHub code
+// scanning and most CI dashboards ingest. Emitting staleness in it lets a CI
+// job upload the output of "bazelle status --format=sarif" or "bazelle update
+// --check --format=sarif" and have each stale BUILD file flagged inline on the
+// pull request.
+//
+// Only the subset of SARIF needed for file-level results is modeled: one run,
+// one rule, and one result per stale BUILD file with its path relative to the
+// workspace root (%SRCROOT%).
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"slices"
+)
+
+const (
+	// Version is the SARIF version of the emitted logs.
+	Version = "2.1.0"
+
+	// Schema is the JSON schema the emitted logs conform to.
+	Schema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// RuleStaleBuild is the rule id of a stale BUILD file result.
+	RuleStaleBuild = "bazelle/stale-build"
+
+	// SrcRoot is the base id of result locations: the workspace root.
+	SrcRoot = "%SRCROOT%"
+)
+
+// Log is a SARIF log file.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is the output of a single tool invocation.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the tool that produced a run.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver is the tool component that produced the results.
+type Driver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule describes a kind of result.
+type Rule struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name,omitempty"`
+	ShortDescription Message `json:"shortDescription"`
+	Help             Message `json:"help"`
+}
+
+// Message is a plain text message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is one finding.
+type Result struct {
+	RuleID    string     `json:"ruleId"`
+	Level     string     `json:"level"`
+	Message   Message    `json:"message"`
+	Locations []Location `json:"locations"`
+}
+
+// Location is where a result was found.
+type Location struct {
+	PhysicalLocation PhysicalLocation `json:"physicalLocation"`
+}
+
+// PhysicalLocation is a location in a file.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+}
+
+// ArtifactLocation is a file, relative to the base given by URIBaseID.
+type ArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+// staleBuildRule describes RuleStaleBuild.
+var staleBuildRule = Rule{
+	ID:               RuleStaleBuild,
+	Name:             "StaleBuildFile",
+	ShortDescription: Message{Text: "BUILD file is out of date with its sources"},
+	Help:             Message{Text: "Run 'bazelle update' and commit the regenerated BUILD files."},
+}
+
+// StaleBuildLog returns a log with one RuleStaleBuild result per BUILD file
+// in buildFiles, which are slash-separated paths relative to the workspace
+// root. Results are sorted by path.
+func StaleBuildLog(buildFiles []string) *Log {
+	buildFiles = slices.Sorted(slices.Values(buildFiles))
+	results := make([]Result, 0, len(buildFiles))
+	for _, f := range buildFiles {
+		pkg := path.Dir(f)
+		if pkg == "." {
+			pkg = "the root package"
+		}
+		results = append(results, Result{
+			RuleID:  RuleStaleBuild,
+			Level:   "error",
+			Message: Message{Text: "BUILD file of " + pkg + " is stale; run 'bazelle update' to regenerate it."},
+			Locations: []Location{{
+				PhysicalLocation: PhysicalLocation{
+					ArtifactLocation: ArtifactLocation{URI: f, URIBaseID: SrcRoot},
+				},
+			}},
+		})
+	}
+
+	return &Log{
+		Schema:  Schema,
+		Version: Version,
+		Runs: []Run{{
+			Tool: Tool{Driver: Driver{
+				Name:           "bazelle",
+				InformationURI: "https://github.com/albertocavalcante/bazelle",
+				Rules:          []Rule{staleBuildRule},
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// Write writes l to w as indented JSON.
+func (l *Log) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(l)
+}
@@ -0,0 +1,85 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestStaleBuildLog(t *testing.T) {
+	var buf bytes.Buffer
+	if err := StaleBuildLog([]string{"lib/BUILD", "BUILD.bazel", "app/BUILD.bazel"}).Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	// Decode generically so the check covers the emitted field names
+	var log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string                `json:"ruleId"`
+				Level     string                `json:"level"`
+				Message   struct{ Text string } `json:"message"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI       string `json:"uri"`
+							URIBaseID string `json:"uriBaseId"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("emitted SARIF is not valid JSON: %v\n%s", err, buf.String())
+	}
+
+	if log.Version != "2.1.0" || log.Schema != Schema {
+		t.Errorf("version/schema = %q/%q, want 2.1.0/%s", log.Version, log.Schema, Schema)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("len(runs) = %d, want 1", len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "bazelle" || len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != RuleStaleBuild {
+		t.Errorf("driver = %+v, want bazelle with the %s rule", run.Tool.Driver, RuleStaleBuild)
+	}
+
+	want := []string{"BUILD.bazel", "app/BUILD.bazel", "lib/BUILD"}
+	if len(run.Results) != len(want) {
+		t.Fatalf("len(results) = %d, want %d", len(run.Results), len(want))
+	}
+	for i, r := range run.Results {
+		if r.RuleID != RuleStaleBuild || r.Level != "error" || r.Message.Text == "" {
+			t.Errorf("result %d = %+v, want a %s error with a message", i, r, RuleStaleBuild)
+		}
+		if len(r.Locations) != 1 {
+			t.Fatalf("result %d has %d locations, want 1", i, len(r.Locations))
+		}
+		loc := r.Locations[0].PhysicalLocation.ArtifactLocation
+		if loc.URI != want[i] || loc.URIBaseID != SrcRoot {
+			t.Errorf("result %d location = %+v, want %s under %s", i, loc, want[i], SrcRoot)
+		}
+	}
+}
+
+func TestStaleBuildLog_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := StaleBuildLog(nil).Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	// An empty results array (not null) tells consumers the run is clean
+	if !bytes.Contains(buf.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("empty log should have an empty results array:\n%s", buf.String())
+	}
+}
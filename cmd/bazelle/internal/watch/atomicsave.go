@@ -0,0 +1,151 @@
+package watch
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// atomicSaveWindow is how long a removed source file may take to reappear
+// for the removal and the re-creation to count as a single save, and how
+// long after a reported change further events for the file are folded into
+// it.
+const atomicSaveWindow = 100 * time.Millisecond
+
+// saveTracker turns the events of source files into changes, recognizing
+// the ways editors save atomically instead of writing the file in place:
+//
+//   - write a temporary file and rename it over the original (most
+//     editors): the original gets a create event, but it is modified, not
+//     added;
+//   - move the original aside, then write a new one (Vim, JetBrains "safe
+//     write"): the original gets a rename and a create event, which would
+//     otherwise be reported as a deletion and an addition.
+//
+// The temporary files themselves are ignored, see isEditorTempFile. Each
+// change is passed to onChange once, and events that merely follow it, like
+// a write after the create, are passed with quiet set so the directory is
+// still updated but the change not reported twice.
+type saveTracker struct {
+	window   time.Duration
+	onChange func(path string, change ChangeType, quiet bool)
+
+	mu       sync.Mutex
+	known    map[string]bool        // source files known to exist
+	removed  map[string]*time.Timer // removals waiting to be undone
+	reported map[string]time.Time   // when a change of the file was last reported
+	stopped  bool
+}
+
+func newSaveTracker(window time.Duration, onChange func(path string, change ChangeType, quiet bool)) *saveTracker {
+	return &saveTracker{
+		window:   window,
+		onChange: onChange,
+		known:    make(map[string]bool),
+		removed:  make(map[string]*time.Timer),
+		reported: make(map[string]time.Time),
+	}
+}
+
+// add records that the source file at path exists, so a create event for
+// it is a modification.
+func (s *saveTracker) add(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.known[path] = true
+}
+
+// event handles op on the source file at path. A removal is reported only
+// once the save window passes without the file being created again.
+func (s *saveTracker) event(path string, op fsnotify.Op) {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+
+	var change ChangeType
+	switch {
+	case op.Has(fsnotify.Create):
+		if timer, ok := s.removed[path]; ok {
+			timer.Stop()
+			delete(s.removed, path)
+			change = ChangeModified
+		} else if s.known[path] {
+			change = ChangeModified
+		} else {
+			change = ChangeAdded
+		}
+		s.known[path] = true
+	case op.Has(fsnotify.Write):
+		change = ChangeModified
+	case op.Has(fsnotify.Remove) || op.Has(fsnotify.Rename):
+		if _, ok := s.removed[path]; !ok {
+			s.removed[path] = time.AfterFunc(s.window, func() { s.expire(path) })
+		}
+		s.mu.Unlock()
+		return
+	default:
+		s.mu.Unlock()
+		return // Ignore chmod events
+	}
+
+	now := time.Now()
+	quiet := now.Sub(s.reported[path]) < s.window
+	if !quiet {
+		s.reported[path] = now
+	}
+	s.mu.Unlock()
+
+	s.onChange(path, change, quiet)
+}
+
+// expire reports the removal of path, which was not undone in time.
+func (s *saveTracker) expire(path string) {
+	s.mu.Lock()
+	if _, ok := s.removed[path]; !ok || s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.removed, path)
+	delete(s.known, path)
+	delete(s.reported, path)
+	s.mu.Unlock()
+
+	s.onChange(path, ChangeDeleted, false)
+}
+
+// stop drops the removals still waiting to be reported.
+func (s *saveTracker) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	for path, timer := range s.removed {
+		timer.Stop()
+		delete(s.removed, path)
+	}
+}
+
+// isEditorTempFile reports whether the file at path is one editors create
+// while saving or editing another: Vim swap and backup files and its "4913"
+// write probe, Emacs lock and auto-save files, JetBrains safe-write files and
+// the ".tmp" files of rename-over-original saves.
+func isEditorTempFile(path string) bool {
+	name := filepath.Base(path)
+	switch {
+	case name == "4913",
+		strings.HasPrefix(name, ".#"),
+		strings.HasPrefix(name, "#") && strings.HasSuffix(name, "#"),
+		strings.HasSuffix(name, "~"):
+		return true
+	}
+	for _, suffix := range []string{".swp", ".swx", ".tmp", "___jb_tmp___", "___jb_old___"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package watch
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// recordedChanges collects the changes a saveTracker reports.
+type recordedChanges struct {
+	mu      sync.Mutex
+	changes []string // "<change> <path>", quiet ones marked "(quiet)"
+}
+
+func (r *recordedChanges) record(path string, change ChangeType, quiet bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := fmt.Sprintf("%s %s", change, path)
+	if quiet {
+		entry += " (quiet)"
+	}
+	r.changes = append(r.changes, entry)
+}
+
+func (r *recordedChanges) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.changes)
+}
+
+func TestSaveTracker(t *testing.T) {
+	const window = 50 * time.Millisecond
+	tests := []struct {
+		name   string
+		known  bool // Foo.kt exists before the events
+		events []fsnotify.Op
+		want   []string
+	}{
+		{
+			name:   "write in place",
+			known:  true,
+			events: []fsnotify.Op{fsnotify.Write, fsnotify.Write},
+			want:   []string{"~ Foo.kt", "~ Foo.kt (quiet)"},
+		},
+		{
+			name:   "rename over the original",
+			known:  true,
+			events: []fsnotify.Op{fsnotify.Create},
+			want:   []string{"~ Foo.kt"},
+		},
+		{
+			name:   "move the original aside and write a new one",
+			known:  true,
+			events: []fsnotify.Op{fsnotify.Rename, fsnotify.Create, fsnotify.Write, fsnotify.Chmod},
+			want:   []string{"~ Foo.kt", "~ Foo.kt (quiet)"},
+		},
+		{
+			name:   "remove and re-create",
+			known:  true,
+			events: []fsnotify.Op{fsnotify.Remove, fsnotify.Create},
+			want:   []string{"~ Foo.kt"},
+		},
+		{
+			name:   "new file",
+			events: []fsnotify.Op{fsnotify.Create, fsnotify.Write},
+			want:   []string{"+ Foo.kt", "~ Foo.kt (quiet)"},
+		},
+		{
+			name:   "deletion",
+			known:  true,
+			events: []fsnotify.Op{fsnotify.Remove},
+			want:   []string{"- Foo.kt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got recordedChanges
+			s := newSaveTracker(window, got.record)
+			defer s.stop()
+			if tt.known {
+				s.add("Foo.kt")
+			}
+			for _, op := range tt.events {
+				s.event("Foo.kt", op)
+			}
+			time.Sleep(3 * window) // let removals expire
+
+			if changes := got.get(); !slices.Equal(changes, tt.want) {
+				t.Errorf("changes = %q, want %q", changes, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveTracker_RecreateAfterDeletion(t *testing.T) {
+	const window = 20 * time.Millisecond
+	var got recordedChanges
+	s := newSaveTracker(window, got.record)
+	defer s.stop()
+	s.add("Foo.kt")
+
+	s.event("Foo.kt", fsnotify.Remove)
+	time.Sleep(3 * window)
+	s.event("Foo.kt", fsnotify.Create)
+
+	if want := []string{"- Foo.kt", "+ Foo.kt"}; !slices.Equal(got.get(), want) {
+		t.Errorf("changes = %q, want %q", got.get(), want)
+	}
+}
+
+func TestSaveTracker_StopDropsPendingRemovals(t *testing.T) {
+	const window = 20 * time.Millisecond
+	var got recordedChanges
+	s := newSaveTracker(window, got.record)
+	s.add("Foo.kt")
+
+	s.event("Foo.kt", fsnotify.Remove)
+	s.stop()
+	time.Sleep(3 * window)
+
+	if changes := got.get(); len(changes) != 0 {
+		t.Errorf("changes after stop = %q, want none", changes)
+	}
+}
+
+func TestIsEditorTempFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/Foo.kt", false},
+		{"src/Foo.kt~", true},
+		{"src/.Foo.kt.swp", true},
+		{"src/.Foo.kt.swx", true},
+		{"src/4913", true},
+		{"src/.#Foo.kt", true},
+		{"src/#Foo.kt#", true},
+		{"src/Foo.kt___jb_tmp___", true},
+		{"src/Foo.kt___jb_old___", true},
+		{"src/Foo.kt.tmp", true},
+		{"src/main.py", false},
+		{"src/#hash.py", false},
+	}
+	for _, tt := range tests {
+		if got := isEditorTempFile(tt.path); got != tt.want {
+			t.Errorf("isEditorTempFile(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
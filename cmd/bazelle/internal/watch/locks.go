@@ -0,0 +1,95 @@
+package watch
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// PackageLocks makes update runs take turns per package: a run that covers
+// a package another run holds, or a package above or below one it holds,
+// waits for it to finish, since updating a directory also updates the
+// packages below it. It is shared by every watcher of a daemon, so manual
+// updates and watch-triggered ones, even from a watcher that is being
+// replaced, never write a BUILD file at the same time. The zero value is
+// not usable; use NewPackageLocks.
+type PackageLocks struct {
+	mu   sync.Mutex
+	held map[string]chan struct{} // closed when the holder unlocks
+
+	onWait func(key string) // test hook, called before waiting for key
+}
+
+// NewPackageLocks returns an empty set of package locks.
+func NewPackageLocks() *PackageLocks {
+	return &PackageLocks{held: make(map[string]chan struct{})}
+}
+
+// Lock locks the packages keys, directory paths, waiting for the runs that
+// hold any of them or any directory above or below them, and returns the
+// keys it had to wait for and a function that unlocks them all. The keys are
+// locked all at once when none of them is held, so overlapping runs cannot
+// deadlock.
+func (l *PackageLocks) Lock(keys []string) (waited []string, unlock func()) {
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	release := make(chan struct{})
+	for {
+		l.mu.Lock()
+		c, busy := l.conflict(keys)
+		if !busy {
+			for _, key := range keys {
+				l.held[key] = release
+			}
+			l.mu.Unlock()
+			break
+		}
+		holder := l.held[c.held]
+		onWait := l.onWait
+		l.mu.Unlock()
+		if onWait != nil {
+			onWait(c.wanted)
+		}
+		if !slices.Contains(waited, c.wanted) {
+			waited = append(waited, c.wanted)
+		}
+		<-holder
+	}
+
+	return waited, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, key := range keys {
+			delete(l.held, key)
+		}
+		close(release)
+	}
+}
+
+// lockConflict is a key a run wants that overlaps a key another run holds.
+type lockConflict struct {
+	wanted, held string
+}
+
+// conflict returns the first of keys that is held, or has a held directory
+// above or below it, along with the held key. The caller holds mu.
+func (l *PackageLocks) conflict(keys []string) (lockConflict, bool) {
+	for _, key := range keys {
+		for _, held := range slices.Sorted(maps.Keys(l.held)) {
+			if nested(key, held) || nested(held, key) {
+				return lockConflict{wanted: key, held: held}, true
+			}
+		}
+	}
+	return lockConflict{}, false
+}
+
+// nested reports whether the directory path dir is parent or below it.
+func nested(dir, parent string) bool {
+	rel, ok := strings.CutPrefix(dir, parent)
+	return ok && (rel == "" || strings.HasPrefix(rel, string(filepath.Separator)))
+}
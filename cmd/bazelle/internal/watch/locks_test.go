@@ -0,0 +1,113 @@
+package watch
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPackageLocks(t *testing.T) {
+	locks := NewPackageLocks()
+
+	waited, unlock := locks.Lock([]string{"b", "a", "b"})
+	if len(waited) != 0 {
+		t.Errorf("Lock() of free packages waited for %v", waited)
+	}
+
+	// A run covering a held package waits for it; a disjoint one does not.
+	waiting := make(chan string, 1)
+	locks.onWait = func(key string) { waiting <- key }
+	if waited, unlockC := locks.Lock([]string{"c"}); len(waited) != 0 {
+		t.Errorf("Lock(c) waited for %v", waited)
+	} else {
+		unlockC()
+	}
+
+	got := make(chan []string)
+	go func() {
+		waited, unlock := locks.Lock([]string{"c", "b"})
+		unlock()
+		got <- waited
+	}()
+	if key := <-waiting; key != "b" {
+		t.Errorf("waiting for %q, want b", key)
+	}
+	unlock()
+	if waited := <-got; !reflect.DeepEqual(waited, []string{"b"}) {
+		t.Errorf("Lock(c, b) waited for %v, want [b]", waited)
+	}
+}
+
+func TestPackageLocks_Exclusive(t *testing.T) {
+	locks := NewPackageLocks()
+	var inside, overlap atomic.Int32
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				_, unlock := locks.Lock([]string{"pkg", "other"})
+				if inside.Add(1) > 1 {
+					overlap.Add(1)
+				}
+				inside.Add(-1)
+				unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := overlap.Load(); n > 0 {
+		t.Errorf("%d runs held the same package at once", n)
+	}
+}
+
+func TestPackageLocks_Nested(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "ws")
+	a, ab, ac := filepath.Join(root, "a"), filepath.Join(root, "a", "b"), filepath.Join(root, "a", "c")
+	tests := []struct {
+		name       string
+		held, want string
+		wait       bool
+	}{
+		{name: "parent held", held: a, want: ab, wait: true},
+		{name: "child held", held: ab, want: a, wait: true},
+		{name: "root held", held: root, want: ab, wait: true},
+		{name: "sibling held", held: ab, want: ac},
+		{name: "name prefix held", held: a, want: filepath.Join(root, "ab")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locks := NewPackageLocks()
+			_, unlock := locks.Lock([]string{tt.held})
+
+			waiting := make(chan string, 1)
+			locks.onWait = func(key string) { waiting <- key }
+			got := make(chan []string)
+			go func() {
+				waited, unlock := locks.Lock([]string{tt.want})
+				unlock()
+				got <- waited
+			}()
+
+			if !tt.wait {
+				if waited := <-got; len(waited) != 0 {
+					t.Errorf("Lock(%s) waited for %v while %s was held", tt.want, waited, tt.held)
+				}
+				unlock()
+				return
+			}
+			if key := <-waiting; key != tt.want {
+				t.Errorf("waiting for %q, want %q", key, tt.want)
+			}
+			unlock()
+			if waited := <-got; !reflect.DeepEqual(waited, []string{tt.want}) {
+				t.Errorf("Lock(%s) waited for %v, want [%s]", tt.want, waited, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +21,9 @@ const (
 	ChangeDeleted  ChangeType = "-"
 )
 
+// clearScreen moves the cursor home and erases the terminal.
+const clearScreen = "\033[H\033[2J"
+
 // Logger handles watch mode output formatting.
 type Logger struct {
 	writer  io.Writer
@@ -27,9 +31,16 @@ type Logger struct {
 	verbose bool
 	noColor bool
 	jsonOut bool
+	clear   bool
 
 	statsMu sync.Mutex
 	stats   WatchStats
+
+	// cycleMu guards cycleDone, which is set while the output on screen
+	// ends with a finished update cycle (or the ready message), so the
+	// next line logged starts a new cycle and clears the screen first.
+	cycleMu   sync.Mutex
+	cycleDone bool
 }
 
 // WatchStats tracks statistics for the watch session.
@@ -45,6 +56,11 @@ type LoggerConfig struct {
 	Verbose bool
 	NoColor bool
 	JSON    bool
+
+	// Clear erases the terminal before each update cycle is logged. It
+	// needs the escape sequences that NoColor turns off, so it only takes
+	// effect when the writer is a terminal and NoColor and JSON are unset.
+	Clear bool
 }
 
 // NewLogger creates a new logger with the given configuration.
@@ -60,11 +76,13 @@ func NewLogger(cfg LoggerConfig) *Logger {
 	}
 
 	return &Logger{
-		writer:  writer,
-		isTTY:   isTTY,
-		verbose: cfg.Verbose,
-		noColor: cfg.NoColor,
-		jsonOut: cfg.JSON,
+		writer:    writer,
+		isTTY:     isTTY,
+		verbose:   cfg.Verbose,
+		noColor:   cfg.NoColor,
+		jsonOut:   cfg.JSON,
+		clear:     cfg.Clear,
+		cycleDone: true,
 		stats: WatchStats{
 			StartTime: time.Now(),
 		},
@@ -111,6 +129,7 @@ func (l *Logger) FileChanged(path string, change ChangeType) {
 	}
 
 	if l.verbose {
+		l.startCycle()
 		l.printf("[%s] %s %s\n", l.timestamp(), l.colorize(string(change), change), path)
 	}
 }
@@ -126,6 +145,7 @@ func (l *Logger) Updating(dirs []string) {
 		return
 	}
 
+	l.startCycle()
 	if len(dirs) == 1 {
 		l.printf("[%s] updating //%s:all...\n", l.timestamp(), dirs[0])
 	} else {
@@ -150,6 +170,45 @@ func (l *Logger) Updated(buildFile string) {
 
 	checkmark := l.colorize("\u2713", ChangeAdded) // checkmark
 	l.printf("[%s] %s %s updated\n", l.timestamp(), checkmark, buildFile)
+	l.endCycle()
+}
+
+// UpdatedAll logs a successful update cycle as a single event listing
+// every BUILD file it wrote.
+func (l *Logger) UpdatedAll(buildFiles []string) {
+	l.statsMu.Lock()
+	l.stats.UpdateCount += len(buildFiles)
+	l.statsMu.Unlock()
+
+	if l.jsonOut {
+		l.writeJSON(map[string]any{
+			"event":       "updated",
+			"build_files": buildFiles,
+			"time":        time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	checkmark := l.colorize("\u2713", ChangeAdded) // checkmark
+	l.printf("[%s] %s %d BUILD files updated: %s\n", l.timestamp(), checkmark,
+		len(buildFiles), strings.Join(buildFiles, ", "))
+	l.endCycle()
+}
+
+// ResolveMapReloaded logs that the resolve map at path was read again after
+// a change and now holds entries patterns.
+func (l *Logger) ResolveMapReloaded(path string, entries int) {
+	if l.jsonOut {
+		l.writeJSON(map[string]any{
+			"event":   "resolve_map_reloaded",
+			"path":    path,
+			"entries": entries,
+			"time":    time.Now().Format(time.RFC3339),
+		})
+		return
+	}
+
+	l.printf("[%s] reloaded %s (%d entries)\n", l.timestamp(), path, entries)
 }
 
 // Error logs an error.
@@ -169,6 +228,7 @@ func (l *Logger) Error(err error) {
 
 	xmark := l.colorize("\u2717", ChangeDeleted) // xmark
 	l.printf("[%s] %s error: %v\n", l.timestamp(), xmark, err)
+	l.endCycle()
 }
 
 // Shutdown logs the shutdown message with statistics.
@@ -199,6 +259,33 @@ func (l *Logger) Stats() WatchStats {
 	return l.stats
 }
 
+// startCycle clears the screen if the last line logged finished an update
+// cycle and clearing is enabled.
+func (l *Logger) startCycle() {
+	if !l.canClear() {
+		return
+	}
+	l.cycleMu.Lock()
+	defer l.cycleMu.Unlock()
+	if l.cycleDone {
+		l.printf("%s", clearScreen)
+		l.cycleDone = false
+	}
+}
+
+// endCycle records that an update cycle finished, so the next one starts on
+// a cleared screen.
+func (l *Logger) endCycle() {
+	l.cycleMu.Lock()
+	defer l.cycleMu.Unlock()
+	l.cycleDone = true
+}
+
+// canClear reports whether the screen is cleared between update cycles.
+func (l *Logger) canClear() bool {
+	return l.clear && l.isTTY && !l.noColor && !l.jsonOut
+}
+
 // timestamp returns the current time formatted as HH:MM:SS.
 func (l *Logger) timestamp() string {
 	return time.Now().Format("15:04:05")
@@ -106,6 +106,36 @@ func TestLogger_Updated(t *testing.T) {
 	}
 }
 
+func TestLogger_UpdatedAll(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LoggerConfig{Writer: &buf, NoColor: true})
+
+	logger.UpdatedAll([]string{"a/BUILD.bazel", "b/BUILD.bazel"})
+
+	output := buf.String()
+	if strings.Count(output, "\n") != 1 {
+		t.Errorf("expected a single line: %s", output)
+	}
+	if !strings.Contains(output, "2 BUILD files updated: a/BUILD.bazel, b/BUILD.bazel") {
+		t.Errorf("expected both build files in output: %s", output)
+	}
+	if got := logger.Stats().UpdateCount; got != 2 {
+		t.Errorf("UpdateCount = %d, want 2", got)
+	}
+}
+
+func TestLogger_ResolveMapReloaded(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LoggerConfig{Writer: &buf, NoColor: true})
+
+	logger.ResolveMapReloaded("lib/.bazelle/resolve.toml", 3)
+
+	output := buf.String()
+	if !strings.Contains(output, "reloaded lib/.bazelle/resolve.toml (3 entries)") {
+		t.Errorf("expected reloaded map in output: %s", output)
+	}
+}
+
 func TestLogger_Error(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(LoggerConfig{Writer: &buf, NoColor: true})
@@ -225,6 +255,47 @@ func TestLogger_JSON_Updated(t *testing.T) {
 	}
 }
 
+func TestLogger_JSON_UpdatedAll(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LoggerConfig{Writer: &buf, JSON: true})
+
+	logger.UpdatedAll([]string{"a/BUILD.bazel", "b/BUILD.bazel"})
+
+	var event struct {
+		Event      string   `json:"event"`
+		BuildFiles []string `json:"build_files"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+
+	if event.Event != "updated" {
+		t.Errorf("expected event=updated, got %v", event.Event)
+	}
+	if len(event.BuildFiles) != 2 || event.BuildFiles[0] != "a/BUILD.bazel" || event.BuildFiles[1] != "b/BUILD.bazel" {
+		t.Errorf("expected build_files=[a/BUILD.bazel b/BUILD.bazel], got %v", event.BuildFiles)
+	}
+}
+
+func TestLogger_JSON_ResolveMapReloaded(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LoggerConfig{Writer: &buf, JSON: true})
+
+	logger.ResolveMapReloaded(".bazelle/resolve.toml", 2)
+
+	var event map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("failed to parse JSON: %v", err)
+	}
+
+	if event["event"] != "resolve_map_reloaded" {
+		t.Errorf("expected event=resolve_map_reloaded, got %v", event["event"])
+	}
+	if event["path"] != ".bazelle/resolve.toml" || event["entries"] != float64(2) {
+		t.Errorf("expected path and entries, got %v", event)
+	}
+}
+
 func TestLogger_JSON_Error(t *testing.T) {
 	var buf bytes.Buffer
 	logger := NewLogger(LoggerConfig{Writer: &buf, JSON: true})
@@ -243,3 +314,61 @@ func TestLogger_JSON_Error(t *testing.T) {
 		t.Errorf("expected error message, got %v", event["error"])
 	}
 }
+
+func TestLogger_Clear(t *testing.T) {
+	tests := []struct {
+		name      string
+		isTTY     bool
+		cfg       LoggerConfig
+		wantClear bool
+	}{
+		{name: "tty with clear", isTTY: true, cfg: LoggerConfig{Clear: true}, wantClear: true},
+		{name: "tty without clear", isTTY: true, cfg: LoggerConfig{}},
+		{name: "not a tty", cfg: LoggerConfig{Clear: true}},
+		{name: "no color", isTTY: true, cfg: LoggerConfig{Clear: true, NoColor: true}},
+		{name: "json", isTTY: true, cfg: LoggerConfig{Clear: true, JSON: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.cfg.Writer = &buf
+			logger := NewLogger(tt.cfg)
+			logger.isTTY = tt.isTTY
+
+			for range 2 {
+				logger.Updating([]string{"src"})
+				logger.Updated("src/BUILD.bazel")
+			}
+
+			wantCount := 0
+			if tt.wantClear {
+				wantCount = 2
+			}
+			if got := strings.Count(buf.String(), clearScreen); got != wantCount {
+				t.Errorf("clear sequences = %d, want %d in output: %q", got, wantCount, buf.String())
+			}
+			if tt.wantClear && !strings.HasPrefix(buf.String(), clearScreen) {
+				t.Errorf("expected output to start with the clear sequence: %q", buf.String())
+			}
+		})
+	}
+}
+
+func TestLogger_Clear_KeepsCycleFileChanges(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LoggerConfig{Writer: &buf, Verbose: true, Clear: true})
+	logger.isTTY = true
+
+	logger.FileChanged("src/main.go", ChangeModified)
+	logger.Updating([]string{"src"})
+	logger.Updated("src/BUILD.bazel")
+
+	output := buf.String()
+	if strings.Count(output, clearScreen) != 1 || !strings.HasPrefix(output, clearScreen) {
+		t.Errorf("expected one clear before the cycle's first line: %q", output)
+	}
+	if !strings.Contains(output, "src/main.go") {
+		t.Errorf("expected the file change to survive the clear: %q", output)
+	}
+}
@@ -5,32 +5,126 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"maps"
 	"os"
+	"os/exec"
+	"path"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/depgraph"
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/incremental"
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/output"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/progress"
+	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+	"github.com/bazelbuild/bazel-gazelle/label"
 	"github.com/bazelbuild/bazel-gazelle/language"
-	"github.com/bazelbuild/bazel-gazelle/runner"
 	"github.com/fsnotify/fsnotify"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
 // Config configures the watcher.
 type Config struct {
-	Root            string
+	Root            string // workspace root, made absolute by New
 	Languages       []language.Language
 	LangFilter      []string // filter by language name (nil = all)
 	Debounce        int      // debounce window in milliseconds
 	Verbose         bool
 	NoColor         bool
 	JSON            bool
+	Clear           bool // clear the terminal before each update cycle
 	GazelleDefaults []string
+
+	// OnUpdate is a shell command run after each successful update cycle
+	// (empty = none). See runOnUpdate for the environment it receives.
+	OnUpdate string
+
+	// OnProgress, if set, receives progress events while gazelle runs.
+	OnProgress progress.Func
+
+	// Events selects whether each update cycle is reported package by
+	// package or as one consolidated event, in the JSON stream and to
+	// OnUpdated ("" = EventsPerPackage).
+	Events EventMode
+
+	// OnUpdated, if set, is called after each successful update cycle with
+	// the directories it updated: once per directory, or once with all of
+	// them under EventsConsolidated.
+	OnUpdated func(dirs []string)
+
+	// ReplayOnStart updates the packages that are already stale when Run
+	// starts, before any file event is handled.
+	ReplayOnStart bool
+
+	// WarmIndex builds the symbol index of Root (see Symbols) when Run
+	// starts, before the watcher reports ready. Each update cycle then
+	// re-indexes the packages it updates and warns about the imports of
+	// their sources that no workspace package declares.
+	WarmIndex bool
+
+	// Writer, if set, receives the generated BUILD files instead of the
+	// workspace.
+	Writer output.Writer
+
+	// Locks, if set, are the package locks the watcher's update runs take
+	// turns on; watchers sharing them never update a package concurrently.
+	// A watcher without them gets its own.
+	Locks *PackageLocks
 }
 
+// Environment variables passed to the OnUpdate command.
+const (
+	// EnvUpdatedPackages holds the updated packages as space-separated
+	// Bazel labels (e.g. "//src/auth //lib").
+	EnvUpdatedPackages = "BAZELLE_UPDATED_PACKAGES"
+	// EnvWorkspaceRoot holds the absolute path of the watched workspace.
+	EnvWorkspaceRoot = "BAZELLE_WORKSPACE_ROOT"
+)
+
+// EventMode selects how the watcher reports the packages an update cycle
+// regenerated.
+type EventMode string
+
+const (
+	// EventsPerPackage reports each updated package as its own event.
+	EventsPerPackage EventMode = "per-package"
+	// EventsConsolidated reports one event per update cycle listing every
+	// updated package, which keeps a large cycle to a single notification.
+	EventsConsolidated EventMode = "consolidated"
+)
+
+// ParseEventMode parses the name of an EventMode; "" is EventsPerPackage.
+func ParseEventMode(s string) (EventMode, error) {
+	switch mode := EventMode(s); mode {
+	case "", EventsPerPackage:
+		return EventsPerPackage, nil
+	case EventsConsolidated:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown event mode %q (want %s or %s)", s, EventsPerPackage, EventsConsolidated)
+	}
+}
+
+// State is the lifecycle state of a Watcher.
+type State string
+
+const (
+	// StateStarting means Run has not finished setting up the watch yet.
+	StateStarting State = "starting"
+	// StateIndexing means Run is building the symbol index (Config.WarmIndex).
+	StateIndexing State = "indexing"
+	// StateReady means the watcher is handling file events.
+	StateReady State = "ready"
+)
+
 // Watcher watches for file changes and updates BUILD files.
 type Watcher struct {
 	config     Config
@@ -39,14 +133,41 @@ type Watcher struct {
 	debouncer  *Debouncer
 	logger     *Logger
 	extensions map[string]bool
-	ignoreDirs map[string]bool
+	ignorer    *langs.Ignorer
+	saves      *saveTracker
 
-	// gazelleMu prevents concurrent Gazelle runs
+	// gazelleMu prevents concurrent Gazelle runs. Update runs lock their
+	// packages in locks before taking it.
 	gazelleMu sync.Mutex
+	locks     *PackageLocks
+
+	// pending are the directories whose changes Reindex took into the
+	// tracker state before an update regenerated their BUILD files. They
+	// stay stale until an update runs on them. Guarded by gazelleMu, as is
+	// debouncer once Run has set it.
+	pending map[string]bool
+
+	stateMu sync.RWMutex
+	state   State
+	symbols *jvm.PackageIndex // nil until built
+
+	// resolveMaps are the resolve maps that update runs resolve imports with
+	// and filter the unresolved imports they report by, shared with the
+	// other watchers of Root (see deppolicy.UseResolveMaps) until
+	// releaseResolveMaps. They are reloaded when their files change, see
+	// reloadResolveMap.
+	resolveMaps        *resolvemap.Layered
+	releaseResolveMaps func()
 }
 
 // New creates a new watcher with the given configuration.
 func New(cfg Config) (*Watcher, error) {
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve workspace root: %w", err)
+	}
+	cfg.Root = root
+
 	fsWatcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file watcher: %w", err)
@@ -55,25 +176,35 @@ func New(cfg Config) (*Watcher, error) {
 	// Build extension filter from shared config
 	extensions := langs.ExtensionSet(cfg.LangFilter)
 
-	// Build ignore directory filter from shared config
-	ignoreDirs := langs.IgnoreDirSet(nil)
+	// Build ignore directory filter from shared config and .bazelignore
+	ignorer := langs.NewIgnorer(cfg.Root)
 
 	logger := NewLogger(LoggerConfig{
 		Verbose: cfg.Verbose,
 		NoColor: cfg.NoColor,
 		JSON:    cfg.JSON,
+		Clear:   cfg.Clear,
 	})
 
 	tracker := incremental.NewTracker(cfg.Root, cfg.LangFilter)
 
+	locks := cfg.Locks
+	if locks == nil {
+		locks = NewPackageLocks()
+	}
+
 	w := &Watcher{
 		config:     cfg,
 		fsWatcher:  fsWatcher,
 		tracker:    tracker,
 		logger:     logger,
 		extensions: extensions,
-		ignoreDirs: ignoreDirs,
+		ignorer:    ignorer,
+		locks:      locks,
+		state:      StateStarting,
 	}
+	w.saves = newSaveTracker(atomicSaveWindow, w.fileChanged)
+	w.resolveMaps, w.releaseResolveMaps = deppolicy.UseResolveMaps(cfg.Root)
 
 	return w, nil
 }
@@ -85,14 +216,33 @@ func (w *Watcher) Run(ctx context.Context) error {
 	if debounceWindow <= 0 {
 		debounceWindow = 500 * time.Millisecond
 	}
+	w.gazelleMu.Lock()
 	w.debouncer = NewDebouncer(debounceWindow, w.handleChangedDirs)
+	w.gazelleMu.Unlock()
 	defer w.debouncer.Stop()
+	defer w.saves.stop()
 
 	// Add workspace root recursively
 	if err := w.addRecursive(w.config.Root); err != nil {
 		return fmt.Errorf("failed to watch workspace: %w", err)
 	}
 
+	if w.config.WarmIndex {
+		w.setState(StateIndexing)
+		if err := w.warmIndex(ctx); err != nil {
+			w.logger.Shutdown()
+			return nil
+		}
+	}
+
+	// Catch up on changes made while nobody was watching. Events raised in
+	// the meantime queue up and are handled once the loop starts.
+	if w.config.ReplayOnStart {
+		w.replayStale(ctx)
+	}
+
+	w.setState(StateReady)
+
 	// Log ready message
 	// Note: TrackedFileCount may be 0 on first run before any state exists
 	fileCount := w.tracker.TrackedFileCount()
@@ -120,7 +270,121 @@ func (w *Watcher) Run(ctx context.Context) error {
 	}
 }
 
-// addRecursive adds a directory and all subdirectories to the watcher.
+// replayStale runs an update cycle for the packages that are stale against
+// the stored state. Without stored state every package with sources is stale.
+func (w *Watcher) replayStale(ctx context.Context) {
+	w.gazelleMu.Lock()
+	cs, err := w.tracker.Status(ctx)
+	dirs := w.staleDirs(cs)
+	w.gazelleMu.Unlock()
+	if err != nil {
+		w.logger.Error(fmt.Errorf("failed to detect stale packages: %w", err))
+		return
+	}
+	w.handleChangedDirs(dirs)
+}
+
+// staleDirs returns the sorted directories that cs affects and those still
+// pending after a Reindex. The caller holds gazelleMu.
+func (w *Watcher) staleDirs(cs *incremental.ChangeSet) []string {
+	dirs := cs.AffectedDirs()
+	for dir := range w.pending {
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	slices.Sort(dirs)
+	return dirs
+}
+
+// warmIndex builds the symbol index for every indexable language the
+// watcher covers. A language that fails to index is logged and left out.
+// It returns ctx.Err() without an index if ctx is done first.
+func (w *Watcher) warmIndex(ctx context.Context) error {
+	symbols := jvm.NewPackageIndex()
+	for _, lang := range w.indexedLanguages() {
+		if err := depgraph.Index(ctx, symbols, w.config.Root, "", lang); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			w.logger.Error(fmt.Errorf("failed to index %s sources: %w", lang, err))
+		}
+	}
+
+	w.stateMu.Lock()
+	w.symbols = symbols
+	w.stateMu.Unlock()
+	return nil
+}
+
+// indexedLanguages returns the languages of depgraph.Languages that the
+// watcher covers.
+func (w *Watcher) indexedLanguages() []string {
+	if len(w.config.LangFilter) == 0 {
+		return depgraph.Languages
+	}
+	var indexed []string
+	for _, lang := range depgraph.Languages {
+		if slices.Contains(w.config.LangFilter, lang) {
+			indexed = append(indexed, lang)
+		}
+	}
+	return indexed
+}
+
+// reindex updates the symbol index, if it was built, for the sources of
+// dirs after an update cycle changed them, and returns the imports of those
+// sources that no package of the index declares. A language that fails to
+// re-index is logged and reports nothing. The caller holds gazelleMu.
+func (w *Watcher) reindex(dirs []string) []deppolicy.Unresolved {
+	symbols := w.Symbols()
+	if symbols == nil {
+		return nil
+	}
+
+	var unresolved []deppolicy.Unresolved
+	for _, lang := range w.indexedLanguages() {
+		byTarget, err := depgraph.Reindex(context.Background(), symbols, w.config.Root, dirs, lang)
+		if err != nil {
+			w.logger.Error(fmt.Errorf("failed to re-index %s sources: %w", lang, err))
+			continue
+		}
+		targets := slices.SortedFunc(maps.Keys(byTarget), func(a, b label.Label) int {
+			return strings.Compare(a.String(), b.String())
+		})
+		for _, target := range targets {
+			for _, imp := range byTarget[target] {
+				unresolved = append(unresolved, deppolicy.Unresolved{Import: imp, From: target})
+			}
+		}
+	}
+	return unresolved
+}
+
+func (w *Watcher) setState(s State) {
+	w.stateMu.Lock()
+	w.state = s
+	w.stateMu.Unlock()
+}
+
+// State returns the lifecycle state of the watcher.
+func (w *Watcher) State() State {
+	w.stateMu.RLock()
+	defer w.stateMu.RUnlock()
+	return w.state
+}
+
+// Symbols returns the symbol index built by Config.WarmIndex and kept
+// current by the update cycles, mapping each source package to the targets
+// that declare it, or nil if it has not been built.
+func (w *Watcher) Symbols() *jvm.PackageIndex {
+	w.stateMu.RLock()
+	defer w.stateMu.RUnlock()
+	return w.symbols
+}
+
+// addRecursive adds a directory and all subdirectories to the watcher, and
+// records the source files in them as existing.
 func (w *Watcher) addRecursive(root string) error {
 	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -137,15 +401,16 @@ func (w *Watcher) addRecursive(root string) error {
 		}
 
 		if !d.IsDir() {
+			if w.extensions[filepath.Ext(path)] {
+				w.saves.add(path)
+			}
 			return nil
 		}
 
 		// Check if directory should be ignored
-		name := d.Name()
-		for prefix := range w.ignoreDirs {
-			if strings.HasPrefix(name, prefix) {
-				return filepath.SkipDir
-			}
+		if w.ignorer.SkipDir(path) {
+			w.watchResolveMapDir(path)
+			return filepath.SkipDir
 		}
 
 		// Add directory to watcher
@@ -184,11 +449,17 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	if event.Has(fsnotify.Create) {
 		if info, err := os.Stat(path); err == nil && info.IsDir() {
 			// Check if should be ignored
-			name := filepath.Base(path)
-			for prefix := range w.ignoreDirs {
-				if strings.HasPrefix(name, prefix) {
-					return
+			if w.ignorer.SkipDir(path) {
+				if w.watchResolveMapDir(path) {
+					// The map may have been written before the watch was added
+					mapPath := filepath.Join(filepath.Dir(path), resolvemap.DefaultPath)
+					if _, err := os.Stat(mapPath); err == nil {
+						if dir, ok := w.resolveMapDir(mapPath); ok {
+							w.reloadResolveMap(dir)
+						}
+					}
 				}
+				return
 			}
 			// Add new directory to watcher
 			if err := w.addRecursive(path); err != nil {
@@ -205,26 +476,29 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 		// So we just need to handle file renames here
 	}
 
-	// Filter by extension
-	ext := filepath.Ext(path)
-	if !w.extensions[ext] {
+	if dir, ok := w.resolveMapDir(path); ok {
+		if event.Op != fsnotify.Chmod {
+			w.reloadResolveMap(dir)
+		}
 		return
 	}
 
-	// Determine change type and log
-	var changeType ChangeType
-	switch {
-	case event.Has(fsnotify.Create):
-		changeType = ChangeAdded
-	case event.Has(fsnotify.Write):
-		changeType = ChangeModified
-	case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
-		changeType = ChangeDeleted
-	default:
-		return // Ignore chmod events
+	// Filter by extension, and skip the files editors save through
+	ext := filepath.Ext(path)
+	if !w.extensions[ext] || isEditorTempFile(path) {
+		return
 	}
 
-	w.logger.FileChanged(path, changeType)
+	// Atomic saves become a single modification, see saveTracker
+	w.saves.event(path, event.Op)
+}
+
+// fileChanged logs a change of the source file at path, unless quiet, and
+// queues its directory for an update.
+func (w *Watcher) fileChanged(path string, change ChangeType, quiet bool) {
+	if !quiet {
+		w.logger.FileChanged(path, change)
+	}
 
 	// Get relative directory and debounce
 	relPath, err := filepath.Rel(w.config.Root, path)
@@ -236,6 +510,71 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	w.debouncer.Add(dir)
 }
 
+// watchResolveMapDir adds a watch for the directory at path, which SkipDir
+// excludes, if resolve maps are kept in directories of its name, and reports
+// whether they are. Only the directory itself is watched, not what is below
+// it.
+func (w *Watcher) watchResolveMapDir(path string) bool {
+	if filepath.Base(path) != filepath.Dir(resolvemap.DefaultPath) {
+		return false
+	}
+	if err := w.fsWatcher.Add(path); err != nil && w.config.Verbose {
+		w.logger.Error(fmt.Errorf("failed to watch %s: %w", path, err))
+	}
+	return true
+}
+
+// resolveMapDir reports whether path is the resolve map of a workspace
+// directory and returns that directory, slash-separated and relative to Root
+// ("" for the root).
+func (w *Watcher) resolveMapDir(path string) (string, bool) {
+	rel, err := filepath.Rel(w.config.Root, path)
+	if err != nil {
+		return "", false
+	}
+	if rel == resolvemap.DefaultPath {
+		return "", true
+	}
+	dir, ok := strings.CutSuffix(rel, string(filepath.Separator)+resolvemap.DefaultPath)
+	if !ok || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(dir), true
+}
+
+// reloadResolveMap reads the resolve map of dir again after its file
+// changed, so the next update run uses it, and logs the result. A map that
+// fails to load is logged and the one loaded before is kept.
+func (w *Watcher) reloadResolveMap(dir string) {
+	m, err := w.resolveMaps.Reload(dir)
+
+	path := filepath.Join(filepath.FromSlash(dir), resolvemap.DefaultPath)
+	if err != nil {
+		w.logger.Error(fmt.Errorf("failed to reload %s, keeping the previous map: %w", path, err))
+		return
+	}
+	w.logger.ResolveMapReloaded(path, len(m.Entries))
+}
+
+// unresolvedWarnings returns the warnings for the imports the run left
+// unresolved and for indexed, the imports that the symbol index does not
+// declare, except those that a resolve map applying to the importing
+// package covers.
+func (w *Watcher) unresolvedWarnings(indexed []deppolicy.Unresolved) []string {
+	var warnings []string
+	for _, u := range slices.Concat(deppolicy.UnresolvedImports(), indexed) {
+		layers, err := w.resolveMaps.For(u.From.Pkg)
+		if err != nil {
+			w.logger.Error(fmt.Errorf("failed to load resolve maps for //%s: %w", u.From.Pkg, err))
+		}
+		if _, ok := layers.Lookup(u.Import); ok {
+			continue
+		}
+		warnings = append(warnings, u.Warning())
+	}
+	return warnings
+}
+
 // handleChangedDirs is called when the debouncer flushes.
 // It runs gazelle on the affected directories.
 func (w *Watcher) handleChangedDirs(dirs []string) {
@@ -243,48 +582,235 @@ func (w *Watcher) handleChangedDirs(dirs []string) {
 		return
 	}
 
-	// Prevent concurrent Gazelle runs
+	if _, err := w.run(dirs, w.config.OnProgress, true); err != nil {
+		w.logger.Error(err)
+	}
+}
+
+// UpdateResult is the outcome of an Update.
+type UpdateResult struct {
+	// Dirs are the directories updated, sorted.
+	Dirs []string
+
+	// BuildFiles are the BUILD files the update changed, relative to Root,
+	// slash-separated and sorted. It is empty when every BUILD file of Dirs
+	// was already current and so was left as it was.
+	BuildFiles []string
+
+	// Warnings are the warnings logged while the directories were updated,
+	// such as parse warnings of the language extensions, in the order they
+	// were first logged. Messages logged at the same time by the rest of
+	// the process are included too. They end with the ambiguous imports and
+	// then the unresolved imports that no resolve map covers, including,
+	// with Config.WarmIndex, those no package of the symbol index declares.
+	Warnings []string
+}
+
+// Update runs gazelle now on dirs, given relative to Root, or on the
+// current stale set when dirs is empty. Progress goes to onProgress, which
+// may be nil, instead of Config.OnProgress. It returns the directories it
+// updated, none if dirs is empty and nothing is stale, and the warnings
+// logged while updating them.
+//
+// Update waits for any run that covers one of its packages. Given dirs are
+// updated regardless; stale-set packages that such a run left up to date
+// are skipped.
+func (w *Watcher) Update(ctx context.Context, dirs []string, onProgress progress.Func) (*UpdateResult, error) {
+	if len(dirs) > 0 {
+		return w.run(dirs, onProgress, false)
+	}
+
+	w.gazelleMu.Lock()
+	cs, err := w.tracker.Status(ctx)
+	dirs = w.staleDirs(cs)
+	w.gazelleMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return &UpdateResult{}, nil
+	}
+	return w.run(dirs, onProgress, true)
+}
+
+// run updates dirs once no other run holds any of their packages, or any
+// package above or below them (see PackageLocks). With coalesce, the
+// packages it had to wait for are dropped if the run that held them left
+// them up to date: a watch cycle queued behind a manual update of the same
+// package has nothing left to do.
+func (w *Watcher) run(dirs []string, onProgress progress.Func, coalesce bool) (*UpdateResult, error) {
+	keys := make([]string, len(dirs))
+	for i, dir := range dirs {
+		keys[i] = filepath.Join(w.config.Root, dir)
+	}
+	waited, unlock := w.locks.Lock(keys)
+	defer unlock()
+
 	w.gazelleMu.Lock()
 	defer w.gazelleMu.Unlock()
 
+	dirs = slices.Clone(dirs)
+	if coalesce && len(waited) > 0 {
+		cs, err := w.tracker.Status(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect stale packages: %w", err)
+		}
+		stale := w.staleDirs(cs)
+		dirs = slices.DeleteFunc(dirs, func(dir string) bool {
+			return slices.Contains(waited, filepath.Join(w.config.Root, dir)) && !slices.Contains(stale, dir)
+		})
+		if len(dirs) == 0 {
+			return &UpdateResult{}, nil
+		}
+	}
+
+	// Ambiguous imports are only logged at info level, so they are taken
+	// from the dependency policy, reset for the run as update does
+	deppolicy.Reset()
+	stopRecording := log.RecordWarnings()
+	buildFiles, err := w.update(dirs, onProgress)
+	warnings := stopRecording()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range deppolicy.Ambiguities() {
+		warnings = append(warnings, a.Warning())
+	}
+	warnings = append(warnings, w.unresolvedWarnings(w.reindex(dirs))...)
+	return &UpdateResult{Dirs: dirs, BuildFiles: buildFiles, Warnings: warnings}, nil
+}
+
+// update runs gazelle on dirs, sorting them in place, then refreshes the
+// tracker state and runs the OnUpdate command. It returns the BUILD files
+// gazelle changed. The caller holds the locks of the packages and gazelleMu.
+func (w *Watcher) update(dirs []string, onProgress progress.Func) ([]string, error) {
 	// Sort directories for consistent output
 	slices.Sort(dirs)
 
 	w.logger.Updating(dirs)
 
-	// Convert to targets
-	targets := make([]string, len(dirs))
-	for i, dir := range dirs {
-		if dir == "." {
-			targets[i] = "//:all"
-		} else {
-			targets[i] = "//" + dir + ":all"
-		}
+	// Run gazelle. Only the BUILD files it changes reach the writer.
+	written := &recordingWriter{next: w.config.Writer}
+	if written.next == nil {
+		written.next = output.FSWriter{Root: w.config.Root}
+	}
+	langs := progress.Languages(w.config.Languages, len(dirs), onProgress)
+	if err := output.Run(langs, w.config.Root, written, w.gazelleArgs(dirs)...); err != nil {
+		return nil, fmt.Errorf("gazelle failed: %w", err)
 	}
 
-	// Build gazelle arguments
+	// Refresh tracker state
+	ctx := context.Background()
+	if err := w.tracker.Refresh(ctx); err != nil {
+		return nil, fmt.Errorf("failed to update state: %w", err)
+	}
+	for _, dir := range dirs {
+		delete(w.pending, dir)
+	}
+
+	w.reportUpdated(dirs)
+	w.runOnUpdate(dirs)
+	slices.Sort(written.paths)
+	return written.paths, nil
+}
+
+// gazelleArgs returns the arguments of a gazelle update of dirs.
+func (w *Watcher) gazelleArgs(dirs []string) []string {
 	args := []string{"update"}
 	args = append(args, w.config.GazelleDefaults...)
-	args = append(args, targets...)
+	// Gazelle takes the directories to update as paths, not labels
+	for _, dir := range dirs {
+		args = append(args, filepath.Join(w.config.Root, dir))
+	}
+	return args
+}
+
+// recordingWriter hands BUILD files to next and remembers their paths.
+type recordingWriter struct {
+	next  output.Writer
+	paths []string
+}
+
+func (r *recordingWriter) WriteBuildFile(rel string, content []byte) error {
+	if err := r.next.WriteBuildFile(rel, content); err != nil {
+		return err
+	}
+	r.paths = append(r.paths, rel)
+	return nil
+}
+
+// reportUpdated logs the BUILD files written for dirs and passes dirs to
+// OnUpdated, as one event per directory or, under EventsConsolidated, as a
+// single event for the cycle.
+func (w *Watcher) reportUpdated(dirs []string) {
+	// Check for the actual BUILD file of each directory
+	buildFiles := make([]string, len(dirs))
+	for i, dir := range dirs {
+		buildFiles[i] = w.findBuildFile(dir)
+	}
 
-	// Run gazelle
-	if err := runner.Run(w.config.Languages, w.config.Root, args...); err != nil {
-		w.logger.Error(fmt.Errorf("gazelle failed: %w", err))
+	if w.config.Events == EventsConsolidated {
+		w.logger.UpdatedAll(buildFiles)
+		if w.config.OnUpdated != nil {
+			w.config.OnUpdated(dirs)
+		}
 		return
 	}
 
-	// Refresh tracker state
-	ctx := context.Background()
-	if err := w.tracker.Refresh(ctx); err != nil {
-		w.logger.Error(fmt.Errorf("failed to update state: %w", err))
+	for i, dir := range dirs {
+		w.logger.Updated(buildFiles[i])
+		if w.config.OnUpdated != nil {
+			w.config.OnUpdated([]string{dir})
+		}
+	}
+}
+
+// runOnUpdate runs the configured OnUpdate command for the updated dirs.
+//
+// The command runs through the platform shell in the workspace root, with
+// EnvUpdatedPackages and EnvWorkspaceRoot added to the environment.
+// A failing command is logged and otherwise ignored so the watcher keeps
+// running.
+func (w *Watcher) runOnUpdate(dirs []string) {
+	if w.config.OnUpdate == "" {
 		return
 	}
 
-	// Log success for each directory, checking for actual BUILD file
-	for _, dir := range dirs {
-		buildFile := w.findBuildFile(dir)
-		w.logger.Updated(buildFile)
+	packages := make([]string, len(dirs))
+	for i, dir := range dirs {
+		packages[i] = packageLabel(dir)
 	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", w.config.OnUpdate)
+	} else {
+		cmd = exec.Command("sh", "-c", w.config.OnUpdate)
+	}
+	cmd.Dir = w.config.Root
+	cmd.Env = append(os.Environ(),
+		EnvUpdatedPackages+"="+strings.Join(packages, " "),
+		EnvWorkspaceRoot+"="+w.config.Root,
+	)
+	// Keep the JSON event stream on stdout parseable.
+	cmd.Stdout = w.logger.writer
+	if w.config.JSON {
+		cmd.Stdout = os.Stderr
+	}
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		w.logger.Error(fmt.Errorf("on-update command failed: %w", err))
+	}
+}
+
+// packageLabel converts a workspace-relative directory to a package label,
+// e.g. "src/auth" -> "//src/auth" and "." -> "//".
+func packageLabel(dir string) string {
+	if dir == "." {
+		return "//"
+	}
+	return "//" + filepath.ToSlash(dir)
 }
 
 // findBuildFile returns the path to the BUILD file in a directory,
@@ -306,8 +832,198 @@ func (w *Watcher) findBuildFile(dir string) string {
 	return filepath.Join(dir, "BUILD.bazel")
 }
 
+// Reindex rebuilds the file index for the watch root from disk.
+//
+// This picks up changes made while events were missed (e.g. a large branch
+// switch) without restarting the watcher. Newly created directories are also
+// added to the watch set, and the symbol index, if it was built, is built
+// again. The directories of the changed files remain stale for Update, Plan
+// and replay until an update runs on them, and while Run is active they are
+// queued for the next update cycle. It waits for any in-flight Gazelle run to
+// finish. Returns the number of tracked files after the rebuild.
+func (w *Watcher) Reindex(ctx context.Context) (int, error) {
+	w.gazelleMu.Lock()
+	dirs, err := w.reindexFiles(ctx)
+	debouncer := w.debouncer
+	w.gazelleMu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	// The debouncer may run the cycle at once, which takes gazelleMu
+	if debouncer != nil {
+		for _, dir := range dirs {
+			debouncer.Add(dir)
+		}
+	}
+	return w.tracker.TrackedFileCount(), nil
+}
+
+// reindexFiles implements Reindex and returns the directories left pending.
+// The caller holds gazelleMu.
+func (w *Watcher) reindexFiles(ctx context.Context) ([]string, error) {
+	cs, err := w.tracker.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.tracker.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	dirs := cs.AffectedDirs()
+	if len(dirs) > 0 && w.pending == nil {
+		w.pending = make(map[string]bool)
+	}
+	for _, dir := range dirs {
+		w.pending[dir] = true
+	}
+
+	if w.fsWatcher != nil {
+		if err := w.addRecursive(w.config.Root); err != nil {
+			return nil, fmt.Errorf("failed to watch workspace: %w", err)
+		}
+	}
+
+	if w.Symbols() != nil {
+		if err := w.warmIndex(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return w.staleDirs(incremental.NewChangeSet()), nil
+}
+
+// PlannedUpdate is a package that the next update cycle would regenerate,
+// with the source changes that make it stale. A package left stale by
+// Reindex lists no changes.
+type PlannedUpdate struct {
+	Dir          string   // workspace-relative directory, "." for the root
+	Package      string   // package label, e.g. "//src/auth"
+	BuildFile    string   // BUILD file gazelle would write, relative to Root
+	NewBuildFile bool     // BuildFile does not exist yet and would be created
+	Added        []string // new source files in the package
+	Modified     []string // changed source files in the package
+	Deleted      []string // removed source files in the package
+	Diff         string   // unified diff of BuildFile against disk, empty if gazelle leaves it unchanged
+}
+
+// Plan returns the packages an update would regenerate for the current
+// stale set, sorted by directory. Gazelle runs for them into memory, and
+// each package carries the diff of its BUILD file against disk; nothing is
+// written. It waits for any in-flight Gazelle run to finish.
+func (w *Watcher) Plan(ctx context.Context) ([]PlannedUpdate, error) {
+	w.gazelleMu.Lock()
+	defer w.gazelleMu.Unlock()
+
+	cs, err := w.tracker.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byDir := make(map[string]*PlannedUpdate)
+	planDir := func(dir string) *PlannedUpdate {
+		if p, ok := byDir[dir]; ok {
+			return p
+		}
+		buildFile := w.findBuildFile(dir)
+		_, statErr := os.Stat(filepath.Join(w.config.Root, buildFile))
+		p := &PlannedUpdate{
+			Dir:          dir,
+			Package:      packageLabel(dir),
+			BuildFile:    buildFile,
+			NewBuildFile: errors.Is(statErr, fs.ErrNotExist),
+		}
+		byDir[dir] = p
+		return p
+	}
+	plan := func(file string) *PlannedUpdate {
+		return planDir(filepath.Dir(file))
+	}
+	for _, f := range cs.Added {
+		p := plan(f)
+		p.Added = append(p.Added, f)
+	}
+	for _, f := range cs.Modified {
+		p := plan(f)
+		p.Modified = append(p.Modified, f)
+	}
+	for _, f := range cs.Deleted {
+		p := plan(f)
+		p.Deleted = append(p.Deleted, f)
+	}
+
+	// Directories pending after a Reindex are planned without changes
+	dirs := w.staleDirs(cs)
+	for _, dir := range dirs {
+		planDir(dir)
+	}
+	if len(dirs) > 0 {
+		generated := &output.MemWriter{}
+		if err := output.Run(w.config.Languages, w.config.Root, generated, w.gazelleArgs(dirs)...); err != nil {
+			return nil, fmt.Errorf("gazelle failed: %w", err)
+		}
+		for rel, content := range generated.Files() {
+			// Gazelle also writes the BUILD files of subdirectories
+			p := planDir(filepath.FromSlash(path.Dir(rel)))
+			p.BuildFile = filepath.FromSlash(rel)
+			if p.Diff, err = w.buildFileDiff(rel, content); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	planned := make([]PlannedUpdate, 0, len(byDir))
+	for _, dir := range slices.Sorted(maps.Keys(byDir)) {
+		planned = append(planned, *byDir[dir])
+	}
+	return planned, nil
+}
+
+// buildFileDiff returns the unified diff from the BUILD file rel, a
+// slash-separated path relative to Root, to content. A missing file is
+// diffed from /dev/null.
+func (w *Watcher) buildFileDiff(rel string, content []byte) (string, error) {
+	diff := difflib.UnifiedDiff{
+		B:        difflib.SplitLines(string(content)),
+		FromFile: rel,
+		ToFile:   rel,
+		Context:  3,
+	}
+	old, err := os.ReadFile(filepath.Join(w.config.Root, filepath.FromSlash(rel)))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		diff.FromFile = "/dev/null"
+	case err != nil:
+		return "", err
+	case len(old) > 0:
+		diff.A = difflib.SplitLines(string(old))
+	}
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// FileCount returns the number of files in the watcher's index.
+func (w *Watcher) FileCount() int {
+	return w.tracker.TrackedFileCount()
+}
+
+// Index returns the tracked file-to-package mapping: each workspace-relative
+// file path maps to the label of the package that owns it (its directory).
+// This is the view the incremental logic uses to pick directories to update.
+func (w *Watcher) Index() (map[string]string, error) {
+	files, err := w.tracker.TrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]string, len(files))
+	for _, f := range files {
+		index[f] = packageLabel(filepath.Dir(f))
+	}
+	return index, nil
+}
+
 // Close closes the watcher and releases resources.
 func (w *Watcher) Close() error {
+	if w.releaseResolveMaps != nil {
+		w.releaseResolveMaps()
+	}
 	if w.fsWatcher != nil {
 		return w.fsWatcher.Close()
 	}
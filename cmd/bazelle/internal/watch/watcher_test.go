@@ -1,9 +1,25 @@
 package watch
 
 import (
+	"bytes"
+	"context"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/progress"
+	"github.com/albertocavalcante/bazelle/gazelle-python/python"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	golang "github.com/bazelbuild/bazel-gazelle/language/go"
+	"github.com/bazelbuild/bazel-gazelle/language/proto"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	"github.com/fsnotify/fsnotify"
 )
 
 func TestIsWatchLimitError(t *testing.T) {
@@ -153,15 +169,15 @@ func TestNewWatcher(t *testing.T) {
 	}
 
 	// Verify ignore dirs are populated
-	if len(w.ignoreDirs) == 0 {
-		t.Error("ignoreDirs map is empty")
+	if w.ignorer == nil {
+		t.Fatal("ignorer is nil")
 	}
 
-	// Check that common ignore patterns are present
-	expectedIgnores := []string{"bazel-", ".", "node_modules"}
+	// Check that common ignore patterns are applied
+	expectedIgnores := []string{"bazel-out", ".git", "node_modules"}
 	for _, dir := range expectedIgnores {
-		if !w.ignoreDirs[dir] {
-			t.Errorf("expected ignore pattern %s not found", dir)
+		if !w.ignorer.SkipDir(filepath.Join(tmpDir, dir)) {
+			t.Errorf("expected directory %s to be ignored", dir)
 		}
 	}
 }
@@ -233,3 +249,776 @@ func TestWatcherCloseNilFsWatcher(t *testing.T) {
 		t.Errorf("Close() on nil fsWatcher error = %v", err)
 	}
 }
+
+func TestRunOnUpdate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command uses POSIX sh syntax")
+	}
+
+	// Watch the workspace by a relative path
+	root := t.TempDir()
+	t.Chdir(filepath.Dir(root))
+	if err := os.WriteFile(filepath.Join(root, "MODULE.bazel"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"", filepath.Join("src", "auth")} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, rel := range []string{"main.go", filepath.Join("src", "auth", "auth.go")} {
+		if err := os.WriteFile(filepath.Join(root, rel), []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := New(Config{
+		Root:            filepath.Base(root),
+		Languages:       []language.Language{proto.NewLanguage(), golang.NewLanguage()},
+		LangFilter:      []string{"go"},
+		GazelleDefaults: []string{"-repo_root=" + root, "-go_prefix=example.com/ws"},
+		OnUpdate:        `printf '%s|%s' "$BAZELLE_UPDATED_PACKAGES" "$BAZELLE_WORKSPACE_ROOT" > hook.out`,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+	out := &lockedBuffer{}
+	w.logger = NewLogger(LoggerConfig{Writer: out, NoColor: true})
+
+	// The debouncer flushing both packages
+	w.handleChangedDirs([]string{"src/auth", "."})
+
+	got, err := os.ReadFile(filepath.Join(root, "hook.out"))
+	if err != nil {
+		t.Fatalf("on-update command did not run: %v; output:\n%s", err, out.String())
+	}
+	want := "// //src/auth|" + root
+	if string(got) != want {
+		t.Errorf("command saw %q, want %q", got, want)
+	}
+	if stats := w.logger.Stats(); stats.ErrorCount != 0 {
+		t.Errorf("ErrorCount = %d, want 0", stats.ErrorCount)
+	}
+}
+
+func TestRunOnUpdateFailureIsLogged(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command uses POSIX sh syntax")
+	}
+
+	var buf bytes.Buffer
+	w := &Watcher{
+		config: Config{Root: t.TempDir(), OnUpdate: "exit 3"},
+		logger: NewLogger(LoggerConfig{Writer: &buf, NoColor: true}),
+	}
+
+	w.runOnUpdate([]string{"pkg"})
+
+	if stats := w.logger.Stats(); stats.ErrorCount != 1 {
+		t.Errorf("ErrorCount = %d, want 1", stats.ErrorCount)
+	}
+	if !strings.Contains(buf.String(), "on-update command failed") {
+		t.Errorf("expected failure to be logged, got %q", buf.String())
+	}
+}
+
+func TestRunOnUpdateNoCommand(t *testing.T) {
+	// No command configured: must be a no-op (logger is nil on purpose).
+	w := &Watcher{config: Config{Root: t.TempDir()}}
+	w.runOnUpdate([]string{"pkg"})
+}
+
+// lockedBuffer is a bytes.Buffer safe for use by a running watcher and the
+// test reading its output.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunReplayOnStart(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "MODULE.bazel"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(tmpDir, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "src", "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(Config{
+		Root:            tmpDir,
+		LangFilter:      []string{"go"},
+		GazelleDefaults: []string{"-repo_root=" + tmpDir},
+		ReplayOnStart:   true,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+
+	out := &lockedBuffer{}
+	w.logger = NewLogger(LoggerConfig{Writer: out, JSON: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(out.String(), `"event":"ready"`) {
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("watcher never became ready; output:\n%s", out.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	log := out.String()
+	lines := strings.Split(log, "\n")
+	updating := slices.IndexFunc(lines, func(l string) bool { return strings.Contains(l, `"event":"updating"`) })
+	ready := slices.IndexFunc(lines, func(l string) bool { return strings.Contains(l, `"event":"ready"`) })
+	if updating < 0 || updating > ready {
+		t.Fatalf("stale package was not updated before the watcher became ready; output:\n%s", log)
+	}
+	if !strings.Contains(lines[updating], `"dirs":["src"]`) {
+		t.Errorf("replay updated the wrong packages; output:\n%s", log)
+	}
+	if !w.tracker.HasState() {
+		t.Error("replay did not record state for the updated packages")
+	}
+}
+
+func TestRunWarmIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "lib", "Util.kt"), []byte("package com.example.util\n\nfun util() = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(Config{Root: tmpDir, LangFilter: []string{"kotlin"}, WarmIndex: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+	w.logger = NewLogger(LoggerConfig{Writer: &lockedBuffer{}, JSON: true})
+	if got := w.State(); got != StateStarting {
+		t.Errorf("State() before Run = %q, want %q", got, StateStarting)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for w.State() != StateReady {
+		if time.Now().After(deadline) {
+			cancel()
+			t.Fatalf("watcher never became ready; state %q", w.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The index must already be there the moment the watcher reports ready.
+	symbols := w.Symbols()
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if symbols == nil {
+		t.Fatal("symbol index was not built before the watcher became ready")
+	}
+	targets := symbols.Targets("com.example.util")
+	if len(targets) != 1 || targets[0].String() != "//lib" {
+		t.Errorf("Targets(com.example.util) = %v, want [//lib]", targets)
+	}
+}
+
+func TestRunWarmIndexCancelled(t *testing.T) {
+	w, err := New(Config{Root: t.TempDir(), LangFilter: []string{"kotlin"}, WarmIndex: true})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer w.Close()
+	w.logger = NewLogger(LoggerConfig{Writer: &lockedBuffer{}, JSON: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if w.Symbols() != nil {
+		t.Error("symbol index was built although the context was cancelled")
+	}
+	if got := w.State(); got == StateReady {
+		t.Errorf("State() = %q, want the watcher not to become ready", got)
+	}
+}
+
+func TestUpdateReindexesSymbols(t *testing.T) {
+	root := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("MODULE.bazel", "")
+	writeFile("lib/Util.kt", "package com.example.util\n\nfun util() = 1\n")
+	writeFile("app/App.kt", "package com.example.app\n\nimport com.example.util.util\n")
+
+	w, err := New(Config{
+		Root:            root,
+		Languages:       []language.Language{proto.NewLanguage(), golang.NewLanguage()},
+		GazelleDefaults: []string{"-repo_root=" + root, "-go_prefix=example.com/ws"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	w.logger = NewLogger(LoggerConfig{Writer: &lockedBuffer{}, JSON: true})
+	if err := w.warmIndex(context.Background()); err != nil {
+		t.Fatalf("warmIndex() error = %v", err)
+	}
+
+	// lib moves to another package, leaving the import of app dangling
+	writeFile("lib/Util.kt", "package com.example.text\n\nfun util() = 1\n")
+	result, err := w.Update(context.Background(), []string{"lib", "app"}, nil)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if got, want := w.Symbols().Packages(), []string{"com.example.app", "com.example.text"}; !slices.Equal(got, want) {
+		t.Errorf("Symbols().Packages() = %v, want %v", got, want)
+	}
+	want := `unresolved import: //app imports "com.example.util.util"`
+	if !slices.Contains(result.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, want)
+	}
+}
+
+// newGoWorkspace creates a workspace with a Go package in src and returns a
+// watcher for it that generates with the Go extension.
+func newGoWorkspace(t *testing.T, onProgress progress.Func) *Watcher {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "MODULE.bazel"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "src", "lib.go"), []byte("package src\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := New(Config{
+		Root:            root,
+		Languages:       []language.Language{proto.NewLanguage(), golang.NewLanguage()},
+		LangFilter:      []string{"go"},
+		GazelleDefaults: []string{"-repo_root=" + root, "-go_prefix=example.com/ws"},
+		OnProgress:      onProgress,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	w.logger = NewLogger(LoggerConfig{Writer: &lockedBuffer{}, JSON: true})
+	return w
+}
+
+func TestReindexKeepsChangedPackagesStale(t *testing.T) {
+	w := newGoWorkspace(t, nil)
+	ctx := context.Background()
+	if _, err := w.Update(ctx, nil, nil); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// A package added out of band, then taken into the file index
+	tool := filepath.Join(w.config.Root, "tool")
+	if err := os.MkdirAll(tool, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tool, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := w.Reindex(ctx); err != nil || n != 2 {
+		t.Fatalf("Reindex() = %d, %v, want 2 files", n, err)
+	}
+
+	planned, err := w.Plan(ctx)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(planned) != 1 || planned[0].Dir != "tool" || !planned[0].NewBuildFile {
+		t.Fatalf("Plan() = %+v, want the new tool package", planned)
+	}
+	if diff := planned[0].Diff; !strings.HasPrefix(diff, "--- /dev/null\n+++ tool/BUILD.bazel\n") || !strings.Contains(diff, "+go_library(") {
+		t.Errorf("Plan() diff = %q, want a new BUILD file with a go_library", diff)
+	}
+	if _, err := os.Stat(filepath.Join(tool, "BUILD.bazel")); !os.IsNotExist(err) {
+		t.Errorf("Plan() wrote the tool BUILD file (stat error = %v)", err)
+	}
+
+	result, err := w.Update(ctx, nil, nil)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if want := []string{"tool"}; !slices.Equal(result.Dirs, want) {
+		t.Errorf("Update() dirs = %v, want %v", result.Dirs, want)
+	}
+	if _, err := os.Stat(filepath.Join(tool, "BUILD.bazel")); err != nil {
+		t.Errorf("tool BUILD file was not generated: %v", err)
+	}
+	if planned, err := w.Plan(ctx); err != nil || len(planned) != 0 {
+		t.Errorf("Plan() after the update = %+v, %v, want nothing stale", planned, err)
+	}
+}
+
+func TestUpdateAndWatchCycleSamePackage(t *testing.T) {
+	// Each run tags its progress events; serialized runs never interleave.
+	var mu sync.Mutex
+	var runs []string
+	record := func(run string) progress.Func {
+		return func(progress.Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			runs = append(runs, run)
+		}
+	}
+	w := newGoWorkspace(t, record("watch"))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := w.Update(context.Background(), []string{"src"}, record("manual")); err != nil {
+			t.Errorf("Update() error = %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		w.handleChangedDirs([]string{"src"})
+	}()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Contains(runs, "manual") {
+		t.Fatalf("manual update did not run; progress %v", runs)
+	}
+	// Switching between runs more than once means they overlapped.
+	switches := 0
+	for i := 1; i < len(runs); i++ {
+		if runs[i] != runs[i-1] {
+			switches++
+		}
+	}
+	if switches > 1 {
+		t.Errorf("update runs interleaved: %v", runs)
+	}
+
+	path := filepath.Join(w.config.Root, "src", "BUILD.bazel")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := rule.LoadData(path, "src", data)
+	if err != nil {
+		t.Fatalf("BUILD file is corrupt: %v\n%s", err, data)
+	}
+	if rules := f.Rules; len(rules) != 1 || rules[0].Kind() != "go_library" {
+		t.Errorf("BUILD file rules = %d, want a single go_library:\n%s", len(rules), data)
+	}
+}
+
+func TestWatchCycleCoalescesBehindUpdate(t *testing.T) {
+	watchRuns := 0
+	w := newGoWorkspace(t, func(progress.Event) { watchRuns++ })
+
+	// Hold src as a running update would, and let a watch cycle queue up
+	// behind it.
+	key := filepath.Join(w.config.Root, "src")
+	_, unlock := w.locks.Lock([]string{key})
+	waiting := make(chan struct{})
+	w.locks.onWait = func(string) { close(waiting) }
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.handleChangedDirs([]string{"src"})
+	}()
+	<-waiting
+
+	// The holder updates src, leaving it up to date.
+	w.gazelleMu.Lock()
+	_, err := w.update([]string{"src"}, nil)
+	w.gazelleMu.Unlock()
+	unlock()
+	if err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+
+	<-done
+	if watchRuns != 0 {
+		t.Errorf("watch cycle regenerated src after the update it waited for")
+	}
+
+	// A package still stale after the wait is updated.
+	if err := os.WriteFile(filepath.Join(w.config.Root, "src", "more.go"), []byte("package src\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, unlock = w.locks.Lock([]string{key})
+	waiting = make(chan struct{})
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		w.handleChangedDirs([]string{"src"})
+	}()
+	<-waiting
+	unlock()
+	<-done
+	if watchRuns == 0 {
+		t.Error("watch cycle skipped src although it was still stale")
+	}
+}
+
+func TestUpdateReportsWarnings(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"MODULE.bazel":      "",
+		"BUILD.bazel":       "# gazelle:python_enabled true\n",
+		"tools/run.py":      "import os, sys\nsys.path.insert(0, os.path.dirname(__file__))\n",
+		"tools/__init__.py": "",
+		"quiet/lib.py":      "import os\n",
+		"quiet/__init__.py": "",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := New(Config{
+		Root:            root,
+		Languages:       []language.Language{python.NewLanguage()},
+		GazelleDefaults: []string{"-repo_root=" + root},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	w.logger = NewLogger(LoggerConfig{Writer: &lockedBuffer{}, JSON: true})
+
+	result, err := w.Update(context.Background(), []string{"tools"}, nil)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if !slices.Equal(result.Dirs, []string{"tools"}) {
+		t.Errorf("Dirs = %v, want [tools]", result.Dirs)
+	}
+	if !slices.ContainsFunc(result.Warnings, func(w string) bool {
+		return strings.Contains(w, "modifies sys.path") && strings.Contains(w, "tools/run.py")
+	}) {
+		t.Errorf("Warnings = %q, want the sys.path warning for tools/run.py", result.Warnings)
+	}
+
+	result, err = w.Update(context.Background(), []string{"quiet"}, nil)
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(result.Warnings) != 0 {
+		t.Errorf("Warnings of a clean package = %q, want none", result.Warnings)
+	}
+}
+
+func TestParseEventMode(t *testing.T) {
+	for in, want := range map[string]EventMode{
+		"":             EventsPerPackage,
+		"per-package":  EventsPerPackage,
+		"consolidated": EventsConsolidated,
+	} {
+		if got, err := ParseEventMode(in); err != nil || got != want {
+			t.Errorf("ParseEventMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseEventMode("batched"); err == nil {
+		t.Error("ParseEventMode(batched) succeeded, want error")
+	}
+}
+
+func TestUpdateEventsPerCycle(t *testing.T) {
+	tests := []struct {
+		mode EventMode
+		want [][]string
+	}{
+		{mode: EventsPerPackage, want: [][]string{{"a"}, {"b"}, {"src"}}},
+		{mode: EventsConsolidated, want: [][]string{{"a", "b", "src"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			w := newGoWorkspace(t, nil)
+			out := &lockedBuffer{}
+			w.logger = NewLogger(LoggerConfig{Writer: out, JSON: true})
+
+			var mu sync.Mutex
+			var events [][]string
+			w.config.Events = tt.mode
+			w.config.OnUpdated = func(dirs []string) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, slices.Clone(dirs))
+			}
+
+			// Change files in three packages within one debounce window.
+			var files []string
+			for _, pkg := range []string{"a", "b", "src"} {
+				dir := filepath.Join(w.config.Root, pkg)
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				file := filepath.Join(dir, pkg+"_more.go")
+				if err := os.WriteFile(file, []byte("package "+pkg+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				files = append(files, file)
+			}
+
+			flushed := make(chan struct{})
+			w.debouncer = NewDebouncer(50*time.Millisecond, func(dirs []string) {
+				w.handleChangedDirs(dirs)
+				close(flushed)
+			})
+			defer w.debouncer.Stop()
+			for _, file := range files {
+				w.handleEvent(fsnotify.Event{Name: file, Op: fsnotify.Create})
+			}
+
+			select {
+			case <-flushed:
+			case <-time.After(10 * time.Second):
+				t.Fatal("debouncer never flushed")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(events) != len(tt.want) {
+				t.Fatalf("OnUpdated called %d times with %v, want %v", len(events), events, tt.want)
+			}
+			for i := range events {
+				if !slices.Equal(events[i], tt.want[i]) {
+					t.Errorf("OnUpdated call %d got %v, want %v", i, events[i], tt.want[i])
+				}
+			}
+			if got := strings.Count(out.String(), `"event":"updated"`); got != len(tt.want) {
+				t.Errorf("logged %d updated events, want %d:\n%s", got, len(tt.want), out)
+			}
+		})
+	}
+}
+
+func TestRunAtomicSave(t *testing.T) {
+	w := newGoWorkspace(t, nil)
+	out := &lockedBuffer{}
+	w.logger = NewLogger(LoggerConfig{Writer: out, JSON: true})
+	w.config.Debounce = 50
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+	waitForLog := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for !strings.Contains(out.String(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("log never contained %s; output:\n%s", want, out.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForLog(`"event":"ready"`)
+
+	// Save src/lib.go the way most editors do: write a temporary file
+	// next to it, then rename it over the original.
+	target := filepath.Join(w.config.Root, "src", "lib.go")
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, []byte("package src\n\nconst X = 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		t.Fatal(err)
+	}
+	waitForLog(`"event":"updated"`)
+	time.Sleep(2 * atomicSaveWindow) // any late events would be logged by now
+
+	var changes []string
+	for line := range strings.Lines(out.String()) {
+		if strings.Contains(line, `"event":"file_changed"`) {
+			changes = append(changes, line)
+		}
+	}
+	if len(changes) != 1 {
+		t.Fatalf("logged %d file changes, want 1:\n%s", len(changes), out.String())
+	}
+	if !strings.Contains(changes[0], `"change":"~"`) || !strings.Contains(changes[0], `lib.go"`) {
+		t.Errorf("file change = %s, want a modification of src/lib.go", changes[0])
+	}
+}
+
+func TestUpdateReloadsResolveMaps(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"MODULE.bazel":          "",
+		"BUILD.bazel":           "# gazelle:python_enabled true\n",
+		".bazelle/resolve.toml": "[resolve]\n",
+		"app/main.py":           "import requests\nimport yaml\n",
+		"app/__init__.py":       "",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := New(Config{
+		Root:            root,
+		Languages:       []language.Language{python.NewLanguage()},
+		GazelleDefaults: []string{"-repo_root=" + root},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = w.Close() })
+	out := &lockedBuffer{}
+	w.logger = NewLogger(LoggerConfig{Writer: out, JSON: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	})
+	waitForLog := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for !strings.Contains(out.String(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("log never contained %s; output:\n%s", want, out.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForLog(`"event":"ready"`)
+
+	// saveMap replaces the resolve map of dir the way editors save files:
+	// write a temporary file, then rename it into place.
+	saveMap := func(dir, content string) {
+		t.Helper()
+		tmp := filepath.Join(root, "resolve.toml.tmp")
+		if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		dst := filepath.Join(root, dir, ".bazelle", "resolve.toml")
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unresolved := func() []string {
+		t.Helper()
+		result, err := w.Update(context.Background(), []string{"app"}, nil)
+		if err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		var imports []string
+		for _, warning := range result.Warnings {
+			if imp, ok := strings.CutPrefix(warning, "unresolved import: //app imports "); ok {
+				imports = append(imports, imp)
+			}
+		}
+		return imports
+	}
+
+	if got, want := unresolved(), []string{`"requests"`, `"yaml"`}; !slices.Equal(got, want) {
+		t.Errorf("unresolved imports = %v, want %v", got, want)
+	}
+
+	saveMap(".", "[resolve]\n\"yaml\" = \"@pip//pyyaml\"\n")
+	waitForLog(`"entries":1,"event":"resolve_map_reloaded","path":".bazelle/resolve.toml"`)
+	if got, want := unresolved(), []string{`"requests"`}; !slices.Equal(got, want) {
+		t.Errorf("unresolved imports after editing the global map = %v, want %v", got, want)
+	}
+	checkDeps := func(want ...string) {
+		t.Helper()
+		content, err := os.ReadFile(filepath.Join(root, "app", "BUILD.bazel"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dep := range want {
+			if !strings.Contains(string(content), strconv.Quote(dep)) {
+				t.Errorf("app/BUILD.bazel does not depend on %s:\n%s", dep, content)
+			}
+		}
+	}
+	checkDeps("@pip//pyyaml")
+
+	// A map in a directory that had none is picked up too, and takes
+	// precedence over the global map
+	saveMap("app", "[resolve]\n\"requests\" = \"@pip//requests\"\n\"yaml\" = \"@pip//ruamel_yaml\"\n")
+	waitForLog(`"entries":2,"event":"resolve_map_reloaded","path":"app`)
+	if got := unresolved(); len(got) != 0 {
+		t.Errorf("unresolved imports after adding a local map = %v, want none", got)
+	}
+	checkDeps("@pip//requests", "@pip//ruamel_yaml")
+
+	// A map that no longer parses leaves the previous one in use
+	saveMap("app", "[resolve\n")
+	waitForLog("keeping the previous map")
+	if got := unresolved(); len(got) != 0 {
+		t.Errorf("unresolved imports after breaking the local map = %v, want none", got)
+	}
+	checkDeps("@pip//requests", "@pip//ruamel_yaml")
+}
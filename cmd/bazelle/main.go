@@ -3,6 +3,7 @@ package main
 
 import (
 	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/cli"
+	"github.com/albertocavalcante/bazelle/cmd/bazelle/internal/langs"
 	"github.com/albertocavalcante/bazelle/internal/log"
 	"github.com/albertocavalcante/bazelle/pkg/config"
 	"github.com/albertocavalcante/bazelle/pkg/registry"
@@ -19,6 +20,11 @@ func main() {
 	// Re-initialize logger with config values
 	log.Init(cfg.Log.Verbosity, cfg.Log.Format)
 
+	// Register custom source extensions before anything reads them
+	if err := langs.AddExtensions(cfg.Extensions); err != nil {
+		log.Warn("ignoring custom source extensions", "error", err)
+	}
+
 	// Load languages based on configuration
 	languages := registry.LoadLanguages(cfg)
 
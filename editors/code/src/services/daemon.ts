@@ -103,6 +103,7 @@ export interface UpdateRunParams {
 
 export interface UpdateRunResult {
   status: string;
+  changed: boolean;
   updated_dirs?: string[];
   duration?: string;
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/internal/wspath"
 	"github.com/albertocavalcante/bazelle/pkg/jvm"
 	"github.com/bazelbuild/bazel-gazelle/language"
 	"github.com/bazelbuild/bazel-gazelle/rule"
@@ -62,7 +63,7 @@ func (g *groovyLang) generateLibraryRule(args language.GenerateArgs, gc *GroovyC
 	r.SetAttr("visibility", []string{gc.Visibility})
 
 	// Parse files to collect imports and packages
-	allImports, packages := g.collectImportsAndPackages(args.Dir, files)
+	allImports, packages := g.collectImportsAndPackages(args.Config.RepoRoot, args.Dir, files)
 
 	// Store imports for resolution phase
 	r.SetPrivateAttr("groovy_imports", allImports)
@@ -107,7 +108,7 @@ func (g *groovyLang) generateTestRule(args language.GenerateArgs, gc *GroovyConf
 	}
 
 	// Parse files to collect imports and packages
-	allImports, packages := g.collectImportsAndPackages(args.Dir, files)
+	allImports, packages := g.collectImportsAndPackages(args.Config.RepoRoot, args.Dir, files)
 
 	// Store imports for resolution phase
 	r.SetPrivateAttr("groovy_imports", allImports)
@@ -145,8 +146,9 @@ func shouldUseSpock(hasSpecFiles bool, gc *GroovyConfig) bool {
 	return hasSpecFiles
 }
 
-// collectImportsAndPackages parses Groovy files and collects imports and packages.
-func (g *groovyLang) collectImportsAndPackages(dir string, files []string) ([]string, []string) {
+// collectImportsAndPackages parses the Groovy files in dir, a directory of
+// the workspace at repoRoot, and collects imports and packages.
+func (g *groovyLang) collectImportsAndPackages(repoRoot, dir string, files []string) ([]string, []string) {
 	seen := make(map[string]bool)
 	pkgSeen := make(map[string]bool)
 	var allImports []string
@@ -157,7 +159,7 @@ func (g *groovyLang) collectImportsAndPackages(dir string, files []string) ([]st
 		result, err := g.parser.ParseFile(fullPath)
 		if err != nil {
 			log.Warn("failed to parse groovy file",
-				"file", file, "error", err)
+				"file", wspath.Display(repoRoot, fullPath), "error", err)
 			continue
 		}
 
@@ -3,10 +3,11 @@ package groovy
 import (
 	"bufio"
 	"maps"
-	"os"
 	"regexp"
 	"slices"
 	"strings"
+
+	"github.com/albertocavalcante/bazelle/pkg/util"
 )
 
 // GroovyParser provides HEURISTIC parsing of Groovy source files using regex.
@@ -27,7 +28,7 @@ import (
 // The following edge cases may produce incorrect results:
 //   - Package/import declarations inside string literals are matched as real
 //   - Multi-line package declarations are not supported
-//   - @Grab annotations with unusual formatting may be partially captured
+//   - @Grab annotations split across several lines are not captured
 //   - Comments within package/import statements may confuse the parser
 //
 // # Thread Safety
@@ -40,7 +41,9 @@ type GroovyParser struct {
 	importRegex       *regexp.Regexp // Matches regular imports
 	importStaticRegex *regexp.Regexp // Matches static imports
 	starImportRegex   *regexp.Regexp // Matches star imports (import X.*)
-	grabRegex         *regexp.Regexp // Matches @Grab annotations
+	grabRegex         *regexp.Regexp // Matches the start of @Grab annotations
+	grabValueRegex    *regexp.Regexp // Matches the short form @Grab argument
+	grabAttrRegex     *regexp.Regexp // Matches the named @Grab arguments
 	declarationRegex  *regexp.Regexp // Detects start of code (end of imports)
 
 	// FQN scanner for detecting inline fully qualified names (also HEURISTIC)
@@ -52,9 +55,20 @@ type GroovyParser struct {
 
 // GrabDependency represents a @Grab annotation dependency.
 type GrabDependency struct {
-	Group   string
-	Module  string
-	Version string
+	Group      string
+	Module     string
+	Version    string // empty if not given; "*" for the latest version
+	Classifier string
+}
+
+// Coordinates returns the Maven coordinates of d, "group:module", followed
+// by ":version" when d has a version.
+func (d GrabDependency) Coordinates() string {
+	coords := d.Group + ":" + d.Module
+	if d.Version != "" {
+		coords += ":" + d.Version
+	}
+	return coords
 }
 
 // ParseResult contains the parsed metadata from a Groovy file.
@@ -71,7 +85,8 @@ type ParseResult struct {
 	// StarImports is a list of star imports (e.g., "com.example.*").
 	StarImports []string
 
-	// GrabDeps is a list of @Grab annotation dependencies.
+	// GrabDeps is a list of @Grab annotation dependencies, including the
+	// ones grouped in @Grapes, in source order.
 	GrabDeps []GrabDependency
 
 	// FQNs is a list of fully qualified names found in the code body.
@@ -124,10 +139,19 @@ func NewParser(opts ...ParserOption) *GroovyParser {
 		// End anchor prevents matching "import com.example.*.Foo" (invalid)
 		starImportRegex: regexp.MustCompile(`^\s*import\s+([a-zA-Z][a-zA-Z0-9_]*(?:\.[a-zA-Z][a-zA-Z0-9_]*)*)\.\*\s*$`),
 
-		// HEURISTIC: Match @Grab annotations
-		// Handles: @Grab('group:module:version') and @Grab(group='x', module='y', version='z')
-		// Captures: either the short form string or individual parts
-		grabRegex: regexp.MustCompile(`@Grab\s*\(\s*(?:'([^']+)'|"([^"]+)"|group\s*=\s*['"]([^'"]+)['"].*module\s*=\s*['"]([^'"]+)['"].*version\s*=\s*['"]([^'"]+)['"])`),
+		// HEURISTIC: Match the start of @Grab annotations
+		// Handles: @Grab(...), @groovy.lang.Grab(...) and each @Grab of a
+		// @Grapes([@Grab(...), @Grab(...)]) written on one line
+		// Does not match @GrabConfig, @GrabExclude or @GrabResolver
+		grabRegex: regexp.MustCompile(`@(?:groovy\.lang\.)?Grab\s*\(`),
+
+		// HEURISTIC: Match the short form @Grab argument
+		// Handles: 'group:module:version' and value="group:module:version"
+		grabValueRegex: regexp.MustCompile(`^\s*(?:value\s*=\s*)?(?:'([^']*)'|"([^"]*)")\s*$`),
+
+		// HEURISTIC: Match the named @Grab arguments, in any order
+		// Handles: group='x', module="y", version='z'
+		grabAttrRegex: regexp.MustCompile(`(\w+)\s*=\s*(?:'([^']*)'|"([^"]*)")`),
 
 		// HEURISTIC: Detect start of code (end of import section)
 		// Matches any Groovy declaration keyword at start of line
@@ -146,12 +170,14 @@ func NewParser(opts ...ParserOption) *GroovyParser {
 
 // ParseFile parses a Groovy source file and returns metadata.
 func (p *GroovyParser) ParseFile(path string) (*ParseResult, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
+	return util.TimeParse(path, func() (*ParseResult, error) {
+		content, err := util.ReadSourceFile(path)
+		if err != nil {
+			return nil, err
+		}
 
-	return p.ParseContent(string(content), path)
+		return p.ParseContent(content, path)
+	})
 }
 
 // ParseContent parses Groovy source code content and returns metadata.
@@ -200,9 +226,12 @@ func (p *GroovyParser) ParseContent(content string, path string) (*ParseResult,
 		}
 
 		// Check for @Grab annotations anywhere in the file
-		if matches := p.grabRegex.FindStringSubmatch(line); len(matches) > 0 {
-			grab := parseGrabAnnotation(matches)
-			if grab.Module != "" {
+		for _, loc := range p.grabRegex.FindAllStringIndex(line, -1) {
+			args := line[loc[1]:]
+			if end := strings.IndexByte(args, ')'); end >= 0 {
+				args = args[:end]
+			}
+			if grab := p.parseGrabAnnotation(args); grab.Module != "" {
 				result.GrabDeps = append(result.GrabDeps, grab)
 			}
 		}
@@ -283,40 +312,52 @@ func (p *GroovyParser) ParseFiles(paths []string) ([]*ParseResult, error) {
 	return results, nil
 }
 
-// parseGrabAnnotation extracts dependency info from @Grab regex matches.
-func parseGrabAnnotation(matches []string) GrabDependency {
+// parseGrabAnnotation extracts dependency info from the arguments of a
+// @Grab annotation, the text between its parentheses. It returns the zero
+// GrabDependency if args name no group and module.
+func (p *GroovyParser) parseGrabAnnotation(args string) GrabDependency {
 	// Short form: @Grab('group:module:version')
-	shortForm := matches[1]
-	if shortForm == "" {
-		shortForm = matches[2] // Double quotes
-	}
-	if shortForm != "" {
-		parts := strings.Split(shortForm, ":")
-		if len(parts) >= 3 {
-			return GrabDependency{
-				Group:   parts[0],
-				Module:  parts[1],
-				Version: parts[2],
-			}
-		}
-		if len(parts) == 2 {
-			return GrabDependency{
-				Group:  parts[0],
-				Module: parts[1],
-			}
-		}
+	if m := p.grabValueRegex.FindStringSubmatch(args); m != nil {
+		return parseGrabCoordinates(m[1] + m[2])
 	}
 
 	// Long form: @Grab(group='x', module='y', version='z')
-	if len(matches) > 5 && matches[3] != "" && matches[4] != "" {
-		return GrabDependency{
-			Group:   matches[3],
-			Module:  matches[4],
-			Version: matches[5],
+	var grab GrabDependency
+	for _, m := range p.grabAttrRegex.FindAllStringSubmatch(args, -1) {
+		value := m[2] + m[3]
+		switch m[1] {
+		case "group":
+			grab.Group = value
+		case "module":
+			grab.Module = value
+		case "version":
+			grab.Version = value
+		case "classifier":
+			grab.Classifier = value
 		}
 	}
+	if grab.Group == "" || grab.Module == "" {
+		return GrabDependency{}
+	}
+	return grab
+}
 
-	return GrabDependency{}
+// parseGrabCoordinates parses the short form @Grab coordinates,
+// "group:module[:version[:classifier]][@ext]". The extension is dropped.
+func parseGrabCoordinates(coords string) GrabDependency {
+	coords, _, _ = strings.Cut(coords, "@")
+	parts := strings.Split(coords, ":")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return GrabDependency{}
+	}
+	grab := GrabDependency{Group: parts[0], Module: parts[1]}
+	if len(parts) > 2 {
+		grab.Version = parts[2]
+	}
+	if len(parts) > 3 {
+		grab.Classifier = parts[3]
+	}
+	return grab
 }
 
 // buildAllDependencies combines imports and FQNs into a single list.
@@ -202,6 +202,58 @@ func TestParseGrabAnnotations(t *testing.T) {
 				{Group: "com.google.guava", Module: "guava", Version: "31.0-jre"},
 			},
 		},
+		{
+			name:    "long form grab",
+			content: "@Grab(group='org.apache.commons', module='commons-lang3', version='3.12.0')\nclass Foo {}",
+			wantGrab: []GrabDependency{
+				{Group: "org.apache.commons", Module: "commons-lang3", Version: "3.12.0"},
+			},
+		},
+		{
+			name:    "long form in any order with classifier",
+			content: `@Grab(module="json-lib", classifier="jdk15", group="net.sf.json-lib", version="2.4")\nclass Foo {}`,
+			wantGrab: []GrabDependency{
+				{Group: "net.sf.json-lib", Module: "json-lib", Version: "2.4", Classifier: "jdk15"},
+			},
+		},
+		{
+			name:    "short form with classifier and extension",
+			content: "@Grab('net.sf.json-lib:json-lib:2.4:jdk15@jar')\nclass Foo {}",
+			wantGrab: []GrabDependency{
+				{Group: "net.sf.json-lib", Module: "json-lib", Version: "2.4", Classifier: "jdk15"},
+			},
+		},
+		{
+			name: "script with grapes",
+			content: `#!/usr/bin/env groovy
+@GrabResolver(name='internal', root='https://repo.example.com/maven')
+@Grapes([
+    @Grab('com.google.guava:guava:31.0-jre'),
+    @groovy.lang.Grab(group='org.slf4j', module='slf4j-api', version='2.0.9')
+])
+@GrabExclude('commons-logging:commons-logging')
+import com.google.common.collect.ImmutableList
+
+println ImmutableList.of(1, 2, 3)
+`,
+			wantGrab: []GrabDependency{
+				{Group: "com.google.guava", Module: "guava", Version: "31.0-jre"},
+				{Group: "org.slf4j", Module: "slf4j-api", Version: "2.0.9"},
+			},
+		},
+		{
+			name:    "grapes on one line",
+			content: "@Grapes([@Grab('org.jsoup:jsoup:1.16.1'), @Grab(value='com.h2database:h2:*')])\nclass Foo {}",
+			wantGrab: []GrabDependency{
+				{Group: "org.jsoup", Module: "jsoup", Version: "1.16.1"},
+				{Group: "com.h2database", Module: "h2", Version: "*"},
+			},
+		},
+		{
+			name:     "grab without module",
+			content:  "@Grab('guava')\n@Grab(group='com.google.guava')\nclass Foo {}",
+			wantGrab: []GrabDependency{},
+		},
 	}
 
 	parser := NewParser()
@@ -225,11 +277,29 @@ func TestParseGrabAnnotations(t *testing.T) {
 				if result.GrabDeps[i].Version != grab.Version {
 					t.Errorf("GrabDeps[%d].Version = %q, want %q", i, result.GrabDeps[i].Version, grab.Version)
 				}
+				if result.GrabDeps[i].Classifier != grab.Classifier {
+					t.Errorf("GrabDeps[%d].Classifier = %q, want %q", i, result.GrabDeps[i].Classifier, grab.Classifier)
+				}
 			}
 		})
 	}
 }
 
+func TestGrabDependencyCoordinates(t *testing.T) {
+	tests := []struct {
+		grab GrabDependency
+		want string
+	}{
+		{GrabDependency{Group: "com.google.guava", Module: "guava", Version: "31.0-jre"}, "com.google.guava:guava:31.0-jre"},
+		{GrabDependency{Group: "org.slf4j", Module: "slf4j-api"}, "org.slf4j:slf4j-api"},
+	}
+	for _, tt := range tests {
+		if got := tt.grab.Coordinates(); got != tt.want {
+			t.Errorf("%+v.Coordinates() = %q, want %q", tt.grab, got, tt.want)
+		}
+	}
+}
+
 func TestStripComments(t *testing.T) {
 	tests := []struct {
 		name    string
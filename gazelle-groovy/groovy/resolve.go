@@ -1,6 +1,7 @@
 package groovy
 
 import (
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
 	"github.com/bazelbuild/bazel-gazelle/config"
 	"github.com/bazelbuild/bazel-gazelle/label"
 	"github.com/bazelbuild/bazel-gazelle/repo"
@@ -55,22 +56,34 @@ func (*groovyLang) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Rem
 			continue
 		}
 
-		// Try to resolve using the rule index
-		spec := resolve.ImportSpec{
-			Lang: groovyName,
-			Imp:  imp,
+		// Try the resolve maps, the aggregate targets, then the rule index
+		l, ok := deppolicy.Pinned(c, from, imp)
+		if !ok {
+			l, ok = deppolicy.Aggregate(c, imp)
 		}
-		if matches := ix.FindRulesByImport(spec, groovyName); len(matches) > 0 {
-			// Use the first match
-			l := matches[0].Label
+		if !ok {
+			spec := resolve.ImportSpec{
+				Lang: groovyName,
+				Imp:  imp,
+			}
+			l, ok = deppolicy.Choose(from, imp, ix.FindRulesByImport(spec, groovyName))
+		}
+		if ok {
+			if l.Equal(from) {
+				// The import resolves to the rule itself, such as the
+				// aggregate it belongs to
+				continue
+			}
 			if l.Repo == "" && l.Pkg == from.Pkg {
 				// Same package, use relative label
 				deps = append(deps, ":"+l.Name)
 			} else {
 				deps = append(deps, l.String())
 			}
+			continue
 		}
 		// If not found in index, the import might be an external dependency
+		deppolicy.RecordUnresolved(from, imp)
 	}
 
 	if len(deps) > 0 {
@@ -86,6 +99,6 @@ func (*groovyLang) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Rem
 				seen[d] = true
 			}
 		}
-		r.SetAttr("deps", existingDeps)
+		r.SetAttr("deps", deppolicy.Deps(deppolicy.Apply(c, from, existingDeps)))
 	}
 }
@@ -0,0 +1,54 @@
+package groovy
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/resolve"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+func TestResolve_AggregateTarget(t *testing.T) {
+	root := t.TempDir()
+	policy := filepath.Join(root, deppolicy.DefaultPath)
+	if err := os.MkdirAll(filepath.Dir(policy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[aggregate]\n\"com.example.foo.*\" = \"//libs/foo\"\n"
+	if err := os.WriteFile(policy, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deppolicy.Reset()
+	t.Cleanup(deppolicy.Reset)
+
+	gc := NewGroovyConfig()
+	gc.SetEnabled(true)
+	c := &config.Config{RepoRoot: root, Exts: map[string]interface{}{groovyName: gc}}
+	lang := NewLanguage()
+	ix := resolve.NewRuleIndex(func(*rule.Rule, string) resolve.Resolver { return lang })
+	ix.Finish()
+
+	imports := []string{"com.example.foo.api.Client", "com.example.foo.impl.Store", "com.example.bar.Thing"}
+
+	r := rule.NewRule("groovy_library", "app")
+	lang.Resolve(c, ix, nil, r, imports, label.New("", "app", "app"))
+	if got, want := r.AttrStrings("deps"), []string{"//libs/foo"}; !slices.Equal(got, want) {
+		t.Errorf("deps = %v, want %v", got, want)
+	}
+	unresolved := deppolicy.UnresolvedImports()
+	if len(unresolved) != 1 || unresolved[0].Import != "com.example.bar.Thing" {
+		t.Errorf("UnresolvedImports() = %v, want only com.example.bar.Thing", unresolved)
+	}
+
+	// The umbrella target itself does not depend on itself.
+	self := rule.NewRule("groovy_library", "foo")
+	lang.Resolve(c, ix, nil, self, imports[:2], label.New("", "libs/foo", "foo"))
+	if got := self.AttrStrings("deps"); len(got) != 0 {
+		t.Errorf("deps of //libs/foo = %v, want none", got)
+	}
+}
@@ -0,0 +1,122 @@
+package java
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+// Java AST node types of module declarations (module-info.java).
+const (
+	nodeModuleDeclaration = "module_declaration"
+	nodeModuleBody        = "module_body"
+	nodeRequiresDirective = "requires_module_directive"
+	nodeRequiresModifier  = "requires_modifier"
+	nodeExportsDirective  = "exports_module_directive"
+	nodeOpen              = "open"
+	modifierTransitive    = "transitive"
+	modifierStatic        = "static"
+)
+
+// Module is the JPMS module declared by a module-info.java file.
+type Module struct {
+	// Name is the module name (e.g., "com.example.app").
+	Name string
+
+	// Open reports an open module, whose packages are all open to
+	// reflection.
+	Open bool
+
+	// Requires lists the modules this module reads, sorted by name.
+	Requires []Require
+
+	// Exports lists the packages the module exports, sorted. Qualified
+	// exports ("exports a.b to c.d") are included without their targets.
+	Exports []string
+}
+
+// Require is a "requires" directive of a module declaration.
+type Require struct {
+	// Module is the name of the required module (e.g., "java.sql").
+	Module string
+
+	// Transitive reports "requires transitive": modules reading this one
+	// read the required module too.
+	Transitive bool
+
+	// Static reports "requires static": the module is needed at compile
+	// time only.
+	Static bool
+}
+
+// Dependencies returns the names of the required modules that are not part
+// of the Java platform (see IsPlatformModule), sorted. These are the
+// modules a build has to provide.
+func (m *Module) Dependencies() []string {
+	var deps []string
+	for _, r := range m.Requires {
+		if !IsPlatformModule(r.Module) {
+			deps = append(deps, r.Module)
+		}
+	}
+	return deps
+}
+
+// IsPlatformModule reports whether the module name belongs to the Java
+// platform, which the JDK provides: the java.* and jdk.* modules.
+func IsPlatformModule(name string) bool {
+	return strings.HasPrefix(name, "java.") || strings.HasPrefix(name, "jdk.")
+}
+
+// parseModule extracts the module declared by a module_declaration node.
+//
+// The grammar models "open module a.b { requires static c; }" as the
+// children [open, module, scoped_identifier, module_body], the directives
+// being named children of the body.
+func parseModule(decl treesitter.Node, source []byte) *Module {
+	m := &Module{Name: qualifiedName(decl, source)}
+
+	for _, child := range treesitter.Children(decl) {
+		switch child.Type() {
+		case nodeOpen:
+			m.Open = true
+		case nodeModuleBody:
+			for _, directive := range treesitter.NamedChildren(child) {
+				switch directive.Type() {
+				case nodeRequiresDirective:
+					if r, ok := parseRequire(directive, source); ok {
+						m.Requires = append(m.Requires, r)
+					}
+				case nodeExportsDirective:
+					if pkg := qualifiedName(directive, source); pkg != "" {
+						m.Exports = append(m.Exports, pkg)
+					}
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(m.Requires, func(a, b Require) int { return cmp.Compare(a.Module, b.Module) })
+	slices.Sort(m.Exports)
+	return m
+}
+
+// parseRequire extracts a requires_module_directive node.
+func parseRequire(directive treesitter.Node, source []byte) (Require, bool) {
+	var r Require
+	for _, child := range treesitter.NamedChildren(directive) {
+		if child.Type() != nodeRequiresModifier {
+			continue
+		}
+		switch strings.TrimSpace(child.Content(source)) {
+		case modifierTransitive:
+			r.Transitive = true
+		case modifierStatic:
+			r.Static = true
+		}
+	}
+	r.Module = qualifiedName(directive, source)
+	return r, r.Module != ""
+}
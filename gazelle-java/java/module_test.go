@@ -0,0 +1,128 @@
+package java
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+)
+
+const moduleInfo = `import com.example.spi.Plugin;
+
+/** The application module. */
+@Deprecated
+open module com.example.app {
+    requires transitive java.sql;
+    requires static lombok;
+    requires com.example.core;
+    requires com.fasterxml
+        .jackson.databind;
+
+    exports com.example.app.api;
+    exports com.example.app.spi to com.example.plugin, com.example.other;
+    opens com.example.app.model;
+    uses Plugin;
+    provides Plugin with com.example.app.DefaultPlugin;
+}
+`
+
+func TestParser_ModuleInfo(t *testing.T) {
+	p := newTestParser(t)
+
+	result, err := p.ParseContent(ctx, moduleInfo, jvm.ModuleInfoFile)
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if result.HasSyntaxErrors {
+		t.Error("module-info.java should parse without syntax errors")
+	}
+	m := result.Module
+	if m == nil {
+		t.Fatal("Module: expected the module declaration, got nil")
+	}
+
+	if m.Name != "com.example.app" || !m.Open {
+		t.Errorf("Module: expected open com.example.app, got %q (open %v)", m.Name, m.Open)
+	}
+	wantRequires := []Require{
+		{Module: "com.example.core"},
+		{Module: "com.fasterxml.jackson.databind"},
+		{Module: "java.sql", Transitive: true},
+		{Module: "lombok", Static: true},
+	}
+	if !reflect.DeepEqual(m.Requires, wantRequires) {
+		t.Errorf("Requires: expected %+v, got %+v", wantRequires, m.Requires)
+	}
+	wantDeps := []string{"com.example.core", "com.fasterxml.jackson.databind", "lombok"}
+	if got := m.Dependencies(); !reflect.DeepEqual(got, wantDeps) {
+		t.Errorf("Dependencies: expected %v, got %v", wantDeps, got)
+	}
+	wantExports := []string{"com.example.app.api", "com.example.app.spi"}
+	if !reflect.DeepEqual(m.Exports, wantExports) {
+		t.Errorf("Exports: expected %v, got %v", wantExports, m.Exports)
+	}
+	if !reflect.DeepEqual(result.Imports, []string{"com.example.spi.Plugin"}) {
+		t.Errorf("Imports: expected [com.example.spi.Plugin], got %v", result.Imports)
+	}
+}
+
+func TestParser_NoModule(t *testing.T) {
+	p := newTestParser(t)
+
+	result, err := p.ParseContent(ctx, "package a.b;\n\nclass A {}\n", "A.java")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if result.Module != nil {
+		t.Errorf("Module: expected nil for a class file, got %+v", result.Module)
+	}
+}
+
+func TestParser_KotlinModuleInfo(t *testing.T) {
+	p := newTestParser(t)
+
+	// Kotlin projects keep module-info.java beside the Kotlin sources
+	root := t.TempDir()
+	dir := filepath.Join(root, "src", "main", "kotlin")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "module com.example.kt {\n    requires kotlin.stdlib;\n    requires kotlinx.coroutines.core;\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, jvm.ModuleInfoFile), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rel := jvm.FindModuleInfo(root)
+	if rel == "" {
+		t.Fatal("FindModuleInfo found no module-info.java under src/main/kotlin")
+	}
+	result, err := p.ParseFile(ctx, filepath.Join(root, rel))
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if result.Module == nil || result.Module.Open {
+		t.Fatalf("Module: expected a non-open module, got %+v", result.Module)
+	}
+	want := []string{"kotlin.stdlib", "kotlinx.coroutines.core"}
+	if got := result.Module.Dependencies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies: expected %v, got %v", want, got)
+	}
+}
+
+func TestIsPlatformModule(t *testing.T) {
+	tests := map[string]bool{
+		"java.base":        true,
+		"java.sql":         true,
+		"jdk.httpserver":   true,
+		"javax.inject":     false,
+		"kotlin.stdlib":    false,
+		"com.example.core": false,
+	}
+	for name, want := range tests {
+		if got := IsPlatformModule(name); got != want {
+			t.Errorf("IsPlatformModule(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package java
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+var ctx = context.Background()
+
+// newTestParser returns a Java parser, skipping when no backend supports Java.
+func newTestParser(t *testing.T) *Parser {
+	t.Helper()
+	p, err := NewParser(treesitter.BackendAuto)
+	if err != nil {
+		var unsupported treesitter.ErrLanguageNotSupported
+		if errors.As(err, &unsupported) {
+			t.Skipf("Java not supported: %v", err)
+		}
+		t.Fatalf("NewParser failed: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func TestParser_ParseFile(t *testing.T) {
+	p := newTestParser(t)
+
+	content := `package com.example.app;
+
+import java.util.List;
+import java.util.Map;
+import com.google.common.collect.ImmutableList;
+import static org.junit.Assert.assertEquals;
+import static org.hamcrest.Matchers.*;
+import com.example.util.*;
+
+// import com.example.Commented;
+
+public class Example {
+    String s = "import com.example.InString;";
+
+    public static void main(String[] args) {
+        List<String> items = ImmutableList.of("a", "b");
+    }
+}
+`
+	path := filepath.Join(t.TempDir(), "Example.java")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := p.ParseFile(ctx, path)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if result.Package != "com.example.app" {
+		t.Errorf("Package: expected com.example.app, got %q", result.Package)
+	}
+	if result.FilePath != path {
+		t.Errorf("FilePath: expected %q, got %q", path, result.FilePath)
+	}
+
+	expectedImports := []string{"com.google.common.collect.ImmutableList", "java.util.List", "java.util.Map"}
+	if !reflect.DeepEqual(result.Imports, expectedImports) {
+		t.Errorf("Imports: expected %v, got %v", expectedImports, result.Imports)
+	}
+
+	expectedStatic := []string{"org.hamcrest.Matchers", "org.junit.Assert.assertEquals"}
+	if !reflect.DeepEqual(result.StaticImports, expectedStatic) {
+		t.Errorf("StaticImports: expected %v, got %v", expectedStatic, result.StaticImports)
+	}
+
+	expectedStar := []string{"com.example.util"}
+	if !reflect.DeepEqual(result.StarImports, expectedStar) {
+		t.Errorf("StarImports: expected %v, got %v", expectedStar, result.StarImports)
+	}
+
+	if result.HasSyntaxErrors {
+		t.Error("valid source should not report syntax errors")
+	}
+}
+
+func TestParser_MultiLineImport(t *testing.T) {
+	p := newTestParser(t)
+
+	content := "package a.b;\n\nimport java.util\n    .concurrent\n    .ConcurrentHashMap;\n\nclass A {}\n"
+	result, err := p.ParseContent(ctx, content, "A.java")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	expected := []string{"java.util.concurrent.ConcurrentHashMap"}
+	if !reflect.DeepEqual(result.Imports, expected) {
+		t.Errorf("Imports: expected %v, got %v", expected, result.Imports)
+	}
+}
+
+func TestParser_DefaultPackage(t *testing.T) {
+	p := newTestParser(t)
+
+	result, err := p.ParseContent(ctx, "import java.io.File;\n\nclass A {}\n", "A.java")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if result.Package != "" {
+		t.Errorf("Package: expected empty for default package, got %q", result.Package)
+	}
+	if !reflect.DeepEqual(result.Imports, []string{"java.io.File"}) {
+		t.Errorf("Imports: expected [java.io.File], got %v", result.Imports)
+	}
+}
+
+func TestParser_SyntaxErrors(t *testing.T) {
+	p := newTestParser(t)
+
+	content := "package a.b;\n\nimport java.util.List;\n\nclass A {\n    void broken( {\n"
+	result, err := p.ParseContent(ctx, content, "A.java")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if !result.HasSyntaxErrors {
+		t.Error("expected HasSyntaxErrors for broken source")
+	}
+	if !reflect.DeepEqual(result.Imports, []string{"java.util.List"}) {
+		t.Errorf("Imports: expected [java.util.List], got %v", result.Imports)
+	}
+}
+
+func TestNewParser_WazeroUnsupported(t *testing.T) {
+	_, err := NewParser(treesitter.BackendWazero)
+	if err == nil {
+		t.Skip("wazero backend supports Java")
+	}
+	var unsupported treesitter.ErrLanguageNotSupported
+	if !errors.As(err, &unsupported) {
+		t.Skipf("wazero backend unavailable: %v", err)
+	}
+	if unsupported.Language != treesitter.Java {
+		t.Errorf("Language = %q, want %q", unsupported.Language, treesitter.Java)
+	}
+}
+
+// closeCounter is a fake tree-sitter backend without Java support that
+// counts how many of its kind are open. Only Name, SupportsLanguage and
+// Close may be called.
+type closeCounter struct {
+	treesitter.Backend
+	open *int
+}
+
+func (c *closeCounter) Name() string { return "counted" }
+
+func (c *closeCounter) SupportsLanguage(treesitter.Language) bool { return false }
+
+func (c *closeCounter) Close() error {
+	*c.open--
+	return nil
+}
+
+func TestNewParser_ClosesUnsupportedBackend(t *testing.T) {
+	open := 0
+	old := newBackend
+	t.Cleanup(func() { newBackend = old })
+	newBackend = func(treesitter.BackendType) (treesitter.Backend, error) {
+		open++
+		return &closeCounter{open: &open}, nil
+	}
+
+	_, err := NewParser(treesitter.BackendAuto)
+	var unsupported treesitter.ErrLanguageNotSupported
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("error = %v, want ErrLanguageNotSupported", err)
+	}
+	if open != 0 {
+		t.Errorf("%d backends left open", open)
+	}
+}
@@ -2,10 +2,13 @@ package kotlin
 
 import (
 	"flag"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/albertocavalcante/bazelle/internal/log"
 	"github.com/albertocavalcante/bazelle/pkg/jvm"
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
 	"github.com/bazelbuild/bazel-gazelle/config"
 	"github.com/bazelbuild/bazel-gazelle/rule"
 )
@@ -16,11 +19,49 @@ type KotlinConfig struct {
 	jvm.BaseConfig
 
 	// ParserBackend specifies which parsing strategy to use.
-	// Options: "heuristic" (default), "treesitter", "hybrid"
+	// Options: "heuristic" (default), "treesitter", "hybrid", "adaptive"
 	ParserBackend ParserBackendType
 
 	// EnableFQNScanning enables detection of fully-qualified names in code body.
 	EnableFQNScanning bool
+
+	// AdaptiveMaxBytes and AdaptiveMaxLines are the sizes above which the
+	// adaptive backend parses a file with tree-sitter (see BackendConfig).
+	AdaptiveMaxBytes int
+	AdaptiveMaxLines int
+
+	// FQNScanMaxLines limits FQN scanning to the first lines of each file's
+	// code body (see BackendConfig).
+	FQNScanMaxLines int
+}
+
+var (
+	treeCacheMu sync.RWMutex
+	treeCache   treesitter.TreeCache // set with UseTreeCache
+)
+
+// UseTreeCache makes the tree-sitter backends that GenerateRules creates
+// keep their parse trees in trees, so that a long-running process such as
+// the daemon does not parse unchanged files again on every run. A nil trees
+// stops the caching.
+func UseTreeCache(trees treesitter.TreeCache) {
+	treeCacheMu.Lock()
+	defer treeCacheMu.Unlock()
+	treeCache = trees
+}
+
+// BackendConfig returns the parser backend configuration of the directory.
+func (c *KotlinConfig) BackendConfig() BackendConfig {
+	cfg := DefaultBackendConfig()
+	cfg.EnableFQNScanning = c.EnableFQNScanning
+	cfg.AdaptiveMaxBytes = c.AdaptiveMaxBytes
+	cfg.AdaptiveMaxLines = c.AdaptiveMaxLines
+	cfg.FQNScanMaxLines = c.FQNScanMaxLines
+
+	treeCacheMu.RLock()
+	cfg.TreeCache = treeCache
+	treeCacheMu.RUnlock()
+	return cfg
 }
 
 // Clone implements jvm.Config.
@@ -31,10 +72,14 @@ func (c *KotlinConfig) Clone() jvm.Config {
 
 // NewKotlinConfig creates a new KotlinConfig with default values.
 func NewKotlinConfig() *KotlinConfig {
+	defaults := DefaultBackendConfig()
 	return &KotlinConfig{
 		BaseConfig:        jvm.NewBaseConfig(jvm.Kotlin),
 		ParserBackend:     BackendHeuristic,
 		EnableFQNScanning: true,
+		AdaptiveMaxBytes:  defaults.AdaptiveMaxBytes,
+		AdaptiveMaxLines:  defaults.AdaptiveMaxLines,
+		FQNScanMaxLines:   defaults.FQNScanMaxLines,
 	}
 }
 
@@ -65,6 +110,9 @@ func (*kotlinLang) KnownDirectives() []string {
 	directives = append(directives,
 		"kotlin_parser_backend",
 		"kotlin_fqn_scanning",
+		"kotlin_fqn_scan_max_lines",
+		"kotlin_adaptive_max_bytes",
+		"kotlin_adaptive_max_lines",
 	)
 	return directives
 }
@@ -96,6 +144,8 @@ func (*kotlinLang) Configure(c *config.Config, rel string, f *rule.File) {
 			kc.ParserBackend = BackendTreeSitter
 		case "hybrid":
 			kc.ParserBackend = BackendHybrid
+		case "adaptive":
+			kc.ParserBackend = BackendAdaptive
 		default:
 			log.Warn("unknown kotlin_parser_backend, using heuristic",
 				"value", value, "language", "kotlin")
@@ -105,6 +155,34 @@ func (*kotlinLang) Configure(c *config.Config, rel string, f *rule.File) {
 	handlers["kotlin_fqn_scanning"] = func(cfg jvm.Config, value string) {
 		cfg.(*KotlinConfig).EnableFQNScanning = strings.ToLower(value) == "true"
 	}
+	handlers["kotlin_fqn_scan_max_lines"] = func(cfg jvm.Config, value string) {
+		if n, ok := parseThreshold("kotlin_fqn_scan_max_lines", value); ok {
+			cfg.(*KotlinConfig).FQNScanMaxLines = n
+		}
+	}
+	handlers["kotlin_adaptive_max_bytes"] = func(cfg jvm.Config, value string) {
+		if n, ok := parseThreshold("kotlin_adaptive_max_bytes", value); ok {
+			cfg.(*KotlinConfig).AdaptiveMaxBytes = n
+		}
+	}
+	handlers["kotlin_adaptive_max_lines"] = func(cfg jvm.Config, value string) {
+		if n, ok := parseThreshold("kotlin_adaptive_max_lines", value); ok {
+			cfg.(*KotlinConfig).AdaptiveMaxLines = n
+		}
+	}
 
 	jvm.ProcessDirectives(f, newKc, handlers)
 }
+
+// parseThreshold parses the value of a size threshold directive, a
+// non-negative integer where 0 disables the threshold. An invalid value is
+// logged and leaves the inherited threshold in place.
+func parseThreshold(directive, value string) (int, bool) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < 0 {
+		log.Warn("invalid "+directive+", keeping the inherited value",
+			"value", value, "language", "kotlin")
+		return 0, false
+	}
+	return n, true
+}
@@ -26,6 +26,9 @@ func TestNewKotlinConfig(t *testing.T) {
 	if kc.LoadPath != "" {
 		t.Errorf("Expected LoadPath to be empty, got '%s'", kc.LoadPath)
 	}
+	if kc.AdaptiveMaxBytes != 32*1024 || kc.AdaptiveMaxLines != 1000 {
+		t.Errorf("Expected adaptive thresholds 32768 bytes and 1000 lines, got %d and %d", kc.AdaptiveMaxBytes, kc.AdaptiveMaxLines)
+	}
 }
 
 func TestGetKotlinConfig_WithConfig(t *testing.T) {
@@ -340,6 +343,7 @@ func TestConfigure_ParserBackend(t *testing.T) {
 		{"heuristic", "heuristic", BackendHeuristic},
 		{"treesitter", "treesitter", BackendTreeSitter},
 		{"hybrid", "hybrid", BackendHybrid},
+		{"adaptive", "adaptive", BackendAdaptive},
 		{"TREESITTER uppercase", "TREESITTER", BackendTreeSitter},
 		{"Hybrid mixed case", "Hybrid", BackendHybrid},
 		{"invalid defaults to heuristic", "invalid", BackendHeuristic},
@@ -405,6 +409,67 @@ func TestConfigure_FQNScanning(t *testing.T) {
 	}
 }
 
+func TestConfigure_FQNScanMaxLines(t *testing.T) {
+	c := &config.Config{
+		Exts: make(map[string]interface{}),
+	}
+	c.Exts[kotlinName] = NewKotlinConfig()
+	if got := GetKotlinConfig(c).FQNScanMaxLines; got != 0 {
+		t.Errorf("Expected no FQN scan limit by default, got %d", got)
+	}
+
+	lang := &kotlinLang{}
+	lang.Configure(c, "", &rule.File{
+		Directives: []rule.Directive{{Key: "kotlin_fqn_scan_max_lines", Value: "500"}},
+	})
+	parent := GetKotlinConfig(c)
+	if cfg := parent.BackendConfig(); cfg.FQNScanMaxLines != 500 {
+		t.Errorf("Expected BackendConfig FQN scan limit 500, got %d", cfg.FQNScanMaxLines)
+	}
+
+	// An invalid value keeps the inherited limit
+	lang.Configure(c, "sub", &rule.File{
+		Directives: []rule.Directive{{Key: "kotlin_fqn_scan_max_lines", Value: "-5"}},
+	})
+	if got := GetKotlinConfig(c).FQNScanMaxLines; got != 500 {
+		t.Errorf("Expected inherited FQN scan limit 500, got %d", got)
+	}
+}
+
+func TestConfigure_AdaptiveThresholds(t *testing.T) {
+	c := &config.Config{
+		Exts: make(map[string]interface{}),
+	}
+	c.Exts[kotlinName] = NewKotlinConfig()
+
+	lang := &kotlinLang{}
+	lang.Configure(c, "", &rule.File{
+		Directives: []rule.Directive{
+			{Key: "kotlin_adaptive_max_bytes", Value: "65536"},
+			{Key: "kotlin_adaptive_max_lines", Value: "0"},
+		},
+	})
+	parent := GetKotlinConfig(c)
+	if parent.AdaptiveMaxBytes != 65536 || parent.AdaptiveMaxLines != 0 {
+		t.Errorf("Expected thresholds 65536 bytes and 0 lines, got %d and %d", parent.AdaptiveMaxBytes, parent.AdaptiveMaxLines)
+	}
+	if cfg := parent.BackendConfig(); cfg.AdaptiveMaxBytes != 65536 || cfg.AdaptiveMaxLines != 0 {
+		t.Errorf("Expected BackendConfig thresholds 65536 bytes and 0 lines, got %d and %d", cfg.AdaptiveMaxBytes, cfg.AdaptiveMaxLines)
+	}
+
+	// Invalid values keep the inherited thresholds
+	lang.Configure(c, "sub", &rule.File{
+		Directives: []rule.Directive{
+			{Key: "kotlin_adaptive_max_bytes", Value: "-1"},
+			{Key: "kotlin_adaptive_max_lines", Value: "many"},
+		},
+	})
+	child := GetKotlinConfig(c)
+	if child.AdaptiveMaxBytes != 65536 || child.AdaptiveMaxLines != 0 {
+		t.Errorf("Expected inherited thresholds 65536 bytes and 0 lines, got %d and %d", child.AdaptiveMaxBytes, child.AdaptiveMaxLines)
+	}
+}
+
 func TestKnownDirectives(t *testing.T) {
 	lang := &kotlinLang{}
 	directives := lang.KnownDirectives()
@@ -417,6 +482,9 @@ func TestKnownDirectives(t *testing.T) {
 		"kotlin_load",
 		"kotlin_parser_backend",
 		"kotlin_fqn_scanning",
+		"kotlin_fqn_scan_max_lines",
+		"kotlin_adaptive_max_bytes",
+		"kotlin_adaptive_max_lines",
 	}
 
 	if len(directives) != len(expected) {
@@ -16,6 +16,12 @@ var (
 	charLiteralRegex   = regexp.MustCompile(`'(?:[^'\\]|\\.)*'`)
 )
 
+// Package-level compiled regexes for supertypeNames.
+var (
+	typeHeaderRegex    = regexp.MustCompile(`\b(?:class|interface|object)\b`)
+	qualifiedNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*`)
+)
+
 // Embedded data files for FQN filtering.
 // These lists help the heuristic scanner distinguish between:
 //   - Stdlib types (excluded - always available)
@@ -108,6 +114,9 @@ func getKotlinCommonPrefixes() []string {
 //   - Known package prefixes (com., org., io., etc.)
 //   - Naming conventions (lowercase.packages.UppercaseClass)
 //   - Context clues (: Type, as Type, is Type, FQN())
+//   - Supertype lists of class headers and object expressions
+//   - Delegation targets (Api by com.example.Impl)
+//   - Member access on holders (com.example.Constants.MAX)
 //
 // # Use Cases
 //
@@ -142,6 +151,14 @@ type FQNScanner struct {
 	// Matches: "com.example.Factory()", "com.example.Builder<T>("
 	fqnCallPattern *regexp.Regexp
 
+	// Pattern to detect delegation targets (HEURISTIC)
+	// Matches: "Api by com.example.DefaultApi", "val x by com.example.Prefs.int()"
+	delegatePattern *regexp.Regexp
+
+	// Pattern to detect member access on an FQN (HEURISTIC)
+	// Matches: "com.example.Constants.MAX", "acme.config.Limits.maxSize"
+	memberAccessPattern *regexp.Regexp
+
 	// Known standard library packages to exclude (DETERMINISTIC lookup)
 	// FQNs starting with these prefixes are filtered out
 	stdlibPrefixes map[string]bool
@@ -149,6 +166,10 @@ type FQNScanner struct {
 	// Known Kotlin built-in types to exclude (DETERMINISTIC lookup)
 	// Class names matching these are filtered out
 	builtinTypes map[string]bool
+
+	// Maximum number of code lines to scan, counted from codeStartLine
+	// 0 means no limit (see SetMaxLines)
+	maxLines int
 }
 
 // NewFQNScanner creates a new FQN scanner with default patterns.
@@ -157,6 +178,8 @@ type FQNScanner struct {
 //   - Prefix patterns for common package namespaces (com, org, io, etc.)
 //   - Type usage patterns for detecting FQNs in type contexts
 //   - Function call patterns for detecting FQN constructor/method calls
+//   - A delegation pattern for FQNs after "by"
+//   - A member access pattern for constants and members of FQN holders
 //   - Exclusion lists for stdlib and built-in types
 //
 // All patterns are HEURISTIC and may produce false positives/negatives.
@@ -217,9 +240,38 @@ func NewFQNScanner() *FQNScanner {
 		`\b([a-z][a-z0-9_]*(?:\.[a-z][a-z0-9_]*)+\.[A-Z][a-zA-Z0-9_]*)\s*[(<]`,
 	)
 
+	// Delegation pattern (HEURISTIC)
+	//
+	// Matches FQNs after "by" in class and property delegation, which need
+	// not be calls when the delegate is an object:
+	//   - class Foo : Api by com.example.DefaultApi
+	//   - val name by com.example.Prefs.string()
+	s.delegatePattern = regexp.MustCompile(
+		`\bby\s+([a-z][a-z0-9_]*(?:\.[a-z][a-z0-9_]*)+\.[A-Z][a-zA-Z0-9_]*)`,
+	)
+
+	// Member access pattern (HEURISTIC)
+	//
+	// Matches the object or class holding a member accessed by FQN, such as
+	// a const val, with packages of any prefix. The member may be in any
+	// case, so constants named in upper case still yield their holder:
+	//   - if (size > acme.config.Limits.MAX_SIZE)
+	//   - com.example.Constants.TIMEOUT
+	s.memberAccessPattern = regexp.MustCompile(
+		`\b([a-z][a-z0-9_]*(?:\.[a-z][a-z0-9_]*)+\.[A-Z][a-zA-Z0-9_]*)\.[A-Za-z_]`,
+	)
+
 	return s
 }
 
+// SetMaxLines bounds Scan to the first n lines of the code body, so that
+// huge files, typically generated ones, do not dominate parse time. FQNs
+// used only past the limit are missed, and the result reports Truncated.
+// A non-positive n removes the limit, which is the default.
+func (s *FQNScanner) SetMaxLines(n int) {
+	s.maxLines = max(n, 0)
+}
+
 // ScanResult contains FQNs found in the code body.
 //
 // All FQNs in this result are HEURISTIC detections. They should be treated
@@ -233,6 +285,10 @@ type ScanResult struct {
 	// Useful for debugging or displaying FQN locations to users.
 	// Line numbers are 1-indexed.
 	FQNToLocations map[string][]int
+
+	// Truncated reports that the scan stopped at the scanner's line limit
+	// before the end of the file.
+	Truncated bool
 }
 
 // Scan scans the code body for FQN usages using HEURISTIC pattern matching.
@@ -242,7 +298,8 @@ type ScanResult struct {
 //   - codeStartLine: 0-indexed line number where code begins (after imports)
 //
 // The scanner only examines lines from codeStartLine onwards, avoiding
-// false matches in the import section.
+// false matches in the import section, and with SetMaxLines at most that
+// many of them.
 //
 // # Algorithm
 //
@@ -283,7 +340,13 @@ func (s *FQNScanner) Scan(content string, codeStartLine int) *ScanResult {
 		}
 	}
 
-	for lineNum := codeStartLine; lineNum < len(lines); lineNum++ {
+	endLine := len(lines)
+	if s.maxLines > 0 && codeStartLine+s.maxLines < endLine {
+		endLine = codeStartLine + s.maxLines
+		result.Truncated = true
+	}
+
+	for lineNum := codeStartLine; lineNum < endLine; lineNum++ {
 		line := lines[lineNum]
 
 		// Strip triple-quoted string content while tracking multi-line state.
@@ -318,6 +381,26 @@ func (s *FQNScanner) Scan(content string, codeStartLine int) *ScanResult {
 				addFQN(match[1], lineNum)
 			}
 		}
+
+		// Scan for delegation targets (by com.example.Impl)
+		for _, match := range s.delegatePattern.FindAllStringSubmatch(line, -1) {
+			if len(match) > 1 {
+				addFQN(match[1], lineNum)
+			}
+		}
+
+		// Scan for member access on holders (com.example.Constants.MAX)
+		for _, match := range s.memberAccessPattern.FindAllStringSubmatch(line, -1) {
+			if len(match) > 1 {
+				addFQN(match[1], lineNum)
+			}
+		}
+
+		// Scan supertype lists, which the patterns above only cover for the
+		// first entry (": a.b.Base(), a.b.Listener")
+		for _, name := range supertypeNames(line) {
+			addFQN(outerClassFQN(name), lineNum)
+		}
 	}
 
 	// Sort FQNs for deterministic output
@@ -361,20 +444,108 @@ func (s *FQNScanner) shouldInclude(fqn string) bool {
 		return false
 	}
 
-	// Get the prefix (first segment)
-	prefix := parts[0]
+	// Exclude kotlin/java stdlib (they're usually already on classpath)
+	return !isStdlibName(s.stdlibPrefixes, fqn)
+}
 
-	// Include kotlinx explicitly (it's a separate dependency from kotlin stdlib)
-	if prefix == "kotlinx" {
-		return true
+// isStdlibName reports whether a dotted name (FQN, import or package) falls
+// under one of the stdlib prefixes, matched on its first segment. kotlinx is
+// a separate dependency from the kotlin stdlib and never matches.
+func isStdlibName(prefixes map[string]bool, name string) bool {
+	prefix, _, _ := strings.Cut(name, ".")
+	return prefix != "kotlinx" && prefixes[prefix]
+}
+
+// supertypeNames returns the type names in the supertype lists of the class,
+// interface and object headers on a single line, covering declarations
+// ("class Foo(x: Int) : a.b.Base(x), a.b.Listener {") and object expressions
+// ("object : a.b.Listener {"). Lists that continue on the next line are only
+// read up to the end of this one.
+func supertypeNames(line string) []string {
+	var names []string
+	for _, loc := range typeHeaderRegex.FindAllStringIndex(line, -1) {
+		if strings.HasSuffix(line[:loc[0]], "::") {
+			continue // Foo::class reference, not a header
+		}
+		list, ok := supertypeList(line[loc[1]:])
+		if !ok {
+			continue
+		}
+		for _, entry := range splitTopLevel(list, ',') {
+			if name := qualifiedNameRegex.FindString(strings.TrimSpace(entry)); name != "" {
+				names = append(names, name)
+			}
+		}
 	}
+	return names
+}
 
-	// Exclude kotlin/java stdlib (they're usually already on classpath)
-	if s.stdlibPrefixes[prefix] {
-		return false
+// supertypeList returns the text after the first ':' outside brackets in a
+// type header, up to the body or where clause. It reports false when the
+// header ends before any ':'.
+func supertypeList(header string) (string, bool) {
+	depth := 0
+	start := -1
+	for i, r := range header {
+		switch r {
+		case '(', '<':
+			depth++
+		case ')', '>':
+			depth = max(depth-1, 0)
+		case ':':
+			if depth == 0 && start < 0 {
+				start = i + 1
+			}
+		case '{', '=':
+			if depth == 0 {
+				if start < 0 {
+					return "", false
+				}
+				return strings.Split(header[start:i], " where ")[0], true
+			}
+		}
 	}
+	if start < 0 {
+		return "", false
+	}
+	return strings.Split(header[start:], " where ")[0], true
+}
 
-	return true
+// splitTopLevel splits s on sep, ignoring separators nested in brackets.
+func splitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(', '<':
+			depth++
+		case ')', '>':
+			depth = max(depth-1, 0)
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// outerClassFQN trims a qualified name to its outermost class, the same
+// granularity the FQN patterns capture: "a.b.Outer.Inner" and
+// "a.b.Outer.member" become "a.b.Outer". Names that do not start with a
+// package ("Outer.Inner", "Base") yield "".
+func outerClassFQN(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part != "" && part[0] >= 'A' && part[0] <= 'Z' {
+			if i == 0 {
+				return ""
+			}
+			return strings.Join(parts[:i+1], ".")
+		}
+	}
+	return ""
 }
 
 // cleanFQN removes any trailing characters that aren't part of the FQN.
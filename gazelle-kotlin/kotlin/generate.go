@@ -26,7 +26,9 @@ package kotlin
 //   - Support common annotation processors (Dagger, Room, etc.)
 
 import (
+	"context"
 	"path/filepath"
+	"sync"
 
 	"github.com/albertocavalcante/bazelle/internal/log"
 	"github.com/albertocavalcante/bazelle/pkg/jvm"
@@ -34,6 +36,14 @@ import (
 	"github.com/bazelbuild/bazel-gazelle/rule"
 )
 
+// newBackend creates the parser backend of GenerateRules. Tests replace it
+// to inspect the backend once the rules are generated.
+var newBackend = NewParserBackend
+
+// unavailableBackends are the backends already warned about by
+// parserBackend, so the warning is not repeated for every directory.
+var unavailableBackends sync.Map
+
 // GenerateRules implements language.Language.
 func (k *kotlinLang) GenerateRules(args language.GenerateArgs) language.GenerateResult {
 	kc := GetKotlinConfig(args.Config)
@@ -50,24 +60,27 @@ func (k *kotlinLang) GenerateRules(args language.GenerateArgs) language.Generate
 		return language.GenerateResult{}
 	}
 
+	backend := parserBackend(kc)
+	defer func() { _ = backend.Close() }()
+
 	var rules []*rule.Rule
 	var imports []interface{}
 
 	// Generate library rule for main sources
 	if len(mainFiles) > 0 {
-		libRule := k.generateLibraryRule(args, kc, mainFiles)
+		libRule, libImports := k.generateLibraryRule(args, kc, backend, mainFiles)
 		if libRule != nil {
 			rules = append(rules, libRule)
-			imports = append(imports, nil)
+			imports = append(imports, libImports)
 		}
 	}
 
 	// Generate test rule for test sources
 	if len(testFiles) > 0 {
-		testRule := k.generateTestRule(args, kc, testFiles, len(mainFiles) > 0)
+		testRule, testImports := k.generateTestRule(args, kc, backend, testFiles, len(mainFiles) > 0)
 		if testRule != nil {
 			rules = append(rules, testRule)
-			imports = append(imports, nil)
+			imports = append(imports, testImports)
 		}
 	}
 
@@ -77,16 +90,30 @@ func (k *kotlinLang) GenerateRules(args language.GenerateArgs) language.Generate
 	}
 }
 
-// generateLibraryRule creates a kt_jvm_library (or custom macro) rule.
-func (k *kotlinLang) generateLibraryRule(args language.GenerateArgs, kc *KotlinConfig, files []string) *rule.Rule {
+// parserBackend creates the parser backend that kc selects, configured by
+// its directives. A backend whose tree-sitter runtime is unavailable falls
+// back to the heuristic backend, with a warning the first time.
+func parserBackend(kc *KotlinConfig) ParserBackend {
+	cfg := kc.BackendConfig()
+	backend, err := newBackend(kc.ParserBackend, cfg)
+	if err != nil {
+		if _, warned := unavailableBackends.LoadOrStore(kc.ParserBackend, true); !warned {
+			log.Warn("kotlin parser backend unavailable, using heuristic",
+				"backend", kc.ParserBackend, "error", err)
+		}
+		return NewHeuristicBackend(cfg)
+	}
+	return backend
+}
+
+// generateLibraryRule creates a kt_jvm_library (or custom macro) rule and
+// returns it with the dependencies (imports and FQNs) of its files.
+func (k *kotlinLang) generateLibraryRule(args language.GenerateArgs, kc *KotlinConfig, backend ParserBackend, files []string) (*rule.Rule, []string) {
 	// Derive target name from directory name using jvm package
 	name := jvm.DeriveTargetName(args.Dir, args.Config.RepoRoot)
 
 	r := rule.NewRule(kc.LibraryMacro, name)
-	r.SetAttr("srcs", rule.GlobValue{Patterns: []string{
-		"src/main/kotlin/**/*.kt",
-		"src/main/kotlin/**/*.kts",
-	}})
+	r.SetAttr("srcs", rule.GlobValue{Patterns: jvm.Kotlin.GlobPatterns("src/main/kotlin")})
 	r.SetAttr("visibility", []string{kc.Visibility})
 
 	// Parse files to get package info
@@ -95,7 +122,7 @@ func (k *kotlinLang) generateLibraryRule(args language.GenerateArgs, kc *KotlinC
 		fullPaths[i] = filepath.Join(args.Dir, f)
 	}
 
-	results, err := k.parser.ParseFiles(fullPaths)
+	results, err := ParseFilesWithBackend(context.Background(), backend, fullPaths)
 	if err != nil {
 		log.Warn("failed to parse kotlin files",
 			"target", name, "error", err)
@@ -104,20 +131,18 @@ func (k *kotlinLang) generateLibraryRule(args language.GenerateArgs, kc *KotlinC
 		r.SetPrivateAttr("packages", GetPackages(results))
 	}
 
-	return r
+	return r, GetAllDependencies(results)
 }
 
-// generateTestRule creates a kt_jvm_test (or custom macro) rule.
-func (k *kotlinLang) generateTestRule(args language.GenerateArgs, kc *KotlinConfig, files []string, hasMain bool) *rule.Rule {
+// generateTestRule creates a kt_jvm_test (or custom macro) rule and returns
+// it with the dependencies (imports and FQNs) of its files.
+func (k *kotlinLang) generateTestRule(args language.GenerateArgs, kc *KotlinConfig, backend ParserBackend, files []string, hasMain bool) (*rule.Rule, []string) {
 	// Derive target name from directory name using jvm package
 	baseName := jvm.DeriveTargetName(args.Dir, args.Config.RepoRoot)
 	name := jvm.DeriveTestTargetName(args.Dir, args.Config.RepoRoot)
 
 	r := rule.NewRule(kc.TestMacro, name)
-	r.SetAttr("srcs", rule.GlobValue{Patterns: []string{
-		"src/test/kotlin/**/*.kt",
-		"src/test/kotlin/**/*.kts",
-	}})
+	r.SetAttr("srcs", rule.GlobValue{Patterns: jvm.Kotlin.GlobPatterns("src/test/kotlin")})
 
 	// Parse files to get test packages
 	fullPaths := make([]string, len(files))
@@ -125,7 +150,7 @@ func (k *kotlinLang) generateTestRule(args language.GenerateArgs, kc *KotlinConf
 		fullPaths[i] = filepath.Join(args.Dir, f)
 	}
 
-	results, err := k.parser.ParseFiles(fullPaths)
+	results, err := ParseFilesWithBackend(context.Background(), backend, fullPaths)
 	if err != nil {
 		log.Warn("failed to parse kotlin test files",
 			"target", name, "error", err)
@@ -143,6 +168,6 @@ func (k *kotlinLang) generateTestRule(args language.GenerateArgs, kc *KotlinConf
 		r.SetAttr("deps", []string{":" + baseName})
 	}
 
-	return r
+	return r, GetAllDependencies(results)
 }
 
@@ -1,11 +1,15 @@
 package kotlin
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/albertocavalcante/bazelle/pkg/jvm"
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
 	"github.com/bazelbuild/bazel-gazelle/config"
 	"github.com/bazelbuild/bazel-gazelle/language"
 	"github.com/bazelbuild/bazel-gazelle/rule"
@@ -507,3 +511,148 @@ func TestGenerateRules_TestRuleAttributes(t *testing.T) {
 		t.Error("Expected test rule to have associates attribute")
 	}
 }
+
+func TestGenerateRules_AddedFileExtension(t *testing.T) {
+	// The extension stays registered for the rest of the tests, none of
+	// which depends on the exact srcs globs
+	jvm.AddFileExtensions(jvm.Kotlin, ".ktm")
+
+	tmpDir := t.TempDir()
+	mainDir := filepath.Join(tmpDir, "src", "main", "kotlin", "com", "example")
+	if err := os.MkdirAll(mainDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(mainDir, "Main.ktm"), []byte("package com.example\n\nclass Main\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	c := &config.Config{
+		Exts:     make(map[string]interface{}),
+		RepoRoot: tmpDir,
+	}
+	kc := NewKotlinConfig()
+	kc.Enabled = true
+	c.Exts[kotlinName] = kc
+
+	result := NewLanguage().GenerateRules(language.GenerateArgs{Config: c, Dir: tmpDir})
+	if len(result.Gen) != 1 {
+		t.Fatalf("Expected 1 rule (library), got %d", len(result.Gen))
+	}
+	glob, ok := rule.ParseGlobExpr(result.Gen[0].Attr("srcs"))
+	if !ok {
+		t.Fatal("Expected srcs to be a glob expression")
+	}
+	if !slices.Contains(glob.Patterns, "src/main/kotlin/**/*.ktm") {
+		t.Errorf("srcs patterns = %v, want the .ktm sources globbed", glob.Patterns)
+	}
+}
+
+func TestGenerateRules_AdaptiveBackend(t *testing.T) {
+	if len(treesitter.AvailableBackends()) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	tmpDir := t.TempDir()
+	mainDir := filepath.Join(tmpDir, "src", "main", "kotlin", "com", "example")
+	if err := os.MkdirAll(mainDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	var large strings.Builder
+	large.WriteString("package com.example\n\nimport com.example.util.Strings\n\n")
+	for i := range 20 {
+		fmt.Fprintf(&large, "val field%d = %d\n", i, i)
+	}
+	files := map[string]string{
+		"Small.kt": "package com.example\n\nclass Small\n",
+		"Large.kt": large.String(),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(mainDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+
+	c := &config.Config{
+		Exts:     make(map[string]interface{}),
+		RepoRoot: tmpDir,
+	}
+	c.Exts[kotlinName] = NewKotlinConfig()
+	lang := NewLanguage()
+	lang.(*kotlinLang).Configure(c, "", &rule.File{
+		Directives: []rule.Directive{
+			{Key: "kotlin_enabled", Value: "true"},
+			{Key: "kotlin_parser_backend", Value: "adaptive"},
+			{Key: "kotlin_adaptive_max_lines", Value: "10"},
+		},
+	})
+
+	var created []ParserBackend
+	newBackend = func(typ ParserBackendType, cfg BackendConfig) (ParserBackend, error) {
+		backend, err := NewParserBackend(typ, cfg)
+		created = append(created, backend)
+		return backend, err
+	}
+	t.Cleanup(func() { newBackend = NewParserBackend })
+
+	result := lang.GenerateRules(language.GenerateArgs{Config: c, Dir: tmpDir})
+	if len(result.Gen) != 1 {
+		t.Fatalf("Expected 1 rule (library), got %d", len(result.Gen))
+	}
+	if len(created) != 1 {
+		t.Fatalf("Expected 1 parser backend, got %d", len(created))
+	}
+	adaptive, ok := created[0].(*AdaptiveBackend)
+	if !ok {
+		t.Fatalf("Expected the adaptive backend, got %T", created[0])
+	}
+	// The file over the line threshold is parsed with tree-sitter
+	if got, want := adaptive.Stats(), (AdaptiveStats{Heuristic: 1, TreeSitter: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if deps := result.Imports[0].([]string); !slices.Contains(deps, "com.example.util.Strings") {
+		t.Errorf("Imports = %v, want the import of the large file", deps)
+	}
+}
+
+func TestGenerateRules_FQNScanMaxLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	mainDir := filepath.Join(tmpDir, "src", "main", "kotlin", "com", "example")
+	if err := os.MkdirAll(mainDir, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	var content strings.Builder
+	content.WriteString("package com.example\n\nclass Main {\n")
+	for i := range 20 {
+		fmt.Fprintf(&content, "    val field%d = %d\n", i, i)
+	}
+	content.WriteString("    val client = com.other.net.Client()\n}\n")
+	if err := os.WriteFile(filepath.Join(mainDir, "Main.kt"), []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	generate := func(directives ...rule.Directive) []string {
+		t.Helper()
+		c := &config.Config{
+			Exts:     make(map[string]interface{}),
+			RepoRoot: tmpDir,
+		}
+		c.Exts[kotlinName] = NewKotlinConfig()
+		lang := NewLanguage()
+		lang.(*kotlinLang).Configure(c, "", &rule.File{
+			Directives: append([]rule.Directive{{Key: "kotlin_enabled", Value: "true"}}, directives...),
+		})
+		result := lang.GenerateRules(language.GenerateArgs{Config: c, Dir: tmpDir})
+		if len(result.Imports) != 1 {
+			t.Fatalf("Expected 1 rule (library), got %d", len(result.Imports))
+		}
+		return result.Imports[0].([]string)
+	}
+
+	if deps := generate(); !slices.Contains(deps, "com.other.net.Client") {
+		t.Errorf("Imports = %v, want the FQN at the end of the file", deps)
+	}
+	// The FQN is past the first 10 lines of the file
+	if deps := generate(rule.Directive{Key: "kotlin_fqn_scan_max_lines", Value: "10"}); slices.Contains(deps, "com.other.net.Client") {
+		t.Errorf("Imports = %v, want the FQN past the scan limit left out", deps)
+	}
+}
@@ -53,8 +53,8 @@ func TestKinds_KtJvmLibrary(t *testing.T) {
 	if !info.MergeableAttrs["srcs"] {
 		t.Error("Expected srcs to be in MergeableAttrs")
 	}
-	if !info.MergeableAttrs["deps"] {
-		t.Error("Expected deps to be in MergeableAttrs")
+	if info.MergeableAttrs["deps"] {
+		t.Error("Expected deps not to be in MergeableAttrs")
 	}
 
 	// Check ResolveAttrs
@@ -86,8 +86,8 @@ func TestKinds_KtJvmTest(t *testing.T) {
 	if !info.MergeableAttrs["srcs"] {
 		t.Error("Expected srcs to be in MergeableAttrs")
 	}
-	if !info.MergeableAttrs["deps"] {
-		t.Error("Expected deps to be in MergeableAttrs")
+	if info.MergeableAttrs["deps"] {
+		t.Error("Expected deps not to be in MergeableAttrs")
 	}
 
 	// Check ResolveAttrs
@@ -114,8 +114,8 @@ func TestKinds_KtJvmBinary(t *testing.T) {
 	if !info.MergeableAttrs["srcs"] {
 		t.Error("Expected srcs to be in MergeableAttrs")
 	}
-	if !info.MergeableAttrs["deps"] {
-		t.Error("Expected deps to be in MergeableAttrs")
+	if info.MergeableAttrs["deps"] {
+		t.Error("Expected deps not to be in MergeableAttrs")
 	}
 
 	// Check ResolveAttrs
@@ -138,8 +138,8 @@ func TestKinds_CustomMacros(t *testing.T) {
 		if !info.MergeableAttrs["srcs"] {
 			t.Error("kt_library: Expected srcs to be in MergeableAttrs")
 		}
-		if !info.MergeableAttrs["deps"] {
-			t.Error("kt_library: Expected deps to be in MergeableAttrs")
+		if info.MergeableAttrs["deps"] {
+			t.Error("kt_library: Expected deps not to be in MergeableAttrs")
 		}
 		if !info.ResolveAttrs["deps"] {
 			t.Error("kt_library: Expected deps to be in ResolveAttrs")
@@ -156,8 +156,8 @@ func TestKinds_CustomMacros(t *testing.T) {
 		if !info.MergeableAttrs["srcs"] {
 			t.Error("kt_test: Expected srcs to be in MergeableAttrs")
 		}
-		if !info.MergeableAttrs["deps"] {
-			t.Error("kt_test: Expected deps to be in MergeableAttrs")
+		if info.MergeableAttrs["deps"] {
+			t.Error("kt_test: Expected deps not to be in MergeableAttrs")
 		}
 		if !info.ResolveAttrs["deps"] {
 			t.Error("kt_test: Expected deps to be in ResolveAttrs")
@@ -181,9 +181,10 @@ func TestKinds_AllHaveConsistentStructure(t *testing.T) {
 			t.Errorf("%s: Expected srcs to be in MergeableAttrs", kindName)
 		}
 
-		// All should have deps in MergeableAttrs
-		if !info.MergeableAttrs["deps"] {
-			t.Errorf("%s: Expected deps to be in MergeableAttrs", kindName)
+		// None should have deps in MergeableAttrs: they are merged only
+		// after resolution, into the existing list
+		if info.MergeableAttrs["deps"] {
+			t.Errorf("%s: Expected deps not to be in MergeableAttrs", kindName)
 		}
 
 		// All should have deps in ResolveAttrs
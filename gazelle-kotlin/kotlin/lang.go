@@ -8,15 +8,11 @@ import (
 const kotlinName = "kotlin"
 
 // kotlinLang implements the language.Language interface for Kotlin.
-type kotlinLang struct {
-	parser *KotlinParser
-}
+type kotlinLang struct{}
 
 // NewLanguage creates a new Kotlin language extension for Gazelle.
 func NewLanguage() language.Language {
-	return &kotlinLang{
-		parser: NewParser(),
-	}
+	return &kotlinLang{}
 }
 
 // Name returns the name of the language extension.
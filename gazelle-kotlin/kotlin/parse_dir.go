@@ -0,0 +1,63 @@
+package kotlin
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+)
+
+// DirResult is the outcome of ParseDir.
+type DirResult struct {
+	// Results holds the metadata of every parsed file.
+	Results []*ParseResult
+
+	// Unresolved lists the imports, star-import packages and FQNs that the
+	// resolver could not map to any target, sorted and deduplicated. Stdlib
+	// names are never reported since no workspace target provides them.
+	Unresolved []string
+}
+
+// ParseDir parses every Kotlin file under dir, recursively, and checks each
+// dependency against resolve, which reports whether some target provides it
+// (jvm.PackageIndex.Contains, a resolve map lookup, or a mix of both). A nil
+// resolve reports every non-stdlib dependency as unresolved.
+//
+// This is meant for tooling that wants to surface gaps in resolution, such as
+// third-party packages missing from the resolve map.
+func (p *KotlinParser) ParseDir(dir string, resolve func(dep string) bool) (*DirResult, error) {
+	files := jvm.FindLanguageFiles(dir, "", jvm.Kotlin)
+	slices.Sort(files)
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = filepath.Join(dir, f)
+	}
+
+	results, err := p.ParseFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	stdlib := getKotlinStdlibPrefixes()
+	unresolved := make(map[string]bool)
+	check := func(dep string) {
+		if isStdlibName(stdlib, dep) || (resolve != nil && resolve(dep)) {
+			return
+		}
+		unresolved[dep] = true
+	}
+	for _, r := range results {
+		for _, dep := range r.AllDependencies {
+			check(dep)
+		}
+		for _, imp := range r.StarImports {
+			check(imp)
+		}
+	}
+
+	return &DirResult{
+		Results:    results,
+		Unresolved: slices.Sorted(maps.Keys(unresolved)),
+	}, nil
+}
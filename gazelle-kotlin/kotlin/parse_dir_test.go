@@ -0,0 +1,82 @@
+package kotlin
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+func TestParser_ParseDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"src/main/kotlin/com/example/app/App.kt": `package com.example.app
+
+import com.example.util.Strings
+import com.example.missing.Widget
+import com.google.common.collect.ImmutableList
+import kotlin.collections.List
+import java.time.Instant
+import com.example.model.*
+
+class App {
+    val helper = com.example.util.Helper()
+    val gone = com.example.gone.Thing()
+}
+`,
+		"src/main/kotlin/com/example/app/Util.kt": `package com.example.app
+
+import com.example.missing.Widget
+`,
+		"README.md": "not kotlin",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index := jvm.NewPackageIndex()
+	index.Add("com.example.util", label.New("", "util", "util"))
+	index.Add("com.example.model", label.New("", "model", "model"))
+
+	got, err := NewParser().ParseDir(dir, index.Contains)
+	if err != nil {
+		t.Fatalf("ParseDir failed: %v", err)
+	}
+
+	if len(got.Results) != 2 {
+		t.Errorf("ParseDir returned %d results, want 2", len(got.Results))
+	}
+	want := []string{
+		"com.example.gone.Thing",
+		"com.example.missing.Widget",
+		"com.google.common.collect.ImmutableList",
+	}
+	if !slices.Equal(got.Unresolved, want) {
+		t.Errorf("Unresolved = %v, want %v", got.Unresolved, want)
+	}
+}
+
+func TestParser_ParseDirNilResolver(t *testing.T) {
+	dir := t.TempDir()
+	content := "package com.example\n\nimport com.example.util.*\nimport kotlin.io.println\n"
+	if err := os.WriteFile(filepath.Join(dir, "Main.kt"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewParser().ParseDir(dir, nil)
+	if err != nil {
+		t.Fatalf("ParseDir failed: %v", err)
+	}
+	if want := []string{"com.example.util"}; !slices.Equal(got.Unresolved, want) {
+		t.Errorf("Unresolved = %v, want %v", got.Unresolved, want)
+	}
+}
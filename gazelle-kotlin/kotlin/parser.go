@@ -2,11 +2,17 @@ package kotlin
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"maps"
-	"os"
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/pkg/util"
 )
 
 // KotlinParser provides HEURISTIC parsing of Kotlin source files using regex.
@@ -52,8 +58,15 @@ type KotlinParser struct {
 	// FQN scanner for detecting inline fully qualified names (also HEURISTIC)
 	fqnScanner *FQNScanner
 
+	// Resource reference scanner; nil unless WithResourceLoaders is used
+	resourceScanner *util.ResourceRefScanner
+
+	// DI annotation names to record; nil unless WithDiAnnotations is used
+	diAnnotations map[string]bool
+
 	// Configuration
 	enableFQNScanning bool
+	testFilePatterns  []string // nil = DefaultTestFilePatterns
 }
 
 // ParseResult contains the parsed metadata from a Kotlin file.
@@ -84,8 +97,85 @@ type ParseResult struct {
 	// FilePath is the path to the parsed file.
 	FilePath string
 
+	// ResourceReferences lists resource paths passed as string literals to
+	// the configured loader functions (see WithResourceLoaders), in order of
+	// first appearance. Empty unless resource scanning is enabled.
+	ResourceReferences []string
+
+	// DiAnnotations lists the configured dependency-injection annotations
+	// (see WithDiAnnotations) used in the file, such as "Inject" or
+	// "HiltAndroidApp", in order of first appearance. A resolver can map
+	// them to the framework dependency and its annotation processor. Empty
+	// unless DI annotation scanning is enabled.
+	DiAnnotations []string
+
+	// IsTest indicates the file matches a test naming convention
+	// (see IsTestFile), so it belongs in a test target.
+	IsTest bool
+
 	// CodeStartLine is the line number where code starts (after imports).
 	CodeStartLine int
+
+	// ImportsByOrigin groups Imports and StarImports by util.OriginStdlib,
+	// util.OriginFirstParty and util.OriginThirdParty. Nil until
+	// ClassifyImports is called.
+	ImportsByOrigin map[string][]string
+
+	// DeclarationKinds maps each type declared in the file to its kind: the
+	// declaration keyword ("class", "interface", "object") preceded by any
+	// class modifiers, e.g. "data class", "enum class", "sealed interface" or
+	// "companion object". Nested types are keyed by their dotted path within
+	// the file ("Outer.Inner"); unnamed companions use "Companion". Only the
+	// tree-sitter backend sets it.
+	DeclarationKinds map[string]string
+
+	// HasSyntaxErrors reports that the source did not parse cleanly. The
+	// result is partial: it holds whatever could be extracted from the
+	// well-formed parts of the file. Only the tree-sitter backend sets it.
+	HasSyntaxErrors bool
+
+	// ActualTypeAliases maps the name of each "actual typealias" in the file
+	// to the FQN of the platform type it maps the expect declaration to. For
+	// "actual typealias AtomicRef<T> = java.util.concurrent.atomic.AtomicReference<T>"
+	// this would be {"AtomicRef": "java.util.concurrent.atomic.AtomicReference"}.
+	// The targets are also added to FQNs. This is ALWAYS HEURISTIC.
+	ActualTypeAliases map[string]string
+
+	// IsPlatformSpecific reports that the file maps expect declarations to
+	// platform types with "actual typealias", so it only builds for the
+	// platform source set it belongs to.
+	IsPlatformSpecific bool
+
+	// ReifiedTypeRefs are the FQNs of the types named by qualified name in
+	// the signatures and bodies of top-level inline functions with a reified
+	// type parameter, sorted. The compiler inlines these functions into their
+	// call sites, so the types are needed wherever the functions are used. They
+	// are also added to FQNs, even with FQN scanning disabled. This is ALWAYS
+	// HEURISTIC.
+	ReifiedTypeRefs []string
+
+	// OptInMarkers are the marker annotations of the experimental APIs the
+	// file opts into, named by class literal in @OptIn arguments, including
+	// "@file:OptIn", sorted. Markers are qualified through the imports; one
+	// that matches no import (a same-package or default-imported marker) is
+	// kept as written. The classes of the qualified markers, except stdlib
+	// ones, are also added to FQNs, even with FQN scanning disabled. This is
+	// ALWAYS HEURISTIC.
+	OptInMarkers []string
+
+	// UsesCoroutines reports that the file declares suspend functions, at
+	// top level or as members. Such code usually builds on kotlinx.coroutines
+	// even when it imports nothing from it, so a resolver can add the
+	// coroutines dependency the imports do not ask for. Suspend lambdas and
+	// function types are not counted. This is ALWAYS HEURISTIC.
+	UsesCoroutines bool
+
+	// DeclaredTypes are the top-level classes, interfaces and objects the
+	// file declares, by simple name, sorted. Nested types are left out: an
+	// import of one ("com.example.Outer.Inner") resolves through the file
+	// declaring its top-level type. The heuristic backend only finds
+	// declarations that start at the beginning of a line.
+	DeclaredTypes []string
 }
 
 // ParserOption configures the parser.
@@ -98,6 +188,45 @@ func WithFQNScanning(enabled bool) ParserOption {
 	}
 }
 
+// WithFQNScanMaxLines limits FQN scanning to the first n lines of the code
+// body of each file (see FQNScanner.SetMaxLines); a file cut short is logged.
+// By default the whole file is scanned.
+func WithFQNScanMaxLines(n int) ParserOption {
+	return func(p *KotlinParser) {
+		p.fqnScanner.SetMaxLines(n)
+	}
+}
+
+// WithTestFilePatterns sets the patterns used to populate ParseResult.IsTest.
+// See IsTestFile for the pattern syntax.
+func WithTestFilePatterns(patterns []string) ParserOption {
+	return func(p *KotlinParser) {
+		p.testFilePatterns = patterns
+	}
+}
+
+// WithResourceLoaders enables HEURISTIC extraction of resource references:
+// string literals passed to any of the named functions (e.g. "getResource",
+// "getResourceAsStream") are recorded in ParseResult.ResourceReferences.
+// Disabled by default.
+func WithResourceLoaders(loaders []string) ParserOption {
+	return func(p *KotlinParser) {
+		p.resourceScanner = util.NewResourceRefScanner(loaders)
+	}
+}
+
+// WithDiAnnotations enables HEURISTIC detection of dependency-injection
+// annotations: uses of any of the named annotations (e.g. "Inject",
+// "Module", "HiltAndroidApp") are recorded in ParseResult.DiAnnotations.
+// Names are matched against the annotation's simple name, so "@Inject",
+// "@field:Inject" and "@javax.inject.Inject" all count as "Inject".
+// Disabled by default.
+func WithDiAnnotations(names []string) ParserOption {
+	return func(p *KotlinParser) {
+		p.diAnnotations = newDiAnnotationSet(names)
+	}
+}
+
 // NewParser creates a new KotlinParser with the given options.
 //
 // The parser is configured with regex patterns optimized for common Kotlin
@@ -116,12 +245,13 @@ func NewParser(opts ...ParserOption) *KotlinParser {
 	p := &KotlinParser{
 		// HEURISTIC: Match package declarations
 		// Handles: "package com.example" and "package `reserved.keywords`"
+		// The name stops at a trailing ";" or comment ("package a.b; // x")
 		// Limitation: Matches in strings/comments are false positives
 		packageRegex: regexp.MustCompile(`^\s*package\s+([a-zA-Z][a-zA-Z0-9_.]*|` + "`[^`]+`" + `)`),
 
 		// HEURISTIC: Match regular imports
 		// Handles: "import com.example.SomeClass"
-		// Limitation: Multi-line imports with line breaks are not captured
+		// Wrapped names are joined first (see ParseContent)
 		importRegex: regexp.MustCompile(`^\s*import\s+([a-zA-Z][a-zA-Z0-9_]*(?:\.[a-zA-Z][a-zA-Z0-9_]*)*)`),
 
 		// HEURISTIC: Match aliased imports
@@ -157,12 +287,12 @@ func NewParser(opts ...ParserOption) *KotlinParser {
 
 // ParseFile parses a Kotlin source file and returns metadata.
 func (p *KotlinParser) ParseFile(path string) (*ParseResult, error) {
-	content, err := os.ReadFile(path)
+	content, err := util.ReadSourceFile(path)
 	if err != nil {
-		return nil, err
+		return nil, util.NewParseError(path, string(BackendHeuristic), err)
 	}
 
-	return p.ParseContent(string(content), path)
+	return p.ParseContent(content, path)
 }
 
 // ParseContent parses Kotlin source code content and returns metadata.
@@ -182,8 +312,10 @@ func (p *KotlinParser) ParseFile(path string) (*ParseResult, error) {
 //
 // The CodeStartLine in the result indicates where code begins (after imports).
 func (p *KotlinParser) ParseContent(content string, path string) (*ParseResult, error) {
+	content = normalizeNewlines(content)
 	result := &ParseResult{
 		FilePath:      path,
+		IsTest:        IsTestFile(path, p.testFilePatterns),
 		Imports:       make([]string, 0),
 		StarImports:   make([]string, 0),
 		ImportAliases: make(map[string]string),
@@ -199,72 +331,84 @@ func (p *KotlinParser) ParseContent(content string, path string) (*ParseResult,
 	lineNum := 0
 	importSectionEnded := false
 
+	// HEURISTIC: An import is held back until the next non-blank line so that
+	// a name wrapped across lines ("import com.example\n    .Foo") can be
+	// joined before matching, instead of capturing the first fragment.
+	pendingImport := ""
+
 	for scanner.Scan() {
 		lineNum++
 		line := scanner.Text()
 
 		line, inBlockComment = stripComments(line, inBlockComment)
 
-		trimmed := strings.TrimSpace(line)
-		if trimmed == "" {
-			continue
-		}
+		// HEURISTIC: Statements separated by semicolons ("import a.B; import
+		// c.D") are handled one at a time, as if each were on its own line.
+		// Only the last can continue on the next line.
+		stmts := splitStatements(line)
+		for i, stmt := range stmts {
+			trimmed := strings.TrimSpace(stmt)
+			if trimmed == "" {
+				continue
+			}
 
-		// Parse file-level annotations (before package declaration)
-		if result.Package == "" && strings.HasPrefix(trimmed, "@file") {
-			if matches := p.annotationRegex.FindStringSubmatch(line); len(matches) > 1 {
-				result.Annotations = append(result.Annotations, matches[1])
+			if pendingImport != "" {
+				if strings.HasPrefix(trimmed, ".") || strings.HasSuffix(pendingImport, ".") {
+					pendingImport += trimmed
+					continue
+				}
+				p.parseImport(pendingImport, result)
+				pendingImport = ""
 			}
-			continue
-		}
 
-		// Try to match package declaration
-		if result.Package == "" {
-			if matches := p.packageRegex.FindStringSubmatch(line); len(matches) > 1 {
-				result.Package = cleanPackageName(matches[1])
+			// Parse file-level annotations (before package declaration)
+			if result.Package == "" && strings.HasPrefix(trimmed, "@file") {
+				if matches := p.annotationRegex.FindStringSubmatch(stmt); len(matches) > 1 {
+					result.Annotations = append(result.Annotations, matches[1])
+				}
 				continue
 			}
-		}
 
-		// Check if we've reached the end of imports section
-		if p.declarationRegex.MatchString(line) {
-			if !importSectionEnded {
-				importSectionEnded = true
-				result.CodeStartLine = lineNum
+			// Try to match package declaration
+			if result.Package == "" {
+				if matches := p.packageRegex.FindStringSubmatch(stmt); len(matches) > 1 {
+					result.Package = cleanPackageName(matches[1])
+					continue
+				}
 			}
-			// Don't break - we might still want to scan for FQNs
-			continue
-		}
 
-		// Don't parse imports if we're past the import section
-		if importSectionEnded {
-			continue
-		}
-
-		// Try to match star imports first (more specific)
-		if matches := p.starImportRegex.FindStringSubmatch(line); len(matches) > 1 {
-			result.StarImports = append(result.StarImports, matches[1])
-			continue
-		}
+			// Check if we've reached the end of imports section
+			if p.declarationRegex.MatchString(stmt) {
+				if !importSectionEnded {
+					importSectionEnded = true
+					result.CodeStartLine = lineNum
+				}
+				// Don't break - we might still want to scan for FQNs
+				continue
+			}
 
-		// Try to match import with alias
-		if matches := p.importAliasRegex.FindStringSubmatch(line); len(matches) > 2 {
-			importPath := matches[1]
-			alias := matches[2]
-			result.Imports = append(result.Imports, importPath)
-			result.ImportAliases[alias] = importPath
-			continue
-		}
+			// Don't parse imports if we're past the import section
+			if importSectionEnded {
+				continue
+			}
 
-		// Try to match regular import
-		if matches := p.importRegex.FindStringSubmatch(line); len(matches) > 1 {
-			result.Imports = append(result.Imports, matches[1])
-			continue
+			if p.importRegex.MatchString(stmt) {
+				if i < len(stmts)-1 {
+					// Ended by a semicolon, so not wrapped
+					p.parseImport(trimmed, result)
+				} else {
+					pendingImport = trimmed
+				}
+			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, err
+		// The scanner stops on the line it could not read
+		return nil, &util.ParseError{Path: path, Backend: string(BackendHeuristic), Line: lineNum + 1, Err: err}
+	}
+	if pendingImport != "" {
+		p.parseImport(pendingImport, result)
 	}
 
 	// Set code start line if not already set
@@ -272,32 +416,516 @@ func (p *KotlinParser) ParseContent(content string, path string) (*ParseResult,
 		result.CodeStartLine = lineNum
 	}
 
+	result.ResourceReferences = scanResourceReferences(p.resourceScanner, content)
+	result.DiAnnotations = scanDiAnnotations(p.diAnnotations, content)
+
 	// Scan for FQNs in the code body if enabled (HEURISTIC)
 	if p.enableFQNScanning {
 		startLine := max(result.CodeStartLine-1, 0)
 		scanResult := p.fqnScanner.Scan(content, startLine)
 		result.FQNs = scanResult.FQNs
+		logTruncatedScan(path, p.fqnScanner, scanResult)
 	}
 
+	scanActualTypeAliases(content, result)
+	scanReifiedTypeRefs(content, result)
+	scanOptInMarkers(content, result)
+	result.UsesCoroutines = declaresSuspendFun(content)
+	result.DeclaredTypes = scanDeclaredTypes(content)
+
 	// Build combined dependencies list
 	result.AllDependencies = buildAllDependencies(result)
 
 	return result, nil
 }
 
-// ParseFiles parses multiple Kotlin files and returns their metadata.
+// logTruncatedScan notes that the FQN scan of path stopped at the line limit
+// of scanner, so FQNs further down the file were not looked for.
+func logTruncatedScan(path string, scanner *FQNScanner, scanResult *ScanResult) {
+	if scanResult.Truncated {
+		log.Info("kotlin FQN scan stopped at line limit",
+			"file", path, "max_lines", scanner.maxLines)
+	}
+}
+
+// normalizeNewlines converts CRLF and lone CR line endings to LF, so that
+// line numbers and extracted names do not depend on how the file was saved.
+func normalizeNewlines(content string) string {
+	if !strings.Contains(content, "\r") {
+		return content
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(content, "\r\n", "\n"), "\r", "\n")
+}
+
+// scanResourceReferences collects the unique resource references in content,
+// skipping commented-out code. Returns nil when scanner is nil.
+func scanResourceReferences(scanner *util.ResourceRefScanner, content string) []string {
+	if scanner == nil {
+		return nil
+	}
+
+	var refs []string
+	inBlockComment := false
+	for line := range strings.Lines(content) {
+		line, inBlockComment = stripComments(strings.TrimRight(line, "\r\n"), inBlockComment)
+		for _, ref := range scanner.Scan(line) {
+			if !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
+
+// diAnnotationRegex matches an annotation use, with an optional use-site
+// target ("@field:Inject"). Captures the dotted annotation name.
+var diAnnotationRegex = regexp.MustCompile(`@(?:\w+\s*:\s*)?([A-Za-z_][\w.]*)`)
+
+// newDiAnnotationSet returns names as a set, or nil if there are none.
+func newDiAnnotationSet(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// scanDiAnnotations collects the unique annotations in content whose simple
+// name is in names, skipping commented-out code. Returns nil when names is
+// empty.
+func scanDiAnnotations(names map[string]bool, content string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+
+	var found []string
+	inBlockComment := false
+	for line := range strings.Lines(content) {
+		line, inBlockComment = stripComments(strings.TrimRight(line, "\r\n"), inBlockComment)
+		if !strings.Contains(line, "@") {
+			continue
+		}
+		for _, m := range diAnnotationRegex.FindAllStringSubmatch(line, -1) {
+			name := m[1][strings.LastIndex(m[1], ".")+1:]
+			if names[name] && !slices.Contains(found, name) {
+				found = append(found, name)
+			}
+		}
+	}
+	return found
+}
+
+// actualTypeAliasRegex matches a typealias declaration on one line.
+// Captures: [full match, modifiers, alias name, target type name]
+// The target's type arguments are left out of the match.
+var actualTypeAliasRegex = regexp.MustCompile(`^\s*((?:\w+\s+)*)typealias\s+(\w+)\s*(?:<[^=]*>)?\s*=\s*([A-Za-z_][\w.]*)`)
+
+// scanActualTypeAliases records the "actual typealias" declarations in
+// content in result.ActualTypeAliases, adds their targets to result.FQNs and
+// sets result.IsPlatformSpecific. It must run after the imports are parsed:
+// a target given by simple name ("= AtomicReference<T>") is expanded through
+// the imports, and one that matches no import (a same-package or default
+// import type) is left out.
+func scanActualTypeAliases(content string, result *ParseResult) {
+	var targets []string
+	inBlockComment := false
+	for line := range strings.Lines(content) {
+		line, inBlockComment = stripComments(strings.TrimRight(line, "\r\n"), inBlockComment)
+		if !strings.Contains(line, "typealias") {
+			continue
+		}
+		m := actualTypeAliasRegex.FindStringSubmatch(line)
+		if m == nil || !slices.Contains(strings.Fields(m[1]), "actual") {
+			continue
+		}
+		result.IsPlatformSpecific = true
+		target := qualifyTypeName(m[3], result)
+		if target == "" {
+			continue
+		}
+		if result.ActualTypeAliases == nil {
+			result.ActualTypeAliases = make(map[string]string)
+		}
+		result.ActualTypeAliases[m[2]] = target
+		targets = append(targets, target)
+	}
+	if len(targets) > 0 {
+		result.FQNs = sortedUnion(result.FQNs, targets)
+	}
+}
+
+// reifiedInlineFunRegex matches the head of an inline function with a
+// reified type parameter, such as "inline fun <reified T : Any> decode(".
+var reifiedInlineFunRegex = regexp.MustCompile(`^(?:[@\w]+\s+)*inline\s+(?:\w+\s+)*fun\s*<[^>]*\breified\b`)
+
+// reifiedTypeRefRegexes match a qualified type name in a type position: a
+// type argument, a type annotation or bound, an is/as target, a class literal
+// or the receiver of a call. Captures the qualified name.
+var reifiedTypeRefRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`(?:[<,:]|\bis|\bas\??)\s*([a-z]\w*(?:\.[a-z]\w*)*\.[A-Z][\w.]*)`),
+	regexp.MustCompile(`\b([a-z]\w*(?:\.[a-z]\w*)*\.[A-Z][\w.]*?)\s*::\s*class\b`),
+	regexp.MustCompile(`\b([a-z]\w*(?:\.[a-z]\w*)*\.[A-Z]\w*)\s*[(<.]`),
+}
+
+// scanReifiedTypeRefs records in result.ReifiedTypeRefs, and adds to
+// result.FQNs, the qualified type names used by the top-level inline
+// functions in content that have a reified type parameter. A function runs
+// from its head to the brace closing its body or, for an expression body, up
+// to the next line that is not indented. Stdlib and built-in types are left
+// out, as they are by FQN scanning.
+func scanReifiedTypeRefs(content string, result *ParseResult) {
+	stdlib := getKotlinStdlibPrefixes()
+	builtins := getKotlinBuiltinTypes()
+
+	var refs []string
+	depth := 0
+	inFun, opened := false, false
+	inTripleQuote, inBlockComment := false, false
+	for line := range strings.Lines(content) {
+		line, inTripleQuote = stripTripleQuoted(strings.TrimRight(line, "\r\n"), inTripleQuote)
+		line, inBlockComment = stripComments(line, inBlockComment)
+		line = removeStringLiterals(line)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if depth == 0 {
+			switch {
+			case reifiedInlineFunRegex.MatchString(trimmed):
+				inFun, opened = true, false
+			case inFun && !opened && !continuesDeclaration(line):
+				inFun = false
+			}
+		}
+		if inFun {
+			for _, re := range reifiedTypeRefRegexes {
+				for _, m := range re.FindAllStringSubmatch(line, -1) {
+					fqn := outerClassFQN(m[1])
+					if fqn == "" || builtins[fqn[strings.LastIndex(fqn, ".")+1:]] || isStdlibName(stdlib, fqn) {
+						continue
+					}
+					if !slices.Contains(refs, fqn) {
+						refs = append(refs, fqn)
+					}
+				}
+			}
+			if strings.Contains(line, "{") {
+				opened = true
+			}
+		}
+
+		depth = max(depth+strings.Count(line, "{")-strings.Count(line, "}"), 0)
+		if depth == 0 && opened {
+			inFun, opened = false, false
+		}
+	}
+	if len(refs) > 0 {
+		slices.Sort(refs)
+		result.ReifiedTypeRefs = refs
+		result.FQNs = sortedUnion(result.FQNs, refs)
+	}
+}
+
+// optInRegex matches the head of an @OptIn annotation, with an optional
+// use-site target ("@file:OptIn(") or package ("@kotlin.OptIn(").
+var optInRegex = regexp.MustCompile(`@(?:\w+\s*:\s*)?(?:kotlin\.)?OptIn\s*\(`)
+
+// classLiteralRegex matches a class literal. Captures the type name.
+var classLiteralRegex = regexp.MustCompile(`([A-Za-z_][\w.]*)\s*::\s*class\b`)
+
+// scanOptInMarkers records in result.OptInMarkers the marker classes named
+// in the @OptIn annotations of content, and adds the qualified ones that are
+// not stdlib types to result.FQNs. Arguments may span lines. It must run
+// after the imports are parsed, which qualify markers given by simple name.
+func scanOptInMarkers(content string, result *ParseResult) {
+	if !strings.Contains(content, "OptIn") {
+		return
+	}
+
+	var code strings.Builder
+	inTripleQuote, inBlockComment := false, false
+	for line := range strings.Lines(content) {
+		line, inTripleQuote = stripTripleQuoted(strings.TrimRight(line, "\r\n"), inTripleQuote)
+		line, inBlockComment = stripComments(line, inBlockComment)
+		code.WriteString(removeStringLiterals(line))
+		code.WriteByte('\n')
+	}
+
+	stdlib := getKotlinStdlibPrefixes()
+	var markers, deps []string
+	text := code.String()
+	for _, loc := range optInRegex.FindAllStringIndex(text, -1) {
+		for _, m := range classLiteralRegex.FindAllStringSubmatch(parenthesized(text[loc[1]:]), -1) {
+			marker := m[1]
+			if fqn := qualifyTypeName(marker, result); fqn != "" {
+				marker = fqn
+				if dep := outerClassFQN(fqn); dep != "" && !isStdlibName(stdlib, dep) {
+					deps = append(deps, dep)
+				}
+			}
+			if !slices.Contains(markers, marker) {
+				markers = append(markers, marker)
+			}
+		}
+	}
+	if len(markers) > 0 {
+		slices.Sort(markers)
+		result.OptInMarkers = markers
+	}
+	if len(deps) > 0 {
+		result.FQNs = sortedUnion(result.FQNs, deps)
+	}
+}
+
+// suspendFunRegex matches a function declared with the suspend modifier,
+// among any others ("override suspend fun", "suspend inline fun").
+var suspendFunRegex = regexp.MustCompile(`\bsuspend\s+(?:(?:public|private|protected|internal|open|final|abstract|override|inline|operator|infix|tailrec|external|actual|expect)\s+)*fun\b`)
+
+// declaresSuspendFun reports whether content declares a suspend function
+// outside comments and string literals.
+func declaresSuspendFun(content string) bool {
+	if !strings.Contains(content, "suspend") {
+		return false
+	}
+	inTripleQuote, inBlockComment := false, false
+	for line := range strings.Lines(content) {
+		line, inTripleQuote = stripTripleQuoted(strings.TrimRight(line, "\r\n"), inTripleQuote)
+		line, inBlockComment = stripComments(line, inBlockComment)
+		if suspendFunRegex.MatchString(removeStringLiterals(line)) {
+			return true
+		}
+	}
+	return false
+}
+
+// topLevelTypeRegex matches a class, interface or object declaration that
+// starts at the beginning of a line, after any modifiers and annotations
+// ("@Serializable data class User", "fun interface Handler"). A companion
+// object is never top-level, so "companion" is not among the modifiers.
+var topLevelTypeRegex = regexp.MustCompile(`^(?:(?:@[\w.:]+(?:\([^)]*\))?|public|internal|private|data|enum|sealed|abstract|open|final|annotation|value|inline|fun|expect|actual)\s+)*(?:class|interface|object)\s+(` + "`[^`]+`" + `|\w+)`)
+
+// scanDeclaredTypes returns the sorted names of the top-level types content
+// declares outside comments and string literals. A declaration counts as
+// top-level when it is not indented. See ParseResult.DeclaredTypes.
+func scanDeclaredTypes(content string) []string {
+	var types []string
+	inTripleQuote, inBlockComment := false, false
+	for line := range strings.Lines(content) {
+		line, inTripleQuote = stripTripleQuoted(strings.TrimRight(line, "\r\n"), inTripleQuote)
+		line, inBlockComment = stripComments(line, inBlockComment)
+		if m := topLevelTypeRegex.FindStringSubmatch(removeStringLiterals(line)); m != nil {
+			types = append(types, strings.Trim(m[1], "`"))
+		}
+	}
+	slices.Sort(types)
+	return slices.Compact(types)
+}
+
+// parenthesized returns the text of s up to the parenthesis closing one that
+// was opened just before it, or all of s if it is never closed.
+func parenthesized(s string) string {
+	depth := 1
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return s[:i]
+			}
+		}
+	}
+	return s
+}
+
+// continuesDeclaration reports whether line, read at the top level, carries
+// on the declaration of the line before it: it is indented, or starts with
+// the rest of a signature or body.
+func continuesDeclaration(line string) bool {
+	if line[0] == ' ' || line[0] == '\t' {
+		return true
+	}
+	return strings.ContainsAny(line[:1], ")=:{.")
+}
+
+// qualifyTypeName returns the FQN of the type name used in a file with the
+// imports in result. A name starting with a lowercase segment is taken to be
+// qualified already; one whose first segment is neither imported nor
+// qualified yields "".
+func qualifyTypeName(name string, result *ParseResult) string {
+	first, rest, _ := strings.Cut(name, ".")
+	if first == "" {
+		return ""
+	}
+	if imp, ok := result.ImportAliases[first]; ok {
+		return joinTypeName(imp, rest)
+	}
+	for _, imp := range result.Imports {
+		if imp == first || strings.HasSuffix(imp, "."+first) {
+			return joinTypeName(imp, rest)
+		}
+	}
+	if rest != "" && unicode.IsLower(rune(first[0])) {
+		return name
+	}
+	return ""
+}
+
+// joinTypeName appends the nested type path rest, if any, to fqn.
+func joinTypeName(fqn, rest string) string {
+	if rest == "" {
+		return fqn
+	}
+	return fqn + "." + rest
+}
+
+// splitStatements splits a line, with comments already stripped, at the
+// semicolons that separate statements, e.g. "import a.B; import c.D" into
+// "import a.B" and " import c.D". Semicolons in double-quoted strings and in
+// backticked names are kept. A trailing semicolon leaves an empty last
+// statement.
+func splitStatements(line string) []string {
+	if !strings.Contains(line, ";") {
+		return []string{line}
+	}
+
+	var stmts []string
+	start := 0
+	var quote byte // '"' or '`' while inside one, else 0
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' && quote == '"' {
+				i++ // skip the escaped character
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '`':
+			quote = ch
+		case ch == ';':
+			stmts = append(stmts, line[start:i])
+			start = i + 1
+		}
+	}
+	return append(stmts, line[start:])
+}
+
+// parseImport records a single (possibly re-joined) import statement.
+func (p *KotlinParser) parseImport(stmt string, result *ParseResult) {
+	// Try to match star imports first (more specific)
+	if matches := p.starImportRegex.FindStringSubmatch(stmt); len(matches) > 1 {
+		result.StarImports = append(result.StarImports, matches[1])
+		return
+	}
+
+	// Try to match import with alias
+	if matches := p.importAliasRegex.FindStringSubmatch(stmt); len(matches) > 2 {
+		importPath := matches[1]
+		alias := matches[2]
+		result.Imports = append(result.Imports, importPath)
+		result.ImportAliases[alias] = importPath
+		return
+	}
+
+	// Try to match regular import
+	if matches := p.importRegex.FindStringSubmatch(stmt); len(matches) > 1 {
+		result.Imports = append(result.Imports, matches[1])
+	}
+}
+
+// ParseFiles parses multiple Kotlin files and returns their metadata in the
+// order given. Files are parsed concurrently by util.Jobs workers (--jobs or
+// BAZELLE_JOBS, defaulting to GOMAXPROCS). A file that takes longer than
+// util.ParseTimeout (--parse-timeout or BAZELLE_PARSE_TIMEOUT) is skipped with
+// a warning and left out of the results. If any other file fails, the error
+// for the earliest such path is returned.
 func (p *KotlinParser) ParseFiles(paths []string) ([]*ParseResult, error) {
-	results := make([]*ParseResult, 0, len(paths))
-	for _, path := range paths {
-		result, err := p.ParseFile(path)
-		if err != nil {
+	return p.ParseFilesContext(context.Background(), paths)
+}
+
+// ParseFilesContext is like ParseFiles but stops parsing once ctx is done,
+// returning ctx.Err().
+func (p *KotlinParser) ParseFilesContext(ctx context.Context, paths []string) ([]*ParseResult, error) {
+	results, _, err := parseFiles(ctx, paths, func(ctx context.Context, path string) (*ParseResult, error) {
+		if err := ctx.Err(); err != nil {
 			return nil, err
 		}
-		results = append(results, result)
+		return p.ParseFile(path)
+	})
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, err
 	}
 	return results, nil
 }
 
+// ParseFilesWithBackend is like KotlinParser.ParseFiles but parses with
+// backend. The per-file context is cancelled at the parse timeout, which stops
+// a tree-sitter parse in place instead of leaving it running in the
+// background.
+func ParseFilesWithBackend(ctx context.Context, backend ParserBackend, paths []string) ([]*ParseResult, error) {
+	results, _, err := parseFiles(ctx, paths, backend.ParseFile)
+	return results, err
+}
+
+// parseFiles runs parse over paths on a worker pool, each call under the
+// parse timeout and timed for util.TraceParses. It returns the results of the
+// files that parsed, in order, and the paths that timed out.
+func parseFiles(ctx context.Context, paths []string, parse func(context.Context, string) (*ParseResult, error)) ([]*ParseResult, []string, error) {
+	jobs, err := util.Jobs(0)
+	if err != nil {
+		return nil, nil, err
+	}
+	timeout, err := util.ParseTimeout(0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]*ParseResult, len(paths))
+	errs := make([]error, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(jobs, len(paths)) {
+		wg.Go(func() {
+			for i := range next {
+				results[i], errs[i] = util.TimeParse(paths[i], func() (*ParseResult, error) {
+					return util.WithParseTimeout(ctx, timeout, paths[i], func(ctx context.Context) (*ParseResult, error) {
+						return parse(ctx, paths[i])
+					})
+				})
+			}
+		})
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	parsed := results[:0]
+	var skipped []string
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, util.ErrParseTimeout):
+			log.Warn("skipping kotlin file that exceeded the parse timeout",
+				"file", paths[i], "timeout", timeout)
+			skipped = append(skipped, paths[i])
+		case err != nil:
+			return nil, nil, err
+		default:
+			parsed = append(parsed, results[i])
+		}
+	}
+	return parsed, skipped, nil
+}
+
 // buildAllDependencies combines imports and FQNs into a single list.
 func buildAllDependencies(result *ParseResult) []string {
 	depSet := make(map[string]bool)
@@ -323,6 +951,25 @@ func cleanPackageName(name string) string {
 	return strings.Trim(name, "`")
 }
 
+// ClassifyImports fills in ImportsByOrigin. Imports under the Kotlin/Java
+// stdlib prefixes (the same ones FQN scanning excludes) are stdlib; of the
+// rest, those for which isFirstParty reports true (typically because they
+// fall under a package defined in the workspace) are first-party, and the
+// remainder are third-party. Star imports are classified by their package.
+func (r *ParseResult) ClassifyImports(isFirstParty func(imp string) bool) {
+	stdlib := getKotlinStdlibPrefixes()
+	r.ImportsByOrigin = util.GroupByOrigin(slices.Concat(r.Imports, r.StarImports), func(imp string) string {
+		switch {
+		case isStdlibName(stdlib, imp):
+			return util.OriginStdlib
+		case isFirstParty != nil && isFirstParty(imp):
+			return util.OriginFirstParty
+		default:
+			return util.OriginThirdParty
+		}
+	})
+}
+
 // GetPackages returns unique packages from parse results.
 func GetPackages(results []*ParseResult) []string {
 	pkgSet := make(map[string]bool)
@@ -359,6 +1006,14 @@ func GetAllDependencies(results []*ParseResult) []string {
 	return slices.Sorted(maps.Keys(depSet))
 }
 
+// GetProductionDependencies is like GetAllDependencies but skips results for
+// test files (IsTest), so the set reflects only what production code needs.
+func GetProductionDependencies(results []*ParseResult) []string {
+	return GetAllDependencies(slices.DeleteFunc(slices.Clone(results), func(r *ParseResult) bool {
+		return r.IsTest
+	}))
+}
+
 // ImportInfo contains detailed information about an import.
 type ImportInfo struct {
 	// Path is the full import path (e.g., "com.example.Foo").
@@ -47,22 +47,31 @@
 //   - Generated or minified code
 //   - When false positives/negatives are unacceptable
 //
+// Use BackendAdaptive for:
+//   - Codebases mixing hand-written sources with large generated files
+//   - Heuristic speed on small files and tree-sitter accuracy on big ones,
+//     chosen per file (see BackendConfig.AdaptiveMaxBytes)
+//
 // Use BackendHybrid for:
 //   - Validating heuristic accuracy against tree-sitter
 //   - Gradual migration from heuristic to tree-sitter
 //   - Debugging parsing discrepancies
+//   - Maximizing recall by merging both results (HybridUnion)
 package kotlin
 
 import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
+	"hash/fnv"
+	"maps"
 	"slices"
 	"strings"
+	"sync/atomic"
 
 	"github.com/albertocavalcante/bazelle/internal/log"
 	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+	"github.com/albertocavalcante/bazelle/pkg/util"
 )
 
 // -----------------------------------------------------------------------------
@@ -109,6 +118,19 @@ const (
 	//   - Debugging parsing discrepancies
 	//   - Gradual migration from heuristic to tree-sitter
 	BackendHybrid ParserBackendType = "hybrid"
+
+	// BackendAdaptive picks a backend per file by its size: small files are
+	// parsed with the heuristic backend, files above the AdaptiveMaxBytes or
+	// AdaptiveMaxLines threshold with tree-sitter.
+	//
+	// Large files are where the heuristic backend is least reliable (they
+	// are often generated, with unusual formatting), while most hand-written
+	// files are small enough for its approximations to hold.
+	//
+	// Accuracy: tree-sitter's for large files, heuristic's for small ones.
+	// Performance: close to heuristic for typical codebases.
+	// Dependencies: Requires tree-sitter runtime (CGO or WASM backend).
+	BackendAdaptive ParserBackendType = "adaptive"
 )
 
 // ParserBackend abstracts the parsing implementation, allowing callers to
@@ -186,6 +208,19 @@ func (e ErrLanguageNotSupported) Error() string {
 // Configuration
 // -----------------------------------------------------------------------------
 
+// HybridMergeMode specifies how HybridBackend combines backend results.
+type HybridMergeMode string
+
+const (
+	// HybridPrimaryOnly returns the primary backend's result and discards the
+	// other, falling back to it only on errors.
+	HybridPrimaryOnly HybridMergeMode = "primary"
+
+	// HybridUnion returns the union of imports, star imports, FQNs, and
+	// annotations found by either backend, deduplicated and sorted.
+	HybridUnion HybridMergeMode = "union"
+)
+
 // BackendConfig holds configuration for parser backends.
 //
 // These options control the balance between parsing accuracy and performance.
@@ -200,6 +235,14 @@ type BackendConfig struct {
 	// Default: true
 	EnableFQNScanning bool
 
+	// FQNScanMaxLines limits FQN scanning to the first lines of each file's
+	// code body, bounding its cost on huge (usually generated) files. FQNs
+	// used only further down are missed; files cut short are logged at info
+	// level. 0 means no limit.
+	//
+	// Default: 0
+	FQNScanMaxLines int
+
 	// TreeSitterBackend specifies which tree-sitter runtime to use.
 	//
 	// This affects TreeSitterBackend and HybridBackend only.
@@ -209,6 +252,24 @@ type BackendConfig struct {
 	// They differ only in performance characteristics and build requirements.
 	TreeSitterBackend treesitter.BackendType
 
+	// ResourceLoaders lists function names whose string-literal arguments are
+	// recorded as ParseResult.ResourceReferences. This is ALWAYS HEURISTIC.
+	//
+	// Default: nil (resource scanning disabled)
+	ResourceLoaders []string
+
+	// DiAnnotations lists dependency-injection annotation names recorded as
+	// ParseResult.DiAnnotations. This is ALWAYS HEURISTIC.
+	//
+	// Default: nil (DI annotation scanning disabled)
+	DiAnnotations []string
+
+	// TestFilePatterns classifies files as tests (ParseResult.IsTest).
+	//
+	// See IsTestFile for the pattern syntax.
+	// Default: nil (DefaultTestFilePatterns)
+	TestFilePatterns []string
+
 	// HybridPrimary specifies which backend's output to use in hybrid mode.
 	//
 	// In hybrid mode, both backends run and results are compared. This option
@@ -217,8 +278,22 @@ type BackendConfig struct {
 	//   - BackendTreeSitter: Use tree-sitter result, validate against heuristic
 	//
 	// Default: BackendHeuristic (use heuristic for speed, validate for accuracy)
+	//
+	// Ignored when HybridMerge is HybridUnion.
 	HybridPrimary ParserBackendType
 
+	// HybridMerge specifies how hybrid mode combines the two backends' results.
+	//
+	//   - HybridPrimaryOnly: Return the HybridPrimary backend's result as-is
+	//   - HybridUnion: Return the deduplicated union of both results
+	//
+	// Union mode maximizes recall: an import or FQN found by either backend is
+	// kept. This is useful when each backend catches different edge cases, at
+	// the cost of also keeping heuristic false positives.
+	//
+	// Default: HybridPrimaryOnly
+	HybridMerge HybridMergeMode
+
 	// HybridLogDiffs enables logging of differences between backends.
 	//
 	// When enabled, any differences between heuristic and tree-sitter results
@@ -226,21 +301,61 @@ type BackendConfig struct {
 	//
 	// Default: true
 	HybridLogDiffs bool
+
+	// HeuristicSampleRate is the fraction of files, from 0 to 1, that the
+	// heuristic backend also parses with tree-sitter to measure how often the
+	// two disagree, without paying for hybrid mode on every file. Divergences
+	// are logged at debug level and counted (see HeuristicBackend.SampleStats).
+	//
+	// Files are picked by a hash of their path, so a given rate samples the
+	// same files on every run. Sampling needs a tree-sitter runtime; without
+	// one it is disabled with a warning.
+	//
+	// Default: 0 (no sampling)
+	HeuristicSampleRate float64
+
+	// AdaptiveMaxBytes is the size, in bytes, above which adaptive mode
+	// parses a file with tree-sitter instead of the heuristic backend.
+	// 0 disables the size threshold.
+	//
+	// Default: 32768 (32 KiB)
+	AdaptiveMaxBytes int
+
+	// AdaptiveMaxLines is the line count above which adaptive mode parses a
+	// file with tree-sitter instead of the heuristic backend. A file over
+	// either threshold is parsed with tree-sitter. 0 disables the line
+	// threshold.
+	//
+	// Default: 1000
+	AdaptiveMaxLines int
+
+	// TreeCache, if set, keeps the trees that tree-sitter parses, so a file
+	// whose content is unchanged is not parsed again (see UseTreeCache).
+	//
+	// Default: nil (every parse builds and closes its tree)
+	TreeCache treesitter.TreeCache
 }
 
 // DefaultBackendConfig returns sensible defaults for parser configuration.
 //
 // Defaults favor heuristic parsing with FQN scanning enabled:
 //   - EnableFQNScanning: true (detect inline FQNs)
+//   - FQNScanMaxLines: 0 (scan whole files)
 //   - TreeSitterBackend: Auto (let runtime choose best backend)
 //   - HybridPrimary: Heuristic (prefer speed over accuracy)
+//   - HybridMerge: PrimaryOnly (return a single backend's result)
 //   - HybridLogDiffs: true (log differences for debugging)
+//   - HeuristicSampleRate: 0 (no accuracy sampling)
+//   - AdaptiveMaxBytes: 32 KiB, AdaptiveMaxLines: 1000 (adaptive thresholds)
 func DefaultBackendConfig() BackendConfig {
 	return BackendConfig{
 		EnableFQNScanning: true,
 		TreeSitterBackend: treesitter.BackendAuto,
 		HybridPrimary:     BackendHeuristic,
+		HybridMerge:       HybridPrimaryOnly,
 		HybridLogDiffs:    true,
+		AdaptiveMaxBytes:  32 * 1024,
+		AdaptiveMaxLines:  1000,
 	}
 }
 
@@ -262,6 +377,7 @@ func DefaultBackendConfig() BackendConfig {
 //	typ := BackendHeuristic  // Fast, no dependencies, ~99% accuracy
 //	typ := BackendTreeSitter // Slower, needs runtime, 100% accuracy
 //	typ := BackendHybrid     // Both (for validation/debugging)
+//	typ := BackendAdaptive   // Per file: heuristic if small, else tree-sitter
 //
 // Returns an error if tree-sitter is requested but not available.
 func NewParserBackend(typ ParserBackendType, cfg BackendConfig) (ParserBackend, error) {
@@ -272,6 +388,8 @@ func NewParserBackend(typ ParserBackendType, cfg BackendConfig) (ParserBackend,
 		return NewTreeSitterBackend(cfg)
 	case BackendHybrid:
 		return NewHybridBackend(cfg)
+	case BackendAdaptive:
+		return NewAdaptiveBackend(cfg)
 	default:
 		return nil, ErrBackendNotSupported{Backend: typ, Reason: "unknown type"}
 	}
@@ -294,6 +412,8 @@ func NewParserBackend(typ ParserBackendType, cfg BackendConfig) (ParserBackend,
 //   - Escaped characters in strings containing "import" may confuse the parser
 //   - Complex annotation syntax may not be fully captured
 //   - Minified code with unusual formatting may parse incorrectly
+//   - Wrapped imports are rejoined only where a line break falls before a
+//     "." (or after a trailing one); a break before "as" loses the alias
 //
 // Why Use Heuristics:
 //   - Zero external dependencies (no CGO, no WASM runtime)
@@ -301,9 +421,11 @@ func NewParserBackend(typ ParserBackendType, cfg BackendConfig) (ParserBackend,
 //   - Sufficient for most real-world, hand-written Kotlin code
 //   - Well-tested patterns tuned for common coding conventions
 //
-// For maximum accuracy, use TreeSitterBackend instead.
+// For maximum accuracy, use TreeSitterBackend instead. To monitor accuracy
+// in production, set BackendConfig.HeuristicSampleRate.
 type HeuristicBackend struct {
-	parser *KotlinParser
+	parser  *KotlinParser
+	sampler *divergenceSampler // nil unless HeuristicSampleRate > 0
 }
 
 // NewHeuristicBackend creates a new heuristic (regex-based) backend.
@@ -311,28 +433,118 @@ type HeuristicBackend struct {
 // The backend can optionally scan for fully-qualified names (FQNs) in the
 // code body. FQN scanning is itself heuristic (see FQNScanner).
 func NewHeuristicBackend(cfg BackendConfig) *HeuristicBackend {
-	var opts []ParserOption
+	opts := []ParserOption{
+		WithTestFilePatterns(cfg.TestFilePatterns),
+		WithResourceLoaders(cfg.ResourceLoaders),
+		WithDiAnnotations(cfg.DiAnnotations),
+	}
 	if !cfg.EnableFQNScanning {
 		opts = append(opts, WithFQNScanning(false))
 	}
-	return &HeuristicBackend{parser: NewParser(opts...)}
+	if cfg.FQNScanMaxLines > 0 {
+		opts = append(opts, WithFQNScanMaxLines(cfg.FQNScanMaxLines))
+	}
+	b := &HeuristicBackend{parser: NewParser(opts...)}
+
+	if cfg.HeuristicSampleRate > 0 {
+		ts, err := NewTreeSitterBackend(cfg)
+		if err != nil {
+			log.Warn("heuristic sampling disabled: tree-sitter unavailable",
+				"rate", cfg.HeuristicSampleRate, "error", err)
+		} else {
+			b.sampler = newDivergenceSampler(cfg.HeuristicSampleRate, ts)
+		}
+	}
+	return b
 }
 
 func (b *HeuristicBackend) Name() string { return string(BackendHeuristic) }
 
-func (b *HeuristicBackend) ParseContent(_ context.Context, content, path string) (*ParseResult, error) {
-	return b.parser.ParseContent(content, path)
+func (b *HeuristicBackend) ParseContent(ctx context.Context, content, path string) (*ParseResult, error) {
+	result, err := b.parser.ParseContent(content, path)
+	if err == nil && b.sampler != nil {
+		b.sampler.check(ctx, result, content, path)
+	}
+	return result, err
 }
 
 func (b *HeuristicBackend) ParseFile(ctx context.Context, path string) (*ParseResult, error) {
 	content, err := readFileContent(path)
 	if err != nil {
-		return nil, err
+		return nil, util.NewParseError(path, b.Name(), err)
 	}
 	return b.ParseContent(ctx, content, path)
 }
 
-func (b *HeuristicBackend) Close() error { return nil }
+// SampleStats reports how many files were checked against tree-sitter and how
+// many of those diverged. It is zero when sampling is disabled.
+func (b *HeuristicBackend) SampleStats() SampleStats {
+	if b.sampler == nil {
+		return SampleStats{}
+	}
+	return SampleStats{
+		Sampled:  b.sampler.sampled.Load(),
+		Diverged: b.sampler.diverged.Load(),
+	}
+}
+
+func (b *HeuristicBackend) Close() error {
+	if b.sampler != nil {
+		return b.sampler.verifier.Close()
+	}
+	return nil
+}
+
+// SampleStats holds the counters of heuristic accuracy sampling.
+type SampleStats struct {
+	// Sampled is the number of files also parsed with tree-sitter.
+	Sampled int64
+
+	// Diverged is the number of sampled files where compareResults found
+	// differences between the two backends.
+	Diverged int64
+}
+
+// divergenceSampler re-parses a fraction of heuristic results with a
+// deterministic backend and counts the files where they disagree.
+type divergenceSampler struct {
+	threshold uint64 // sample when the path hash is below this
+	verifier  ParserBackend
+	sampled   atomic.Int64
+	diverged  atomic.Int64
+}
+
+func newDivergenceSampler(rate float64, verifier ParserBackend) *divergenceSampler {
+	return &divergenceSampler{
+		threshold: uint64(min(max(rate, 0), 1) * (1 << 32)),
+		verifier:  verifier,
+	}
+}
+
+// selects reports whether path falls in the sampled fraction.
+func (s *divergenceSampler) selects(path string) bool {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(path))
+	return uint64(h.Sum32()) < s.threshold
+}
+
+// check compares result against the verifier's parse of the same content if
+// path is sampled. Verifier errors are logged and not counted.
+func (s *divergenceSampler) check(ctx context.Context, result *ParseResult, content, path string) {
+	if !s.selects(path) {
+		return
+	}
+	verified, err := s.verifier.ParseContent(ctx, content, path)
+	if err != nil {
+		log.V(3).Debugw("heuristic sample parse failed", "path", path, "error", err)
+		return
+	}
+	s.sampled.Add(1)
+	if diff := compareResults(result, verified); diff.HasDifferences() {
+		s.diverged.Add(1)
+		log.V(3).Debugw("heuristic sample diff", "path", path, "diff", diff.String())
+	}
+}
 
 // -----------------------------------------------------------------------------
 // TreeSitterBackend - AST-Based Parsing (DETERMINISTIC)
@@ -351,6 +563,16 @@ const (
 	nodeFunctionDeclaration = "function_declaration"
 	nodePropertyDeclaration = "property_declaration"
 	nodeTypeAlias           = "type_alias"
+	nodeCompanionObject     = "companion_object"
+	nodeClassBody           = "class_body"
+	nodeEnumClassBody       = "enum_class_body"
+	nodeModifiers           = "modifiers"
+	nodeClassModifier       = "class_modifier"
+	nodeTypeIdentifier      = "type_identifier"
+	nodeDelegationSpecifier = "delegation_specifier"
+	nodeUserType            = "user_type"
+	nodeExplicitDelegation  = "explicit_delegation"
+	nodePropertyDelegate    = "property_delegate"
 )
 
 // declarationNodeTypes lists node types that mark the start of code.
@@ -393,14 +615,24 @@ type TreeSitterBackend struct {
 	backend      treesitter.Backend
 	enableFQN    bool
 	heuristicFQN *FQNScanner // Note: FQN scanning is always heuristic
+	testPatterns []string
+	resources    *util.ResourceRefScanner // Note: resource scanning is always heuristic
+	trees        treesitter.TreeCache     // nil = no caching
+
+	diAnnotations map[string]bool // Note: DI annotation scanning is always heuristic
 }
 
+// newRuntime creates the tree-sitter runtime of a TreeSitterBackend. Tests
+// replace it to count the runtimes left open.
+var newRuntime = treesitter.NewBackend
+
 // NewTreeSitterBackend creates a deterministic AST-based parser backend.
 //
 // Returns an error if the tree-sitter runtime is not available or doesn't
-// support Kotlin parsing.
+// support Kotlin parsing; a runtime that was created is closed before
+// returning.
 func NewTreeSitterBackend(cfg BackendConfig) (*TreeSitterBackend, error) {
-	backend, err := treesitter.NewBackend(cfg.TreeSitterBackend)
+	backend, err := newRuntime(cfg.TreeSitterBackend)
 	if err != nil {
 		return nil, fmt.Errorf("create tree-sitter backend: %w", err)
 	}
@@ -410,19 +642,37 @@ func NewTreeSitterBackend(cfg BackendConfig) (*TreeSitterBackend, error) {
 		return nil, ErrLanguageNotSupported{Backend: backend.Name()}
 	}
 
+	heuristicFQN := NewFQNScanner()
+	heuristicFQN.SetMaxLines(cfg.FQNScanMaxLines)
+
 	return &TreeSitterBackend{
-		backend:      backend,
-		enableFQN:    cfg.EnableFQNScanning,
-		heuristicFQN: NewFQNScanner(),
+		backend:       backend,
+		enableFQN:     cfg.EnableFQNScanning,
+		heuristicFQN:  heuristicFQN,
+		testPatterns:  cfg.TestFilePatterns,
+		resources:     util.NewResourceRefScanner(cfg.ResourceLoaders),
+		trees:         cfg.TreeCache,
+		diAnnotations: newDiAnnotationSet(cfg.DiAnnotations),
 	}, nil
 }
 
 func (b *TreeSitterBackend) Name() string { return string(BackendTreeSitter) }
 
-func (b *TreeSitterBackend) ParseContent(ctx context.Context, content, path string) (_ *ParseResult, retErr error) {
+func (b *TreeSitterBackend) ParseContent(ctx context.Context, content, path string) (*ParseResult, error) {
+	result, err := b.parseContent(ctx, content, path)
+	if err != nil {
+		return nil, util.NewParseError(path, b.Name(), err)
+	}
+	return result, nil
+}
+
+// parseContent does the work of ParseContent, which adds the file context to
+// its errors.
+func (b *TreeSitterBackend) parseContent(ctx context.Context, content, path string) (_ *ParseResult, retErr error) {
 	if b == nil {
 		return nil, fmt.Errorf("TreeSitterBackend is nil")
 	}
+	content = normalizeNewlines(content)
 	parser, err := b.backend.NewParser(treesitter.Kotlin)
 	if err != nil {
 		return nil, fmt.Errorf("create Kotlin parser: %w", err)
@@ -433,21 +683,32 @@ func (b *TreeSitterBackend) ParseContent(ctx context.Context, content, path stri
 		}
 	}()
 
-	tree, err := parser.Parse(ctx, []byte(content))
-	if err != nil {
-		return nil, fmt.Errorf("parse Kotlin source: %w", err)
-	}
-	defer func() {
-		if closeErr := tree.Close(); closeErr != nil && retErr == nil {
-			retErr = fmt.Errorf("close tree: %w", closeErr)
+	var tree treesitter.Tree
+	if b.trees != nil {
+		var release func()
+		tree, release, err = b.trees.GetOrParse(ctx, parser, path, []byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse Kotlin source: %w", err)
 		}
-	}()
+		defer release()
+	} else {
+		tree, err = parser.Parse(ctx, []byte(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse Kotlin source: %w", err)
+		}
+		defer func() {
+			if closeErr := tree.Close(); closeErr != nil && retErr == nil {
+				retErr = fmt.Errorf("close tree: %w", closeErr)
+			}
+		}()
+	}
 
 	source := []byte(content)
 	root := tree.RootNode()
 
 	result := &ParseResult{
 		FilePath:      path,
+		IsTest:        IsTestFile(path, b.testPatterns),
 		Imports:       make([]string, 0),
 		StarImports:   make([]string, 0),
 		ImportAliases: make(map[string]string),
@@ -456,17 +717,48 @@ func (b *TreeSitterBackend) ParseContent(ctx context.Context, content, path stri
 	}
 
 	result.Package = extractPackageFromAST(root, source)
+	result.DeclarationKinds = extractDeclarationKindsFromAST(root, source)
 	extractImportsFromAST(root, source, result)
 	result.Annotations = extractAnnotationsFromAST(root, source)
 	result.CodeStartLine = findCodeStartLineFromAST(root)
 
-	// FQN scanning uses heuristic approach (AST-based FQN detection is future work)
+	// A tree with ERROR/MISSING nodes still carries the valid declarations
+	// around the broken region, so return them as a partial result instead
+	// of failing the whole file.
+	result.HasSyntaxErrors = tree.HasError()
+	result.ResourceReferences = scanResourceReferences(b.resources, content)
+	result.DiAnnotations = scanDiAnnotations(b.diAnnotations, content)
+
+	// FQN scanning uses heuristic approach (AST-based FQN detection beyond
+	// supertypes is future work)
 	if b.enableFQN && b.heuristicFQN != nil && result.CodeStartLine > 0 {
 		startLine := max(result.CodeStartLine-1, 0)
 		scanResult := b.heuristicFQN.Scan(content, startLine)
 		result.FQNs = scanResult.FQNs
+		logTruncatedScan(path, b.heuristicFQN, scanResult)
+	}
+
+	// Supertypes and delegation targets come from the AST, so entries of a
+	// supertype list that spans several lines are caught as well.
+	if b.enableFQN && b.heuristicFQN != nil {
+		var referenced []string
+		names := append(extractSupertypesFromAST(root, source), extractDelegatesFromAST(root, source)...)
+		for _, name := range names {
+			if fqn := outerClassFQN(name); b.heuristicFQN.shouldInclude(fqn) {
+				referenced = append(referenced, fqn)
+			}
+		}
+		if len(referenced) > 0 {
+			result.FQNs = sortedUnion(result.FQNs, referenced)
+		}
 	}
 
+	scanActualTypeAliases(content, result)
+	scanReifiedTypeRefs(content, result)
+	scanOptInMarkers(content, result)
+	result.UsesCoroutines = declaresSuspendFun(content)
+	result.DeclaredTypes = topLevelTypes(result.DeclarationKinds)
+
 	result.AllDependencies = buildAllDependencies(result)
 	return result, nil
 }
@@ -474,7 +766,7 @@ func (b *TreeSitterBackend) ParseContent(ctx context.Context, content, path stri
 func (b *TreeSitterBackend) ParseFile(ctx context.Context, path string) (*ParseResult, error) {
 	content, err := readFileContent(path)
 	if err != nil {
-		return nil, err
+		return nil, util.NewParseError(path, b.Name(), err)
 	}
 	return b.ParseContent(ctx, content, path)
 }
@@ -524,7 +816,10 @@ func extractImportsFromAST(root treesitter.Node, source []byte, result *ParseRes
 
 // processImportNode extracts details from a single import node.
 func processImportNode(node treesitter.Node, source []byte, result *ParseResult) {
-	content := strings.TrimSpace(node.Content(source))
+	// The header of an import followed by another on the same line ends in
+	// the semicolon separating them
+	content := joinWrappedName(node.Content(source))
+	content = strings.TrimSpace(strings.TrimSuffix(content, ";"))
 
 	// Remove "import " prefix
 	path, found := strings.CutPrefix(content, "import ")
@@ -556,7 +851,7 @@ func processImportNode(node treesitter.Node, source []byte, result *ParseResult)
 
 	// Regular import
 	if identNode := node.ChildByFieldName(nodeIdentifier); identNode != nil && !identNode.IsNull() {
-		if p := identNode.Content(source); p != "" {
+		if p := joinWrappedName(identNode.Content(source)); p != "" {
 			result.Imports = append(result.Imports, p)
 			return
 		}
@@ -568,6 +863,14 @@ func processImportNode(node treesitter.Node, source []byte, result *ParseResult)
 	}
 }
 
+// joinWrappedName collapses the line breaks and indentation that Kotlin allows
+// before a "." in a qualified name, so "com.example\n    .Foo" becomes
+// "com.example.Foo". Other whitespace is reduced to single spaces.
+func joinWrappedName(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return strings.ReplaceAll(strings.ReplaceAll(s, " .", "."), ". ", ".")
+}
+
 // extractAnnotationsFromAST finds file-level annotations in the AST.
 func extractAnnotationsFromAST(root treesitter.Node, source []byte) []string {
 	var annotations []string
@@ -588,6 +891,137 @@ func extractAnnotationsFromAST(root treesitter.Node, source []byte) []string {
 	return annotations
 }
 
+// extractSupertypesFromAST returns the names in every supertype list in the
+// AST, from class and interface declarations as well as object expressions
+// ("object : a.b.Listener {}"). Each delegation_specifier holds a user_type
+// directly, inside a constructor_invocation ("a.b.Base()") or inside an
+// explicit_delegation ("a.b.Api by impl").
+func extractSupertypesFromAST(root treesitter.Node, source []byte) []string {
+	var names []string
+	for _, spec := range treesitter.FindByType(root, nodeDelegationSpecifier) {
+		typ := spec
+		for typ.Type() != nodeUserType {
+			children := treesitter.NamedChildren(typ)
+			if len(children) == 0 {
+				break
+			}
+			typ = children[0]
+		}
+		if typ.Type() != nodeUserType {
+			continue
+		}
+
+		var parts []string
+		for _, id := range treesitter.ChildrenByType(typ, nodeTypeIdentifier) {
+			parts = append(parts, id.Content(source))
+		}
+		if len(parts) > 0 {
+			names = append(names, strings.Join(parts, "."))
+		}
+	}
+	return names
+}
+
+// extractDelegatesFromAST returns the qualified names that delegation
+// targets start with, from class delegation ("Api by a.b.DefaultApi()") and
+// property delegation ("val x by a.b.Prefs.string()"). The delegate is the
+// expression after "by"; its leading dotted name is kept and any call or
+// further member access dropped.
+func extractDelegatesFromAST(root treesitter.Node, source []byte) []string {
+	var names []string
+	for _, nodeType := range []string{nodeExplicitDelegation, nodePropertyDelegate} {
+		for _, node := range treesitter.FindByType(root, nodeType) {
+			children := treesitter.NamedChildren(node)
+			if len(children) == 0 {
+				continue
+			}
+			expr := joinWrappedName(children[len(children)-1].Content(source))
+			if name := qualifiedNameRegex.FindString(expr); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// extractDeclarationKindsFromAST maps the class, interface and object
+// declarations in the AST to their kinds. See ParseResult.DeclarationKinds.
+func extractDeclarationKindsFromAST(root treesitter.Node, source []byte) map[string]string {
+	kinds := make(map[string]string)
+	collectDeclarationKinds(root, "", source, kinds)
+	return kinds
+}
+
+// topLevelTypes returns the sorted names of the top-level types among the
+// declarations of a file: the keys of ParseResult.DeclarationKinds that are
+// not dotted paths.
+func topLevelTypes(kinds map[string]string) []string {
+	var types []string
+	for name := range kinds {
+		if !strings.Contains(name, ".") {
+			types = append(types, name)
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+// collectDeclarationKinds records the type declarations directly under n and
+// recurses into their bodies. Local types inside functions are not visited.
+func collectDeclarationKinds(n treesitter.Node, prefix string, source []byte, kinds map[string]string) {
+	for _, child := range treesitter.NamedChildren(n) {
+		switch child.Type() {
+		case nodeClassDeclaration, nodeObjectDeclaration, nodeCompanionObject:
+		default:
+			continue
+		}
+
+		name, kind := declarationKind(child, source)
+		if name == "" || kind == "" {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		kinds[name] = kind
+
+		for _, body := range treesitter.NamedChildren(child) {
+			if t := body.Type(); t == nodeClassBody || t == nodeEnumClassBody {
+				collectDeclarationKinds(body, name, source, kinds)
+			}
+		}
+	}
+}
+
+// declarationKind returns the name and kind of a single type declaration.
+//
+// The grammar puts most class modifiers under modifiers/class_modifier
+// ("data class"), but "enum" and "companion" are bare keyword children
+// alongside "class" and "object".
+func declarationKind(decl treesitter.Node, source []byte) (name, kind string) {
+	var words []string
+	for _, child := range treesitter.Children(decl) {
+		switch t := child.Type(); t {
+		case nodeModifiers:
+			for _, mod := range treesitter.ChildrenByType(child, nodeClassModifier) {
+				words = append(words, mod.Content(source))
+			}
+		case "enum", "companion":
+			words = append(words, t)
+		case "class", "interface", "object":
+			kind = strings.Join(append(words, t), " ")
+		case nodeTypeIdentifier:
+			if name == "" {
+				name = child.Content(source)
+			}
+		}
+	}
+	if name == "" && decl.Type() == nodeCompanionObject {
+		name = "Companion"
+	}
+	return name, kind
+}
+
 // findCodeStartLineFromAST finds where declarations begin in the AST.
 func findCodeStartLineFromAST(root treesitter.Node) int {
 	minLine := -1
@@ -627,7 +1061,7 @@ func findCodeStartLineFromAST(root treesitter.Node) int {
 //  1. Runs both backends on the same input
 //  2. Compares results (package, imports, star imports)
 //  3. Logs differences if HybridLogDiffs is enabled
-//  4. Returns the primary backend's result
+//  4. Returns the primary backend's result, or the union of both results
 //  5. Falls back to the other backend on errors
 //
 // # Result Selection
@@ -636,23 +1070,33 @@ func findCodeStartLineFromAST(root treesitter.Node) int {
 //   - BackendHeuristic (default): Returns heuristic result, validates against tree-sitter
 //   - BackendTreeSitter: Returns tree-sitter result, validates against heuristic
 //
+// When HybridMerge is HybridUnion, HybridPrimary is ignored and the merged
+// result of both backends is returned instead (see mergeResults).
+//
 // This allows using hybrid mode in production while collecting validation data.
 type HybridBackend struct {
 	heuristic  *HeuristicBackend  // Regex-based (heuristic)
 	treesitter *TreeSitterBackend // AST-based (deterministic)
 	primary    ParserBackendType  // Which result to return
+	merge      HybridMergeMode    // How to combine results
 	logDiffs   bool               // Log differences between backends
 	cfg        BackendConfig
 }
 
 // NewHybridBackend creates a validation backend that runs both parsing strategies.
 //
-// Requires tree-sitter support; returns an error if tree-sitter is unavailable.
+// Requires tree-sitter support; returns an error if tree-sitter is unavailable,
+// after closing the heuristic backend it had already created.
 func NewHybridBackend(cfg BackendConfig) (*HybridBackend, error) {
-	heuristic := NewHeuristicBackend(cfg)
+	// Hybrid mode already compares every file, so sampling would only repeat
+	// the work.
+	hcfg := cfg
+	hcfg.HeuristicSampleRate = 0
+	heuristic := NewHeuristicBackend(hcfg)
 
 	ts, err := NewTreeSitterBackend(cfg)
 	if err != nil {
+		_ = heuristic.Close()
 		return nil, fmt.Errorf("create tree-sitter for hybrid: %w", err)
 	}
 
@@ -660,6 +1104,7 @@ func NewHybridBackend(cfg BackendConfig) (*HybridBackend, error) {
 		heuristic:  heuristic,
 		treesitter: ts,
 		primary:    cfg.HybridPrimary,
+		merge:      cfg.HybridMerge,
 		logDiffs:   cfg.HybridLogDiffs,
 		cfg:        cfg,
 	}, nil
@@ -680,6 +1125,10 @@ func (b *HybridBackend) ParseContent(ctx context.Context, content, path string)
 		}
 	}
 
+	if b.merge == HybridUnion && hErr == nil && tsErr == nil {
+		return mergeResults(tsResult, hResult), nil
+	}
+
 	// Return based on primary preference with fallback
 	if b.primary == BackendTreeSitter {
 		if tsErr != nil {
@@ -701,7 +1150,7 @@ func (b *HybridBackend) ParseContent(ctx context.Context, content, path string)
 func (b *HybridBackend) ParseFile(ctx context.Context, path string) (*ParseResult, error) {
 	content, err := readFileContent(path)
 	if err != nil {
-		return nil, err
+		return nil, util.NewParseError(path, b.Name(), err)
 	}
 	return b.ParseContent(ctx, content, path)
 }
@@ -710,6 +1159,103 @@ func (b *HybridBackend) Close() error {
 	return errors.Join(b.heuristic.Close(), b.treesitter.Close())
 }
 
+// -----------------------------------------------------------------------------
+// AdaptiveBackend - Per-File Selection by Size
+// -----------------------------------------------------------------------------
+
+// AdaptiveBackend parses each file with the heuristic backend if it is small
+// and with tree-sitter if it exceeds the AdaptiveMaxBytes or AdaptiveMaxLines
+// threshold of its BackendConfig.
+//
+// A file tree-sitter fails to parse falls back to the heuristic backend, so
+// a large file is never left without a result that the heuristic backend
+// could have produced.
+type AdaptiveBackend struct {
+	heuristic  *HeuristicBackend  // Small files (heuristic)
+	treesitter *TreeSitterBackend // Large files (deterministic)
+	maxBytes   int                // Tree-sitter above this size; 0 = no limit
+	maxLines   int                // Tree-sitter above this line count; 0 = no limit
+
+	heuristicFiles  atomic.Int64
+	treeSitterFiles atomic.Int64
+}
+
+// NewAdaptiveBackend creates a backend that chooses between the heuristic
+// and tree-sitter backends by file size.
+//
+// Requires tree-sitter support; returns an error if tree-sitter is
+// unavailable, after closing the heuristic backend it had already created.
+func NewAdaptiveBackend(cfg BackendConfig) (*AdaptiveBackend, error) {
+	heuristic := NewHeuristicBackend(cfg)
+
+	ts, err := NewTreeSitterBackend(cfg)
+	if err != nil {
+		_ = heuristic.Close()
+		return nil, fmt.Errorf("create tree-sitter for adaptive: %w", err)
+	}
+
+	return &AdaptiveBackend{
+		heuristic:  heuristic,
+		treesitter: ts,
+		maxBytes:   cfg.AdaptiveMaxBytes,
+		maxLines:   cfg.AdaptiveMaxLines,
+	}, nil
+}
+
+func (b *AdaptiveBackend) Name() string { return string(BackendAdaptive) }
+
+func (b *AdaptiveBackend) ParseContent(ctx context.Context, content, path string) (*ParseResult, error) {
+	if b.large(content) {
+		result, err := b.treesitter.ParseContent(ctx, content, path)
+		if err == nil {
+			b.treeSitterFiles.Add(1)
+			return result, nil
+		}
+		log.V(3).Debugw("adaptive tree-sitter failed, using heuristic",
+			"path", path, "error", err)
+	}
+	b.heuristicFiles.Add(1)
+	return b.heuristic.ParseContent(ctx, content, path)
+}
+
+// large reports whether content exceeds one of the thresholds.
+func (b *AdaptiveBackend) large(content string) bool {
+	if b.maxBytes > 0 && len(content) > b.maxBytes {
+		return true
+	}
+	return b.maxLines > 0 && strings.Count(content, "\n") > b.maxLines
+}
+
+func (b *AdaptiveBackend) ParseFile(ctx context.Context, path string) (*ParseResult, error) {
+	content, err := readFileContent(path)
+	if err != nil {
+		return nil, util.NewParseError(path, b.Name(), err)
+	}
+	return b.ParseContent(ctx, content, path)
+}
+
+// Stats reports how many files each backend has parsed.
+func (b *AdaptiveBackend) Stats() AdaptiveStats {
+	return AdaptiveStats{
+		Heuristic:  b.heuristicFiles.Load(),
+		TreeSitter: b.treeSitterFiles.Load(),
+	}
+}
+
+func (b *AdaptiveBackend) Close() error {
+	return errors.Join(b.heuristic.Close(), b.treesitter.Close())
+}
+
+// AdaptiveStats holds the counters of an AdaptiveBackend.
+type AdaptiveStats struct {
+	// Heuristic is the number of files parsed with the heuristic backend,
+	// including large files tree-sitter failed to parse.
+	Heuristic int64
+
+	// TreeSitter is the number of files parsed with tree-sitter.
+	TreeSitter int64
+}
+
 // -----------------------------------------------------------------------------
 // Comparison Utilities - Heuristic vs Deterministic Validation
 // -----------------------------------------------------------------------------
@@ -796,10 +1342,87 @@ func compareResults(h, ts *ParseResult) ResultDiff {
 	return diff
 }
 
+// mergeResults returns the union of two parse results.
+//
+// List fields are deduplicated and sorted, except ResourceReferences and
+// DiAnnotations, which keep the order of a followed by the extra entries of
+// b. Scalar fields (package, code start
+// line) are taken from a, falling back to b when a has no value. Aliases,
+// declaration kinds and actual typealiases from a win over conflicting
+// entries from b.
+func mergeResults(a, b *ParseResult) *ParseResult {
+	merged := &ParseResult{
+		Package:            a.Package,
+		Imports:            sortedUnion(a.Imports, b.Imports),
+		StarImports:        sortedUnion(a.StarImports, b.StarImports),
+		ImportAliases:      make(map[string]string, len(a.ImportAliases)+len(b.ImportAliases)),
+		FQNs:               sortedUnion(a.FQNs, b.FQNs),
+		ResourceReferences: orderedUnion(a.ResourceReferences, b.ResourceReferences),
+		DiAnnotations:      orderedUnion(a.DiAnnotations, b.DiAnnotations),
+		ReifiedTypeRefs:    sortedUnion(a.ReifiedTypeRefs, b.ReifiedTypeRefs),
+		OptInMarkers:       sortedUnion(a.OptInMarkers, b.OptInMarkers),
+		DeclaredTypes:      sortedUnion(a.DeclaredTypes, b.DeclaredTypes),
+		Annotations:        sortedUnion(a.Annotations, b.Annotations),
+		FilePath:           a.FilePath,
+		IsTest:             a.IsTest || b.IsTest,
+		CodeStartLine:      a.CodeStartLine,
+		HasSyntaxErrors:    a.HasSyntaxErrors || b.HasSyntaxErrors,
+		IsPlatformSpecific: a.IsPlatformSpecific || b.IsPlatformSpecific,
+		UsesCoroutines:     a.UsesCoroutines || b.UsesCoroutines,
+	}
+	if len(a.DeclarationKinds)+len(b.DeclarationKinds) > 0 {
+		merged.DeclarationKinds = make(map[string]string, len(a.DeclarationKinds)+len(b.DeclarationKinds))
+		maps.Copy(merged.DeclarationKinds, b.DeclarationKinds)
+		maps.Copy(merged.DeclarationKinds, a.DeclarationKinds)
+	}
+	if len(a.ActualTypeAliases)+len(b.ActualTypeAliases) > 0 {
+		merged.ActualTypeAliases = make(map[string]string, len(a.ActualTypeAliases)+len(b.ActualTypeAliases))
+		maps.Copy(merged.ActualTypeAliases, b.ActualTypeAliases)
+		maps.Copy(merged.ActualTypeAliases, a.ActualTypeAliases)
+	}
+	if merged.Package == "" {
+		merged.Package = b.Package
+	}
+	if merged.FilePath == "" {
+		merged.FilePath = b.FilePath
+	}
+	if merged.CodeStartLine == 0 {
+		merged.CodeStartLine = b.CodeStartLine
+	}
+	maps.Copy(merged.ImportAliases, b.ImportAliases)
+	maps.Copy(merged.ImportAliases, a.ImportAliases)
+
+	merged.AllDependencies = buildAllDependencies(merged)
+	return merged
+}
+
 // -----------------------------------------------------------------------------
 // Helpers
 // -----------------------------------------------------------------------------
 
+// sortedUnion returns the deduplicated, sorted union of a and b.
+func sortedUnion(a, b []string) []string {
+	set := toStringSet(a)
+	for _, s := range b {
+		set[s] = struct{}{}
+	}
+	return slices.Sorted(maps.Keys(set))
+}
+
+// orderedUnion returns a followed by the entries of b that a lacks, without
+// duplicates, keeping the order of first appearance.
+func orderedUnion(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	var union []string
+	for _, s := range slices.Concat(a, b) {
+		if _, ok := seen[s]; !ok {
+			seen[s] = struct{}{}
+			union = append(union, s)
+		}
+	}
+	return union
+}
+
 // toStringSet converts a slice to a set for efficient lookup.
 func toStringSet(slice []string) map[string]struct{} {
 	set := make(map[string]struct{}, len(slice))
@@ -821,11 +1444,11 @@ func sortedDifference(a, b map[string]struct{}) []string {
 	return diff
 }
 
-// readFileContent reads a file as string.
+// readFileContent reads a file as a valid UTF-8 string.
 func readFileContent(path string) (string, error) {
-	content, err := os.ReadFile(path)
+	content, err := util.ReadSourceFile(path)
 	if err != nil {
-		return "", fmt.Errorf("read file %s: %w", path, err)
+		return "", fmt.Errorf("read file: %w", err)
 	}
-	return string(content), nil
+	return content, nil
 }
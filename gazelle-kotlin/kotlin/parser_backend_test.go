@@ -2,12 +2,16 @@ package kotlin
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+	"github.com/albertocavalcante/bazelle/pkg/util"
 )
 
 var ctx = context.Background()
@@ -129,6 +133,652 @@ class Foo
 	}
 }
 
+func TestTreeSitterBackend_DiAnnotations(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	cfg := DefaultBackendConfig()
+	cfg.DiAnnotations = []string{"Inject"}
+	backend, err := NewTreeSitterBackend(cfg)
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := "package com.example\n\nclass Foo @Inject constructor(val bar: Bar)\n"
+	result, err := backend.ParseContent(ctx, content, "Foo.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if want := []string{"Inject"}; !reflect.DeepEqual(result.DiAnnotations, want) {
+		t.Errorf("DiAnnotations = %v, want %v", result.DiAnnotations, want)
+	}
+}
+
+func TestTreeSitterBackend_ReifiedInlineFunction(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := "package com.example\n\ninline fun <reified T> typeName(): String =\n    acme.Names.of<T>(com.example.model.User::class)\n"
+	result, err := backend.ParseContent(ctx, content, "Names.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if want := []string{"acme.Names", "com.example.model.User"}; !reflect.DeepEqual(result.ReifiedTypeRefs, want) {
+		t.Errorf("ReifiedTypeRefs = %v, want %v", result.ReifiedTypeRefs, want)
+	}
+	if !slices.Contains(result.AllDependencies, "com.example.model.User") {
+		t.Errorf("AllDependencies = %v, want com.example.model.User", result.AllDependencies)
+	}
+}
+
+func TestTreeSitterBackend_OptInMarker(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := "package com.example\n\n@OptIn(kotlinx.coroutines.ExperimentalCoroutinesApi::class)\nfun launch() = Unit\n"
+	result, err := backend.ParseContent(ctx, content, "Launch.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if want := []string{"kotlinx.coroutines.ExperimentalCoroutinesApi"}; !reflect.DeepEqual(result.OptInMarkers, want) {
+		t.Errorf("OptInMarkers = %v, want %v", result.OptInMarkers, want)
+	}
+	if !slices.Contains(result.AllDependencies, "kotlinx.coroutines.ExperimentalCoroutinesApi") {
+		t.Errorf("AllDependencies = %v, want kotlinx.coroutines.ExperimentalCoroutinesApi", result.AllDependencies)
+	}
+}
+
+func TestTreeSitterBackend_UsesCoroutines(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := "package com.example\n\nclass Api {\n    suspend fun fetch(): String = \"\"\n}\n"
+	result, err := backend.ParseContent(ctx, content, "Api.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if !result.UsesCoroutines {
+		t.Error("UsesCoroutines = false, want true for a suspend member")
+	}
+}
+
+func TestTreeSitterBackend_DeclaredTypes(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := "package com.example\n\nclass Outer {\n    class Inner\n    companion object\n}\n\nobject Registry\n"
+	result, err := backend.ParseContent(ctx, content, "Outer.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if want := []string{"Outer", "Registry"}; !reflect.DeepEqual(result.DeclaredTypes, want) {
+		t.Errorf("DeclaredTypes = %v, want %v", result.DeclaredTypes, want)
+	}
+}
+
+func TestTreeSitterBackend_ActualTypeAlias(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := "package com.example\n\nactual typealias Instant = platform.Foundation.NSDate\n"
+	result, err := backend.ParseContent(ctx, content, "Instant.ios.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if want := map[string]string{"Instant": "platform.Foundation.NSDate"}; !reflect.DeepEqual(result.ActualTypeAliases, want) {
+		t.Errorf("ActualTypeAliases = %v, want %v", result.ActualTypeAliases, want)
+	}
+	if !result.IsPlatformSpecific {
+		t.Error("IsPlatformSpecific = false, want true")
+	}
+	if !slices.Contains(result.AllDependencies, "platform.Foundation.NSDate") {
+		t.Errorf("AllDependencies = %v, want platform.Foundation.NSDate", result.AllDependencies)
+	}
+}
+
+func TestTreeSitterBackend_CRLFMatchesLF(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	cfg := DefaultBackendConfig()
+	cfg.DiAnnotations = []string{"Inject", "Singleton"}
+	backend, err := NewTreeSitterBackend(cfg)
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	lf, err := backend.ParseContent(ctx, crlfTestContent, "Sample.kt")
+	if err != nil {
+		t.Fatalf("ParseContent(LF) failed: %v", err)
+	}
+	crlf, err := backend.ParseContent(ctx, strings.ReplaceAll(crlfTestContent, "\n", "\r\n"), "Sample.kt")
+	if err != nil {
+		t.Fatalf("ParseContent(CRLF) failed: %v", err)
+	}
+	if !reflect.DeepEqual(crlf, lf) {
+		t.Errorf("CRLF result differs from LF:\n got: %+v\nwant: %+v", crlf, lf)
+	}
+}
+
+func TestTreeSitterBackend_ParseErrorContext(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	_, err = backend.ParseFile(ctx, "does/not/exist.kt")
+	var pe *util.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("ParseFile error = %v, want a *util.ParseError", err)
+	}
+	if pe.Path != "does/not/exist.kt" || pe.Backend != string(BackendTreeSitter) {
+		t.Errorf("ParseError = %+v, want path does/not/exist.kt, backend treesitter", pe)
+	}
+}
+
+func TestTreeSitterBackend_DeclarationKinds(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := `package com.example
+
+import kotlinx.serialization.Serializable
+
+@Serializable
+data class User(val name: String)
+
+enum class Color { RED, GREEN }
+
+sealed class Shape {
+    object Empty : Shape()
+    private data class Circle(val r: Double) : Shape()
+}
+
+class Plain {
+    companion object {
+        fun create() = Plain()
+    }
+}
+
+abstract class Base
+
+interface Repository
+
+sealed interface Event
+
+object Registry
+
+fun helper() {
+    class Local
+}
+`
+
+	result, err := backend.ParseContent(ctx, content, "Models.kt")
+	if err != nil || result == nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"User":            "data class",
+		"Color":           "enum class",
+		"Shape":           "sealed class",
+		"Shape.Empty":     "object",
+		"Shape.Circle":    "data class",
+		"Plain":           "class",
+		"Plain.Companion": "companion object",
+		"Base":            "class",
+		"Repository":      "interface",
+		"Event":           "sealed interface",
+		"Registry":        "object",
+	}
+	if !reflect.DeepEqual(result.DeclarationKinds, expected) {
+		t.Errorf("DeclarationKinds:\n  got:  %v\n  want: %v", result.DeclarationKinds, expected)
+	}
+}
+
+func TestBackends_WrappedImport(t *testing.T) {
+	content := `package com.example
+
+import com.example
+    .util
+    .Strings
+import com.example.model
+    .*
+import org.junit.Test
+
+class Foo
+`
+	wantImports := []string{"com.example.util.Strings", "org.junit.Test"}
+	wantStars := []string{"com.example.model"}
+
+	check := func(t *testing.T, result *ParseResult) {
+		t.Helper()
+		imports := slices.Sorted(slices.Values(result.Imports))
+		if !reflect.DeepEqual(imports, wantImports) {
+			t.Errorf("Imports: expected %v, got %v", wantImports, imports)
+		}
+		if !reflect.DeepEqual(result.StarImports, wantStars) {
+			t.Errorf("StarImports: expected %v, got %v", wantStars, result.StarImports)
+		}
+		if result.Package != "com.example" {
+			t.Errorf("Package: expected com.example, got %q", result.Package)
+		}
+	}
+
+	t.Run("heuristic", func(t *testing.T) {
+		result, err := NewHeuristicBackend(DefaultBackendConfig()).ParseContent(ctx, content, "Foo.kt")
+		if err != nil {
+			t.Fatalf("ParseContent failed: %v", err)
+		}
+		check(t, result)
+		if result.CodeStartLine != 10 {
+			t.Errorf("CodeStartLine: expected 10, got %d", result.CodeStartLine)
+		}
+	})
+
+	t.Run("treesitter", func(t *testing.T) {
+		if len(treesitter.AvailableBackends()) == 0 {
+			t.Skip("No tree-sitter backends available")
+		}
+		backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+		if err != nil {
+			t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+		}
+		defer backend.Close()
+
+		result, err := backend.ParseContent(ctx, content, "Foo.kt")
+		if err != nil {
+			t.Fatalf("ParseContent failed: %v", err)
+		}
+		check(t, result)
+	})
+}
+
+func TestBackends_PackageTrailingSemicolonOrComment(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"semicolon", "package com.example;"},
+		{"line comment", "package com.example // the example package"},
+		{"block comment", "package com.example /* generated */"},
+		{"semicolon and comment", "package com.example; // trailing"},
+		{"space before semicolon", "package com.example ;"},
+	}
+
+	var tsBackend *TreeSitterBackend
+	if len(treesitter.AvailableBackends()) > 0 {
+		backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+		if err != nil {
+			t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+		}
+		defer backend.Close()
+		tsBackend = backend
+	}
+
+	for _, tt := range tests {
+		content := tt.header + "\n\nimport org.junit.Test\n\nclass Foo\n"
+		check := func(t *testing.T, result *ParseResult) {
+			t.Helper()
+			if result.Package != "com.example" {
+				t.Errorf("Package: expected com.example, got %q", result.Package)
+			}
+			if !reflect.DeepEqual(result.Imports, []string{"org.junit.Test"}) {
+				t.Errorf("Imports: expected [org.junit.Test], got %v", result.Imports)
+			}
+		}
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("heuristic", func(t *testing.T) {
+				result, err := NewHeuristicBackend(DefaultBackendConfig()).ParseContent(ctx, content, "Foo.kt")
+				if err != nil {
+					t.Fatalf("ParseContent failed: %v", err)
+				}
+				check(t, result)
+			})
+
+			t.Run("treesitter", func(t *testing.T) {
+				if tsBackend == nil {
+					t.Skip("No tree-sitter backends available")
+				}
+				result, err := tsBackend.ParseContent(ctx, content, "Foo.kt")
+				if err != nil {
+					t.Fatalf("ParseContent failed: %v", err)
+				}
+				check(t, result)
+			})
+		})
+	}
+}
+
+func TestBackends_SupertypeFQNs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "anonymous object implementing qualified interface",
+			content: `package app
+
+fun listen() {
+    bus.register(object : acme.events.Listener {
+        override fun onEvent() {}
+    })
+}
+`,
+			want: []string{"acme.events.Listener"},
+		},
+		{
+			name: "class extending qualified base",
+			content: `package app
+
+class Service(name: String) : acme.core.BaseService(name), acme.core.Closeable {
+}
+`,
+			want: []string{"acme.core.BaseService", "acme.core.Closeable"},
+		},
+		{
+			name: "generic and nested supertypes",
+			content: `package app
+
+object Handlers : acme.events.Handler<String>, acme.events.Registry.Entry
+`,
+			want: []string{"acme.events.Handler", "acme.events.Registry"},
+		},
+		{
+			name: "unqualified and stdlib supertypes ignored",
+			content: `package app
+
+class Local : Base(), java.io.Serializable, Outer.Inner
+`,
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Run("heuristic", func(t *testing.T) {
+				result, err := NewHeuristicBackend(DefaultBackendConfig()).ParseContent(ctx, tt.content, "App.kt")
+				if err != nil {
+					t.Fatalf("ParseContent failed: %v", err)
+				}
+				if !reflect.DeepEqual(result.FQNs, tt.want) {
+					t.Errorf("FQNs: expected %v, got %v", tt.want, result.FQNs)
+				}
+			})
+
+			t.Run("treesitter", func(t *testing.T) {
+				if len(treesitter.AvailableBackends()) == 0 {
+					t.Skip("No tree-sitter backends available")
+				}
+				backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+				if err != nil {
+					t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+				}
+				defer backend.Close()
+
+				result, err := backend.ParseContent(ctx, tt.content, "App.kt")
+				if err != nil {
+					t.Fatalf("ParseContent failed: %v", err)
+				}
+				if !reflect.DeepEqual(result.FQNs, tt.want) {
+					t.Errorf("FQNs: expected %v, got %v", tt.want, result.FQNs)
+				}
+			})
+		})
+	}
+}
+
+func TestBackends_DelegationFQNs(t *testing.T) {
+	content := `package app
+
+import acme.api.Repository
+
+class CachedRepository(cache: Cache) : Repository by acme.impl.DefaultRepository(), Closeable by acme.impl.NoopCloser {
+    val timeout by acme.config.Settings.duration()
+    val local by lazy { 42 }
+}
+`
+	want := []string{"acme.config.Settings", "acme.impl.DefaultRepository", "acme.impl.NoopCloser"}
+
+	check := func(t *testing.T, result *ParseResult) {
+		t.Helper()
+		if !reflect.DeepEqual(result.FQNs, want) {
+			t.Errorf("FQNs: expected %v, got %v", want, result.FQNs)
+		}
+		for _, fqn := range want {
+			if !slices.Contains(result.AllDependencies, fqn) {
+				t.Errorf("AllDependencies missing delegated %s: %v", fqn, result.AllDependencies)
+			}
+		}
+	}
+
+	t.Run("heuristic", func(t *testing.T) {
+		result, err := NewHeuristicBackend(DefaultBackendConfig()).ParseContent(ctx, content, "CachedRepository.kt")
+		if err != nil {
+			t.Fatalf("ParseContent failed: %v", err)
+		}
+		check(t, result)
+	})
+
+	t.Run("treesitter", func(t *testing.T) {
+		if len(treesitter.AvailableBackends()) == 0 {
+			t.Skip("No tree-sitter backends available")
+		}
+		backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+		if err != nil {
+			t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+		}
+		defer backend.Close()
+
+		result, err := backend.ParseContent(ctx, content, "CachedRepository.kt")
+		if err != nil {
+			t.Fatalf("ParseContent failed: %v", err)
+		}
+		check(t, result)
+	})
+}
+
+func TestBackends_ConstantHolderFQNs(t *testing.T) {
+	content := `package app
+
+const val LOCAL_LIMIT = 10
+
+fun check(size: Int): Boolean {
+    if (size > acme.config.Limits.MAX_SIZE) return false
+    log(com.example.Constants.TAG, acme.config.Outer.Nested.DEFAULT)
+    return size != LOCAL_LIMIT
+}
+`
+	want := []string{"acme.config.Limits", "acme.config.Outer", "com.example.Constants"}
+
+	check := func(t *testing.T, result *ParseResult) {
+		t.Helper()
+		if !reflect.DeepEqual(result.FQNs, want) {
+			t.Errorf("FQNs: expected %v, got %v", want, result.FQNs)
+		}
+		for _, fqn := range want {
+			if !slices.Contains(result.AllDependencies, fqn) {
+				t.Errorf("AllDependencies missing constant holder %s: %v", fqn, result.AllDependencies)
+			}
+		}
+	}
+
+	t.Run("heuristic", func(t *testing.T) {
+		result, err := NewHeuristicBackend(DefaultBackendConfig()).ParseContent(ctx, content, "Check.kt")
+		if err != nil {
+			t.Fatalf("ParseContent failed: %v", err)
+		}
+		check(t, result)
+	})
+
+	t.Run("treesitter", func(t *testing.T) {
+		if len(treesitter.AvailableBackends()) == 0 {
+			t.Skip("No tree-sitter backends available")
+		}
+		backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+		if err != nil {
+			t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+		}
+		defer backend.Close()
+
+		result, err := backend.ParseContent(ctx, content, "Check.kt")
+		if err != nil {
+			t.Fatalf("ParseContent failed: %v", err)
+		}
+		check(t, result)
+	})
+}
+
+func TestTreeSitterBackend_MultilineSupertypeList(t *testing.T) {
+	if len(treesitter.AvailableBackends()) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := `package app
+
+class Service :
+    acme.core.BaseService(),
+    acme.core.Closeable
+`
+	result, err := backend.ParseContent(ctx, content, "Service.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	want := []string{"acme.core.BaseService", "acme.core.Closeable"}
+	if !reflect.DeepEqual(result.FQNs, want) {
+		t.Errorf("FQNs: expected %v, got %v", want, result.FQNs)
+	}
+}
+
+func TestTreeSitterBackend_SyntaxErrorPartialResult(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	cfg := DefaultBackendConfig()
+	backend, err := NewTreeSitterBackend(cfg)
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	// The class body is left unterminated mid-edit.
+	content := `package com.example
+
+import com.example.foo.Bar
+import org.junit.Test
+
+class Foo {
+    fun broken( {
+        val x = 
+`
+
+	result, err := backend.ParseContent(ctx, content, "Foo.kt")
+	if err != nil || result == nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	if !result.HasSyntaxErrors {
+		t.Error("expected HasSyntaxErrors to be set")
+	}
+	if result.Package != "com.example" {
+		t.Errorf("Package: expected com.example, got %q", result.Package)
+	}
+	expected := []string{"com.example.foo.Bar", "org.junit.Test"}
+	if !reflect.DeepEqual(result.Imports, expected) {
+		t.Errorf("Imports: expected %v, got %v", expected, result.Imports)
+	}
+}
+
+func TestTreeSitterBackend_CleanSourceHasNoSyntaxErrors(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	result, err := backend.ParseContent(ctx, "package com.example\n\nclass Foo\n", "Foo.kt")
+	if err != nil || result == nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if result.HasSyntaxErrors {
+		t.Error("expected HasSyntaxErrors to be false for valid source")
+	}
+}
+
 func TestTreeSitterBackend_ImportAlias(t *testing.T) {
 	backends := treesitter.AvailableBackends()
 	if len(backends) == 0 {
@@ -164,6 +814,53 @@ class Foo
 	}
 }
 
+func TestBackends_SemicolonSeparatedImports(t *testing.T) {
+	content := "package com.example; import com.example.a.First; import com.example.b.Second\n" +
+		"import com.example.c.*; import com.example.d.Fourth as Fourth4\n" +
+		"\n" +
+		"class Foo\n"
+	wantImports := []string{"com.example.a.First", "com.example.b.Second", "com.example.d.Fourth"}
+
+	check := func(t *testing.T, backend ParserBackend) {
+		t.Helper()
+		result, err := backend.ParseContent(ctx, content, "Foo.kt")
+		if err != nil || result == nil {
+			t.Fatalf("ParseContent failed: %v", err)
+		}
+		if result.Package != "com.example" {
+			t.Errorf("Package: expected com.example, got %q", result.Package)
+		}
+		imports := slices.Sorted(slices.Values(result.Imports))
+		if !reflect.DeepEqual(imports, wantImports) {
+			t.Errorf("Imports: expected %v, got %v", wantImports, imports)
+		}
+		if want := []string{"com.example.c"}; !reflect.DeepEqual(result.StarImports, want) {
+			t.Errorf("StarImports: expected %v, got %v", want, result.StarImports)
+		}
+		if got := result.ImportAliases["Fourth4"]; got != "com.example.d.Fourth" {
+			t.Errorf("ImportAliases: expected Fourth4 -> com.example.d.Fourth, got %v", result.ImportAliases)
+		}
+	}
+
+	t.Run("heuristic", func(t *testing.T) {
+		backend := NewHeuristicBackend(DefaultBackendConfig())
+		defer backend.Close()
+		check(t, backend)
+	})
+
+	t.Run("tree-sitter", func(t *testing.T) {
+		if len(treesitter.AvailableBackends()) == 0 {
+			t.Skip("No tree-sitter backends available")
+		}
+		backend, err := NewTreeSitterBackend(DefaultBackendConfig())
+		if err != nil || backend == nil {
+			t.Fatalf("Failed to create TreeSitterBackend: %v", err)
+		}
+		defer backend.Close()
+		check(t, backend)
+	})
+}
+
 func TestHybridBackend_ComparesResults(t *testing.T) {
 	backends := treesitter.AvailableBackends()
 	if len(backends) == 0 {
@@ -196,6 +893,92 @@ class MyTest
 	}
 }
 
+// recordingBackend stands in for tree-sitter in sampling tests. It parses
+// like the heuristic backend but reports an extra import for Diverge.kt, and
+// counts how often it is invoked.
+type recordingBackend struct {
+	*HeuristicBackend
+	calls atomic.Int64
+}
+
+func (b *recordingBackend) ParseContent(ctx context.Context, content, path string) (*ParseResult, error) {
+	b.calls.Add(1)
+	result, err := b.HeuristicBackend.ParseContent(ctx, content, path)
+	if err == nil && path == "Diverge.kt" {
+		result.Imports = append(result.Imports, "com.example.Missed")
+	}
+	return result, err
+}
+
+func TestHeuristicBackend_Sampling(t *testing.T) {
+	files := []string{"A.kt", "B.kt", "Diverge.kt"}
+	content := "package com.example\n\nimport com.example.util.Strings\n\nclass A\n"
+
+	tests := []struct {
+		name         string
+		rate         float64
+		wantCalls    int64
+		wantSampled  int64
+		wantDiverged int64
+	}{
+		{name: "every file", rate: 1.0, wantCalls: 3, wantSampled: 3, wantDiverged: 1},
+		{name: "no files", rate: 0.0, wantCalls: 0, wantSampled: 0, wantDiverged: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := &recordingBackend{HeuristicBackend: NewHeuristicBackend(DefaultBackendConfig())}
+			backend := NewHeuristicBackend(DefaultBackendConfig())
+			backend.sampler = newDivergenceSampler(tt.rate, verifier)
+
+			for _, path := range files {
+				result, err := backend.ParseContent(ctx, content, path)
+				if err != nil {
+					t.Fatalf("ParseContent(%s) failed: %v", path, err)
+				}
+				// The heuristic result is returned untouched.
+				if !slices.Equal(result.Imports, []string{"com.example.util.Strings"}) {
+					t.Errorf("ParseContent(%s) imports = %v", path, result.Imports)
+				}
+			}
+
+			if got := verifier.calls.Load(); got != tt.wantCalls {
+				t.Errorf("verifier called %d times, want %d", got, tt.wantCalls)
+			}
+			want := SampleStats{Sampled: tt.wantSampled, Diverged: tt.wantDiverged}
+			if got := backend.SampleStats(); got != want {
+				t.Errorf("SampleStats() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestHeuristicBackend_SamplingConfig(t *testing.T) {
+	if got := NewHeuristicBackend(DefaultBackendConfig()); got.sampler != nil {
+		t.Error("sampling should be disabled by default")
+	}
+
+	if len(treesitter.AvailableBackends()) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	cfg := DefaultBackendConfig()
+	cfg.HeuristicSampleRate = 1.0
+	backend := NewHeuristicBackend(cfg)
+	defer backend.Close()
+	if backend.sampler == nil {
+		t.Fatal("HeuristicSampleRate 1.0 should enable sampling")
+	}
+
+	content := "package com.example\n\nimport com.example.util.Strings\n\nclass A\n"
+	if _, err := backend.ParseContent(ctx, content, "A.kt"); err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if got, want := backend.SampleStats(), (SampleStats{Sampled: 1}); got != want {
+		t.Errorf("SampleStats() = %+v, want %+v", got, want)
+	}
+}
+
 func TestHybridBackend_UsesPrimary(t *testing.T) {
 	backends := treesitter.AvailableBackends()
 	if len(backends) == 0 {
@@ -240,6 +1023,230 @@ class Foo
 	}
 }
 
+func TestHybridBackend_Union(t *testing.T) {
+	backends := treesitter.AvailableBackends()
+	if len(backends) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	cfg := DefaultBackendConfig()
+	cfg.HybridMerge = HybridUnion
+	cfg.HybridLogDiffs = false
+
+	backend, err := NewHybridBackend(cfg)
+	if err != nil || backend == nil {
+		t.Fatalf("Failed to create HybridBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := `package com.example
+
+import kotlin.test.Test
+import com.example.util.*
+
+class Foo
+`
+	result, err := backend.ParseContent(ctx, content, "Test.kt")
+	if err != nil || result == nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	if result.Package != "com.example" {
+		t.Errorf("Package: expected 'com.example', got '%s'", result.Package)
+	}
+	if !slices.Equal(result.Imports, []string{"kotlin.test.Test"}) {
+		t.Errorf("Imports: expected [kotlin.test.Test], got %v", result.Imports)
+	}
+	if !slices.Equal(result.StarImports, []string{"com.example.util"}) {
+		t.Errorf("StarImports: expected [com.example.util], got %v", result.StarImports)
+	}
+}
+
+// memoTreeCache is a treesitter.TreeCache keeping one tree per content. It
+// counts the parses and the releases of the trees it hands out.
+type memoTreeCache struct {
+	trees    map[string]treesitter.Tree
+	parses   int
+	releases int
+}
+
+func (c *memoTreeCache) GetOrParse(ctx context.Context, parser treesitter.Parser, path string, content []byte) (treesitter.Tree, func(), error) {
+	key := path + "\x00" + string(content)
+	tree, ok := c.trees[key]
+	if !ok {
+		var err error
+		if tree, err = parser.Parse(ctx, content); err != nil {
+			return nil, nil, err
+		}
+		c.trees[key] = tree
+		c.parses++
+	}
+	return tree, func() { c.releases++ }, nil
+}
+
+func TestTreeSitterBackend_TreeCache(t *testing.T) {
+	if len(treesitter.AvailableBackends()) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	trees := &memoTreeCache{trees: make(map[string]treesitter.Tree)}
+	t.Cleanup(func() {
+		for _, tree := range trees.trees {
+			_ = tree.Close()
+		}
+	})
+	cfg := DefaultBackendConfig()
+	cfg.TreeCache = trees
+	backend, err := NewTreeSitterBackend(cfg)
+	if err != nil {
+		t.Fatalf("NewTreeSitterBackend: %v", err)
+	}
+	defer backend.Close()
+
+	content := "package com.example\n\nimport com.example.util.Strings\n\nclass Cached\n"
+	for range 2 {
+		result, err := backend.ParseContent(ctx, content, "Cached.kt")
+		if err != nil {
+			t.Fatalf("ParseContent: %v", err)
+		}
+		if !slices.Equal(result.Imports, []string{"com.example.util.Strings"}) {
+			t.Errorf("Imports = %v, want [com.example.util.Strings]", result.Imports)
+		}
+	}
+	// The second parse is served from the cache, and both trees released
+	if trees.parses != 1 || trees.releases != 2 {
+		t.Errorf("parses = %d, releases = %d, want 1 parse and 2 releases", trees.parses, trees.releases)
+	}
+}
+
+func TestAdaptiveBackend_BySize(t *testing.T) {
+	if len(treesitter.AvailableBackends()) == 0 {
+		t.Skip("No tree-sitter backends available")
+	}
+
+	small := "package com.example\n\nimport com.example.util.Strings\n\nclass Small\n"
+	var large strings.Builder
+	large.WriteString("package com.example.generated\n\nimport com.example.util.Strings\n\n")
+	for i := range 100 {
+		fmt.Fprintf(&large, "val field%d = %d\n", i, i)
+	}
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		maxLines int
+		content  string
+		pkg      string
+		want     AdaptiveStats
+	}{
+		{"small file", 1024, 50, small, "com.example", AdaptiveStats{Heuristic: 1}},
+		{"over line threshold", 0, 50, large.String(), "com.example.generated", AdaptiveStats{TreeSitter: 1}},
+		{"over byte threshold", 1024, 0, large.String(), "com.example.generated", AdaptiveStats{TreeSitter: 1}},
+		{"thresholds disabled", 0, 0, large.String(), "com.example.generated", AdaptiveStats{Heuristic: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultBackendConfig()
+			cfg.AdaptiveMaxBytes = tt.maxBytes
+			cfg.AdaptiveMaxLines = tt.maxLines
+			backend, err := NewAdaptiveBackend(cfg)
+			if err != nil {
+				t.Fatalf("NewAdaptiveBackend: %v", err)
+			}
+			defer backend.Close()
+
+			result, err := backend.ParseContent(ctx, tt.content, "Test.kt")
+			if err != nil {
+				t.Fatalf("ParseContent: %v", err)
+			}
+			if result.Package != tt.pkg {
+				t.Errorf("Package = %q, want %q", result.Package, tt.pkg)
+			}
+			if !slices.Equal(result.Imports, []string{"com.example.util.Strings"}) {
+				t.Errorf("Imports = %v, want [com.example.util.Strings]", result.Imports)
+			}
+			if got := backend.Stats(); got != tt.want {
+				t.Errorf("Stats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	// Each backend found an import the other missed.
+	ts := &ParseResult{
+		Package:       "com.example",
+		Imports:       []string{"com.example.Shared", "com.example.OnlyTreeSitter"},
+		StarImports:   []string{"com.example.util"},
+		ImportAliases: map[string]string{"Alias": "com.example.Shared"},
+		FQNs:          []string{},
+		FilePath:      "Foo.kt",
+		CodeStartLine: 5,
+
+		ResourceReferences: []string{"z.txt", "a.txt"},
+		DiAnnotations:      []string{"Inject", "HiltAndroidApp"},
+
+		DeclarationKinds: map[string]string{"Foo": "data class"},
+	}
+	h := &ParseResult{
+		Package:       "com.example",
+		Imports:       []string{"com.example.OnlyHeuristic", "com.example.Shared"},
+		StarImports:   []string{"com.example.util"},
+		ImportAliases: map[string]string{"Alias": "com.example.Other", "H": "com.example.OnlyHeuristic"},
+		FQNs:          []string{"org.lib.Inline"},
+		Annotations:   []string{"JvmName"},
+		FilePath:      "Foo.kt",
+		CodeStartLine: 4,
+
+		ResourceReferences: []string{"m.txt", "a.txt"},
+		DiAnnotations:      []string{"Inject", "AndroidEntryPoint"},
+	}
+
+	merged := mergeResults(ts, h)
+
+	wantImports := []string{"com.example.OnlyHeuristic", "com.example.OnlyTreeSitter", "com.example.Shared"}
+	if !slices.Equal(merged.Imports, wantImports) {
+		t.Errorf("Imports: expected %v, got %v", wantImports, merged.Imports)
+	}
+	if !slices.Equal(merged.StarImports, []string{"com.example.util"}) {
+		t.Errorf("StarImports: expected [com.example.util], got %v", merged.StarImports)
+	}
+	if !slices.Equal(merged.FQNs, []string{"org.lib.Inline"}) {
+		t.Errorf("FQNs: expected [org.lib.Inline], got %v", merged.FQNs)
+	}
+	if !slices.Equal(merged.Annotations, []string{"JvmName"}) {
+		t.Errorf("Annotations: expected [JvmName], got %v", merged.Annotations)
+	}
+	// Resource references keep their order of first appearance
+	wantResources := []string{"z.txt", "a.txt", "m.txt"}
+	if !slices.Equal(merged.ResourceReferences, wantResources) {
+		t.Errorf("ResourceReferences: expected %v, got %v", wantResources, merged.ResourceReferences)
+	}
+	// So do DI annotations
+	wantDi := []string{"Inject", "HiltAndroidApp", "AndroidEntryPoint"}
+	if !slices.Equal(merged.DiAnnotations, wantDi) {
+		t.Errorf("DiAnnotations: expected %v, got %v", wantDi, merged.DiAnnotations)
+	}
+	if merged.DeclarationKinds["Foo"] != "data class" {
+		t.Errorf("DeclarationKinds: expected Foo to be kept, got %v", merged.DeclarationKinds)
+	}
+	if merged.ImportAliases["Alias"] != "com.example.Shared" {
+		t.Errorf("Alias: expected first result to win, got %q", merged.ImportAliases["Alias"])
+	}
+	if merged.ImportAliases["H"] != "com.example.OnlyHeuristic" {
+		t.Errorf("Alias H: expected com.example.OnlyHeuristic, got %q", merged.ImportAliases["H"])
+	}
+	if merged.CodeStartLine != 5 {
+		t.Errorf("CodeStartLine: expected 5, got %d", merged.CodeStartLine)
+	}
+
+	wantDeps := []string{"com.example.OnlyHeuristic", "com.example.OnlyTreeSitter", "com.example.Shared", "org.lib.Inline"}
+	if !slices.Equal(merged.AllDependencies, wantDeps) {
+		t.Errorf("AllDependencies: expected %v, got %v", wantDeps, merged.AllDependencies)
+	}
+}
+
 func TestNewParserBackend_InvalidType(t *testing.T) {
 	cfg := DefaultBackendConfig()
 	_, err := NewParserBackend("invalid", cfg)
@@ -254,6 +1261,109 @@ func TestNewParserBackend_InvalidType(t *testing.T) {
 	}
 }
 
+// countedRuntime is a fake tree-sitter runtime that tracks how many of its
+// kind are open. Only Name, SupportsLanguage and Close may be called.
+type countedRuntime struct {
+	treesitter.Backend
+	kotlin bool
+	open   *atomic.Int32
+}
+
+func (r *countedRuntime) Name() string { return "counted" }
+
+func (r *countedRuntime) SupportsLanguage(lang treesitter.Language) bool {
+	return r.kotlin && lang == treesitter.Kotlin
+}
+
+func (r *countedRuntime) Close() error {
+	r.open.Add(-1)
+	return nil
+}
+
+// countRuntimes makes the backends created during the test use
+// countedRuntimes, supporting Kotlin if kotlin is set, and returns the
+// number left open.
+func countRuntimes(t *testing.T, kotlin bool) *atomic.Int32 {
+	t.Helper()
+	open := &atomic.Int32{}
+	old := newRuntime
+	t.Cleanup(func() { newRuntime = old })
+	newRuntime = func(treesitter.BackendType) (treesitter.Backend, error) {
+		open.Add(1)
+		return &countedRuntime{kotlin: kotlin, open: open}, nil
+	}
+	return open
+}
+
+func TestBackendConstructors_CloseOnError(t *testing.T) {
+	constructors := []struct {
+		name string
+		new  func(BackendConfig) (ParserBackend, error)
+	}{
+		{"NewTreeSitterBackend", func(cfg BackendConfig) (ParserBackend, error) { return NewTreeSitterBackend(cfg) }},
+		{"NewHybridBackend", func(cfg BackendConfig) (ParserBackend, error) { return NewHybridBackend(cfg) }},
+		{"NewParserBackend tree-sitter", func(cfg BackendConfig) (ParserBackend, error) {
+			return NewParserBackend(BackendTreeSitter, cfg)
+		}},
+		{"NewParserBackend hybrid", func(cfg BackendConfig) (ParserBackend, error) {
+			return NewParserBackend(BackendHybrid, cfg)
+		}},
+		{"NewParserBackend adaptive", func(cfg BackendConfig) (ParserBackend, error) {
+			return NewParserBackend(BackendAdaptive, cfg)
+		}},
+	}
+
+	for _, c := range constructors {
+		t.Run(c.name, func(t *testing.T) {
+			open := countRuntimes(t, false)
+			_, err := c.new(DefaultBackendConfig())
+
+			var unsupported ErrLanguageNotSupported
+			if !errors.As(err, &unsupported) {
+				t.Fatalf("error = %v, want ErrLanguageNotSupported", err)
+			}
+			if n := open.Load(); n != 0 {
+				t.Errorf("%d tree-sitter runtimes left open", n)
+			}
+		})
+	}
+
+	t.Run("NewHeuristicBackend sampling", func(t *testing.T) {
+		open := countRuntimes(t, false)
+		cfg := DefaultBackendConfig()
+		cfg.HeuristicSampleRate = 1.0
+		backend := NewHeuristicBackend(cfg)
+
+		if backend.sampler != nil {
+			t.Error("sampling enabled without Kotlin support")
+		}
+		if n := open.Load(); n != 0 {
+			t.Errorf("%d tree-sitter runtimes left open", n)
+		}
+	})
+}
+
+func TestBackendConstructors_CloseReleasesRuntime(t *testing.T) {
+	for _, typ := range []ParserBackendType{BackendTreeSitter, BackendHybrid, BackendAdaptive} {
+		t.Run(string(typ), func(t *testing.T) {
+			open := countRuntimes(t, true)
+			backend, err := NewParserBackend(typ, DefaultBackendConfig())
+			if err != nil {
+				t.Fatalf("NewParserBackend: %v", err)
+			}
+			if n := open.Load(); n != 1 {
+				t.Fatalf("%d tree-sitter runtimes open, want 1", n)
+			}
+			if err := backend.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+			if n := open.Load(); n != 0 {
+				t.Errorf("%d tree-sitter runtimes left open after Close", n)
+			}
+		})
+	}
+}
+
 func TestBackendConfig_Defaults(t *testing.T) {
 	cfg := DefaultBackendConfig()
 
@@ -263,9 +1373,16 @@ func TestBackendConfig_Defaults(t *testing.T) {
 	if cfg.HybridPrimary != BackendHeuristic {
 		t.Errorf("HybridPrimary should be 'heuristic' by default, got '%s'", cfg.HybridPrimary)
 	}
+	if cfg.HybridMerge != HybridPrimaryOnly {
+		t.Errorf("HybridMerge should be 'primary' by default, got '%s'", cfg.HybridMerge)
+	}
 	if !cfg.HybridLogDiffs {
 		t.Error("HybridLogDiffs should be true by default")
 	}
+	if cfg.AdaptiveMaxBytes != 32*1024 || cfg.AdaptiveMaxLines != 1000 {
+		t.Errorf("Adaptive thresholds should be 32 KiB and 1000 lines by default, got %d bytes and %d lines",
+			cfg.AdaptiveMaxBytes, cfg.AdaptiveMaxLines)
+	}
 }
 
 // TestBackendConsistency verifies that heuristic and tree-sitter produce
@@ -1,12 +1,19 @@
 package kotlin
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
 	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/jvm"
+	"github.com/albertocavalcante/bazelle/pkg/util"
+	"github.com/bazelbuild/bazel-gazelle/label"
 )
 
 func TestParser_ParseFile(t *testing.T) {
@@ -58,6 +65,35 @@ class MyTest {
 	}
 }
 
+func TestParser_ParseFile_InvalidUTF8(t *testing.T) {
+	tmpDir := t.TempDir()
+	ktFile := filepath.Join(tmpDir, "Latin1.kt")
+
+	// "caf\xe9" is latin-1 encoded and not valid UTF-8.
+	content := "package com.example\n\n" +
+		"import com.example.util.Helper\n" +
+		"// caf\xe9 \xff\xfe\n" +
+		"import org.junit.Test\n\n" +
+		"class Foo { val s = \"\xe9t\xe9\" }\n"
+	if err := os.WriteFile(ktFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	parser := NewParser()
+	result, err := parser.ParseFile(ktFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if result.Package != "com.example" {
+		t.Errorf("Expected package 'com.example', got '%s'", result.Package)
+	}
+	expected := []string{"com.example.util.Helper", "org.junit.Test"}
+	if !slices.Equal(result.Imports, expected) {
+		t.Errorf("Expected imports %v, got %v", expected, result.Imports)
+	}
+}
+
 func TestParser_ParseFile_WithComments(t *testing.T) {
 	tmpDir := t.TempDir()
 	ktFile := filepath.Join(tmpDir, "Commented.kt")
@@ -187,6 +223,56 @@ class Test
 	}
 }
 
+func TestParser_SemicolonSeparatedImports(t *testing.T) {
+	parser := NewParser()
+	content := "package com.example.test; import com.example.models.User; import org.json.JSONObject as Json\n" +
+		"import com.example.models.*; import com.example.utils.Helper;\n" +
+		"import com.example.text.`semi;colon`.Name\n" +
+		"\n" +
+		"class Test { val x = 1; val y = 2 }\n"
+	result, err := parser.ParseContent(content, "Test.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	if result.Package != "com.example.test" {
+		t.Errorf("Package: expected com.example.test, got %q", result.Package)
+	}
+	// The backticked name is not matched, as on a line of its own
+	wantImports := []string{"com.example.models.User", "org.json.JSONObject", "com.example.utils.Helper", "com.example.text"}
+	if !reflect.DeepEqual(result.Imports, wantImports) {
+		t.Errorf("Imports: expected %v, got %v", wantImports, result.Imports)
+	}
+	if want := []string{"com.example.models"}; !reflect.DeepEqual(result.StarImports, want) {
+		t.Errorf("Star imports: expected %v, got %v", want, result.StarImports)
+	}
+	if want := map[string]string{"Json": "org.json.JSONObject"}; !reflect.DeepEqual(result.ImportAliases, want) {
+		t.Errorf("Import aliases: expected %v, got %v", want, result.ImportAliases)
+	}
+	if result.CodeStartLine != 5 {
+		t.Errorf("CodeStartLine: expected 5, got %d", result.CodeStartLine)
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"import a.B", []string{"import a.B"}},
+		{"import a.B; import c.D", []string{"import a.B", " import c.D"}},
+		{"import a.B;", []string{"import a.B", ""}},
+		{`@file:JvmName("a;b"); package p`, []string{`@file:JvmName("a;b")`, " package p"}},
+		{`val s = "x\";y"; val t = 1`, []string{`val s = "x\";y"`, " val t = 1"}},
+		{"import a.`b;c`.D; import e.F", []string{"import a.`b;c`.D", " import e.F"}},
+	}
+	for _, tt := range tests {
+		if got := splitStatements(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitStatements(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
 func TestParser_FileAnnotations(t *testing.T) {
 	parser := NewParser()
 	content := `@file:JvmName("MyUtils")
@@ -403,6 +489,43 @@ func TestGetAllDependencies(t *testing.T) {
 	}
 }
 
+func TestGetProductionDependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"src/main/kotlin/Service.kt":     "package com.example\n\nimport io.ktor.client.HttpClient\nimport com.example.model.User\n\nclass Service\n",
+		"src/test/kotlin/ServiceTest.kt": "package com.example\n\nimport org.junit.Test\nimport io.mockk.mockk\nimport com.example.model.User\n\nclass ServiceTest\n",
+	}
+	var paths []string
+	for rel, content := range files {
+		path := filepath.Join(tmpDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	results, err := NewParser().ParseFiles(paths)
+	if err != nil {
+		t.Fatalf("ParseFiles failed: %v", err)
+	}
+
+	expected := []string{"com.example.model.User", "io.ktor.client.HttpClient"}
+	if deps := GetProductionDependencies(results); !reflect.DeepEqual(deps, expected) {
+		t.Errorf("GetProductionDependencies: expected %v, got %v", expected, deps)
+	}
+
+	all := GetAllDependencies(results)
+	if !slices.Contains(all, "org.junit.Test") || !slices.Contains(all, "io.mockk.mockk") {
+		t.Errorf("GetAllDependencies should still include test imports, got %v", all)
+	}
+	if len(results) != 2 {
+		t.Errorf("GetProductionDependencies must not modify its input, got %d results", len(results))
+	}
+}
+
 func TestGetImportInfo(t *testing.T) {
 	result := &ParseResult{
 		Imports:       []string{"com.example.models.User", "org.json.JSONObject"},
@@ -897,6 +1020,44 @@ func TestParser_SingleCharPackage(t *testing.T) {
 	}
 }
 
+func TestSupertypeNames(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"class Foo : a.b.Base()", []string{"a.b.Base"}},
+		{"class Foo(val x: Int, y: a.b.Y) : a.b.Base(x), a.b.Iface {", []string{"a.b.Base", "a.b.Iface"}},
+		{"class Box<T : a.b.Bound>(t: T) : a.b.Holder<T> where T : a.b.Other", []string{"a.b.Holder"}},
+		{"val l = object : a.b.Listener {}", []string{"a.b.Listener"}},
+		{"val x = object : A {}; val y = object : a.b.B {}", []string{"A", "a.b.B"}},
+		{"interface Api : a.b.Base, a.b.Api by impl", []string{"a.b.Base", "a.b.Api"}},
+		{"val k = Foo::class.java", nil},
+		{"class Plain {", nil},
+		{"data object Empty", nil},
+	}
+
+	for _, tt := range tests {
+		if got := supertypeNames(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("supertypeNames(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestOuterClassFQN(t *testing.T) {
+	tests := map[string]string{
+		"a.b.Base":        "a.b.Base",
+		"a.b.Outer.Inner": "a.b.Outer",
+		"Outer.Inner":     "",
+		"Base":            "",
+		"a.b.c":           "",
+	}
+	for name, want := range tests {
+		if got := outerClassFQN(name); got != want {
+			t.Errorf("outerClassFQN(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
 func TestFQNScanner_NegativeCodeStartLine(t *testing.T) {
 	// Tests fix for BUG-001/002: Scanner should handle negative indices gracefully
 	scanner := NewFQNScanner()
@@ -933,6 +1094,69 @@ func TestFQNScanner_CodeStartLineBeyondContent(t *testing.T) {
 	}
 }
 
+func TestFQNScanner_MaxLines(t *testing.T) {
+	content := `package com.example.test
+
+class Generated {
+    val a = com.example.first.First()
+    val b = com.example.second.Second()
+    val c = com.example.third.Third()
+}
+`
+	scanner := NewFQNScanner()
+	scanner.SetMaxLines(3)
+
+	// Lines 2-4 (0-indexed) are scanned; Second sits on the last of them.
+	result := scanner.Scan(content, 2)
+	expected := []string{"com.example.first.First", "com.example.second.Second"}
+	if !reflect.DeepEqual(result.FQNs, expected) {
+		t.Errorf("FQNs: expected %v, got %v", expected, result.FQNs)
+	}
+	if !result.Truncated {
+		t.Error("expected scan past the line limit to be truncated")
+	}
+
+	// A file within the limit is scanned in full.
+	scanner.SetMaxLines(100)
+	result = scanner.Scan(content, 2)
+	if len(result.FQNs) != 3 || result.Truncated {
+		t.Errorf("within limit: FQNs = %v, Truncated = %v, want all 3 FQNs untruncated", result.FQNs, result.Truncated)
+	}
+
+	// A non-positive limit means no limit.
+	scanner.SetMaxLines(-1)
+	if result = scanner.Scan(content, 2); len(result.FQNs) != 3 || result.Truncated {
+		t.Errorf("no limit: FQNs = %v, Truncated = %v, want all 3 FQNs untruncated", result.FQNs, result.Truncated)
+	}
+}
+
+func TestParser_FQNScanMaxLines(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("package com.example.gen\n\nobject Table {\n")
+	for range 50 {
+		b.WriteString("    val row = com.example.rows.Row()\n")
+	}
+	b.WriteString("    val last = com.example.tail.Tail()\n}\n")
+	content := b.String()
+
+	result, err := NewParser(WithFQNScanMaxLines(10)).ParseContent(content, "Table.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if want := []string{"com.example.rows.Row"}; !reflect.DeepEqual(result.FQNs, want) {
+		t.Errorf("FQNs with limit: expected %v, got %v", want, result.FQNs)
+	}
+
+	small := "package com.example.gen\n\nval tail = com.example.tail.Tail()\n"
+	result, err = NewParser(WithFQNScanMaxLines(10)).ParseContent(small, "Small.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if want := []string{"com.example.tail.Tail"}; !reflect.DeepEqual(result.FQNs, want) {
+		t.Errorf("FQNs of small file: expected %v, got %v", want, result.FQNs)
+	}
+}
+
 func TestFQNScanner_FQNInStringTemplate(t *testing.T) {
 	scanner := NewFQNScanner()
 	content := `package com.example.test
@@ -1132,3 +1356,531 @@ func TestCleanFQN_EdgeCases(t *testing.T) {
 		}
 	}
 }
+
+func TestParser_ResourceReferences(t *testing.T) {
+	content := `package com.example
+
+class Config {
+    val yaml = javaClass.getResource("config.yaml")
+    val props = Config::class.java.getResourceAsStream("/app.properties")
+    val again = javaClass.getResource("config.yaml")
+    val dynamic = javaClass.getResource(name)
+    val template = javaClass.getResource("${dir}/x.txt")
+    val other = load("unrelated.txt")
+    // javaClass.getResource("commented.txt")
+}
+`
+
+	p := NewParser(WithResourceLoaders([]string{"getResource", "getResourceAsStream"}))
+	result, err := p.ParseContent(content, "Config.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	expected := []string{"config.yaml", "/app.properties"}
+	if !reflect.DeepEqual(result.ResourceReferences, expected) {
+		t.Errorf("ResourceReferences: expected %v, got %v", expected, result.ResourceReferences)
+	}
+}
+
+func TestParser_ResourceReferencesDisabledByDefault(t *testing.T) {
+	content := "package com.example\n\nval x = javaClass.getResource(\"config.yaml\")\n"
+
+	result, err := NewParser().ParseContent(content, "Config.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if len(result.ResourceReferences) != 0 {
+		t.Errorf("expected no resource references by default, got %v", result.ResourceReferences)
+	}
+}
+
+func TestParser_DiAnnotations(t *testing.T) {
+	content := `package com.example
+
+import javax.inject.Inject
+import javax.inject.Singleton
+
+@Singleton
+class Repository @Inject constructor(private val api: Api) {
+    @field:Inject lateinit var clock: Clock
+    @javax.inject.Named("cache") val cache = Cache()
+    // @Module is commented out
+}
+`
+
+	p := NewParser(WithDiAnnotations([]string{"Inject", "Singleton", "Module", "Named"}))
+	result, err := p.ParseContent(content, "Repository.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	expected := []string{"Singleton", "Inject", "Named"}
+	if !reflect.DeepEqual(result.DiAnnotations, expected) {
+		t.Errorf("DiAnnotations: expected %v, got %v", expected, result.DiAnnotations)
+	}
+}
+
+func TestParser_DiAnnotationsDisabledByDefault(t *testing.T) {
+	content := "package com.example\n\nclass Foo @Inject constructor()\n"
+
+	result, err := NewParser().ParseContent(content, "Foo.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if len(result.DiAnnotations) != 0 {
+		t.Errorf("expected no DI annotations by default, got %v", result.DiAnnotations)
+	}
+}
+
+func TestParser_ActualTypeAlias(t *testing.T) {
+	content := `package com.example.time
+
+import java.util.concurrent.atomic.AtomicReference
+import platform.Foundation.NSDate as PlatformDate
+
+actual typealias AtomicRef<T> = AtomicReference<T>
+public actual typealias Instant = PlatformDate
+actual typealias Lock = java.util.concurrent.locks.ReentrantLock
+actual typealias Entry = platform.posix.dirent.Entry
+// actual typealias Commented = com.example.Ignored
+typealias Local = com.example.time.Clock
+actual typealias Duration = LocalDuration
+`
+
+	result, err := NewParser().ParseContent(content, "Time.ios.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"AtomicRef": "java.util.concurrent.atomic.AtomicReference",
+		"Instant":   "platform.Foundation.NSDate",
+		"Lock":      "java.util.concurrent.locks.ReentrantLock",
+		"Entry":     "platform.posix.dirent.Entry",
+	}
+	if !reflect.DeepEqual(result.ActualTypeAliases, expected) {
+		t.Errorf("ActualTypeAliases: expected %v, got %v", expected, result.ActualTypeAliases)
+	}
+	if !result.IsPlatformSpecific {
+		t.Error("expected file with actual typealias to be platform-specific")
+	}
+	for _, fqn := range expected {
+		if !slices.Contains(result.AllDependencies, fqn) {
+			t.Errorf("AllDependencies %v missing platform type %s", result.AllDependencies, fqn)
+		}
+	}
+	if slices.Contains(result.AllDependencies, "com.example.Ignored") {
+		t.Errorf("AllDependencies %v contains commented-out typealias target", result.AllDependencies)
+	}
+}
+
+func TestParser_TypeAliasNotPlatformSpecific(t *testing.T) {
+	content := "package com.example\n\ntypealias Handler = com.example.events.Listener\n"
+
+	result, err := NewParser().ParseContent(content, "Handler.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if result.IsPlatformSpecific || len(result.ActualTypeAliases) != 0 {
+		t.Errorf("plain typealias: IsPlatformSpecific = %v, ActualTypeAliases = %v", result.IsPlatformSpecific, result.ActualTypeAliases)
+	}
+}
+
+func TestParser_ReifiedInlineFunction(t *testing.T) {
+	content := `package com.example.json
+
+inline fun <reified T : com.example.model.Entity> decode(json: String): T =
+    com.example.codec.Decoder.decode(json, T::class) as T
+
+@PublishedApi
+internal inline fun <reified T> isUser(value: Any): Boolean {
+    // value is com.example.Ignored
+    val type = acme.User::class
+    return value is T && value !is kotlin.collections.List<*>
+}
+
+class Registry {
+    inline fun <reified T> member(): T = error(com.example.member.Member::class)
+}
+
+fun plain(): Any = com.example.model.Account::class
+`
+
+	// FQN scanning would find the three-segment names on its own; the
+	// reified scan must not depend on it.
+	result, err := NewParser(WithFQNScanning(false)).ParseContent(content, "Json.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	expected := []string{"acme.User", "com.example.codec.Decoder", "com.example.model.Entity"}
+	if !reflect.DeepEqual(result.ReifiedTypeRefs, expected) {
+		t.Errorf("ReifiedTypeRefs: expected %v, got %v", expected, result.ReifiedTypeRefs)
+	}
+	for _, fqn := range expected {
+		if !slices.Contains(result.AllDependencies, fqn) {
+			t.Errorf("AllDependencies %v missing reified type reference %s", result.AllDependencies, fqn)
+		}
+	}
+}
+
+func TestParser_OptInMarkers(t *testing.T) {
+	content := `@file:OptIn(kotlinx.coroutines.ExperimentalCoroutinesApi::class)
+
+package com.example.worker
+
+import com.example.flags.ExperimentalFlags
+
+@OptIn(ExperimentalFlags::class, kotlin.ExperimentalStdlibApi::class)
+class Worker {
+    @kotlin.OptIn(
+        markerClass = [acme.api.Preview.Unstable::class],
+    )
+    fun run() {
+        // @OptIn(com.example.Commented::class)
+        val s = "@OptIn(com.example.InString::class)"
+    }
+
+    @OptIn(ExperimentalTime::class)
+    fun time() = Unit
+}
+`
+
+	result, err := NewParser(WithFQNScanning(false)).ParseContent(content, "Worker.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	expected := []string{
+		"ExperimentalTime",
+		"acme.api.Preview.Unstable",
+		"com.example.flags.ExperimentalFlags",
+		"kotlin.ExperimentalStdlibApi",
+		"kotlinx.coroutines.ExperimentalCoroutinesApi",
+	}
+	if !reflect.DeepEqual(result.OptInMarkers, expected) {
+		t.Errorf("OptInMarkers: expected %v, got %v", expected, result.OptInMarkers)
+	}
+	for _, fqn := range []string{"kotlinx.coroutines.ExperimentalCoroutinesApi", "acme.api.Preview", "com.example.flags.ExperimentalFlags"} {
+		if !slices.Contains(result.AllDependencies, fqn) {
+			t.Errorf("AllDependencies %v missing opt-in marker %s", result.AllDependencies, fqn)
+		}
+	}
+	if slices.Contains(result.AllDependencies, "kotlin.ExperimentalStdlibApi") {
+		t.Errorf("AllDependencies %v contains the stdlib marker kotlin.ExperimentalStdlibApi", result.AllDependencies)
+	}
+}
+
+func TestParser_UsesCoroutines(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{
+			name:    "top-level suspend fun",
+			content: "package com.example\n\nsuspend fun fetch(): String = \"\"\n",
+			want:    true,
+		},
+		{
+			name: "member with other modifiers",
+			content: `package com.example
+
+class Repo : Source {
+    override suspend fun load() = Unit
+    private suspend inline fun <T> retry(block: () -> T): T = block()
+}
+`,
+			want: true,
+		},
+		{
+			name: "suspend only in comments and strings",
+			content: `package com.example
+
+// suspend fun commented()
+/* suspend fun blocked() */
+val doc = "suspend fun quoted()"
+fun plain() = Unit
+`,
+			want: false,
+		},
+		{
+			name:    "suspend function type",
+			content: "package com.example\n\nfun run(block: suspend () -> Unit) = Unit\n",
+			want:    false,
+		},
+	}
+
+	parser := NewParser()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parser.ParseContent(tt.content, "Test.kt")
+			if err != nil {
+				t.Fatalf("ParseContent failed: %v", err)
+			}
+			if result.UsesCoroutines != tt.want {
+				t.Errorf("UsesCoroutines = %v, want %v", result.UsesCoroutines, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_DeclaredTypes(t *testing.T) {
+	content := `package com.example.model
+
+import kotlinx.serialization.Serializable
+
+@Serializable data class User(val name: String) {
+    class Builder
+    companion object
+}
+
+sealed interface Shape
+internal object Registry
+fun interface Handler { fun handle() }
+enum class Color { RED }
+
+// class Commented
+val doc = "class Quoted"
+
+fun helper() {
+    class Local
+}
+`
+	result, err := NewParser().ParseContent(content, "User.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	want := []string{"Color", "Handler", "Registry", "Shape", "User"}
+	if !reflect.DeepEqual(result.DeclaredTypes, want) {
+		t.Errorf("DeclaredTypes = %v, want %v", result.DeclaredTypes, want)
+	}
+}
+
+// crlfTestContent exercises every line-based step of parsing: file
+// annotations, a wrapped import, an alias, a block comment spanning lines and
+// FQNs in the body.
+const crlfTestContent = `@file:JvmName("Sample")
+package com.example.app
+
+import com.example.model
+    .User
+import com.example.util.Strings as S
+import org.junit.jupiter.api.*
+
+/*
+ * Block comment before the first declaration.
+ */
+@Singleton
+class Sample @Inject constructor() {
+    val user = com.example.other.Profile()
+    val config = javaClass.getResource("app.yaml")
+}
+`
+
+func TestParser_LineEndingsMatchLF(t *testing.T) {
+	p := NewParser(
+		WithFQNScanning(true),
+		WithResourceLoaders([]string{"getResource"}),
+		WithDiAnnotations([]string{"Inject", "Singleton"}),
+	)
+
+	lf, err := p.ParseContent(crlfTestContent, "Sample.kt")
+	if err != nil {
+		t.Fatalf("ParseContent(LF) failed: %v", err)
+	}
+
+	for name, eol := range map[string]string{"CRLF": "\r\n", "CR": "\r"} {
+		t.Run(name, func(t *testing.T) {
+			got, err := p.ParseContent(strings.ReplaceAll(crlfTestContent, "\n", eol), "Sample.kt")
+			if err != nil {
+				t.Fatalf("ParseContent failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, lf) {
+				t.Errorf("result differs from LF:\n got: %+v\nwant: %+v", got, lf)
+			}
+		})
+	}
+
+	if lf.CodeStartLine != 13 {
+		t.Errorf("CodeStartLine = %d, want 13", lf.CodeStartLine)
+	}
+	if want := []string{"com.example.model.User", "com.example.util.Strings"}; !reflect.DeepEqual(lf.Imports, want) {
+		t.Errorf("Imports = %v, want %v", lf.Imports, want)
+	}
+}
+
+func TestParser_ParseErrorContext(t *testing.T) {
+	dir := t.TempDir()
+	p := NewParser()
+
+	missing := filepath.Join(dir, "Missing.kt")
+	_, err := p.ParseFile(missing)
+	var pe *util.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("ParseFile(missing) error = %v, want a *util.ParseError", err)
+	}
+	if pe.Path != missing || pe.Backend != "heuristic" || pe.Line != 0 {
+		t.Errorf("ParseError = %+v, want path %s, backend heuristic, no line", pe, missing)
+	}
+
+	// A line past the scanner's 1MB limit fails on that line
+	content := "package com.example\n\nval s = \"" + strings.Repeat("x", 2*1024*1024) + "\"\n"
+	_, err = p.ParseContent(content, "Huge.kt")
+	if !errors.As(err, &pe) {
+		t.Fatalf("ParseContent(huge) error = %v, want a *util.ParseError", err)
+	}
+	if pe.Path != "Huge.kt" || pe.Line != 3 {
+		t.Errorf("ParseError = %+v, want path Huge.kt at line 3", pe)
+	}
+}
+
+func TestParser_ParseFilesConcurrent(t *testing.T) {
+	t.Setenv(util.EnvVarJobs, "3")
+
+	tmpDir := t.TempDir()
+	var paths []string
+	for i := range 10 {
+		path := filepath.Join(tmpDir, fmt.Sprintf("File%d.kt", i))
+		content := fmt.Sprintf("package com.example.p%d\n\nclass File%d\n", i, i)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	results, err := NewParser().ParseFiles(paths)
+	if err != nil {
+		t.Fatalf("ParseFiles failed: %v", err)
+	}
+	if len(results) != len(paths) {
+		t.Fatalf("expected %d results, got %d", len(paths), len(results))
+	}
+	for i, result := range results {
+		if want := fmt.Sprintf("com.example.p%d", i); result.Package != want {
+			t.Errorf("results[%d].Package = %q, want %q", i, result.Package, want)
+		}
+	}
+
+	if _, err := NewParser().ParseFiles(append(paths, filepath.Join(tmpDir, "Missing.kt"))); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
+
+func TestParser_ParseFilesContextCancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Main.kt")
+	if err := os.WriteFile(path, []byte("package com.example\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewParser().ParseFilesContext(ctx, []string{path}); !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseFilesContext() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestParser_ParseFilesInvalidJobs(t *testing.T) {
+	t.Setenv(util.EnvVarJobs, "zero")
+
+	if _, err := NewParser().ParseFiles(nil); err == nil || !strings.Contains(err.Error(), util.EnvVarJobs) {
+		t.Errorf("expected %s error, got %v", util.EnvVarJobs, err)
+	}
+}
+
+// slowBackend stalls on files named Slow*.kt until its context is done, like
+// a tree-sitter parse of a pathological file, and parses the rest normally.
+type slowBackend struct {
+	*HeuristicBackend
+}
+
+func (b slowBackend) ParseFile(ctx context.Context, path string) (*ParseResult, error) {
+	if strings.HasPrefix(filepath.Base(path), "Slow") {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return b.HeuristicBackend.ParseFile(ctx, path)
+}
+
+func TestParseFiles_SkipsFilesPastTimeout(t *testing.T) {
+	t.Setenv(util.EnvVarParseTimeout, "20ms")
+
+	tmpDir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"A.kt", "Slow.kt", "B.kt"} {
+		path := filepath.Join(tmpDir, name)
+		content := "package com.example." + strings.ToLower(strings.TrimSuffix(name, ".kt")) + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	backend := slowBackend{NewHeuristicBackend(DefaultBackendConfig())}
+	results, skipped, err := parseFiles(context.Background(), paths, backend.ParseFile)
+	if err != nil {
+		t.Fatalf("parseFiles failed: %v", err)
+	}
+
+	if want := []string{paths[1]}; !slices.Equal(skipped, want) {
+		t.Errorf("skipped = %v, want %v", skipped, want)
+	}
+	var pkgs []string
+	for _, r := range results {
+		pkgs = append(pkgs, r.Package)
+	}
+	if want := []string{"com.example.a", "com.example.b"}; !slices.Equal(pkgs, want) {
+		t.Errorf("parsed packages = %v, want %v", pkgs, want)
+	}
+
+	results, err = ParseFilesWithBackend(context.Background(), backend, paths)
+	if err != nil {
+		t.Fatalf("ParseFilesWithBackend failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("ParseFilesWithBackend returned %d results, want 2", len(results))
+	}
+}
+
+func TestParser_ParseFilesInvalidTimeout(t *testing.T) {
+	t.Setenv(util.EnvVarParseTimeout, "soon")
+
+	if _, err := NewParser().ParseFiles(nil); err == nil || !strings.Contains(err.Error(), util.EnvVarParseTimeout) {
+		t.Errorf("expected %s error, got %v", util.EnvVarParseTimeout, err)
+	}
+}
+
+func TestParseResult_ClassifyImports(t *testing.T) {
+	content := `package com.example.app
+
+import kotlin.collections.List
+import java.time.Instant
+import kotlinx.coroutines.flow.Flow
+import com.google.common.collect.ImmutableList
+import com.example.util.Strings
+import com.example.model.*
+import org.junit.*
+
+class App
+`
+	result, err := NewParser().ParseContent(content, "App.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	workspace := jvm.NewPackageIndex()
+	workspace.Add("com.example.util", label.New("", "util", "util"))
+	workspace.Add("com.example.model", label.New("", "model", "model"))
+	result.ClassifyImports(workspace.Contains)
+
+	want := map[string][]string{
+		util.OriginStdlib:     {"java.time.Instant", "kotlin.collections.List"},
+		util.OriginFirstParty: {"com.example.model", "com.example.util.Strings"},
+		util.OriginThirdParty: {"com.google.common.collect.ImmutableList", "kotlinx.coroutines.flow.Flow", "org.junit"},
+	}
+	if !reflect.DeepEqual(result.ImportsByOrigin, want) {
+		t.Errorf("ImportsByOrigin:\n  got:  %v\n  want: %v", result.ImportsByOrigin, want)
+	}
+}
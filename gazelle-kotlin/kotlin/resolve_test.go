@@ -0,0 +1,104 @@
+package kotlin
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/resolve"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+func TestResolvePinnedImport(t *testing.T) {
+	root := t.TempDir()
+	source := "package com.example\n\nimport com.google.common.collect.ImmutableList\nimport org.slf4j.Logger\n\nclass Main\n"
+	files := map[string]string{
+		resolvemap.DefaultPath:                             "[resolve]\n\"com.google.common.*\" = \"@maven//:guava\"\n\"org.slf4j.*\" = \"@maven//:slf4j_api\"\n",
+		"services/pay/" + resolvemap.DefaultPath:           "[resolve]\n\"com.google.common.*\" = \"@maven//:guava_android\"\n",
+		"services/pay/src/main/kotlin/com/example/Main.kt": source,
+		"app/src/main/kotlin/com/example/Main.kt":          source,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deppolicy.Reset()
+	t.Cleanup(deppolicy.Reset)
+
+	kc := NewKotlinConfig()
+	kc.Enabled = true
+	c := &config.Config{RepoRoot: root, Exts: map[string]interface{}{kotlinName: kc}}
+	lang := NewLanguage()
+	ix := resolve.NewRuleIndex(func(*rule.Rule, string) resolve.Resolver { return lang })
+	ix.Finish()
+
+	tests := []struct {
+		pkg  string
+		want []string
+	}{
+		{pkg: "services/pay", want: []string{"@maven//:guava_android", "@maven//:slf4j_api"}},
+		{pkg: "app", want: []string{"@maven//:guava", "@maven//:slf4j_api"}},
+	}
+	for _, tt := range tests {
+		result := lang.GenerateRules(language.GenerateArgs{
+			Config: c,
+			Dir:    filepath.Join(root, filepath.FromSlash(tt.pkg)),
+			Rel:    tt.pkg,
+		})
+		if len(result.Gen) != 1 {
+			t.Fatalf("GenerateRules(%s) generated %d rules, want 1", tt.pkg, len(result.Gen))
+		}
+		r := result.Gen[0]
+		lang.Resolve(c, ix, nil, r, result.Imports[0], label.New("", tt.pkg, r.Name()))
+		if got := r.AttrStrings("deps"); !slices.Equal(got, tt.want) {
+			t.Errorf("deps of //%s = %v, want %v", tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAggregateTarget(t *testing.T) {
+	root := t.TempDir()
+	policy := filepath.Join(root, deppolicy.DefaultPath)
+	if err := os.MkdirAll(filepath.Dir(policy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[aggregate]\n\"com.example.foo.*\" = \"//libs/foo\"\n"
+	if err := os.WriteFile(policy, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deppolicy.Reset()
+	t.Cleanup(deppolicy.Reset)
+
+	kc := NewKotlinConfig()
+	kc.Enabled = true
+	c := &config.Config{RepoRoot: root, Exts: map[string]interface{}{kotlinName: kc}}
+	lang := NewLanguage()
+	ix := resolve.NewRuleIndex(func(*rule.Rule, string) resolve.Resolver { return lang })
+	ix.Finish()
+
+	imports := []string{"com.example.foo.api.Client", "com.example.foo.impl.Store", "com.example.bar.Thing"}
+
+	r := rule.NewRule("kt_jvm_library", "app")
+	lang.Resolve(c, ix, nil, r, imports, label.New("", "app", "app"))
+	if got, want := r.AttrStrings("deps"), []string{"//libs/foo"}; !slices.Equal(got, want) {
+		t.Errorf("deps = %v, want %v", got, want)
+	}
+
+	// The umbrella target itself does not depend on itself.
+	self := rule.NewRule("kt_jvm_library", "foo")
+	lang.Resolve(c, ix, nil, self, imports[:2], label.New("", "libs/foo", "foo"))
+	if got := self.AttrStrings("deps"); len(got) != 0 {
+		t.Errorf("deps of //libs/foo = %v, want none", got)
+	}
+}
@@ -0,0 +1,50 @@
+package kotlin
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultTestFilePatterns are the naming conventions used to classify Kotlin
+// files as tests when no custom patterns are configured.
+//
+// Patterns ending in "/" are directory patterns: they match when the file
+// lives anywhere below a directory with that path (e.g. "src/test/"),
+// compared case-insensitively so "Src/Test/" matches as well, as it would
+// on a case-insensitive filesystem. All other patterns are globs
+// (path.Match syntax) matched case-sensitively against the file's base
+// name, since they encode naming conventions like "*Test.kt".
+var DefaultTestFilePatterns = []string{
+	"*Test.kt",
+	"*Tests.kt",
+	"*Spec.kt",
+	"*IT.kt",
+	"src/test/",
+}
+
+// IsTestFile reports whether path looks like a Kotlin test file according
+// to patterns. A nil patterns slice means DefaultTestFilePatterns.
+func IsTestFile(filePath string, patterns []string) bool {
+	if patterns == nil {
+		patterns = DefaultTestFilePatterns
+	}
+
+	slashed := "/" + filepath.ToSlash(filePath)
+	base := path.Base(slashed)
+	folded := strings.ToLower(slashed)
+
+	for _, pattern := range patterns {
+		if dir, isDir := strings.CutSuffix(pattern, "/"); isDir {
+			dir = strings.ToLower(strings.Trim(dir, "/"))
+			if dir != "" && strings.Contains(folded, "/"+dir+"/") {
+				return true
+			}
+			continue
+		}
+		if ok, err := path.Match(pattern, base); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package kotlin
+
+import "testing"
+
+func TestIsTestFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		patterns []string
+		want     bool
+	}{
+		{"Test suffix", "lib/src/main/kotlin/com/example/FooTest.kt", nil, true},
+		{"Spec suffix", "FooSpec.kt", nil, true},
+		{"under src/test", "lib/src/test/kotlin/com/example/Helpers.kt", nil, true},
+		{"main source", "lib/src/main/kotlin/com/example/Foo.kt", nil, false},
+		{"Test in middle of name", "lib/src/main/kotlin/TestUtils.kt", nil, false},
+		{"directory prefix is not a segment", "lib/src/testing/Foo.kt", nil, false},
+		{"custom glob", "FooCheck.kt", []string{"*Check.kt"}, true},
+		{"custom patterns replace defaults", "FooTest.kt", []string{"*Check.kt"}, false},
+		{"custom directory", "project/androidTest/Foo.kt", []string{"androidTest/"}, true},
+		{"empty patterns disable detection", "src/test/FooTest.kt", []string{}, false},
+		{"mixed-case src/test", "lib/Src/Test/kotlin/Helpers.kt", nil, true},
+		{"upper-case src/test", "LIB/SRC/TEST/KOTLIN/Helpers.kt", nil, true},
+		{"mixed-case prefix is not a segment", "lib/Src/Testing/Foo.kt", nil, false},
+		{"mixed-case custom directory", "project/AndroidTEST/Foo.kt", []string{"androidTest/"}, true},
+		{"base name globs keep their case", "lib/src/main/kotlin/Footest.kt", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTestFile(tt.path, tt.patterns); got != tt.want {
+				t.Errorf("IsTestFile(%q, %v) = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParser_ParseContent_IsTest(t *testing.T) {
+	content := "package com.example\n\nclass Foo\n"
+
+	p := NewParser()
+	for path, want := range map[string]bool{
+		"src/main/kotlin/com/example/FooTest.kt":  true,
+		"src/test/kotlin/com/example/Fixtures.kt": true,
+		"src/main/kotlin/com/example/Foo.kt":      false,
+	} {
+		result, err := p.ParseContent(content, path)
+		if err != nil {
+			t.Fatalf("ParseContent(%q) failed: %v", path, err)
+		}
+		if result.IsTest != want {
+			t.Errorf("ParseContent(%q).IsTest = %v, want %v", path, result.IsTest, want)
+		}
+	}
+
+	custom := NewParser(WithTestFilePatterns([]string{"*Check.kt"}))
+	result, err := custom.ParseContent(content, "FooTest.kt")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if result.IsTest {
+		t.Error("custom patterns should replace the defaults")
+	}
+}
@@ -2,6 +2,8 @@ package python
 
 import (
 	"flag"
+	"path"
+	"slices"
 	"strings"
 
 	"github.com/albertocavalcante/bazelle/internal/log"
@@ -29,7 +31,8 @@ type PythonConfig struct {
 	// LoadPath is the path for loading custom macros.
 	LoadPath string
 
-	// TestFramework is the test framework to use ("pytest", "unittest").
+	// TestFramework is the test framework to use (TestFrameworkPytest,
+	// TestFrameworkUnittest or TestFrameworkNose).
 	TestFramework string
 
 	// StdlibModulesFile is an optional path to a custom stdlib modules list.
@@ -40,6 +43,12 @@ type PythonConfig struct {
 
 	// NamespacePackages enables namespace package detection (PEP 420).
 	NamespacePackages bool
+
+	// ImportRoots are the workspace-relative directories that Python code
+	// imports from, as if they were on sys.path, such as "src" in a src
+	// layout. The module name of a directory is its path relative to the
+	// deepest import root that contains it.
+	ImportRoots []string
 }
 
 // Clone creates a copy of the configuration.
@@ -50,6 +59,7 @@ func (c *PythonConfig) Clone() *PythonConfig {
 		pipCopy := *c.Pip
 		clone.Pip = &pipCopy
 	}
+	clone.ImportRoots = slices.Clone(c.ImportRoots)
 	return &clone
 }
 
@@ -61,7 +71,7 @@ func NewPythonConfig() *PythonConfig {
 		TestMacro:         "py_test",
 		BinaryMacro:       "py_binary",
 		Visibility:        "//visibility:public",
-		TestFramework:     "pytest",
+		TestFramework:     TestFrameworkPytest,
 		Pip:               NewPipConfig(),
 		NamespacePackages: false,
 	}
@@ -101,6 +111,7 @@ func (*pythonLang) KnownDirectives() []string {
 		"python_requirements_file",
 		"python_pip_repository",
 		"python_namespace_packages",
+		"python_import_roots",
 	}
 }
 
@@ -137,12 +148,12 @@ func (*pythonLang) Configure(c *config.Config, rel string, f *rule.File) {
 			newPc.LoadPath = d.Value
 		case "python_test_framework":
 			switch strings.ToLower(d.Value) {
-			case "pytest", "unittest":
+			case TestFrameworkPytest, TestFrameworkUnittest, TestFrameworkNose:
 				newPc.TestFramework = strings.ToLower(d.Value)
 			default:
 				log.Warn("unknown python_test_framework, using pytest",
 					"value", d.Value, "language", "python")
-				newPc.TestFramework = "pytest"
+				newPc.TestFramework = TestFrameworkPytest
 			}
 		case "python_stdlib_modules_file":
 			newPc.StdlibModulesFile = d.Value
@@ -158,6 +169,30 @@ func (*pythonLang) Configure(c *config.Config, rel string, f *rule.File) {
 			newPc.Pip.PipRepository = d.Value
 		case "python_namespace_packages":
 			newPc.NamespacePackages = strings.ToLower(d.Value) == "true"
+		case "python_import_roots":
+			newPc.ImportRoots = parseImportRoots(d.Value)
+		}
+	}
+}
+
+// parseImportRoots parses the comma-separated value of the
+// python_import_roots directive. Roots are cleaned to slash-separated
+// workspace-relative paths, with "." or "" naming the workspace root; an
+// empty value clears the roots.
+func parseImportRoots(value string) []string {
+	var roots []string
+	for _, root := range strings.Split(value, ",") {
+		root = strings.TrimSpace(root)
+		if root == "" {
+			continue
+		}
+		root = strings.Trim(path.Clean(strings.ReplaceAll(root, "\\", "/")), "/")
+		if root == "." {
+			root = ""
+		}
+		if !slices.Contains(roots, root) {
+			roots = append(roots, root)
 		}
 	}
+	return roots
 }
@@ -1,6 +1,7 @@
 package python
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/bazelbuild/bazel-gazelle/config"
@@ -152,6 +153,7 @@ func TestKnownDirectives(t *testing.T) {
 		"python_requirements_file",
 		"python_pip_repository",
 		"python_namespace_packages",
+		"python_import_roots",
 	}
 
 	if len(directives) != len(expected) {
@@ -311,6 +313,8 @@ func TestConfigureTestFrameworkCaseInsensitive(t *testing.T) {
 		{"unittest", "unittest"},
 		{"Unittest", "unittest"},
 		{"UNITTEST", "unittest"},
+		{"nose", "nose"},
+		{"Nose", "nose"},
 	}
 
 	for _, tt := range tests {
@@ -361,3 +365,40 @@ func TestConfigureWithNoExistingConfig(t *testing.T) {
 		t.Error("expected Enabled to be true")
 	}
 }
+
+func TestConfigureImportRoots(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{value: "src", expected: []string{"src"}},
+		{value: "src, lib/python/ ,src", expected: []string{"src", "lib/python"}},
+		{value: "./src/../py", expected: []string{"py"}},
+		{value: ".", expected: []string{""}},
+		{value: "", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			lang := &pythonLang{}
+			c := &config.Config{Exts: make(map[string]interface{})}
+			c.Exts[pythonName] = NewPythonConfig()
+			f := &rule.File{Directives: []rule.Directive{{Key: "python_import_roots", Value: tt.value}}}
+
+			lang.Configure(c, "", f)
+
+			if got := GetPythonConfig(c).ImportRoots; !slices.Equal(got, tt.expected) {
+				t.Errorf("python_import_roots=%q: got %q, want %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPythonConfigCloneImportRoots(t *testing.T) {
+	original := &PythonConfig{ImportRoots: []string{"src"}}
+	clone := original.Clone()
+	clone.ImportRoots[0] = "lib"
+	if original.ImportRoots[0] != "src" {
+		t.Error("clone modification affected original import roots")
+	}
+}
@@ -0,0 +1,125 @@
+package python
+
+import (
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/pkg/util"
+)
+
+// codingRegex matches a PEP 263 encoding declaration, such as
+// "# -*- coding: latin-1 -*-" or "# vim: set fileencoding=utf-8 :".
+var codingRegex = regexp.MustCompile(`^[ \t\f]*#.*?coding[:=][ \t]*([-\w.]+)`)
+
+// utf8BOM is the UTF-8 byte order mark, which Python skips before looking
+// for a coding comment.
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// readPythonSource reads a Python file and decodes it to UTF-8 text; see
+// decodeSource.
+func readPythonSource(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return decodeSource(path, data), nil
+}
+
+// decodeSource converts the raw bytes of a Python file to UTF-8 text,
+// honoring the encoding named by a PEP 263 coding comment.
+//
+// Latin-1 and cp1252 sources are transcoded. Files without a coding comment
+// are UTF-8, the Python 3 default, as are files declaring UTF-8 or ASCII.
+// Any other declared encoding is not supported: it is logged and the file is
+// read as UTF-8, which keeps ASCII import statements intact.
+func decodeSource(path string, data []byte) string {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	switch enc := normalizeEncoding(declaredEncoding(data)); enc {
+	case "", "utf-8", "ascii":
+		return util.SanitizeSource(path, data)
+	case "latin-1":
+		return decodeSingleByte(data, nil)
+	case "cp1252":
+		return decodeSingleByte(data, &cp1252High)
+	default:
+		log.Warn("unsupported python source encoding, reading as UTF-8",
+			"file", path, "encoding", enc)
+		return util.SanitizeSource(path, data)
+	}
+}
+
+// declaredEncoding returns the encoding named by the coding comment of data,
+// or "" if there is none. As in Python, the comment must be on the first or
+// second line, and on the second only if the first is blank or a comment.
+func declaredEncoding(data []byte) string {
+	for range 2 {
+		line, rest, _ := bytes.Cut(data, []byte("\n"))
+		if m := codingRegex.FindSubmatch(line); m != nil {
+			return string(m[1])
+		}
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) > 0 && trimmed[0] != '#' {
+			return ""
+		}
+		data = rest
+	}
+	return ""
+}
+
+// normalizeEncoding maps the spellings Python accepts for the supported
+// encodings to a canonical name, following the normalization of the Python
+// tokenizer: case and "_" versus "-" do not matter, and a suffix such as
+// "utf-8-unix" names the same encoding. Other names are returned lowercased.
+func normalizeEncoding(enc string) string {
+	enc = strings.ReplaceAll(strings.ToLower(enc), "_", "-")
+	switch {
+	case enc == "utf8" || encodingIs(enc, "utf-8"):
+		return "utf-8"
+	case encodingIs(enc, "latin-1") || encodingIs(enc, "iso-8859-1") || encodingIs(enc, "iso-latin-1"):
+		return "latin-1"
+	}
+	switch enc {
+	case "latin1", "iso8859-1", "l1", "cp819":
+		return "latin-1"
+	case "cp1252", "windows-1252":
+		return "cp1252"
+	case "ascii", "us-ascii":
+		return "ascii"
+	}
+	return enc
+}
+
+// encodingIs reports whether the normalized name enc is base or base with a
+// "-" suffix.
+func encodingIs(enc, base string) bool {
+	return enc == base || strings.HasPrefix(enc, base+"-")
+}
+
+// decodeSingleByte decodes a single-byte encoding. Bytes below 0x80 are
+// ASCII; high maps 0x80-0x9F for encodings that assign those bytes, and the
+// rest match their Latin-1 code point.
+func decodeSingleByte(data []byte, high *[32]rune) string {
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xA0 && high != nil:
+			b.WriteRune(high[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// cp1252High maps bytes 0x80-0x9F of Windows-1252. The five bytes it leaves
+// undefined decode to U+FFFD.
+var cp1252High = [32]rune{
+	'€', '\uFFFD', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\uFFFD', 'Ž', '\uFFFD',
+	'\uFFFD', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\uFFFD', 'ž', 'Ÿ',
+}
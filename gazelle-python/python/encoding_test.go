@@ -0,0 +1,71 @@
+package python
+
+import "testing"
+
+func TestDeclaredEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"emacs", "# -*- coding: latin-1 -*-\nimport os\n", "latin-1"},
+		{"vim", "# vim: set fileencoding=cp1252 :\n", "cp1252"},
+		{"plain", "# coding=utf-8\n", "utf-8"},
+		{"second line", "#!/usr/bin/env python\n# coding: latin-1\n", "latin-1"},
+		{"after blank line", "\n# coding: latin-1\n", "latin-1"},
+		{"after code", "import os\n# coding: latin-1\n", ""},
+		{"third line", "#!/usr/bin/env python\n#\n# coding: latin-1\n", ""},
+		{"not a comment", "coding = 'latin-1'\n", ""},
+		{"none", "import os\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := declaredEncoding([]byte(tt.content)); got != tt.want {
+				t.Errorf("declaredEncoding(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeEncoding(t *testing.T) {
+	tests := map[string]string{
+		"UTF-8":        "utf-8",
+		"utf8":         "utf-8",
+		"utf-8-unix":   "utf-8",
+		"Latin-1":      "latin-1",
+		"latin1":       "latin-1",
+		"ISO-8859-1":   "latin-1",
+		"iso_8859_1":   "latin-1",
+		"windows-1252": "cp1252",
+		"ascii":        "ascii",
+		"euc-jp":       "euc-jp",
+	}
+	for enc, want := range tests {
+		if got := normalizeEncoding(enc); got != want {
+			t.Errorf("normalizeEncoding(%q) = %q, want %q", enc, got, want)
+		}
+	}
+}
+
+func TestDecodeSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"latin-1", "# coding: latin-1\nx = '\xe9'\n", "# coding: latin-1\nx = 'é'\n"},
+		{"cp1252", "# coding: cp1252\nx = '\x80\x96'\n", "# coding: cp1252\nx = '€–'\n"},
+		{"utf-8 bom", "\xef\xbb\xbf# coding: utf-8\nx = 'é'\n", "# coding: utf-8\nx = 'é'\n"},
+		{"default utf-8", "x = 'é'\n", "x = 'é'\n"},
+		{"unsupported", "# coding: euc-jp\nimport os\n", "# coding: euc-jp\nimport os\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeSource("test.py", []byte(tt.content)); got != tt.want {
+				t.Errorf("decodeSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,10 +23,13 @@ package python
 
 import (
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/internal/wspath"
 	"github.com/bazelbuild/bazel-gazelle/language"
 	"github.com/bazelbuild/bazel-gazelle/rule"
 )
@@ -107,8 +110,11 @@ func (p *pythonLang) generateLibraryRule(args language.GenerateArgs, pc *PythonC
 	// Parse files to collect imports
 	allImports := p.collectImports(args, files)
 
-	// Store imports for resolution phase
+	// Store imports for resolution phase, and the modules the library
+	// provides for indexing
 	r.SetPrivateAttr("python_imports", allImports)
+	pkg := derivePythonPackage(args.Dir, args.Config.RepoRoot, pc.ImportRoots)
+	r.SetPrivateAttr("python_provides", providedModules(pkg, files))
 
 	return r, allImports
 }
@@ -150,13 +156,13 @@ func (p *pythonLang) generateBinaryRule(args language.GenerateArgs, pc *PythonCo
 	result, err := p.parser.ParseFile(fullPath)
 	if err != nil {
 		log.Warn("failed to parse python file",
-			"file", mainFile, "error", err)
+			"file", wspath.Display(args.Config.RepoRoot, fullPath), "error", err)
 		return r, nil
 	}
 
 	// Collect both absolute and resolved relative imports
 	allImports := result.GetAllImports()
-	currentPkg := derivePythonPackage(args.Dir, args.Config.RepoRoot)
+	currentPkg := derivePythonPackage(args.Dir, args.Config.RepoRoot, pc.ImportRoots)
 	allImports = append(allImports, result.ResolveRelativeImports(currentPkg)...)
 
 	r.SetPrivateAttr("python_imports", allImports)
@@ -170,16 +176,20 @@ func (p *pythonLang) collectImports(args language.GenerateArgs, files []string)
 	var allImports []string
 
 	// Derive the current Python package from the directory path
-	currentPkg := derivePythonPackage(args.Dir, args.Config.RepoRoot)
+	currentPkg := derivePythonPackage(args.Dir, args.Config.RepoRoot, GetPythonConfig(args.Config).ImportRoots)
 
 	for _, file := range files {
 		fullPath := filepath.Join(args.Dir, file)
 		result, err := p.parser.ParseFile(fullPath)
 		if err != nil {
 			log.Warn("failed to parse python file",
-				"file", file, "error", err)
+				"file", wspath.Display(args.Config.RepoRoot, fullPath), "error", err)
 			continue
 		}
+		if result.ManipulatesSysPath {
+			log.Warn("python file modifies sys.path; its dependencies may be incomplete",
+				"file", wspath.Display(args.Config.RepoRoot, fullPath))
+		}
 
 		// Collect absolute imports
 		for _, imp := range result.GetAllImports() {
@@ -202,29 +212,96 @@ func (p *pythonLang) collectImports(args language.GenerateArgs, files []string)
 }
 
 // derivePythonPackage derives a Python package name from a directory path.
-// For example, "src/myapp/utils" -> "myapp.utils" (assuming src is root)
-func derivePythonPackage(dir, repoRoot string) string {
-	rel, err := filepath.Rel(repoRoot, dir)
+// The path is taken relative to the deepest of importRoots containing it, so
+// with the import root "src", "src/myapp/utils" -> "myapp.utils". Without a
+// matching import root, a leading "src/", "lib/" or "python/" is skipped.
+func derivePythonPackage(dir, repoRoot string, importRoots []string) string {
+	rel, err := wspath.Rel(repoRoot, dir)
 	if err != nil {
 		return ""
 	}
 
-	// Skip common source directories
-	rel = strings.TrimPrefix(rel, "src/")
-	rel = strings.TrimPrefix(rel, "lib/")
-	rel = strings.TrimPrefix(rel, "python/")
+	if root, ok := deepestImportRoot(rel, importRoots); ok {
+		rel = strings.TrimPrefix(strings.TrimPrefix(rel, root), "/")
+	} else {
+		// Skip common source directories
+		rel = strings.TrimPrefix(rel, "src/")
+		rel = strings.TrimPrefix(rel, "lib/")
+		rel = strings.TrimPrefix(rel, "python/")
+	}
 
 	if rel == "." || rel == "" {
 		return ""
 	}
 
 	// Convert path separators to dots
-	pkg := strings.ReplaceAll(rel, string(filepath.Separator), ".")
+	pkg := strings.ReplaceAll(rel, "/", ".")
 	return pkg
 }
 
-// findPythonSources finds Python source files in a directory.
-// Returns both .py and .pyi (type stub) files.
+// deepestImportRoot returns the longest of roots that is rel or one of its
+// parent directories. The root "" contains every directory.
+func deepestImportRoot(rel string, roots []string) (string, bool) {
+	best, found := "", false
+	for _, root := range roots {
+		if root != "" && rel != root && !strings.HasPrefix(rel, root+"/") {
+			continue
+		}
+		if !found || len(root) > len(best) {
+			best, found = root, true
+		}
+	}
+	return best, found
+}
+
+// providedModules returns the modules a library of files in the package pkg
+// makes importable: the package itself and one module per source file.
+func providedModules(pkg string, files []string) []string {
+	var modules []string
+	if pkg != "" {
+		modules = append(modules, pkg)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(file, filepath.Ext(file))
+		if pkg != "" {
+			name = pkg + "." + name
+		}
+		if !slices.Contains(modules, name) {
+			modules = append(modules, name)
+		}
+	}
+	return modules
+}
+
+// sourceExtensions are the extensions of Python source files, type stubs
+// (.pyi) included, then those registered with AddFileExtensions.
+var sourceExtensions = []string{".py", ".pyi"}
+
+// AddFileExtensions registers exts, such as ".pyw", as extra extensions of
+// Python source files, so library srcs globs and source lookups include them.
+// Extensions must have their leading dot; known ones are skipped. The CLI
+// registers the extensions configured with langs.AddExtensions; call it at
+// startup, before any rules are generated.
+func AddFileExtensions(exts ...string) {
+	for _, ext := range exts {
+		if !slices.Contains(sourceExtensions, ext) {
+			sourceExtensions = append(sourceExtensions, ext)
+		}
+	}
+}
+
+// isPythonSource reports whether name has one of sourceExtensions, and
+// whether it is a package's __init__ file.
+func isPythonSource(name string) (source, init bool) {
+	ext := filepath.Ext(name)
+	if !slices.Contains(sourceExtensions, ext) {
+		return false, false
+	}
+	return true, strings.TrimSuffix(name, ext) == "__init__"
+}
+
+// findPythonSources finds Python source files in a directory: those with
+// any of sourceExtensions, such as .py and .pyi (type stub) files.
 func findPythonSources(dir string, testsOnly bool) []string {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -238,15 +315,13 @@ func findPythonSources(dir string, testsOnly bool) []string {
 		}
 		name := entry.Name()
 
-		// Accept both .py and .pyi files
-		isPy := strings.HasSuffix(name, ".py")
-		isPyi := strings.HasSuffix(name, ".pyi")
-		if !isPy && !isPyi {
+		source, init := isPythonSource(name)
+		if !source {
 			continue
 		}
 
-		// Skip __init__.py and __init__.pyi for source collection (handled separately)
-		if name == "__init__.py" || name == "__init__.pyi" {
+		// Skip __init__ files for source collection (handled separately)
+		if init {
 			continue
 		}
 
@@ -309,12 +384,12 @@ func hasTypeStubs(dir string) bool {
 
 // deriveTargetName derives a target name from the directory path.
 func deriveTargetName(dir, repoRoot string) string {
-	rel, err := filepath.Rel(repoRoot, dir)
+	rel, err := wspath.Rel(repoRoot, dir)
 	if err != nil {
 		return filepath.Base(dir)
 	}
 	// Use last path segment as the name
-	name := filepath.Base(rel)
+	name := path.Base(rel)
 	if name == "." || name == "" {
 		return "lib"
 	}
@@ -323,12 +398,16 @@ func deriveTargetName(dir, repoRoot string) string {
 	return name
 }
 
-// getSrcGlobs returns glob patterns for Python source files.
-// Includes both .py and .pyi (type stub) files.
+// getSrcGlobs returns glob patterns for Python source files, one per
+// extension in sourceExtensions, such as .py and .pyi (type stub) files.
 func getSrcGlobs() rule.GlobValue {
 	// Use glob patterns for Python files, excluding tests
+	patterns := make([]string, 0, len(sourceExtensions))
+	for _, ext := range sourceExtensions {
+		patterns = append(patterns, "*"+ext)
+	}
 	return rule.GlobValue{
-		Patterns: []string{"*.py", "*.pyi"},
+		Patterns: patterns,
 		Excludes: []string{"*_test.py", "test_*.py"},
 	}
 }
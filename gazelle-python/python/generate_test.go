@@ -1,10 +1,16 @@
 package python
 
 import (
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
+	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/language"
 	"github.com/bazelbuild/bazel-gazelle/rule"
 )
 
@@ -68,6 +74,46 @@ func TestDeriveTargetName(t *testing.T) {
 	}
 }
 
+func TestDerivePythonPackage(t *testing.T) {
+	root := t.TempDir()
+	pkgDir := filepath.Join(root, "src", "myapp", "utils")
+	if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	alias := filepath.Join(t.TempDir(), "ws")
+	if err := os.Symlink(root, alias); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		dir         string
+		repoRoot    string
+		importRoots []string
+		expected    string
+	}{
+		{name: "source_root_stripped", dir: pkgDir, repoRoot: root, expected: "myapp.utils"},
+		{name: "root_directory", dir: root, repoRoot: root, expected: ""},
+		{name: "dir_through_symlinked_root", dir: filepath.Join(alias, "src", "myapp", "utils"), repoRoot: root, expected: "myapp.utils"},
+		{name: "symlinked_repo_root", dir: pkgDir, repoRoot: alias, expected: "myapp.utils"},
+		{name: "outside_repo", dir: t.TempDir(), repoRoot: root, expected: ""},
+		{name: "import_root", dir: pkgDir, repoRoot: root, importRoots: []string{"src/myapp"}, expected: "utils"},
+		{name: "deepest_import_root", dir: pkgDir, repoRoot: root, importRoots: []string{"src", "src/myapp"}, expected: "utils"},
+		{name: "workspace_import_root", dir: pkgDir, repoRoot: root, importRoots: []string{""}, expected: "src.myapp.utils"},
+		{name: "import_root_itself", dir: filepath.Join(root, "src"), repoRoot: root, importRoots: []string{"src"}, expected: ""},
+		{name: "no_matching_import_root", dir: pkgDir, repoRoot: root, importRoots: []string{"lib"}, expected: "myapp.utils"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := derivePythonPackage(tt.dir, tt.repoRoot, tt.importRoots); got != tt.expected {
+				t.Errorf("derivePythonPackage(%q, %q, %q) = %q, want %q",
+					tt.dir, tt.repoRoot, tt.importRoots, got, tt.expected)
+			}
+		})
+	}
+}
+
 // ============================================================================
 // getSrcGlobs Tests
 // ============================================================================
@@ -240,6 +286,28 @@ func TestFindPythonSourcesOnlyInit(t *testing.T) {
 	}
 }
 
+func TestAddFileExtensions(t *testing.T) {
+	saved := slices.Clone(sourceExtensions)
+	t.Cleanup(func() { sourceExtensions = saved })
+
+	AddFileExtensions(".pyw", ".py")
+
+	tmpDir := t.TempDir()
+	for _, name := range []string{"__init__.pyw", "app.pyw", "lib.py", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sources := findPythonSources(tmpDir, false)
+	slices.Sort(sources)
+	if want := []string{"app.pyw", "lib.py"}; !slices.Equal(sources, want) {
+		t.Errorf("findPythonSources() = %v, want %v", sources, want)
+	}
+	if got, want := getSrcGlobs().Patterns, []string{"*.py", "*.pyi", "*.pyw"}; !slices.Equal(got, want) {
+		t.Errorf("getSrcGlobs().Patterns = %v, want %v", got, want)
+	}
+}
+
 // ============================================================================
 // GlobValue Tests
 // ============================================================================
@@ -300,3 +368,45 @@ func TestDeriveTargetNameInvalidRelPath(t *testing.T) {
 		t.Errorf("expected 'package', got %q", result)
 	}
 }
+
+func TestCollectImportsWarnsOnSysPath(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "tools")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "import os, sys\nsys.path.insert(0, os.path.join(os.path.dirname(__file__), 'vendor'))\nimport vendored\n"
+	if err := os.WriteFile(filepath.Join(dir, "run.py"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The logger writes to the os.Stderr it was created with
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	log.Init(log.VerbosityWarn, "text")
+	t.Cleanup(func() {
+		os.Stderr = stderr
+		log.Init(log.VerbosityWarn, "text")
+	})
+
+	p := &pythonLang{parser: NewParser()}
+	args := language.GenerateArgs{Config: &config.Config{RepoRoot: root}, Dir: dir, Rel: "tools"}
+	imports := p.collectImports(args, []string{"run.py"})
+
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(imports, "vendored") {
+		t.Errorf("imports = %v, want vendored still collected", imports)
+	}
+	if !strings.Contains(string(out), "modifies sys.path") || !strings.Contains(string(out), "tools/run.py") {
+		t.Errorf("log output = %q, want a sys.path warning naming tools/run.py", out)
+	}
+}
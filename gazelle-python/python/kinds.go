@@ -15,7 +15,6 @@ func (*pythonLang) Kinds() map[string]rule.KindInfo {
 			SubstituteAttrs: map[string]bool{},
 			MergeableAttrs: map[string]bool{
 				"srcs": true,
-				"deps": true,
 			},
 			ResolveAttrs: map[string]bool{
 				"deps": true,
@@ -29,7 +28,6 @@ func (*pythonLang) Kinds() map[string]rule.KindInfo {
 			SubstituteAttrs: map[string]bool{},
 			MergeableAttrs: map[string]bool{
 				"srcs": true,
-				"deps": true,
 			},
 			ResolveAttrs: map[string]bool{
 				"deps": true,
@@ -43,7 +41,6 @@ func (*pythonLang) Kinds() map[string]rule.KindInfo {
 			SubstituteAttrs: map[string]bool{},
 			MergeableAttrs: map[string]bool{
 				"srcs": true,
-				"deps": true,
 			},
 			ResolveAttrs: map[string]bool{
 				"deps": true,
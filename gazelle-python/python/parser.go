@@ -2,9 +2,14 @@ package python
 
 import (
 	"bufio"
-	"os"
+	"maps"
+	"path"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+
+	"github.com/albertocavalcante/bazelle/pkg/util"
 )
 
 // RelativeImport represents a relative import statement in Python.
@@ -20,6 +25,10 @@ type RelativeImport struct {
 
 	// Names is the list of names being imported
 	Names []string
+
+	// TypingOnly indicates the import is inside an `if TYPE_CHECKING:` block,
+	// see ParseResult.TypingOnlyImports.
+	TypingOnly bool
 }
 
 // ParseResult contains the result of parsing a Python file.
@@ -38,14 +47,88 @@ type ParseResult struct {
 	// These are "from . import X" or "from ..module import Y" style imports.
 	RelativeImports []RelativeImport
 
+	// TypingOnlyImports lists, sorted, the top-level modules that are
+	// imported only inside `if TYPE_CHECKING:` blocks: they are needed by
+	// type checkers but never imported at runtime. They are also in Imports
+	// and FromImports; GetRuntimeImports leaves them out. A module imported
+	// both inside and outside such a block is a runtime import. HEURISTIC:
+	// the block is the lines indented under an `if TYPE_CHECKING:` or
+	// `if typing.TYPE_CHECKING:` line, up to and excluding its `else:`.
+	TypingOnlyImports []string
+
 	// HasMainBlock indicates if the file has an `if __name__ == "__main__":` block.
 	HasMainBlock bool
 
+	// HasShebang indicates if the first line is an interpreter directive
+	// such as "#!/usr/bin/env python3". Together with HasMainBlock it marks
+	// a file that is meant to be executed directly.
+	HasShebang bool
+
+	// Interpreter is the program named by the shebang, without its directory:
+	// "python3" for both "#!/usr/bin/env python3" and "#!/usr/bin/python3".
+	// Empty if there is no shebang.
+	Interpreter string
+
 	// IsTestFile indicates if the file appears to be a test file.
 	// This is a HEURISTIC based on filename patterns (test_*.py, *_test.py).
 	IsTestFile bool
+
+	// ResourceReferences lists resource paths passed as string literals to
+	// the configured loader functions, in order of first appearance.
+	// Empty unless enabled with WithResourceLoaders.
+	ResourceReferences []string
+
+	// ImportsByOrigin groups the imported top-level modules by
+	// util.OriginStdlib, util.OriginFirstParty and util.OriginThirdParty.
+	// Relative imports are always first-party and are listed as written
+	// (".utils", ".."). Nil until ClassifyImports is called.
+	ImportsByOrigin map[string][]string
+
+	// Decorators lists the names of the decorators applied to functions,
+	// methods and classes, without arguments ("app.route", "pytest.fixture"),
+	// in order of first appearance. HEURISTIC: any line starting with "@"
+	// outside a docstring counts, whatever its indentation.
+	Decorators []string
+
+	// ManipulatesSysPath indicates the file changes sys.path at runtime, e.g.
+	// with sys.path.insert(0, ...). Modules it then imports may come from
+	// directories the resolver knows nothing about, so its dependencies may be
+	// incomplete. HEURISTIC: detected from the statement text only.
+	ManipulatesSysPath bool
+
+	// TestFramework is the test framework the file imports: TestFrameworkPytest,
+	// TestFrameworkUnittest or TestFrameworkNose, or empty if it imports none.
+	// A file importing several is classified by the one driving the run:
+	// pytest runs unittest and nose tests, and nose runs unittest ones, so
+	// pytest wins over nose, which wins over unittest. Importing only
+	// unittest.mock does not make a file a unittest test.
+	TestFramework string
+
+	// Metadata holds module-level dunder assignments with string literal
+	// values, e.g. "__version__" -> "1.2.3". A later assignment overrides an
+	// earlier one, as at runtime. Nil unless enabled with WithMetadata.
+	Metadata map[string]string
+
+	// All lists the names in the module's __all__, in order. Adjacent string
+	// literals are concatenated as in Python, so "foo" "bar" is the single
+	// name "foobar", and "__all__ += [...]" extends the list. Nil if the
+	// module assigns no __all__. HEURISTIC: only unindented assignments of a
+	// list or tuple literal are read, and elements that are not string
+	// literals are skipped.
+	All []string
 }
 
+// Test frameworks reported in ParseResult.TestFramework and accepted by the
+// python_test_framework directive.
+const (
+	TestFrameworkPytest   = "pytest"
+	TestFrameworkUnittest = "unittest"
+	TestFrameworkNose     = "nose"
+)
+
+// parserBackend names the parser in a util.ParseError.
+const parserBackend = "heuristic"
+
 // PythonParser provides HEURISTIC parsing of Python source files using regex.
 //
 // # Heuristic Parsing
@@ -64,7 +147,8 @@ type ParseResult struct {
 // The following edge cases may produce incorrect results:
 //   - Import statements inside multi-line strings are matched as real imports
 //   - Multi-line import statements with unusual formatting may be missed
-//   - Conditional imports (inside if/try blocks) are treated as regular imports
+//   - Conditional imports (inside if/try blocks) are treated as regular imports,
+//     except under `if TYPE_CHECKING:` (see ParseResult.TypingOnlyImports)
 //   - Dynamic imports (importlib) are not detected
 //
 // # Thread Safety
@@ -83,14 +167,56 @@ type PythonParser struct {
 
 	// HEURISTIC: Matches `if __name__ == "__main__":` or similar
 	mainBlockRegex *regexp.Regexp
+
+	// HEURISTIC: Matches a decorator line such as "@app.route("/")"
+	decoratorRegex *regexp.Regexp
+
+	// HEURISTIC: Matches a statement that changes sys.path
+	sysPathRegex *regexp.Regexp
+
+	// HEURISTIC: Finds literal resource paths; nil unless WithResourceLoaders is used
+	resourceScanner *util.ResourceRefScanner
+
+	// HEURISTIC: Matches `__name__ = "value"` at module level; nil unless WithMetadata is used
+	metadataRegex *regexp.Regexp
+
+	// HEURISTIC: Matches the start of an `__all__ = [...]` assignment at module level
+	allRegex *regexp.Regexp
+
+	// HEURISTIC: Matches `if TYPE_CHECKING:`
+	typeCheckingRegex *regexp.Regexp
+}
+
+// ParserOption configures the parser.
+type ParserOption func(*PythonParser)
+
+// WithResourceLoaders enables HEURISTIC extraction of resource references:
+// string literals passed to any of the named functions (e.g. "open",
+// "pkgutil.get_data") are recorded in ParseResult.ResourceReferences.
+// Disabled by default.
+func WithResourceLoaders(loaders []string) ParserOption {
+	return func(p *PythonParser) {
+		p.resourceScanner = util.NewResourceRefScanner(loaders)
+	}
+}
+
+// WithMetadata enables HEURISTIC extraction of module-level metadata such as
+// `__version__ = "1.2.3"` or `__author__ = 'Jane'` into ParseResult.Metadata.
+// Only unindented assignments of a plain string literal are recorded; computed
+// values (e.g. importlib.metadata lookups) are skipped. Disabled by default.
+func WithMetadata() ParserOption {
+	return func(p *PythonParser) {
+		// Captures: [full match, dunder name, double-quoted value, single-quoted value]
+		p.metadataRegex = regexp.MustCompile(`^(__[a-zA-Z][a-zA-Z0-9_]*__)\s*(?::\s*str\s*)?=\s*(?:"([^"\\]*)"|'([^'\\]*)')\s*(?:#.*)?$`)
+	}
 }
 
 // NewParser creates a new Python parser with HEURISTIC regex patterns.
 //
 // The patterns are designed to match common Python import conventions.
 // They do NOT validate Python syntax; they extract metadata that looks correct.
-func NewParser() *PythonParser {
-	return &PythonParser{
+func NewParser(opts ...ParserOption) *PythonParser {
+	p := &PythonParser{
 		// HEURISTIC: Match import statements
 		// Handles: "import os", "import os.path", "import os as operating_system"
 		// Limitation: Matches imports inside strings (false positive)
@@ -109,7 +235,36 @@ func NewParser() *PythonParser {
 		// HEURISTIC: Match main block
 		// Handles: if __name__ == "__main__": (with single or double quotes)
 		mainBlockRegex: regexp.MustCompile(`^\s*if\s+__name__\s*==\s*['""]__main__['""]\s*:`),
+
+		// HEURISTIC: Match decorators
+		// Handles: "@property", "@app.route("/")", "@pytest.fixture(scope="module")"
+		// Captures: [full match, dotted decorator name]
+		decoratorRegex: regexp.MustCompile(`^\s*@\s*([a-zA-Z_][a-zA-Z0-9_]*(?:\.[a-zA-Z_][a-zA-Z0-9_]*)*)`),
+
+		// HEURISTIC: Match sys.path manipulation
+		// Handles: "sys.path.insert(0, d)", "sys.path.append(d)", "sys.path = [...]",
+		// "sys.path += [...]", "sys.path[:0] = [...]"
+		// Limitation: Misses aliases such as "from sys import path"
+		sysPathRegex: regexp.MustCompile(`\bsys\.path\s*(?:\.\s*(?:insert|append|extend|remove|pop|clear)\s*\(|(?:\[[^\]]*\]\s*)?\+?=(?:[^=]|$))`),
+
+		// HEURISTIC: Match __all__ assignments
+		// Handles: "__all__ = [...]", "__all__ = (...)", "__all__: list[str] = [...]",
+		// "__all__ += [...]"
+		// Captures: [full match, "+" when extending]
+		allRegex: regexp.MustCompile(`^__all__\s*(?::[^=]*)?(\+?)=\s*[\[(]`),
+
+		// HEURISTIC: Match the guard of typing-only imports
+		// Handles: "if TYPE_CHECKING:", "if typing.TYPE_CHECKING:"
+		// Captures: [full match, indentation]
+		// Limitation: Misses compound conditions ("if TYPE_CHECKING or ...")
+		typeCheckingRegex: regexp.MustCompile(`^(\s*)if\s+(?:typing\s*\.\s*)?TYPE_CHECKING\s*:\s*(?:#.*)?$`),
+	}
+
+	for _, opt := range opts {
+		opt(p)
 	}
+
+	return p
 }
 
 // ParseFile parses a Python file and returns the parse result.
@@ -117,25 +272,67 @@ func NewParser() *PythonParser {
 // This method performs HEURISTIC parsing using regex pattern matching.
 // Results are accurate for conventional Python code but may be incorrect
 // for edge cases. See PythonParser documentation for known limitations.
+// The file is decoded per its PEP 263 coding comment, if it has one.
 func (p *PythonParser) ParseFile(path string) (*ParseResult, error) {
-	file, err := os.Open(path)
+	return util.TimeParse(path, func() (*ParseResult, error) {
+		return p.parseFile(path)
+	})
+}
+
+// parseFile is ParseFile without the timing for util.TraceParses.
+func (p *PythonParser) parseFile(path string) (*ParseResult, error) {
+	content, err := readPythonSource(path)
 	if err != nil {
-		return nil, err
+		return nil, util.NewParseError(path, parserBackend, err)
 	}
-	defer func() { _ = file.Close() }()
 
 	result := &ParseResult{
 		FromImports: make(map[string][]string),
 		IsTestFile:  isTestFile(path),
 	}
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(strings.NewReader(content))
 	inMultilineString := false
 	multilineDelim := ""
+	firstLine := true
+	lineNum := 0
+
+	// HEURISTIC: An __all__ literal spanning lines is collected until its
+	// closing bracket; extendAll tells an assignment from "+="
+	inAll, pendingAll := false, ""
+	extendAll := false
+
+	// HEURISTIC: Indentation of the enclosing `if TYPE_CHECKING:` line, or -1
+	// outside such a block; typing and runtime track where modules are
+	// imported
+	typingIndent := -1
+	typing, runtime := make(map[string]bool), make(map[string]bool)
+	recordModule := func(module string) {
+		if typingIndent >= 0 {
+			typing[module] = true
+		} else {
+			runtime[module] = true
+		}
+	}
 
 	for scanner.Scan() {
+		lineNum++
 		line := scanner.Text()
 
+		if inAll {
+			pendingAll += "\n" + line
+			if names, ok := parseAllNames(pendingAll); ok {
+				result.All = assignAll(result.All, names, extendAll)
+				inAll = false
+			}
+			continue
+		}
+
+		if firstLine {
+			firstLine = false
+			result.HasShebang, result.Interpreter = parseShebang(line)
+		}
+
 		// HEURISTIC: Track multiline strings to skip their content
 		// This prevents matching import-like text inside docstrings
 		if !inMultilineString {
@@ -165,11 +362,59 @@ func (p *PythonParser) ParseFile(path string) (*ParseResult, error) {
 			continue
 		}
 
+		// Track `if TYPE_CHECKING:` blocks; a line indented no deeper than
+		// the guard, such as its "else:", ends the block
+		if trimmed != "" {
+			indent := len(line) - len(strings.TrimLeft(line, " \t"))
+			if typingIndent >= 0 && indent <= typingIndent {
+				typingIndent = -1
+			}
+			if matches := p.typeCheckingRegex.FindStringSubmatch(line); matches != nil {
+				typingIndent = len(matches[1])
+				continue
+			}
+		}
+
 		// Check for main block
 		if p.mainBlockRegex.MatchString(line) {
 			result.HasMainBlock = true
 		}
 
+		if matches := p.decoratorRegex.FindStringSubmatch(line); len(matches) > 1 {
+			if !slices.Contains(result.Decorators, matches[1]) {
+				result.Decorators = append(result.Decorators, matches[1])
+			}
+		}
+
+		if p.sysPathRegex.MatchString(line) {
+			result.ManipulatesSysPath = true
+		}
+
+		for _, ref := range p.resourceScanner.Scan(line) {
+			if !slices.Contains(result.ResourceReferences, ref) {
+				result.ResourceReferences = append(result.ResourceReferences, ref)
+			}
+		}
+
+		if p.metadataRegex != nil {
+			if matches := p.metadataRegex.FindStringSubmatch(line); len(matches) > 3 {
+				if result.Metadata == nil {
+					result.Metadata = make(map[string]string)
+				}
+				result.Metadata[matches[1]] = matches[2] + matches[3]
+			}
+		}
+
+		if matches := p.allRegex.FindStringSubmatch(line); matches != nil {
+			extendAll = matches[1] == "+"
+			if names, ok := parseAllNames(line[len(matches[0]):]); ok {
+				result.All = assignAll(result.All, names, extendAll)
+			} else {
+				inAll, pendingAll = true, line[len(matches[0]):]
+			}
+			continue
+		}
+
 		// Check for import statements
 		if matches := p.importRegex.FindStringSubmatch(line); len(matches) > 1 {
 			// Handle multiple imports on one line: import os, sys, re
@@ -182,6 +427,8 @@ func (p *PythonParser) ParseFile(path string) (*ParseResult, error) {
 				}
 				if imp != "" {
 					result.Imports = append(result.Imports, getTopLevelModule(imp))
+					recordModule(getTopLevelModule(imp))
+					result.TestFramework = preferTestFramework(result.TestFramework, testFrameworkOf(imp, nil))
 				}
 			}
 		}
@@ -195,9 +442,10 @@ func (p *PythonParser) ParseFile(path string) (*ParseResult, error) {
 			importedNames := parseImportNames(names)
 			if len(importedNames) > 0 {
 				result.RelativeImports = append(result.RelativeImports, RelativeImport{
-					Level:  len(dots),
-					Module: module,
-					Names:  importedNames,
+					Level:      len(dots),
+					Module:     module,
+					Names:      importedNames,
+					TypingOnly: typingIndent >= 0,
 				})
 			}
 			continue
@@ -213,14 +461,24 @@ func (p *PythonParser) ParseFile(path string) (*ParseResult, error) {
 			if len(importedNames) > 0 {
 				topLevel := getTopLevelModule(module)
 				result.FromImports[topLevel] = append(result.FromImports[topLevel], importedNames...)
+				recordModule(topLevel)
+				result.TestFramework = preferTestFramework(result.TestFramework, testFrameworkOf(module, importedNames))
 			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, err
+		// The scanner stops on the line it could not read
+		return nil, &util.ParseError{Path: path, Backend: parserBackend, Line: lineNum + 1, Err: err}
 	}
 
+	for module := range typing {
+		if !runtime[module] {
+			result.TypingOnlyImports = append(result.TypingOnlyImports, module)
+		}
+	}
+	slices.Sort(result.TypingOnlyImports)
+
 	return result, nil
 }
 
@@ -272,9 +530,142 @@ func parseImportNames(names string) []string {
 	return result
 }
 
-// isTestFile checks if a file path indicates a test file.
+// parseAllNames reads the string literals of an __all__ literal, given the
+// text after its opening bracket up to at least the closing one, and returns
+// false if the literal is not closed yet. Adjacent literals are joined into a
+// single name, comments and line continuations are skipped, and an element
+// that contains anything but string literals yields no name.
+func parseAllNames(s string) ([]string, bool) {
+	var names []string
+	var name strings.Builder
+	literal, other := false, false
+	endElement := func() {
+		if literal && !other {
+			names = append(names, name.String())
+		}
+		name.Reset()
+		literal, other = false, false
+	}
+
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\'':
+			raw := i > 0 && (s[i-1] == 'r' || s[i-1] == 'R')
+			j := i + 1
+			for ; j < len(s) && s[j] != c && s[j] != '\n'; j++ {
+				if s[j] == '\\' && !raw && j+1 < len(s) {
+					j++
+				}
+				name.WriteByte(s[j])
+			}
+			literal = true
+			i = j
+		case c == '#':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case c == ',' && depth == 0:
+			endElement()
+		case c == '[' || c == '(' || c == '{':
+			depth++
+			other = true
+		case c == ']' || c == ')' || c == '}':
+			if depth == 0 {
+				endElement()
+				return names, true
+			}
+			depth--
+		case c == ' ' || c == '\t' || c == '\n' || c == '\\':
+		case (c == 'r' || c == 'R' || c == 'u' || c == 'U') && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\''):
+			// A string prefix, read with the literal that follows
+		default:
+			other = true
+		}
+	}
+	return nil, false
+}
+
+// assignAll applies an __all__ assignment of names to the names assigned so
+// far: "+=" extends them, "=" replaces them.
+func assignAll(all, names []string, extend bool) []string {
+	if !extend {
+		all = nil
+	}
+	return append(append([]string{}, all...), names...)
+}
+
+// parseShebang reports whether line is a shebang and returns the interpreter
+// it names. With /usr/bin/env the interpreter is the first argument that is
+// not an env option or VAR=value assignment ("#!/usr/bin/env -S python3 -u"
+// yields "python3").
+func parseShebang(line string) (bool, string) {
+	rest, ok := strings.CutPrefix(line, "#!")
+	if !ok {
+		return false, ""
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return true, ""
+	}
+
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, arg := range fields[1:] {
+			if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+				interpreter = path.Base(arg)
+				break
+			}
+		}
+	}
+	return true, interpreter
+}
+
+// testFrameworkOf returns the test framework an import of module (with the
+// imported names, for "from module import ...") pulls in, or "" for none.
+func testFrameworkOf(module string, names []string) string {
+	switch getTopLevelModule(module) {
+	case "pytest", "_pytest":
+		return TestFrameworkPytest
+	case "nose", "nose2":
+		return TestFrameworkNose
+	case "unittest":
+		// unittest.mock is used with every framework
+		if module == "unittest.mock" || strings.HasPrefix(module, "unittest.mock.") {
+			return ""
+		}
+		if module == "unittest" && len(names) > 0 && !slices.ContainsFunc(names, func(n string) bool { return n != "mock" }) {
+			return ""
+		}
+		return TestFrameworkUnittest
+	}
+	return ""
+}
+
+// testFrameworkRank orders the test frameworks by which one runs the tests
+// of the others.
+var testFrameworkRank = map[string]int{
+	TestFrameworkUnittest: 1,
+	TestFrameworkNose:     2,
+	TestFrameworkPytest:   3,
+}
+
+// preferTestFramework returns whichever of the frameworks a and b runs the
+// tests of the other.
+func preferTestFramework(a, b string) string {
+	if testFrameworkRank[b] > testFrameworkRank[a] {
+		return b
+	}
+	return a
+}
+
+// isTestFile checks if a file path indicates a test file. Both the file name
+// and the directory components are compared case-insensitively, so a
+// "Tests/" directory on a case-insensitive filesystem counts as "tests/".
 func isTestFile(path string) bool {
-	base := strings.ToLower(path)
+	base := strings.ToLower(filepath.ToSlash(path))
 	return strings.HasSuffix(base, "_test.py") ||
 		strings.HasPrefix(base, "test_") ||
 		strings.Contains(base, "/test_") ||
@@ -303,6 +694,55 @@ func (r *ParseResult) GetAllImports() []string {
 	return result
 }
 
+// GetRuntimeImports returns GetAllImports without the modules imported only
+// for type checking (TypingOnlyImports).
+func (r *ParseResult) GetRuntimeImports() []string {
+	return slices.DeleteFunc(r.GetAllImports(), func(module string) bool {
+		return slices.Contains(r.TypingOnlyImports, module)
+	})
+}
+
+// GetProductionDependencies returns the sorted, deduplicated modules imported
+// by results, skipping test files (IsTestFile) so the set reflects only what
+// production code needs.
+func GetProductionDependencies(results []*ParseResult) []string {
+	seen := make(map[string]bool)
+	for _, r := range results {
+		if r.IsTestFile {
+			continue
+		}
+		for _, imp := range r.GetAllImports() {
+			seen[imp] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(seen))
+}
+
+// ClassifyImports fills in ImportsByOrigin. Modules known to IsStdlib are
+// stdlib; of the rest, those for which isFirstParty reports true (typically
+// because the workspace indexes a rule providing the module) are first-party,
+// and the remainder are third-party.
+func (r *ParseResult) ClassifyImports(isFirstParty func(module string) bool) {
+	r.ImportsByOrigin = util.GroupByOrigin(r.GetAllImports(), func(module string) string {
+		switch {
+		case IsStdlib(module):
+			return util.OriginStdlib
+		case isFirstParty != nil && isFirstParty(module):
+			return util.OriginFirstParty
+		default:
+			return util.OriginThirdParty
+		}
+	})
+
+	for _, rel := range r.RelativeImports {
+		imp := strings.Repeat(".", rel.Level) + rel.Module
+		if !slices.Contains(r.ImportsByOrigin[util.OriginFirstParty], imp) {
+			r.ImportsByOrigin[util.OriginFirstParty] = append(r.ImportsByOrigin[util.OriginFirstParty], imp)
+		}
+	}
+	slices.Sort(r.ImportsByOrigin[util.OriginFirstParty])
+}
+
 // ResolveRelativeImport resolves a relative import to an absolute module path.
 //
 // Parameters:
@@ -1,9 +1,15 @@
 package python
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/util"
 )
 
 func TestParseFile(t *testing.T) {
@@ -197,6 +203,33 @@ import external_package
 	}
 }
 
+func TestParseFileInvalidUTF8(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "latin1.py")
+
+	// "caf\xe9" is latin-1 encoded and not valid UTF-8.
+	content := "import os\n" +
+		"# caf\xe9 \xff\xfe\n" +
+		"from requests import Session\n" +
+		"NAME = \"\xe9t\xe9\"\n"
+	if err := os.WriteFile(testFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser()
+	result, err := parser.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	if len(result.Imports) != 1 || result.Imports[0] != "os" {
+		t.Errorf("expected ['os'], got %v", result.Imports)
+	}
+	if names := result.FromImports["requests"]; len(names) != 1 || names[0] != "Session" {
+		t.Errorf("expected requests -> ['Session'], got %v", result.FromImports)
+	}
+}
+
 // ============================================================================
 // Additional Parser Edge Case Tests
 // ============================================================================
@@ -748,6 +781,10 @@ func TestIsTestFileExtended(t *testing.T) {
 		{"EXAMPLE_TEST.PY", true}, // case insensitive
 		{"/path/to/tests/module.py", true},
 		{"project/tests/subdir/file.py", true},
+		{"src/Tests/bar.py", true}, // directory components are case insensitive
+		{"src/TESTS/bar.py", true},
+		{"Src/Tests/Test_Foo.py", true},
+		{"pkg/Test_Helpers.py", true},
 
 		// Negative cases - tests/foo.py without leading / doesn't match /tests/
 		{"tests/foo.py", false}, // doesn't match /tests/ pattern (needs leading /)
@@ -761,6 +798,8 @@ func TestIsTestFileExtended(t *testing.T) {
 		{"test.py", false},   // just "test" not "test_"
 		{"unittest.py", false},
 		{"src/main.py", false},
+		{"src/Testing/bar.py", false},
+		{"Tests/foo.py", false}, // same as tests/foo.py
 	}
 
 	for _, tt := range tests {
@@ -1082,3 +1121,546 @@ func TestNewParser(t *testing.T) {
 		t.Error("mainBlockRegex is nil")
 	}
 }
+
+func TestParseFileResourceReferences(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "loader.py")
+
+	content := `
+import pkgutil
+
+DATA = pkgutil.get_data(__name__, "data/schema.json")
+CONFIG = load_resource('config.yaml')
+AGAIN = load_resource("config.yaml")
+DYNAMIC = load_resource(name)
+FORMATTED = load_resource(f"{name}.yaml")
+# load_resource("commented.yaml")
+`
+	if err := os.WriteFile(testFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	parser := NewParser(WithResourceLoaders([]string{"load_resource"}))
+	result, err := parser.ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	// get_data is not a configured loader, and its first argument isn't a literal anyway.
+	if len(result.ResourceReferences) != 1 || result.ResourceReferences[0] != "config.yaml" {
+		t.Errorf("ResourceReferences = %v, want [config.yaml]", result.ResourceReferences)
+	}
+
+	result, err = NewParser().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if len(result.ResourceReferences) != 0 {
+		t.Errorf("expected no resource references by default, got %v", result.ResourceReferences)
+	}
+}
+
+func TestParseFileMetadata(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "__init__.py")
+
+	content := `"""Package docstring.
+
+__version__ = "0.0.0"
+"""
+
+__version__ = "1.2.3"
+__author__ = 'Jane Doe'  # maintainer
+__license__: str = "Apache-2.0"
+__all__ = ["foo"]
+__build__ = compute_build()
+# __email__ = "commented@example.com"
+
+def configure():
+    __version__ = "9.9.9"
+`
+	if err := os.WriteFile(testFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	result, err := NewParser(WithMetadata()).ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	want := map[string]string{
+		"__version__": "1.2.3",
+		"__author__":  "Jane Doe",
+		"__license__": "Apache-2.0",
+	}
+	if !reflect.DeepEqual(result.Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", result.Metadata, want)
+	}
+
+	result, err = NewParser().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if result.Metadata != nil {
+		t.Errorf("expected no metadata by default, got %v", result.Metadata)
+	}
+}
+
+func TestParseFileAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{name: "implicit_concatenation", content: `__all__ = ["foo" "bar"]` + "\n", want: []string{"foobar"}},
+		{name: "mixed_quotes_and_prefixes", content: `__all__ = ('a' "b", r"c\d", u'e')` + "\n", want: []string{"ab", `c\d`, "e"}},
+		{
+			name: "multiline",
+			content: `__all__ = [
+    "first",  # the first
+    "sec"
+    "ond",
+    'third',
+]
+import os
+`,
+			want: []string{"first", "second", "third"},
+		},
+		{name: "extended", content: "__all__ = [\"a\"]\n__all__ += [\"b\" \"c\"]\n", want: []string{"a", "bc"}},
+		{name: "reassigned", content: "__all__ = [\"a\"]\n__all__ = [\"b\"]\n", want: []string{"b"}},
+		{name: "annotated", content: "__all__: list[str] = [\"x\"]\n", want: []string{"x"}},
+		{name: "non_literal_elements_skipped", content: "__all__ = [\"a\", name, \"b\" + suffix, \"c\"]\n", want: []string{"a", "c"}},
+		{name: "empty", content: "__all__ = []\n", want: []string{}},
+		{name: "indented_ignored", content: "if True:\n    __all__ = [\"a\"]\n", want: nil},
+		{name: "absent", content: "import os\n", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), "mod.py")
+			if err := os.WriteFile(testFile, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			result, err := NewParser().ParseFile(testFile)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+			if !reflect.DeepEqual(result.All, tt.want) {
+				t.Errorf("All = %#v, want %#v", result.All, tt.want)
+			}
+		})
+	}
+
+	// Imports after a multi-line __all__ are still parsed
+	testFile := filepath.Join(t.TempDir(), "mod.py")
+	if err := os.WriteFile(testFile, []byte(tests[2].content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewParser().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if !slices.Contains(result.Imports, "os") {
+		t.Errorf("Imports = %v, want os after the __all__ literal", result.Imports)
+	}
+}
+
+func TestParseFileDecorators(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test_app.py")
+
+	content := `"""Docstring mentioning @docstring.decorator."""
+
+import pytest
+from flask import Flask
+
+app = Flask(__name__)
+
+@app.route("/users/<id>", methods=["GET"])
+def get_user(id):
+    return id
+
+@pytest.fixture(scope="module")
+def client():
+    return app.test_client()
+
+class Handler:
+    @property
+    def name(self):
+        return "handler"
+
+    @app.route("/health")
+    def health(self):
+        return "ok"
+
+# @commented.out
+@ dataclasses.dataclass
+class Config:
+    debug: bool = False
+`
+	if err := os.WriteFile(testFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	result, err := NewParser().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	want := []string{"app.route", "pytest.fixture", "property", "dataclasses.dataclass"}
+	if !reflect.DeepEqual(result.Decorators, want) {
+		t.Errorf("Decorators = %v, want %v", result.Decorators, want)
+	}
+}
+
+func TestParseFileManipulatesSysPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"insert", "import os, sys\nsys.path.insert(0, os.path.dirname(__file__))\nimport vendored\n", true},
+		{"append", "import sys\nif True:\n    sys.path.append('/opt/lib')\n", true},
+		{"assign", "import sys\nsys.path = ['/opt/lib'] + sys.path\n", true},
+		{"augmented assign", "import sys\nsys.path += ['/opt/lib']\n", true},
+		{"slice assign", "import sys\nsys.path[:0] = ['/opt/lib']\n", true},
+		{"read only", "import sys\nprint(sys.path)\nif sys.path == []:\n    pass\n", false},
+		{"comment", "import sys\n# sys.path.insert(0, '.')\n", false},
+		{"docstring", "\"\"\"\nCall sys.path.insert(0, '.') first.\n\"\"\"\nimport sys\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), "main.py")
+			if err := os.WriteFile(testFile, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			result, err := NewParser().ParseFile(testFile)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+			if result.ManipulatesSysPath != tt.want {
+				t.Errorf("ManipulatesSysPath = %v, want %v", result.ManipulatesSysPath, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFileTestFramework(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"pytest", "import pytest\n\ndef test_add():\n    assert 1 + 1 == 2\n", TestFrameworkPytest},
+		{"pytest from", "from pytest import fixture, raises\n", TestFrameworkPytest},
+		{"unittest", "import unittest\n\nclass AddTest(unittest.TestCase):\n    pass\n", TestFrameworkUnittest},
+		{"unittest from", "from unittest import TestCase, mock\n", TestFrameworkUnittest},
+		{"nose", "from nose.tools import assert_equal\n", TestFrameworkNose},
+		{"nose2", "import nose2\n", TestFrameworkNose},
+		{"pytest with unittest", "import unittest\nimport pytest\n", TestFrameworkPytest},
+		{"nose with unittest", "import unittest\nfrom nose import with_setup\n", TestFrameworkNose},
+		{"mock only", "from unittest import mock\nimport unittest.mock\nfrom unittest.mock import patch\n", ""},
+		{"mock with pytest", "from unittest.mock import patch\nimport pytest\n", TestFrameworkPytest},
+		{"none", "import os\nfrom collections import defaultdict\n", ""},
+		{"commented", "# import pytest\nimport os\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), "test_math.py")
+			if err := os.WriteFile(testFile, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			result, err := NewParser().ParseFile(testFile)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+			if result.TestFramework != tt.want {
+				t.Errorf("TestFramework = %q, want %q", result.TestFramework, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFileLatin1CodingComment(t *testing.T) {
+	// "é" is the single byte 0xE9 in Latin-1, which is not valid UTF-8.
+	content := "#!/usr/bin/env python\n" +
+		"# -*- coding: latin-1 -*-\n" +
+		"\"\"\"Utilitaires de r\xe9sum\xe9.\"\"\"\n" +
+		"import os\n" +
+		"from utils.texte import r\xe9sum\xe9, na\xefve as naive\n"
+
+	testFile := filepath.Join(t.TempDir(), "resume.py")
+	if err := os.WriteFile(testFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	result, err := NewParser().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if !reflect.DeepEqual(result.Imports, []string{"os"}) {
+		t.Errorf("Imports = %v, want [os]", result.Imports)
+	}
+	want := []string{"résumé", "naïve"}
+	if got := result.FromImports["utils"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("FromImports[utils] = %q, want %q", got, want)
+	}
+}
+
+func TestParseFileParseError(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	missing := filepath.Join(tmpDir, "missing.py")
+	_, err := NewParser().ParseFile(missing)
+	var pe *util.ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("ParseFile(missing) error = %v, want a *util.ParseError", err)
+	}
+	if pe.Path != missing || pe.Backend != "heuristic" {
+		t.Errorf("ParseError = %+v, want path %s, backend heuristic", pe, missing)
+	}
+
+	// A line past the scanner's buffer fails on that line
+	huge := filepath.Join(tmpDir, "huge.py")
+	content := "import os\nDATA = \"" + strings.Repeat("x", 128*1024) + "\"\n"
+	if err := os.WriteFile(huge, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	_, err = NewParser().ParseFile(huge)
+	if !errors.As(err, &pe) {
+		t.Fatalf("ParseFile(huge) error = %v, want a *util.ParseError", err)
+	}
+	if pe.Path != huge || pe.Line != 2 {
+		t.Errorf("ParseError = %+v, want path %s at line 2", pe, huge)
+	}
+}
+
+func TestGetProductionDependencies(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"service.py":       "import requests\nfrom myapp.models import User\n",
+		"test_service.py":  "import pytest\nfrom unittest import mock\nimport requests\n",
+		"tests/helpers.py": "import factory\n",
+	}
+	var paths []string
+	for rel, content := range files {
+		path := filepath.Join(tmpDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	results, err := NewParser().ParseFiles(paths)
+	if err != nil {
+		t.Fatalf("ParseFiles failed: %v", err)
+	}
+
+	want := []string{"myapp", "requests"}
+	if got := GetProductionDependencies(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProductionDependencies = %v, want %v", got, want)
+	}
+}
+
+func TestParseResultClassifyImports(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "service.py")
+
+	content := `import os
+import json
+from collections import defaultdict
+import requests
+from flask import Flask
+import myapp.models
+from myapp.utils import helper
+from . import views
+from ..core import config
+`
+	if err := os.WriteFile(testFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	result, err := NewParser().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	workspace := map[string]bool{"myapp": true}
+	result.ClassifyImports(func(module string) bool { return workspace[module] })
+
+	want := map[string][]string{
+		util.OriginStdlib:     {"collections", "json", "os"},
+		util.OriginFirstParty: {".", "..core", "myapp"},
+		util.OriginThirdParty: {"flask", "requests"},
+	}
+	if !reflect.DeepEqual(result.ImportsByOrigin, want) {
+		t.Errorf("ImportsByOrigin = %v, want %v", result.ImportsByOrigin, want)
+	}
+}
+
+func TestParseFileShebang(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		wantShebang     bool
+		wantInterpreter string
+	}{
+		{
+			name:            "env_python3",
+			content:         "#!/usr/bin/env python3\nimport sys\n\nif __name__ == \"__main__\":\n    sys.exit(0)\n",
+			wantShebang:     true,
+			wantInterpreter: "python3",
+		},
+		{
+			name:            "absolute_path",
+			content:         "#!/usr/bin/python3.12\nimport os\n",
+			wantShebang:     true,
+			wantInterpreter: "python3.12",
+		},
+		{
+			name:            "env_with_options",
+			content:         "#!/usr/bin/env -S PYTHONUNBUFFERED=1 pypy3 -u\n",
+			wantShebang:     true,
+			wantInterpreter: "pypy3",
+		},
+		{
+			name:            "space_after_bang",
+			content:         "#! /usr/local/bin/python\n",
+			wantShebang:     true,
+			wantInterpreter: "python",
+		},
+		{
+			name:            "non_python_interpreter",
+			content:         "#!/bin/sh\n",
+			wantShebang:     true,
+			wantInterpreter: "sh",
+		},
+		{
+			name:            "crlf_line_ending",
+			content:         "#!/usr/bin/env python3\r\nimport os\r\n",
+			wantShebang:     true,
+			wantInterpreter: "python3",
+		},
+		{
+			name:        "no_shebang",
+			content:     "import os\n",
+			wantShebang: false,
+		},
+		{
+			name:        "comment_first_line",
+			content:     "# !/usr/bin/env python3\n",
+			wantShebang: false,
+		},
+		{
+			name:        "shebang_not_first_line",
+			content:     "\n#!/usr/bin/env python3\n",
+			wantShebang: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), "tool.py")
+			if err := os.WriteFile(testFile, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+
+			result, err := NewParser().ParseFile(testFile)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+
+			if result.HasShebang != tt.wantShebang {
+				t.Errorf("HasShebang = %v, want %v", result.HasShebang, tt.wantShebang)
+			}
+			if result.Interpreter != tt.wantInterpreter {
+				t.Errorf("Interpreter = %q, want %q", result.Interpreter, tt.wantInterpreter)
+			}
+		})
+	}
+}
+
+func TestParseFileTypeCheckingImports(t *testing.T) {
+	content := `from __future__ import annotations
+
+import os
+from typing import TYPE_CHECKING
+
+if TYPE_CHECKING:
+    # Only needed for annotations
+    import numpy as np
+    from pandas import DataFrame
+    from os import PathLike
+
+    from .models import User
+else:
+    import json
+
+import requests
+
+
+def load(path: PathLike) -> DataFrame:
+    if typing.TYPE_CHECKING:
+        from yaml import Loader
+    return requests.get(os.fspath(path))
+`
+	testFile := filepath.Join(t.TempDir(), "loader.py")
+	if err := os.WriteFile(testFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	result, err := NewParser().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	// os is imported at runtime too, and json only in the else branch
+	wantTyping := []string{"numpy", "pandas", "yaml"}
+	if !reflect.DeepEqual(result.TypingOnlyImports, wantTyping) {
+		t.Errorf("TypingOnlyImports = %v, want %v", result.TypingOnlyImports, wantTyping)
+	}
+
+	// Typing-only imports are still reported as imports
+	all := result.GetAllImports()
+	for _, module := range []string{"numpy", "pandas", "yaml", "json", "requests"} {
+		if !slices.Contains(all, module) {
+			t.Errorf("GetAllImports() = %v, missing %q", all, module)
+		}
+	}
+
+	runtime := result.GetRuntimeImports()
+	slices.Sort(runtime)
+	wantRuntime := []string{"__future__", "json", "os", "requests", "typing"}
+	if !reflect.DeepEqual(runtime, wantRuntime) {
+		t.Errorf("GetRuntimeImports() = %v, want %v", runtime, wantRuntime)
+	}
+
+	wantRelative := []RelativeImport{{Level: 1, Module: "models", Names: []string{"User"}, TypingOnly: true}}
+	if !reflect.DeepEqual(result.RelativeImports, wantRelative) {
+		t.Errorf("RelativeImports = %+v, want %+v", result.RelativeImports, wantRelative)
+	}
+}
+
+func TestParseFileNoTypeCheckingImports(t *testing.T) {
+	content := "import os\n\nif DEBUG:\n    import pdb\n\nTYPE_CHECKING = False\nif TYPE_CHECKING or os.environ.get(\"X\"):\n    import yaml\n"
+	testFile := filepath.Join(t.TempDir(), "main.py")
+	if err := os.WriteFile(testFile, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	result, err := NewParser().ParseFile(testFile)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if len(result.TypingOnlyImports) != 0 {
+		t.Errorf("TypingOnlyImports = %v, want none", result.TypingOnlyImports)
+	}
+}
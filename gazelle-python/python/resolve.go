@@ -1,6 +1,9 @@
 package python
 
 import (
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
 	"github.com/bazelbuild/bazel-gazelle/config"
 	"github.com/bazelbuild/bazel-gazelle/label"
 	"github.com/bazelbuild/bazel-gazelle/repo"
@@ -10,15 +13,15 @@ import (
 
 // Imports implements resolve.Resolver.
 func (*pythonLang) Imports(c *config.Config, r *rule.Rule, f *rule.File) []resolve.ImportSpec {
-	// Return import specs for this rule
-	// The imports are stored as a private attribute during generation
-	if imports := r.PrivateAttr("python_imports"); imports != nil {
-		if importList, ok := imports.([]string); ok {
-			specs := make([]resolve.ImportSpec, 0, len(importList))
-			for _, imp := range importList {
+	// Index the modules the rule provides, recorded during generation.
+	// Rules that provide none, such as tests, are not importable.
+	if provides := r.PrivateAttr("python_provides"); provides != nil {
+		if modules, ok := provides.([]string); ok {
+			specs := make([]resolve.ImportSpec, 0, len(modules))
+			for _, module := range modules {
 				specs = append(specs, resolve.ImportSpec{
 					Lang: pythonName,
-					Imp:  imp,
+					Imp:  module,
 				})
 			}
 			return specs
@@ -56,14 +59,19 @@ func (*pythonLang) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Rem
 			continue
 		}
 
-		// Try to resolve using the rule index first
-		spec := resolve.ImportSpec{
-			Lang: pythonName,
-			Imp:  imp,
+		// Try the resolve maps, the aggregate targets, then the rule index
+		l, ok := deppolicy.Pinned(c, from, imp)
+		if !ok {
+			l, ok = deppolicy.Aggregate(c, imp)
+		}
+		if !ok {
+			l, ok = findModule(ix, from, imp)
 		}
-		if matches := ix.FindRulesByImport(spec, pythonName); len(matches) > 0 {
-			// Use the first match
-			l := matches[0].Label
+		if ok {
+			if l.Equal(from) {
+				// The rule imports one of its own modules
+				continue
+			}
 			var depLabel string
 			if l.Repo == "" && l.Pkg == from.Pkg {
 				// Same package, use relative label
@@ -85,11 +93,32 @@ func (*pythonLang) Resolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.Rem
 					seen[pipLabel] = true
 					deps = append(deps, pipLabel)
 				}
+				continue
 			}
 		}
+		deppolicy.RecordUnresolved(from, imp)
 	}
 
+	deps = deppolicy.Apply(c, from, deps)
 	if len(deps) > 0 {
-		r.SetAttr("deps", deps)
+		r.SetAttr("deps", deppolicy.Deps(deps))
+	}
+}
+
+// findModule finds the rule that provides the module imp, or else the
+// nearest of its parent packages, so "mypkg.sub.mod" resolves to the rule of
+// "mypkg.sub" when no rule provides the module itself.
+func findModule(ix *resolve.RuleIndex, from label.Label, imp string) (label.Label, bool) {
+	for module := imp; module != ""; {
+		spec := resolve.ImportSpec{Lang: pythonName, Imp: module}
+		if l, ok := deppolicy.Choose(from, imp, ix.FindRulesByImport(spec, pythonName)); ok {
+			return l, true
+		}
+		i := strings.LastIndexByte(module, '.')
+		if i < 0 {
+			break
+		}
+		module = module[:i]
 	}
+	return label.NoLabel, false
 }
@@ -0,0 +1,178 @@
+package python
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/language"
+	"github.com/bazelbuild/bazel-gazelle/resolve"
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+func TestResolveSrcLayoutImport(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"src/mypkg/__init__.py":  "",
+		"src/mypkg/core.py":      "from . import helpers\n",
+		"src/mypkg/helpers.py":   "def helper(): pass\n",
+		"app/main.py":            "from mypkg import core\nimport mypkg.helpers\n",
+		"app/test_main.py":       "import mypkg\n",
+		"tools/lint_rules.py":    "import mypkg.core.Checker\n",
+		"src/mypkg/sub/extra.py": "",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lang := NewLanguage()
+	rootConfig := &config.Config{RepoRoot: root, Exts: make(map[string]interface{})}
+	rootConfig.Exts[pythonName] = NewPythonConfig()
+	lang.Configure(rootConfig, "", &rule.File{Directives: []rule.Directive{
+		{Key: "python_enabled", Value: "true"},
+		{Key: "python_import_roots", Value: "src"},
+	}})
+
+	type generated struct {
+		config  *config.Config
+		rule    *rule.Rule
+		imports any
+		label   label.Label
+	}
+	var gen []generated
+	ix := resolve.NewRuleIndex(func(*rule.Rule, string) resolve.Resolver { return lang })
+	for _, rel := range []string{"src/mypkg", "src/mypkg/sub", "app", "tools"} {
+		c := rootConfig.Clone()
+		lang.Configure(c, rel, nil)
+		res := lang.GenerateRules(language.GenerateArgs{
+			Config: c,
+			Dir:    filepath.Join(root, filepath.FromSlash(rel)),
+			Rel:    rel,
+		})
+		f := rule.EmptyFile(filepath.Join(root, rel, "BUILD.bazel"), rel)
+		for i, r := range res.Gen {
+			ix.AddRule(c, r, f)
+			gen = append(gen, generated{c, r, res.Imports[i], label.New("", rel, r.Name())})
+		}
+	}
+	ix.Finish()
+
+	deps := make(map[string][]string)
+	for _, g := range gen {
+		lang.Resolve(g.config, ix, nil, g.rule, g.imports, g.label)
+		deps[g.label.String()] = g.rule.AttrStrings("deps")
+	}
+
+	want := map[string][]string{
+		"//src/mypkg":     nil,
+		"//src/mypkg/sub": nil,
+		"//app":           {"//src/mypkg"},
+		"//app:app_test":  {"//src/mypkg"},
+		"//tools":         {"//src/mypkg"},
+	}
+	for l, wantDeps := range want {
+		if got, ok := deps[l]; !ok {
+			t.Errorf("no rule %s generated; got rules %v", l, deps)
+		} else if !slices.Equal(got, wantDeps) {
+			t.Errorf("deps of %s = %v, want %v", l, got, wantDeps)
+		}
+	}
+}
+
+func TestProvidedModules(t *testing.T) {
+	got := providedModules("mypkg", []string{"core.py", "core.pyi", "helpers.py"})
+	want := []string{"mypkg", "mypkg.core", "mypkg.helpers"}
+	if !slices.Equal(got, want) {
+		t.Errorf("providedModules = %v, want %v", got, want)
+	}
+
+	got = providedModules("", []string{"setup.py"})
+	if want := []string{"setup"}; !slices.Equal(got, want) {
+		t.Errorf("providedModules at the import root = %v, want %v", got, want)
+	}
+}
+
+func TestResolveAggregateTarget(t *testing.T) {
+	root := t.TempDir()
+	policy := filepath.Join(root, deppolicy.DefaultPath)
+	if err := os.MkdirAll(filepath.Dir(policy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "[aggregate]\n\"vendor.foo.*\" = \"//third_party/foo\"\n"
+	if err := os.WriteFile(policy, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deppolicy.Reset()
+	t.Cleanup(deppolicy.Reset)
+
+	pc := NewPythonConfig()
+	pc.Enabled = true
+	c := &config.Config{RepoRoot: root, Exts: map[string]interface{}{pythonName: pc}}
+	ix := resolve.NewRuleIndex(func(*rule.Rule, string) resolve.Resolver { return NewLanguage() })
+	ix.Finish()
+
+	r := rule.NewRule("py_library", "app")
+	imports := []string{"vendor.foo", "vendor.foo.api.client", "vendor.foo.impl.store", "vendor.bar"}
+	NewLanguage().Resolve(c, ix, nil, r, imports, label.New("", "app", "app"))
+
+	if got, want := r.AttrStrings("deps"), []string{"//third_party/foo"}; !slices.Equal(got, want) {
+		t.Errorf("deps = %v, want %v", got, want)
+	}
+	if got := deppolicy.UnresolvedImports(); len(got) != 1 || got[0].Import != "vendor.bar" {
+		t.Errorf("UnresolvedImports() = %v, want only vendor.bar", got)
+	}
+}
+
+func TestResolvePinnedImport(t *testing.T) {
+	root := t.TempDir()
+	maps := map[string]string{
+		"":             "[resolve]\n\"yaml\" = \"@pip//pyyaml\"\n\"requests\" = \"@pip//requests\"\n",
+		"services/pay": "[resolve]\n\"yaml\" = \"@pip//ruamel_yaml\"\n",
+	}
+	for dir, content := range maps {
+		path := filepath.Join(root, filepath.FromSlash(dir), resolvemap.DefaultPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deppolicy.Reset()
+	t.Cleanup(deppolicy.Reset)
+
+	pc := NewPythonConfig()
+	pc.Enabled = true
+	c := &config.Config{RepoRoot: root, Exts: map[string]interface{}{pythonName: pc}}
+	ix := resolve.NewRuleIndex(func(*rule.Rule, string) resolve.Resolver { return NewLanguage() })
+	ix.Finish()
+
+	tests := []struct {
+		pkg  string
+		want []string
+	}{
+		{pkg: "services/pay", want: []string{"@pip//ruamel_yaml", "@pip//requests"}},
+		{pkg: "app", want: []string{"@pip//pyyaml", "@pip//requests"}},
+	}
+	for _, tt := range tests {
+		r := rule.NewRule("py_library", "lib")
+		NewLanguage().Resolve(c, ix, nil, r, []string{"yaml", "requests"}, label.New("", tt.pkg, "lib"))
+		if got := r.AttrStrings("deps"); !slices.Equal(got, tt.want) {
+			t.Errorf("deps of //%s = %v, want %v", tt.pkg, got, tt.want)
+		}
+	}
+	if got := deppolicy.UnresolvedImports(); len(got) != 0 {
+		t.Errorf("UnresolvedImports() = %v, want none", got)
+	}
+}
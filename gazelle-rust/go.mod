@@ -0,0 +1,5 @@
+module github.com/albertocavalcante/bazelle/gazelle-rust
+
+go 1.25
+
+toolchain go1.25.6
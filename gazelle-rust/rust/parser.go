@@ -0,0 +1,280 @@
+// Package rust provides Rust source parsing for the bazelle Rust extension.
+//
+// The extension is EXPERIMENTAL: it only extracts the crates and paths a
+// source file refers to, and is not registered with the bazelle CLI, so
+// nothing generates Rust rules until a project opts in by building against
+// this package.
+package rust
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+	"github.com/albertocavalcante/bazelle/pkg/util"
+)
+
+// Rust AST node types from the tree-sitter-rust grammar.
+const (
+	nodeUseDeclaration         = "use_declaration"
+	nodeExternCrateDeclaration = "extern_crate_declaration"
+	nodeScopedIdentifier       = "scoped_identifier"
+	nodeScopedUseList          = "scoped_use_list"
+	nodeUseList                = "use_list"
+	nodeUseAsClause            = "use_as_clause"
+	nodeUseWildcard            = "use_wildcard"
+	nodeIdentifier             = "identifier"
+	nodeCrate                  = "crate"
+	nodeSelf                   = "self"
+	nodeSuper                  = "super"
+)
+
+// pathSeparator separates the segments of a Rust path.
+const pathSeparator = "::"
+
+// ParseResult contains the parsed metadata from a Rust file.
+type ParseResult struct {
+	// Uses lists the paths brought into scope by use declarations, sorted.
+	// Braced lists are expanded ("use a::{b, c::D}" -> "a::b", "a::c::D"),
+	// aliases are dropped ("use a::B as C" -> "a::B") and glob imports keep
+	// their "*" ("use a::*" -> "a::*"). Paths relative to the current crate
+	// keep their crate, self or super prefix.
+	Uses []string
+
+	// ExternCrates lists the crates named by extern crate declarations,
+	// sorted, without their aliases.
+	ExternCrates []string
+
+	// Crates lists the external crates the file refers to through its uses
+	// and extern crates, sorted: the first segment of every path that does
+	// not start at crate, self or super. Standard library crates are
+	// included; see IsStdCrate.
+	Crates []string
+
+	// FilePath is the path to the parsed file.
+	FilePath string
+
+	// HasSyntaxErrors reports that the source did not parse cleanly. The
+	// result then holds whatever was extracted from the well-formed parts.
+	HasSyntaxErrors bool
+}
+
+// Parser extracts use and extern crate declarations from Rust source files
+// using tree-sitter.
+//
+// # Deterministic Behavior
+//
+// Parsing walks the tree-sitter-rust AST, so declarations inside comments or
+// string literals are ignored, and uses nested in modules, functions or
+// braced lists are all found.
+//
+// # Dependencies
+//
+// Requires a tree-sitter backend with Rust support. The CGO backend supports
+// Rust; the wazero backend currently does not.
+type Parser struct {
+	backend treesitter.Backend
+}
+
+// newBackend creates the tree-sitter backend of a Parser. Tests replace it
+// to check that NewParser closes the backends it rejects.
+var newBackend = treesitter.NewBackend
+
+// NewParser creates a Rust parser on the given tree-sitter backend type.
+//
+// Returns treesitter.ErrLanguageNotSupported if the backend cannot parse Rust,
+// after closing it.
+func NewParser(typ treesitter.BackendType) (*Parser, error) {
+	backend, err := newBackend(typ)
+	if err != nil {
+		return nil, fmt.Errorf("create tree-sitter backend: %w", err)
+	}
+
+	if !backend.SupportsLanguage(treesitter.Rust) {
+		_ = backend.Close()
+		return nil, treesitter.ErrLanguageNotSupported{Language: treesitter.Rust, Backend: backend.Name()}
+	}
+
+	return &Parser{backend: backend}, nil
+}
+
+// ParseFile reads and parses a Rust source file.
+func (p *Parser) ParseFile(ctx context.Context, path string) (*ParseResult, error) {
+	return util.TimeParse(path, func() (*ParseResult, error) {
+		content, err := util.ReadSourceFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return p.ParseContent(ctx, content, path)
+	})
+}
+
+// ParseContent parses Rust source code and returns the paths and crates it
+// refers to.
+func (p *Parser) ParseContent(ctx context.Context, content, path string) (_ *ParseResult, retErr error) {
+	parser, err := p.backend.NewParser(treesitter.Rust)
+	if err != nil {
+		return nil, fmt.Errorf("create Rust parser: %w", err)
+	}
+	defer func() {
+		if closeErr := parser.Close(); closeErr != nil && retErr == nil {
+			retErr = fmt.Errorf("close parser: %w", closeErr)
+		}
+	}()
+
+	source := []byte(content)
+	tree, err := parser.Parse(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("parse Rust source: %w", err)
+	}
+	defer func() {
+		if closeErr := tree.Close(); closeErr != nil && retErr == nil {
+			retErr = fmt.Errorf("close tree: %w", closeErr)
+		}
+	}()
+
+	root := tree.RootNode()
+	result := &ParseResult{
+		FilePath:        path,
+		Uses:            make([]string, 0),
+		ExternCrates:    make([]string, 0),
+		Crates:          make([]string, 0),
+		HasSyntaxErrors: tree.HasError(),
+	}
+
+	for _, decl := range treesitter.FindByType(root, nodeUseDeclaration) {
+		for _, child := range treesitter.NamedChildren(decl) {
+			collectUses(child, "", source, &result.Uses)
+		}
+	}
+
+	for _, decl := range treesitter.FindByType(root, nodeExternCrateDeclaration) {
+		if name := externCrateName(decl, source); name != "" {
+			result.ExternCrates = append(result.ExternCrates, name)
+		}
+	}
+
+	result.Uses = sortedUnique(result.Uses)
+	result.ExternCrates = sortedUnique(result.ExternCrates)
+	for _, use := range result.Uses {
+		if crate := crateOf(use); crate != "" {
+			result.Crates = append(result.Crates, crate)
+		}
+	}
+	result.Crates = sortedUnique(append(result.Crates, result.ExternCrates...))
+	return result, nil
+}
+
+// Close releases the underlying tree-sitter backend.
+func (p *Parser) Close() error {
+	if p.backend != nil {
+		return p.backend.Close()
+	}
+	return nil
+}
+
+// IsStdCrate reports whether the crate is one the Rust toolchain provides
+// rather than a dependency: std, core, alloc, proc_macro and test.
+func IsStdCrate(name string) bool {
+	switch name {
+	case "std", "core", "alloc", "proc_macro", "test":
+		return true
+	}
+	return false
+}
+
+// collectUses appends the paths a use tree brings into scope, prefixed by
+// the path of the enclosing braced lists.
+//
+// The grammar models "use a::{b::{c, d as e}, f::*};" as a scoped_use_list
+// whose children are the path "a" and a use_list holding another
+// scoped_use_list and a use_wildcard; "d as e" is a use_as_clause.
+// Visibility modifiers and comments are the other named children of a
+// declaration and are skipped.
+func collectUses(node treesitter.Node, prefix string, source []byte, uses *[]string) {
+	switch node.Type() {
+	case nodeIdentifier, nodeScopedIdentifier, nodeCrate, nodeSelf, nodeSuper:
+		// "use a::{self, b}" imports a itself.
+		if name := pathName(node, source); name == nodeSelf && prefix != "" {
+			*uses = append(*uses, prefix)
+		} else {
+			*uses = append(*uses, joinPath(prefix, name))
+		}
+	case nodeUseAsClause:
+		if children := treesitter.NamedChildren(node); len(children) > 0 {
+			collectUses(children[0], prefix, source, uses)
+		}
+	case nodeUseWildcard:
+		var path string
+		if children := treesitter.NamedChildren(node); len(children) > 0 {
+			path = pathName(children[0], source)
+		}
+		*uses = append(*uses, joinPath(joinPath(prefix, path), "*"))
+	case nodeScopedUseList:
+		path := prefix
+		for _, child := range treesitter.NamedChildren(node) {
+			if child.Type() == nodeUseList {
+				collectUses(child, path, source, uses)
+			} else {
+				path = joinPath(prefix, pathName(child, source))
+			}
+		}
+	case nodeUseList:
+		for _, child := range treesitter.NamedChildren(node) {
+			collectUses(child, prefix, source, uses)
+		}
+	}
+}
+
+// externCrateName returns the crate named by an extern_crate_declaration
+// node, whose children are [extern, crate, identifier, as, identifier, ;]
+// with an alias, or "" if it names none.
+func externCrateName(decl treesitter.Node, source []byte) string {
+	for _, child := range treesitter.NamedChildren(decl) {
+		if child.Type() == nodeIdentifier {
+			return child.Content(source)
+		}
+	}
+	return ""
+}
+
+// pathName returns the text of a path node. Whitespace is dropped so paths
+// split across lines come out whole, and so is the leading "::" of paths
+// anchored at the extern prelude ("::serde::Serialize").
+func pathName(node treesitter.Node, source []byte) string {
+	name := strings.Join(strings.Fields(node.Content(source)), "")
+	return strings.TrimPrefix(name, pathSeparator)
+}
+
+// joinPath appends the segments of name to the path prefix.
+func joinPath(prefix, name string) string {
+	switch {
+	case prefix == "":
+		return name
+	case name == "":
+		return prefix
+	}
+	return prefix + pathSeparator + name
+}
+
+// crateOf returns the crate a used path starts at, or "" if it is relative
+// to the current crate.
+func crateOf(path string) string {
+	crate, _, _ := strings.Cut(path, pathSeparator)
+	switch crate {
+	case nodeCrate, nodeSelf, nodeSuper, "*", "":
+		return ""
+	}
+	if strings.HasPrefix(crate, "$") {
+		return "" // $crate in macro definitions
+	}
+	return crate
+}
+
+// sortedUnique sorts values and drops duplicates.
+func sortedUnique(values []string) []string {
+	slices.Sort(values)
+	return slices.Compact(values)
+}
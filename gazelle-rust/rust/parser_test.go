@@ -0,0 +1,197 @@
+package rust
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/treesitter"
+)
+
+var ctx = context.Background()
+
+// newTestParser returns a Rust parser, skipping when no backend supports Rust.
+func newTestParser(t *testing.T) *Parser {
+	t.Helper()
+	p, err := NewParser(treesitter.BackendAuto)
+	if err != nil {
+		var unsupported treesitter.ErrLanguageNotSupported
+		if errors.As(err, &unsupported) {
+			t.Skipf("Rust not supported: %v", err)
+		}
+		t.Fatalf("NewParser failed: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Close() })
+	return p
+}
+
+func TestParser_ParseFile(t *testing.T) {
+	p := newTestParser(t)
+
+	content := `extern crate log;
+extern crate serde_json as json;
+
+use std::collections::HashMap;
+use serde::{Deserialize, Serialize};
+use tokio::sync::{mpsc, Mutex as AsyncMutex};
+pub(crate) use regex::Regex as Re;
+use ::anyhow::Result;
+use crate::model::User;
+use super::util::*;
+use self::inner::{self, Helper};
+use itertools::{Itertools, izip};
+
+// use commented::Out;
+
+mod inner {
+    use once_cell::sync::Lazy;
+    pub struct Helper;
+}
+
+fn main() {
+    use rand::Rng;
+    let s = "use in_string::Nope;";
+}
+`
+	path := filepath.Join(t.TempDir(), "main.rs")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := p.ParseFile(ctx, path)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if result.FilePath != path {
+		t.Errorf("FilePath: expected %q, got %q", path, result.FilePath)
+	}
+
+	expectedUses := []string{
+		"anyhow::Result",
+		"crate::model::User",
+		"itertools::Itertools",
+		"itertools::izip",
+		"once_cell::sync::Lazy",
+		"rand::Rng",
+		"regex::Regex",
+		"self::inner",
+		"self::inner::Helper",
+		"serde::Deserialize",
+		"serde::Serialize",
+		"std::collections::HashMap",
+		"super::util::*",
+		"tokio::sync::Mutex",
+		"tokio::sync::mpsc",
+	}
+	if !reflect.DeepEqual(result.Uses, expectedUses) {
+		t.Errorf("Uses: expected %v, got %v", expectedUses, result.Uses)
+	}
+
+	expectedExtern := []string{"log", "serde_json"}
+	if !reflect.DeepEqual(result.ExternCrates, expectedExtern) {
+		t.Errorf("ExternCrates: expected %v, got %v", expectedExtern, result.ExternCrates)
+	}
+
+	expectedCrates := []string{
+		"anyhow", "itertools", "log", "once_cell", "rand", "regex",
+		"serde", "serde_json", "std", "tokio",
+	}
+	if !reflect.DeepEqual(result.Crates, expectedCrates) {
+		t.Errorf("Crates: expected %v, got %v", expectedCrates, result.Crates)
+	}
+
+	if result.HasSyntaxErrors {
+		t.Error("valid source should not report syntax errors")
+	}
+}
+
+func TestParser_NestedUseList(t *testing.T) {
+	p := newTestParser(t)
+
+	content := "use a::{\n    b::{c, d::*},\n    e as f,\n};\n"
+	result, err := p.ParseContent(ctx, content, "lib.rs")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+
+	expected := []string{"a::b::c", "a::b::d::*", "a::e"}
+	if !reflect.DeepEqual(result.Uses, expected) {
+		t.Errorf("Uses: expected %v, got %v", expected, result.Uses)
+	}
+	if !reflect.DeepEqual(result.Crates, []string{"a"}) {
+		t.Errorf("Crates: expected [a], got %v", result.Crates)
+	}
+}
+
+func TestParser_SyntaxErrors(t *testing.T) {
+	p := newTestParser(t)
+
+	content := "use std::fmt;\n\nfn broken( {\n"
+	result, err := p.ParseContent(ctx, content, "lib.rs")
+	if err != nil {
+		t.Fatalf("ParseContent failed: %v", err)
+	}
+	if !result.HasSyntaxErrors {
+		t.Error("expected HasSyntaxErrors for broken source")
+	}
+	if !reflect.DeepEqual(result.Uses, []string{"std::fmt"}) {
+		t.Errorf("Uses: expected [std::fmt], got %v", result.Uses)
+	}
+}
+
+func TestIsStdCrate(t *testing.T) {
+	for name, want := range map[string]bool{
+		"std":        true,
+		"core":       true,
+		"alloc":      true,
+		"proc_macro": true,
+		"serde":      false,
+		"stdx":       false,
+	} {
+		if got := IsStdCrate(name); got != want {
+			t.Errorf("IsStdCrate(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+// closeCounter is a fake tree-sitter backend without Rust support that
+// counts how many of its kind are open. Only Name, SupportsLanguage and
+// Close may be called.
+type closeCounter struct {
+	treesitter.Backend
+	open *int
+}
+
+func (c *closeCounter) Name() string { return "counted" }
+
+func (c *closeCounter) SupportsLanguage(treesitter.Language) bool { return false }
+
+func (c *closeCounter) Close() error {
+	*c.open--
+	return nil
+}
+
+func TestNewParser_ClosesUnsupportedBackend(t *testing.T) {
+	open := 0
+	old := newBackend
+	t.Cleanup(func() { newBackend = old })
+	newBackend = func(treesitter.BackendType) (treesitter.Backend, error) {
+		open++
+		return &closeCounter{open: &open}, nil
+	}
+
+	_, err := NewParser(treesitter.BackendAuto)
+	var unsupported treesitter.ErrLanguageNotSupported
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("error = %v, want ErrLanguageNotSupported", err)
+	}
+	if unsupported.Language != treesitter.Rust {
+		t.Errorf("Language = %q, want %q", unsupported.Language, treesitter.Rust)
+	}
+	if open != 0 {
+		t.Errorf("%d backends left open", open)
+	}
+}
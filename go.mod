@@ -6,10 +6,12 @@ require (
 	github.com/BurntSushi/toml v1.4.0
 	github.com/EngFlow/gazelle_cc v0.5.0
 	github.com/bazelbuild/bazel-gazelle v0.47.0
+	github.com/bazelbuild/buildtools v0.0.0-20250930140053-2eb4fccefb52
 	github.com/cespare/xxhash/v2 v2.3.0
 	github.com/fsnotify/fsnotify v1.8.0
 	github.com/kisielk/errcheck v1.9.0
 	github.com/malivvan/tree-sitter v0.0.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/smacker/go-tree-sitter v0.0.0-20240827094217-dd81d9e9be82
 	github.com/spf13/cobra v1.10.2
 	go.uber.org/nilaway v0.0.0-20251208195206-89df5f7e6199
@@ -21,11 +23,9 @@ require (
 replace github.com/bazelbuild/bazel-gazelle => github.com/albertocavalcante/fork-bazel-gazelle v0.0.0-20260120124537-c16e1e6df9fc
 
 require (
-	github.com/bazelbuild/buildtools v0.0.0-20250930140053-2eb4fccefb52 // indirect
 	github.com/bmatcuk/doublestar/v4 v4.9.1 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/klauspost/compress v1.18.0 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
 	github.com/tetratelabs/wazero v1.8.2 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
@@ -55,7 +55,7 @@ func newLogger(format string) *zap.Logger {
 		atomLevel,
 	)
 
-	return zap.New(core)
+	return zap.New(zapcore.NewTee(core, recorderCore{}))
 }
 
 func customLevelEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
@@ -0,0 +1,110 @@
+package log
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Active warning recorders, see RecordWarnings.
+var (
+	recordersMu sync.Mutex
+	recorders   = make(map[*warningRecorder]struct{})
+	recording   atomic.Int32 // len(recorders), read without the lock
+)
+
+// warningRecorder collects the warnings of one RecordWarnings call.
+type warningRecorder struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+// RecordWarnings starts collecting the messages logged at warning level or
+// above, whatever the verbosity, and returns a function that stops and
+// returns them, formatted as "message (key=value, ...)" in the order they
+// were first logged and without duplicates. Recordings may overlap: each
+// one sees every warning logged in the process while it is active.
+func RecordWarnings() (stop func() []string) {
+	r := &warningRecorder{}
+	recordersMu.Lock()
+	recorders[r] = struct{}{}
+	recording.Store(int32(len(recorders)))
+	recordersMu.Unlock()
+
+	return func() []string {
+		recordersMu.Lock()
+		delete(recorders, r)
+		recording.Store(int32(len(recorders)))
+		recordersMu.Unlock()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return slices.Clone(r.warnings)
+	}
+}
+
+// add records warning unless r already has it.
+func (r *warningRecorder) add(warning string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.warnings, warning) {
+		r.warnings = append(r.warnings, warning)
+	}
+}
+
+// recorderCore is the zapcore.Core that passes warnings to the active
+// recorders. Every logger made by newLogger tees into one.
+type recorderCore struct {
+	fields []zapcore.Field // context added with With
+}
+
+func (c recorderCore) Enabled(l zapcore.Level) bool {
+	return l >= zapcore.WarnLevel && recording.Load() > 0
+}
+
+func (c recorderCore) With(fields []zapcore.Field) zapcore.Core {
+	return recorderCore{fields: append(slices.Clip(c.fields), fields...)}
+}
+
+func (c recorderCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c recorderCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	warning := formatWarning(ent.Message, append(slices.Clip(c.fields), fields...))
+
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+	for r := range recorders {
+		r.add(warning)
+	}
+	return nil
+}
+
+func (c recorderCore) Sync() error {
+	return nil
+}
+
+// formatWarning formats a warning message and its fields, sorted by key.
+func formatWarning(msg string, fields []zapcore.Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	pairs := make([]string, 0, len(enc.Fields))
+	for _, key := range slices.Sorted(maps.Keys(enc.Fields)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, enc.Fields[key]))
+	}
+	return msg + " (" + strings.Join(pairs, ", ") + ")"
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRecordWarnings(t *testing.T) {
+	Init(VerbosityError, "text")
+	t.Cleanup(func() { Init(VerbosityWarn, "text") })
+
+	Warn("before recording")
+
+	outer := RecordWarnings()
+	Warn("python file modifies sys.path", "file", "tools/run.py")
+	inner := RecordWarnings()
+	Component("daemon").Warnw("client gone", "error", errors.New("broken pipe"))
+	Info("not a warning")
+	Error("failed")
+	Warn("python file modifies sys.path", "file", "tools/run.py")
+
+	innerWant := []string{
+		"client gone (component=daemon, error=broken pipe)",
+		"failed",
+		"python file modifies sys.path (file=tools/run.py)",
+	}
+	if got := inner(); !slices.Equal(got, innerWant) {
+		t.Errorf("inner recording = %q, want %q", got, innerWant)
+	}
+	Warn("after inner")
+
+	want := []string{
+		"python file modifies sys.path (file=tools/run.py)",
+		"client gone (component=daemon, error=broken pipe)",
+		"failed",
+		"after inner",
+	}
+	if got := outer(); !slices.Equal(got, want) {
+		t.Errorf("outer recording = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+// Package wspath converts file paths to the workspace-relative form that
+// extensions report in diagnostics and use as resolution keys.
+package wspath
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Rel returns p relative to the workspace root, cleaned and slash-separated
+// like a Bazel package path: "src/app/Main.kt", or "" for the root itself.
+// A relative p is taken relative to root, so already workspace-relative
+// paths come back normalized.
+//
+// The paths are first compared as written, which keeps symlinks inside the
+// workspace under their workspace path. Only when p is not under root that
+// way are symlinks resolved on both, so a root or path reached through an
+// alias (such as macOS /tmp for /private/tmp) still matches. Paths that are
+// outside the workspace either way are an error.
+func Rel(root, p string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(absRoot, p)
+	}
+	p = filepath.Clean(p)
+
+	if rel, ok := within(absRoot, p); ok {
+		return rel, nil
+	}
+	if rel, ok := within(evalSymlinks(absRoot), evalSymlinks(p)); ok {
+		return rel, nil
+	}
+	return "", fmt.Errorf("%s is outside workspace %s", p, root)
+}
+
+// Display returns p relative to root for messages, or p unchanged if it is
+// not in the workspace.
+func Display(root, p string) string {
+	rel, err := Rel(root, p)
+	if err != nil {
+		return p
+	}
+	if rel == "" {
+		return "."
+	}
+	return rel
+}
+
+// within returns p relative to root in slash form if it is root or below.
+func within(root, p string) (string, bool) {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// evalSymlinks resolves the symlinks of p. Paths that do not exist, such as
+// files deleted since an event named them, are resolved through their
+// nearest existing ancestor.
+func evalSymlinks(p string) string {
+	var rest []string
+	for dir := p; ; {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(append([]string{real}, rest...)...)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return p
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+		dir = parent
+	}
+}
@@ -0,0 +1,82 @@
+package wspath
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRel(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src", "app"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	aliasDir := t.TempDir()
+	alias := filepath.Join(aliasDir, "ws")
+	if err := os.Symlink(root, alias); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	linked := filepath.Join(root, "linked")
+	if err := os.Symlink(filepath.Join(root, "src"), linked); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		root    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "absolute", root: root, path: filepath.Join(root, "src", "app", "Main.kt"), want: "src/app/Main.kt"},
+		{name: "absolute root", root: root, path: root, want: ""},
+		{name: "unclean absolute", root: root, path: root + "/src/../src/./app/", want: "src/app"},
+		{name: "relative", root: root, path: filepath.Join("src", "app"), want: "src/app"},
+		{name: "relative with dot-dot", root: root, path: "src/app/../lib/util.py", want: "src/lib/util.py"},
+		{name: "relative root", root: root, path: ".", want: ""},
+		{name: "path through aliased root", root: root, path: filepath.Join(alias, "src", "Main.kt"), want: "src/Main.kt"},
+		{name: "aliased root", root: alias, path: filepath.Join(root, "src"), want: "src"},
+		{name: "symlink in workspace keeps its path", root: root, path: filepath.Join(linked, "app"), want: "linked/app"},
+		{name: "missing file through alias", root: root, path: filepath.Join(alias, "src", "Deleted.kt"), want: "src/Deleted.kt"},
+		{name: "relative escaping root", root: root, path: "../elsewhere", wantErr: true},
+		{name: "absolute outside root", root: root, path: aliasDir, wantErr: true},
+		{name: "sibling with root as prefix", root: root, path: root + "-other/src", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Rel(tt.root, tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Rel(%q, %q) = %q, want error", tt.root, tt.path, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Rel(%q, %q) failed: %v", tt.root, tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("Rel(%q, %q) = %q, want %q", tt.root, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplay(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "Other.kt")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: filepath.Join(root, "src", "Main.kt"), want: "src/Main.kt"},
+		{path: root, want: "."},
+		{path: outside, want: outside},
+	}
+	for _, tt := range tests {
+		if got := Display(root, tt.path); got != tt.want {
+			t.Errorf("Display(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -56,6 +56,10 @@ type Config struct {
 
 	// Bzl configures the Bazel Starlark language extension.
 	Bzl BzlConfig `toml:"bzl"`
+
+	// Extensions lists extra source file extensions per language, on top
+	// of the built-in ones (e.g. kotlin = [".ktm"]).
+	Extensions map[string][]string `toml:"extensions"`
 }
 
 // LanguagesConfig specifies which languages to enable/disable.
@@ -86,7 +90,8 @@ type KotlinConfig struct {
 	// Enabled specifies whether the Kotlin extension is enabled.
 	Enabled *bool `toml:"enabled"`
 
-	// ParserBackend is the parsing strategy ("heuristic", "treesitter", "hybrid").
+	// ParserBackend is the parsing strategy ("heuristic", "treesitter", "hybrid",
+	// "adaptive").
 	ParserBackend string `toml:"parser_backend"`
 
 	// LibraryMacro is the macro to use for kt_jvm_library rules.
@@ -421,4 +426,16 @@ func (c *Config) Merge(other *Config) {
 	if other.Bzl.Enabled != nil {
 		c.Bzl.Enabled = other.Bzl.Enabled
 	}
+
+	// Merge extensions: every layer adds to the ones below it
+	for lang, exts := range other.Extensions {
+		if c.Extensions == nil {
+			c.Extensions = make(map[string][]string)
+		}
+		for _, ext := range exts {
+			if !slices.Contains(c.Extensions[lang], ext) {
+				c.Extensions[lang] = append(c.Extensions[lang], ext)
+			}
+		}
+	}
 }
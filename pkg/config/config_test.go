@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -100,6 +101,19 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMerge_Extensions(t *testing.T) {
+	base := NewConfig()
+	base.Merge(&Config{Extensions: map[string][]string{"kotlin": {".ktm"}}})
+	base.Merge(&Config{Extensions: map[string][]string{"kotlin": {".ktm", ".kt2"}, "python": {".pyw"}}})
+
+	if got := base.Extensions["kotlin"]; !slices.Equal(got, []string{".ktm", ".kt2"}) {
+		t.Errorf("kotlin extensions = %v, want [.ktm .kt2]", got)
+	}
+	if got := base.Extensions["python"]; !slices.Equal(got, []string{".pyw"}) {
+		t.Errorf("python extensions = %v, want [.pyw]", got)
+	}
+}
+
 func TestLoadConfigFile(t *testing.T) {
 	// Create a temporary config file
 	tmpDir := t.TempDir()
@@ -117,6 +131,9 @@ parser_backend = "treesitter"
 [python]
 enabled = true
 test_framework = "pytest"
+
+[extensions]
+kotlin = [".ktm"]
 `
 	if err := os.WriteFile(configPath, []byte(configContent), 0o644); err != nil {
 		t.Fatalf("failed to write config file: %v", err)
@@ -150,6 +167,11 @@ test_framework = "pytest"
 	if cfg.Python.TestFramework != "pytest" {
 		t.Errorf("python test framework should be 'pytest', got %q", cfg.Python.TestFramework)
 	}
+
+	// Check extensions
+	if got := cfg.Extensions["kotlin"]; !slices.Equal(got, []string{".ktm"}) {
+		t.Errorf("kotlin extensions should be [.ktm], got %v", got)
+	}
 }
 
 func TestApplyEnvironmentVariables(t *testing.T) {
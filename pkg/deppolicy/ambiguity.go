@@ -0,0 +1,109 @@
+package deppolicy
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/resolve"
+)
+
+// Ambiguity records an import that more than one target could satisfy.
+type Ambiguity struct {
+	// Import is the ambiguous import.
+	Import string
+
+	// From is the rule the import was resolved for.
+	From label.Label
+
+	// Chosen is the target the import was resolved to.
+	Chosen label.Label
+
+	// Candidates are all the targets that could satisfy the import, in the
+	// order Choose ranks them; Chosen is the first.
+	Candidates []label.Label
+}
+
+// String describes a for reports, e.g. `//app imports "foo.bar": chose
+// //lib/foo:bar over //lib:foo`.
+func (a Ambiguity) String() string {
+	others := make([]string, 0, len(a.Candidates)-1)
+	for _, l := range a.Candidates[1:] {
+		others = append(others, l.String())
+	}
+	return fmt.Sprintf("%s imports %q: chose %s over %s", a.From, a.Import, a.Chosen, strings.Join(others, ", "))
+}
+
+// Warning describes a as a warning of the run that recorded it, for the
+// warnings of update results: the description of String prefixed with
+// "ambiguous import: ".
+func (a Ambiguity) Warning() string {
+	return "ambiguous import: " + a.String()
+}
+
+var ambiguities []Ambiguity
+
+// Choose picks the target an import resolves to from the index matches for
+// it, which gazelle returns in no guaranteed order. The most specific
+// package wins (the one with the most path segments), and ties are broken
+// by the lexicographically smallest label, so the choice does not depend on
+// the order of matches. When several distinct targets match, the ambiguity
+// is logged and recorded for Ambiguities. It returns false if there are no
+// matches.
+func Choose(from label.Label, imp string, matches []resolve.FindResult) (label.Label, bool) {
+	candidates := make([]label.Label, 0, len(matches))
+	for _, m := range matches {
+		if !slices.ContainsFunc(candidates, m.Label.Equal) {
+			candidates = append(candidates, m.Label)
+		}
+	}
+	if len(candidates) == 0 {
+		return label.NoLabel, false
+	}
+	slices.SortFunc(candidates, compareCandidates)
+
+	if len(candidates) > 1 {
+		a := Ambiguity{Import: imp, From: from, Chosen: candidates[0], Candidates: candidates}
+		log.Info("ambiguous import", "rule", from.String(), "import", imp, "chose", a.Chosen.String(), "candidates", len(candidates))
+		mu.Lock()
+		ambiguities = append(ambiguities, a)
+		mu.Unlock()
+	}
+	return candidates[0], true
+}
+
+// compareCandidates orders deeper packages first, then labels
+// lexicographically.
+func compareCandidates(a, b label.Label) int {
+	return cmp.Or(
+		cmp.Compare(packageDepth(b.Pkg), packageDepth(a.Pkg)),
+		strings.Compare(a.String(), b.String()),
+	)
+}
+
+// packageDepth returns the number of path segments of pkg; the root package
+// has none.
+func packageDepth(pkg string) int {
+	if pkg == "" {
+		return 0
+	}
+	return strings.Count(pkg, "/") + 1
+}
+
+// Ambiguities returns the ambiguities recorded since the last Reset, sorted
+// by rule and import.
+func Ambiguities() []Ambiguity {
+	mu.Lock()
+	defer mu.Unlock()
+	sorted := slices.Clone(ambiguities)
+	slices.SortFunc(sorted, func(a, b Ambiguity) int {
+		return cmp.Or(
+			strings.Compare(a.From.String(), b.From.String()),
+			strings.Compare(a.Import, b.Import),
+		)
+	})
+	return sorted
+}
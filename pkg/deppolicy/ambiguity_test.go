@@ -0,0 +1,87 @@
+package deppolicy
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+	"github.com/bazelbuild/bazel-gazelle/resolve"
+)
+
+func findResults(labels ...label.Label) []resolve.FindResult {
+	results := make([]resolve.FindResult, len(labels))
+	for i, l := range labels {
+		results[i] = resolve.FindResult{Label: l}
+	}
+	return results
+}
+
+func TestChoose_Deterministic(t *testing.T) {
+	resetState(t)
+	from := label.New("", "app", "app")
+	shallow := label.New("", "lib", "foo")
+	deep := label.New("", "lib/foo", "bar")
+
+	// Every run must pick the same target whatever order the index returns
+	orders := [][]label.Label{{shallow, deep}, {deep, shallow}}
+	for range 3 {
+		for _, order := range orders {
+			got, ok := Choose(from, "foo.bar", findResults(order...))
+			if !ok || got != deep {
+				t.Fatalf("Choose(%v) = %v, %v; want %v", order, got, ok, deep)
+			}
+		}
+	}
+
+	ambiguities := Ambiguities()
+	if len(ambiguities) != 6 {
+		t.Fatalf("Ambiguities() returned %d entries, want 6: %v", len(ambiguities), ambiguities)
+	}
+	want := "//app imports \"foo.bar\": chose //lib/foo:bar over //lib:foo"
+	if got := ambiguities[0].String(); got != want {
+		t.Errorf("Ambiguity.String() = %q, want %q", got, want)
+	}
+	if got := ambiguities[0].Warning(); got != "ambiguous import: "+want {
+		t.Errorf("Ambiguity.Warning() = %q, want %q", got, "ambiguous import: "+want)
+	}
+	if got := ambiguities[0].Candidates; !slices.Equal(got, []label.Label{deep, shallow}) {
+		t.Errorf("Candidates = %v, want [%v %v]", got, deep, shallow)
+	}
+
+	Reset()
+	if got := Ambiguities(); len(got) != 0 {
+		t.Errorf("Ambiguities() after Reset = %v, want none", got)
+	}
+}
+
+func TestChoose_LexicographicTieBreak(t *testing.T) {
+	resetState(t)
+	from := label.New("", "app", "app")
+	a := label.New("", "lib/a", "util")
+	b := label.New("", "lib/b", "util")
+
+	for _, order := range [][]label.Label{{a, b}, {b, a}} {
+		if got, _ := Choose(from, "util", findResults(order...)); got != a {
+			t.Errorf("Choose(%v) = %v, want %v", order, got, a)
+		}
+	}
+}
+
+func TestChoose_SingleMatch(t *testing.T) {
+	resetState(t)
+	from := label.New("", "app", "app")
+	lib := label.New("", "lib", "lib")
+
+	// The same target matched twice is not ambiguous
+	got, ok := Choose(from, "lib", findResults(lib, lib))
+	if !ok || got != lib {
+		t.Errorf("Choose() = %v, %v; want %v", got, ok, lib)
+	}
+	if got := Ambiguities(); len(got) != 0 {
+		t.Errorf("Ambiguities() = %v, want none", got)
+	}
+
+	if _, ok := Choose(from, "missing", nil); ok {
+		t.Error("Choose() with no matches returned ok")
+	}
+}
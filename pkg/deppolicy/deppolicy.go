@@ -0,0 +1,295 @@
+// Package deppolicy enforces dependency policy on the deps a language
+// extension resolved for a rule, before they are written to the BUILD file.
+//
+// Policy is applied by hooks: functions that receive a rule's resolved deps
+// and return the deps to keep. A hook can drop or rewrite deps, and can veto
+// the result by returning an error. Apply runs the workspace's banned deps
+// list first, then every hook added with RegisterHook, in registration order.
+//
+// # Banned Deps
+//
+// The banned deps list lives at .bazelle/deps.toml and maps each banned label
+// to the reason it is banned:
+//
+//	# Fail the run when a banned dependency is found
+//	fail = true
+//
+//	[banned]
+//	"@maven//:log4j_log4j" = "use @maven//:org_apache_logging_log4j_log4j_api"
+//	"//legacy/utils"       = "legacy/utils is being deleted"
+//
+// Banned deps are always removed. With fail set, finding one is also an
+// error.
+//
+// # Aggregate Targets
+//
+// The same file can map import patterns to umbrella targets, for packages
+// that expose one target bundling their submodules:
+//
+//	[aggregate]
+//	"com.example.foo.*" = "//libs/foo"
+//
+// Patterns are written as in the resolve map (see package resolvemap): an
+// exact import, or a prefix ending in ".*" that matches the package and
+// everything below it, the most specific pattern winning. Language
+// extensions call Aggregate before looking an import up in the rule index,
+// so every import under com.example.foo resolves to //libs/foo rather than
+// to the fine-grained target providing it.
+//
+// # Resolve Maps
+//
+// Extensions call Pinned before anything else, so an import that the resolve
+// maps (see package resolvemap) pin to a label resolves to it whatever
+// automatic resolution would pick. The maps of the importing rule's
+// directory take precedence over those of the directories above it, which
+// take precedence over the global map.
+//
+// # Errors
+//
+// Gazelle's resolve step cannot return errors, so hook errors are logged and
+// recorded instead. Callers running gazelle call Reset before the run and
+// Errors after it to fail on any veto.
+//
+// # Ambiguous Imports
+//
+// When more than one target could satisfy an import, language extensions
+// call Choose to pick one deterministically. The ambiguities are recorded
+// the same way as errors, for Ambiguities to report after the run.
+//
+// # Merging
+//
+// Extensions set the resolved deps as a Deps value, which merges them into
+// the rule's existing deps list instead of replacing it, keeping the order
+// and comments of the deps that remain.
+package deppolicy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/albertocavalcante/bazelle/internal/log"
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// DefaultPath is the location of the banned deps list relative to the
+// workspace root.
+var DefaultPath = filepath.Join(".bazelle", "deps.toml")
+
+// Hook transforms the deps resolved for the rule labeled from. It returns the
+// deps to keep; a non-nil error vetoes the result, which is recorded for
+// Errors while the returned deps are still used.
+type Hook func(from label.Label, deps []string) ([]string, error)
+
+// Policy is a loaded banned deps list and its aggregate targets.
+type Policy struct {
+	// Path is the file the policy was loaded from, if any.
+	Path string
+
+	// Banned maps each banned label, in absolute form, to the reason it is
+	// banned.
+	Banned map[string]string
+
+	// Fail makes finding a banned dep an error rather than only removing it.
+	Fail bool
+
+	// Aggregates maps import patterns to the umbrella targets, in absolute
+	// form, that the matching imports resolve to. Nil if there are none.
+	Aggregates *resolvemap.Map
+}
+
+// file is the on-disk TOML layout.
+type file struct {
+	Fail      bool              `toml:"fail"`
+	Banned    map[string]string `toml:"banned"`
+	Aggregate map[string]string `toml:"aggregate"`
+}
+
+// Load reads the banned deps list and aggregate targets at path. A missing
+// file yields an empty policy.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Policy{Path: path}, nil
+		}
+		return nil, fmt.Errorf("read dep policy: %w", err)
+	}
+
+	var f file
+	if _, err := toml.Decode(string(data), &f); err != nil {
+		return nil, fmt.Errorf("parse dep policy %s: %w", path, err)
+	}
+
+	p := &Policy{Path: path, Banned: make(map[string]string, len(f.Banned)), Fail: f.Fail}
+	for dep, reason := range f.Banned {
+		l, err := label.Parse(dep)
+		if err != nil {
+			return nil, fmt.Errorf("parse dep policy %s: banned dep %q: %w", path, dep, err)
+		}
+		p.Banned[l.String()] = reason
+	}
+
+	if len(f.Aggregate) > 0 {
+		entries := make([]resolvemap.Entry, 0, len(f.Aggregate))
+		for pattern, target := range f.Aggregate {
+			l, err := label.Parse(target)
+			if err != nil {
+				return nil, fmt.Errorf("parse dep policy %s: aggregate target %q: %w", path, target, err)
+			}
+			if l.Relative {
+				return nil, fmt.Errorf("parse dep policy %s: aggregate target %q must be an absolute label", path, target)
+			}
+			entries = append(entries, resolvemap.Entry{Pattern: pattern, Label: l.String()})
+		}
+		p.Aggregates = resolvemap.New(path, entries)
+	}
+	return p, nil
+}
+
+// Aggregate returns the umbrella target that imp resolves to under the
+// aggregate targets of the workspace at c.RepoRoot, if a pattern matches it.
+// A policy that fails to load is recorded for Errors, as by Apply.
+func Aggregate(c *config.Config, imp string) (label.Label, bool) {
+	policy, err := policyFor(c.RepoRoot)
+	if err != nil {
+		record(err)
+	}
+	target, ok := policy.Aggregates.Lookup(imp)
+	if !ok {
+		return label.NoLabel, false
+	}
+	l, err := label.Parse(target)
+	if err != nil {
+		return label.NoLabel, false // Load checked the label
+	}
+	return l, true
+}
+
+// Prune removes the banned deps from deps, which are relative to the package
+// of from. It implements Hook, failing if Fail is set and any dep was
+// removed.
+func (p *Policy) Prune(from label.Label, deps []string) ([]string, error) {
+	if p == nil || len(p.Banned) == 0 {
+		return deps, nil
+	}
+
+	var kept, banned []string
+	for _, dep := range deps {
+		reason, ok := p.Banned[absDep(dep, from)]
+		if !ok {
+			kept = append(kept, dep)
+			continue
+		}
+		log.Warn("removing banned dependency", "rule", from.String(), "dep", dep, "reason", reason)
+		banned = append(banned, fmt.Sprintf("%s (%s)", dep, reason))
+	}
+
+	if p.Fail && len(banned) > 0 {
+		return kept, fmt.Errorf("%s depends on banned deps: %s", from, strings.Join(banned, ", "))
+	}
+	return kept, nil
+}
+
+// absDep returns dep as an absolute label string, or dep itself if it does
+// not parse.
+func absDep(dep string, from label.Label) string {
+	l, err := label.Parse(dep)
+	if err != nil {
+		return dep
+	}
+	return l.Abs(from.Repo, from.Pkg).String()
+}
+
+var (
+	mu       sync.Mutex
+	hooks    []Hook
+	policies = make(map[string]*Policy) // repo root -> loaded policy
+	errs     []error
+)
+
+// RegisterHook adds h to the hooks Apply runs after the banned deps list.
+func RegisterHook(h Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, h)
+}
+
+// Apply runs the banned deps list of the workspace at c.RepoRoot and then
+// the registered hooks over deps, returning the deps to set on the rule
+// labeled from. Errors are logged and recorded for Errors.
+func Apply(c *config.Config, from label.Label, deps []string) []string {
+	policy, err := policyFor(c.RepoRoot)
+	if err != nil {
+		record(err)
+	}
+
+	mu.Lock()
+	chain := append([]Hook{policy.Prune}, hooks...)
+	mu.Unlock()
+
+	deps = slices.Clone(deps)
+	for _, h := range chain {
+		kept, err := h(from, deps)
+		if err != nil {
+			record(err)
+		}
+		deps = kept
+	}
+	return deps
+}
+
+// policyFor returns the policy of the workspace at root, loading it on first
+// use. A policy that fails to load is reported once and treated as empty.
+func policyFor(root string) (*Policy, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if p, ok := policies[root]; ok {
+		return p, nil
+	}
+	p, err := Load(filepath.Join(root, DefaultPath))
+	if err != nil {
+		p = &Policy{}
+	}
+	policies[root] = p
+	return p, err
+}
+
+// record logs err and keeps it for Errors.
+func record(err error) {
+	log.Error("dependency policy violation", "error", err)
+	mu.Lock()
+	defer mu.Unlock()
+	errs = append(errs, err)
+}
+
+// Errors returns the errors recorded since the last Reset joined together,
+// or nil if there were none.
+func Errors() error {
+	mu.Lock()
+	defer mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// Reset clears the recorded errors, ambiguities and unresolved imports and
+// the loaded policies and resolve maps, so the next Apply, Aggregate or
+// Pinned reads their files again.
+// Registered hooks and the resolve maps in use through UseResolveMaps are
+// kept.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	errs = nil
+	ambiguities = nil
+	unresolved = nil
+	clear(policies)
+	clear(resolveMaps)
+	clear(resolveMapErrs)
+}
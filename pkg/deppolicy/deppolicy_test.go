@@ -0,0 +1,187 @@
+package deppolicy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// writeWorkspace creates a workspace whose banned deps list holds content
+// and returns a gazelle config rooted at it.
+func writeWorkspace(t *testing.T, content string) *config.Config {
+	t.Helper()
+	root := t.TempDir()
+	path := filepath.Join(root, DefaultPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &config.Config{RepoRoot: root}
+}
+
+// resetState clears recorded errors, cached policies and registered hooks
+// before and after the test.
+func resetState(t *testing.T) {
+	t.Helper()
+	clearAll := func() {
+		Reset()
+		mu.Lock()
+		hooks = nil
+		clear(usedResolveMaps)
+		mu.Unlock()
+	}
+	clearAll()
+	t.Cleanup(clearAll)
+}
+
+func TestApply_RemovesBannedDeps(t *testing.T) {
+	resetState(t)
+	c := writeWorkspace(t, `
+[banned]
+"@maven//:log4j_log4j" = "use slf4j"
+"//legacy/utils" = "being deleted"
+`)
+
+	from := label.New("", "app", "app")
+	got := Apply(c, from, []string{"//lib:core", "@maven//:log4j_log4j", "//legacy/utils:utils", ":helpers"})
+
+	if want := []string{"//lib:core", ":helpers"}; !slices.Equal(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+	if err := Errors(); err != nil {
+		t.Errorf("Errors() = %v, want nil without fail", err)
+	}
+}
+
+func TestApply_BannedDepFails(t *testing.T) {
+	resetState(t)
+	c := writeWorkspace(t, `
+fail = true
+
+[banned]
+"//legacy:utils" = "being deleted"
+`)
+
+	// ":utils" is relative to //legacy, so it names the banned target
+	from := label.New("", "legacy", "app")
+	got := Apply(c, from, []string{":utils", "//lib:core"})
+
+	if want := []string{"//lib:core"}; !slices.Equal(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+	err := Errors()
+	if err == nil {
+		t.Fatal("Errors() = nil, want banned dep error")
+	}
+	if !strings.Contains(err.Error(), ":utils (being deleted)") {
+		t.Errorf("Errors() = %v, want it to name the banned dep and reason", err)
+	}
+
+	Reset()
+	if err := Errors(); err != nil {
+		t.Errorf("Errors() after Reset = %v, want nil", err)
+	}
+}
+
+func TestApply_RunsRegisteredHooks(t *testing.T) {
+	resetState(t)
+	c := writeWorkspace(t, `
+[banned]
+"//banned:dep" = "no"
+`)
+
+	var seen []string
+	RegisterHook(func(from label.Label, deps []string) ([]string, error) {
+		seen = slices.Clone(deps)
+		return append(deps, "//extra:dep"), errors.New("vetoed")
+	})
+
+	got := Apply(c, label.New("", "app", "app"), []string{"//banned:dep", "//lib:core"})
+
+	if want := []string{"//lib:core"}; !slices.Equal(seen, want) {
+		t.Errorf("hook saw %v, want %v after the banned list", seen, want)
+	}
+	if want := []string{"//lib:core", "//extra:dep"}; !slices.Equal(got, want) {
+		t.Errorf("Apply() = %v, want %v", got, want)
+	}
+	if err := Errors(); err == nil || !strings.Contains(err.Error(), "vetoed") {
+		t.Errorf("Errors() = %v, want the hook's veto", err)
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	p, err := Load(filepath.Join(t.TempDir(), "deps.toml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(p.Banned) != 0 || p.Fail {
+		t.Errorf("Load() = %+v, want empty policy", p)
+	}
+}
+
+func TestLoad_InvalidLabel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deps.toml")
+	if err := os.WriteFile(path, []byte("[banned]\n\"//bad:a:b\" = \"x\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load() error = nil, want invalid label error")
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	resetState(t)
+	c := writeWorkspace(t, `
+[aggregate]
+"com.example.foo.*" = "//libs/foo"
+"com.example.foo.internal.*" = "//libs/foo:internal"
+"com.example.Single" = "@maven//:com_example_single"
+`)
+
+	tests := []struct {
+		imp  string
+		want string
+	}{
+		{imp: "com.example.foo", want: "//libs/foo"},
+		{imp: "com.example.foo.api.Client", want: "//libs/foo"},
+		{imp: "com.example.foo.internal.Cache", want: "//libs/foo:internal"},
+		{imp: "com.example.Single", want: "@maven//:com_example_single"},
+		{imp: "com.example.foobar.Thing"},
+		{imp: "com.example.Single.Nested"},
+	}
+	for _, tt := range tests {
+		got, ok := Aggregate(c, tt.imp)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("Aggregate(%q) = %v, want no match", tt.imp, got)
+			}
+			continue
+		}
+		if !ok || got.String() != tt.want {
+			t.Errorf("Aggregate(%q) = %v, %v, want %s", tt.imp, got, ok, tt.want)
+		}
+	}
+	if err := Errors(); err != nil {
+		t.Errorf("Errors() = %v, want nil", err)
+	}
+}
+
+func TestAggregate_InvalidTarget(t *testing.T) {
+	resetState(t)
+	c := writeWorkspace(t, "[aggregate]\n\"com.example.*\" = \":foo\"\n")
+
+	if got, ok := Aggregate(c, "com.example.Foo"); ok {
+		t.Errorf("Aggregate() = %v, want no match for an unloadable policy", got)
+	}
+	if err := Errors(); err == nil || !strings.Contains(err.Error(), "absolute label") {
+		t.Errorf("Errors() = %v, want relative target error", err)
+	}
+}
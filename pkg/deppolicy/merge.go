@@ -0,0 +1,127 @@
+package deppolicy
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/rule"
+	bzl "github.com/bazelbuild/buildtools/build"
+)
+
+// Deps is a deps attribute value that merges into the rule's existing deps
+// with the fewest edits, so an update only shows the deps that changed:
+//
+//   - existing deps that are still resolved keep their position and
+//     comments, and so do deps marked "# keep";
+//   - other existing deps are removed;
+//   - new deps are inserted where they sort, as buildifier orders labels,
+//     so a sorted list stays sorted.
+//
+// Extensions set it with r.SetAttr("deps", Deps(deps)). The attribute must
+// be in the kind's ResolveAttrs but not its MergeableAttrs, or gazelle drops
+// the existing deps before resolution and nothing is left to merge into.
+type Deps []string
+
+var (
+	_ rule.BzlExprValue = Deps(nil)
+	_ rule.Merger       = Deps(nil)
+)
+
+// BzlExpr implements rule.BzlExprValue.
+func (d Deps) BzlExpr() bzl.Expr {
+	return rule.ExprFromValue([]string(d))
+}
+
+// Merge implements rule.Merger. Deps that are not a plain list, such as a
+// list concatenated with a select, are merged the way gazelle merges them.
+func (d Deps) Merge(other bzl.Expr) bzl.Expr {
+	existing, ok := other.(*bzl.ListExpr)
+	if !ok {
+		return mergeDefault(d, other)
+	}
+
+	want := make(map[string]bool, len(d))
+	for _, dep := range d {
+		want[dep] = true
+	}
+
+	var merged []bzl.Expr
+	kept := make(map[string]bool)
+	keepComment := false
+	for _, e := range existing.List {
+		s, isString := e.(*bzl.StringExpr)
+		keep := rule.ShouldKeep(e)
+		if !keep && (!isString || !want[s.Value]) {
+			continue
+		}
+		keepComment = keepComment || keep
+		merged = append(merged, e)
+		if isString {
+			kept[s.Value] = true
+		}
+	}
+
+	var added []string
+	for _, dep := range d {
+		if !kept[dep] && !slices.Contains(added, dep) {
+			added = append(added, dep)
+		}
+	}
+	slices.SortFunc(added, compareDeps)
+	for _, dep := range added {
+		i := slices.IndexFunc(merged, func(e bzl.Expr) bool {
+			s, ok := e.(*bzl.StringExpr)
+			return ok && compareDeps(s.Value, dep) > 0
+		})
+		if i < 0 {
+			i = len(merged)
+		}
+		merged = slices.Insert(merged, i, bzl.Expr(&bzl.StringExpr{Value: dep}))
+	}
+
+	if len(merged) == 0 {
+		return nil
+	}
+	return &bzl.ListExpr{
+		List:           merged,
+		ForceMultiLine: existing.ForceMultiLine || keepComment,
+	}
+}
+
+// mergeDefault merges deps into other as gazelle merges a plain string list.
+func mergeDefault(deps []string, other bzl.Expr) bzl.Expr {
+	src, dst := rule.NewRule("", ""), rule.NewRule("", "")
+	src.SetAttr("deps", deps)
+	dst.SetAttr("deps", other)
+	rule.MergeRules(src, dst, map[string]bool{"deps": true}, "")
+	return dst.Attr("deps")
+}
+
+// compareDeps orders labels as buildifier sorts them: plain strings, then
+// ":local" labels, then "//" labels, then "@repo" labels, each group by its
+// components split at "." and ":".
+func compareDeps(a, b string) int {
+	return cmp.Or(
+		cmp.Compare(depPhase(a), depPhase(b)),
+		slices.Compare(depComponents(a), depComponents(b)),
+		cmp.Compare(a, b),
+	)
+}
+
+func depPhase(dep string) int {
+	switch {
+	case strings.HasPrefix(dep, ":"):
+		return 1
+	case strings.HasPrefix(dep, "//"):
+		return 2
+	case strings.HasPrefix(dep, "@"):
+		return 3
+	default:
+		return 0
+	}
+}
+
+func depComponents(dep string) []string {
+	return strings.Split(strings.ReplaceAll(dep, ":", "."), ".")
+}
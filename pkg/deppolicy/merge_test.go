@@ -0,0 +1,106 @@
+package deppolicy
+
+import (
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/rule"
+)
+
+func TestDepsMerge(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		deps     []string
+		want     string
+	}{
+		{
+			name: "adds a dep",
+			existing: `deps = [
+        # Core
+        "//core",  # pinned
+        "//util",
+        "@maven//:guava",
+    ]`,
+			deps: []string{"@maven//:guava", "//util", "//model", "//core"},
+			want: `deps = [
+        # Core
+        "//core",  # pinned
+        "//model",
+        "//util",
+        "@maven//:guava",
+    ]`,
+		},
+		{
+			name: "removes a dep",
+			existing: `deps = [
+        "//core",  # pinned
+        "//gone",
+        "//util",
+    ]`,
+			deps: []string{"//util", "//core"},
+			want: `deps = [
+        "//core",  # pinned
+        "//util",
+    ]`,
+		},
+		{
+			name: "keeps kept deps",
+			existing: `deps = [
+        "//manual",  # keep
+        "//core",
+    ]`,
+			deps: []string{"//new"},
+			want: `deps = [
+        "//manual",  # keep
+        "//new",
+    ]`,
+		},
+		{
+			name:     "removes every dep",
+			existing: `deps = ["//gone"]`,
+			deps:     nil,
+			want:     ``,
+		},
+		{
+			name:     "select",
+			existing: `deps = ["//core"] + select({"//conditions:default": ["//extra"]})`,
+			deps:     []string{"//core", "//new"},
+			// The select is dropped with its last dep, as gazelle does
+			want: `deps = [
+        "//core",
+        "//new",
+    ]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := rule.LoadData("BUILD.bazel", "", []byte("py_library(\n    name = \"lib\",\n    "+tt.existing+",\n)\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			src := rule.NewRule("py_library", "lib")
+			if tt.deps != nil {
+				src.SetAttr("deps", Deps(tt.deps))
+			}
+			rule.MergeRules(src, f.Rules[0], map[string]bool{"deps": true}, f.Path)
+
+			want := "py_library(name = \"lib\")\n"
+			if tt.want != "" {
+				want = "py_library(\n    name = \"lib\",\n    " + tt.want + ",\n)\n"
+			}
+			if got := string(f.Format()); got != want {
+				t.Errorf("merged deps:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestCompareDeps(t *testing.T) {
+	ordered := []string{"plain", ":local", "//a", "//a:c", "//a/b", "//b", "@maven//:guava"}
+	for i := range len(ordered) - 1 {
+		if compareDeps(ordered[i], ordered[i+1]) >= 0 {
+			t.Errorf("compareDeps(%q, %q) >= 0, want < 0", ordered[i], ordered[i+1])
+		}
+	}
+}
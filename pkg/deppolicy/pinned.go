@@ -0,0 +1,107 @@
+package deppolicy
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+var (
+	resolveMaps     = make(map[string]*resolvemap.Layered) // repo root -> loaded maps
+	usedResolveMaps = make(map[string]*sharedResolveMaps)  // repo root -> maps kept across Reset
+	resolveMapErrs  = make(map[string]bool)                // errors already recorded
+)
+
+// sharedResolveMaps are the resolve maps of a workspace that refs callers of
+// UseResolveMaps hold.
+type sharedResolveMaps struct {
+	maps *resolvemap.Layered
+	refs int
+}
+
+// UseResolveMaps makes Pinned look the imports of the workspace at root up
+// in the returned maps, instead of reading the maps again after every Reset,
+// until release is called. A long-running caller that reloads the maps when
+// their files change, such as the watcher, uses it so that each run goes by
+// the maps as last reloaded. Callers for the same root share the maps, which
+// stay in use until every one of them has called release; calling release
+// again has no effect.
+func UseResolveMaps(root string) (maps *resolvemap.Layered, release func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	root = filepath.Clean(root)
+	shared, ok := usedResolveMaps[root]
+	if !ok {
+		shared = &sharedResolveMaps{maps: resolvemap.NewLayered(root)}
+		usedResolveMaps[root] = shared
+	}
+	shared.refs++
+
+	var once sync.Once
+	return shared.maps, func() {
+		once.Do(func() {
+			mu.Lock()
+			defer mu.Unlock()
+			if shared.refs--; shared.refs == 0 {
+				delete(usedResolveMaps, root)
+			}
+		})
+	}
+}
+
+// Pinned returns the label that the resolve maps of the workspace at
+// c.RepoRoot pin imp to for the rule labeled from, if a pattern matches it:
+// the maps of the rule's package and of each directory above it, the most
+// local first, ending with the global map (see resolvemap.Layered). A map
+// that fails to load, or a label that does not parse, is recorded once for
+// Errors.
+func Pinned(c *config.Config, from label.Label, imp string) (label.Label, bool) {
+	layers, err := resolveMapsFor(c.RepoRoot).For(from.Pkg)
+	if err != nil {
+		recordResolveMapErr(err)
+	}
+	target, ok := layers.Lookup(imp)
+	if !ok {
+		return label.NoLabel, false
+	}
+	l, err := label.Parse(target)
+	if err != nil {
+		recordResolveMapErr(fmt.Errorf("resolve map label %q for %q: %w", target, imp, err))
+		return label.NoLabel, false
+	}
+	return l, true
+}
+
+// resolveMapsFor returns the resolve maps of the workspace at root: those
+// in use through UseResolveMaps, or else maps loaded on first use after a
+// Reset.
+func resolveMapsFor(root string) *resolvemap.Layered {
+	mu.Lock()
+	defer mu.Unlock()
+	root = filepath.Clean(root)
+	if shared, ok := usedResolveMaps[root]; ok {
+		return shared.maps
+	}
+	maps, ok := resolveMaps[root]
+	if !ok {
+		maps = resolvemap.NewLayered(root)
+		resolveMaps[root] = maps
+	}
+	return maps
+}
+
+// recordResolveMapErr records err unless an identical error was recorded
+// since the last Reset, since every import of every rule looks the maps up.
+func recordResolveMapErr(err error) {
+	mu.Lock()
+	seen := resolveMapErrs[err.Error()]
+	resolveMapErrs[err.Error()] = true
+	mu.Unlock()
+	if !seen {
+		record(err)
+	}
+}
@@ -0,0 +1,133 @@
+package deppolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/albertocavalcante/bazelle/pkg/resolvemap"
+	"github.com/bazelbuild/bazel-gazelle/config"
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// writeResolveMaps writes the resolve map of each workspace-relative
+// directory in maps under root.
+func writeResolveMaps(t *testing.T, root string, maps map[string]string) {
+	t.Helper()
+	for dir, content := range maps {
+		path := filepath.Join(root, filepath.FromSlash(dir), resolvemap.DefaultPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPinned(t *testing.T) {
+	resetState(t)
+	root := t.TempDir()
+	writeResolveMaps(t, root, map[string]string{
+		"":             "[resolve]\n\"com.google.common.*\" = \"@maven//:guava\"\n\"yaml\" = \"@pip//pyyaml\"\n",
+		"services/pay": "[resolve]\n\"com.google.common.*\" = \"@maven//:guava_android\"\n",
+	})
+	c := &config.Config{RepoRoot: root}
+
+	tests := []struct {
+		from string
+		imp  string
+		want string
+	}{
+		{from: "app", imp: "com.google.common.collect.ImmutableList", want: "@maven//:guava"},
+		{from: "services/pay/api", imp: "com.google.common.collect.ImmutableList", want: "@maven//:guava_android"},
+		{from: "services/pay/api", imp: "yaml", want: "@pip//pyyaml"},
+		{from: "services/pay", imp: "com.example.Thing"},
+	}
+	for _, tt := range tests {
+		got, ok := Pinned(c, label.New("", tt.from, "x"), tt.imp)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("Pinned(//%s, %q) = %v, want no match", tt.from, tt.imp, got)
+			}
+			continue
+		}
+		if !ok || got.String() != tt.want {
+			t.Errorf("Pinned(//%s, %q) = %v, %v, want %s", tt.from, tt.imp, got, ok, tt.want)
+		}
+	}
+	if err := Errors(); err != nil {
+		t.Errorf("Errors() = %v, want none", err)
+	}
+}
+
+func TestPinned_InvalidMap(t *testing.T) {
+	resetState(t)
+	root := t.TempDir()
+	writeResolveMaps(t, root, map[string]string{"": "[resolve\n"})
+	c := &config.Config{RepoRoot: root}
+
+	for range 2 {
+		if got, ok := Pinned(c, label.New("", "app", "app"), "yaml"); ok {
+			t.Errorf("Pinned() = %v, want no match for an unloadable map", got)
+		}
+	}
+	err := Errors()
+	if err == nil {
+		t.Fatal("Errors() = nil, want the load error")
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 1 {
+		t.Errorf("Errors() = %v, want the load error recorded once", err)
+	}
+}
+
+func TestUseResolveMaps(t *testing.T) {
+	resetState(t)
+	root := t.TempDir()
+	writeResolveMaps(t, root, map[string]string{"": "[resolve]\n\"yaml\" = \"@pip//pyyaml\"\n"})
+	c := &config.Config{RepoRoot: root}
+	maps, release := UseResolveMaps(root)
+
+	pinned := func() string {
+		t.Helper()
+		l, ok := Pinned(c, label.New("", "app", "app"), "yaml")
+		if !ok {
+			return ""
+		}
+		return l.String()
+	}
+	if got, want := pinned(), "@pip//pyyaml"; got != want {
+		t.Errorf("Pinned() = %q, want %q", got, want)
+	}
+
+	// The maps in use are kept across Reset until they are reloaded
+	writeResolveMaps(t, root, map[string]string{"": "[resolve]\n\"yaml\" = \"@pip//ruamel_yaml\"\n"})
+	Reset()
+	if got, want := pinned(), "@pip//pyyaml"; got != want {
+		t.Errorf("Pinned() after Reset = %q, want %q", got, want)
+	}
+	if _, err := maps.Reload(""); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pinned(), "@pip//ruamel_yaml"; got != want {
+		t.Errorf("Pinned() after Reload = %q, want %q", got, want)
+	}
+
+	// A second user of the root shares the maps and keeps them in use
+	// after the first releases them, even if it releases them twice
+	shared, releaseShared := UseResolveMaps(root)
+	if shared != maps {
+		t.Error("UseResolveMaps() for the same root returned other maps")
+	}
+	writeResolveMaps(t, root, map[string]string{"": "[resolve]\n"})
+	release()
+	release()
+	if got, want := pinned(), "@pip//ruamel_yaml"; got != want {
+		t.Errorf("Pinned() after one release = %q, want %q", got, want)
+	}
+
+	releaseShared()
+	if got := pinned(); got != "" {
+		t.Errorf("Pinned() after every release = %q, want no match", got)
+	}
+}
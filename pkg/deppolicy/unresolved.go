@@ -0,0 +1,53 @@
+package deppolicy
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// Unresolved records an import that no target satisfies.
+type Unresolved struct {
+	// Import is the unresolved import.
+	Import string
+
+	// From is the rule the import was resolved for.
+	From label.Label
+}
+
+// Warning describes u as a warning of the run that recorded it, for the
+// warnings of update results, e.g. `unresolved import: //app imports "yaml"`.
+func (u Unresolved) Warning() string {
+	return fmt.Sprintf("unresolved import: %s imports %q", u.From, u.Import)
+}
+
+var unresolved []Unresolved
+
+// RecordUnresolved records, for UnresolvedImports, that the rule labeled
+// from imports imp and nothing provides it: neither the rule index nor any
+// mapping to external targets. Language extensions call it for the imports
+// they drop from deps; imports left out on purpose, such as the standard
+// library's, are not unresolved.
+func RecordUnresolved(from label.Label, imp string) {
+	mu.Lock()
+	defer mu.Unlock()
+	unresolved = append(unresolved, Unresolved{Import: imp, From: from})
+}
+
+// UnresolvedImports returns the imports recorded by RecordUnresolved since
+// the last Reset, sorted by rule and import and without duplicates.
+func UnresolvedImports() []Unresolved {
+	mu.Lock()
+	defer mu.Unlock()
+	sorted := slices.Clone(unresolved)
+	slices.SortFunc(sorted, func(a, b Unresolved) int {
+		return cmp.Or(
+			strings.Compare(a.From.String(), b.From.String()),
+			strings.Compare(a.Import, b.Import),
+		)
+	})
+	return slices.Compact(sorted)
+}
@@ -0,0 +1,41 @@
+package deppolicy
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+func TestUnresolvedImports(t *testing.T) {
+	resetState(t)
+	app := label.New("", "app", "app")
+	lib := label.New("", "lib", "lib")
+
+	RecordUnresolved(lib, "yaml")
+	RecordUnresolved(app, "requests")
+	RecordUnresolved(app, "attr")
+	RecordUnresolved(app, "requests")
+
+	want := []Unresolved{
+		{Import: "attr", From: app},
+		{Import: "requests", From: app},
+		{Import: "yaml", From: lib},
+	}
+	if got := UnresolvedImports(); !slices.Equal(got, want) {
+		t.Errorf("UnresolvedImports() = %v, want %v", got, want)
+	}
+
+	Reset()
+	if got := UnresolvedImports(); len(got) != 0 {
+		t.Errorf("UnresolvedImports() after Reset = %v, want none", got)
+	}
+}
+
+func TestUnresolvedWarning(t *testing.T) {
+	u := Unresolved{Import: "yaml", From: label.New("", "app", "app")}
+	want := `unresolved import: //app imports "yaml"`
+	if got := u.Warning(); got != want {
+		t.Errorf("Warning() = %q, want %q", got, want)
+	}
+}
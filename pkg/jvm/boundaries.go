@@ -0,0 +1,133 @@
+package jvm
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// DefaultBuildFileNames are the BUILD file names that mark a Bazel package.
+var DefaultBuildFileNames = []string{"BUILD.bazel", "BUILD"}
+
+// PackageBoundaries maps source directories to the Bazel package that owns
+// them: the nearest enclosing directory with a BUILD file.
+//
+// Repos do not always have one Bazel package per JVM package. A BUILD file in
+// src/com/example may own everything below it, so a type in
+// com.example.foo belongs to //src/com/example rather than a
+// //src/com/example/foo package that does not exist.
+//
+// PackageBoundaries caches lookups and is safe for concurrent use.
+type PackageBoundaries struct {
+	repoRoot       string
+	buildFileNames []string
+
+	mu    sync.Mutex
+	cache map[string]string // slash-separated dir -> owning package, or "\x00" for none
+}
+
+// noPackage marks a cached directory with no enclosing Bazel package.
+const noPackage = "\x00"
+
+// NewPackageBoundaries creates a PackageBoundaries for the repo at repoRoot.
+// A nil buildFileNames uses DefaultBuildFileNames.
+func NewPackageBoundaries(repoRoot string, buildFileNames []string) *PackageBoundaries {
+	if buildFileNames == nil {
+		buildFileNames = DefaultBuildFileNames
+	}
+	return &PackageBoundaries{
+		repoRoot:       repoRoot,
+		buildFileNames: buildFileNames,
+		cache:          make(map[string]string),
+	}
+}
+
+// NearestPackage returns the Bazel package owning rel, a slash-separated
+// directory relative to the repo root: rel itself if it has a BUILD file,
+// otherwise its nearest ancestor that does. The root package is "". It
+// returns false if no directory up to the repo root has a BUILD file.
+func (b *PackageBoundaries) NearestPackage(rel string) (string, bool) {
+	rel = cleanRel(rel)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var visited []string
+	pkg := noPackage
+	for dir := rel; ; dir = parentDir(dir) {
+		if cached, ok := b.cache[dir]; ok {
+			pkg = cached
+			break
+		}
+		visited = append(visited, dir)
+		if b.hasBuildFile(dir) {
+			pkg = dir
+			break
+		}
+		if dir == "" {
+			break
+		}
+	}
+
+	for _, dir := range visited {
+		b.cache[dir] = pkg
+	}
+	return pkg, pkg != noPackage
+}
+
+// Target returns the default target (see DeriveTargetName) of the Bazel
+// package owning rel, a slash-separated directory relative to the repo root,
+// as found by NearestPackage. It returns false if no package owns rel.
+func (b *PackageBoundaries) Target(rel string) (label.Label, bool) {
+	pkg, ok := b.NearestPackage(rel)
+	if !ok {
+		return label.NoLabel, false
+	}
+	return label.New("", pkg, DeriveTargetName(pkg, b.repoRoot)), true
+}
+
+// hasBuildFile reports whether the directory rel contains a BUILD file.
+func (b *PackageBoundaries) hasBuildFile(rel string) bool {
+	dir := filepath.Join(b.repoRoot, filepath.FromSlash(rel))
+	for _, name := range b.buildFileNames {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// FQNPackage returns the package part of a fully qualified name: the leading
+// segments up to the first one that starts with an uppercase letter, which by
+// convention is the outermost class ("com.example.Foo.Inner" ->
+// "com.example"). A name with no such segment is returned unchanged.
+func FQNPackage(fqn string) string {
+	segments := strings.Split(StarImportPackage(fqn), ".")
+	for i, seg := range segments {
+		if seg != "" && seg[0] >= 'A' && seg[0] <= 'Z' {
+			return strings.Join(segments[:i], ".")
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// cleanRel normalizes a repo-relative directory, mapping the root to "".
+func cleanRel(rel string) string {
+	rel = path.Clean(filepath.ToSlash(rel))
+	if rel == "." || rel == "/" {
+		return ""
+	}
+	return strings.TrimPrefix(rel, "/")
+}
+
+// parentDir returns the parent of a cleaned repo-relative directory.
+func parentDir(rel string) string {
+	if i := strings.LastIndexByte(rel, '/'); i >= 0 {
+		return rel[:i]
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package jvm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// writeFixture creates the given files (with empty content) under root.
+func writeFixture(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, rel := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPackageBoundaries_Target(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root,
+		// Coarse package: the BUILD file sits two levels above the sources.
+		"src/com/BUILD.bazel",
+		"src/com/example/foo/Bar.kt",
+		// Fine-grained package with a plain BUILD file.
+		"src/com/acme/util/BUILD",
+		"src/com/acme/util/Strings.kt",
+		// Sources with no BUILD file anywhere above them.
+		"other/org/sample/Thing.kt",
+	)
+	b := NewPackageBoundaries(root, nil)
+
+	tests := []struct {
+		name   string
+		dir    string
+		want   label.Label
+		wantOK bool
+	}{
+		{
+			name:   "coarse package two levels up",
+			dir:    "src/com/example/foo",
+			want:   label.New("", "src/com", "com"),
+			wantOK: true,
+		},
+		{
+			name:   "package with its own BUILD file",
+			dir:    "src/com/acme/util",
+			want:   label.New("", "src/com/acme/util", "util"),
+			wantOK: true,
+		},
+		{
+			name:   "no enclosing BUILD file",
+			dir:    "other/org/sample",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := b.Target(tt.dir)
+			if ok != tt.wantOK {
+				t.Fatalf("Target(%q) ok = %v, want %v (label %v)", tt.dir, ok, tt.wantOK, got)
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("Target(%q) = %v, want %v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageBoundaries_NearestPackage(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, "BUILD.bazel", "a/b/BUILD.bazel", "a/b/c/d/File.kt")
+	b := NewPackageBoundaries(root, nil)
+
+	tests := map[string]string{
+		"a/b/c/d": "a/b",
+		"a/b/c":   "a/b",
+		"a/b":     "a/b",
+		"a":       "",
+		"":        "",
+		".":       "",
+	}
+	for rel, want := range tests {
+		got, ok := b.NearestPackage(rel)
+		if !ok || got != want {
+			t.Errorf("NearestPackage(%q) = %q, %v; want %q, true", rel, got, ok, want)
+		}
+	}
+
+	// The root package's default target is named after the repo directory.
+	l, ok := b.Target("a")
+	if !ok || !l.Equal(label.New("", "", filepath.Base(root))) {
+		t.Errorf("Target(a) = %v, %v; want root package target", l, ok)
+	}
+}
+
+func TestPackageBoundaries_BuildFileNames(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, "lib/BUILD", "lib/com/x/Y.kt")
+
+	if _, ok := NewPackageBoundaries(root, []string{"BUILD.bazel"}).Target("lib/com/x"); ok {
+		t.Error("BUILD should not count when only BUILD.bazel is configured")
+	}
+	if l, ok := NewPackageBoundaries(root, nil).Target("lib/com/x"); !ok || l.Pkg != "lib" {
+		t.Errorf("Target = %v, %v; want //lib", l, ok)
+	}
+}
+
+func TestFQNPackage(t *testing.T) {
+	tests := map[string]string{
+		"com.example.Foo":       "com.example",
+		"com.example.Foo.Inner": "com.example",
+		"com.example.*":         "com.example",
+		"com.example":           "com.example",
+		"Foo":                   "",
+	}
+	for fqn, want := range tests {
+		if got := FQNPackage(fqn); got != want {
+			t.Errorf("FQNPackage(%q) = %q, want %q", fqn, got, want)
+		}
+	}
+}
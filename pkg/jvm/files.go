@@ -52,20 +52,52 @@ func FindTestSources(baseDir string, lang Language) []string {
 	return FindLanguageFiles(baseDir, lang.TestSourceDir(), lang)
 }
 
+// ModuleInfoFile is the file that declares a JPMS module. It is always Java,
+// even in Kotlin projects, where it sits beside the Kotlin sources.
+const ModuleInfoFile = "module-info.java"
+
+// IsModuleInfo reports whether path is a module-info.java file.
+func IsModuleInfo(path string) bool {
+	return filepath.Base(path) == ModuleInfoFile
+}
+
+// FindModuleInfo returns the module-info.java of the main sources under
+// baseDir, relative to baseDir, or "" if the sources declare no module. It
+// looks in src/main/java, then in the source directories of the other JVM
+// languages, since Kotlin projects may keep it in src/main/kotlin.
+func FindModuleInfo(baseDir string) string {
+	for _, lang := range []Language{Java, Kotlin, Scala, Groovy} {
+		rel := filepath.Join(lang.MainSourceDir(), ModuleInfoFile)
+		if info, err := os.Stat(filepath.Join(baseDir, rel)); err == nil && !info.IsDir() {
+			return rel
+		}
+	}
+	return ""
+}
+
 // IsSourceDir checks if a directory contains source files for the given language.
 func IsSourceDir(dir string, lang Language) bool {
-	return strings.Contains(dir, lang.MainSourceDir()) ||
-		strings.Contains(dir, lang.TestSourceDir())
+	return hasPathComponents(dir, lang.MainSourceDir()) ||
+		hasPathComponents(dir, lang.TestSourceDir())
 }
 
 // IsTestDir checks if a directory is a test directory.
 func IsTestDir(dir string) bool {
-	return strings.Contains(dir, filepath.Join("src", "test"))
+	return hasPathComponents(dir, filepath.Join("src", "test"))
 }
 
 // IsMainDir checks if a directory is a main source directory.
 func IsMainDir(dir string) bool {
-	return strings.Contains(dir, filepath.Join("src", "main"))
+	return hasPathComponents(dir, filepath.Join("src", "main"))
+}
+
+// hasPathComponents reports whether dir contains the path components of sub
+// in sequence. Separators are normalized and case is ignored, so the result
+// is the same whether or not the filesystem is case-sensitive: "Src/Test"
+// matches "src/test", while "src/testing" does not.
+func hasPathComponents(dir, sub string) bool {
+	folded := "/" + strings.ToLower(filepath.ToSlash(dir)) + "/"
+	return strings.Contains(folded, "/"+strings.ToLower(filepath.ToSlash(sub))+"/")
 }
 
 // HasSourceFiles checks if any source files exist in the given directory.
@@ -125,6 +125,9 @@ func TestIsSourceDir(t *testing.T) {
 		{"/project/src/test/kotlin/com/example", Kotlin, true},
 		{"/project/src/main/java/com/example", Kotlin, false},
 		{"/project/lib/kotlin", Kotlin, false},
+		{"/Project/Src/Main/Kotlin/com/example", Kotlin, true},
+		{"/project/SRC/TEST/KOTLIN", Kotlin, true},
+		{"/project/src/main/kotlinx", Kotlin, false},
 	}
 
 	for _, tt := range tests {
@@ -146,6 +149,10 @@ func TestIsTestDir(t *testing.T) {
 		{"/project/src/test/java", true},
 		{"/project/src/main/kotlin", false},
 		{"/project/test/kotlin", false},
+		{"/project/Src/Test/kotlin", true},
+		{"/project/SRC/TEST", true},
+		{"/project/src/testing/kotlin", false},
+		{"/project/Src/Testing/kotlin", false},
 	}
 
 	for _, tt := range tests {
@@ -167,6 +174,8 @@ func TestIsMainDir(t *testing.T) {
 		{"/project/src/main/java", true},
 		{"/project/src/test/kotlin", false},
 		{"/project/main/kotlin", false},
+		{"/project/Src/Main/java", true},
+		{"/project/src/mainline", false},
 	}
 
 	for _, tt := range tests {
@@ -178,3 +187,51 @@ func TestIsMainDir(t *testing.T) {
 		})
 	}
 }
+
+func TestFindModuleInfo(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+	}{
+		{"java", []string{"src/main/java/module-info.java"}, filepath.Join("src", "main", "java", "module-info.java")},
+		{"kotlin", []string{"src/main/kotlin/module-info.java"}, filepath.Join("src", "main", "kotlin", "module-info.java")},
+		{"java first", []string{"src/main/java/module-info.java", "src/main/kotlin/module-info.java"}, filepath.Join("src", "main", "java", "module-info.java")},
+		{"test sources only", []string{"src/test/java/module-info.java"}, ""},
+		{"none", []string{"src/main/java/Main.java"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for _, f := range tt.files {
+				path := filepath.Join(tmpDir, filepath.FromSlash(f))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatalf("Failed to create dir: %v", err)
+				}
+				if err := os.WriteFile(path, []byte("module a {}\n"), 0644); err != nil {
+					t.Fatalf("Failed to create file %s: %v", f, err)
+				}
+			}
+
+			if got := FindModuleInfo(tmpDir); got != tt.want {
+				t.Errorf("FindModuleInfo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsModuleInfo(t *testing.T) {
+	tests := map[string]bool{
+		"module-info.java":                 true,
+		"src/main/java/module-info.java":   true,
+		"src/main/java/package-info.java":  false,
+		"src/main/java/mymodule-info.java": false,
+		"module-info.class":                false,
+	}
+	for path, want := range tests {
+		if got := IsModuleInfo(path); got != want {
+			t.Errorf("IsModuleInfo(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
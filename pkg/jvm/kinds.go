@@ -4,12 +4,16 @@ import "github.com/bazelbuild/bazel-gazelle/rule"
 
 // StandardLibraryKindInfo returns the standard KindInfo for library rules.
 // This is suitable for java_library, kt_jvm_library, groovy_library, etc.
+//
+// Like the other kinds here, deps is a resolve attribute only: merging it
+// before resolution would drop the existing deps, which deppolicy.Deps
+// merges the resolved ones into instead.
 func StandardLibraryKindInfo() rule.KindInfo {
 	return rule.KindInfo{
 		MatchAny:        false,
 		NonEmptyAttrs:   map[string]bool{"srcs": true},
 		SubstituteAttrs: map[string]bool{},
-		MergeableAttrs:  map[string]bool{"srcs": true, "deps": true},
+		MergeableAttrs:  map[string]bool{"srcs": true},
 		ResolveAttrs:    map[string]bool{"deps": true},
 	}
 }
@@ -21,7 +25,7 @@ func StandardTestKindInfo() rule.KindInfo {
 		MatchAny:        false,
 		NonEmptyAttrs:   map[string]bool{"srcs": true},
 		SubstituteAttrs: map[string]bool{},
-		MergeableAttrs:  map[string]bool{"srcs": true, "deps": true},
+		MergeableAttrs:  map[string]bool{"srcs": true},
 		ResolveAttrs:    map[string]bool{"deps": true},
 	}
 }
@@ -33,7 +37,7 @@ func StandardBinaryKindInfo() rule.KindInfo {
 		MatchAny:        false,
 		NonEmptyAttrs:   map[string]bool{"srcs": true},
 		SubstituteAttrs: map[string]bool{},
-		MergeableAttrs:  map[string]bool{"srcs": true, "deps": true},
+		MergeableAttrs:  map[string]bool{"srcs": true},
 		ResolveAttrs:    map[string]bool{"deps": true},
 	}
 }
@@ -45,7 +49,7 @@ func SpecTestKindInfo() rule.KindInfo {
 		MatchAny:        false,
 		NonEmptyAttrs:   map[string]bool{"specs": true},
 		SubstituteAttrs: map[string]bool{},
-		MergeableAttrs:  map[string]bool{"specs": true, "deps": true},
+		MergeableAttrs:  map[string]bool{"specs": true},
 		ResolveAttrs:    map[string]bool{"deps": true},
 	}
 }
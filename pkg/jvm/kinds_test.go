@@ -17,8 +17,9 @@ func TestStandardLibraryKindInfo(t *testing.T) {
 		t.Error("StandardLibraryKindInfo().MergeableAttrs[srcs] = false, want true")
 	}
 
-	if !info.MergeableAttrs["deps"] {
-		t.Error("StandardLibraryKindInfo().MergeableAttrs[deps] = false, want true")
+	// deps are merged only after resolution, into the existing list
+	if info.MergeableAttrs["deps"] {
+		t.Error("StandardLibraryKindInfo().MergeableAttrs[deps] = true, want false")
 	}
 
 	if !info.ResolveAttrs["deps"] {
@@ -0,0 +1,220 @@
+package jvm
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// PackageIndex maps JVM package names (e.g., "com.example.util") to the
+// first-party targets that contain sources declaring that package.
+//
+// A JVM package may be split across several Bazel targets, so a star import
+// resolves to every target registered for its package. PackageIndex is shared
+// by the JVM extensions so that Java, Kotlin and Groovy star imports resolve
+// the same way.
+//
+// Top-level types can be registered too (see AddType), so that a type and
+// the types nested in it resolve to the target declaring it rather than to
+// every target of its package. Only the bazelle dependency graph registers
+// them so far: DefaultResolve does not consult the index, so the deps the
+// extensions generate are unaffected.
+//
+// PackageIndex is safe for concurrent use.
+type PackageIndex struct {
+	mu       sync.RWMutex
+	packages map[string]map[label.Label]struct{}
+	types    map[string]map[label.Label]struct{}
+}
+
+// NewPackageIndex creates an empty PackageIndex.
+func NewPackageIndex() *PackageIndex {
+	return &PackageIndex{
+		packages: make(map[string]map[label.Label]struct{}),
+		types:    make(map[string]map[label.Label]struct{}),
+	}
+}
+
+// Add records that target provides sources in the JVM package pkg.
+// Empty package names (the default package) are ignored.
+func (ix *PackageIndex) Add(pkg string, target label.Label) {
+	if pkg == "" {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	addTarget(ix.packages, pkg, target)
+}
+
+// AddType records that target declares the top-level type fqn (e.g.,
+// "com.example.Outer"). Nested types are not registered on their own: they
+// resolve through their outermost type.
+func (ix *PackageIndex) AddType(fqn string, target label.Label) {
+	if fqn == "" {
+		return
+	}
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	addTarget(ix.types, fqn, target)
+}
+
+// Remove drops target from every package and type it was registered for,
+// so that the target can be indexed again after its sources change.
+func (ix *PackageIndex) Remove(target label.Label) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	removeTarget(ix.packages, target)
+	removeTarget(ix.types, target)
+}
+
+// Targets returns the targets registered for the JVM package pkg, sorted.
+func (ix *PackageIndex) Targets(pkg string) []label.Label {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return sortedTargets(ix.packages[pkg])
+}
+
+// typeTargets returns the targets declaring the top-level type fqn, sorted.
+func (ix *PackageIndex) typeTargets(fqn string) []label.Label {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return sortedTargets(ix.types[fqn])
+}
+
+// Packages returns the JVM packages registered in the index, sorted.
+func (ix *PackageIndex) Packages() []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	packages := make([]string, 0, len(ix.packages))
+	for pkg := range ix.packages {
+		packages = append(packages, pkg)
+	}
+	slices.Sort(packages)
+	return packages
+}
+
+// ResolveStarImport returns the first-party targets a star import refers to.
+//
+// imp may be written with or without the trailing ".*" ("com.example.*" and
+// "com.example" are equivalent), matching how the language parsers record
+// star imports. The target doing the import (from) is excluded so a package
+// never depends on itself. Only the exact package matches; subpackages are
+// not included, as in Java and Kotlin.
+func (ix *PackageIndex) ResolveStarImport(imp string, from label.Label) []label.Label {
+	targets := slices.DeleteFunc(ix.Targets(StarImportPackage(imp)), func(l label.Label) bool {
+		return l.Equal(from)
+	})
+	if len(targets) == 0 {
+		return nil
+	}
+	return targets
+}
+
+// ResolveImport returns the first-party targets an explicit import or FQN
+// refers to, excluding from: the targets declaring its top-level type if
+// that type was registered with AddType, otherwise those declaring its
+// package.
+//
+// The package is everything before the first capitalized segment, which is
+// the top-level type: "com.example.util.Strings.Inner" names the nested type
+// Inner of "com.example.util.Strings", in "com.example.util". An import with
+// no capitalized segment names a top-level function or property, such as an
+// extension function ("com.example.ext.toSlug"), so its last segment is the
+// member and the rest is the package.
+func (ix *PackageIndex) ResolveImport(imp string, from label.Label) []label.Label {
+	pkg := FQNPackage(imp)
+	var targets []label.Label
+	if name := StarImportPackage(imp); pkg == name {
+		i := strings.LastIndexByte(pkg, '.')
+		if i < 0 {
+			return nil
+		}
+		pkg = pkg[:i]
+	} else {
+		targets = ix.typeTargets(topLevelType(name, pkg))
+	}
+	if len(targets) == 0 {
+		targets = ix.Targets(pkg)
+	}
+	targets = slices.DeleteFunc(targets, func(l label.Label) bool {
+		return l.Equal(from)
+	})
+	if len(targets) == 0 {
+		return nil
+	}
+	return targets
+}
+
+// Contains reports whether imp names a registered package or something
+// inside one: "com.example.util", "com.example.util.*" and
+// "com.example.util.Strings" all match a registered "com.example.util".
+// This makes it usable as a first-party predicate for import classification.
+func (ix *PackageIndex) Contains(imp string) bool {
+	name := StarImportPackage(imp)
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	for {
+		if len(ix.packages[name]) > 0 {
+			return true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return false
+		}
+		name = name[:i]
+	}
+}
+
+// StarImportPackage returns the package named by a star import, stripping a
+// trailing ".*" if present.
+func StarImportPackage(imp string) string {
+	return strings.TrimSuffix(strings.TrimSpace(imp), ".*")
+}
+
+// topLevelType returns the top-level type of fqn, whose package is pkg:
+// the first segment after the package ("com.example.Outer.Inner" in
+// "com.example" -> "com.example.Outer").
+func topLevelType(fqn, pkg string) string {
+	rest := fqn
+	if pkg != "" {
+		rest = strings.TrimPrefix(fqn, pkg+".")
+	}
+	name, _, _ := strings.Cut(rest, ".")
+	return strings.TrimSuffix(fqn, rest) + name
+}
+
+func addTarget(index map[string]map[label.Label]struct{}, key string, target label.Label) {
+	targets, ok := index[key]
+	if !ok {
+		targets = make(map[label.Label]struct{})
+		index[key] = targets
+	}
+	targets[target] = struct{}{}
+}
+
+func removeTarget(index map[string]map[label.Label]struct{}, target label.Label) {
+	for key, targets := range index {
+		delete(targets, target)
+		if len(targets) == 0 {
+			delete(index, key)
+		}
+	}
+}
+
+func sortedTargets(targets map[label.Label]struct{}) []label.Label {
+	if len(targets) == 0 {
+		return nil
+	}
+	result := make([]label.Label, 0, len(targets))
+	for l := range targets {
+		result = append(result, l)
+	}
+	slices.SortFunc(result, compareLabels)
+	return result
+}
+
+func compareLabels(a, b label.Label) int {
+	return strings.Compare(a.String(), b.String())
+}
@@ -0,0 +1,337 @@
+package jvm
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// fixturePackageIndex builds an index for the layout:
+//
+//	lib/util        -> com.example.util
+//	lib/util/extra  -> com.example.util (split package)
+//	lib/util/inner  -> com.example.util.inner
+//	app             -> com.example.app
+func fixturePackageIndex() *PackageIndex {
+	ix := NewPackageIndex()
+	ix.Add("com.example.util", label.New("", "lib/util", "util"))
+	ix.Add("com.example.util", label.New("", "lib/util/extra", "extra"))
+	ix.Add("com.example.util.inner", label.New("", "lib/util/inner", "inner"))
+	ix.Add("com.example.app", label.New("", "app", "app"))
+	return ix
+}
+
+func TestPackageIndex_ResolveStarImport(t *testing.T) {
+	ix := fixturePackageIndex()
+	app := label.New("", "app", "app")
+
+	tests := []struct {
+		name string
+		imp  string
+		from label.Label
+		want []label.Label
+	}{
+		{
+			name: "star suffix",
+			imp:  "com.example.util.*",
+			from: app,
+			want: []label.Label{
+				label.New("", "lib/util", "util"),
+				label.New("", "lib/util/extra", "extra"),
+			},
+		},
+		{
+			name: "bare package",
+			imp:  "com.example.util",
+			from: app,
+			want: []label.Label{
+				label.New("", "lib/util", "util"),
+				label.New("", "lib/util/extra", "extra"),
+			},
+		},
+		{
+			name: "subpackage is separate",
+			imp:  "com.example.util.inner.*",
+			from: app,
+			want: []label.Label{label.New("", "lib/util/inner", "inner")},
+		},
+		{
+			name: "excludes importing target",
+			imp:  "com.example.util.*",
+			from: label.New("", "lib/util/extra", "extra"),
+			want: []label.Label{label.New("", "lib/util", "util")},
+		},
+		{
+			name: "self import only",
+			imp:  "com.example.app.*",
+			from: app,
+			want: nil,
+		},
+		{
+			name: "parent package not matched",
+			imp:  "com.example.*",
+			from: app,
+			want: nil,
+		},
+		{
+			name: "third-party package",
+			imp:  "java.util.*",
+			from: app,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ix.ResolveStarImport(tt.imp, tt.from)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveStarImport(%q) = %v, want %v", tt.imp, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageIndex_ResolveImport(t *testing.T) {
+	ix := fixturePackageIndex()
+	ix.Add("com.example.ext", label.New("", "lib/ext", "ext"))
+	app := label.New("", "app", "app")
+	util := []label.Label{
+		label.New("", "lib/util", "util"),
+		label.New("", "lib/util/extra", "extra"),
+	}
+
+	tests := []struct {
+		name string
+		imp  string
+		from label.Label
+		want []label.Label
+	}{
+		{
+			name: "class",
+			imp:  "com.example.util.Strings",
+			from: app,
+			want: util,
+		},
+		{
+			name: "nested class",
+			imp:  "com.example.util.inner.Helper.Builder",
+			from: app,
+			want: []label.Label{label.New("", "lib/util/inner", "inner")},
+		},
+		{
+			name: "extension function",
+			imp:  "com.example.ext.toSlug",
+			from: app,
+			want: []label.Label{label.New("", "lib/ext", "ext")},
+		},
+		{
+			name: "top-level function in split package",
+			imp:  "com.example.util.format",
+			from: app,
+			want: util,
+		},
+		{
+			name: "object member",
+			imp:  "com.example.util.Strings.shout",
+			from: app,
+			want: util,
+		},
+		{
+			name: "excludes importing target",
+			imp:  "com.example.ext.toSlug",
+			from: label.New("", "lib/ext", "ext"),
+			want: nil,
+		},
+		{
+			name: "function in unknown package",
+			imp:  "com.example.other.toSlug",
+			from: app,
+			want: nil,
+		},
+		{
+			name: "single segment",
+			imp:  "println",
+			from: app,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ix.ResolveImport(tt.imp, tt.from)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveImport(%q) = %v, want %v", tt.imp, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageIndex_ResolveImportType(t *testing.T) {
+	ix := fixturePackageIndex()
+	extra := label.New("", "lib/util/extra", "extra")
+	ix.AddType("com.example.util.Strings", extra)
+	app := label.New("", "app", "app")
+	util := []label.Label{
+		label.New("", "lib/util", "util"),
+		label.New("", "lib/util/extra", "extra"),
+	}
+
+	tests := []struct {
+		name string
+		imp  string
+		from label.Label
+		want []label.Label
+	}{
+		{
+			name: "declared type",
+			imp:  "com.example.util.Strings",
+			from: app,
+			want: []label.Label{extra},
+		},
+		{
+			name: "nested type resolves to outer type",
+			imp:  "com.example.util.Strings.Inner",
+			from: app,
+			want: []label.Label{extra},
+		},
+		{
+			name: "deeply nested type",
+			imp:  "com.example.util.Strings.Inner.Builder",
+			from: app,
+			want: []label.Label{extra},
+		},
+		{
+			name: "unregistered type falls back to package",
+			imp:  "com.example.util.Other.Inner",
+			from: app,
+			want: util,
+		},
+		{
+			name: "top-level function in split package",
+			imp:  "com.example.util.format",
+			from: app,
+			want: util,
+		},
+		{
+			name: "excludes importing target",
+			imp:  "com.example.util.Strings.Inner",
+			from: extra,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ix.ResolveImport(tt.imp, tt.from)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveImport(%q) = %v, want %v", tt.imp, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTopLevelType(t *testing.T) {
+	tests := []struct {
+		fqn, pkg, want string
+	}{
+		{"com.example.Outer", "com.example", "com.example.Outer"},
+		{"com.example.Outer.Inner", "com.example", "com.example.Outer"},
+		{"Outer.Inner", "", "Outer"},
+	}
+	for _, tt := range tests {
+		if got := topLevelType(tt.fqn, tt.pkg); got != tt.want {
+			t.Errorf("topLevelType(%q, %q) = %q, want %q", tt.fqn, tt.pkg, got, tt.want)
+		}
+	}
+}
+
+func TestPackageIndex_AddDeduplicates(t *testing.T) {
+	ix := NewPackageIndex()
+	l := label.New("", "lib", "lib")
+	ix.Add("com.example", l)
+	ix.Add("com.example", l)
+	ix.Add("", label.New("", "root", "root"))
+
+	if got := ix.Targets("com.example"); !reflect.DeepEqual(got, []label.Label{l}) {
+		t.Errorf("Targets = %v, want [%v]", got, l)
+	}
+	if got := ix.Targets(""); got != nil {
+		t.Errorf("default package should not be indexed, got %v", got)
+	}
+	if got := ix.Packages(); !reflect.DeepEqual(got, []string{"com.example"}) {
+		t.Errorf("Packages = %v, want [com.example]", got)
+	}
+}
+
+func TestPackageIndex_Remove(t *testing.T) {
+	ix := fixturePackageIndex()
+	extra := label.New("", "lib/util/extra", "extra")
+	ix.AddType("com.example.util.Strings", extra)
+
+	ix.Remove(extra)
+	ix.Remove(label.New("", "lib/util/inner", "inner"))
+
+	if got, want := ix.Targets("com.example.util"), []label.Label{label.New("", "lib/util", "util")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Targets(com.example.util) = %v, want %v", got, want)
+	}
+	if got := ix.typeTargets("com.example.util.Strings"); got != nil {
+		t.Errorf("typeTargets(com.example.util.Strings) = %v, want none", got)
+	}
+	if got, want := ix.Packages(), []string{"com.example.app", "com.example.util"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Packages = %v, want %v", got, want)
+	}
+}
+
+func TestPackageIndex_Concurrent(t *testing.T) {
+	ix := NewPackageIndex()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ix.Add("com.example", label.New("", "lib", string(rune('a'+i))))
+			_ = ix.ResolveStarImport("com.example.*", label.NoLabel)
+		}()
+	}
+	wg.Wait()
+
+	if got := len(ix.Targets("com.example")); got != 8 {
+		t.Errorf("expected 8 targets, got %d", got)
+	}
+}
+
+func TestPackageIndex_Contains(t *testing.T) {
+	ix := fixturePackageIndex()
+
+	tests := map[string]bool{
+		"com.example.util":               true,
+		"com.example.util.*":             true,
+		"com.example.util.Strings":       true,
+		"com.example.util.inner.Helper":  true,
+		"com.example.app.Main.Companion": true,
+		"com.example":                    false,
+		"com.example.other.Thing":        false,
+		"java.util.List":                 false,
+	}
+	for imp, want := range tests {
+		if got := ix.Contains(imp); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", imp, got, want)
+		}
+	}
+}
+
+func TestStarImportPackage(t *testing.T) {
+	tests := map[string]string{
+		"com.example.*":   "com.example",
+		"com.example":     "com.example",
+		" com.example.* ": "com.example",
+		"":                "",
+	}
+	for imp, want := range tests {
+		if got := StarImportPackage(imp); got != want {
+			t.Errorf("StarImportPackage(%q) = %q, want %q", imp, got, want)
+		}
+	}
+}
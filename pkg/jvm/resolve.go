@@ -1,6 +1,9 @@
 package jvm
 
 import (
+	"slices"
+
+	"github.com/albertocavalcante/bazelle/pkg/deppolicy"
 	"github.com/bazelbuild/bazel-gazelle/config"
 	"github.com/bazelbuild/bazel-gazelle/label"
 	"github.com/bazelbuild/bazel-gazelle/repo"
@@ -21,13 +24,38 @@ func DefaultEmbeds(r *rule.Rule, from label.Label) []label.Label {
 	return nil
 }
 
-// DefaultResolve performs default dependency resolution for a rule.
-// For MVP implementations, this is a no-op as automatic resolution
-// will be implemented in a later phase.
+// DefaultResolve performs default dependency resolution for a rule. imports
+// are the rule's imports, FQNs and star-import packages as a []string, or
+// nil.
+//
+// For MVP implementations, imports are not resolved automatically: only
+// those the resolve maps pin to a label (see deppolicy.Pinned) or that fall
+// under an aggregate target (see deppolicy.Aggregate) are added to deps,
+// and the dependency policy is applied to the resulting deps set.
 func DefaultResolve(c *config.Config, ix *resolve.RuleIndex, rc *repo.RemoteCache, r *rule.Rule, imports interface{}, from label.Label) {
-	// For MVP, we don't resolve dependencies automatically.
-	// Users add deps manually after generation.
-	// This will be implemented in a later phase.
+	deps := r.AttrStrings("deps")
+	importList, _ := imports.([]string)
+	for _, imp := range importList {
+		// Try the resolve maps, then the aggregate targets
+		l, ok := deppolicy.Pinned(c, from, imp)
+		if !ok {
+			l, ok = deppolicy.Aggregate(c, imp)
+		}
+		if !ok || l.Equal(from) {
+			continue
+		}
+		dep := l.String()
+		if l.Repo == "" && l.Pkg == from.Pkg {
+			// Same package, use relative label
+			dep = ":" + l.Name
+		}
+		if !slices.Contains(deps, dep) {
+			deps = append(deps, dep)
+		}
+	}
+	if len(deps) > 0 {
+		r.SetAttr("deps", deppolicy.Deps(deppolicy.Apply(c, from, deps)))
+	}
 }
 
 // ImportSpec creates an ImportSpec for a JVM import.
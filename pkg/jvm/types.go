@@ -23,7 +23,10 @@
 //   - Test sources: src/test/<language>/
 package jvm
 
-import "path/filepath"
+import (
+	"path/filepath"
+	"slices"
+)
 
 // Language represents a JVM programming language.
 //
@@ -52,7 +55,8 @@ const (
 	Scala Language = "scala"
 )
 
-// FileExtensions returns the file extensions for this language.
+// FileExtensions returns the file extensions for this language: the
+// built-in ones, then those registered with AddFileExtensions.
 //
 // DETERMINISTIC: The same language always returns the same extensions.
 //
@@ -65,18 +69,36 @@ const (
 //   - Java: .java
 //   - Scala: .scala (source), .sc (Ammonite scripts/worksheets)
 func (l Language) FileExtensions() []string {
+	var exts []string
 	switch l {
 	case Kotlin:
-		return []string{".kt", ".kts"}
+		exts = []string{".kt", ".kts"}
 	case Groovy:
-		return []string{".groovy", ".gvy", ".gy", ".gsh"}
+		exts = []string{".groovy", ".gvy", ".gy", ".gsh"}
 	case Java:
-		return []string{".java"}
+		exts = []string{".java"}
 	case Scala:
-		return []string{".scala", ".sc"}
+		exts = []string{".scala", ".sc"}
 	default:
 		return nil
 	}
+	return append(exts, extraExtensions[l]...)
+}
+
+// extraExtensions are the extensions registered with AddFileExtensions.
+var extraExtensions = make(map[Language][]string)
+
+// AddFileExtensions registers exts, such as ".ktm", as extra file extensions
+// of l, so its source globs and file lookups include them. Extensions must
+// have their leading dot; those l already has are skipped. The CLI registers
+// the extensions configured with langs.AddExtensions; call it at startup,
+// before any rules are generated.
+func AddFileExtensions(l Language, exts ...string) {
+	for _, ext := range exts {
+		if !slices.Contains(l.FileExtensions(), ext) {
+			extraExtensions[l] = append(extraExtensions[l], ext)
+		}
+	}
 }
 
 // MainSourceDir returns the standard source directory for main sources.
@@ -2,6 +2,7 @@ package jvm
 
 import (
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -32,6 +33,24 @@ func TestLanguageFileExtensions(t *testing.T) {
 	}
 }
 
+func TestAddFileExtensions(t *testing.T) {
+	t.Cleanup(func() { clear(extraExtensions) })
+
+	AddFileExtensions(Kotlin, ".ktm", ".kt", ".ktm")
+
+	want := []string{".kt", ".kts", ".ktm"}
+	if got := Kotlin.FileExtensions(); !slices.Equal(got, want) {
+		t.Errorf("FileExtensions() = %v, want %v", got, want)
+	}
+	wantGlobs := []string{"src/**/*.kt", "src/**/*.kts", "src/**/*.ktm"}
+	if got := Kotlin.GlobPatterns("src"); !slices.Equal(got, wantGlobs) {
+		t.Errorf("GlobPatterns() = %v, want %v", got, wantGlobs)
+	}
+	if got := Java.FileExtensions(); !slices.Equal(got, []string{".java"}) {
+		t.Errorf("Java FileExtensions() = %v, want only the built-in ones", got)
+	}
+}
+
 func TestLanguageMainSourceDir(t *testing.T) {
 	tests := []struct {
 		lang Language
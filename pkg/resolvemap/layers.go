@@ -0,0 +1,115 @@
+package resolvemap
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Layers are the resolve maps that apply to one directory of a workspace,
+// the most local first. The first map with a pattern matching an import
+// decides its label, so a directory-local map overrides the maps above it
+// for the imports it covers and leaves the others to them.
+type Layers []*Map
+
+// Lookup returns the label for imp from the first map that has a matching
+// pattern, using that map's most specific one.
+func (ls Layers) Lookup(imp string) (string, bool) {
+	for _, m := range ls {
+		if l, ok := m.Lookup(imp); ok {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// Layered loads the resolve maps of a workspace: the global map at
+// DefaultPath under the workspace root and the directory-local maps at
+// DefaultPath under any directory below it, such as
+// "services/payments/.bazelle/resolve.toml". Each map is read once, on first
+// use, and again only when reloaded.
+//
+// Layered is safe for concurrent use.
+type Layered struct {
+	root string
+
+	mu   sync.Mutex
+	maps map[string]*Map // directory -> loaded map, empty if it has none
+}
+
+// NewLayered returns a loader for the resolve maps of the workspace at
+// root.
+func NewLayered(root string) *Layered {
+	return &Layered{root: root, maps: make(map[string]*Map)}
+}
+
+// For returns the maps that apply to dir, a slash-separated directory
+// relative to the workspace root ("" for the root): those of dir and each of
+// its parents that has one, nearest first, ending with the global map.
+func (l *Layered) For(dir string) (Layers, error) {
+	dir = cleanDir(dir)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var layers Layers
+	for {
+		m, err := l.load(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(m.Entries) > 0 {
+			layers = append(layers, m)
+		}
+		if dir == "" {
+			return layers, nil
+		}
+		if i := strings.LastIndexByte(dir, '/'); i >= 0 {
+			dir = dir[:i]
+		} else {
+			dir = ""
+		}
+	}
+}
+
+// Reload reads the map of dir again, for when its file has changed, and
+// returns it. If the file cannot be loaded, the map read before is kept and
+// the error returned.
+func (l *Layered) Reload(dir string) (*Map, error) {
+	dir = cleanDir(dir)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old, cached := l.maps[dir]
+	delete(l.maps, dir)
+	m, err := l.load(dir)
+	if err != nil {
+		if cached {
+			l.maps[dir] = old
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// load returns the map of dir, reading it on first use. The caller holds mu.
+func (l *Layered) load(dir string) (*Map, error) {
+	if m, ok := l.maps[dir]; ok {
+		return m, nil
+	}
+	m, err := Load(filepath.Join(l.root, filepath.FromSlash(dir), DefaultPath))
+	if err != nil {
+		return nil, err
+	}
+	l.maps[dir] = m
+	return m, nil
+}
+
+// cleanDir normalizes a directory argument of For or Reload, mapping the
+// workspace root to "".
+func cleanDir(dir string) string {
+	dir = path.Clean(dir)
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
@@ -0,0 +1,143 @@
+package resolvemap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeWorkspaceMaps(t *testing.T, maps map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for dir, content := range maps {
+		path := filepath.Join(root, filepath.FromSlash(dir), DefaultPath)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestLayered(t *testing.T) {
+	root := writeWorkspaceMaps(t, map[string]string{
+		"": `
+[resolve]
+"com.google.common.*" = "@maven//:guava"
+"org.junit.Test" = "@maven//:junit_junit"
+`,
+		"services/payments": `
+[resolve]
+"com.google.*" = "@maven_payments//:guava"
+`,
+	})
+	l := NewLayered(root)
+
+	tests := []struct {
+		dir    string
+		imp    string
+		want   string
+		wantOK bool
+	}{
+		// The local prefix wins over the more specific global one
+		{"services/payments", "com.google.common.collect.ImmutableList", "@maven_payments//:guava", true},
+		{"services/payments/api/v1", "com.google.common.base.Strings", "@maven_payments//:guava", true},
+		// Imports the local map does not cover fall through to the global one
+		{"services/payments", "org.junit.Test", "@maven//:junit_junit", true},
+		{"services/orders", "com.google.common.base.Strings", "@maven//:guava", true},
+		{"", "com.google.common.base.Strings", "@maven//:guava", true},
+		{"services/payments", "org.example.Missing", "", false},
+	}
+	for _, tt := range tests {
+		layers, err := l.For(tt.dir)
+		if err != nil {
+			t.Fatalf("For(%q) error = %v", tt.dir, err)
+		}
+		got, ok := layers.Lookup(tt.imp)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("For(%q).Lookup(%q) = (%q, %v), want (%q, %v)", tt.dir, tt.imp, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLayered_For(t *testing.T) {
+	root := writeWorkspaceMaps(t, map[string]string{
+		"":    "[resolve]\n\"a.*\" = \"//a\"\n",
+		"x":   "[resolve]\n\"b.*\" = \"//b\"\n",
+		"x/y": "[resolve]\n",
+	})
+
+	layers, err := NewLayered(root).For("x/y/z")
+	if err != nil {
+		t.Fatalf("For() error = %v", err)
+	}
+	// The empty map of x/y is left out
+	want := []string{
+		filepath.Join(root, "x", DefaultPath),
+		filepath.Join(root, DefaultPath),
+	}
+	if len(layers) != len(want) {
+		t.Fatalf("For() returned %d maps, want %d", len(layers), len(want))
+	}
+	for i, m := range layers {
+		if m.Path != want[i] {
+			t.Errorf("layers[%d].Path = %q, want %q", i, m.Path, want[i])
+		}
+	}
+}
+
+func TestLayered_InvalidLocalMap(t *testing.T) {
+	root := writeWorkspaceMaps(t, map[string]string{"lib": "[resolve\n"})
+	if _, err := NewLayered(root).For("lib/sub"); err == nil {
+		t.Error("For() error = nil, want parse error")
+	}
+}
+
+func TestLayered_Reload(t *testing.T) {
+	root := writeWorkspaceMaps(t, map[string]string{"lib": "[resolve]\n\"a.*\" = \"//old\"\n"})
+	l := NewLayered(root)
+	lookup := func() string {
+		t.Helper()
+		layers, err := l.For("lib")
+		if err != nil {
+			t.Fatalf("For() error = %v", err)
+		}
+		got, _ := layers.Lookup("a.b")
+		return got
+	}
+	if got := lookup(); got != "//old" {
+		t.Fatalf("Lookup() = %q, want //old", got)
+	}
+
+	path := filepath.Join(root, "lib", DefaultPath)
+	if err := os.WriteFile(path, []byte("[resolve]\n\"a.*\" = \"//new\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The cached map is used until it is reloaded
+	if got := lookup(); got != "//old" {
+		t.Errorf("Lookup() before Reload = %q, want //old", got)
+	}
+	m, err := l.Reload("lib")
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(m.Entries) != 1 {
+		t.Errorf("Reload() returned %d entries, want 1", len(m.Entries))
+	}
+	if got := lookup(); got != "//new" {
+		t.Errorf("Lookup() after Reload = %q, want //new", got)
+	}
+
+	// A broken edit keeps the map that was loaded before
+	if err := os.WriteFile(path, []byte("[resolve\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Reload("lib"); err == nil {
+		t.Error("Reload() error = nil, want parse error")
+	}
+	if got := lookup(); got != "//new" {
+		t.Errorf("Lookup() after a failed Reload = %q, want //new", got)
+	}
+}
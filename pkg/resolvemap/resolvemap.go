@@ -0,0 +1,132 @@
+// Package resolvemap loads the resolve override map: a workspace file that
+// pins imports to Bazel labels when automatic resolution picks the wrong
+// target or none at all.
+//
+// The map lives at .bazelle/resolve.toml and holds a single [resolve] table
+// from import pattern to label:
+//
+//	[resolve]
+//	"com.google.common.*" = "@maven//:com_google_guava_guava"
+//	"org.junit.Test"      = "@maven//:junit_junit"
+//	"yaml"                = "@pip//pyyaml"
+//
+// A pattern is either an exact import or a package prefix ending in ".*",
+// which matches the package itself and everything below it. When several
+// patterns match, the most specific one wins: an exact pattern beats any
+// prefix, and a longer prefix beats a shorter one.
+//
+// Any directory below the workspace root may hold a map of its own at the
+// same relative path, e.g. services/payments/.bazelle/resolve.toml, which
+// applies to the sources under that directory. See Layered for how the maps
+// are combined. The language extensions resolve imports through the maps with
+// deppolicy.Pinned.
+package resolvemap
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultPath is the location of the resolve map relative to the workspace root.
+var DefaultPath = filepath.Join(".bazelle", "resolve.toml")
+
+// Entry maps one import pattern to a label.
+type Entry struct {
+	// Pattern is an exact import ("org.junit.Test") or a package prefix
+	// ending in ".*" ("com.google.common.*").
+	Pattern string
+
+	// Label is the Bazel label the matching imports resolve to.
+	Label string
+}
+
+// IsPrefix reports whether the entry is a package prefix pattern.
+func (e Entry) IsPrefix() bool {
+	return strings.HasSuffix(e.Pattern, ".*")
+}
+
+// Matches reports whether imp is covered by the entry's pattern.
+func (e Entry) Matches(imp string) bool {
+	if pkg, ok := strings.CutSuffix(e.Pattern, ".*"); ok {
+		return imp == pkg || strings.HasPrefix(imp, pkg+".")
+	}
+	return imp == e.Pattern
+}
+
+// Map is a loaded resolve override map.
+type Map struct {
+	// Path is the file the map was loaded from, if any.
+	Path string
+
+	// Entries are sorted from most to least specific, so the first entry
+	// that matches an import is the one that applies.
+	Entries []Entry
+}
+
+// file is the on-disk TOML layout.
+type file struct {
+	Resolve map[string]string `toml:"resolve"`
+}
+
+// Load reads the resolve map at path. A missing file yields an empty map.
+func Load(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Map{Path: path}, nil
+		}
+		return nil, fmt.Errorf("read resolve map: %w", err)
+	}
+
+	var f file
+	if _, err := toml.Decode(string(data), &f); err != nil {
+		return nil, fmt.Errorf("parse resolve map %s: %w", path, err)
+	}
+
+	entries := make([]Entry, 0, len(f.Resolve))
+	for pattern, l := range f.Resolve {
+		entries = append(entries, Entry{Pattern: pattern, Label: l})
+	}
+	return New(path, entries), nil
+}
+
+// New creates a map from entries, ordering them by specificity.
+func New(path string, entries []Entry) *Map {
+	entries = slices.Clone(entries)
+	slices.SortFunc(entries, compareSpecificity)
+	return &Map{Path: path, Entries: entries}
+}
+
+// Lookup returns the label for imp from the most specific matching pattern.
+func (m *Map) Lookup(imp string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	for _, e := range m.Entries {
+		if e.Matches(imp) {
+			return e.Label, true
+		}
+	}
+	return "", false
+}
+
+// compareSpecificity orders exact patterns before prefixes and longer
+// prefixes before shorter ones, breaking ties by pattern.
+func compareSpecificity(a, b Entry) int {
+	if a.IsPrefix() != b.IsPrefix() {
+		if a.IsPrefix() {
+			return 1
+		}
+		return -1
+	}
+	if c := cmp.Compare(len(b.Pattern), len(a.Pattern)); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Pattern, b.Pattern)
+}
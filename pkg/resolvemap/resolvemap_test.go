@@ -0,0 +1,68 @@
+package resolvemap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeMap(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "resolve.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeMap(t, `
+[resolve]
+"com.google.common.*" = "@maven//:guava"
+"com.google.common.collect.*" = "@maven//:guava_collect"
+"com.google.common.collect.ImmutableList" = "//third_party:immutable_list"
+`)
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(m.Entries) != 3 {
+		t.Fatalf("len(Entries) = %d, want 3", len(m.Entries))
+	}
+
+	tests := []struct {
+		imp    string
+		want   string
+		wantOK bool
+	}{
+		{"com.google.common.collect.ImmutableList", "//third_party:immutable_list", true},
+		{"com.google.common.collect.ImmutableMap", "@maven//:guava_collect", true},
+		{"com.google.common.base.Strings", "@maven//:guava", true},
+		{"com.google.common", "@maven//:guava", true},
+		{"com.google.commonx.Foo", "", false},
+		{"org.junit.Test", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := m.Lookup(tt.imp)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("Lookup(%q) = (%q, %v), want (%q, %v)", tt.imp, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	m, err := Load(filepath.Join(t.TempDir(), "resolve.toml"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(m.Entries) != 0 {
+		t.Errorf("Entries = %v, want empty", m.Entries)
+	}
+}
+
+func TestLoad_InvalidTOML(t *testing.T) {
+	if _, err := Load(writeMap(t, "[resolve\n")); err == nil {
+		t.Error("Load() error = nil, want parse error")
+	}
+}
@@ -0,0 +1,106 @@
+package resolvemap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bazelbuild/bazel-gazelle/label"
+)
+
+// Severity classifies a validation problem.
+type Severity string
+
+const (
+	// SeverityError marks an entry that cannot work as written.
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks an entry that works but is probably a mistake.
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is a single validation finding for a resolve map entry.
+type Problem struct {
+	Severity Severity `json:"severity"`
+	Pattern  string   `json:"pattern"`
+	Message  string   `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%s: %q: %s", p.Severity, p.Pattern, p.Message)
+}
+
+// Validate checks the map for mistakes and returns the problems found,
+// errors first.
+//
+// Errors are malformed patterns and labels that do not parse. Warnings are
+// patterns that match none of imports (the imports used in the workspace;
+// pass nil to skip this check) and overlapping patterns that send the same
+// imports to different labels, where only the more specific one applies.
+func Validate(m *Map, imports []string) []Problem {
+	var problems []Problem
+	for _, e := range m.Entries {
+		if err := validatePattern(e.Pattern); err != nil {
+			problems = append(problems, Problem{SeverityError, e.Pattern, err.Error()})
+			continue
+		}
+		if _, err := label.Parse(e.Label); err != nil {
+			problems = append(problems, Problem{SeverityError, e.Pattern,
+				fmt.Sprintf("invalid label %q: %v", e.Label, err)})
+		}
+		if imports != nil && !slices.ContainsFunc(imports, e.Matches) {
+			problems = append(problems, Problem{SeverityWarning, e.Pattern,
+				"matches no import in the workspace"})
+		}
+	}
+
+	// Entries are ordered most specific first, so a broader pattern that
+	// also covers e can only come after it.
+	for i, e := range m.Entries {
+		for _, broader := range m.Entries[i+1:] {
+			if broader.IsPrefix() && broader.Matches(strings.TrimSuffix(e.Pattern, ".*")) && broader.Label != e.Label {
+				problems = append(problems, Problem{SeverityWarning, e.Pattern,
+					fmt.Sprintf("overlaps %q with a different label (%s vs %s); the more specific pattern wins",
+						broader.Pattern, e.Label, broader.Label)})
+			}
+		}
+	}
+
+	slices.SortStableFunc(problems, func(a, b Problem) int {
+		if a.Severity == b.Severity {
+			return 0
+		}
+		if a.Severity == SeverityError {
+			return -1
+		}
+		return 1
+	})
+	return problems
+}
+
+// HasErrors reports whether any problem is an error.
+func HasErrors(problems []Problem) bool {
+	return slices.ContainsFunc(problems, func(p Problem) bool {
+		return p.Severity == SeverityError
+	})
+}
+
+// validatePattern checks that pattern is a dotted name, optionally ending
+// in ".*", with no other wildcards or empty segments.
+func validatePattern(pattern string) error {
+	name := strings.TrimSuffix(pattern, ".*")
+	if name == "" {
+		return fmt.Errorf("empty pattern")
+	}
+	for seg := range strings.SplitSeq(name, ".") {
+		if seg == "" {
+			return fmt.Errorf("empty segment in pattern")
+		}
+		for _, r := range seg {
+			if r != '_' && r != '$' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') {
+				return fmt.Errorf("invalid character %q in pattern (only a trailing \".*\" wildcard is allowed)", r)
+			}
+		}
+	}
+	return nil
+}
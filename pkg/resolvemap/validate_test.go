@@ -0,0 +1,73 @@
+package resolvemap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	m, err := Load(writeMap(t, `
+[resolve]
+"com.google.common.*" = "@maven//:guava"
+"com.example.unused.*" = "//lib:unused"
+"org.junit.Test" = "@maven//junit:"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imports := []string{"com.google.common.collect.ImmutableList", "org.junit.Test"}
+	problems := Validate(m, imports)
+
+	if !HasErrors(problems) {
+		t.Error("HasErrors() = false, want true")
+	}
+	assertProblem(t, problems, SeverityError, "org.junit.Test", "invalid label")
+	assertProblem(t, problems, SeverityWarning, "com.example.unused.*", "matches no import")
+	if len(problems) != 2 {
+		t.Errorf("got %d problems, want 2: %v", len(problems), problems)
+	}
+	if problems[0].Severity != SeverityError {
+		t.Errorf("problems[0] = %v, want errors first", problems[0])
+	}
+}
+
+func TestValidate_Overlap(t *testing.T) {
+	m := New("", []Entry{
+		{Pattern: "com.google.*", Label: "@maven//:google"},
+		{Pattern: "com.google.common.*", Label: "@maven//:guava"},
+		{Pattern: "com.google.common.base.*", Label: "@maven//:guava"},
+	})
+
+	problems := Validate(m, nil)
+
+	// Both nested patterns disagree with com.google.*; the two guava
+	// patterns agree with each other and are not reported as a pair.
+	assertProblem(t, problems, SeverityWarning, "com.google.common.*", `overlaps "com.google.*"`)
+	assertProblem(t, problems, SeverityWarning, "com.google.common.base.*", `overlaps "com.google.*"`)
+	if len(problems) != 2 {
+		t.Errorf("got %d problems, want 2: %v", len(problems), problems)
+	}
+	if HasErrors(problems) {
+		t.Error("HasErrors() = true, want false")
+	}
+}
+
+func TestValidate_InvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"", ".*", "com..example", "com.*.Foo", "com.example.", "com example"} {
+		problems := Validate(New("", []Entry{{Pattern: pattern, Label: "//lib"}}), nil)
+		if !HasErrors(problems) {
+			t.Errorf("Validate(%q) = %v, want an error", pattern, problems)
+		}
+	}
+}
+
+func assertProblem(t *testing.T, problems []Problem, sev Severity, pattern, msg string) {
+	t.Helper()
+	for _, p := range problems {
+		if p.Severity == sev && p.Pattern == pattern && strings.Contains(p.Message, msg) {
+			return
+		}
+	}
+	t.Errorf("no %s for %q containing %q in %v", sev, pattern, msg, problems)
+}
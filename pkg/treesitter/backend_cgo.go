@@ -105,7 +105,25 @@ func (b *cgoBackend) NewParser(lang Language) (Parser, error) {
 	}, nil
 }
 
+// cgoLanguages caches the grammars handed out by getSitterLanguage for the
+// whole process, keyed by Language.
+var cgoLanguages sync.Map
+
+// getSitterLanguage returns the grammar for lang, shared by all CGO backends.
 func (b *cgoBackend) getSitterLanguage(lang Language) (*sitter.Language, error) {
+	if cached, ok := cgoLanguages.Load(lang); ok {
+		return cached.(*sitter.Language), nil
+	}
+	sitterLang, err := b.loadSitterLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := cgoLanguages.LoadOrStore(lang, sitterLang)
+	return cached.(*sitter.Language), nil
+}
+
+// loadSitterLanguage loads the grammar for lang from its binding.
+func (b *cgoBackend) loadSitterLanguage(lang Language) (*sitter.Language, error) {
 	switch lang {
 	case Go:
 		return golang.GetLanguage(), nil
@@ -19,17 +19,37 @@ type wazeroBackend struct {
 	languages map[Language]sitter.Language
 }
 
-// NewWazeroBackend creates a new WASM/wazero-based tree-sitter backend.
-// This backend uses malivvan/tree-sitter which runs tree-sitter in WASM via wazero,
-// eliminating the need for CGO. Currently only C and C++ are supported.
-func NewWazeroBackend() (Backend, error) {
+// wazeroRuntime is the compiled tree-sitter WASM module together with the
+// grammars loaded into it. Compiling the module takes hundreds of
+// milliseconds, so one runtime is shared by every wazero backend in the
+// process. The languages map is read-only once the runtime is built.
+type wazeroRuntime struct {
+	ctx       context.Context
+	ts        sitter.TreeSitter
+	languages map[Language]sitter.Language
+}
+
+var (
+	wazeroRuntimeMu sync.Mutex
+	sharedRuntime   *wazeroRuntime
+)
+
+// loadWazeroRuntime returns the process-wide runtime, building it on first
+// use. A failed build is not cached, so the next backend retries it.
+func loadWazeroRuntime() (*wazeroRuntime, error) {
+	wazeroRuntimeMu.Lock()
+	defer wazeroRuntimeMu.Unlock()
+	if sharedRuntime != nil {
+		return sharedRuntime, nil
+	}
+
 	ctx := context.Background()
 	ts, err := sitter.New(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize tree-sitter wazero runtime: %w", err)
 	}
 
-	b := &wazeroBackend{
+	rt := &wazeroRuntime{
 		ctx:       ctx,
 		ts:        ts,
 		languages: make(map[Language]sitter.Language),
@@ -37,13 +57,34 @@ func NewWazeroBackend() (Backend, error) {
 
 	// Pre-load supported languages
 	if langC, err := ts.LanguageC(ctx); err == nil {
-		b.languages[C] = langC
+		rt.languages[C] = langC
 	}
 	if langCpp, err := ts.LanguageCpp(ctx); err == nil {
-		b.languages[Cpp] = langCpp
+		rt.languages[Cpp] = langCpp
 	}
 
-	return b, nil
+	sharedRuntime = rt
+	return rt, nil
+}
+
+// NewWazeroBackend creates a new WASM/wazero-based tree-sitter backend.
+// This backend uses malivvan/tree-sitter which runs tree-sitter in WASM via wazero,
+// eliminating the need for CGO. Currently only C and C++ are supported.
+//
+// The WASM module and its grammars are compiled once per process and shared
+// by all wazero backends, so only the first call pays for compilation. As
+// with the parsers of a single backend, parsers of different wazero backends
+// run on the same WASM instance and must not be used concurrently.
+func NewWazeroBackend() (Backend, error) {
+	rt, err := loadWazeroRuntime()
+	if err != nil {
+		return nil, err
+	}
+	return &wazeroBackend{
+		ctx:       rt.ctx,
+		ts:        rt.ts,
+		languages: rt.languages,
+	}, nil
 }
 
 func (b *wazeroBackend) Name() string {
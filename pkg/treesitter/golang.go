@@ -0,0 +1,54 @@
+package treesitter
+
+import "strings"
+
+// Go build constraint comment prefixes.
+const (
+	goBuildPrefix     = "//go:build"
+	goPlusBuildPrefix = "// +build"
+)
+
+// ExtractGoBuildTags returns the build constraint expressions of a Go file.
+//
+// It reads the "//go:build" and legacy "// +build" comment nodes that precede
+// the package clause and returns their expressions in source order, with the
+// comment prefix stripped (e.g., "linux && amd64", "linux,386 darwin,!cgo").
+// Constraint-looking comments after the package clause are ignored, matching
+// the Go toolchain.
+//
+// root must be the root node of a tree parsed with the Go grammar, and src the
+// source it was parsed from. Returns nil if the file has no constraints.
+func ExtractGoBuildTags(root Node, src []byte) []string {
+	if root == nil || root.IsNull() {
+		return nil
+	}
+
+	var tags []string
+	for _, child := range Children(root) {
+		if child.Type() == "package_clause" {
+			break
+		}
+		if child.Type() != "comment" {
+			continue
+		}
+
+		text := strings.TrimSpace(child.Content(src))
+		if expr, ok := cutConstraintPrefix(text, goBuildPrefix); ok {
+			tags = append(tags, expr)
+		} else if expr, ok := cutConstraintPrefix(text, goPlusBuildPrefix); ok {
+			tags = append(tags, expr)
+		}
+	}
+	return tags
+}
+
+// cutConstraintPrefix strips a constraint prefix from a comment, requiring
+// the prefix to be followed by whitespace and a non-empty expression.
+func cutConstraintPrefix(comment, prefix string) (string, bool) {
+	rest, found := strings.CutPrefix(comment, prefix)
+	if !found || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+		return "", false
+	}
+	expr := strings.TrimSpace(rest)
+	return expr, expr != ""
+}
@@ -11,8 +11,10 @@ type BackendType string
 
 const (
 	// BackendAuto automatically selects the best available backend.
-	// It tries CGO first (production-ready, broad language support),
-	// then falls back to wazero (CGO-free, limited language support).
+	// The choice is deterministic: CGO (production-ready, broad language
+	// support) whenever it is compiled in, otherwise wazero (CGO-free,
+	// limited language support). Use SelectedBackend to see which one
+	// BackendAuto resolves to in the current build.
 	BackendAuto BackendType = "auto"
 
 	// BackendCGO uses the CGO-based backend (smacker/go-tree-sitter).
@@ -27,8 +29,20 @@ const (
 // EnvVarBackend is the environment variable used to select the backend.
 const EnvVarBackend = "BAZELLE_TREESITTER_BACKEND"
 
+// SelectedBackend returns the concrete backend type that BackendAuto
+// resolves to: BackendCGO when the CGO backend is available in this build,
+// BackendWazero otherwise.
+func SelectedBackend() BackendType {
+	b, err := NewCGOBackend()
+	if err != nil {
+		return BackendWazero
+	}
+	_ = b.Close()
+	return BackendCGO
+}
+
 // NewBackend creates a backend of the specified type.
-// For BackendAuto, it tries CGO first, then falls back to wazero.
+// For BackendAuto, it creates the backend reported by SelectedBackend.
 func NewBackend(typ BackendType) (Backend, error) {
 	switch typ {
 	case BackendCGO:
@@ -36,12 +50,7 @@ func NewBackend(typ BackendType) (Backend, error) {
 	case BackendWazero:
 		return NewWazeroBackend()
 	case BackendAuto:
-		// Try CGO first as it's production-ready with broad language support
-		if b, err := NewCGOBackend(); err == nil {
-			return b, nil
-		}
-		// Fall back to wazero for CGO-free environments
-		return NewWazeroBackend()
+		return NewBackend(SelectedBackend())
 	default:
 		return nil, fmt.Errorf("unknown backend type: %s", typ)
 	}
@@ -52,7 +61,7 @@ func NewBackend(typ BackendType) (Backend, error) {
 // BackendAuto.
 //
 // Valid values are:
-//   - "auto" (default): Try CGO first, fall back to wazero
+//   - "auto" (default): CGO if compiled in, otherwise wazero
 //   - "cgo": Use the CGO backend
 //   - "wazero": Use the wazero/WASM backend
 func NewBackendFromEnv() (Backend, error) {
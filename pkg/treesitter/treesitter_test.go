@@ -3,7 +3,10 @@ package treesitter
 import (
 	"context"
 	"os"
+	"runtime/debug"
+	"strings"
 	"testing"
+	"time"
 )
 
 // testBackend runs tests against a specific backend.
@@ -206,6 +209,75 @@ func TestWazeroBackend(t *testing.T) {
 	}
 }
 
+// resetWazeroRuntime drops the shared wazero runtime, so the next backend
+// compiles the WASM module again.
+func resetWazeroRuntime() {
+	wazeroRuntimeMu.Lock()
+	defer wazeroRuntimeMu.Unlock()
+	sharedRuntime = nil
+}
+
+// parseString parses source with a new backend of typ and returns the
+// S-expression of the tree.
+func parseString(t *testing.T, typ BackendType, lang Language, source string) string {
+	t.Helper()
+	backend, err := NewBackend(typ)
+	if err != nil {
+		t.Fatalf("NewBackend(%s) failed: %v", typ, err)
+	}
+	defer backend.Close()
+
+	parser, err := backend.NewParser(lang)
+	if err != nil {
+		t.Fatalf("NewParser(%s) failed: %v", lang, err)
+	}
+	defer parser.Close()
+
+	tree, err := parser.Parse(context.Background(), []byte(source))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	defer tree.Close()
+	return tree.RootNode().String()
+}
+
+func TestCachedGrammarsParseIdentically(t *testing.T) {
+	source := "#include <stdio.h>\nint main(void) { printf(\"hi\\n\"); return 0; }\n"
+
+	for _, typ := range AvailableBackends() {
+		t.Run(string(typ), func(t *testing.T) {
+			resetWazeroRuntime()
+			fresh := parseString(t, typ, C, source)
+			cached := parseString(t, typ, C, source)
+			if cached != fresh {
+				t.Errorf("cached grammar parse differs:\n got: %s\nwant: %s", cached, fresh)
+			}
+		})
+	}
+}
+
+func TestWazeroBackendSharesRuntime(t *testing.T) {
+	first, err := NewWazeroBackend()
+	if err != nil {
+		t.Fatalf("NewWazeroBackend failed: %v", err)
+	}
+	// Closing a backend must leave the shared runtime usable for others
+	_ = first.Close()
+
+	second, err := NewWazeroBackend()
+	if err != nil {
+		t.Fatalf("NewWazeroBackend failed: %v", err)
+	}
+	defer second.Close()
+
+	if first.(*wazeroBackend).ts != second.(*wazeroBackend).ts {
+		t.Error("wazero backends did not share the compiled runtime")
+	}
+	if _, err := second.NewParser(C); err != nil {
+		t.Errorf("NewParser after closing another backend failed: %v", err)
+	}
+}
+
 func TestWazeroBackendUnsupportedLanguage(t *testing.T) {
 	backend, err := NewWazeroBackend()
 	if err != nil {
@@ -275,6 +347,42 @@ func TestNewBackend(t *testing.T) {
 	}
 }
 
+// cgoEnabled reports whether the test binary was built with cgo, as
+// recorded in its build info, skipping the test when it is not recorded.
+func cgoEnabled(t *testing.T) bool {
+	t.Helper()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "CGO_ENABLED" {
+				return setting.Value == "1"
+			}
+		}
+	}
+	t.Skip("build info does not record CGO_ENABLED")
+	return false
+}
+
+func TestSelectedBackend(t *testing.T) {
+	want := BackendWazero
+	if cgoEnabled(t) {
+		want = BackendCGO
+	}
+
+	if got := SelectedBackend(); got != want {
+		t.Fatalf("SelectedBackend() = %q, want %q", got, want)
+	}
+
+	backend, err := NewBackend(BackendAuto)
+	if err != nil {
+		t.Fatalf("NewBackend(auto) failed: %v", err)
+	}
+	defer backend.Close()
+
+	if backend.Name() != string(want) {
+		t.Errorf("NewBackend(auto).Name() = %q, want %q", backend.Name(), want)
+	}
+}
+
 func TestNewBackendFromEnv(t *testing.T) {
 	// Save original env var
 	origVal := os.Getenv(EnvVarBackend)
@@ -834,6 +942,46 @@ func world() {}
 	})
 }
 
+func TestTraversalDeeplyNested(t *testing.T) {
+	backend, err := NewCGOBackend()
+	if err != nil {
+		t.Skipf("CGO backend not available: %v", err)
+	}
+	defer backend.Close()
+
+	parser, err := backend.NewParser(Go)
+	if err != nil {
+		t.Fatalf("NewParser(Go) failed: %v", err)
+	}
+	defer parser.Close()
+
+	const depth = 5000
+	source := "package main\n\nvar x = " + strings.Repeat("(", depth) + "1" + strings.Repeat(")", depth) + "\n"
+	tree, err := parser.ParseString(context.Background(), source)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	defer tree.Close()
+	root := tree.RootNode()
+
+	nodes := 0
+	if !Walk(root, func(Node) bool { nodes++; return true }) {
+		t.Fatal("Walk stopped early")
+	}
+	if nodes < depth {
+		t.Errorf("Walk visited %d nodes, want at least %d", nodes, depth)
+	}
+	if got := len(FindByType(root, "parenthesized_expression")); got != depth {
+		t.Errorf("FindByType(parenthesized_expression) found %d nodes, want %d", got, depth)
+	}
+	if lit := FindFirst(root, func(n Node) bool { return n.Type() == "int_literal" }); lit == nil || lit.Content(tree.Source()) != "1" {
+		t.Errorf("FindFirst(int_literal) = %v, want the innermost literal", lit)
+	}
+	if HasErrors(root) {
+		t.Error("HasErrors() = true for a valid file")
+	}
+}
+
 func TestAvailableBackends(t *testing.T) {
 	backends := AvailableBackends()
 	if len(backends) == 0 {
@@ -1076,3 +1224,242 @@ func main() {
 		cgoBackend.Close()
 	}
 }
+
+// importBenchmarks are the sources BenchmarkImportExtraction parses, one per
+// common language, with the node types holding their imports.
+var importBenchmarks = []struct {
+	lang      Language
+	nodeTypes []string
+	source    string
+}{
+	{Go, []string{"import_spec"}, `package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"example.com/app/internal/auth"
+	"example.com/app/internal/store"
+)
+
+func Serve(ctx context.Context, s *store.Store) error {
+	return http.ListenAndServe(fmt.Sprint(":8080"), auth.Wrap(s))
+}
+`},
+	{Java, []string{"import_declaration"}, `package com.example.server;
+
+import java.util.List;
+import java.util.Map;
+import com.example.auth.Authenticator;
+import com.example.store.Store;
+import static java.util.Objects.requireNonNull;
+
+public class Server {
+    private final Store store;
+
+    public Server(Store store) {
+        this.store = requireNonNull(store);
+    }
+}
+`},
+	{Kotlin, []string{"import_header"}, `package com.example.server
+
+import kotlinx.coroutines.launch
+import com.example.auth.Authenticator
+import com.example.store.Store
+import com.example.util.*
+
+class Server(private val store: Store) {
+    fun start() = launch { Authenticator().check(store) }
+}
+`},
+	{Python, []string{"import_statement", "import_from_statement"}, `import os
+import sys
+from collections import defaultdict
+from app.auth import authenticate
+from app.store import Store
+
+
+def serve(store: Store) -> None:
+    authenticate(os.environ["TOKEN"], defaultdict(list))
+    sys.exit(0)
+`},
+	{C, []string{"preproc_include"}, `#include <stdio.h>
+#include <stdlib.h>
+#include "auth.h"
+#include "store.h"
+
+int main(void) {
+    struct store *s = store_open("db");
+    return auth_check(s) ? EXIT_SUCCESS : EXIT_FAILURE;
+}
+`},
+	{Cpp, []string{"preproc_include"}, `#include <map>
+#include <string>
+#include <vector>
+#include "auth/authenticator.h"
+#include "store/store.h"
+
+namespace app {
+int Serve(const std::vector<std::string>& args) {
+    std::map<std::string, int> counts;
+    return Authenticator().Check(Store::Open(args[0])) ? 0 : 1;
+}
+}  // namespace app
+`},
+}
+
+// BenchmarkImportExtraction measures import-extraction throughput (parse the
+// file, then collect its import nodes) for each backend and common language,
+// reported as files/s. Backends that are not compiled in and languages a
+// backend does not support are skipped, so the CGO and wazero results can be
+// compared wherever both run:
+//
+//	go test -run '^$' -bench ImportExtraction ./pkg/treesitter
+func BenchmarkImportExtraction(b *testing.B) {
+	for _, typ := range []BackendType{BackendCGO, BackendWazero} {
+		b.Run(string(typ), func(b *testing.B) {
+			backend, err := NewBackend(typ)
+			if err != nil {
+				b.Skipf("%s backend not available: %v", typ, err)
+			}
+			defer backend.Close()
+
+			for _, bench := range importBenchmarks {
+				b.Run(string(bench.lang), func(b *testing.B) {
+					if !backend.SupportsLanguage(bench.lang) {
+						b.Skipf("%s backend does not support %s", typ, bench.lang)
+					}
+					parser, err := backend.NewParser(bench.lang)
+					if err != nil {
+						b.Fatalf("NewParser(%s) failed: %v", bench.lang, err)
+					}
+					defer parser.Close()
+
+					ctx := context.Background()
+					source := []byte(bench.source)
+					b.SetBytes(int64(len(source)))
+					for b.Loop() {
+						tree, err := parser.Parse(ctx, source)
+						if err != nil {
+							b.Fatalf("Parse failed: %v", err)
+						}
+						imports := 0
+						for _, nodeType := range bench.nodeTypes {
+							imports += len(FindByType(tree.RootNode(), nodeType))
+						}
+						tree.Close()
+						if imports == 0 {
+							b.Fatalf("no %v nodes found in %s source", bench.nodeTypes, bench.lang)
+						}
+					}
+					b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "files/s")
+				})
+			}
+		})
+	}
+}
+
+// BenchmarkNewWazeroBackend measures creating a wazero backend on the shared
+// runtime. The library offers no way to close a runtime, so the one that has
+// to be compiled is built once, before the loop, and its cost is reported as
+// the first-ns/op metric for comparison.
+func BenchmarkNewWazeroBackend(b *testing.B) {
+	resetWazeroRuntime()
+	start := time.Now()
+	first, err := NewWazeroBackend()
+	if err != nil {
+		b.Fatal(err)
+	}
+	compile := time.Since(start)
+	if err := first.Close(); err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		backend, err := NewWazeroBackend()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := backend.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(compile.Nanoseconds()), "first-ns/op")
+}
+
+func TestExtractGoBuildTags(t *testing.T) {
+	backend, err := NewCGOBackend()
+	if err != nil {
+		t.Skipf("CGO backend not available: %v", err)
+	}
+	defer backend.Close()
+
+	parser, err := backend.NewParser(Go)
+	if err != nil {
+		t.Fatalf("NewParser(Go) failed: %v", err)
+	}
+	defer parser.Close()
+
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{
+			name: "go:build and +build",
+			source: `// Copyright notice.
+
+//go:build linux && (amd64 || arm64)
+// +build linux
+// +build amd64 arm64
+
+package sys
+
+//go:build ignored
+func F() {}
+`,
+			want: []string{"linux && (amd64 || arm64)", "linux", "amd64 arm64"},
+		},
+		{
+			name: "no constraints",
+			source: `// Package main does things.
+package main
+`,
+			want: nil,
+		},
+		{
+			name: "malformed prefix",
+			source: `//go:buildlinux
+//go:build
+package main
+`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := parser.ParseString(context.Background(), tt.source)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			defer tree.Close()
+
+			got := ExtractGoBuildTags(tree.RootNode(), tree.Source())
+			if len(got) != len(tt.want) {
+				t.Fatalf("ExtractGoBuildTags() = %q, want %q", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("tag[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if got := ExtractGoBuildTags(nil, nil); got != nil {
+		t.Errorf("ExtractGoBuildTags(nil) = %q, want nil", got)
+	}
+}
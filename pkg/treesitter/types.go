@@ -222,6 +222,17 @@ type Tree interface {
 	Close() error
 }
 
+// TreeCache keeps parse trees across parses of the same file, so that a
+// long-running process such as the daemon does not parse unchanged files
+// again on every update cycle.
+type TreeCache interface {
+	// GetOrParse returns the tree of content, the source of the file at
+	// path, parsing it with parser unless a tree of the same content is
+	// cached. The caller calls release once done with the tree and does not
+	// close it.
+	GetOrParse(ctx context.Context, parser Parser, path string, content []byte) (tree Tree, release func(), err error)
+}
+
 // Node represents a node in the syntax tree.
 type Node interface {
 	// Type returns the grammar type of this node (e.g., "import_statement",
@@ -415,21 +426,15 @@ func ChildrenByType(n Node, nodeType string) []Node {
 // FindFirst performs a depth-first search and returns the first node matching the predicate.
 // Returns nil if no matching node is found.
 func FindFirst(n Node, predicate func(Node) bool) Node {
-	if n == nil || n.IsNull() {
-		return nil
-	}
-	if predicate(n) {
-		return n
-	}
-	count := n.ChildCount()
-	for i := uint32(0); i < count; i++ {
-		if child := n.Child(i); child != nil {
-			if found := FindFirst(child, predicate); found != nil {
-				return found
-			}
+	var found Node
+	Walk(n, func(node Node) bool {
+		if predicate(node) {
+			found = node
+			return false // stop walking
 		}
-	}
-	return nil
+		return true
+	})
+	return found
 }
 
 // FindAll performs a depth-first search and returns all nodes matching the predicate.
@@ -447,18 +452,25 @@ func FindAll(n Node, predicate func(Node) bool) []Node {
 // Walk traverses the tree in depth-first order, calling the visitor function for each node.
 // The visitor returns true to continue walking, false to stop.
 // Walk returns true if the entire tree was traversed, false if stopped early.
+//
+// The traversal keeps its pending nodes on an explicit stack rather than
+// recursing, so trees nested thousands of levels deep (generated or minified
+// code) are walked in constant goroutine stack space.
 func Walk(n Node, visitor func(Node) bool) bool {
 	if n == nil || n.IsNull() {
 		return true
 	}
-	if !visitor(n) {
-		return false
-	}
-	count := n.ChildCount()
-	for i := uint32(0); i < count; i++ {
-		if child := n.Child(i); child != nil {
-			if !Walk(child, visitor) {
-				return false
+	stack := []Node{n}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !visitor(node) {
+			return false
+		}
+		// Push in reverse so the first child is visited next
+		for i := node.ChildCount(); i > 0; i-- {
+			if child := node.Child(i - 1); child != nil && !child.IsNull() {
+				stack = append(stack, child)
 			}
 		}
 	}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ParseError reports a source file that a parser backend failed to parse.
+//
+// Language parsers return it for every per-file failure (unreadable file,
+// oversized line, backend error), so callers can use errors.As to present
+// the failing file and backend the same way for every language.
+type ParseError struct {
+	// Path is the file that failed to parse.
+	Path string
+
+	// Backend names the parser that failed ("heuristic", "treesitter").
+	Backend string
+
+	// Line is the 1-based line the failure occurred on, or 0 if unknown.
+	Line int
+
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	loc := e.Path
+	if e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", e.Path, e.Line)
+	}
+	return fmt.Sprintf("%s: %s parser: %v", loc, e.Backend, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// NewParseError wraps err in a *ParseError for path and backend. It returns
+// nil for a nil err, and err itself if it already carries a *ParseError, so
+// the innermost parser's context is kept.
+func NewParseError(path, backend string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		return err
+	}
+	return &ParseError{Path: path, Backend: backend, Err: err}
+}
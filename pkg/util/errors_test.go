@@ -0,0 +1,55 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *ParseError
+		want string
+	}{
+		{
+			name: "without line",
+			err:  &ParseError{Path: "src/App.kt", Backend: "treesitter", Err: fs.ErrNotExist},
+			want: "src/App.kt: treesitter parser: file does not exist",
+		},
+		{
+			name: "with line",
+			err:  &ParseError{Path: "app.py", Backend: "heuristic", Line: 12, Err: errors.New("token too long")},
+			want: "app.py:12: heuristic parser: token too long",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewParseError(t *testing.T) {
+	if err := NewParseError("a.kt", "heuristic", nil); err != nil {
+		t.Errorf("NewParseError(nil) = %v, want nil", err)
+	}
+
+	err := NewParseError("a.kt", "heuristic", fs.ErrNotExist)
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Path != "a.kt" || pe.Backend != "heuristic" {
+		t.Fatalf("NewParseError() = %#v, want a ParseError for a.kt", err)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("ParseError does not unwrap to its cause")
+	}
+
+	// An error that already has file context keeps the innermost one
+	wrapped := fmt.Errorf("context: %w", err)
+	if got := NewParseError("a.kt", "hybrid", wrapped); got != wrapped {
+		t.Errorf("NewParseError() = %v, want the existing error unchanged", got)
+	}
+}
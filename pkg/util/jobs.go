@@ -0,0 +1,35 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// EnvVarJobs is the environment variable used to set parser concurrency.
+const EnvVarJobs = "BAZELLE_JOBS"
+
+// Jobs returns the number of parser workers to use.
+//
+// A positive flagValue (from --jobs) takes precedence. Otherwise the
+// BAZELLE_JOBS environment variable is used, and if that is unset or empty
+// the default is runtime.GOMAXPROCS(0). An invalid or non-positive
+// BAZELLE_JOBS value is an error.
+func Jobs(flagValue int) (int, error) {
+	if flagValue > 0 {
+		return flagValue, nil
+	}
+
+	envVal := strings.TrimSpace(os.Getenv(EnvVarJobs))
+	if envVal == "" {
+		return runtime.GOMAXPROCS(0), nil
+	}
+
+	n, err := strconv.Atoi(envVal)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s value %q: must be a positive integer", EnvVarJobs, envVal)
+	}
+	return n, nil
+}
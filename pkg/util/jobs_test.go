@@ -0,0 +1,47 @@
+package util
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestJobs(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    int
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{name: "env sets worker count", env: "3", want: 3},
+		{name: "env with whitespace", env: " 6 ", want: 6},
+		{name: "flag overrides env", flag: 2, env: "3", want: 2},
+		{name: "flag without env", flag: 5, want: 5},
+		{name: "default", want: runtime.GOMAXPROCS(0)},
+		{name: "zero flag falls back to env", flag: 0, env: "4", want: 4},
+		{name: "invalid env", env: "many", wantErr: true},
+		{name: "zero env", env: "0", wantErr: true},
+		{name: "negative env", env: "-1", wantErr: true},
+		{name: "flag ignores invalid env", flag: 2, env: "many", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvVarJobs, tt.env)
+
+			got, err := Jobs(tt.flag)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Jobs(%d) with %s=%q: expected error, got %d", tt.flag, EnvVarJobs, tt.env, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Jobs(%d) failed: %v", tt.flag, err)
+			}
+			if got != tt.want {
+				t.Errorf("Jobs(%d) with %s=%q = %d, want %d", tt.flag, EnvVarJobs, tt.env, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package util
+
+import "slices"
+
+// Import origins are the keys of the ImportsByOrigin maps that the language
+// parsers fill in when asked to classify their imports.
+const (
+	// OriginStdlib is the language's standard library.
+	OriginStdlib = "stdlib"
+
+	// OriginFirstParty is code that lives in the workspace.
+	OriginFirstParty = "first_party"
+
+	// OriginThirdParty is everything else, e.g. Maven or pip dependencies.
+	OriginThirdParty = "third_party"
+)
+
+// GroupByOrigin buckets imports using origin, which returns one of the
+// Origin* constants for each import. Each bucket is sorted and deduplicated;
+// origins with no imports are omitted.
+func GroupByOrigin(imports []string, origin func(imp string) string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, imp := range imports {
+		o := origin(imp)
+		if !slices.Contains(groups[o], imp) {
+			groups[o] = append(groups[o], imp)
+		}
+	}
+	for _, group := range groups {
+		slices.Sort(group)
+	}
+	return groups
+}
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ParseTiming is how long the parse of one source file took.
+type ParseTiming struct {
+	Path     string
+	Duration time.Duration
+}
+
+// Active parse traces, see TraceParses.
+var (
+	tracesMu sync.Mutex
+	traces   = make(map[*parseTrace]struct{})
+	tracing  atomic.Int32 // len(traces), read without the lock
+)
+
+// parseTrace collects the timings of one TraceParses call.
+type parseTrace struct {
+	mu      sync.Mutex
+	slowest map[string]time.Duration // path -> longest parse
+}
+
+// TraceParses starts recording how long each file parsed with TimeParse
+// takes and returns a function that stops and returns the timings, slowest
+// first. A file parsed more than once is listed once, with its longest
+// parse. Traces may overlap: each one sees every parse in the process while
+// it is active.
+func TraceParses() (stop func() []ParseTiming) {
+	t := &parseTrace{slowest: make(map[string]time.Duration)}
+	tracesMu.Lock()
+	traces[t] = struct{}{}
+	tracing.Store(int32(len(traces)))
+	tracesMu.Unlock()
+
+	return func() []ParseTiming {
+		tracesMu.Lock()
+		delete(traces, t)
+		tracing.Store(int32(len(traces)))
+		tracesMu.Unlock()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		timings := make([]ParseTiming, 0, len(t.slowest))
+		for path, d := range t.slowest {
+			timings = append(timings, ParseTiming{Path: path, Duration: d})
+		}
+		slices.SortFunc(timings, func(a, b ParseTiming) int {
+			return cmp.Or(cmp.Compare(b.Duration, a.Duration), cmp.Compare(a.Path, b.Path))
+		})
+		return timings
+	}
+}
+
+// TimeParse runs parse, the parse of the file at path, and returns its
+// result. While a TraceParses trace is active the time it took is recorded,
+// whether or not it failed; otherwise parse is just called.
+func TimeParse[T any](path string, parse func() (T, error)) (T, error) {
+	if tracing.Load() == 0 {
+		return parse()
+	}
+
+	start := time.Now()
+	val, err := parse()
+	d := time.Since(start)
+
+	tracesMu.Lock()
+	defer tracesMu.Unlock()
+	for t := range traces {
+		t.add(path, d)
+	}
+	return val, err
+}
+
+// add records a parse of path that took d.
+func (t *parseTrace) add(path string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if d > t.slowest[path] {
+		t.slowest[path] = d
+	}
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTraceParses(t *testing.T) {
+	parse := func(path string, d time.Duration, err error) {
+		t.Helper()
+		_, _ = TimeParse(path, func() (int, error) {
+			time.Sleep(d)
+			return 0, err
+		})
+	}
+
+	parse("untraced.kt", 0, nil)
+
+	stop := TraceParses()
+	parse("small.kt", 0, nil)
+	parse("large.kt", 30*time.Millisecond, nil)
+	parse("medium.kt", 10*time.Millisecond, nil)
+	parse("failed.kt", 20*time.Millisecond, errors.New("syntax error"))
+	parse("small.kt", time.Millisecond, nil) // parsed again, more slowly
+	timings := stop()
+	parse("late.kt", 0, nil)
+
+	var paths []string
+	for i, timing := range timings {
+		paths = append(paths, timing.Path)
+		if i > 0 && timing.Duration > timings[i-1].Duration {
+			t.Errorf("timings not sorted slowest first: %v", timings)
+		}
+	}
+	if want := []string{"large.kt", "failed.kt", "medium.kt", "small.kt"}; !slices.Equal(paths, want) {
+		t.Errorf("traced paths = %v, want %v", paths, want)
+	}
+	if d := timings[len(timings)-1].Duration; d < time.Millisecond {
+		t.Errorf("small.kt duration = %s, want its slower parse of at least 1ms", d)
+	}
+}
+
+func TestTimeParse_ReturnsResult(t *testing.T) {
+	stop := TraceParses()
+	defer stop()
+
+	wantErr := errors.New("boom")
+	got, err := TimeParse("a.py", func() (string, error) { return "ok", wantErr })
+	if got != "ok" || !errors.Is(err, wantErr) {
+		t.Errorf("TimeParse() = %q, %v; want ok, %v", got, err, wantErr)
+	}
+}
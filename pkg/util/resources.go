@@ -0,0 +1,59 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ResourceRefScanner finds resource paths passed as string literals to a
+// configured set of loader functions, e.g. getResource("config.yaml").
+//
+// This is HEURISTIC: it matches call syntax on a single line and does not
+// know what the called function really is. Only the first argument is
+// considered, and only when it is a plain literal. Computed paths
+// (variables, concatenation, string templates or interpolation) are ignored
+// because they cannot be resolved to a file statically.
+type ResourceRefScanner struct {
+	regex *regexp.Regexp
+}
+
+// NewResourceRefScanner creates a scanner for calls to the given loader
+// function names. Names are matched after any receiver, so "getResource"
+// also matches "javaClass.getResource(...)"; qualified names such as
+// "pkgutil.get_data" must appear as written.
+//
+// Returns nil if loaders is empty; a nil scanner finds nothing.
+func NewResourceRefScanner(loaders []string) *ResourceRefScanner {
+	names := make([]string, 0, len(loaders))
+	for _, l := range loaders {
+		if l = strings.TrimSpace(l); l != "" {
+			names = append(names, regexp.QuoteMeta(l))
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	// Captures: [full match, double-quoted literal, single-quoted literal]
+	pattern := `(?:^|[^\w])(?:` + strings.Join(names, "|") + `)\s*\(\s*(?:"([^"\\$]*)"|'([^'\\]*)')\s*[,)]`
+	return &ResourceRefScanner{regex: regexp.MustCompile(pattern)}
+}
+
+// Scan returns the literal resource paths referenced on line, in order.
+func (s *ResourceRefScanner) Scan(line string) []string {
+	if s == nil {
+		return nil
+	}
+
+	var refs []string
+	for _, m := range s.regex.FindAllStringSubmatch(line, -1) {
+		ref := m[1]
+		if ref == "" {
+			ref = m[2]
+		}
+		if ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
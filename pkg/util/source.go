@@ -0,0 +1,35 @@
+package util
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/albertocavalcante/bazelle/internal/log"
+)
+
+// ReadSourceFile reads a source file and returns its content as valid UTF-8.
+//
+// Invalid UTF-8 sequences are replaced with U+FFFD and a warning is logged, so
+// a single badly encoded file does not poison the parse of an entire run.
+// Import and package declarations are ASCII in every supported language, so
+// they survive the replacement intact.
+func ReadSourceFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return SanitizeSource(path, data), nil
+}
+
+// SanitizeSource converts raw source bytes to a valid UTF-8 string.
+//
+// The path is only used for the warning logged when invalid bytes are found.
+func SanitizeSource(path string, data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	log.Warn("source file contains invalid UTF-8, replacing invalid bytes",
+		"file", path)
+	return strings.ToValidUTF8(string(data), string(utf8.RuneError))
+}
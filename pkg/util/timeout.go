@@ -0,0 +1,80 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// EnvVarParseTimeout is the environment variable used to set the per-file
+// parse timeout.
+const EnvVarParseTimeout = "BAZELLE_PARSE_TIMEOUT"
+
+// ErrParseTimeout is returned (wrapped) by WithParseTimeout when a parse runs
+// past its deadline.
+var ErrParseTimeout = errors.New("parse timed out")
+
+// ParseTimeout returns how long a single file parse may run.
+//
+// A positive flagValue (from --parse-timeout) takes precedence. Otherwise the
+// BAZELLE_PARSE_TIMEOUT environment variable is used, as a Go duration
+// ("500ms", "5s"). If that is unset or empty the result is 0, meaning no
+// limit. An invalid or negative BAZELLE_PARSE_TIMEOUT value is an error.
+func ParseTimeout(flagValue time.Duration) (time.Duration, error) {
+	if flagValue > 0 {
+		return flagValue, nil
+	}
+
+	envVal := strings.TrimSpace(os.Getenv(EnvVarParseTimeout))
+	if envVal == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(envVal)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s value %q: must be a non-negative duration", EnvVarParseTimeout, envVal)
+	}
+	return d, nil
+}
+
+// WithParseTimeout runs parse under a context that expires after timeout and
+// returns its result. If the deadline passes first, it returns an error
+// wrapping ErrParseTimeout without waiting for parse to finish; parse should
+// watch ctx (tree-sitter parsers do) so the abandoned call stops promptly.
+// A non-positive timeout runs parse directly with ctx.
+func WithParseTimeout[T any](ctx context.Context, timeout time.Duration, path string, parse func(context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return parse(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		val T
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		val, err := parse(ctx)
+		done <- outcome{val, err}
+	}()
+
+	select {
+	case o := <-done:
+		if o.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			var zero T
+			return zero, fmt.Errorf("%s: %w after %s", path, ErrParseTimeout, timeout)
+		}
+		return o.val, o.err
+	case <-ctx.Done():
+		var zero T
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return zero, fmt.Errorf("%s: %w after %s", path, ErrParseTimeout, timeout)
+		}
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,107 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    time.Duration
+		env     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "default is no limit", want: 0},
+		{name: "env sets timeout", env: "2s", want: 2 * time.Second},
+		{name: "env with whitespace", env: " 500ms ", want: 500 * time.Millisecond},
+		{name: "flag overrides env", flag: time.Second, env: "2s", want: time.Second},
+		{name: "zero env disables", env: "0", want: 0},
+		{name: "invalid env", env: "soon", wantErr: true},
+		{name: "negative env", env: "-1s", wantErr: true},
+		{name: "flag ignores invalid env", flag: time.Second, env: "soon", want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvVarParseTimeout, tt.env)
+
+			got, err := ParseTimeout(tt.flag)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseTimeout(%s) with %s=%q: expected error, got %s", tt.flag, EnvVarParseTimeout, tt.env, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTimeout(%s) failed: %v", tt.flag, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTimeout(%s) with %s=%q = %s, want %s", tt.flag, EnvVarParseTimeout, tt.env, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithParseTimeout(t *testing.T) {
+	fast := func(context.Context) (string, error) { return "ok", nil }
+	failing := func(context.Context) (string, error) { return "", errors.New("boom") }
+	// blocked ignores ctx, like a regex parser stuck on a huge file.
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	blocked := func(context.Context) (string, error) {
+		<-release
+		return "late", nil
+	}
+	// cancellable returns once ctx is done, like a tree-sitter parse.
+	cancellable := func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		parse    func(context.Context) (string, error)
+		want     string
+		wantErr  bool
+		timedOut bool
+	}{
+		{name: "fast parse", timeout: time.Second, parse: fast, want: "ok"},
+		{name: "no limit", timeout: 0, parse: fast, want: "ok"},
+		{name: "parse error passes through", timeout: time.Second, parse: failing, wantErr: true},
+		{name: "blocked parse is abandoned", timeout: 10 * time.Millisecond, parse: blocked, wantErr: true, timedOut: true},
+		{name: "cancellable parse stops", timeout: 10 * time.Millisecond, parse: cancellable, wantErr: true, timedOut: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WithParseTimeout(context.Background(), tt.timeout, "a.kt", tt.parse)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WithParseTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrParseTimeout) != tt.timedOut {
+				t.Errorf("WithParseTimeout() error = %v, timed out = %v, want %v", err, !tt.timedOut, tt.timedOut)
+			}
+			if got != tt.want {
+				t.Errorf("WithParseTimeout() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithParseTimeout_ParentCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := WithParseTimeout(ctx, time.Second, "a.kt", func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) || errors.Is(err, ErrParseTimeout) {
+		t.Errorf("WithParseTimeout() error = %v, want context.Canceled", err)
+	}
+}